@@ -0,0 +1,115 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"yanm/internal/config"
+)
+
+// command is a verb of the yanm command line, e.g. `yanm config validate`.
+type command struct {
+	name    string
+	summary string
+	// run runs the command with the arguments after its name and returns the exit code.
+	run func(args []string, stdin io.Reader, stdout, stderr io.Writer) int
+}
+
+// _defaultCommand runs when no command is given, so `yanm -config config.yml` keeps
+// starting the monitor.
+const _defaultCommand = "run"
+
+// commands returns the commands in the order they are listed in the usage.
+func commands() []command {
+	return []command{
+		{name: "run", summary: "Run the monitor until interrupted (the default)", run: runMonitorCommand},
+		{name: "speedtest", summary: "Run a single speed test and print the result", run: runSpeedTestCommand},
+		{name: "ping", summary: "Run the configured latency checks once or -count times and print the results", run: runPingCommand},
+		{name: "config", summary: "Validate, generate or describe the configuration", run: runConfigCommand},
+		{name: "grafana-dashboard", summary: "Print a Grafana dashboard charting the metrics of the configured storage", run: runGrafanaDashboardCommand},
+		{name: "service", summary: "Install, uninstall, start or stop the Windows service", run: runServiceCommand},
+		{name: "version", summary: "Print the version and build information", run: runVersionCommand},
+		{name: "help", summary: "Show this help", run: func(_ []string, _ io.Reader, stdout, _ io.Writer) int {
+			printUsage(stdout)
+			return 0
+		}},
+	}
+}
+
+// runCommand runs the command named by the first of args and returns the exit code.
+func runCommand(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	name := _defaultCommand
+	if len(args) > 0 {
+		name, args = args[0], args[1:]
+	}
+	for _, cmd := range commands() {
+		if cmd.name == name {
+			return cmd.run(args, stdin, stdout, stderr)
+		}
+	}
+	fmt.Fprintf(stderr, "unknown command %q\n\n", name)
+	printUsage(stderr)
+	return 2
+}
+
+// printUsage lists the commands and the flags shared by all of them.
+func printUsage(w io.Writer) {
+	fmt.Fprintf(w, "usage: yanm [flags] [command] [command flags]\n\nCommands:\n")
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, cmd := range commands() {
+		fmt.Fprintf(tw, "  %s\t%s\n", cmd.name, cmd.summary)
+	}
+	_ = tw.Flush()
+
+	fmt.Fprintf(w, "\nFlags, accepted before or after the command:\n")
+	fs := flag.NewFlagSet("yanm", flag.ContinueOnError)
+	addConfigFlags(fs)
+	addVersionFlag(fs)
+	fs.SetOutput(w)
+	fs.PrintDefaults()
+}
+
+// addConfigFlags registers the flags locating and loading the configuration on fs. They
+// default to the values already set, so a command's flag set keeps the ones given
+// before the command.
+func addConfigFlags(fs *flag.FlagSet) {
+	fs.StringVar(&configFile, "config", configFile,
+		"Path to the configuration file, directory or http(s) URL, defaults to $"+config.PathEnv+
+			" or the first of "+strings.Join(config.SearchPaths(), ", ")+" that exists")
+	fs.BoolVar(&strictConfig, "strict-config", strictConfig, "Fail on unknown configuration keys")
+	fs.StringVar(&configProfile, "profile", configProfile, "Configuration profile to apply, defaults to $"+config.ProfileEnv)
+	fs.StringVar(&configHeader, "config-header", configHeader,
+		"Header sent when -config is an http(s) URL, e.g. 'Authorization: Bearer token', defaults to $YANM_CONFIG_HEADER")
+	fs.DurationVar(&configRefresh, "config-refresh", configRefresh, "How often to reload the configuration, 0 only reloads on SIGHUP")
+}
+
+// newCommandFlags returns the flag set of a command, which also accepts the
+// configuration flags.
+func newCommandFlags(name string, stderr io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet("yanm "+name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	addConfigFlags(fs)
+	return fs
+}
+
+// runMonitorCommand runs the monitor until it is interrupted.
+func runMonitorCommand(args []string, _ io.Reader, _, stderr io.Writer) int {
+	fs := newCommandFlags("run", stderr)
+	fs.BoolVar(&dryRun, "dry-run", false, "Run every check but print the results instead of storing them, and don't report errors, send reports or call the failover webhook")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintf(stderr, "unexpected arguments: %s\n", strings.Join(fs.Args(), " "))
+		return 2
+	}
+
+	if err := runMonitor(); err != nil {
+		fmt.Fprintf(stderr, "yanm: %v\n", err)
+		return 1
+	}
+	return 0
+}
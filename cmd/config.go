@@ -0,0 +1,154 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"yanm/internal/config"
+
+	"go.uber.org/multierr"
+	"gopkg.in/yaml.v3"
+)
+
+const _configUsage = "usage: yanm [-config path] config validate|init|schema|defaults"
+
+// runConfigCommand runs a `config` subcommand and returns the process exit code.
+func runConfigCommand(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprintln(stderr, _configUsage)
+		return 2
+	}
+
+	switch args[0] {
+	case "validate":
+		if err := newCommandFlags("config validate", stderr).Parse(args[1:]); err != nil {
+			return 2
+		}
+		return validateConfig(stdout, stderr)
+	case "init":
+		return initConfig(args[1:], stdin, stdout, stderr)
+	case "defaults":
+		out, err := yaml.Marshal(config.Defaults())
+		if err != nil {
+			fmt.Fprintf(stderr, "failed to render configuration: %v\n", err)
+			return 1
+		}
+		fmt.Fprintf(stdout, "%s", out)
+		return 0
+	case "schema":
+		out, err := config.JSONSchema()
+		if err != nil {
+			fmt.Fprintf(stderr, "failed to generate schema: %v\n", err)
+			return 1
+		}
+		fmt.Fprintf(stdout, "%s\n", out)
+		return 0
+	default:
+		fmt.Fprintf(stderr, "unknown config command %q\n%s\n", args[0], _configUsage)
+		return 2
+	}
+}
+
+// validateConfig loads the configuration and prints the effective configuration, with defaults
+// and environment overrides applied, or the validation error.
+func validateConfig(stdout, stderr io.Writer) int {
+	cfg, err := loadConfig()
+	if err != nil {
+		for _, err := range multierr.Errors(err) {
+			fmt.Fprintf(stderr, "%s: %v\n", configSource(), err)
+		}
+		return 1
+	}
+
+	out, err := yaml.Marshal(cfg.Redacted())
+	if err != nil {
+		fmt.Fprintf(stderr, "failed to render configuration: %v\n", err)
+		return 1
+	}
+	fmt.Fprintf(stdout, "# %s is valid, effective configuration:\n%s", configSource(), out)
+	return 0
+}
+
+// initConfig writes a commented default configuration, optionally prompting for the
+// most common settings.
+func initConfig(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("config init", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	output := fs.String("o", "config.yml", "Path to write the configuration to, - for stdout")
+	force := fs.Bool("force", false, "Overwrite an existing file")
+	interactive := fs.Bool("interactive", false, "Prompt for the metrics engine and intervals")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	opts := config.DefaultInitOptions()
+	if *interactive {
+		if err := promptInitOptions(bufio.NewScanner(stdin), stderr, &opts); err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+	}
+
+	if *output == "-" {
+		if err := config.WriteDefault(stdout, opts); err != nil {
+			fmt.Fprintf(stderr, "failed to render configuration: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+
+	flags := os.O_WRONLY | os.O_CREATE | os.O_EXCL
+	if *force {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	}
+	f, err := os.OpenFile(*output, flags, 0o644)
+	if err != nil {
+		fmt.Fprintf(stderr, "failed to create %s: %v\n", *output, err)
+		return 1
+	}
+	defer f.Close()
+
+	if err := config.WriteDefault(f, opts); err != nil {
+		fmt.Fprintf(stderr, "failed to write %s: %v\n", *output, err)
+		return 1
+	}
+	fmt.Fprintf(stdout, "Wrote %s\n", *output)
+	return 0
+}
+
+// promptInitOptions asks for each setting on prompts, keeping the current value on an empty answer.
+func promptInitOptions(in *bufio.Scanner, prompts io.Writer, opts *config.InitOptions) error {
+	ask := func(question, current string) string {
+		fmt.Fprintf(prompts, "%s [%s]: ", question, current)
+		if !in.Scan() {
+			return current
+		}
+		if answer := strings.TrimSpace(in.Text()); answer != "" {
+			return answer
+		}
+		return current
+	}
+
+	switch engine := ask("Metrics engine (prometheus, influxdb, no-op)", opts.Engine); engine {
+	case "prometheus", "influxdb", "no-op":
+		opts.Engine = engine
+	default:
+		return fmt.Errorf("unknown metrics engine %q", engine)
+	}
+
+	var err error
+	opts.PingIntervalSeconds, err = strconv.Atoi(ask("Ping interval in seconds", strconv.Itoa(opts.PingIntervalSeconds)))
+	if err != nil {
+		return fmt.Errorf("invalid ping interval: %w", err)
+	}
+	opts.SpeedTestIntervalMinutes, err = strconv.Atoi(ask("Speed test interval in minutes", strconv.Itoa(opts.SpeedTestIntervalMinutes)))
+	if err != nil {
+		return fmt.Errorf("invalid speed test interval: %w", err)
+	}
+	return in.Err()
+}
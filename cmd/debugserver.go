@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"yanm/internal/config"
+	"yanm/internal/debughttp"
+)
+
+// debugServer runs the debug server, replacing it when its settings are reloaded.
+type debugServer struct {
+	logger *slog.Logger
+	// build creates a server with every page for the debug_server settings.
+	build func(config.DebugServerConfig) (*debughttp.Server, error)
+
+	mu     sync.Mutex
+	cfg    config.DebugServerConfig
+	server *debughttp.Server // nil while disabled
+}
+
+// start creates and starts the server, unless cfg disables it.
+func (d *debugServer) start(ctx context.Context, cfg config.DebugServerConfig) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	server, err := d.create(cfg)
+	if err != nil {
+		return err
+	}
+	return d.serve(ctx, cfg, server)
+}
+
+// restart replaces the server with one for cfg, keeping the running one if the new one
+// cannot be created, e.g. because of a missing certificate, and starting the previous one
+// again if the new one cannot listen, e.g. because its address is in use.
+func (d *debugServer) restart(ctx context.Context, cfg config.DebugServerConfig) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	server, err := d.create(cfg)
+	if err != nil {
+		return err
+	}
+	prev, running := d.cfg, d.server != nil
+	// before serving, as the new server usually listens on the same address.
+	d.shutdown(ctx)
+	d.server = nil
+	if err := d.serve(ctx, cfg, server); err != nil {
+		if running {
+			d.restore(ctx, prev)
+		}
+		return err
+	}
+	return nil
+}
+
+// restore starts a server for the previous settings, as a server that was stopped cannot
+// serve again.
+func (d *debugServer) restore(ctx context.Context, cfg config.DebugServerConfig) {
+	server, err := d.create(cfg)
+	if err == nil {
+		err = d.serve(ctx, cfg, server)
+	}
+	if err != nil {
+		d.logger.Error("Failed to restore debug server, it is stopped until the next reload", "error", err)
+	}
+}
+
+// stop stops the server.
+func (d *debugServer) stop(ctx context.Context) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.shutdown(ctx)
+	d.server = nil
+}
+
+func (d *debugServer) create(cfg config.DebugServerConfig) (*debughttp.Server, error) {
+	if cfg.Disabled {
+		return nil, nil
+	}
+	return d.build(cfg)
+}
+
+// serve starts server, which becomes the current one once it listens.
+func (d *debugServer) serve(ctx context.Context, cfg config.DebugServerConfig, server *debughttp.Server) error {
+	if server == nil {
+		d.cfg, d.server = cfg, nil
+		d.logger.Info("Debug server is not enabled, skipping start.")
+		return nil
+	}
+	d.logger.Info("Starting debug server", "address", cfg.ListenAddress)
+	if err := server.Start(ctx); err != nil {
+		return err
+	}
+	d.cfg, d.server = cfg, server
+	return nil
+}
+
+// shutdown stops the current server, waiting up to its grace period for the requests
+// being served.
+func (d *debugServer) shutdown(ctx context.Context) {
+	if d.server == nil {
+		return
+	}
+	// ctx is already cancelled by the time the server is stopped at exit.
+	shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx),
+		time.Duration(d.cfg.ShutdownGraceSeconds)*time.Second)
+	defer cancel()
+	if err := d.server.Stop(shutdownCtx); err != nil {
+		d.logger.Error("Failed to stop debug server", "error", err)
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"testing"
+
+	"yanm/internal/config"
+	"yanm/internal/debughttp"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// freeAddress returns a local address nothing listens on.
+func freeAddress(t *testing.T) string {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := l.Addr().String()
+	require.NoError(t, l.Close())
+	return addr
+}
+
+func newTestDebugServer() *debugServer {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	return &debugServer{
+		logger: logger,
+		build: func(cfg config.DebugServerConfig) (*debughttp.Server, error) {
+			return debughttp.NewServer(debughttp.Config{ListenAddress: cfg.ListenAddress}, logger)
+		},
+	}
+}
+
+func TestDebugServer_RestartAddressInUse(t *testing.T) {
+	ctx := context.Background()
+	d := newTestDebugServer()
+	defer d.stop(ctx)
+	cfg := config.DebugServerConfig{ListenAddress: freeAddress(t), ShutdownGraceSeconds: 1}
+	require.NoError(t, d.start(ctx, cfg))
+
+	taken, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer taken.Close()
+
+	err = d.restart(ctx, config.DebugServerConfig{ListenAddress: taken.Addr().String(), ShutdownGraceSeconds: 1})
+	require.Error(t, err)
+
+	assert.Equal(t, cfg, d.cfg, "the previous settings are kept")
+	require.NotNil(t, d.server)
+	resp, err := http.Get("http://" + cfg.ListenAddress + "/")
+	require.NoError(t, err, "the previous server serves again")
+	resp.Body.Close()
+}
+
+func TestDebugServer_Restart(t *testing.T) {
+	ctx := context.Background()
+	d := newTestDebugServer()
+	defer d.stop(ctx)
+	require.NoError(t, d.start(ctx, config.DebugServerConfig{ListenAddress: freeAddress(t), ShutdownGraceSeconds: 1}))
+
+	next := config.DebugServerConfig{ListenAddress: freeAddress(t), ShutdownGraceSeconds: 1}
+	require.NoError(t, d.restart(ctx, next))
+
+	assert.Equal(t, next, d.cfg)
+	resp, err := http.Get("http://" + next.ListenAddress + "/")
+	require.NoError(t, err)
+	resp.Body.Close()
+}
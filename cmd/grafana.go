@@ -0,0 +1,63 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"os"
+
+	"yanm/internal/storage"
+)
+
+// runGrafanaDashboardCommand prints a Grafana dashboard charting the metrics of the
+// configured storage, ready to import.
+func runGrafanaDashboardCommand(args []string, _ io.Reader, stdout, stderr io.Writer) int {
+	fs := newCommandFlags("grafana-dashboard", stderr)
+	datasource := fs.String("datasource", "", "Datasource the dashboard queries, prometheus or influxdb, defaults to the configured metrics engine")
+	bucket := fs.String("bucket", "", "InfluxDB bucket the dashboard queries, defaults to the configured one")
+	title := fs.String("title", "", "Title of the dashboard")
+	out := fs.String("o", "", "Write the dashboard to this file instead of stdout")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Fprintf(stderr, "%s: %v\n", configSource(), err)
+		return 1
+	}
+	if *datasource == "" {
+		*datasource = storage.DashboardPrometheus
+		if cfg.Metrics.Engine == storage.DashboardInfluxDB {
+			*datasource = storage.DashboardInfluxDB
+		}
+	}
+
+	dashboard, err := storage.NewGrafanaDashboard(storage.DashboardOptions{
+		Datasource: *datasource,
+		Title:      *title,
+		Namespace:  cfg.Metrics.Prometheus.Namespace,
+		Labels:     cfg.Metrics.Prometheus.Labels,
+		Bucket:     cmp.Or(*bucket, cfg.Metrics.InfluxDB.Bucket),
+	})
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+
+	w := stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			fmt.Fprintf(stderr, "failed to create the dashboard file: %v\n", err)
+			return 1
+		}
+		defer f.Close()
+		w = f
+	}
+	if err := writeJSON(w, dashboard); err != nil {
+		fmt.Fprintf(stderr, "failed to write the dashboard: %v\n", err)
+		return 1
+	}
+	return 0
+}
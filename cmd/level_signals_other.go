@@ -0,0 +1,13 @@
+//go:build windows || plan9
+
+package main
+
+import (
+	"context"
+	"log/slog"
+
+	"yanm/internal/logger"
+)
+
+// watchLevelSignals does nothing, SIGUSR1 and SIGUSR2 don't exist on this platform.
+func watchLevelSignals(context.Context, *slog.Logger, *logger.Controller) {}
@@ -0,0 +1,35 @@
+//go:build !windows && !plan9
+
+package main
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"yanm/internal/logger"
+)
+
+// watchLevelSignals makes SIGUSR1 log more and SIGUSR2 log less, one level at a time,
+// until ctx is done.
+func watchLevelSignals(ctx context.Context, log *slog.Logger, levels *logger.Controller) {
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGUSR1, syscall.SIGUSR2)
+	defer signal.Stop(sigChan)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case sig := <-sigChan:
+			level := levels.LessVerbose
+			if sig == syscall.SIGUSR1 {
+				level = levels.MoreVerbose
+			}
+			// logged at warn so the change shows up at any level but error.
+			log.WarnContext(ctx, "Changed log level", "signal", sig.String(), "level", level())
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"yanm/internal/config"
+	"yanm/internal/debughttp"
+	"yanm/internal/failover"
+	"yanm/internal/network"
+	"yanm/internal/storage"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// speedTestClient is a speed test backend, whose results the debug page and the gRPC API
+// show.
+type speedTestClient interface {
+	network.SpeedTester
+	debughttp.PageProvider
+	History() ([]*network.PingResult, []*network.PerformanceResult)
+}
+
+// link is an uplink measured by a monitor of its own, see network.links.
+type link struct {
+	// name labels the link's results, empty for the default route.
+	name   string
+	client speedTestClient
+	// comparison is the client of network.speedtest.compare_backend, nil when not comparing.
+	comparison speedTestClient
+	// address looks up the link's local address again, as an interface's may change.
+	address func() (string, error)
+}
+
+// newLinks returns a link for each configured uplink, with speed test clients sending
+// their tests from the link's address. Without links, the single link is the default route.
+func newLinks(logger *slog.Logger, cfg config.NetworkConfig) ([]link, error) {
+	if len(cfg.Links) == 0 {
+		client, comparison, err := newSpeedTestClients(logger, cfg.SpeedTest, "")
+		if err != nil {
+			return nil, err
+		}
+		return []link{{client: client, comparison: comparison}}, nil
+	}
+
+	links := make([]link, 0, len(cfg.Links))
+	for _, linkCfg := range cfg.Links {
+		address := func() (string, error) { return linkCfg.SourceAddress, nil }
+		if linkCfg.Interface != "" {
+			address = func() (string, error) { return network.InterfaceAddress(linkCfg.Interface) }
+		}
+		source, err := address()
+		if err != nil {
+			return nil, fmt.Errorf("network.links[%s]: %w", linkCfg.Name, err)
+		}
+		logger.Info("Monitoring link", "link", linkCfg.Name, "interface", linkCfg.Interface, "source", source)
+		client, comparison, err := newSpeedTestClients(logger.With("link", linkCfg.Name), cfg.SpeedTest, source)
+		if err != nil {
+			return nil, fmt.Errorf("network.links[%s]: %w", linkCfg.Name, err)
+		}
+		links = append(links, link{
+			name:       linkCfg.Name,
+			client:     client,
+			comparison: comparison,
+			address:    address,
+		})
+	}
+	return links, nil
+}
+
+// newSpeedTestClients returns the client of the configured speed test backend and, when
+// comparing backends, that of the backend compared to, sending their tests from source
+// when set.
+func newSpeedTestClients(logger *slog.Logger, cfg config.SpeedTestConfig, source string) (client, comparison speedTestClient, err error) {
+	client, err = newSpeedTestClient(logger, cfg.Backend, cfg.LibreSpeed, source)
+	if err != nil || cfg.CompareBackend == "" {
+		return client, nil, err
+	}
+	comparison, err = newSpeedTestClient(logger, cfg.CompareBackend, cfg.LibreSpeed, source)
+	return client, comparison, err
+}
+
+func newSpeedTestClient(logger *slog.Logger, backend string, libreSpeed config.LibreSpeedConfig, source string) (speedTestClient, error) {
+	switch {
+	case backend == network.BackendLibreSpeed:
+		return network.NewLibreSpeedClient(logger, libreSpeed.Server, source)
+	case source != "":
+		return network.NewSourceSpeedTestClient(logger, source), nil
+	default:
+		return network.NewSpeedTestClient(logger), nil
+	}
+}
+
+// newFailoverDetector returns a detector of the traffic leaving the first of links, whose
+// metrics are named under namespace.
+func newFailoverDetector(logger *slog.Logger, links []link, cfg config.FailoverConfig, namespace string, registerer prometheus.Registerer) (*failover.Detector, error) {
+	failoverLinks := make([]failover.Link, len(links))
+	for i, l := range links {
+		failoverLinks[i] = failover.Link{Name: l.name, Address: l.address}
+	}
+	return failover.NewDetector(logger, failoverLinks, failover.Config{
+		Interval:    time.Duration(cfg.IntervalSeconds) * time.Second,
+		PublicIPURL: cfg.PublicIPURL,
+		WebhookURL:  cfg.WebhookURL,
+		Namespace:   namespace,
+	}, registerer)
+}
+
+// logger returns logger annotated with the link's name.
+func (l link) logger(logger *slog.Logger) *slog.Logger {
+	if l.name == "" {
+		return logger
+	}
+	return logger.With("link", l.name)
+}
+
+// registerer returns registerer labelling the monitor's own metrics with the link's name.
+func (l link) registerer(registerer prometheus.Registerer) prometheus.Registerer {
+	if l.name == "" {
+		return registerer
+	}
+	return prometheus.WrapRegistererWith(prometheus.Labels{storage.LabelLink: l.name}, registerer)
+}
+
+// stateFile returns the state file of the link's monitor, path with the link's name added
+// before the extension, e.g. state-lte.json. It is empty when path is.
+func (l link) stateFile(path string) string {
+	if path == "" || l.name == "" {
+		return path
+	}
+	ext := filepath.Ext(path)
+	return fmt.Sprintf("%s-%s%s", strings.TrimSuffix(path, ext), l.name, ext)
+}
@@ -3,123 +3,306 @@ package main
 import (
 	"context"
 	"flag"
-	"log"
+	"maps"
+	"net/netip"
 	"os"
 	"os/signal"
+	"slices"
+	"strings"
+	"sync"
 	"syscall"
 	"time"
 
 	"log/slog"
 
+	"yanm/internal/api"
+	"yanm/internal/central"
 	"yanm/internal/config"
+	"yanm/internal/dashboard"
 	"yanm/internal/debughttp"
 	"yanm/internal/debughttp/debughandler"
+	"yanm/internal/grpcapi"
 	"yanm/internal/logger"
 	"yanm/internal/monitor"
 	"yanm/internal/network"
+	"yanm/internal/report"
+	"yanm/internal/stats"
 	"yanm/internal/storage"
+	"yanm/internal/tracing"
+	"yanm/internal/version"
+
+	"github.com/prometheus/client_golang/prometheus"
 )
 
+// The configuration flags, shared by the commands, see addConfigFlags.
 var (
-	configFile string
+	configFile    string
+	strictConfig  = true
+	configProfile string
+	configHeader  = os.Getenv("YANM_CONFIG_HEADER")
+	configRefresh time.Duration
+)
+
+// dryRun prints the results instead of storing them and turns off error reporting, set
+// by `run -dry-run`.
+var dryRun bool
+
+const (
+	_storageHealthInterval = time.Minute
+
+	// _engineDryRun is the storage health name of the console storage used by -dry-run.
+	_engineDryRun = "dry-run"
 )
 
 func main() {
-	flag.StringVar(&configFile, "config", "config.yml", "Path to the configuration file")
+	addConfigFlags(flag.CommandLine)
+	showVersion := addVersionFlag(flag.CommandLine)
+	flag.Usage = func() { printUsage(flag.CommandLine.Output()) }
 	flag.Parse()
 
-	if err := run(); err != nil {
-		log.Fatal(err)
+	args := flag.Args()
+	if *showVersion {
+		args = []string{"version"}
 	}
+	os.Exit(runCommand(args, os.Stdin, os.Stdout, os.Stderr))
 }
 
-func run() error {
-	cfg, err := config.LoadFile(configFile)
+// run runs the monitor until ctx is done or the process is signalled to stop.
+func run(ctx context.Context) error {
+	started := time.Now()
+
+	cfg, err := loadConfig()
 	if err != nil {
 		return err
 	}
 
-	logger, err := logger.New(cfg.Logging)
+	logging := cfg.Logging
+	if dryRun {
+		logging.ErrorReporting = logger.ErrorReportingConfig{}
+	}
+	logger, logLevels, err := logger.New(logging)
 	if err != nil {
 		return err
 	}
+	// runs last, so the shutdown logs are exported too.
+	defer flushLogs()
+	defer reportPanic(logger)
 
-	logger.Info("Yet Another Network Monitor (YANM) starting up...", "configFile", configFile)
-	logger.Info("Loaded configuration", "settings", cfg)
+	build := version.Get()
+	logger.Info("Yet Another Network Monitor (YANM) starting up...", "configFile", configSource(),
+		"version", build.Version, "commit", build.Commit, "buildDate", build.Date, "goVersion", build.GoVersion)
+	logger.Info("Loaded configuration", "settings", cfg.Redacted())
+	if configFile == "" {
+		logger.Warn("No configuration file found, running with the defaults and environment overrides",
+			"searched", config.SearchPaths())
+	}
+	if dryRun {
+		logger.Warn("Dry run: printing results to stdout instead of storing them, and not reporting errors, sending reports or calling the failover webhook",
+			"engine", cfg.Metrics.Engine)
+	}
+	setTracing(logger, cfg.Tracing)
+	// stops tracing after the speed test in progress, if any, has ended.
+	defer setTracing(logger, config.TracingConfig{})
 
-	ctx, cancel := context.WithCancel(context.Background())
+	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
-	var dataStorage storage.MetricsStorage
-	switch cfg.Metrics.Engine {
-	case "prometheus":
-		dataStorage, err = storage.NewPrometheusStorage(logger)
-	case "no-op":
-		fallthrough
-	default:
-		dataStorage = storage.NewNoOpStorage(logger)
-	}
+	// constant labels distinguish this instance from others scraped by the same Prometheus.
+	registerer := prometheus.WrapRegistererWith(cfg.Metrics.Labels, prometheus.DefaultRegisterer)
+
+	// the result labels are fixed at startup, Prometheus declares them with its metrics.
+	resultLabels := slices.Sorted(maps.Keys(cfg.Labels))
+	backend, err := newStorage(logger, cfg.Metrics, resultLabels, registerer)
 	if err != nil {
 		return err
 	}
+	// the backend is replaced when the metrics engine is changed by a reload.
+	switchableStorage := storage.NewSwitchableStorage(backend)
+	var dataStorage storage.MetricsStorage = switchableStorage
+
+	trackedStorage := storage.NewHealthTrackingStorage(logger, storageEngine(cfg.Metrics), dataStorage, _storageHealthInterval)
+	dataStorage = trackedStorage
+	go trackedStorage.Run(ctx)
+
+	if cfg.Metrics.Aggregation.Enabled {
+		aggregator := storage.NewAggregatingStorage(logger, dataStorage,
+			time.Duration(cfg.Metrics.Aggregation.WindowSeconds)*time.Second)
+		dataStorage = aggregator
+		go aggregator.Run(ctx)
+	}
 	defer dataStorage.Close(ctx)
 
-	speedTestClient := network.NewSpeedTestClient(logger)
+	links, err := newLinks(logger, cfg.Network)
+	if err != nil {
+		return err
+	}
+
+	targets, err := newTargetChecks(cfg.Network)
+	if err != nil {
+		return err
+	}
 
 	// Create handler for the config debug page
 	configDebugHandler := config.NewConfigDebugPageProvider(cfg)
-	monitorSvc := monitor.NewNetwork(logger, dataStorage, speedTestClient,
-		monitor.WithNetworkInterval(time.Duration(cfg.Network.SpeedTest.IntervalMinutes)*time.Minute),
-		monitor.WithPingInterval(time.Duration(cfg.Network.PingTest.IntervalSeconds)*time.Second),
-		monitor.WithPingTriggerThreshold(time.Duration(cfg.Network.PingTest.ThresholdSeconds)*time.Second),
-	)
-
-	routes := []debughttp.DebugRoute{
-		{
-			Path:        "/debug/speedtest",
-			Name:        "Speed Test Results",
-			Description: "Displays recent speed test and ping results.",
-			Handler:     debughandler.NewHTMLProducingHandler(speedTestClient.Debug()),
-		},
-		{
-			Path:        "/debug/config",
-			Name:        "Configuration",
-			Description: "Displays the current application configuration.",
-			Handler:     debughandler.NewHTMLProducingHandler(configDebugHandler),
-		},
-		{
-			Path:        "/debug/monitor",
-			Name:        "Monitor",
-			Description: "Controls the monitor service.",
-			Handler: debughandler.NewHTMLProducingHandler(
-				monitor.NewMonitorDebugPageProvider(monitorSvc)),
-		},
+
+	// each link runs its own ping and speed tests, the first one also checks the targets
+	// and backs the debug pages, gRPC API, plan and reports.
+	monitors := make([]*monitor.Network, len(links))
+	for i, link := range links {
+		opts := []monitor.Option{
+			monitor.WithNetworkInterval(time.Duration(cfg.Network.SpeedTest.IntervalMinutes) * time.Minute),
+			monitor.WithPingInterval(time.Duration(cfg.Network.PingTest.IntervalSeconds) * time.Second),
+			monitor.WithPingTriggerThreshold(time.Duration(cfg.Network.PingTest.ThresholdSeconds) * time.Second),
+			monitor.WithPercentileWindow(time.Duration(cfg.Network.PingTest.PercentileWindowMinutes) * time.Minute),
+			monitor.WithStorageWriteTimeout(time.Duration(cfg.Metrics.WriteTimeoutSeconds) * time.Second),
+			monitor.WithSpeedTestTimeout(time.Duration(cfg.Network.SpeedTest.TimeoutSeconds) * time.Second),
+			monitor.WithRegisterer(link.registerer(registerer)),
+			monitor.WithNamespace(cfg.Metrics.Prometheus.Namespace),
+			monitor.WithRestartOnPanic(cfg.Network.RestartOnPanic),
+			monitor.WithDataBudget(newDataBudget(cfg.Network.SpeedTest.DataBudget)),
+			monitor.WithLink(link.name),
+			monitor.WithLabels(cfg.Labels),
+			monitor.WithStateFile(link.stateFile(cfg.Network.StateFile)),
+		}
+		if i == 0 {
+			opts = append(opts, monitor.WithTargets(targets...))
+		}
+		if link.comparison != nil {
+			opts = append(opts, monitor.WithComparison(cfg.Network.SpeedTest.Backend,
+				monitor.Backend{Name: cfg.Network.SpeedTest.CompareBackend, Client: link.comparison}))
+		}
+		monitors[i] = monitor.NewNetwork(link.logger(logger), dataStorage, link.client, opts...)
 	}
+	monitorSvc, speedTestClient := monitors[0], links[0].client
 
-	debugSrv, err := setupDebugServer(cfg.DebugServer.ListenAddress, logger, routes)
+	var failoverPage debughttp.PageProvider = debughttp.Routes{}
+	if len(links) > 1 {
+		failoverCfg := cfg.Network.Failover
+		if dryRun {
+			failoverCfg.WebhookURL = ""
+		}
+		detector, err := newFailoverDetector(logger, links, failoverCfg, cfg.Metrics.Prometheus.Namespace, registerer)
+		if err != nil {
+			return err
+		}
+		go detector.Run(ctx)
+		failoverPage = detector
+	}
+
+	planTracker, err := report.NewTracker(newPlan(cfg.Plan), cfg.Network.SpeedTest.Backend, cfg.Metrics.Prometheus.Namespace, registerer)
 	if err != nil {
 		return err
 	}
+	planEvents, stopPlanEvents := monitorSvc.Subscribe()
+	defer stopPlanEvents()
+	go planTracker.Run(ctx, planEvents)
+
+	heatmap := report.NewHeatmap()
+	heatmapEvents, stopHeatmapEvents := monitorSvc.Subscribe()
+	defer stopHeatmapEvents()
+	go heatmap.Run(ctx, heatmapEvents)
+
+	resolvers := report.NewResolverComparison(newResolvers(cfg.Network))
+	resolverEvents, stopResolverEvents := monitorSvc.Subscribe()
+	defer stopResolverEvents()
+	go resolvers.Run(ctx, resolverEvents)
+
+	statsCollector := stats.NewCollector(cfg.Stats.Windows(), cfg.Network.SpeedTest.Backend)
+	statsEvents, stopStatsEvents := monitorSvc.Subscribe()
+	defer stopStatsEvents()
+	go statsCollector.Run(ctx, statsEvents)
+
+	// a dry run sends no report, the preview is left out with the rest of the reporter.
+	var reporter debughttp.PageProvider = debughttp.Routes{}
+	if cfg.Reports.Enabled() && !dryRun {
+		r := report.NewReporter(logger, newReportConfig(cfg.Reports), cfg.Network.SpeedTest.Backend, planTracker)
+		reportEvents, stopReportEvents := monitorSvc.Subscribe()
+		defer stopReportEvents()
+		go r.Run(ctx, reportEvents)
+		reporter = r
+	}
 
-	if err := debugSrv.RegisterPage(debughttp.DebugRoute{
-		Path:        "/metrics",
-		Name:        "Metrics",
-		Description: "Displays metrics data.",
-		Handler:     dataStorage.MetricsHTTPHandler(),
-	}); err != nil {
+	// agents report to the debug server, the central server keeps its state across restarts of it.
+	var centralSrv debughttp.PageProvider = debughttp.Routes{}
+	if cfg.CentralServer.Enabled {
+		centralSrv = central.NewServer(logger, dataStorage, time.Duration(cfg.CentralServer.StaleSeconds)*time.Second)
+	}
+
+	// the pages are created again when the debug server is restarted by a reload.
+	debugSrv := &debugServer{
+		logger: logger,
+		build: func(debugCfg config.DebugServerConfig) (*debughttp.Server, error) {
+			return setupDebugServer(debugCfg, logger, registerer, cfg.Metrics.Prometheus.Namespace,
+				speedTestClient,
+				centralSrv,
+				probePages(targets),
+				planTracker,
+				statsCollector,
+				heatmap,
+				resolvers,
+				reporter,
+				monitorSvc,
+				failoverPage,
+				configDebugHandler,
+				trackedStorage,
+				logLevels,
+				debughttp.Routes{
+					{
+						Path:        "/dashboard",
+						Name:        "Dashboard",
+						Description: "Live status, result charts and controls on a single page.",
+						Handler:     dashboard.NewHandler(),
+						Group:       "Results",
+					},
+					{
+						Path:        "/metrics",
+						Name:        "Metrics",
+						Description: "Displays metrics data.",
+						Handler:     dataStorage.MetricsHTTPHandler(),
+						Group:       "System",
+						Order:       60,
+					},
+					{
+						Path:        "/debug/version",
+						Name:        "Version",
+						Description: "Displays the build information, uptime and configuration file.",
+						Handler: debughandler.NewHTMLProducingHandler(
+							version.NewDebugPageProvider(started, configSource())),
+						Group: "System",
+						Order: 70,
+					},
+					{
+						Path:        "/debug/runtime",
+						Name:        "Runtime",
+						Description: "Displays goroutine, heap, garbage collection and file descriptor usage.",
+						Handler: debughandler.NewHTMLProducingHandler(
+							debughttp.NewRuntimeDebugPageProvider()),
+						Group: "System",
+						Order: 80,
+					},
+					{
+						Path:        api.Prefix,
+						Name:        "API",
+						Description: "Serves the OpenAPI document describing the JSON views at /api/v1/openapi.json, and triggers checks at /api/v1/trigger, cancels the speed test in flight at /api/v1/speedtest/cancel and summarizes the recent results at /api/v1/stats.",
+						Handler:     api.NewHandler(monitorSvc, statsCollector),
+						Visibility:  debughttp.NavExclude,
+					},
+				},
+			)
+		},
+	}
+	if err := debugSrv.start(ctx, cfg.DebugServer); err != nil {
 		return err
 	}
+	defer debugSrv.stop(ctx)
 
-	if !cfg.DebugServer.Disabled { // setupDebugServer can return nil if disabled
-		logger.Info("Starting debug server", "address", cfg.DebugServer.ListenAddress)
-		debugSrv.Start(ctx)
-		defer func() {
-			if err := debugSrv.Stop(ctx); err != nil {
-				logger.Error("Failed to stop debug server", "error", err)
+	if cfg.GRPC.ListenAddress != "" {
+		grpcSrv := grpcapi.NewServer(logger, monitorSvc, speedTestClient)
+		go func() {
+			if err := grpcSrv.Serve(ctx, cfg.GRPC.ListenAddress); err != nil {
+				logger.Error("gRPC server failed", "error", err)
 			}
 		}()
-	} else {
-		logger.Info("Debug server is not enabled, skipping start.")
 	}
 
 	sigChan := make(chan os.Signal, 1)
@@ -130,28 +313,325 @@ func run() error {
 		cancel()
 	}()
 
+	settings := make([]monitorSettings, len(monitors))
+	for i, m := range monitors {
+		settings[i] = m
+	}
+	reloader := &reloader{
+		logger:     logger,
+		levels:     logLevels,
+		monitors:   settings,
+		configPage: configDebugHandler,
+		storage:    switchableStorage,
+		health:     trackedStorage,
+		debug:      debugSrv,
+		plan:       planTracker,
+		newStorage: func(cfg config.MetricsConfig) (storage.MetricsStorage, error) {
+			return newStorage(logger, cfg, resultLabels, registerer)
+		},
+		current: cfg,
+	}
+	reloadChan := make(chan os.Signal, 1)
+	signal.Notify(reloadChan, syscall.SIGHUP)
+	var refresh <-chan time.Time // nil blocks forever when refreshing is disabled.
+	if configRefresh > 0 {
+		refreshTicker := time.NewTicker(configRefresh)
+		defer refreshTicker.Stop()
+		refresh = refreshTicker.C
+	}
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-reloadChan:
+				logger.Info("Received SIGHUP, reloading configuration...")
+				reloader.reload(ctx)
+			case <-refresh:
+				logger.Debug("Refreshing configuration...")
+				reloader.reload(ctx)
+			}
+		}
+	}()
+
+	go watchLevelSignals(ctx, logger, logLevels)
+
+	var linkMonitors sync.WaitGroup
+	for _, m := range monitors[1:] {
+		linkMonitors.Add(1)
+		go func() {
+			defer linkMonitors.Done()
+			m.Monitor(ctx)
+		}()
+	}
 	// blocks until ctx is done.
 	monitorSvc.Monitor(ctx)
+	linkMonitors.Wait()
 	return nil
 }
 
+// newTargetChecks builds a monitor check for every configured target, followed by the
+// registered probes, see probes.go.
+func newTargetChecks(cfg config.NetworkConfig) ([]monitor.TargetCheck, error) {
+	checks := make([]monitor.TargetCheck, 0, len(cfg.Targets))
+	for _, target := range cfg.Targets {
+		checker, err := network.NewChecker(network.Target{
+			Name:    target.Name,
+			Type:    target.Type,
+			Address: target.Address,
+			Query:   target.Query,
+			DNSSEC:  target.DNSSEC,
+			Command: target.Command,
+			Timeout: time.Duration(target.TimeoutSeconds) * time.Second,
+		})
+		if err != nil {
+			return nil, err
+		}
+		checks = append(checks, monitor.TargetCheck{
+			Checker:   checker,
+			Interval:  time.Duration(target.IntervalSeconds) * time.Second,
+			Threshold: time.Duration(target.ThresholdSeconds * float64(time.Second)),
+		})
+	}
+
+	probes, err := newProbeChecks(cfg, checks)
+	if err != nil {
+		return nil, err
+	}
+	return append(checks, probes...), nil
+}
+
+// newDataBudget returns the monthly data budget of the speed tests.
+func newDataBudget(cfg config.DataBudgetConfig) monitor.DataBudget {
+	return monitor.DataBudget{
+		MonthlyBytes:  cfg.MonthlyMB * 1_000_000,
+		ResetDay:      cfg.ResetDay,
+		ReducePercent: cfg.ReduceAtPercent,
+	}
+}
+
+// newResolvers returns the resolvers checked by the dns targets, compared on /debug/resolvers.
+func newResolvers(cfg config.NetworkConfig) []report.Resolver {
+	var resolvers []report.Resolver
+	for _, target := range cfg.Targets {
+		if target.Type == network.TargetDNS {
+			resolvers = append(resolvers, report.Resolver{Name: target.Name, Address: target.Address})
+		}
+	}
+	return resolvers
+}
+
+// newPlan returns the plan the speed tests are compared to.
+func newPlan(cfg config.PlanConfig) report.Plan {
+	return report.Plan{
+		Provider:       cfg.Provider,
+		DownloadMbps:   cfg.DownloadMbps,
+		UploadMbps:     cfg.UploadMbps,
+		MinimumPercent: cfg.MinimumPercent,
+		Window:         time.Duration(cfg.WindowDays) * 24 * time.Hour,
+	}
+}
+
+// newReportConfig returns the configuration of the scheduled reports, validated by
+// config.Load.
+func newReportConfig(cfg config.ReportsConfig) report.Config {
+	period := report.Weekly
+	if cfg.Period == "daily" {
+		period = report.Daily
+	}
+	weekday, _ := config.ParseWeekday(cfg.Weekday)
+	at, _ := time.Parse("15:04", cfg.SendAt)
+	return report.Config{
+		Schedule: report.Schedule{
+			Period:   period,
+			Weekday:  weekday,
+			At:       time.Duration(at.Hour())*time.Hour + time.Duration(at.Minute())*time.Minute,
+			Location: time.Local,
+		},
+		Email: report.EmailConfig{
+			SMTPAddress: cfg.Email.SMTPAddress,
+			Username:    cfg.Email.Username,
+			Password:    cfg.Email.Password,
+			From:        cfg.Email.From,
+			To:          cfg.Email.To,
+		},
+		WebhookURL: cfg.WebhookURL,
+	}
+}
+
+// storageEngine returns the name of the backend newStorage creates for cfg.
+func storageEngine(cfg config.MetricsConfig) string {
+	if dryRun {
+		return _engineDryRun
+	}
+	return cfg.Engine
+}
+
+// newStorage creates the backend of the configured metrics engine, registering Prometheus
+// metrics with registerer and labelling them with resultLabels.
+func newStorage(log *slog.Logger, cfg config.MetricsConfig, resultLabels []string, registerer prometheus.Registerer) (storage.MetricsStorage, error) {
+	switch storageEngine(cfg) {
+	case _engineDryRun:
+		return storage.NewConsoleStorage(os.Stdout), nil
+	case "prometheus":
+		return storage.NewPrometheusStorage(log,
+			storage.WithLabels(cfg.Prometheus.Labels...),
+			storage.WithResultLabelNames(resultLabels...),
+			storage.WithNamespace(cfg.Prometheus.Namespace),
+			storage.WithRegisterer(registerer),
+		)
+	case "influxdb":
+		return storage.NewInfluxDBStorage(log, storage.InfluxDBConfig{
+			URL:    cfg.InfluxDB.URL,
+			Token:  cfg.InfluxDB.Token,
+			Org:    cfg.InfluxDB.Org,
+			Bucket: cfg.InfluxDB.Bucket,
+			Tags:   cfg.InfluxDB.Tags,
+
+			CAFile:             cfg.InfluxDB.TLS.CAFile,
+			InsecureSkipVerify: cfg.InfluxDB.TLS.InsecureSkipVerify,
+			Timeout:            time.Duration(cfg.InfluxDB.TimeoutSeconds) * time.Second,
+		})
+	case "central":
+		return storage.NewCentralStorage(log, storage.CentralConfig{
+			URL:   cfg.Central.URL,
+			Token: cfg.Central.Token,
+			Agent: cfg.Central.Agent,
+		})
+	case "no-op":
+		fallthrough
+	default:
+		return storage.NewNoOpStorage(log), nil
+	}
+}
+
+// loadConfig loads the configuration given by -config, or else the one config.Find
+// discovers, remembering its path in configFile for reloads.
+func loadConfig() (*config.Configuration, error) {
+	if configFile == "" {
+		path, err := config.Find()
+		if err != nil {
+			return nil, err
+		}
+		configFile = path
+	}
+	return config.LoadFile(configFile, loadOptions()...)
+}
+
+// configSource describes where the configuration was loaded from, for messages.
+func configSource() string {
+	if configFile == "" {
+		return "defaults (no config file found)"
+	}
+	return configFile
+}
+
+// loadOptions returns the config.LoadOptions set by the command line flags.
+func loadOptions() []config.LoadOption {
+	opts := []config.LoadOption{config.WithStrict(strictConfig)}
+	if configProfile != "" {
+		opts = append(opts, config.WithProfile(configProfile))
+	}
+	if name, value, ok := strings.Cut(configHeader, ":"); ok {
+		opts = append(opts, config.WithHeader(strings.TrimSpace(name), strings.TrimSpace(value)))
+	}
+	return opts
+}
+
 // setupDebugServer initializes the debug HTTP server and registers all known debug pages.
 func setupDebugServer(
-	listenAddress string,
-	logger *slog.Logger,
-	routes []debughttp.DebugRoute,
+	cfg config.DebugServerConfig,
+	log *slog.Logger,
+	registerer prometheus.Registerer,
+	namespace string,
+	providers ...debughttp.PageProvider,
 ) (*debughttp.Server, error) {
-	debugServerConfig := debughttp.Config{ListenAddress: listenAddress}
-	debugSrv, err := debughttp.NewServer(debugServerConfig, logger)
-	if err != nil {
+	var accessLogLevel slog.Level
+	if err := accessLogLevel.UnmarshalText([]byte(cfg.AccessLog.Level)); err != nil {
 		return nil, err
 	}
-
-	for _, route := range routes {
-		if err := debugSrv.RegisterPage(route); err != nil {
+	allowedCIDRs := make([]netip.Prefix, 0, len(cfg.AllowedCIDRs))
+	for _, cidr := range cfg.AllowedCIDRs {
+		prefix, err := netip.ParsePrefix(cidr)
+		if err != nil {
 			return nil, err
 		}
+		allowedCIDRs = append(allowedCIDRs, prefix)
+	}
+	debugServerConfig := debughttp.Config{
+		ListenAddress: cfg.ListenAddress,
+		AllowedCIDRs:  allowedCIDRs,
+		Auth: debughttp.AuthConfig{
+			Username: cfg.Auth.Username,
+			Password: cfg.Auth.Password,
+			Token:    cfg.Auth.Token,
+		},
+		TLS: debughttp.TLSConfig{
+			CertFile:   cfg.TLS.CertFile,
+			KeyFile:    cfg.TLS.KeyFile,
+			SelfSigned: cfg.TLS.SelfSigned,
+		},
+		AccessLog: debughttp.AccessLogConfig{
+			Enabled: cfg.AccessLog.Enabled,
+			Level:   accessLogLevel,
+		},
+		RateLimit: debughttp.RateLimitConfig{
+			RequestsPerMinute: cfg.RateLimit.RequestsPerMinute,
+			Burst:             cfg.RateLimit.Burst,
+		},
+		Pprof:           cfg.Pprof,
+		ReadTimeout:     time.Duration(cfg.ReadTimeoutSeconds) * time.Second,
+		WriteTimeout:    time.Duration(cfg.WriteTimeoutSeconds) * time.Second,
+		IdleTimeout:     time.Duration(cfg.IdleTimeoutSeconds) * time.Second,
+		RefreshInterval: time.Duration(cfg.RefreshSeconds) * time.Second,
+		Registerer:      registerer,
+		Namespace:       namespace,
+		LogPanic:        logger.LogPanic,
+		TemplateDir:     cfg.TemplateDir,
+	}
+	debugSrv, err := debughttp.NewServer(debugServerConfig, log)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := debugSrv.RegisterProviders(providers...); err != nil {
+		return nil, err
 	}
 
 	return debugSrv, nil
 }
+
+// flushLogs sends the logs still buffered for export before the process exits.
+func flushLogs() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_ = logger.Shutdown(ctx)
+}
+
+// setTracing exports traces to the collector cfg sets, replacing the current exporter
+// after exporting the spans it still holds. An empty endpoint turns tracing off.
+func setTracing(log *slog.Logger, cfg config.TracingConfig) {
+	var next *tracing.Tracer
+	if cfg.Endpoint != "" {
+		next = tracing.NewTracer(tracing.Config{Endpoint: cfg.Endpoint, Headers: cfg.Headers, ServiceName: cfg.ServiceName})
+	}
+	prev := tracing.SetTracer(next)
+	if prev == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := prev.Shutdown(ctx); err != nil {
+		log.Warn("Failed to export the remaining traces", "error", err)
+	}
+}
+
+// reportPanic logs a panic of the goroutine it is deferred in, which reports it when error
+// reporting is enabled, and then panics again. Deferred after flushLogs, the report is
+// sent before the process exits.
+func reportPanic(log *slog.Logger) {
+	if v := recover(); v != nil {
+		logger.LogPanic(context.Background(), log, v)
+		panic(v)
+	}
+}
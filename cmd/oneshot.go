@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"yanm/internal/logger"
+)
+
+// Output formats of the one-shot commands.
+const (
+	_outputText = "text"
+	_outputJSON = "json"
+)
+
+// _exitThreshold is the exit code of a one-shot command whose result breached one of its
+// -fail-* thresholds, so a pipeline can tell a slow network from a failed test (1) or a
+// usage error (2).
+const _exitThreshold = 3
+
+// newCommandLogger returns the logger of a one-shot command, which writes warnings and
+// errors, or everything when verbose, to stderr so stdout only holds the result.
+func newCommandLogger(verbose bool) (*slog.Logger, error) {
+	level := "warn"
+	if verbose {
+		level = "debug"
+	}
+	log, _, err := logger.New(logger.Config{Level: level, Format: "text", OutputFile: "stderr"})
+	return log, err
+}
+
+// commandContext returns a context cancelled on SIGINT or SIGTERM, to abort a one-shot
+// command cleanly.
+func commandContext() (context.Context, context.CancelFunc) {
+	return signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+}
+
+// validOutput reports whether output is a known output format, printing the error if not.
+func validOutput(output string, stderr io.Writer) bool {
+	if output == _outputText || output == _outputJSON {
+		return true
+	}
+	fmt.Fprintf(stderr, "unknown output format %q, must be text or json\n", output)
+	return false
+}
+
+// writeJSON writes v to w as indented JSON.
+func writeJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// reportBreaches prints the thresholds a result breached to stderr, returning the exit
+// code of the command.
+func reportBreaches(stderr io.Writer, breaches []string) int {
+	for _, breach := range breaches {
+		fmt.Fprintln(stderr, breach)
+	}
+	if len(breaches) > 0 {
+		return _exitThreshold
+	}
+	return 0
+}
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"yanm/internal/config"
+	"yanm/internal/network"
+)
+
+// pingCheck is a latency check run by `yanm ping`: the monitor's ping of the closest
+// speed test server, or a configured target.
+type pingCheck struct {
+	name  string
+	check func(ctx context.Context) (*network.PingResult, error)
+}
+
+// pingAttempt is a single check result as printed by `yanm ping -output json`.
+type pingAttempt struct {
+	Time      time.Time `json:"time"`
+	Server    string    `json:"server,omitempty"`
+	LatencyMs float64   `json:"latency_ms,omitempty"`
+	JitterMs  float64   `json:"jitter_ms,omitempty"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// pingSummary summarizes the attempts of a check, the latencies are those of the
+// successful attempts.
+type pingSummary struct {
+	Sent     int     `json:"sent"`
+	Received int     `json:"received"`
+	MinMs    float64 `json:"min_ms"`
+	AvgMs    float64 `json:"avg_ms"`
+	MaxMs    float64 `json:"max_ms"`
+	StddevMs float64 `json:"stddev_ms"`
+}
+
+// pingOutput is the result of a check as printed by `yanm ping -output json`.
+type pingOutput struct {
+	Check    string        `json:"check"`
+	Attempts []pingAttempt `json:"attempts"`
+	Summary  pingSummary   `json:"summary"`
+}
+
+// runPingCommand runs the configured latency checks count times and prints the results,
+// exiting non-zero if any attempt failed or a check breached -fail-above-latency.
+func runPingCommand(args []string, _ io.Reader, stdout, stderr io.Writer) int {
+	fs := newCommandFlags("ping", stderr)
+	output := fs.String("output", _outputText, "Output format, text or json")
+	count := fs.Int("count", 1, "How many times to run each check")
+	interval := fs.Duration("interval", time.Second, "How long to wait between rounds of checks")
+	only := fs.String("check", "", "Only run this check: ping, or the name of a target")
+	verbose := fs.Bool("v", false, "Log the progress of the checks to stderr")
+	failAboveLatency := fs.Duration("fail-above-latency", 0, "Exit with 3 if the average latency of a check is above this, e.g. 50ms")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if !validOutput(*output, stderr) {
+		return 2
+	}
+	if *count < 1 {
+		fmt.Fprintln(stderr, "-count must be at least 1")
+		return 2
+	}
+
+	cfg, err := loadConfig()
+	if err != nil {
+		fmt.Fprintf(stderr, "%s: %v\n", configSource(), err)
+		return 1
+	}
+	log, err := newCommandLogger(*verbose)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+
+	checks, err := newPingChecks(cfg, network.NewSpeedTestClient(log))
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	if *only != "" {
+		checks = slices.DeleteFunc(checks, func(c pingCheck) bool { return c.name != *only })
+		if len(checks) == 0 {
+			fmt.Fprintf(stderr, "unknown check %q\n", *only)
+			return 2
+		}
+	}
+
+	ctx, stop := commandContext()
+	defer stop()
+
+	outputs := make([]pingOutput, len(checks))
+	for round := range *count {
+		if round > 0 {
+			select {
+			case <-ctx.Done():
+			case <-time.After(*interval):
+			}
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		for i, c := range checks {
+			attempt := runPingCheck(ctx, c)
+			outputs[i].Check = c.name
+			outputs[i].Attempts = append(outputs[i].Attempts, attempt)
+			if *output == _outputText {
+				fmt.Fprintln(stdout, formatPingAttempt(c.name, attempt))
+			}
+		}
+	}
+
+	failed := false
+	for i := range outputs {
+		outputs[i].Summary = summarizePings(outputs[i].Attempts)
+		failed = failed || outputs[i].Summary.Received < outputs[i].Summary.Sent
+	}
+	if *output == _outputJSON {
+		err = writeJSON(stdout, outputs)
+	} else if *count > 1 {
+		err = writePingSummaries(stdout, outputs)
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "failed to print the results: %v\n", err)
+		return 1
+	}
+	if failed || ctx.Err() != nil {
+		return 1
+	}
+
+	var breaches []string
+	if limit := milliseconds(*failAboveLatency); limit > 0 {
+		for _, o := range outputs {
+			if o.Summary.AvgMs > limit {
+				breaches = append(breaches, fmt.Sprintf("%s: average latency %.1f ms is above -fail-above-latency %s",
+					o.Check, o.Summary.AvgMs, *failAboveLatency))
+			}
+		}
+	}
+	return reportBreaches(stderr, breaches)
+}
+
+// newPingChecks returns the monitor's ping followed by a check for every configured target.
+func newPingChecks(cfg *config.Configuration, client *network.SpeedTestClient) ([]pingCheck, error) {
+	targets, err := newTargetChecks(cfg.Network)
+	if err != nil {
+		return nil, err
+	}
+	checks := []pingCheck{{name: "ping", check: client.PerformPingTest}}
+	for _, target := range targets {
+		checks = append(checks, pingCheck{name: target.Checker.Target().Name, check: target.Checker.Check})
+	}
+	return checks, nil
+}
+
+func runPingCheck(ctx context.Context, c pingCheck) pingAttempt {
+	start := time.Now()
+	result, err := c.check(ctx)
+	if err != nil {
+		return pingAttempt{Time: start, Error: err.Error()}
+	}
+	return pingAttempt{
+		Time:      result.Timestamp,
+		Server:    result.TargetName,
+		LatencyMs: milliseconds(result.Latency),
+		JitterMs:  milliseconds(result.Jitter),
+	}
+}
+
+func formatPingAttempt(name string, a pingAttempt) string {
+	if a.Error != "" {
+		return fmt.Sprintf("%s: failed: %s", name, a.Error)
+	}
+	if a.Server != "" && a.Server != name {
+		name = fmt.Sprintf("%s (%s)", name, a.Server)
+	}
+	return fmt.Sprintf("%s: %.1f ms", name, a.LatencyMs)
+}
+
+func summarizePings(attempts []pingAttempt) pingSummary {
+	s := pingSummary{Sent: len(attempts)}
+	var sum, sumSquares float64
+	for _, a := range attempts {
+		if a.Error != "" {
+			continue
+		}
+		if s.Received == 0 || a.LatencyMs < s.MinMs {
+			s.MinMs = a.LatencyMs
+		}
+		s.MaxMs = max(s.MaxMs, a.LatencyMs)
+		sum += a.LatencyMs
+		sumSquares += a.LatencyMs * a.LatencyMs
+		s.Received++
+	}
+	if s.Received > 0 {
+		n := float64(s.Received)
+		s.AvgMs = sum / n
+		s.StddevMs = math.Sqrt(max(sumSquares/n-s.AvgMs*s.AvgMs, 0))
+	}
+	return s
+}
+
+func writePingSummaries(w io.Writer, outputs []pingOutput) error {
+	var b strings.Builder
+	for _, o := range outputs {
+		s := o.Summary
+		fmt.Fprintf(&b, "\n--- %s: %d sent, %d ok, %.0f%% failed", o.Check, s.Sent, s.Received,
+			100*float64(s.Sent-s.Received)/float64(s.Sent))
+		if s.Received > 0 {
+			fmt.Fprintf(&b, ", min/avg/max/stddev = %.1f/%.1f/%.1f/%.1f ms", s.MinMs, s.AvgMs, s.MaxMs, s.StddevMs)
+		}
+		b.WriteByte('\n')
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
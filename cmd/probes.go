@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"yanm/internal/config"
+	"yanm/internal/monitor"
+	"yanm/internal/network"
+	"yanm/probe"
+)
+
+// Probes written in Go are built into yanm by importing their package for its side
+// effects below, which registers them with probe.Register from an init function:
+//
+//	import _ "example.com/yanm-modem"
+//
+// Each one is then run by the monitor and `yanm ping`, and listed on /debug/probes.
+
+// newProbeChecks builds a monitor check for every registered probe, run on its own
+// interval or else the ping_test interval. A probe cannot share a configured target's name.
+func newProbeChecks(cfg config.NetworkConfig, targets []monitor.TargetCheck) ([]monitor.TargetCheck, error) {
+	names := make(map[string]bool, len(targets))
+	for _, target := range targets {
+		names[target.Checker.Target().Name] = true
+	}
+
+	var checks []monitor.TargetCheck
+	for _, p := range probe.Probes() {
+		if names[p.Name()] {
+			return nil, fmt.Errorf("probe %q has the name of a configured target", p.Name())
+		}
+		check := monitor.TargetCheck{
+			Checker:  network.NewProbeChecker(p),
+			Interval: time.Duration(cfg.PingTest.IntervalSeconds) * time.Second,
+		}
+		if s, ok := p.(probe.Scheduler); ok && s.Interval() > 0 {
+			check.Interval = s.Interval()
+		}
+		if t, ok := p.(probe.Thresholder); ok {
+			check.Threshold = t.Threshold()
+		}
+		checks = append(checks, check)
+	}
+	return checks, nil
+}
+
+// probePages returns the debug pages of the probes among checks.
+func probePages(checks []monitor.TargetCheck) network.ProbePages {
+	var pages network.ProbePages
+	for _, check := range checks {
+		if c, ok := check.Checker.(*network.ProbeChecker); ok {
+			pages = append(pages, c)
+		}
+	}
+	return pages
+}
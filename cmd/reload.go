@@ -0,0 +1,187 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"reflect"
+	"slices"
+	"time"
+
+	"yanm/internal/config"
+	"yanm/internal/logger"
+	"yanm/internal/monitor"
+	"yanm/internal/report"
+	"yanm/internal/storage"
+
+	"go.uber.org/multierr"
+)
+
+// reloader re-reads the configuration file and applies the settings that can
+// change without restarting the process, rebuilding the storage backend, the logger's
+// outputs and the debug server when theirs change.
+type reloader struct {
+	logger     *slog.Logger
+	levels     *logger.Controller
+	monitors   []monitorSettings
+	configPage *config.ConfigPage
+	storage    *storage.SwitchableStorage
+	health     *storage.HealthTrackingStorage
+	debug      *debugServer
+	plan       *report.Tracker
+	// newStorage creates the backend for the metrics settings.
+	newStorage func(config.MetricsConfig) (storage.MetricsStorage, error)
+
+	current *config.Configuration
+}
+
+// monitorSettings are the settings of a monitor.Network that a reload changes in place.
+type monitorSettings interface {
+	SetPingInterval(time.Duration)
+	SetNetworkInterval(time.Duration)
+	SetSpeedTestTimeout(time.Duration)
+	SetDataBudget(monitor.DataBudget)
+	SetPercentileWindow(time.Duration)
+	SetPingTriggerThreshold(time.Duration)
+	SetStorageWriteTimeout(time.Duration)
+	SetRestartOnPanic(bool)
+}
+
+var _ monitorSettings = (*monitor.Network)(nil)
+
+// reload loads configFile and applies any changes, keeping the running configuration on error.
+func (r *reloader) reload(ctx context.Context) {
+	next, err := config.LoadFile(configFile, loadOptions()...)
+	if err != nil {
+		r.logger.ErrorContext(ctx, "Failed to reload configuration, keeping current settings", "error", err)
+		return
+	}
+
+	prev := r.current
+	for _, m := range r.monitors {
+		if next.Network.PingTest.IntervalSeconds != prev.Network.PingTest.IntervalSeconds {
+			m.SetPingInterval(time.Duration(next.Network.PingTest.IntervalSeconds) * time.Second)
+		}
+		if next.Network.SpeedTest.IntervalMinutes != prev.Network.SpeedTest.IntervalMinutes {
+			m.SetNetworkInterval(time.Duration(next.Network.SpeedTest.IntervalMinutes) * time.Minute)
+		}
+		if next.Network.SpeedTest.TimeoutSeconds != prev.Network.SpeedTest.TimeoutSeconds {
+			m.SetSpeedTestTimeout(time.Duration(next.Network.SpeedTest.TimeoutSeconds) * time.Second)
+		}
+		if next.Network.SpeedTest.DataBudget != prev.Network.SpeedTest.DataBudget {
+			m.SetDataBudget(newDataBudget(next.Network.SpeedTest.DataBudget))
+		}
+		if next.Network.PingTest.PercentileWindowMinutes != prev.Network.PingTest.PercentileWindowMinutes {
+			m.SetPercentileWindow(time.Duration(next.Network.PingTest.PercentileWindowMinutes) * time.Minute)
+		}
+		if next.Network.PingTest.ThresholdSeconds != prev.Network.PingTest.ThresholdSeconds {
+			m.SetPingTriggerThreshold(time.Duration(next.Network.PingTest.ThresholdSeconds) * time.Second)
+		}
+		if next.Metrics.WriteTimeoutSeconds != prev.Metrics.WriteTimeoutSeconds {
+			m.SetStorageWriteTimeout(time.Duration(next.Metrics.WriteTimeoutSeconds) * time.Second)
+		}
+		if next.Network.RestartOnPanic != prev.Network.RestartOnPanic {
+			m.SetRestartOnPanic(next.Network.RestartOnPanic)
+		}
+	}
+	if next.Logging.Level != prev.Logging.Level {
+		if err := r.levels.Set(next.Logging.Level); err != nil {
+			r.logger.ErrorContext(ctx, "Failed to apply reloaded log level", "error", err)
+		}
+	}
+
+	if !sameLogging(next.Logging, prev.Logging) {
+		logging := next.Logging
+		if dryRun {
+			logging.ErrorReporting = logger.ErrorReportingConfig{}
+		}
+		if err := r.levels.Reconfigure(ctx, logging); err != nil {
+			r.logger.ErrorContext(ctx, "Failed to apply reloaded logging settings, keeping current outputs", "error", err)
+			next.Logging = prev.Logging
+		} else {
+			r.logger.InfoContext(ctx, "Applied reloaded logging settings")
+		}
+	}
+	if !reflect.DeepEqual(next.Tracing, prev.Tracing) {
+		setTracing(r.logger, next.Tracing)
+		r.logger.InfoContext(ctx, "Applied reloaded tracing settings", "endpoint", next.Tracing.Endpoint)
+	}
+	if next.Plan != prev.Plan {
+		r.plan.SetPlan(newPlan(next.Plan))
+		r.logger.InfoContext(ctx, "Applied reloaded plan", "downloadMbps", next.Plan.DownloadMbps, "uploadMbps", next.Plan.UploadMbps)
+	}
+	// a dry run prints the results whatever the engine.
+	if !dryRun && !sameStorage(next.Metrics, prev.Metrics) {
+		if err := r.switchStorage(ctx, next.Metrics, prev.Metrics); err != nil {
+			r.logger.ErrorContext(ctx, "Failed to switch storage backend, keeping current backend", "error", err)
+			next.Metrics.Engine, next.Metrics.Prometheus, next.Metrics.InfluxDB, next.Metrics.Central =
+				prev.Metrics.Engine, prev.Metrics.Prometheus, prev.Metrics.InfluxDB, prev.Metrics.Central
+		} else {
+			r.logger.InfoContext(ctx, "Switched storage backend", "engine", next.Metrics.Engine)
+		}
+	}
+	if !reflect.DeepEqual(next.DebugServer, prev.DebugServer) {
+		if err := r.debug.restart(ctx, next.DebugServer); err != nil {
+			r.logger.ErrorContext(ctx, "Failed to restart debug server, keeping current server", "error", err)
+			next.DebugServer = prev.DebugServer
+		}
+	}
+
+	// everything else is wired up once at startup.
+	if next.Logging.BufferSize != prev.Logging.BufferSize ||
+		!maps.Equal(next.Metrics.Labels, prev.Metrics.Labels) || !maps.Equal(next.Labels, prev.Labels) ||
+		next.Metrics.Aggregation != prev.Metrics.Aggregation ||
+		next.GRPC != prev.GRPC ||
+		next.CentralServer != prev.CentralServer ||
+		!reflect.DeepEqual(next.Network.Targets, prev.Network.Targets) ||
+		!reflect.DeepEqual(next.Network.Links, prev.Network.Links) || next.Network.Failover != prev.Network.Failover ||
+		next.Network.SpeedTest.Backend != prev.Network.SpeedTest.Backend ||
+		next.Network.SpeedTest.CompareBackend != prev.Network.SpeedTest.CompareBackend ||
+		next.Network.SpeedTest.LibreSpeed != prev.Network.SpeedTest.LibreSpeed ||
+		next.Network.StateFile != prev.Network.StateFile ||
+		!reflect.DeepEqual(next.Reports, prev.Reports) ||
+		!slices.Equal(next.Stats.WindowMinutes, prev.Stats.WindowMinutes) {
+		r.logger.WarnContext(ctx, "Logging buffer size, result labels, metrics labels and aggregation, gRPC, central server, speed test backend, target, link, failover, state file, report and stats window changes require a restart to take effect")
+	}
+
+	r.current = next
+	r.configPage.Update(next)
+	r.logger.InfoContext(ctx, "Reloaded configuration", "configFile", configSource(), "settings", next.Redacted())
+}
+
+// switchStorage replaces the storage backend with one for next, going back to one for
+// prev if it cannot be created.
+func (r *reloader) switchStorage(ctx context.Context, next, prev config.MetricsConfig) error {
+	// the previous backend is closed first, as Prometheus metrics can only be registered once.
+	r.storage.Switch(storage.NewNoOpStorage(r.logger)).Close(ctx)
+
+	backend, err := r.newStorage(next)
+	if err == nil {
+		r.storage.Switch(backend)
+		r.health.Rename(storageEngine(next))
+		return nil
+	}
+	restored, restoreErr := r.newStorage(prev)
+	if restoreErr != nil {
+		r.health.Rename("no-op")
+		return multierr.Append(err, fmt.Errorf("restore the previous backend, results are not stored: %w", restoreErr))
+	}
+	r.storage.Switch(restored)
+	return err
+}
+
+// sameLogging reports whether the logger is unchanged, the level aside, which is set
+// without rebuilding it.
+func sameLogging(a, b logger.Config) bool {
+	a.Level, b.Level = "", ""
+	return reflect.DeepEqual(a, b)
+}
+
+// sameStorage reports whether the storage backend is unchanged.
+func sameStorage(a, b config.MetricsConfig) bool {
+	return a.Engine == b.Engine &&
+		reflect.DeepEqual(a.Prometheus, b.Prometheus) &&
+		reflect.DeepEqual(a.InfluxDB, b.InfluxDB) &&
+		a.Central == b.Central
+}
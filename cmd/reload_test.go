@@ -0,0 +1,140 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"yanm/internal/config"
+	"yanm/internal/monitor"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingMonitor records the settings a reload changes.
+type recordingMonitor struct {
+	calls []string
+}
+
+func (m *recordingMonitor) record(format string, args ...any) {
+	m.calls = append(m.calls, fmt.Sprintf(format, args...))
+}
+
+func (m *recordingMonitor) SetPingInterval(d time.Duration) { m.record("ping interval %s", d) }
+
+func (m *recordingMonitor) SetNetworkInterval(d time.Duration) { m.record("network interval %s", d) }
+
+func (m *recordingMonitor) SetSpeedTestTimeout(d time.Duration) { m.record("speed test timeout %s", d) }
+
+func (m *recordingMonitor) SetDataBudget(b monitor.DataBudget) {
+	m.record("data budget %d", b.MonthlyBytes)
+}
+
+func (m *recordingMonitor) SetPercentileWindow(d time.Duration) { m.record("percentile window %s", d) }
+
+func (m *recordingMonitor) SetPingTriggerThreshold(d time.Duration) {
+	m.record("ping threshold %s", d)
+}
+
+func (m *recordingMonitor) SetStorageWriteTimeout(d time.Duration) {
+	m.record("write timeout %s", d)
+}
+
+func (m *recordingMonitor) SetRestartOnPanic(restart bool) { m.record("restart on panic %t", restart) }
+
+const _baseReloadConfig = `
+network:
+  ping_test:
+    interval_seconds: 5
+`
+
+func TestReloader_Reload(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      string
+		wantCalls   []string
+		wantRestart bool
+	}{
+		{
+			name:   "unchanged",
+			config: _baseReloadConfig,
+		},
+		{
+			name: "monitor settings",
+			config: `
+network:
+  restart_on_panic: true
+  ping_test:
+    interval_seconds: 10
+    threshold_seconds: 2
+    percentile_window_minutes: 30
+  speedtest:
+    interval_minutes: 60
+    timeout_seconds: 120
+    data_budget:
+      monthly_mb: 100
+metrics:
+  write_timeout_seconds: 7
+`,
+			wantCalls: []string{
+				"ping interval 10s",
+				"network interval 1h0m0s",
+				"speed test timeout 2m0s",
+				"data budget 100000000",
+				"percentile window 30m0s",
+				"ping threshold 2s",
+				"write timeout 7s",
+				"restart on panic true",
+			},
+		},
+		{
+			name: "state file",
+			config: `
+network:
+  ping_test:
+    interval_seconds: 5
+  state_file: /var/lib/yanm/state.json
+`,
+			wantRestart: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.yml")
+			require.NoError(t, os.WriteFile(path, []byte(_baseReloadConfig), 0o644))
+			prevConfigFile := configFile
+			configFile = path
+			t.Cleanup(func() { configFile = prevConfigFile })
+
+			current, err := config.LoadFile(path, loadOptions()...)
+			require.NoError(t, err)
+			logs := &bytes.Buffer{}
+			m := &recordingMonitor{}
+			r := &reloader{
+				logger:     slog.New(slog.NewTextHandler(logs, nil)),
+				monitors:   []monitorSettings{m},
+				configPage: config.NewConfigDebugPageProvider(current),
+				current:    current,
+			}
+
+			require.NoError(t, os.WriteFile(path, []byte(tt.config), 0o644))
+			r.reload(context.Background())
+
+			assert.Equal(t, tt.wantCalls, m.calls)
+			if tt.wantRestart {
+				assert.Contains(t, logs.String(), "require a restart to take effect")
+			} else {
+				assert.NotContains(t, logs.String(), "require a restart")
+			}
+			assert.Contains(t, logs.String(), `msg="Reloaded configuration"`)
+			assert.NotSame(t, current, r.current, "the reloaded configuration is current")
+		})
+	}
+}
@@ -0,0 +1,20 @@
+//go:build !windows
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+)
+
+// runMonitor runs the monitor, see yanm.service to run it under systemd.
+func runMonitor() error {
+	return run(context.Background())
+}
+
+// runServiceCommand fails, Windows services only exist on Windows.
+func runServiceCommand(_ []string, _ io.Reader, _, stderr io.Writer) int {
+	fmt.Fprintln(stderr, "yanm service installs a Windows service, use yanm.service with systemd instead")
+	return 1
+}
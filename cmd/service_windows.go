@@ -0,0 +1,221 @@
+//go:build windows
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"yanm/internal/config"
+	"yanm/internal/logger"
+
+	"golang.org/x/sys/windows/svc"
+	"golang.org/x/sys/windows/svc/eventlog"
+	"golang.org/x/sys/windows/svc/mgr"
+)
+
+const (
+	_serviceName        = "yanm"
+	_serviceDisplayName = "Yet Another Network Monitor"
+	_serviceDescription = "Monitors the network's latency and throughput."
+	_serviceUsage       = "usage: yanm [-config path] service install|uninstall|start|stop"
+
+	// _serviceStopTimeout is how long `service stop` waits for the monitor to exit.
+	_serviceStopTimeout = 30 * time.Second
+)
+
+// runMonitor runs the monitor, under the service control manager when started as a
+// Windows service.
+func runMonitor() error {
+	inService, err := svc.IsWindowsService()
+	if err != nil {
+		return fmt.Errorf("detect the Windows service: %w", err)
+	}
+	if !inService {
+		return run(context.Background())
+	}
+
+	handler := &monitorService{}
+	if err := svc.Run(_serviceName, handler); err != nil {
+		return err
+	}
+	return handler.err
+}
+
+// monitorService runs the monitor as a Windows service, stopping it when the service is
+// stopped or the system shuts down.
+type monitorService struct {
+	err error
+}
+
+func (m *monitorService) Execute(_ []string, requests <-chan svc.ChangeRequest, status chan<- svc.Status) (bool, uint32) {
+	status <- svc.Status{State: svc.StartPending}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- run(ctx) }()
+	status <- svc.Status{State: svc.Running, Accepts: svc.AcceptStop | svc.AcceptShutdown}
+
+	for {
+		select {
+		case m.err = <-done:
+			if m.err != nil {
+				// the logger may not be set up yet, e.g. when the configuration is invalid.
+				reportServiceError(m.err)
+				return true, 1
+			}
+			return false, 0
+		case req := <-requests:
+			switch req.Cmd {
+			case svc.Interrogate:
+				status <- req.CurrentStatus
+			case svc.Stop, svc.Shutdown:
+				status <- svc.Status{State: svc.StopPending}
+				cancel()
+			}
+		}
+	}
+}
+
+// reportServiceError writes why the service stopped to the event log, as its output
+// isn't shown anywhere.
+func reportServiceError(err error) {
+	elog, openErr := eventlog.Open(logger.EventLogSource)
+	if openErr != nil {
+		return
+	}
+	defer elog.Close()
+	_ = elog.Error(1, fmt.Sprintf("yanm stopped: %v", err))
+}
+
+// runServiceCommand installs, removes, starts or stops the Windows service.
+func runServiceCommand(args []string, _ io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprintln(stderr, _serviceUsage)
+		return 2
+	}
+	if err := newCommandFlags("service "+args[0], stderr).Parse(args[1:]); err != nil {
+		return 2
+	}
+
+	m, err := mgr.Connect()
+	if err != nil {
+		fmt.Fprintf(stderr, "connect to the service manager: %v\n", err)
+		return 1
+	}
+	defer m.Disconnect()
+
+	switch args[0] {
+	case "install":
+		err = installService(m)
+	case "uninstall":
+		err = uninstallService(m)
+	case "start":
+		err = withService(m, func(s *mgr.Service) error { return s.Start() })
+	case "stop":
+		err = withService(m, stopService)
+	default:
+		fmt.Fprintf(stderr, "unknown service command %q\n%s\n", args[0], _serviceUsage)
+		return 2
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "service %s: %v\n", args[0], err)
+		return 1
+	}
+	fmt.Fprintf(stdout, "service %s: done\n", args[0])
+	return 0
+}
+
+// installService registers yanm as a service starting with the system and restarting
+// when it fails, running this executable with the current configuration flags, and
+// registers the event log source of the eventlog output.
+func installService(m *mgr.Mgr) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	// services start in the system directory, so the configuration found from the current
+	// one is passed on, with a relative path made absolute.
+	cfgPath := configFile
+	if cfgPath == "" {
+		if cfgPath, err = config.Find(); err != nil {
+			return err
+		}
+	}
+	args := []string{fmt.Sprintf("-strict-config=%t", strictConfig)}
+	if cfgPath != "" {
+		if !strings.Contains(cfgPath, "://") {
+			if cfgPath, err = filepath.Abs(cfgPath); err != nil {
+				return err
+			}
+		}
+		args = append(args, "-config", cfgPath)
+	}
+	if configProfile != "" {
+		args = append(args, "-profile", configProfile)
+	}
+	if configRefresh > 0 {
+		args = append(args, "-config-refresh", configRefresh.String())
+	}
+	args = append(args, "run")
+
+	s, err := m.CreateService(_serviceName, exe, mgr.Config{
+		DisplayName: _serviceDisplayName,
+		Description: _serviceDescription,
+		StartType:   mgr.StartAutomatic,
+	}, args...)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+	if err := s.SetRecoveryActions([]mgr.RecoveryAction{{Type: mgr.ServiceRestart, Delay: time.Minute}}, uint32((24 * time.Hour).Seconds())); err != nil {
+		return errors.Join(err, s.Delete())
+	}
+	if err := eventlog.InstallAsEventCreate(logger.EventLogSource, eventlog.Error|eventlog.Warning|eventlog.Info); err != nil {
+		return errors.Join(fmt.Errorf("register the event log source: %w", err), s.Delete())
+	}
+	return nil
+}
+
+// uninstallService removes the service and its event log source.
+func uninstallService(m *mgr.Mgr) error {
+	err := withService(m, func(s *mgr.Service) error { return s.Delete() })
+	if err != nil {
+		return err
+	}
+	return eventlog.Remove(logger.EventLogSource)
+}
+
+// stopService asks the service to stop and waits for it to exit.
+func stopService(s *mgr.Service) error {
+	status, err := s.Control(svc.Stop)
+	if err != nil {
+		return err
+	}
+	deadline := time.Now().Add(_serviceStopTimeout)
+	for status.State != svc.Stopped {
+		if time.Now().After(deadline) {
+			return fmt.Errorf("still running after %v", _serviceStopTimeout)
+		}
+		time.Sleep(300 * time.Millisecond)
+		if status, err = s.Query(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func withService(m *mgr.Mgr, f func(*mgr.Service) error) error {
+	s, err := m.OpenService(_serviceName)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+	return f(s)
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"yanm/internal/network"
+)
+
+// speedTestOutput is a speed test result as printed by `yanm speedtest -output json`,
+// named like the debug server's JSON view.
+type speedTestOutput struct {
+	Time         time.Time `json:"time"`
+	Server       string    `json:"server"`
+	DownloadMbps float64   `json:"download_mbps"`
+	UploadMbps   float64   `json:"upload_mbps"`
+	LatencyMs    float64   `json:"latency_ms"`
+	JitterMs     float64   `json:"jitter_ms"`
+	// PacketLossPercent is omitted when it wasn't measured.
+	PacketLossPercent *float64 `json:"packet_loss_percent,omitempty"`
+	DownloadBytes     int64    `json:"download_bytes"`
+	UploadBytes       int64    `json:"upload_bytes"`
+}
+
+// runSpeedTestCommand runs a single speed test and prints the result, exiting non-zero
+// if it fails or breaches a -fail-* threshold.
+func runSpeedTestCommand(args []string, _ io.Reader, stdout, stderr io.Writer) int {
+	fs := newCommandFlags("speedtest", stderr)
+	output := fs.String("output", _outputText, "Output format, text or json")
+	timeout := fs.Duration("timeout", 2*time.Minute, "Give up on the speed test after this long")
+	verbose := fs.Bool("v", false, "Log the progress of the speed test to stderr")
+	failBelowDownload := fs.Float64("fail-below-download", 0, "Exit with 3 if the download speed is below this many Mbps")
+	failAboveLatency := fs.Duration("fail-above-latency", 0, "Exit with 3 if the latency is above this, e.g. 50ms")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if !validOutput(*output, stderr) {
+		return 2
+	}
+
+	log, err := newCommandLogger(*verbose)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+
+	ctx, stop := commandContext()
+	defer stop()
+	ctx, cancel := context.WithTimeout(ctx, *timeout)
+	defer cancel()
+
+	result, err := network.NewSpeedTestClient(log).PerformSpeedTest(ctx)
+	if err != nil {
+		fmt.Fprintf(stderr, "speed test failed: %v\n", err)
+		return 1
+	}
+
+	out := speedTestOutput{
+		Time:         result.Timestamp,
+		Server:       result.TargetName,
+		DownloadMbps: result.DownloadSpeedMbps,
+		UploadMbps:   result.UploadSpeedMbps,
+		LatencyMs:    milliseconds(result.PingLatency),
+		JitterMs:     milliseconds(result.Jitter),
+
+		DownloadBytes: result.DownloadBytes,
+		UploadBytes:   result.UploadBytes,
+	}
+	if result.PacketLossPercent >= 0 {
+		out.PacketLossPercent = &result.PacketLossPercent
+	}
+
+	if *output == _outputJSON {
+		err = writeJSON(stdout, out)
+	} else {
+		err = writeSpeedTestText(stdout, out)
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "failed to print the result: %v\n", err)
+		return 1
+	}
+
+	var breaches []string
+	if *failBelowDownload > 0 && out.DownloadMbps < *failBelowDownload {
+		breaches = append(breaches, fmt.Sprintf("download %.2f Mbps is below -fail-below-download %g Mbps",
+			out.DownloadMbps, *failBelowDownload))
+	}
+	if *failAboveLatency > 0 && result.PingLatency > *failAboveLatency {
+		breaches = append(breaches, fmt.Sprintf("latency %.1f ms is above -fail-above-latency %s",
+			out.LatencyMs, *failAboveLatency))
+	}
+	return reportBreaches(stderr, breaches)
+}
+
+func writeSpeedTestText(w io.Writer, out speedTestOutput) error {
+	loss := "not measured"
+	if out.PacketLossPercent != nil {
+		loss = fmt.Sprintf("%.1f%%", *out.PacketLossPercent)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 1, ' ', 0)
+	fmt.Fprintf(tw, "Server:\t%s\n", out.Server)
+	fmt.Fprintf(tw, "Download:\t%.2f Mbps\n", out.DownloadMbps)
+	fmt.Fprintf(tw, "Upload:\t%.2f Mbps\n", out.UploadMbps)
+	fmt.Fprintf(tw, "Latency:\t%.1f ms\n", out.LatencyMs)
+	fmt.Fprintf(tw, "Jitter:\t%.1f ms\n", out.JitterMs)
+	fmt.Fprintf(tw, "Packet loss:\t%s\n", loss)
+	fmt.Fprintf(tw, "Data used:\t%.1f MB down, %.1f MB up\n", float64(out.DownloadBytes)/1e6, float64(out.UploadBytes)/1e6)
+	return tw.Flush()
+}
+
+// milliseconds converts d to fractional milliseconds.
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+
+	"yanm/internal/version"
+)
+
+// addVersionFlag registers -version, which runs the version command instead of the one given.
+func addVersionFlag(fs *flag.FlagSet) *bool {
+	return fs.Bool("version", false, "Print the version and exit, like the version command")
+}
+
+// runVersionCommand prints the build information set with -ldflags, see the version package.
+func runVersionCommand(args []string, _ io.Reader, stdout, stderr io.Writer) int {
+	fs := newCommandFlags("version", stderr)
+	output := fs.String("output", _outputText, "Output format, text or json")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if !validOutput(*output, stderr) {
+		return 2
+	}
+
+	info := version.Get()
+	if *output == _outputJSON {
+		if err := writeJSON(stdout, info); err != nil {
+			fmt.Fprintf(stderr, "failed to print the version: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+	fmt.Fprintf(stdout, "yanm %s\n", info)
+	return 0
+}
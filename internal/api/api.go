@@ -0,0 +1,147 @@
+// Package api describes the JSON API served by the debug server, and serves the routes
+// that are not the JSON view of a debug page.
+package api
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+
+	"yanm/internal/monitor"
+	"yanm/internal/network"
+	"yanm/internal/stats"
+)
+
+// Prefix is the path the API handler is registered under.
+const Prefix = "/api/v1/"
+
+// Checks that can be triggered.
+const (
+	CheckPing      = "ping"
+	CheckSpeedTest = "speedtest"
+)
+
+//go:embed openapi.json
+var _openAPI []byte
+
+// Trigger runs the checks requested by other systems, e.g. a router after reconnecting,
+// and cancels a runaway speed test.
+type Trigger interface {
+	// RunPing runs a ping check straight away.
+	RunPing(ctx context.Context) (*network.PingResult, error)
+	// QueueSpeedTest queues a speed test, false when one is already queued.
+	QueueSpeedTest(ctx context.Context) (monitor.Job, bool)
+	// Job returns the queued speed test with id.
+	Job(id string) (monitor.Job, bool)
+	// CancelSpeedTest cancels the speed test in flight, false when none is.
+	CancelSpeedTest(ctx context.Context) bool
+}
+
+var _ Trigger = (*monitor.Network)(nil)
+
+// Summarizer summarizes the recent results over windows.
+type Summarizer interface {
+	Summary() stats.Summary
+}
+
+var _ Summarizer = (*stats.Collector)(nil)
+
+// TriggerResult is the outcome of the checks triggered by a request.
+type TriggerResult struct {
+	Ping      *network.PingResult `json:"ping,omitempty"`
+	PingError string              `json:"ping_error,omitempty"`
+	// SpeedTest is the job of the queued speed test, whose result is looked up by its ID.
+	SpeedTest      *monitor.Job `json:"speedtest,omitempty"`
+	SpeedTestError string       `json:"speedtest_error,omitempty"`
+}
+
+// CancelResult is the outcome of a request to cancel the speed test in flight.
+type CancelResult struct {
+	Canceled bool `json:"canceled"`
+}
+
+// NewHandler returns the handler for the routes under Prefix: the OpenAPI document
+// describing the JSON views of the debug pages, the trigger of trigger's checks and the
+// statistics of summarizer.
+func NewHandler(trigger Trigger, summarizer Summarizer) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET "+Prefix+"openapi.json", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(_openAPI)
+	})
+	mux.HandleFunc("POST "+Prefix+"trigger", func(w http.ResponseWriter, r *http.Request) {
+		serveTrigger(w, r, trigger)
+	})
+	mux.HandleFunc("GET "+Prefix+"trigger/{id}", func(w http.ResponseWriter, r *http.Request) {
+		job, ok := trigger.Job(r.PathValue("id"))
+		if !ok {
+			http.Error(w, "Unknown job", http.StatusNotFound)
+			return
+		}
+		writeJSON(w, http.StatusOK, job)
+	})
+	mux.HandleFunc("POST "+Prefix+"speedtest/cancel", func(w http.ResponseWriter, r *http.Request) {
+		if !trigger.CancelSpeedTest(r.Context()) {
+			writeJSON(w, http.StatusConflict, CancelResult{})
+			return
+		}
+		writeJSON(w, http.StatusOK, CancelResult{Canceled: true})
+	})
+	mux.HandleFunc("GET "+Prefix+"stats", func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, http.StatusOK, summarizer.Summary())
+	})
+	return mux
+}
+
+// serveTrigger runs the checks named by the check parameters, a ping and a speed test
+// when there are none. The ping runs first, so the speed test does not skew its latency.
+func serveTrigger(w http.ResponseWriter, r *http.Request, trigger Trigger) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	checks := r.Form["check"]
+	if len(checks) == 0 {
+		checks = []string{CheckPing, CheckSpeedTest}
+	}
+	for _, check := range checks {
+		if check != CheckPing && check != CheckSpeedTest {
+			http.Error(w, fmt.Sprintf("Invalid check %q, must be %q or %q", check, CheckPing, CheckSpeedTest), http.StatusBadRequest)
+			return
+		}
+	}
+
+	var result TriggerResult
+	status := http.StatusOK
+	if slices.Contains(checks, CheckPing) {
+		ping, err := trigger.RunPing(r.Context())
+		if err != nil {
+			result.PingError = err.Error()
+			status = http.StatusBadGateway
+		}
+		result.Ping = ping
+	}
+	if slices.Contains(checks, CheckSpeedTest) {
+		speedTestStatus := http.StatusAccepted
+		if job, ok := trigger.QueueSpeedTest(r.Context()); ok {
+			result.SpeedTest = &job
+		} else {
+			result.SpeedTestError = "a speed test is already queued"
+			speedTestStatus = http.StatusConflict
+		}
+		// a failed ping is not hidden by the speed test being queued.
+		if status == http.StatusOK {
+			status = speedTestStatus
+		}
+	}
+	writeJSON(w, status, result)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
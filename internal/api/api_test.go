@@ -0,0 +1,211 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"yanm/internal/monitor"
+	"yanm/internal/network"
+	"yanm/internal/stats"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewHandler_OpenAPI(t *testing.T) {
+	rr := httptest.NewRecorder()
+	NewHandler(&fakeTrigger{}, nil).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/v1/openapi.json", nil))
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+
+	var doc struct {
+		OpenAPI string                    `json:"openapi"`
+		Paths   map[string]map[string]any `json:"paths"`
+	}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &doc))
+	assert.Equal(t, "3.0.3", doc.OpenAPI)
+	for _, path := range []string{"/debug/speedtest/", "/debug/monitor/", "/debug/storage/", "/debug/central/", "/debug/probes/", "/debug/plan/", "/debug/failover/", "/debug/heatmap/", "/debug/resolvers/", "/debug/report/", "/debug/config/", "/debug/events/", "/debug/version/", "/debug/runtime/", "/debug/logging/", "/debug/logs/", "/api/v1/trigger", "/api/v1/trigger/{id}", "/api/v1/speedtest/cancel", "/api/v1/stats", "/debug/stats/"} {
+		assert.Contains(t, doc.Paths, path)
+	}
+	assert.Contains(t, doc.Paths["/debug/monitor/"], "post")
+	assert.Contains(t, doc.Paths["/debug/logging/"], "post")
+	assert.Contains(t, doc.Paths["/debug/central/"], "post")
+	assert.Contains(t, doc.Paths["/api/v1/trigger"], "post")
+}
+
+func TestNewHandler_NotFound(t *testing.T) {
+	rr := httptest.NewRecorder()
+	NewHandler(&fakeTrigger{}, nil).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/v1/unknown", nil))
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+type fakeTrigger struct {
+	pingErr error
+	queued  bool
+	running bool
+	checks  []string
+}
+
+func (f *fakeTrigger) RunPing(context.Context) (*network.PingResult, error) {
+	f.checks = append(f.checks, CheckPing)
+	if f.pingErr != nil {
+		return nil, f.pingErr
+	}
+	return &network.PingResult{TargetName: "server", Latency: 12 * time.Millisecond}, nil
+}
+
+func (f *fakeTrigger) QueueSpeedTest(context.Context) (monitor.Job, bool) {
+	f.checks = append(f.checks, CheckSpeedTest)
+	if f.queued {
+		return monitor.Job{}, false
+	}
+	f.queued = true
+	return monitor.Job{ID: "job1", Status: monitor.JobQueued}, true
+}
+
+func (f *fakeTrigger) Job(id string) (monitor.Job, bool) {
+	if id != "job1" {
+		return monitor.Job{}, false
+	}
+	return monitor.Job{ID: "job1", Status: monitor.JobDone, SpeedTest: &network.PerformanceResult{DownloadSpeedMbps: 500}}, true
+}
+
+func (f *fakeTrigger) CancelSpeedTest(context.Context) bool {
+	canceled := f.running
+	f.running = false
+	return canceled
+}
+
+func TestNewHandler_Trigger(t *testing.T) {
+	post := func(h http.Handler, form url.Values) (*httptest.ResponseRecorder, TriggerResult) {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/trigger", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		var result TriggerResult
+		if rr.Header().Get("Content-Type") == "application/json" {
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &result))
+		}
+		return rr, result
+	}
+
+	t.Run("ping", func(t *testing.T) {
+		trigger := &fakeTrigger{}
+		rr, result := post(NewHandler(trigger, nil), url.Values{"check": {"ping"}})
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, []string{CheckPing}, trigger.checks)
+		require.NotNil(t, result.Ping)
+		assert.Equal(t, 12*time.Millisecond, result.Ping.Latency)
+		assert.Nil(t, result.SpeedTest)
+	})
+
+	t.Run("ping and speed test by default", func(t *testing.T) {
+		trigger := &fakeTrigger{}
+		h := NewHandler(trigger, nil)
+		rr, result := post(h, nil)
+		assert.Equal(t, http.StatusAccepted, rr.Code)
+		// the ping runs first, so the speed test does not skew its latency.
+		assert.Equal(t, []string{CheckPing, CheckSpeedTest}, trigger.checks)
+		assert.NotNil(t, result.Ping)
+		require.NotNil(t, result.SpeedTest)
+		assert.Equal(t, "job1", result.SpeedTest.ID)
+
+		rr, result = post(h, url.Values{"check": {"speedtest"}})
+		assert.Equal(t, http.StatusConflict, rr.Code)
+		assert.Equal(t, "a speed test is already queued", result.SpeedTestError)
+	})
+
+	t.Run("failed ping", func(t *testing.T) {
+		rr, result := post(NewHandler(&fakeTrigger{pingErr: errors.New("i/o timeout")}, nil), url.Values{"check": {"ping"}})
+		assert.Equal(t, http.StatusBadGateway, rr.Code)
+		assert.Equal(t, "i/o timeout", result.PingError)
+	})
+
+	t.Run("failed ping and queued speed test", func(t *testing.T) {
+		trigger := &fakeTrigger{pingErr: errors.New("i/o timeout")}
+		rr, result := post(NewHandler(trigger, nil), nil)
+		assert.Equal(t, http.StatusBadGateway, rr.Code)
+		assert.Equal(t, []string{CheckPing, CheckSpeedTest}, trigger.checks)
+		assert.Equal(t, "i/o timeout", result.PingError)
+		// queued nevertheless, its job is returned.
+		require.NotNil(t, result.SpeedTest)
+		assert.Equal(t, "job1", result.SpeedTest.ID)
+	})
+
+	t.Run("invalid check", func(t *testing.T) {
+		trigger := &fakeTrigger{}
+		rr, _ := post(NewHandler(trigger, nil), url.Values{"check": {"ping", "traceroute"}})
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Empty(t, trigger.checks)
+	})
+}
+
+func TestNewHandler_Job(t *testing.T) {
+	h := NewHandler(&fakeTrigger{}, nil)
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/v1/trigger/job1", nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+	var job monitor.Job
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &job))
+	assert.Equal(t, monitor.JobDone, job.Status)
+	assert.Equal(t, 500.0, job.SpeedTest.DownloadSpeedMbps)
+
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/v1/trigger/unknown", nil))
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestNewHandler_CancelSpeedTest(t *testing.T) {
+	h := NewHandler(&fakeTrigger{running: true}, nil)
+	cancel := func() (int, CancelResult) {
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/api/v1/speedtest/cancel", nil))
+		var result CancelResult
+		require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &result))
+		return rr.Code, result
+	}
+
+	code, result := cancel()
+	assert.Equal(t, http.StatusOK, code)
+	assert.True(t, result.Canceled)
+
+	code, result = cancel()
+	assert.Equal(t, http.StatusConflict, code)
+	assert.False(t, result.Canceled)
+
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/v1/speedtest/cancel", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rr.Code)
+}
+
+type fakeSummarizer stats.Summary
+
+func (f fakeSummarizer) Summary() stats.Summary {
+	return stats.Summary(f)
+}
+
+func TestNewHandler_Stats(t *testing.T) {
+	summary := stats.Summary{Windows: []stats.Window{{
+		Minutes:   60,
+		From:      time.Date(2025, 3, 14, 20, 30, 0, 0, time.UTC),
+		LatencyMs: &stats.Stats{Count: 2, Min: 10, Avg: 15, Max: 20, StdDev: 5},
+	}}}
+	rr := httptest.NewRecorder()
+	NewHandler(&fakeTrigger{}, fakeSummarizer(summary)).ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/v1/stats", nil))
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+	var got stats.Summary
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &got))
+	assert.Equal(t, summary, got)
+	assert.NotContains(t, rr.Body.String(), "download_mbps")
+}
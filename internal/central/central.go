@@ -0,0 +1,232 @@
+// Package central receives the results of YANM agents using the central metrics engine,
+// storing them in this instance's backend tagged with the agent they came from.
+package central
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"yanm/internal/debughttp"
+	"yanm/internal/debughttp/debughandler"
+	"yanm/internal/storage"
+
+	"github.com/benbjohnson/clock"
+)
+
+// _maxBatchBytes bounds a posted batch, comfortably above a full agent queue.
+const _maxBatchBytes = 8 << 20
+
+const _centralPage = `
+<h1>Central Server</h1>
+<table>
+	<tr>
+		<th>Agent</th>
+		<th>Labels</th>
+		<th>Last Seen</th>
+		<th>Batches</th>
+		<th>Results</th>
+		<th>Latest Ping</th>
+		<th>Latest Download / Upload</th>
+		<th>Last Error</th>
+	</tr>
+	{{ range . }}
+	<tr>
+		<td>{{ .Name }}{{ if .Stale }} (stale){{ end }}</td>
+		<td>{{ range $name, $value := .Labels }}{{ $name }}="{{ $value }}" {{ else }}-{{ end }}</td>
+		<td>{{ .LastSeen.Format "2006-01-02 15:04:05" }}</td>
+		<td>{{ .Batches }}</td>
+		<td>{{ .Results }}</td>
+		<td>{{ with .LastPing }}{{ .PingMs }} ms at {{ .Time.Format "15:04:05" }}{{ else }}-{{ end }}</td>
+		<td>{{ with .LastSpeedTest }}{{ printf "%.0f / %.0f" .DownloadMbps .UploadMbps }} Mbps at {{ .Time.Format "15:04:05" }}{{ else }}-{{ end }}</td>
+		<td>{{ .LastError }}</td>
+	</tr>
+	{{ else }}
+	<tr><td colspan="8">No agent has pushed results yet.</td></tr>
+	{{ end }}
+</table>
+`
+
+var _centralPageTemplate = template.Must(template.New("central").Parse(_centralPage))
+
+// AgentStatus is what the central server knows about a single agent.
+type AgentStatus struct {
+	Name     string    `json:"name"`
+	LastSeen time.Time `json:"last_seen"`
+	// Stale is set when nothing was received from the agent for the stale period.
+	Stale         bool                   `json:"stale"`
+	Batches       int                    `json:"batches"`
+	Results       int                    `json:"results"`
+	LastPing      *storage.CentralResult `json:"last_ping,omitempty"`
+	LastSpeedTest *storage.CentralResult `json:"last_speed_test,omitempty"`
+	LastError     string                 `json:"last_error,omitempty"`
+	// Labels are the result labels of the latest result stored for the agent.
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// Server stores the batches posted by agents and reports on the agents it heard from.
+type Server struct {
+	backend storage.MetricsStorage
+	stale   time.Duration
+	logger  *slog.Logger
+
+	mu     sync.Mutex
+	agents map[string]*AgentStatus
+
+	// testing fields
+	clock clock.Clock
+}
+
+var _ debughttp.PageProvider = (*Server)(nil)
+
+// NewServer creates a Server storing results in backend, marking agents stale after stale.
+func NewServer(logger *slog.Logger, backend storage.MetricsStorage, stale time.Duration) *Server {
+	return &Server{
+		backend: backend,
+		stale:   stale,
+		logger:  logger.With("component", "central_server"),
+		agents:  make(map[string]*AgentStatus),
+		clock:   clock.New(),
+	}
+}
+
+// DebugRoutes returns the page listing the agents, which is also where they post their results.
+func (s *Server) DebugRoutes() []debughttp.DebugRoute {
+	p := &page{server: s}
+	p.view = debughandler.NewNegotiatingHandler(s.state, _centralPageTemplate)
+	return []debughttp.DebugRoute{{
+		Path:        strings.TrimSuffix(storage.CentralPath, "/"),
+		Name:        "Central",
+		Description: "Receives results from agents and lists the agents reporting to this instance.",
+		Handler:     debughandler.NewHTMLProducingHandler(p),
+		Group:       "Results",
+		Order:       20,
+		AutoRefresh: true,
+	}}
+}
+
+// Agents returns the status of every agent heard from, by name.
+func (s *Server) Agents() []AgentStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.clock.Now()
+	agents := make([]AgentStatus, 0, len(s.agents))
+	for _, agent := range s.agents {
+		status := *agent
+		status.Stale = now.Sub(agent.LastSeen) > s.stale
+		agents = append(agents, status)
+	}
+	slices.SortFunc(agents, func(a, b AgentStatus) int { return cmp.Compare(a.Name, b.Name) })
+	return agents
+}
+
+func (s *Server) state(*http.Request) (any, error) {
+	return s.Agents(), nil
+}
+
+// Receive stores every result in batch, continuing past the results that fail.
+// It returns the number of results stored.
+func (s *Server) Receive(ctx context.Context, batch storage.CentralBatch) (int, error) {
+	ctx = storage.WithAgent(ctx, batch.Agent)
+
+	var (
+		stored      int
+		errs        []error
+		ping, speed *storage.CentralResult
+		labels      map[string]string
+	)
+	for _, result := range batch.Results {
+		if err := result.Store(ctx, s.backend); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		stored++
+		labels = result.Labels
+		switch result.Type {
+		case storage.CentralResultPing:
+			ping = &result
+		case storage.CentralResultSpeedTest:
+			speed = &result
+		}
+	}
+	err := errors.Join(errs...)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	agent, ok := s.agents[batch.Agent]
+	if !ok {
+		agent = &AgentStatus{Name: batch.Agent}
+		s.agents[batch.Agent] = agent
+		s.logger.InfoContext(ctx, "Receiving results from a new agent", "agent", batch.Agent)
+	}
+	agent.LastSeen = s.clock.Now()
+	agent.Batches++
+	agent.Results += stored
+	if stored > 0 {
+		agent.Labels = labels
+	}
+	if ping != nil && (agent.LastPing == nil || !ping.Time.Before(agent.LastPing.Time)) {
+		agent.LastPing = ping
+	}
+	if speed != nil && (agent.LastSpeedTest == nil || !speed.Time.Before(agent.LastSpeedTest.Time)) {
+		agent.LastSpeedTest = speed
+	}
+	agent.LastError = ""
+	if err != nil {
+		agent.LastError = err.Error()
+	}
+	return stored, err
+}
+
+type page struct {
+	server *Server
+	view   http.Handler
+}
+
+func (p *page) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		p.view.ServeHTTP(w, r)
+
+	case http.MethodPost:
+		var batch storage.CentralBatch
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, _maxBatchBytes)).Decode(&batch); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid batch: " + err.Error()})
+			return
+		}
+		if batch.Agent == "" {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "the batch does not name its agent"})
+			return
+		}
+
+		stored, err := p.server.Receive(r.Context(), batch)
+		if err != nil {
+			p.server.logger.ErrorContext(r.Context(), "Failed to store results from agent",
+				"agent", batch.Agent, "stored", stored, "error", err)
+			// the agent would store the results that did make it again on a retry.
+			if stored == 0 {
+				writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+				return
+			}
+		}
+		writeJSON(w, http.StatusOK, map[string]int{"stored": stored})
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
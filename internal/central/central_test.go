@@ -0,0 +1,122 @@
+package central
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"yanm/internal/storage"
+	"yanm/internal/storage/storagemock"
+
+	"github.com/benbjohnson/clock"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_Receive(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	mockClock := clock.NewMock()
+
+	mockCtrl := gomock.NewController(t)
+	backend := storagemock.NewMockMetricsStorage(mockCtrl)
+	s := NewServer(logger, backend, time.Minute)
+	s.clock = mockClock
+
+	ts := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	labels := map[string]string{"site": "office"}
+	ping := storage.CentralResult{Type: storage.CentralResultPing, Time: ts, Server: "Example", PingMs: 12, PacketLossPercent: -1, Labels: labels}
+	speed := storage.CentralResult{Type: storage.CentralResultSpeedTest, Time: ts, Server: "Example", DownloadMbps: 100, UploadMbps: 20, PingMs: 15, DownloadBytes: 150_000_000, UploadBytes: 30_000_000, Labels: labels}
+
+	// results are stored on behalf of the agent that sent them.
+	backend.EXPECT().StorePingResult(gomock.Any(), ts, int64(12), 0.0, -1.0, "Example", "", "").
+		DoAndReturn(func(ctx context.Context, _ time.Time, _ int64, _, _ float64, _, _, _ string) error {
+			assert.Equal(t, "office", storage.Agent(ctx))
+			assert.Equal(t, labels, storage.ResultLabels(ctx))
+			return nil
+		})
+	backend.EXPECT().StoreNetworkPerformance(gomock.Any(), ts, 100.0, 20.0, int64(15), 0.0, 0.0, int64(150_000_000), int64(30_000_000), "Example", "", "").Return(nil)
+
+	stored, err := s.Receive(context.Background(), storage.CentralBatch{
+		Agent:   "office",
+		Results: []storage.CentralResult{ping, speed, {Type: "trace"}},
+	})
+	require.EqualError(t, err, `unknown result type "trace"`)
+	assert.Equal(t, 2, stored)
+
+	assert.Equal(t, []AgentStatus{{
+		Name:          "office",
+		LastSeen:      mockClock.Now(),
+		Batches:       1,
+		Results:       2,
+		LastPing:      &ping,
+		LastSpeedTest: &speed,
+		LastError:     `unknown result type "trace"`,
+		Labels:        labels,
+	}}, s.Agents())
+
+	mockClock.Add(2 * time.Minute)
+	assert.True(t, s.Agents()[0].Stale)
+}
+
+func TestServer_DebugRoutes(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	s := NewServer(logger, storage.NewNoOpStorage(logger), time.Minute)
+
+	routes := s.DebugRoutes()
+	require.Len(t, routes, 1)
+	assert.Equal(t, "/debug/central", routes[0].Path)
+	handler := routes[0].Handler
+
+	tests := []struct {
+		name     string
+		body     string
+		wantCode int
+		wantBody string
+	}{
+		{
+			name:     "stored",
+			body:     `{"agent": "office", "results": [{"type": "ping", "time": "2025-01-02T03:04:05Z", "server": "Example", "ping_ms": 12}]}`,
+			wantCode: http.StatusOK,
+			wantBody: `{"stored": 1}`,
+		},
+		{
+			name:     "no agent",
+			body:     `{"results": []}`,
+			wantCode: http.StatusBadRequest,
+			wantBody: `{"error": "the batch does not name its agent"}`,
+		},
+		{
+			name:     "not json",
+			body:     `agent=office`,
+			wantCode: http.StatusBadRequest,
+			wantBody: `{"error": "invalid batch: invalid character 'a' looking for beginning of value"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, storage.CentralPath, strings.NewReader(tt.body)))
+			assert.Equal(t, tt.wantCode, rr.Code)
+			assert.JSONEq(t, tt.wantBody, rr.Body.String())
+		})
+	}
+
+	req := httptest.NewRequest(http.MethodGet, storage.CentralPath, nil)
+	req.Header.Set("Accept", "application/json")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var agents []AgentStatus
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&agents))
+	require.Len(t, agents, 1)
+	assert.Equal(t, "office", agents[0].Name)
+	assert.Equal(t, 1, agents[0].Results)
+}
@@ -4,54 +4,408 @@ import (
 	"bytes"
 	"fmt"
 	"io"
+	"log/slog"
+	"maps"
+	"net"
+	"net/netip"
+	"net/url"
 	"os"
 	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
 	"yanm/internal/logger"
+	"yanm/internal/network"
 
+	"go.uber.org/multierr"
 	"gopkg.in/yaml.v3"
 )
 
 // Configuration represents the application's configuration structure
 type Configuration struct {
-	Network struct {
-		PingTest struct {
-			IntervalSeconds  int     `yaml:"interval_seconds"`
-			ThresholdSeconds float64 `yaml:"threshold_seconds"`
-		} `yaml:"ping_test"`
-		SpeedTest struct {
-			IntervalMinutes int `yaml:"interval_minutes"`
-			Servers         struct {
-				MaxPingTimeout   string `yaml:"max_ping_timeout"`
-				MaxServersToTest int    `yaml:"max_servers_to_test"`
-			} `yaml:"servers"`
-		} `yaml:"speedtest"`
-	} `yaml:"network"`
-
-	Metrics struct {
-		Engine     string `yaml:"engine"`
-		Prometheus struct {
-		} `yaml:"prometheus"`
-		InfluxDB struct {
-			URL    string `yaml:"url"`
-			Token  string `yaml:"token"`
-			Org    string `yaml:"org"`
-			Bucket string `yaml:"bucket"`
-		} `yaml:"influxdb"`
-	} `yaml:"metrics"`
+	// Include lists files or directories merged underneath this file, relative to it.
+	// Later includes override earlier ones and this file overrides them all.
+	Include []string `yaml:"include,omitempty"`
+
+	Network NetworkConfig `yaml:"network"`
+	Metrics MetricsConfig `yaml:"metrics"`
+
+	// Labels are attached to every result, in every metrics engine, the events and the
+	// debug pages, e.g. the host or site measuring them.
+	Labels map[string]string `yaml:"labels"`
 
 	// Logging configuration
 	Logging logger.Config `yaml:"logging"`
 
 	// Debug server configuration
-	DebugServer struct {
-		Disabled      bool   `yaml:"disabled"`
-		ListenAddress string `yaml:"listen_address"`
-	} `yaml:"debug_server"`
+	DebugServer DebugServerConfig `yaml:"debug_server"`
+
+	// GRPC configures the optional gRPC control and query API.
+	GRPC GRPCConfig `yaml:"grpc"`
+
+	// CentralServer receives the results of agents using the central metrics engine.
+	CentralServer CentralServerConfig `yaml:"central_server"`
+
+	// Tracing exports each speed test run as a trace, when its endpoint is set.
+	Tracing TracingConfig `yaml:"tracing"`
+
+	// Plan is the internet plan subscribed to, which every speed test is compared to.
+	Plan PlanConfig `yaml:"plan"`
+
+	// Reports sends a summary of the period with charts, by email or to a webhook.
+	Reports ReportsConfig `yaml:"reports"`
+
+	// Stats summarizes the recent results over windows, on /api/v1/stats and /debug/stats.
+	Stats StatsConfig `yaml:"stats"`
+}
+
+// StatsConfig lists the windows the recent results are summarized over.
+type StatsConfig struct {
+	// WindowMinutes are the windows ending now, the last hour, day and week by default.
+	WindowMinutes []int `yaml:"window_minutes"`
+}
+
+// Windows returns the configured windows as durations.
+func (c StatsConfig) Windows() []time.Duration {
+	windows := make([]time.Duration, 0, len(c.WindowMinutes))
+	for _, minutes := range c.WindowMinutes {
+		windows = append(windows, time.Duration(minutes)*time.Minute)
+	}
+	return windows
+}
+
+// ReportsConfig sends a report every period. It is only sent when an SMTP server or a
+// webhook is set.
+type ReportsConfig struct {
+	// Period is daily or weekly, weekly by default.
+	Period string `yaml:"period"`
+	// Weekday is the day weekly reports are sent on, monday by default.
+	Weekday string `yaml:"weekday"`
+	// SendAt is the local time of day reports are sent at, as HH:MM, 08:00 by default.
+	SendAt string             `yaml:"send_at"`
+	Email  ReportsEmailConfig `yaml:"email"`
+	// WebhookURL receives the reports as a JSON POST, it usually embeds a token.
+	WebhookURL     string `yaml:"webhook_url" yanm:"secret"`
+	WebhookURLFile string `yaml:"webhook_url_file"`
+	WebhookURLEnv  string `yaml:"webhook_url_env"`
+}
+
+// Enabled reports whether the reports are sent anywhere.
+func (c ReportsConfig) Enabled() bool {
+	return c.Email.SMTPAddress != "" || c.WebhookURL != ""
+}
+
+// ReportsEmailConfig emails the reports through an SMTP server.
+type ReportsEmailConfig struct {
+	// SMTPAddress is the server's host:port, e.g. smtp.example.com:587.
+	SMTPAddress  string   `yaml:"smtp_address"`
+	Username     string   `yaml:"username"`
+	Password     string   `yaml:"password" yanm:"secret"`
+	PasswordFile string   `yaml:"password_file"`
+	PasswordEnv  string   `yaml:"password_env"`
+	From         string   `yaml:"from"`
+	To           []string `yaml:"to"`
+}
+
+// PlanConfig is the internet plan subscribed to. It is only tracked when a download or
+// upload speed is set.
+type PlanConfig struct {
+	// Provider names the ISP on the compliance report.
+	Provider     string  `yaml:"provider"`
+	DownloadMbps float64 `yaml:"download_mbps"`
+	UploadMbps   float64 `yaml:"upload_mbps"`
+	// MinimumPercent of the plan speeds a speed test has to reach to comply, 80 by default.
+	MinimumPercent float64 `yaml:"minimum_percent"`
+	// WindowDays is the rolling period the compliance is computed over, 30 by default.
+	WindowDays int `yaml:"window_days"`
+}
+
+// TracingConfig exports a trace of every speed test run, with a span for each of its
+// phases, to an OpenTelemetry collector over OTLP/HTTP.
+type TracingConfig struct {
+	// Endpoint is the collector's OTLP/HTTP base URL, e.g. http://collector:4318.
+	Endpoint string `yaml:"endpoint"`
+	// Headers are sent with every export, e.g. for authentication.
+	Headers map[string]string `yaml:"headers" yanm:"secret"`
+	// ServiceName is the service.name resource attribute, yanm by default.
+	ServiceName string `yaml:"service_name"`
+}
+
+// CentralServerConfig configures receiving results from agents on the debug server,
+// behind its authentication, and storing them in this instance's metrics engine.
+type CentralServerConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// StaleSeconds marks an agent as stale on the central page when nothing was received
+	// from it for this long.
+	StaleSeconds int `yaml:"stale_seconds"`
 }
 
-func LoadFile(configPath string) (*Configuration, error) {
+// GRPCConfig configures the gRPC server, which is only started when ListenAddress is set.
+type GRPCConfig struct {
+	ListenAddress string `yaml:"listen_address"`
+}
+
+// NetworkConfig configures the network checks.
+type NetworkConfig struct {
+	PingTest  PingTestConfig  `yaml:"ping_test"`
+	SpeedTest SpeedTestConfig `yaml:"speedtest"`
+	// Targets are checked in addition to the closest speedtest server, each on its own interval.
+	Targets []TargetConfig `yaml:"targets"`
+	// RestartOnPanic restarts a check loop that panicked, after logging the panic and
+	// writing its crash report, instead of crashing the process.
+	RestartOnPanic bool `yaml:"restart_on_panic"`
+	// Links are the uplinks of a multi-WAN host, each measured by its own ping and speed
+	// tests and labelled with its name. The tests use the default route when empty.
+	Links []LinkConfig `yaml:"links"`
+	// Failover detects the traffic shifting between the links, when at least two are set.
+	Failover FailoverConfig `yaml:"failover"`
+	// StateFile keeps when the last speed test ran and the data budget usage, so the
+	// schedule and budget carry on across restarts. Each link gets a file of its own,
+	// named after it. Unused when empty.
+	StateFile string `yaml:"state_file"`
+}
+
+// FailoverConfig configures the detection of the traffic leaving the primary link, the
+// first of network.links.
+type FailoverConfig struct {
+	// IntervalSeconds between checks of the link the traffic takes, 30 by default.
+	IntervalSeconds int `yaml:"interval_seconds"`
+	// PublicIPURL returns the caller's public IP as plain text, e.g. https://api.ipify.org.
+	// When set, the traffic is also matched to a link by its public IP.
+	PublicIPURL string `yaml:"public_ip_url"`
+	// WebhookURL is posted every failover and the return to the primary link, it usually
+	// embeds a token.
+	WebhookURL     string `yaml:"webhook_url" yanm:"secret"`
+	WebhookURLFile string `yaml:"webhook_url_file"`
+	WebhookURLEnv  string `yaml:"webhook_url_env"`
+}
+
+// LinkConfig configures an uplink measured on its own.
+type LinkConfig struct {
+	// Name labels the link's results, e.g. fiber or lte.
+	Name string `yaml:"name"`
+	// Interface sends the link's tests from the first address of the network interface,
+	// SourceAddress from the given local IP address. Exactly one of them is required.
+	Interface     string `yaml:"interface"`
+	SourceAddress string `yaml:"source_address"`
+}
+
+// PingTestConfig configures the latency checks.
+type PingTestConfig struct {
+	IntervalSeconds  int     `yaml:"interval_seconds"`
+	ThresholdSeconds float64 `yaml:"threshold_seconds"`
+	// PercentileWindowMinutes is the sliding window the exported ping latency percentiles
+	// are computed over, 15 by default.
+	PercentileWindowMinutes int `yaml:"percentile_window_minutes"`
+}
+
+// TargetConfig configures a latency check against a single target.
+type TargetConfig struct {
+	Name string `yaml:"name"`
+	// Type is one of ping, http, dns, tcp, exec or ntp.
+	Type string `yaml:"type"`
+	// Address is a host for ping, a URL for http, a resolver host:port for dns,
+	// a host:port for tcp and a server host, with an optional port, for ntp targets.
+	Address string `yaml:"address"`
+	// Query is the name resolved by dns targets, defaults to example.com.
+	Query string `yaml:"query"`
+	// DNSSEC has dns targets verify the resolver validates DNSSEC, failing the check
+	// when it does not. The query has to be in a signed zone, as example.com is.
+	DNSSEC bool `yaml:"dnssec"`
+	// Command is the program and its arguments run by exec targets, which write their
+	// result to stdout as JSON.
+	Command []string `yaml:"command"`
+	// IntervalSeconds and ThresholdSeconds default to the ping_test settings, except
+	// for exec targets which only trigger a speed test with a threshold of their own.
+	IntervalSeconds  int     `yaml:"interval_seconds"`
+	ThresholdSeconds float64 `yaml:"threshold_seconds"`
+	TimeoutSeconds   int     `yaml:"timeout_seconds"`
+}
+
+// SpeedTestConfig configures the speed tests.
+type SpeedTestConfig struct {
+	IntervalMinutes int `yaml:"interval_minutes"`
+	// TimeoutSeconds is the deadline of a whole speed test, from the server selection to the
+	// upload, after which it is abandoned. 300 by default.
+	TimeoutSeconds int                    `yaml:"timeout_seconds"`
+	Servers        SpeedTestServersConfig `yaml:"servers"`
+	DataBudget     DataBudgetConfig       `yaml:"data_budget"`
+	// Backend runs the speed tests and pings: "ookla", speedtest.net's servers, by default,
+	// or "librespeed".
+	Backend string `yaml:"backend"`
+	// CompareBackend runs a speed test of the other backend right after each one, labelling
+	// the results of both with their backend to compare them.
+	CompareBackend string           `yaml:"compare_backend"`
+	LibreSpeed     LibreSpeedConfig `yaml:"librespeed"`
+}
+
+// LibreSpeedConfig configures the LibreSpeed server the librespeed backend tests against.
+type LibreSpeedConfig struct {
+	// Server is the URL of the server's backend, serving garbage.php and empty.php.
+	Server string `yaml:"server"`
+}
+
+// DataBudgetConfig caps the data the speed tests transfer each month, for metered links.
+type DataBudgetConfig struct {
+	// MonthlyMB is the data the speed tests may transfer each month, unlimited when zero.
+	// Speed tests are skipped once it is used up.
+	MonthlyMB int64 `yaml:"monthly_mb"`
+	// ResetDay is the day of the month the usage starts over on, 1 by default.
+	ResetDay int `yaml:"reset_day"`
+	// ReduceAtPercent of the budget used, speed tests transfer a third of the data. 80 by
+	// default, 100 never reduces them.
+	ReduceAtPercent float64 `yaml:"reduce_at_percent"`
+}
+
+// SpeedTestServersConfig configures how speed test servers are selected.
+type SpeedTestServersConfig struct {
+	MaxPingTimeout   string `yaml:"max_ping_timeout"`
+	MaxServersToTest int    `yaml:"max_servers_to_test"`
+}
+
+// MetricsConfig configures where check results are stored.
+type MetricsConfig struct {
+	Engine string `yaml:"engine"`
+	// Labels are constant labels attached to every exported metric, so multiple
+	// YANM instances scraped by one Prometheus can be told apart.
+	Labels map[string]string `yaml:"labels"`
+	// WriteTimeoutSeconds bounds every storage write, so a hung backend cannot stall the checks.
+	WriteTimeoutSeconds int               `yaml:"write_timeout_seconds"`
+	Prometheus          PrometheusConfig  `yaml:"prometheus"`
+	InfluxDB            InfluxDBConfig    `yaml:"influxdb"`
+	Aggregation         AggregationConfig `yaml:"aggregation"`
+	Central             CentralConfig     `yaml:"central"`
+}
+
+// PrometheusConfig configures the Prometheus metrics.
+type PrometheusConfig struct {
+	// Namespace prefixes the name of every speed and latency metric, and replaces the yanm
+	// prefix of the monitor's own metrics.
+	Namespace string `yaml:"namespace"`
+	// Labels attached to the speed and latency histograms, any of
+	// server, latitude, longitude, agent, link and backend. Defaults to the first three.
+	Labels []string `yaml:"labels"`
+}
+
+// InfluxDBConfig configures the InfluxDB backend.
+type InfluxDBConfig struct {
+	URL   string `yaml:"url"`
+	Token string `yaml:"token" yanm:"secret"`
+	// TokenFile and TokenEnv read the token from a file or environment variable instead.
+	TokenFile string `yaml:"token_file"`
+	TokenEnv  string `yaml:"token_env"`
+	Org       string `yaml:"org"`
+	Bucket    string `yaml:"bucket"`
+	// Tags are applied to every point, e.g. hostname, location or link name.
+	Tags map[string]string `yaml:"tags"`
+	// TLS configures verification of the server certificate, e.g. for
+	// servers using a self-signed certificate.
+	TLS InfluxDBTLSConfig `yaml:"tls"`
+	// TimeoutSeconds bounds each HTTP request, 0 uses the client default of 20 seconds.
+	TimeoutSeconds int `yaml:"timeout_seconds"`
+}
+
+// InfluxDBTLSConfig configures verification of the InfluxDB server certificate.
+type InfluxDBTLSConfig struct {
+	CAFile             string `yaml:"ca_file"`
+	InsecureSkipVerify bool   `yaml:"insecure_skip_verify"`
+}
+
+// AggregationConfig buffers ping results and stores per-window min/avg/max/p95
+// summaries instead, reducing write volume for push-based backends.
+type AggregationConfig struct {
+	Enabled       bool `yaml:"enabled"`
+	WindowSeconds int  `yaml:"window_seconds"`
+}
+
+// CentralConfig configures the central engine, which pushes results to a central YANM.
+type CentralConfig struct {
+	// URL is the central server's debug server, e.g. https://central.example.com:8090.
+	URL string `yaml:"url"`
+	// Token is the central debug server's auth token.
+	Token string `yaml:"token" yanm:"secret"`
+	// TokenFile and TokenEnv read the token from a file or environment variable instead.
+	TokenFile string `yaml:"token_file"`
+	TokenEnv  string `yaml:"token_env"`
+	// Agent names this instance on the central server, the host name by default.
+	Agent string `yaml:"agent"`
+}
+
+// DebugServerConfig configures the debug HTTP server.
+type DebugServerConfig struct {
+	Disabled bool `yaml:"disabled"`
+	// ListenAddress is a host:port, or unix:///path/to.sock for a unix domain socket.
+	ListenAddress string `yaml:"listen_address"`
+	// AllowedCIDRs restricts the debug server to clients in these networks, e.g. 192.168.1.0/24.
+	AllowedCIDRs []string             `yaml:"allowed_cidrs"`
+	Auth         DebugAuthConfig      `yaml:"auth"`
+	TLS          DebugTLSConfig       `yaml:"tls"`
+	AccessLog    DebugAccessLogConfig `yaml:"access_log"`
+	RateLimit    DebugRateLimitConfig `yaml:"rate_limit"`
+	// Pprof serves the net/http/pprof profiles under /debug/pprof/.
+	Pprof bool `yaml:"pprof"`
+
+	ReadTimeoutSeconds  int `yaml:"read_timeout_seconds"`
+	WriteTimeoutSeconds int `yaml:"write_timeout_seconds"`
+	IdleTimeoutSeconds  int `yaml:"idle_timeout_seconds"`
+	// ShutdownGraceSeconds is how long active requests may take to finish on shutdown.
+	ShutdownGraceSeconds int `yaml:"shutdown_grace_seconds"`
+
+	// RefreshSeconds reloads the speed test and monitor pages in the browser this often,
+	// e.g. for a wall-mounted display. Zero disables reloading.
+	RefreshSeconds int `yaml:"refresh_seconds"`
+
+	// TemplateDir holds layout.html, debug_root.html and static/ files overriding the embedded ones.
+	TemplateDir string `yaml:"template_dir"`
+}
+
+// DebugAccessLogConfig logs every request to the debug server at the given level.
+type DebugAccessLogConfig struct {
+	Enabled bool   `yaml:"enabled"`
+	Level   string `yaml:"level"`
+}
+
+// DebugRateLimitConfig limits the control requests, e.g. triggering a speed test, each
+// client IP may make. Zero requests_per_minute disables the limit.
+type DebugRateLimitConfig struct {
+	RequestsPerMinute int `yaml:"requests_per_minute"`
+	Burst             int `yaml:"burst"`
+}
+
+// DebugTLSConfig serves the debug server over HTTPS, so it can be exposed beyond localhost.
+type DebugTLSConfig struct {
+	CertFile string `yaml:"cert_file"`
+	KeyFile  string `yaml:"key_file"`
+	// SelfSigned generates a certificate at startup instead of loading one.
+	SelfSigned bool `yaml:"self_signed"`
+}
+
+// DebugAuthConfig protects every debug route with basic auth, a bearer token, or both.
+// Like the InfluxDB token, secrets can be read from a file or environment variable.
+type DebugAuthConfig struct {
+	Username     string `yaml:"username"`
+	Password     string `yaml:"password" yanm:"secret"`
+	PasswordFile string `yaml:"password_file"`
+	PasswordEnv  string `yaml:"password_env"`
+	Token        string `yaml:"token" yanm:"secret"`
+	TokenFile    string `yaml:"token_file"`
+	TokenEnv     string `yaml:"token_env"`
+}
+
+// LoadFile reads the configuration from configPath. The format is chosen by the file
+// extension (.yml, .yaml, .json or .toml), or detected from the content otherwise.
+//
+// configPath may also be a directory, in which case every configuration file in it is
+// merged in lexical order, or an http or https URL fetched with any headers from WithHeader.
+// Local files are merged with any files they include, see Configuration.Include.
+func LoadFile(configPath string, opts ...LoadOption) (*Configuration, error) {
 	if configPath == "" {
-		return Load(bytes.NewReader(nil))
+		return Load(bytes.NewReader(nil), opts...)
+	}
+
+	if isRemote(configPath) {
+		return loadRemote(configPath, newLoadOptions(opts), opts)
 	}
 
 	absConfigPath, err := filepath.Abs(configPath)
@@ -59,27 +413,73 @@ func LoadFile(configPath string) (*Configuration, error) {
 		return nil, fmt.Errorf("failed to resolve config path: %v", err)
 	}
 
-	configData, err := os.ReadFile(absConfigPath)
+	info, err := os.Stat(absConfigPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read config file: %v", err)
 	}
+	if !info.IsDir() {
+		configData, err := os.ReadFile(absConfigPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read config file: %v", err)
+		}
 
-	return Load(bytes.NewReader(configData))
+		// files without includes are decoded as is, keeping line numbers in errors accurate.
+		raw, err := unmarshalMap(configData, formatFromPath(configPath))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse config data: %w", err)
+		}
+		if _, ok := raw[_includeKey]; !ok {
+			return LoadFormat(bytes.NewReader(configData), formatFromPath(configPath), opts...)
+		}
+	}
+
+	merged, err := loadLayers(absConfigPath, nil)
+	if err != nil {
+		return nil, err
+	}
+	configData, err := yaml.Marshal(merged)
+	if err != nil {
+		return nil, fmt.Errorf("failed to merge config files: %w", err)
+	}
+	return LoadFormat(bytes.NewReader(configData), FormatYAML, opts...)
+}
+
+// Load reads the configuration from the given io.Reader, detecting whether it is
+// YAML, JSON or TOML, then applies any YANM_* environment variable overrides before
+// validating it.
+func Load(in io.Reader, opts ...LoadOption) (*Configuration, error) {
+	return LoadFormat(in, FormatAuto, opts...)
 }
 
-// Load reads the configuration from the given io.Reader
-func Load(in io.Reader) (*Configuration, error) {
+// LoadFormat reads the configuration in the given format from the io.Reader.
+func LoadFormat(in io.Reader, format Format, opts ...LoadOption) (*Configuration, error) {
+	opt := newLoadOptions(opts)
+
 	configData, err := io.ReadAll(in)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read from input: %w", err)
 	}
 
+	configData, format, err = applyProfile(configData, format, opt.profile, opt.strict)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config data: %w", err)
+	}
+
 	var configuration Configuration
-	err = yaml.Unmarshal(configData, &configuration)
+	err = unmarshal(configData, format, opt.strict, &configuration)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse config data: %w", err)
 	}
 
+	if err := configuration.applyEnvOverrides(os.LookupEnv); err != nil {
+		return nil, err
+	}
+
+	if err := configuration.resolveSecrets(os.LookupEnv); err != nil {
+		return nil, err
+	}
+
+	configuration.applyDefaults()
 	if err := configuration.validate(); err != nil {
 		return nil, err
 	}
@@ -87,11 +487,42 @@ func Load(in io.Reader) (*Configuration, error) {
 	return &configuration, nil
 }
 
+// Defaults returns the configuration used when nothing is set, with every default
+// filled in. Environment overrides are not applied.
+func Defaults() *Configuration {
+	var c Configuration
+	c.applyDefaults()
+	if err := c.validate(); err != nil {
+		panic(fmt.Sprintf("default configuration is invalid: %v", err))
+	}
+	return &c
+}
+
+// applyDefaults fills in the defaults derived from other settings, before validate checks
+// them.
+func (c *Configuration) applyDefaults() {
+	// Default to the full label set, an explicit empty list drops them all.
+	if c.Metrics.Prometheus.Labels == nil {
+		c.Metrics.Prometheus.Labels = []string{"server", "latitude", "longitude"}
+	}
+	// a central server tells its agents apart, a multi-WAN host its links, and the backends
+	// compared their results.
+	if c.CentralServer.Enabled && !slices.Contains(c.Metrics.Prometheus.Labels, "agent") {
+		c.Metrics.Prometheus.Labels = append(slices.Clip(c.Metrics.Prometheus.Labels), "agent")
+	}
+	if len(c.Network.Links) > 0 && !slices.Contains(c.Metrics.Prometheus.Labels, "link") {
+		c.Metrics.Prometheus.Labels = append(slices.Clip(c.Metrics.Prometheus.Labels), "link")
+	}
+	if c.Network.SpeedTest.CompareBackend != "" && !slices.Contains(c.Metrics.Prometheus.Labels, "backend") {
+		c.Metrics.Prometheus.Labels = append(slices.Clip(c.Metrics.Prometheus.Labels), "backend")
+	}
+}
+
+// validate fills in defaults and checks the configuration, returning every problem found
+// at once rather than just the first.
 func (c *Configuration) validate() error {
 	// Validate metrics configuration
-	if err := c.validateMetrics(); err != nil {
-		return err
-	}
+	errs := c.validateMetrics()
 
 	// Set default logging configuration
 	if c.Logging.Level == "" {
@@ -101,11 +532,155 @@ func (c *Configuration) validate() error {
 	if c.Logging.Format == "" {
 		c.Logging.Format = "json"
 	}
+	logToFile := c.Logging.OutputFile != "" && c.Logging.OutputFile != "stdout" && c.Logging.OutputFile != "stderr"
+	if len(c.Logging.Outputs) > 0 && c.Logging.Output != "" {
+		errs = multierr.Append(errs, fmt.Errorf("logging.outputs: replaces logging.output, set only one"))
+	}
+	if c.Logging.Output == "" && len(c.Logging.Outputs) == 0 {
+		c.Logging.Output = "file"
+	}
+	switch c.Logging.Output {
+	case "", "file", "syslog", "journald", "eventlog":
+	default:
+		errs = multierr.Append(errs, fmt.Errorf("logging.output: must be file, syslog, journald or eventlog, got %q", c.Logging.Output))
+	}
+	for i, output := range c.Logging.Outputs {
+		switch output {
+		case "stdout", "stderr", "syslog", "journald", "eventlog":
+		case "file":
+			if !logToFile {
+				errs = multierr.Append(errs, fmt.Errorf("logging.outputs: file requires output_file to be a file"))
+			}
+		default:
+			errs = multierr.Append(errs, fmt.Errorf("logging.outputs: must be stdout, stderr, file, syslog, journald or eventlog, got %q", output))
+		}
+		if slices.Contains(c.Logging.Outputs[:i], output) {
+			errs = multierr.Append(errs, fmt.Errorf("logging.outputs: %s is listed more than once", output))
+		}
+	}
+	if c.Logging.Output == "syslog" || slices.Contains(c.Logging.Outputs, "syslog") {
+		if f := c.Logging.Syslog.Facility; f != "" && !logger.ValidSyslogFacility(f) {
+			errs = multierr.Append(errs, fmt.Errorf("logging.syslog.facility: unknown facility %q", f))
+		}
+	}
+	if rot := c.Logging.Rotation; rot.MaxSizeMB < 0 || rot.MaxBackups < 0 || rot.MaxAgeDays < 0 {
+		errs = multierr.Append(errs, fmt.Errorf("logging.rotation: max_size_mb, max_backups and max_age_days must not be negative"))
+	}
+	if c.Logging.Rotation.Enabled() && !logToFile {
+		errs = multierr.Append(errs, fmt.Errorf("logging.rotation: requires output_file to be a file"))
+	}
+	if f := c.Logging.TimeFormat; f != "" && !logger.ValidTimeFormat(f) {
+		errs = multierr.Append(errs, fmt.Errorf("logging.time_format: %q is not a format name or Go layout", f))
+	}
+	if z := c.Logging.TimeZone; z != "" {
+		if _, err := time.LoadLocation(z); err != nil {
+			errs = multierr.Append(errs, fmt.Errorf("logging.time_zone: %w", err))
+		}
+	}
+	if c.Logging.CrashDir == "" {
+		c.Logging.CrashDir = filepath.Join(os.TempDir(), "yanm-crashes")
+	}
+	if c.Logging.BufferSize == 0 {
+		c.Logging.BufferSize = 500
+	} else if c.Logging.BufferSize < 0 {
+		errs = multierr.Append(errs, fmt.Errorf("logging.buffer_size: must not be negative"))
+	}
+	if dsn := c.Logging.ErrorReporting.SentryDSN; dsn != "" && !logger.ValidSentryDSN(dsn) {
+		errs = multierr.Append(errs, fmt.Errorf("logging.error_reporting.sentry_dsn: must be a DSN such as https://key@o1.ingest.sentry.io/42"))
+	}
+	if webhook := c.Logging.ErrorReporting.WebhookURL; webhook != "" {
+		if u, err := url.Parse(webhook); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = multierr.Append(errs, fmt.Errorf("logging.error_reporting.webhook_url: must be an http(s) URL"))
+		}
+	}
+	if c.Logging.Dedup.WindowSeconds < 0 {
+		errs = multierr.Append(errs, fmt.Errorf("logging.dedup.window_seconds: must not be negative"))
+	}
+	if endpoint := c.Logging.OTLP.Endpoint; endpoint != "" {
+		if u, err := url.Parse(endpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = multierr.Append(errs, fmt.Errorf("logging.otlp.endpoint: must be an http(s) URL, got %q", endpoint))
+		}
+	}
 
 	// Set default debug server configuration
 	if c.DebugServer.ListenAddress == "" {
 		c.DebugServer.ListenAddress = "127.0.0.1:8090" // Default debug server address
 	}
+	if c.DebugServer.ReadTimeoutSeconds <= 0 {
+		c.DebugServer.ReadTimeoutSeconds = 30
+	}
+	if c.DebugServer.WriteTimeoutSeconds <= 0 {
+		c.DebugServer.WriteTimeoutSeconds = 60 // leaves room for a 30 second pprof CPU profile
+	}
+	if c.DebugServer.IdleTimeoutSeconds <= 0 {
+		c.DebugServer.IdleTimeoutSeconds = 120
+	}
+	if c.DebugServer.ShutdownGraceSeconds <= 0 {
+		c.DebugServer.ShutdownGraceSeconds = 10
+	}
+	if c.DebugServer.RefreshSeconds < 0 {
+		errs = multierr.Append(errs, fmt.Errorf("debug_server.refresh_seconds must not be negative"))
+	}
+	if c.DebugServer.ListenAddress == "unix://" {
+		errs = multierr.Append(errs, fmt.Errorf("debug_server.listen_address: unix:// requires a socket path"))
+	}
+	for _, cidr := range c.DebugServer.AllowedCIDRs {
+		if _, err := netip.ParsePrefix(cidr); err != nil {
+			errs = multierr.Append(errs, fmt.Errorf("debug_server.allowed_cidrs: %v", err))
+		}
+	}
+	if auth := c.DebugServer.Auth; (auth.Username == "") != (auth.Password == "") {
+		errs = multierr.Append(errs, fmt.Errorf("debug_server.auth: username and password must be set together"))
+	}
+	if c.DebugServer.AccessLog.Level == "" {
+		c.DebugServer.AccessLog.Level = "info"
+	}
+	var accessLogLevel slog.Level
+	if err := accessLogLevel.UnmarshalText([]byte(c.DebugServer.AccessLog.Level)); err != nil {
+		errs = multierr.Append(errs, fmt.Errorf("debug_server.access_log.level: %v", err))
+	}
+	if rl := c.DebugServer.RateLimit; rl.RequestsPerMinute < 0 || rl.Burst < 0 {
+		errs = multierr.Append(errs, fmt.Errorf("debug_server.rate_limit: requests_per_minute and burst must not be negative"))
+	}
+	if c.CentralServer.Enabled && c.DebugServer.Disabled {
+		errs = multierr.Append(errs, fmt.Errorf("central_server.enabled: requires the debug server, which receives the results"))
+	}
+	if c.CentralServer.StaleSeconds <= 0 {
+		c.CentralServer.StaleSeconds = 600
+	}
+	if endpoint := c.Tracing.Endpoint; endpoint != "" {
+		if u, err := url.Parse(endpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = multierr.Append(errs, fmt.Errorf("tracing.endpoint: must be an http(s) URL, got %q", endpoint))
+		}
+	}
+	if c.Plan.DownloadMbps < 0 || c.Plan.UploadMbps < 0 {
+		errs = multierr.Append(errs, fmt.Errorf("plan: download_mbps and upload_mbps must not be negative"))
+	}
+	if c.Plan.MinimumPercent == 0 {
+		c.Plan.MinimumPercent = 80
+	} else if c.Plan.MinimumPercent < 0 || c.Plan.MinimumPercent > 100 {
+		errs = multierr.Append(errs, fmt.Errorf("plan.minimum_percent: must be between 0 and 100, got %v", c.Plan.MinimumPercent))
+	}
+	if c.Plan.WindowDays == 0 {
+		c.Plan.WindowDays = 30
+	} else if c.Plan.WindowDays < 0 {
+		errs = multierr.Append(errs, fmt.Errorf("plan.window_days: must not be negative"))
+	}
+	errs = multierr.Append(errs, c.Reports.validate())
+	if len(c.Stats.WindowMinutes) == 0 {
+		c.Stats.WindowMinutes = []int{60, 1440, 10080}
+	}
+	for _, minutes := range c.Stats.WindowMinutes {
+		// the results of the longest window are kept in memory.
+		if minutes <= 0 || minutes > _maxStatsWindowMinutes {
+			errs = multierr.Append(errs, fmt.Errorf("stats.window_minutes: must be between 1 and %d, got %d", _maxStatsWindowMinutes, minutes))
+		}
+	}
+	if tls := c.DebugServer.TLS; (tls.CertFile == "") != (tls.KeyFile == "") {
+		errs = multierr.Append(errs, fmt.Errorf("debug_server.tls: cert_file and key_file must be set together"))
+	} else if tls.CertFile != "" && tls.SelfSigned {
+		errs = multierr.Append(errs, fmt.Errorf("debug_server.tls: self_signed cannot be combined with cert_file"))
+	}
 
 	// Set default network ping_test configuration
 	if c.Network.PingTest.IntervalSeconds <= 0 {
@@ -114,25 +689,336 @@ func (c *Configuration) validate() error {
 	if c.Network.PingTest.ThresholdSeconds <= 0 {
 		c.Network.PingTest.ThresholdSeconds = 5.0 // Default to 5.0 seconds
 	}
+	if c.Network.PingTest.PercentileWindowMinutes == 0 {
+		c.Network.PingTest.PercentileWindowMinutes = 15
+	} else if c.Network.PingTest.PercentileWindowMinutes < 0 {
+		errs = multierr.Append(errs, fmt.Errorf("network.ping_test.percentile_window_minutes: must not be negative"))
+	}
+	// a ping is abandoned after the ping timeout, so a higher threshold never triggers a speed test.
+	if threshold := seconds(c.Network.PingTest.ThresholdSeconds); threshold >= network.PingTimeout {
+		errs = multierr.Append(errs, fmt.Errorf(
+			"network.ping_test.threshold_seconds (%v) must be below the %v ping timeout", threshold, network.PingTimeout))
+	}
 
 	// Set default network speedtest configuration
 	if c.Network.SpeedTest.IntervalMinutes <= 0 {
 		c.Network.SpeedTest.IntervalMinutes = 720
 	}
+	if c.Network.SpeedTest.IntervalMinutes < _minSpeedTestIntervalMinutes {
+		errs = multierr.Append(errs, fmt.Errorf(
+			"network.speedtest.interval_minutes must be at least %d, a speed test alone can take a minute",
+			_minSpeedTestIntervalMinutes))
+	}
+	if c.Network.SpeedTest.TimeoutSeconds == 0 {
+		c.Network.SpeedTest.TimeoutSeconds = 300
+	} else if c.Network.SpeedTest.TimeoutSeconds < 0 {
+		errs = multierr.Append(errs, fmt.Errorf("network.speedtest.timeout_seconds: must not be negative"))
+	}
+
+	if budget := &c.Network.SpeedTest.DataBudget; budget.MonthlyMB < 0 {
+		errs = multierr.Append(errs, fmt.Errorf("network.speedtest.data_budget.monthly_mb: must not be negative"))
+	} else {
+		if budget.ResetDay == 0 {
+			budget.ResetDay = 1
+		} else if budget.ResetDay < 1 || budget.ResetDay > 28 {
+			errs = multierr.Append(errs, fmt.Errorf("network.speedtest.data_budget.reset_day: must be between 1 and 28, got %d", budget.ResetDay))
+		}
+		if budget.ReduceAtPercent == 0 {
+			budget.ReduceAtPercent = 80
+		} else if budget.ReduceAtPercent < 0 || budget.ReduceAtPercent > 100 {
+			errs = multierr.Append(errs, fmt.Errorf("network.speedtest.data_budget.reduce_at_percent: must be between 0 and 100, got %v", budget.ReduceAtPercent))
+		}
+	}
 
-	return nil
+	if timeout := c.Network.SpeedTest.Servers.MaxPingTimeout; timeout != "" {
+		if _, err := time.ParseDuration(timeout); err != nil {
+			errs = multierr.Append(errs, fmt.Errorf("network.speedtest.servers.max_ping_timeout: %v", err))
+		}
+	}
+
+	errs = multierr.Append(errs, c.validateBackends())
+	errs = multierr.Append(errs, c.validateLinks())
+	return multierr.Append(errs, c.validateTargets())
 }
 
+func (c *Configuration) validateBackends() error {
+	var errs error
+	speedTest := &c.Network.SpeedTest
+	if speedTest.Backend == "" {
+		speedTest.Backend = "ookla"
+	}
+	for _, option := range []struct{ name, value string }{
+		{"backend", speedTest.Backend},
+		{"compare_backend", speedTest.CompareBackend},
+	} {
+		switch option.value {
+		case "", "ookla":
+		case "librespeed":
+			if speedTest.LibreSpeed.Server == "" {
+				errs = multierr.Append(errs, fmt.Errorf("network.speedtest.librespeed.server is required by the librespeed %s", option.name))
+			}
+		default:
+			errs = multierr.Append(errs, fmt.Errorf("network.speedtest.%s must be 'ookla' or 'librespeed', got %q", option.name, option.value))
+		}
+	}
+	if speedTest.CompareBackend == speedTest.Backend {
+		errs = multierr.Append(errs, fmt.Errorf("network.speedtest.compare_backend: must differ from the backend %q", speedTest.Backend))
+	}
+	if server := speedTest.LibreSpeed.Server; server != "" {
+		if u, err := url.Parse(server); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = multierr.Append(errs, fmt.Errorf("network.speedtest.librespeed.server: must be an http(s) URL, got %q", server))
+		}
+	}
+	return errs
+}
+
+// _minSpeedTestIntervalMinutes leaves room for a speed test, which downloads and uploads
+// for up to a minute, to complete before the next one is scheduled.
+const _minSpeedTestIntervalMinutes = 2
+
+// _maxStatsWindowMinutes bounds the stats windows to 31 days, as a ping every second
+// takes a sample each minute for the whole window.
+const _maxStatsWindowMinutes = 31 * 24 * 60
+
+func (c *Configuration) validateLinks() error {
+	var errs error
+	names := make(map[string]bool, len(c.Network.Links))
+	for i, link := range c.Network.Links {
+		if link.Name == "" {
+			errs = multierr.Append(errs, fmt.Errorf("network.links[%d].name is required", i))
+			continue
+		}
+		if names[link.Name] {
+			errs = multierr.Append(errs, fmt.Errorf("network.links: duplicate link name %q", link.Name))
+		}
+		names[link.Name] = true
+
+		switch {
+		case (link.Interface == "") == (link.SourceAddress == ""):
+			errs = multierr.Append(errs, fmt.Errorf("network.links[%s]: set either interface or source_address", link.Name))
+		case link.SourceAddress != "":
+			if _, err := netip.ParseAddr(link.SourceAddress); err != nil {
+				errs = multierr.Append(errs, fmt.Errorf("network.links[%s].source_address: %v", link.Name, err))
+			}
+		}
+	}
+
+	failover := &c.Network.Failover
+	if failover.IntervalSeconds == 0 {
+		failover.IntervalSeconds = 30
+	} else if failover.IntervalSeconds < 0 {
+		errs = multierr.Append(errs, fmt.Errorf("network.failover.interval_seconds: must not be negative"))
+	}
+	for _, option := range []struct{ name, value string }{
+		{"public_ip_url", failover.PublicIPURL},
+		{"webhook_url", failover.WebhookURL},
+	} {
+		name, value := option.name, option.value
+		if value == "" {
+			continue
+		}
+		if len(c.Network.Links) < 2 {
+			errs = multierr.Append(errs, fmt.Errorf("network.failover.%s: requires at least two network.links", name))
+		}
+		if u, err := url.Parse(value); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = multierr.Append(errs, fmt.Errorf("network.failover.%s: must be an http(s) URL, got %q", name, value))
+		}
+	}
+	return errs
+}
+
+func (c *Configuration) validateTargets() error {
+	var errs error
+	names := make(map[string]bool, len(c.Network.Targets))
+	for i := range c.Network.Targets {
+		target := &c.Network.Targets[i]
+		if target.Name == "" {
+			errs = multierr.Append(errs, fmt.Errorf("network.targets[%d].name is required", i))
+			continue
+		}
+		if names[target.Name] {
+			errs = multierr.Append(errs, fmt.Errorf("network.targets: duplicate target name %q", target.Name))
+		}
+		names[target.Name] = true
+
+		switch target.Type {
+		case "ping", "http", "dns", "tcp", "ntp":
+			if target.Address == "" {
+				errs = multierr.Append(errs, fmt.Errorf("network.targets[%s].address is required", target.Name))
+			}
+		case "exec":
+			if len(target.Command) == 0 || target.Command[0] == "" {
+				errs = multierr.Append(errs, fmt.Errorf("network.targets[%s].command is required by exec targets", target.Name))
+			}
+		default:
+			errs = multierr.Append(errs, fmt.Errorf("network.targets[%s].type must be 'ping', 'http', 'dns', 'tcp', 'exec' or 'ntp'", target.Name))
+		}
+
+		if target.Type == "dns" && target.Query == "" {
+			target.Query = "example.com"
+		}
+		if target.DNSSEC && target.Type != "dns" {
+			errs = multierr.Append(errs, fmt.Errorf("network.targets[%s].dnssec: only dns targets check DNSSEC", target.Name))
+		}
+		if target.IntervalSeconds <= 0 {
+			target.IntervalSeconds = c.Network.PingTest.IntervalSeconds
+		}
+		// a script's run time says little about the network.
+		if target.ThresholdSeconds <= 0 && target.Type != "exec" {
+			target.ThresholdSeconds = c.Network.PingTest.ThresholdSeconds
+		}
+		if target.TimeoutSeconds <= 0 {
+			target.TimeoutSeconds = 10 // Default to 10 seconds, like the speedtest ping
+		}
+		if target.ThresholdSeconds >= float64(target.TimeoutSeconds) {
+			errs = multierr.Append(errs, fmt.Errorf(
+				"network.targets[%s].threshold_seconds (%v) must be below timeout_seconds (%d)",
+				target.Name, target.ThresholdSeconds, target.TimeoutSeconds))
+		}
+	}
+	return errs
+}
+
+// _metricNameRE matches valid Prometheus label names and metric name components.
+var _metricNameRE = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
 func (c *Configuration) validateMetrics() error {
+	var errs error
+
 	// Set default metrics engine
 	if c.Metrics.Engine == "" {
 		c.Metrics.Engine = "prometheus"
 	}
 
 	// Validate metrics engine
-	if c.Metrics.Engine != "prometheus" && c.Metrics.Engine != "no-op" {
-		return fmt.Errorf("metrics.engine must be 'prometheus' or 'no-op'")
+	switch c.Metrics.Engine {
+	case "prometheus", "no-op":
+	case "influxdb":
+		if c.Metrics.InfluxDB.URL == "" {
+			errs = multierr.Append(errs, fmt.Errorf("metrics.influxdb.url is required by the influxdb engine"))
+		}
+		if c.Metrics.InfluxDB.Token == "" {
+			errs = multierr.Append(errs, fmt.Errorf("metrics.influxdb.token is required by the influxdb engine"))
+		}
+	case "central":
+		if u, err := url.Parse(c.Metrics.Central.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = multierr.Append(errs, fmt.Errorf("metrics.central.url must be an http(s) URL, required by the central engine"))
+		}
+	default:
+		errs = multierr.Append(errs, fmt.Errorf("metrics.engine must be 'prometheus', 'influxdb', 'central' or 'no-op'"))
+	}
+
+	for i, label := range c.Metrics.Prometheus.Labels {
+		if label != "server" && label != "latitude" && label != "longitude" && label != "agent" && label != "link" && label != "backend" {
+			errs = multierr.Append(errs, fmt.Errorf(
+				"metrics.prometheus.labels must only contain 'server', 'latitude', 'longitude', 'agent', 'link' or 'backend', got %q", label))
+		} else if slices.Contains(c.Metrics.Prometheus.Labels[:i], label) {
+			// the histograms cannot be registered with a label twice.
+			errs = multierr.Append(errs, fmt.Errorf("metrics.prometheus.labels: duplicate label %q", label))
+		}
+	}
+
+	if ns := c.Metrics.Prometheus.Namespace; ns != "" && !_metricNameRE.MatchString(ns) {
+		errs = multierr.Append(errs, fmt.Errorf("metrics.prometheus.namespace %q is not a valid metric name prefix", ns))
 	}
 
-	return nil
+	// sorted so errors are reported in a stable order.
+	for _, name := range slices.Sorted(maps.Keys(c.Metrics.Labels)) {
+		if !_metricNameRE.MatchString(name) {
+			errs = multierr.Append(errs, fmt.Errorf("metrics.labels: %q is not a valid label name", name))
+		}
+		if name == "server" || name == "latitude" || name == "longitude" || name == "agent" || name == "link" || name == "backend" {
+			errs = multierr.Append(errs, fmt.Errorf("metrics.labels: %q is reserved for per-result labels", name))
+		}
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(c.Labels)) {
+		if !_metricNameRE.MatchString(name) {
+			errs = multierr.Append(errs, fmt.Errorf("labels: %q is not a valid label name", name))
+		}
+		if name == "server" || name == "latitude" || name == "longitude" || name == "agent" || name == "link" || name == "backend" || name == "direction" {
+			errs = multierr.Append(errs, fmt.Errorf("labels: %q is reserved for per-result labels", name))
+		}
+		if _, ok := c.Metrics.Labels[name]; ok {
+			errs = multierr.Append(errs, fmt.Errorf("labels: %q is also set in metrics.labels", name))
+		}
+	}
+
+	if c.Metrics.WriteTimeoutSeconds <= 0 {
+		c.Metrics.WriteTimeoutSeconds = 10 // Default to 10 seconds
+	}
+
+	if c.Metrics.InfluxDB.TimeoutSeconds < 0 {
+		errs = multierr.Append(errs, fmt.Errorf("metrics.influxdb.timeout_seconds must not be negative"))
+	}
+
+	if c.Metrics.Aggregation.WindowSeconds <= 0 {
+		c.Metrics.Aggregation.WindowSeconds = 60 // Default to per-minute summaries
+	}
+
+	if _, ok := c.Metrics.InfluxDB.Tags["server"]; ok {
+		errs = multierr.Append(errs, fmt.Errorf("metrics.influxdb.tags: \"server\" is reserved for the speedtest server tag"))
+	}
+	if _, ok := c.Metrics.InfluxDB.Tags["agent"]; ok && c.CentralServer.Enabled {
+		errs = multierr.Append(errs, fmt.Errorf("metrics.influxdb.tags: \"agent\" is reserved for the agent tag of the central server"))
+	}
+	if _, ok := c.Metrics.InfluxDB.Tags["link"]; ok && len(c.Network.Links) > 0 {
+		errs = multierr.Append(errs, fmt.Errorf("metrics.influxdb.tags: \"link\" is reserved for the link tag of network.links"))
+	}
+	if _, ok := c.Metrics.InfluxDB.Tags["backend"]; ok && c.Network.SpeedTest.CompareBackend != "" {
+		errs = multierr.Append(errs, fmt.Errorf("metrics.influxdb.tags: \"backend\" is reserved for the backend tag of network.speedtest.compare_backend"))
+	}
+
+	return errs
+}
+
+// seconds converts fractional seconds from the configuration to a time.Duration.
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+// validate checks the reports configuration and sets its defaults.
+func (c *ReportsConfig) validate() error {
+	var errs error
+	switch c.Period {
+	case "":
+		c.Period = "weekly"
+	case "daily", "weekly":
+	default:
+		errs = multierr.Append(errs, fmt.Errorf("reports.period: must be daily or weekly, got %q", c.Period))
+	}
+	if c.Weekday == "" {
+		c.Weekday = "monday"
+	} else if _, ok := ParseWeekday(c.Weekday); !ok {
+		errs = multierr.Append(errs, fmt.Errorf("reports.weekday: unknown day %q", c.Weekday))
+	}
+	if c.SendAt == "" {
+		c.SendAt = "08:00"
+	} else if _, err := time.Parse("15:04", c.SendAt); err != nil {
+		errs = multierr.Append(errs, fmt.Errorf("reports.send_at: must be a time of day as HH:MM, got %q", c.SendAt))
+	}
+	if email := c.Email; email.SMTPAddress != "" {
+		if _, _, err := net.SplitHostPort(email.SMTPAddress); err != nil {
+			errs = multierr.Append(errs, fmt.Errorf("reports.email.smtp_address: %v", err))
+		}
+		if email.From == "" || len(email.To) == 0 {
+			errs = multierr.Append(errs, fmt.Errorf("reports.email: from and to are required to send emails"))
+		}
+	}
+	if webhook := c.WebhookURL; webhook != "" {
+		if u, err := url.Parse(webhook); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = multierr.Append(errs, fmt.Errorf("reports.webhook_url: must be an http(s) URL, got %q", webhook))
+		}
+	}
+	return errs
+}
+
+// ParseWeekday parses the English name of a day of the week, in any case.
+func ParseWeekday(name string) (time.Weekday, bool) {
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if strings.EqualFold(d.String(), name) {
+			return d, true
+		}
+	}
+	return 0, false
 }
@@ -2,8 +2,14 @@ package config
 
 import (
 	_ "embed"
+	"encoding/json"
+	"errors"
 	"html/template"
 	"net/http"
+	"sync/atomic"
+
+	"yanm/internal/debughttp"
+	"yanm/internal/debughttp/debughandler"
 
 	"gopkg.in/yaml.v3"
 )
@@ -13,34 +19,65 @@ var configDebugHTMLTemplate string
 
 var configTmpl = template.Must(template.New("config_debug").Parse(configDebugHTMLTemplate))
 
-type configPage struct {
-	cfg *Configuration
+// ConfigPage renders the current configuration, which can be swapped on reload.
+type ConfigPage struct {
+	cfg     atomic.Pointer[Configuration]
+	handler http.Handler
 }
 
-// ServeHTTP handles the request for the configuration debug page.
-func (p *configPage) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
-	yamlBytes, err := yaml.Marshal(p.cfg)
-	if err != nil {
-		http.Error(w, "Failed to render configuration", http.StatusInternalServerError)
-		return
-	}
+// Update replaces the configuration shown by the page.
+func (p *ConfigPage) Update(cfg *Configuration) {
+	p.cfg.Store(cfg)
+}
+
+// ServeHTTP handles the request for the configuration debug page. Secrets are redacted.
+// The configuration is returned as JSON when requested with an application/json Accept header.
+func (p *ConfigPage) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	p.handler.ServeHTTP(w, r)
+}
+
+// configView is the redacted configuration behind both views of the page.
+type configView struct {
+	FormattedConfig template.HTML
+	raw             []byte
+}
 
-	data := struct {
-		FormattedConfig template.HTML
-	}{
-		FormattedConfig: template.HTML(string(yamlBytes)),
+// MarshalJSON decodes through a generic map so the JSON keys match the yaml ones.
+func (v configView) MarshalJSON() ([]byte, error) {
+	var raw map[string]any
+	if err := yaml.Unmarshal(v.raw, &raw); err != nil {
+		return nil, err
 	}
+	return json.Marshal(raw)
+}
 
-	if err := configTmpl.Execute(w, data); err != nil {
-		http.Error(w, "Failed to execute template", http.StatusInternalServerError)
-		return
+func (p *ConfigPage) view(*http.Request) (any, error) {
+	yamlBytes, err := yaml.Marshal(p.cfg.Load().Redacted())
+	if err != nil {
+		return nil, errors.New("failed to render configuration")
 	}
+	return configView{FormattedConfig: template.HTML(string(yamlBytes)), raw: yamlBytes}, nil
 }
 
 // NewConfigDebugPageProvider creates a new debug page provider for the application configuration.
 // The handler returned is the raw content-producing handler.
-func NewConfigDebugPageProvider(cfg *Configuration) http.Handler {
-	return &configPage{
-		cfg: cfg,
-	}
+func NewConfigDebugPageProvider(cfg *Configuration) *ConfigPage {
+	p := &ConfigPage{}
+	p.handler = debughandler.NewNegotiatingHandler(p.view, configTmpl)
+	p.Update(cfg)
+	return p
+}
+
+var _ debughttp.PageProvider = (*ConfigPage)(nil)
+
+// DebugRoutes returns the configuration debug page route.
+func (p *ConfigPage) DebugRoutes() []debughttp.DebugRoute {
+	return []debughttp.DebugRoute{{
+		Path:        "/debug/config",
+		Name:        "Configuration",
+		Description: "Displays the current application configuration.",
+		Handler:     debughandler.NewHTMLProducingHandler(p),
+		Group:       "System",
+		Order:       40,
+	}}
 }
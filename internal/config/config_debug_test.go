@@ -14,64 +14,27 @@ import (
 func TestConfigPage_ServeHTTP(t *testing.T) {
 	// Create a sample configuration
 	sampleConfig := &Configuration{
-		Network: struct {
-			PingTest struct {
-				IntervalSeconds  int     `yaml:"interval_seconds"`
-				ThresholdSeconds float64 `yaml:"threshold_seconds"`
-			} `yaml:"ping_test"`
-			SpeedTest struct {
-				IntervalMinutes int `yaml:"interval_minutes"`
-				Servers         struct {
-					MaxPingTimeout   string `yaml:"max_ping_timeout"`
-					MaxServersToTest int    `yaml:"max_servers_to_test"`
-				} `yaml:"servers"`
-			} `yaml:"speedtest"`
-		}{
-			PingTest: struct {
-				IntervalSeconds  int     `yaml:"interval_seconds"`
-				ThresholdSeconds float64 `yaml:"threshold_seconds"`
-			}{
+		Network: NetworkConfig{
+			PingTest: PingTestConfig{
 				IntervalSeconds:  60,
 				ThresholdSeconds: 5.0, // Example value
 			},
-			SpeedTest: struct {
-				IntervalMinutes int `yaml:"interval_minutes"`
-				Servers         struct {
-					MaxPingTimeout   string `yaml:"max_ping_timeout"`
-					MaxServersToTest int    `yaml:"max_servers_to_test"`
-				} `yaml:"servers"`
-			}{
+			SpeedTest: SpeedTestConfig{
 				IntervalMinutes: 60,
-				Servers: struct {
-					MaxPingTimeout   string `yaml:"max_ping_timeout"`
-					MaxServersToTest int    `yaml:"max_servers_to_test"`
-				}{
+				Servers: SpeedTestServersConfig{
 					MaxPingTimeout:   "1s", // Example value
 					MaxServersToTest: 5,    // Example value
 				},
 			},
 		},
-		Metrics: struct {
-			Engine     string `yaml:"engine"`
-			Prometheus struct {
-			} `yaml:"prometheus"`
-			InfluxDB struct {
-				URL    string `yaml:"url"`
-				Token  string `yaml:"token"`
-				Org    string `yaml:"org"`
-				Bucket string `yaml:"bucket"`
-			} `yaml:"influxdb"`
-		}{
+		Metrics: MetricsConfig{
 			Engine: "prometheus",
 		},
 		Logging: logger.Config{
 			Level:  "info",
 			Format: "text",
 		},
-		DebugServer: struct {
-			Disabled      bool   `yaml:"disabled"`
-			ListenAddress string `yaml:"listen_address"`
-		}{
+		DebugServer: DebugServerConfig{
 			Disabled:      false,
 			ListenAddress: ":8081",
 		},
@@ -101,3 +64,37 @@ func TestConfigPage_ServeHTTP(t *testing.T) {
 
 	assert.Contains(t, body, expectedYAMLString, "handler response body does not contain the exact YAML string")
 }
+
+func TestConfigPage_RedactsSecrets(t *testing.T) {
+	cfg := defaultConfig()
+	cfg.Metrics.InfluxDB.URL = "http://influx:8086"
+	cfg.Metrics.InfluxDB.Token = "super-secret"
+
+	page := NewConfigDebugPageProvider(cfg)
+
+	testCases := []struct {
+		name   string
+		accept string
+	}{
+		{name: "html", accept: "text/html"},
+		{name: "json", accept: "application/json"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/debug/config", nil)
+			req.Header.Set("Accept", tc.accept)
+			rr := httptest.NewRecorder()
+
+			page.ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusOK, rr.Code)
+			assert.NotContains(t, rr.Body.String(), "super-secret")
+			assert.Contains(t, rr.Body.String(), "***")
+			assert.Contains(t, rr.Body.String(), "http://influx:8086")
+		})
+	}
+
+	// the configuration itself is untouched.
+	assert.Equal(t, "super-secret", cfg.Metrics.InfluxDB.Token)
+}
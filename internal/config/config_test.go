@@ -3,12 +3,15 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"yanm/internal/logger"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.uber.org/multierr"
 )
 
 func createTempConfigFile(t *testing.T, content string) string {
@@ -21,6 +24,60 @@ func createTempConfigFile(t *testing.T, content string) string {
 	return configPath
 }
 
+// defaultConfig is the configuration loaded from an empty file.
+func defaultConfig() *Configuration {
+	return &Configuration{
+		Network: NetworkConfig{
+			PingTest: PingTestConfig{
+				IntervalSeconds:         2,
+				ThresholdSeconds:        5.0,
+				PercentileWindowMinutes: 15,
+			},
+			SpeedTest: SpeedTestConfig{
+				IntervalMinutes: 720,
+				TimeoutSeconds:  300,
+				DataBudget:      DataBudgetConfig{ResetDay: 1, ReduceAtPercent: 80},
+				Backend:         "ookla",
+			},
+			Failover: FailoverConfig{IntervalSeconds: 30},
+		},
+		Metrics: MetricsConfig{
+			Engine:              "prometheus",
+			WriteTimeoutSeconds: 10,
+			Prometheus: PrometheusConfig{
+				Labels: []string{"server", "latitude", "longitude"},
+			},
+			Aggregation: AggregationConfig{
+				WindowSeconds: 60,
+			},
+		},
+		Logging: logger.Config{
+			Level:      "info",
+			Format:     "json",
+			Output:     "file",
+			BufferSize: 500,
+			CrashDir:   filepath.Join(os.TempDir(), "yanm-crashes"),
+		},
+		DebugServer: DebugServerConfig{
+			ListenAddress: "127.0.0.1:8090",
+			AccessLog:     DebugAccessLogConfig{Level: "info"},
+
+			ReadTimeoutSeconds:   30,
+			WriteTimeoutSeconds:  60,
+			IdleTimeoutSeconds:   120,
+			ShutdownGraceSeconds: 10,
+		},
+		CentralServer: CentralServerConfig{StaleSeconds: 600},
+		Plan:          PlanConfig{MinimumPercent: 80, WindowDays: 30},
+		Reports:       ReportsConfig{Period: "weekly", Weekday: "monday", SendAt: "08:00"},
+		Stats:         StatsConfig{WindowMinutes: []int{60, 1440, 10080}},
+	}
+}
+
+func TestDefaults(t *testing.T) {
+	assert.Equal(t, defaultConfig(), Defaults())
+}
+
 func TestLoadFile_ErrorConditions(t *testing.T) {
 	testCases := []struct {
 		name          string
@@ -78,62 +135,7 @@ func TestLoadFile_ContentAndValidation(t *testing.T) {
 			name:          "Default Configuration (empty actual file)",
 			pathArgument:  "USE_TEMP_FILE",
 			configContent: "", // Empty content, leads to zero-value Configuration struct
-			wantConfig: &Configuration{
-				Metrics: struct {
-					Engine     string `yaml:"engine"`
-					Prometheus struct {
-					} `yaml:"prometheus"`
-					InfluxDB struct {
-						URL    string `yaml:"url"`
-						Token  string `yaml:"token"`
-						Org    string `yaml:"org"`
-						Bucket string `yaml:"bucket"`
-					} `yaml:"influxdb"`
-				}{
-					Engine: "prometheus",
-				},
-				Network: struct {
-					PingTest struct {
-						IntervalSeconds  int     `yaml:"interval_seconds"`
-						ThresholdSeconds float64 `yaml:"threshold_seconds"`
-					} `yaml:"ping_test"`
-					SpeedTest struct {
-						IntervalMinutes int `yaml:"interval_minutes"`
-						Servers         struct {
-							MaxPingTimeout   string `yaml:"max_ping_timeout"`
-							MaxServersToTest int    `yaml:"max_servers_to_test"`
-						} `yaml:"servers"`
-					} `yaml:"speedtest"`
-				}{
-					PingTest: struct {
-						IntervalSeconds  int     `yaml:"interval_seconds"`
-						ThresholdSeconds float64 `yaml:"threshold_seconds"`
-					}{
-						IntervalSeconds:  2,
-						ThresholdSeconds: 5.0,
-					},
-					SpeedTest: struct {
-						IntervalMinutes int `yaml:"interval_minutes"`
-						Servers         struct {
-							MaxPingTimeout   string `yaml:"max_ping_timeout"`
-							MaxServersToTest int    `yaml:"max_servers_to_test"`
-						} `yaml:"servers"`
-					}{
-						IntervalMinutes: 720,
-					},
-				},
-				Logging: logger.Config{
-					Level:  "info",
-					Format: "json",
-				},
-				DebugServer: struct {
-					Disabled      bool   `yaml:"disabled"`
-					ListenAddress string `yaml:"listen_address"`
-				}{
-					Disabled:      false,
-					ListenAddress: "127.0.0.1:8090",
-				},
-			},
+			wantConfig:    defaultConfig(),
 		},
 		{
 			name:         "Prometheus Configuration (valid)",
@@ -142,62 +144,7 @@ func TestLoadFile_ContentAndValidation(t *testing.T) {
 metrics:
   engine: prometheus
 `,
-			wantConfig: &Configuration{
-				Metrics: struct {
-					Engine     string `yaml:"engine"`
-					Prometheus struct {
-					} `yaml:"prometheus"`
-					InfluxDB struct {
-						URL    string `yaml:"url"`
-						Token  string `yaml:"token"`
-						Org    string `yaml:"org"`
-						Bucket string `yaml:"bucket"`
-					} `yaml:"influxdb"`
-				}{
-					Engine: "prometheus",
-				},
-				Network: struct {
-					PingTest struct {
-						IntervalSeconds  int     `yaml:"interval_seconds"`
-						ThresholdSeconds float64 `yaml:"threshold_seconds"`
-					} `yaml:"ping_test"`
-					SpeedTest struct {
-						IntervalMinutes int `yaml:"interval_minutes"`
-						Servers         struct {
-							MaxPingTimeout   string `yaml:"max_ping_timeout"`
-							MaxServersToTest int    `yaml:"max_servers_to_test"`
-						} `yaml:"servers"`
-					} `yaml:"speedtest"`
-				}{
-					PingTest: struct {
-						IntervalSeconds  int     `yaml:"interval_seconds"`
-						ThresholdSeconds float64 `yaml:"threshold_seconds"`
-					}{
-						IntervalSeconds:  2,
-						ThresholdSeconds: 5.0,
-					},
-					SpeedTest: struct {
-						IntervalMinutes int `yaml:"interval_minutes"`
-						Servers         struct {
-							MaxPingTimeout   string `yaml:"max_ping_timeout"`
-							MaxServersToTest int    `yaml:"max_servers_to_test"`
-						} `yaml:"servers"`
-					}{
-						IntervalMinutes: 720,
-					},
-				},
-				Logging: logger.Config{
-					Level:  "info",
-					Format: "json",
-				},
-				DebugServer: struct {
-					Disabled      bool   `yaml:"disabled"`
-					ListenAddress string `yaml:"listen_address"`
-				}{
-					Disabled:      false,
-					ListenAddress: "127.0.0.1:8090",
-				},
-			},
+			wantConfig: defaultConfig(),
 		},
 		{
 			name:         "Invalid Metrics Engine (validation)",
@@ -206,7 +153,27 @@ metrics:
   engine: invalid_engine
 `,
 			wantConfig:   nil,
-			errorMessage: "metrics.engine must be 'prometheus' or 'no-op'",
+			errorMessage: "metrics.engine must be 'prometheus', 'influxdb', 'central' or 'no-op'",
+		},
+		{
+			name:         "Invalid Prometheus Label (validation)",
+			pathArgument: "USE_TEMP_FILE",
+			configContent: `metrics:
+  prometheus:
+    labels: [server, city]
+`,
+			wantConfig:   nil,
+			errorMessage: `metrics.prometheus.labels must only contain 'server', 'latitude', 'longitude', 'agent', 'link' or 'backend', got "city"`,
+		},
+		{
+			name:         "Duplicate Prometheus Label (validation)",
+			pathArgument: "USE_TEMP_FILE",
+			configContent: `metrics:
+  prometheus:
+    labels: [server, latitude, server]
+`,
+			wantConfig:   nil,
+			errorMessage: `metrics.prometheus.labels: duplicate label "server"`,
 		},
 	}
 
@@ -233,3 +200,377 @@ metrics:
 		})
 	}
 }
+
+func TestLoad_CrossFieldValidation(t *testing.T) {
+	_, err := Load(strings.NewReader(`
+metrics:
+  engine: influxdb
+network:
+  ping_test:
+    threshold_seconds: 12
+  speedtest:
+    interval_minutes: 1
+  targets:
+    - {name: gateway, type: tcp, address: "192.168.1.1:80", threshold_seconds: 3, timeout_seconds: 2}
+`))
+	require.Error(t, err)
+
+	// every problem is reported at once.
+	assert.Equal(t, []string{
+		"metrics.influxdb.url is required by the influxdb engine",
+		"metrics.influxdb.token is required by the influxdb engine",
+		"network.ping_test.threshold_seconds (12s) must be below the 10s ping timeout",
+		"network.speedtest.interval_minutes must be at least 2, a speed test alone can take a minute",
+		"network.targets[gateway].threshold_seconds (3) must be below timeout_seconds (2)",
+	}, errorMessages(multierr.Errors(err)))
+}
+
+func TestLoad_DebugTLS(t *testing.T) {
+	cfg, err := Load(strings.NewReader("debug_server:\n  tls:\n    self_signed: true\n"))
+	require.NoError(t, err)
+	assert.True(t, cfg.DebugServer.TLS.SelfSigned)
+
+	_, err = Load(strings.NewReader("debug_server:\n  tls:\n    cert_file: tls.crt\n"))
+	require.EqualError(t, err, "debug_server.tls: cert_file and key_file must be set together")
+
+	_, err = Load(strings.NewReader("debug_server:\n  tls:\n    cert_file: tls.crt\n    key_file: tls.key\n    self_signed: true\n"))
+	require.EqualError(t, err, "debug_server.tls: self_signed cannot be combined with cert_file")
+}
+
+func TestLoad_DebugAllowedCIDRs(t *testing.T) {
+	cfg, err := Load(strings.NewReader("debug_server:\n  allowed_cidrs: [192.168.1.0/24, 127.0.0.1/32]\n"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"192.168.1.0/24", "127.0.0.1/32"}, cfg.DebugServer.AllowedCIDRs)
+
+	_, err = Load(strings.NewReader("debug_server:\n  allowed_cidrs: [192.168.1.0]\n"))
+	require.ErrorContains(t, err, "debug_server.allowed_cidrs")
+}
+
+func TestLoad_DebugRateLimit(t *testing.T) {
+	cfg, err := Load(strings.NewReader("debug_server:\n  rate_limit:\n    requests_per_minute: 6\n    burst: 3\n"))
+	require.NoError(t, err)
+	assert.Equal(t, DebugRateLimitConfig{RequestsPerMinute: 6, Burst: 3}, cfg.DebugServer.RateLimit)
+
+	_, err = Load(strings.NewReader("debug_server:\n  rate_limit:\n    requests_per_minute: -1\n"))
+	require.EqualError(t, err, "debug_server.rate_limit: requests_per_minute and burst must not be negative")
+}
+
+func TestLoad_LoggingSyslog(t *testing.T) {
+	cfg, err := Load(strings.NewReader("logging:\n  output: syslog\n  syslog:\n    facility: local3\n    tag: probe\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "syslog", cfg.Logging.Output)
+	assert.Equal(t, logger.SyslogConfig{Facility: "local3", Tag: "probe"}, cfg.Logging.Syslog)
+
+	_, err = Load(strings.NewReader("logging:\n  output: syslog\n  syslog:\n    facility: local9\n"))
+	require.EqualError(t, err, `logging.syslog.facility: unknown facility "local9"`)
+
+	_, err = Load(strings.NewReader("logging:\n  output: carrier-pigeon\n"))
+	require.EqualError(t, err, `logging.output: must be file, syslog, journald or eventlog, got "carrier-pigeon"`)
+}
+
+func TestLoad_LoggingTime(t *testing.T) {
+	cfg, err := Load(strings.NewReader("logging:\n  add_source: true\n  time_format: datetime\n  time_zone: America/Denver\n"))
+	require.NoError(t, err)
+	assert.True(t, cfg.Logging.AddSource)
+	assert.Equal(t, "datetime", cfg.Logging.TimeFormat)
+	assert.Equal(t, "America/Denver", cfg.Logging.TimeZone)
+
+	_, err = Load(strings.NewReader("logging:\n  time_format: YYYY-MM-DD\n"))
+	require.EqualError(t, err, `logging.time_format: "YYYY-MM-DD" is not a format name or Go layout`)
+
+	_, err = Load(strings.NewReader("logging:\n  time_zone: Mars/Olympus_Mons\n"))
+	require.EqualError(t, err, "logging.time_zone: unknown time zone Mars/Olympus_Mons")
+}
+
+func TestLoad_LoggingBufferSize(t *testing.T) {
+	cfg, err := Load(strings.NewReader("logging:\n  buffer_size: 2000\n"))
+	require.NoError(t, err)
+	assert.Equal(t, 2000, cfg.Logging.BufferSize)
+
+	_, err = Load(strings.NewReader("logging:\n  buffer_size: -1\n"))
+	require.EqualError(t, err, "logging.buffer_size: must not be negative")
+}
+
+func TestLoad_LoggingErrorReporting(t *testing.T) {
+	cfg, err := Load(strings.NewReader("logging:\n  error_reporting:\n    sentry_dsn: https://key@o1.ingest.sentry.io/42\n    webhook_url: https://hooks.example.com/yanm\n    environment: cabin\n"))
+	require.NoError(t, err)
+	assert.Equal(t, logger.ErrorReportingConfig{
+		SentryDSN:   "https://key@o1.ingest.sentry.io/42",
+		WebhookURL:  "https://hooks.example.com/yanm",
+		Environment: "cabin",
+	}, cfg.Logging.ErrorReporting)
+	assert.Equal(t, "***", cfg.Redacted().Logging.ErrorReporting.SentryDSN)
+
+	// the DSN isn't repeated in the error, as it holds the key.
+	_, err = Load(strings.NewReader("logging:\n  error_reporting:\n    sentry_dsn: https://o1.ingest.sentry.io/42\n"))
+	require.EqualError(t, err, "logging.error_reporting.sentry_dsn: must be a DSN such as https://key@o1.ingest.sentry.io/42")
+
+	_, err = Load(strings.NewReader("logging:\n  error_reporting:\n    webhook_url: hooks.example.com\n"))
+	require.EqualError(t, err, "logging.error_reporting.webhook_url: must be an http(s) URL")
+}
+
+func TestLoad_LoggingDedup(t *testing.T) {
+	cfg, err := Load(strings.NewReader("logging:\n  dedup:\n    window_seconds: 60\n"))
+	require.NoError(t, err)
+	assert.Equal(t, logger.DedupConfig{WindowSeconds: 60}, cfg.Logging.Dedup)
+
+	_, err = Load(strings.NewReader("logging:\n  dedup:\n    window_seconds: -1\n"))
+	require.EqualError(t, err, "logging.dedup.window_seconds: must not be negative")
+}
+
+func TestLoad_Central(t *testing.T) {
+	cfg, err := Load(strings.NewReader("metrics:\n  engine: central\n  central:\n    url: https://central:8090\n    token: secret\n    agent: office\n"))
+	require.NoError(t, err)
+	assert.Equal(t, CentralConfig{URL: "https://central:8090", Token: "secret", Agent: "office"}, cfg.Metrics.Central)
+
+	_, err = Load(strings.NewReader("metrics:\n  engine: central\n  central:\n    url: central:8090\n"))
+	require.EqualError(t, err, "metrics.central.url must be an http(s) URL, required by the central engine")
+
+	cfg, err = Load(strings.NewReader("central_server:\n  enabled: true\nmetrics:\n  prometheus:\n    labels: [server]\n"))
+	require.NoError(t, err)
+	assert.Equal(t, CentralServerConfig{Enabled: true, StaleSeconds: 600}, cfg.CentralServer)
+	assert.Equal(t, []string{"server", "agent"}, cfg.Metrics.Prometheus.Labels)
+
+	_, err = Load(strings.NewReader("central_server:\n  enabled: true\ndebug_server:\n  disabled: true\n"))
+	require.EqualError(t, err, "central_server.enabled: requires the debug server, which receives the results")
+}
+
+func TestLoad_Links(t *testing.T) {
+	cfg, err := Load(strings.NewReader("network:\n  links:\n    - name: fiber\n      interface: eth0\n    - name: lte\n      source_address: 192.168.8.2\n"))
+	require.NoError(t, err)
+	assert.Equal(t, []LinkConfig{
+		{Name: "fiber", Interface: "eth0"},
+		{Name: "lte", SourceAddress: "192.168.8.2"},
+	}, cfg.Network.Links)
+	assert.Equal(t, []string{"server", "latitude", "longitude", "link"}, cfg.Metrics.Prometheus.Labels)
+
+	_, err = Load(strings.NewReader("network:\n  links:\n    - name: fiber\n    - name: fiber\n      interface: eth0\n      source_address: 10.0.0.2\n    - interface: eth1\n"))
+	require.EqualError(t, err, `network.links[fiber]: set either interface or source_address; `+
+		`network.links: duplicate link name "fiber"; network.links[fiber]: set either interface or source_address; `+
+		`network.links[2].name is required`)
+
+	_, err = Load(strings.NewReader("network:\n  links:\n    - name: lte\n      source_address: wwan0\n"))
+	require.EqualError(t, err, `network.links[lte].source_address: ParseAddr("wwan0"): unable to parse IP`)
+
+	cfg, err = Load(strings.NewReader("network:\n  links:\n    - name: fiber\n      interface: eth0\n    - name: lte\n      interface: wwan0\n  failover:\n    public_ip_url: https://api.ipify.org\n"))
+	require.NoError(t, err)
+	assert.Equal(t, FailoverConfig{IntervalSeconds: 30, PublicIPURL: "https://api.ipify.org"}, cfg.Network.Failover)
+
+	_, err = Load(strings.NewReader("network:\n  failover:\n    webhook_url: hooks.example\n"))
+	require.EqualError(t, err, `network.failover.webhook_url: requires at least two network.links; `+
+		`network.failover.webhook_url: must be an http(s) URL, got "hooks.example"`)
+}
+
+func TestLoad_Labels(t *testing.T) {
+	cfg, err := Load(strings.NewReader("labels:\n  host: mybox\n  site: cabin\n"))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"host": "mybox", "site": "cabin"}, cfg.Labels)
+
+	_, err = Load(strings.NewReader("labels:\n  site: cabin\n  link: lte\n  2g: yes\nmetrics:\n  labels:\n    site: cabin\n"))
+	require.EqualError(t, err, `labels: "2g" is not a valid label name; labels: "link" is reserved for per-result labels; `+
+		`labels: "site" is also set in metrics.labels`)
+}
+
+func TestLoad_LoggingOTLP(t *testing.T) {
+	cfg, err := Load(strings.NewReader("logging:\n  otlp:\n    endpoint: http://collector:4318\n    headers:\n      Authorization: Bearer secret\n"))
+	require.NoError(t, err)
+	assert.Equal(t, logger.OTLPConfig{
+		Endpoint: "http://collector:4318",
+		Headers:  map[string]string{"Authorization": "Bearer secret"},
+	}, cfg.Logging.OTLP)
+	assert.Equal(t, map[string]string{"Authorization": "***"}, cfg.Redacted().Logging.OTLP.Headers)
+	assert.Equal(t, "Bearer secret", cfg.Logging.OTLP.Headers["Authorization"])
+
+	_, err = Load(strings.NewReader("logging:\n  otlp:\n    endpoint: collector:4318\n"))
+	require.EqualError(t, err, `logging.otlp.endpoint: must be an http(s) URL, got "collector:4318"`)
+}
+
+func TestLoad_Tracing(t *testing.T) {
+	cfg, err := Load(strings.NewReader("tracing:\n  endpoint: http://collector:4318\n  headers:\n    Authorization: Bearer secret\n  service_name: cabin\n"))
+	require.NoError(t, err)
+	assert.Equal(t, TracingConfig{
+		Endpoint:    "http://collector:4318",
+		Headers:     map[string]string{"Authorization": "Bearer secret"},
+		ServiceName: "cabin",
+	}, cfg.Tracing)
+	assert.Equal(t, map[string]string{"Authorization": "***"}, cfg.Redacted().Tracing.Headers)
+
+	_, err = Load(strings.NewReader("tracing:\n  endpoint: collector:4318\n"))
+	require.EqualError(t, err, `tracing.endpoint: must be an http(s) URL, got "collector:4318"`)
+}
+
+func TestLoad_Plan(t *testing.T) {
+	cfg, err := Load(strings.NewReader("plan:\n  provider: Example ISP\n  download_mbps: 500\n  upload_mbps: 50\n"))
+	require.NoError(t, err)
+	assert.Equal(t, PlanConfig{Provider: "Example ISP", DownloadMbps: 500, UploadMbps: 50, MinimumPercent: 80, WindowDays: 30}, cfg.Plan)
+
+	tests := []struct {
+		yaml string
+		err  string
+	}{
+		{"plan:\n  download_mbps: -500\n", "plan: download_mbps and upload_mbps must not be negative"},
+		{"plan:\n  download_mbps: 500\n  minimum_percent: 120\n", "plan.minimum_percent: must be between 0 and 100, got 120"},
+		{"plan:\n  download_mbps: 500\n  window_days: -1\n", "plan.window_days: must not be negative"},
+	}
+	for _, tt := range tests {
+		_, err := Load(strings.NewReader(tt.yaml))
+		assert.EqualError(t, err, tt.err, tt.yaml)
+	}
+}
+
+func TestLoad_Stats(t *testing.T) {
+	cfg, err := Load(strings.NewReader("stats:\n  window_minutes: [15, 60]\n"))
+	require.NoError(t, err)
+	assert.Equal(t, []time.Duration{15 * time.Minute, time.Hour}, cfg.Stats.Windows())
+
+	_, err = Load(strings.NewReader("stats:\n  window_minutes: [0, 50000]\n"))
+	assert.EqualError(t, err, "stats.window_minutes: must be between 1 and 44640, got 0; stats.window_minutes: must be between 1 and 44640, got 50000")
+}
+
+func TestLoad_DataBudget(t *testing.T) {
+	cfg, err := Load(strings.NewReader("network:\n  speedtest:\n    data_budget:\n      monthly_mb: 20000\n      reset_day: 15\n"))
+	require.NoError(t, err)
+	assert.Equal(t, DataBudgetConfig{MonthlyMB: 20000, ResetDay: 15, ReduceAtPercent: 80}, cfg.Network.SpeedTest.DataBudget)
+
+	tests := []struct {
+		yaml string
+		err  string
+	}{
+		{"network:\n  speedtest:\n    data_budget:\n      monthly_mb: -1\n", "network.speedtest.data_budget.monthly_mb: must not be negative"},
+		{"network:\n  speedtest:\n    data_budget:\n      reset_day: 31\n", "network.speedtest.data_budget.reset_day: must be between 1 and 28, got 31"},
+		{"network:\n  speedtest:\n    data_budget:\n      reduce_at_percent: 120\n", "network.speedtest.data_budget.reduce_at_percent: must be between 0 and 100, got 120"},
+	}
+	for _, tt := range tests {
+		_, err := Load(strings.NewReader(tt.yaml))
+		assert.EqualError(t, err, tt.err, tt.yaml)
+	}
+}
+
+func TestLoad_Backends(t *testing.T) {
+	cfg, err := Load(strings.NewReader("network:\n  speedtest:\n    compare_backend: librespeed\n    librespeed:\n      server: https://librespeed.example/backend/\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "ookla", cfg.Network.SpeedTest.Backend)
+	assert.Equal(t, "librespeed", cfg.Network.SpeedTest.CompareBackend)
+	assert.Equal(t, []string{"server", "latitude", "longitude", "backend"}, cfg.Metrics.Prometheus.Labels)
+
+	tests := []struct {
+		yaml string
+		err  string
+	}{
+		{"network:\n  speedtest:\n    backend: fast\n", `network.speedtest.backend must be 'ookla' or 'librespeed', got "fast"`},
+		{"network:\n  speedtest:\n    compare_backend: librespeed\n", "network.speedtest.librespeed.server is required by the librespeed compare_backend"},
+		{"network:\n  speedtest:\n    compare_backend: ookla\n", `network.speedtest.compare_backend: must differ from the backend "ookla"`},
+		{"network:\n  speedtest:\n    backend: librespeed\n    librespeed:\n      server: librespeed.example\n", `network.speedtest.librespeed.server: must be an http(s) URL, got "librespeed.example"`},
+		{"network:\n  speedtest:\n    compare_backend: librespeed\n    librespeed:\n      server: https://librespeed.example/\nmetrics:\n  influxdb:\n    tags:\n      backend: a\n",
+			`metrics.influxdb.tags: "backend" is reserved for the backend tag of network.speedtest.compare_backend`},
+	}
+	for _, tt := range tests {
+		_, err := Load(strings.NewReader(tt.yaml))
+		assert.EqualError(t, err, tt.err, tt.yaml)
+	}
+}
+
+func TestLoad_Reports(t *testing.T) {
+	t.Setenv("YANM_TEST_SMTP_PASSWORD", "secret")
+	cfg, err := Load(strings.NewReader(`reports:
+  period: daily
+  send_at: "07:30"
+  email:
+    smtp_address: smtp.example.com:587
+    username: yanm
+    password_env: YANM_TEST_SMTP_PASSWORD
+    from: yanm@example.com
+    to: [me@example.com]
+`))
+	require.NoError(t, err)
+	assert.True(t, cfg.Reports.Enabled())
+	assert.Equal(t, ReportsConfig{
+		Period:  "daily",
+		Weekday: "monday",
+		SendAt:  "07:30",
+		Email: ReportsEmailConfig{
+			SMTPAddress: "smtp.example.com:587",
+			Username:    "yanm",
+			Password:    "secret",
+			PasswordEnv: "YANM_TEST_SMTP_PASSWORD",
+			From:        "yanm@example.com",
+			To:          []string{"me@example.com"},
+		},
+	}, cfg.Reports)
+	assert.Equal(t, "***", cfg.Redacted().Reports.Email.Password)
+
+	tests := []struct {
+		yaml string
+		err  string
+	}{
+		{"reports:\n  period: monthly\n", `reports.period: must be daily or weekly, got "monthly"`},
+		{"reports:\n  weekday: someday\n", `reports.weekday: unknown day "someday"`},
+		{"reports:\n  send_at: 8am\n", `reports.send_at: must be a time of day as HH:MM, got "8am"`},
+		{"reports:\n  email:\n    smtp_address: smtp.example.com:587\n", "reports.email: from and to are required to send emails"},
+		{"reports:\n  webhook_url: example.com/hook\n", `reports.webhook_url: must be an http(s) URL, got "example.com/hook"`},
+	}
+	for _, tt := range tests {
+		_, err := Load(strings.NewReader(tt.yaml))
+		assert.EqualError(t, err, tt.err, tt.yaml)
+	}
+}
+
+func TestLoad_LoggingOutputs(t *testing.T) {
+	cfg, err := Load(strings.NewReader("logging:\n  outputs: [stdout, file]\n  output_file: /var/log/yanm/yanm.log\n"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"stdout", "file"}, cfg.Logging.Outputs)
+	assert.Empty(t, cfg.Logging.Output)
+
+	tests := []struct {
+		yaml string
+		err  string
+	}{
+		{"logging:\n  outputs: [stdout, file]\n", "logging.outputs: file requires output_file to be a file"},
+		{"logging:\n  outputs: [stdout, kafka]\n", `logging.outputs: must be stdout, stderr, file, syslog, journald or eventlog, got "kafka"`},
+		{"logging:\n  outputs: [stdout, stdout]\n", "logging.outputs: stdout is listed more than once"},
+		{"logging:\n  output: syslog\n  outputs: [stdout]\n", "logging.outputs: replaces logging.output, set only one"},
+		{"logging:\n  outputs: [stdout, syslog]\n  syslog:\n    facility: local9\n", `logging.syslog.facility: unknown facility "local9"`},
+	}
+	for _, tt := range tests {
+		_, err := Load(strings.NewReader(tt.yaml))
+		assert.EqualError(t, err, tt.err, tt.yaml)
+	}
+}
+
+func TestLoad_LoggingRotation(t *testing.T) {
+	cfg, err := Load(strings.NewReader("logging:\n  output_file: /var/log/yanm/yanm.log\n  rotation:\n    max_size_mb: 10\n    max_backups: 5\n    max_age_days: 30\n    compress: true\n"))
+	require.NoError(t, err)
+	assert.Equal(t, logger.RotationConfig{MaxSizeMB: 10, MaxBackups: 5, MaxAgeDays: 30, Compress: true}, cfg.Logging.Rotation)
+
+	_, err = Load(strings.NewReader("logging:\n  rotation:\n    max_size_mb: 10\n"))
+	require.EqualError(t, err, "logging.rotation: requires output_file to be a file")
+
+	_, err = Load(strings.NewReader("logging:\n  output_file: yanm.log\n  rotation:\n    max_backups: -1\n"))
+	require.EqualError(t, err, "logging.rotation: max_size_mb, max_backups and max_age_days must not be negative")
+}
+
+func TestLoad_DebugAccessLog(t *testing.T) {
+	cfg, err := Load(strings.NewReader("debug_server:\n  access_log:\n    enabled: true\n    level: debug\n"))
+	require.NoError(t, err)
+	assert.Equal(t, DebugAccessLogConfig{Enabled: true, Level: "debug"}, cfg.DebugServer.AccessLog)
+
+	_, err = Load(strings.NewReader("debug_server:\n  access_log:\n    level: loud\n"))
+	require.ErrorContains(t, err, "debug_server.access_log.level")
+}
+
+func TestLoad_DebugUnixSocket(t *testing.T) {
+	cfg, err := Load(strings.NewReader("debug_server:\n  listen_address: unix:///run/yanm/debug.sock\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "unix:///run/yanm/debug.sock", cfg.DebugServer.ListenAddress)
+
+	_, err = Load(strings.NewReader("debug_server:\n  listen_address: unix://\n"))
+	require.EqualError(t, err, "debug_server.listen_address: unix:// requires a socket path")
+}
+
+func errorMessages(errs []error) []string {
+	msgs := make([]string, 0, len(errs))
+	for _, err := range errs {
+		msgs = append(msgs, err.Error())
+	}
+	return msgs
+}
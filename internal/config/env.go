@@ -0,0 +1,119 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// EnvPrefix prefixes every environment variable that overrides a config field.
+const EnvPrefix = "YANM"
+
+// applyEnvOverrides sets fields from environment variables, named after their yaml path.
+// Section names are joined without their underscores so the field name stays unambiguous:
+// network.ping_test.interval_seconds is set by YANM_NETWORK_PINGTEST_INTERVAL_SECONDS.
+//
+// Lists are comma separated and maps are comma separated key=value pairs.
+func (c *Configuration) applyEnvOverrides(lookup func(string) (string, bool)) error {
+	return applyEnv(reflect.ValueOf(c).Elem(), EnvPrefix, lookup)
+}
+
+func applyEnv(v reflect.Value, prefix string, lookup func(string) (string, bool)) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		key, ok := yamlKey(field)
+		if !ok {
+			continue
+		}
+
+		fv := v.Field(i)
+		if fv.Kind() == reflect.Struct {
+			if err := applyEnv(fv, prefix+"_"+strings.ToUpper(strings.ReplaceAll(key, "_", "")), lookup); err != nil {
+				return err
+			}
+			continue
+		}
+
+		name := prefix + "_" + strings.ToUpper(key)
+		raw, ok := lookup(name)
+		if !ok {
+			continue
+		}
+		if err := setFromString(fv, raw); err != nil {
+			return fmt.Errorf("invalid value for %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// yamlKey returns the yaml key of an exported field, false if it is not serialized.
+func yamlKey(field reflect.StructField) (string, bool) {
+	if !field.IsExported() {
+		return "", false
+	}
+	key, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+	if key == "-" {
+		return "", false
+	}
+	if key == "" {
+		key = strings.ToLower(field.Name)
+	}
+	return key, true
+}
+
+func setFromString(v reflect.Value, raw string) error {
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(raw, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported list type %s", v.Type())
+		}
+		items := []string{}
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		v.Set(reflect.ValueOf(items))
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String || v.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("unsupported map type %s", v.Type())
+		}
+		m := map[string]string{}
+		for _, pair := range strings.Split(raw, ",") {
+			if pair = strings.TrimSpace(pair); pair == "" {
+				continue
+			}
+			k, val, ok := strings.Cut(pair, "=")
+			if !ok {
+				return fmt.Errorf("expected key=value, got %q", pair)
+			}
+			m[strings.TrimSpace(k)] = strings.TrimSpace(val)
+		}
+		v.Set(reflect.ValueOf(m))
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package config
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad_EnvOverrides(t *testing.T) {
+	t.Setenv("YANM_METRICS_ENGINE", "no-op")
+	t.Setenv("YANM_NETWORK_PINGTEST_INTERVAL_SECONDS", "30")
+	t.Setenv("YANM_NETWORK_PINGTEST_THRESHOLD_SECONDS", "2.5")
+	t.Setenv("YANM_DEBUGSERVER_DISABLED", "true")
+	t.Setenv("YANM_LOGGING_LEVEL", "debug")
+	t.Setenv("YANM_METRICS_PROMETHEUS_LABELS", "server, latitude")
+	t.Setenv("YANM_METRICS_LABELS", "host=mybox,site=cabin")
+	t.Setenv("YANM_METRICS_INFLUXDB_TLS_CA_FILE", "/etc/ca.pem")
+
+	cfg, err := Load(strings.NewReader(`
+metrics:
+  engine: prometheus
+network:
+  ping_test:
+    interval_seconds: 5
+`))
+	require.NoError(t, err)
+
+	assert.Equal(t, "no-op", cfg.Metrics.Engine)
+	assert.Equal(t, 30, cfg.Network.PingTest.IntervalSeconds)
+	assert.Equal(t, 2.5, cfg.Network.PingTest.ThresholdSeconds)
+	assert.True(t, cfg.DebugServer.Disabled)
+	assert.Equal(t, "debug", cfg.Logging.Level)
+	assert.Equal(t, []string{"server", "latitude"}, cfg.Metrics.Prometheus.Labels)
+	assert.Equal(t, map[string]string{"host": "mybox", "site": "cabin"}, cfg.Metrics.Labels)
+	assert.Equal(t, "/etc/ca.pem", cfg.Metrics.InfluxDB.TLS.CAFile)
+}
+
+func TestLoad_EnvOverrideErrors(t *testing.T) {
+	testCases := []struct {
+		name         string
+		key, value   string
+		errorMessage string
+	}{
+		{
+			name:         "invalid int",
+			key:          "YANM_NETWORK_SPEEDTEST_INTERVAL_MINUTES",
+			value:        "often",
+			errorMessage: `invalid value for YANM_NETWORK_SPEEDTEST_INTERVAL_MINUTES: strconv.ParseInt: parsing "often": invalid syntax`,
+		},
+		{
+			name:         "invalid map",
+			key:          "YANM_METRICS_INFLUXDB_TAGS",
+			value:        "host",
+			errorMessage: `invalid value for YANM_METRICS_INFLUXDB_TAGS: expected key=value, got "host"`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv(tc.key, tc.value)
+
+			_, err := Load(strings.NewReader(""))
+			require.EqualError(t, err, tc.errorMessage)
+		})
+	}
+}
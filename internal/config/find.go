@@ -0,0 +1,47 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// PathEnv names the configuration file, directory or URL when none is given explicitly.
+const PathEnv = EnvPrefix + "_CONFIG"
+
+// SearchPaths returns the locations Find looks for a configuration file in, in order:
+// the working directory, the user's XDG configuration directory and /etc/yanm.
+func SearchPaths() []string {
+	paths := []string{"config.yml"}
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if configHome == "" {
+		if home, err := os.UserHomeDir(); err == nil {
+			configHome = filepath.Join(home, ".config")
+		}
+	}
+	if configHome != "" {
+		paths = append(paths, filepath.Join(configHome, "yanm", "config.yml"))
+	}
+	return append(paths, "/etc/yanm/config.yml")
+}
+
+// Find returns the configuration named by YANM_CONFIG, or else the first of SearchPaths
+// that exists. It returns an empty path when there is none, which LoadFile loads as the
+// defaults and environment overrides alone.
+func Find() (string, error) {
+	if path := os.Getenv(PathEnv); path != "" {
+		return path, nil
+	}
+	for _, path := range SearchPaths() {
+		_, err := os.Stat(path)
+		if err == nil {
+			return path, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return "", fmt.Errorf("failed to look for config file: %w", err)
+		}
+	}
+	return "", nil
+}
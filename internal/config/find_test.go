@@ -0,0 +1,36 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFind(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(dir, "xdg"))
+	t.Setenv(PathEnv, "")
+
+	assert.Equal(t, []string{"config.yml", filepath.Join(dir, "xdg", "yanm", "config.yml"), "/etc/yanm/config.yml"}, SearchPaths())
+
+	// the XDG file is found when there is none in the working directory.
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "xdg", "yanm"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "xdg", "yanm", "config.yml"), nil, 0o644))
+	path, err := Find()
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "xdg", "yanm", "config.yml"), path)
+
+	require.NoError(t, os.WriteFile("config.yml", nil, 0o644))
+	path, err = Find()
+	require.NoError(t, err)
+	assert.Equal(t, "config.yml", path)
+
+	t.Setenv(PathEnv, "https://config.example.com/yanm.yml")
+	path, err = Find()
+	require.NoError(t, err)
+	assert.Equal(t, "https://config.example.com/yanm.yml", path)
+}
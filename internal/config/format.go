@@ -0,0 +1,120 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Format identifies the encoding of a configuration file.
+type Format string
+
+const (
+	// FormatAuto detects the format from the content of the file.
+	FormatAuto Format = ""
+	FormatYAML Format = "yaml"
+	FormatJSON Format = "json"
+	FormatTOML Format = "toml"
+)
+
+// formatFromPath returns the format implied by the file extension, FormatAuto if unknown.
+func formatFromPath(path string) Format {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yml", ".yaml":
+		return FormatYAML
+	case ".json":
+		return FormatJSON
+	case ".toml":
+		return FormatTOML
+	default:
+		return FormatAuto
+	}
+}
+
+// _tomlLineRE matches a TOML table header or a `key = value` pair, neither of which is valid YAML.
+var _tomlLineRE = regexp.MustCompile(`^(\[[A-Za-z0-9_.\-" ]+\]|[A-Za-z0-9_\-"]+\s*=)`)
+
+// sniffFormat guesses the format from the first meaningful line of data, defaulting to YAML.
+func sniffFormat(data []byte) Format {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "{") {
+			return FormatJSON
+		}
+		if _tomlLineRE.MatchString(line) {
+			return FormatTOML
+		}
+		return FormatYAML
+	}
+	return FormatYAML
+}
+
+// unmarshal decodes data in the given format into c. Every format is decoded through
+// the yaml struct tags, so field names are identical across formats. In strict mode
+// keys that do not match a field are an error rather than silently ignored.
+func unmarshal(data []byte, format Format, strict bool, c *Configuration) error {
+	if format == FormatAuto {
+		format = sniffFormat(data)
+	}
+
+	switch format {
+	case FormatYAML, FormatJSON:
+		// JSON is a subset of YAML.
+		return decodeYAML(data, strict, c)
+	case FormatTOML:
+		var raw map[string]any
+		if err := toml.Unmarshal(data, &raw); err != nil {
+			return err
+		}
+		normalized, err := yaml.Marshal(raw)
+		if err != nil {
+			return err
+		}
+		return decodeYAML(normalized, strict, c)
+	default:
+		return fmt.Errorf("unsupported config format %q", format)
+	}
+}
+
+// unmarshalMap decodes data in the given format into a generic map, so layered files can be merged.
+func unmarshalMap(data []byte, format Format) (map[string]any, error) {
+	if format == FormatAuto {
+		format = sniffFormat(data)
+	}
+
+	raw := map[string]any{}
+	switch format {
+	case FormatYAML, FormatJSON:
+		if err := yaml.Unmarshal(data, &raw); err != nil {
+			return nil, err
+		}
+	case FormatTOML:
+		if err := toml.Unmarshal(data, &raw); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported config format %q", format)
+	}
+	return raw, nil
+}
+
+func decodeYAML(data []byte, strict bool, c *Configuration) error {
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(strict)
+	if err := decoder.Decode(c); err != nil && !errors.Is(err, io.EOF) { // empty documents are valid
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	_yamlConfig = `
+# comment
+metrics:
+  engine: no-op
+  labels:
+    site: cabin
+network:
+  ping_test:
+    interval_seconds: 30
+`
+	_jsonConfig = `{
+  "metrics": {"engine": "no-op", "labels": {"site": "cabin"}},
+  "network": {"ping_test": {"interval_seconds": 30}}
+}`
+	_tomlConfig = `
+# comment
+[metrics]
+engine = "no-op"
+
+[metrics.labels]
+site = "cabin"
+
+[network.ping_test]
+interval_seconds = 30
+`
+)
+
+func TestLoad_Formats(t *testing.T) {
+	testCases := []struct {
+		name    string
+		content string
+		file    string
+		format  Format
+	}{
+		{name: "yaml sniffed", content: _yamlConfig, format: FormatYAML},
+		{name: "json sniffed", content: _jsonConfig, format: FormatJSON},
+		{name: "toml sniffed", content: _tomlConfig, format: FormatTOML},
+		{name: "yaml by extension", content: _yamlConfig, file: "config.yaml"},
+		{name: "json by extension", content: _jsonConfig, file: "config.json"},
+		{name: "toml by extension", content: _tomlConfig, file: "config.toml"},
+		{name: "toml with unknown extension", content: _tomlConfig, file: "config.conf"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var (
+				cfg *Configuration
+				err error
+			)
+			if tc.file != "" {
+				path := filepath.Join(t.TempDir(), tc.file)
+				require.NoError(t, os.WriteFile(path, []byte(tc.content), 0644))
+				cfg, err = LoadFile(path)
+			} else {
+				assert.Equal(t, tc.format, sniffFormat([]byte(tc.content)))
+				cfg, err = Load(strings.NewReader(tc.content))
+			}
+			require.NoError(t, err)
+
+			assert.Equal(t, "no-op", cfg.Metrics.Engine)
+			assert.Equal(t, map[string]string{"site": "cabin"}, cfg.Metrics.Labels)
+			assert.Equal(t, 30, cfg.Network.PingTest.IntervalSeconds)
+		})
+	}
+}
+
+func TestLoadFormat_TOMLError(t *testing.T) {
+	_, err := LoadFormat(strings.NewReader("[metrics\nengine = "), FormatTOML)
+	require.ErrorContains(t, err, "failed to parse config data: toml:")
+}
+
+func TestLoadFormat_Strict(t *testing.T) {
+	testCases := []struct {
+		name    string
+		content string
+		format  Format
+	}{
+		{name: "yaml", content: "network:\n  ping_test:\n    interval_secondss: 30\n", format: FormatYAML},
+		{name: "json", content: `{"network": {"ping_test": {"interval_secondss": 30}}}`, format: FormatJSON},
+		{name: "toml", content: "[network.ping_test]\ninterval_secondss = 30\n", format: FormatTOML},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := LoadFormat(strings.NewReader(tc.content), tc.format)
+			require.ErrorContains(t, err, "field interval_secondss not found")
+
+			cfg, err := LoadFormat(strings.NewReader(tc.content), tc.format, WithStrict(false))
+			require.NoError(t, err)
+			assert.Equal(t, 2, cfg.Network.PingTest.IntervalSeconds) // the typo falls back to the default.
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+const _includeKey = "include"
+
+// loadLayers reads the file or directory at path, merged on top of everything it includes.
+// stack holds the files currently being loaded, to detect include cycles.
+func loadLayers(path string, stack []string) (map[string]any, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %v", err)
+	}
+	if info.IsDir() {
+		return loadDir(path, stack)
+	}
+
+	if slices.Contains(stack, path) {
+		return nil, fmt.Errorf("config include cycle: %s -> %s", strings.Join(stack, " -> "), path)
+	}
+	stack = append(stack, path)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %v", err)
+	}
+	layer, err := unmarshalMap(data, formatFromPath(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	includes, err := includePaths(layer[_includeKey])
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	delete(layer, _includeKey)
+
+	merged := map[string]any{}
+	for _, include := range includes {
+		if !filepath.IsAbs(include) {
+			include = filepath.Join(filepath.Dir(path), include)
+		}
+		base, err := loadLayers(include, stack)
+		if err != nil {
+			return nil, err
+		}
+		merged = mergeMaps(merged, base)
+	}
+	return mergeMaps(merged, layer), nil
+}
+
+// loadDir merges every configuration file in dir in lexical order.
+func loadDir(dir string, stack []string) (map[string]any, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config directory: %v", err)
+	}
+
+	merged := map[string]any{}
+	for _, entry := range entries { // ReadDir sorts by file name.
+		if entry.IsDir() || formatFromPath(entry.Name()) == FormatAuto {
+			continue
+		}
+		layer, err := loadLayers(filepath.Join(dir, entry.Name()), stack)
+		if err != nil {
+			return nil, err
+		}
+		merged = mergeMaps(merged, layer)
+	}
+	return merged, nil
+}
+
+func includePaths(v any) ([]string, error) {
+	switch v := v.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []string{v}, nil
+	case []any:
+		paths := make([]string, 0, len(v))
+		for _, p := range v {
+			s, ok := p.(string)
+			if !ok {
+				return nil, fmt.Errorf("include must be a list of paths, got %v", p)
+			}
+			paths = append(paths, s)
+		}
+		return paths, nil
+	default:
+		return nil, fmt.Errorf("include must be a list of paths, got %v", v)
+	}
+}
+
+// mergeMaps merges override into base, recursing into nested maps. Any other value,
+// including lists, is replaced by the one in override.
+func mergeMaps(base, override map[string]any) map[string]any {
+	for k, v := range override {
+		baseMap, baseOK := base[k].(map[string]any)
+		overrideMap, overrideOK := v.(map[string]any)
+		if baseOK && overrideOK {
+			base[k] = mergeMaps(baseMap, overrideMap)
+			continue
+		}
+		base[k] = v
+	}
+	return base
+}
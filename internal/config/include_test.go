@@ -0,0 +1,108 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+}
+
+func TestLoadFile_Include(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"base.yml": `
+metrics:
+  engine: influxdb
+  labels:
+    fleet: home
+  influxdb:
+    url: http://influx:8086
+    token: my-token
+network:
+  ping_test:
+    interval_seconds: 10
+`,
+		"site/overrides.toml": `
+[metrics.labels]
+site = "cabin"
+`,
+		"config.yml": `
+include: [base.yml, site/overrides.toml]
+network:
+  ping_test:
+    interval_seconds: 30
+`,
+	})
+
+	cfg, err := LoadFile(filepath.Join(dir, "config.yml"))
+	require.NoError(t, err)
+
+	assert.Equal(t, "influxdb", cfg.Metrics.Engine)
+	assert.Equal(t, "http://influx:8086", cfg.Metrics.InfluxDB.URL)
+	assert.Equal(t, map[string]string{"fleet": "home", "site": "cabin"}, cfg.Metrics.Labels)
+	assert.Equal(t, 30, cfg.Network.PingTest.IntervalSeconds)
+	assert.Empty(t, cfg.Include)
+}
+
+func TestLoadFile_Directory(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"10-base.yml":   "metrics:\n  engine: no-op\nnetwork:\n  ping_test:\n    interval_seconds: 10\n",
+		"20-site.json":  `{"network": {"ping_test": {"interval_seconds": 20}}}`,
+		"README.md":     "not a config file",
+		"nested/99.yml": "metrics:\n  engine: prometheus\n",
+	})
+
+	cfg, err := LoadFile(dir)
+	require.NoError(t, err)
+
+	assert.Equal(t, "no-op", cfg.Metrics.Engine)
+	assert.Equal(t, 20, cfg.Network.PingTest.IntervalSeconds)
+}
+
+func TestLoadFile_IncludeErrors(t *testing.T) {
+	testCases := []struct {
+		name        string
+		files       map[string]string
+		expectError string
+	}{
+		{
+			name: "cycle",
+			files: map[string]string{
+				"config.yml": "include: [a.yml]\n",
+				"a.yml":      "include: [config.yml]\n",
+			},
+			expectError: "config include cycle",
+		},
+		{
+			name:        "missing include",
+			files:       map[string]string{"config.yml": "include: [missing.yml]\n"},
+			expectError: "failed to read config file",
+		},
+		{
+			name:        "unknown key in include",
+			files:       map[string]string{"config.yml": "include: [a.yml]\n", "a.yml": "metrics:\n  engin: no-op\n"},
+			expectError: "field engin not found",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFiles(t, dir, tc.files)
+
+			_, err := LoadFile(filepath.Join(dir, "config.yml"))
+			require.ErrorContains(t, err, tc.expectError)
+		})
+	}
+}
@@ -0,0 +1,33 @@
+package config
+
+import (
+	_ "embed"
+	"io"
+	"text/template"
+)
+
+//go:embed default.yml.tmpl
+var _defaultTemplate string
+
+var _defaultTmpl = template.Must(template.New("config").Parse(_defaultTemplate))
+
+// InitOptions are the settings asked for when generating a new configuration file.
+type InitOptions struct {
+	Engine                   string
+	PingIntervalSeconds      int
+	SpeedTestIntervalMinutes int
+}
+
+// DefaultInitOptions returns the InitOptions matching the defaults applied on load.
+func DefaultInitOptions() InitOptions {
+	return InitOptions{
+		Engine:                   "prometheus",
+		PingIntervalSeconds:      2,
+		SpeedTestIntervalMinutes: 720,
+	}
+}
+
+// WriteDefault writes a fully commented configuration file using opts.
+func WriteDefault(w io.Writer, opts InitOptions) error {
+	return _defaultTmpl.Execute(w, opts)
+}
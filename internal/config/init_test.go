@@ -0,0 +1,38 @@
+package config
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteDefault(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteDefault(&buf, DefaultInitOptions()))
+
+	cfg, err := Load(&buf)
+	require.NoError(t, err)
+
+	defaults, err := Load(strings.NewReader(""))
+	require.NoError(t, err)
+	assert.Equal(t, defaults, cfg)
+}
+
+func TestWriteDefault_Options(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteDefault(&buf, InitOptions{
+		Engine:                   "influxdb",
+		PingIntervalSeconds:      30,
+		SpeedTestIntervalMinutes: 60,
+	}))
+
+	cfg, err := Load(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, "influxdb", cfg.Metrics.Engine)
+	assert.Equal(t, "http://localhost:8086", cfg.Metrics.InfluxDB.URL)
+	assert.Equal(t, 30, cfg.Network.PingTest.IntervalSeconds)
+	assert.Equal(t, 60, cfg.Network.SpeedTest.IntervalMinutes)
+}
@@ -0,0 +1,71 @@
+package config
+
+import (
+	"net/http"
+	"os"
+)
+
+type loadOptions struct {
+	strict  bool
+	profile string
+	header  http.Header
+}
+
+// LoadOption configures how a configuration is loaded.
+type LoadOption interface {
+	apply(*loadOptions)
+}
+
+type strictOption struct {
+	strict bool
+}
+
+func (o *strictOption) apply(opts *loadOptions) {
+	opts.strict = o.strict
+}
+
+// WithStrict controls whether unknown keys fail loading, so typos like
+// interval_secondss are caught instead of silently using defaults. Strict is the default.
+func WithStrict(strict bool) LoadOption {
+	return &strictOption{strict}
+}
+
+type profileOption struct {
+	profile string
+}
+
+func (o *profileOption) apply(opts *loadOptions) {
+	opts.profile = o.profile
+}
+
+// WithProfile merges the named entry of the profiles map over the rest of the
+// configuration. Without it the profile is read from YANM_PROFILE.
+func WithProfile(profile string) LoadOption {
+	return &profileOption{profile}
+}
+
+type headerOption struct {
+	name, value string
+}
+
+func (o *headerOption) apply(opts *loadOptions) {
+	opts.header.Add(o.name, o.value)
+}
+
+// WithHeader adds a header to the request fetching a remote configuration,
+// e.g. an Authorization header.
+func WithHeader(name, value string) LoadOption {
+	return &headerOption{name, value}
+}
+
+func newLoadOptions(opts []LoadOption) *loadOptions {
+	opt := &loadOptions{
+		strict:  true,
+		profile: os.Getenv(ProfileEnv),
+		header:  http.Header{},
+	}
+	for _, o := range opts {
+		o.apply(opt)
+	}
+	return opt
+}
@@ -0,0 +1,72 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	_profilesKey = "profiles"
+
+	// ProfileEnv selects the profile when none is given explicitly.
+	ProfileEnv = EnvPrefix + "_PROFILE"
+)
+
+// applyProfile merges the selected profile from the top-level profiles map over the rest
+// of the configuration. Data without profiles is returned untouched, keeping line numbers
+// in decode errors accurate. Every profile is checked for unknown keys in strict mode,
+// not just the selected one.
+func applyProfile(data []byte, format Format, profile string, strict bool) ([]byte, Format, error) {
+	raw, err := unmarshalMap(data, format)
+	if err != nil {
+		return nil, format, err
+	}
+
+	profiles, ok := raw[_profilesKey]
+	if !ok {
+		if profile != "" {
+			return nil, format, fmt.Errorf("profile %q selected but no profiles are defined", profile)
+		}
+		return data, format, nil
+	}
+	delete(raw, _profilesKey)
+
+	byName, ok := profiles.(map[string]any)
+	if !ok {
+		return nil, format, fmt.Errorf("profiles must be a map of profile name to settings")
+	}
+	for name, settings := range byName {
+		if _, ok := settings.(map[string]any); !ok {
+			return nil, format, fmt.Errorf("profiles.%s must be a map of settings", name)
+		}
+		out, err := yaml.Marshal(settings)
+		if err != nil {
+			return nil, format, err
+		}
+		if err := decodeYAML(out, strict, &Configuration{}); err != nil {
+			return nil, format, fmt.Errorf("profiles.%s: %w", name, err)
+		}
+	}
+
+	if profile != "" {
+		settings, ok := byName[profile]
+		if !ok {
+			names := make([]string, 0, len(byName))
+			for name := range byName {
+				names = append(names, name)
+			}
+			slices.Sort(names)
+			return nil, format, fmt.Errorf("unknown profile %q, must be one of %s", profile, strings.Join(names, ", "))
+		}
+		raw = mergeMaps(raw, settings.(map[string]any))
+	}
+
+	out, err := yaml.Marshal(raw)
+	if err != nil {
+		return nil, format, err
+	}
+	return out, FormatYAML, nil
+}
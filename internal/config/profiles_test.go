@@ -0,0 +1,88 @@
+package config
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const _profilesConfig = `
+metrics:
+  engine: prometheus
+  labels:
+    owner: me
+network:
+  ping_test:
+    interval_seconds: 10
+profiles:
+  home:
+    metrics:
+      labels:
+        site: home
+  travel:
+    metrics:
+      engine: no-op
+    network:
+      ping_test:
+        interval_seconds: 60
+`
+
+func TestLoad_Profiles(t *testing.T) {
+	testCases := []struct {
+		name         string
+		opts         []LoadOption
+		env          string
+		expectEngine string
+		expectLabels map[string]string
+		expectPing   int
+	}{
+		{
+			name:         "no profile",
+			expectEngine: "prometheus",
+			expectLabels: map[string]string{"owner": "me"},
+			expectPing:   10,
+		},
+		{
+			name:         "home",
+			opts:         []LoadOption{WithProfile("home")},
+			expectEngine: "prometheus",
+			expectLabels: map[string]string{"owner": "me", "site": "home"},
+			expectPing:   10,
+		},
+		{
+			name:         "travel from env",
+			env:          "travel",
+			expectEngine: "no-op",
+			expectLabels: map[string]string{"owner": "me"},
+			expectPing:   60,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv(ProfileEnv, tc.env)
+
+			cfg, err := Load(strings.NewReader(_profilesConfig), tc.opts...)
+			require.NoError(t, err)
+
+			assert.Equal(t, tc.expectEngine, cfg.Metrics.Engine)
+			assert.Equal(t, tc.expectLabels, cfg.Metrics.Labels)
+			assert.Equal(t, tc.expectPing, cfg.Network.PingTest.IntervalSeconds)
+		})
+	}
+}
+
+func TestLoad_ProfileErrors(t *testing.T) {
+	_, err := Load(strings.NewReader(_profilesConfig), WithProfile("office"))
+	require.EqualError(t, err, `failed to parse config data: unknown profile "office", must be one of home, travel`)
+
+	_, err = Load(strings.NewReader("metrics:\n  engine: no-op\n"), WithProfile("home"))
+	require.EqualError(t, err, `failed to parse config data: profile "home" selected but no profiles are defined`)
+
+	// typos in profiles that are not selected are still caught.
+	_, err = Load(strings.NewReader("profiles:\n  home:\n    metrics:\n      engin: no-op\n"))
+	require.ErrorContains(t, err, "profiles.home:")
+	require.ErrorContains(t, err, "field engin not found")
+}
@@ -0,0 +1,37 @@
+package config
+
+import "reflect"
+
+// _redacted replaces the value of secret fields when the configuration is displayed.
+const _redacted = "***"
+
+// Redacted returns a copy of c with every non-empty field tagged `yanm:"secret"` replaced
+// by ***, so the configuration can be displayed without leaking credentials. The values
+// of secret string maps, such as headers, are replaced the same way.
+func (c *Configuration) Redacted() *Configuration {
+	out := *c
+	redact(reflect.ValueOf(&out).Elem())
+	return &out
+}
+
+func redact(v reflect.Value) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		fv := v.Field(i)
+		switch {
+		case t.Field(i).Tag.Get("yanm") == "secret" && fv.Kind() == reflect.String:
+			if fv.String() != "" {
+				fv.SetString(_redacted)
+			}
+		case t.Field(i).Tag.Get("yanm") == "secret" && fv.Kind() == reflect.Map && !fv.IsNil():
+			// the map is shared with c, so swap in a redacted copy.
+			redacted := reflect.MakeMapWithSize(fv.Type(), fv.Len())
+			for iter := fv.MapRange(); iter.Next(); {
+				redacted.SetMapIndex(iter.Key(), reflect.ValueOf(_redacted))
+			}
+			fv.Set(redacted)
+		case fv.Kind() == reflect.Struct:
+			redact(fv)
+		}
+	}
+}
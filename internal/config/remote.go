@@ -0,0 +1,46 @@
+package config
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const _remoteTimeout = 30 * time.Second
+
+// isRemote reports whether configPath is an http or https URL rather than a local path.
+func isRemote(configPath string) bool {
+	return strings.HasPrefix(configPath, "http://") || strings.HasPrefix(configPath, "https://")
+}
+
+// loadRemote fetches the configuration from configURL, sending the headers from opt.
+// The format is chosen by the extension of the URL path, or detected from the content.
+func loadRemote(configURL string, opt *loadOptions, opts []LoadOption) (*Configuration, error) {
+	u, err := url.Parse(configURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config url: %v", err)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, configURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch config: %v", err)
+	}
+	for name, values := range opt.header {
+		req.Header[name] = values
+	}
+
+	client := &http.Client{Timeout: _remoteTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch config: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch config: %s returned %s", u.Redacted(), resp.Status)
+	}
+
+	return LoadFormat(resp.Body, formatFromPath(u.Path), opts...)
+}
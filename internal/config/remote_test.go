@@ -0,0 +1,36 @@
+package config
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadFile_Remote(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/site42.json":
+			_, _ = w.Write([]byte(`{"metrics": {"engine": "no-op"}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	cfg, err := LoadFile(srv.URL+"/site42.json", WithHeader("Authorization", "Bearer secret"))
+	require.NoError(t, err)
+	assert.Equal(t, "no-op", cfg.Metrics.Engine)
+
+	_, err = LoadFile(srv.URL + "/site42.json")
+	require.EqualError(t, err, "failed to fetch config: "+srv.URL+"/site42.json returned 401 Unauthorized")
+
+	_, err = LoadFile(srv.URL+"/missing.yml", WithHeader("Authorization", "Bearer secret"))
+	require.ErrorContains(t, err, "404 Not Found")
+}
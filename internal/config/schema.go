@@ -0,0 +1,54 @@
+package config
+
+import (
+	"encoding/json"
+	"reflect"
+)
+
+const _schemaDraft = "https://json-schema.org/draft/2020-12/schema"
+
+// JSONSchema returns a JSON Schema describing the configuration file, generated from
+// the yaml keys of Configuration. Editors use it for autocomplete and validation.
+func JSONSchema() ([]byte, error) {
+	schema := typeSchema(reflect.TypeOf(Configuration{}))
+	// profiles hold partial configurations merged over the rest of the file.
+	profile := typeSchema(reflect.TypeOf(Configuration{}))
+	schema["properties"].(map[string]any)[_profilesKey] = map[string]any{
+		"type":                 "object",
+		"additionalProperties": profile,
+	}
+	schema["$schema"] = _schemaDraft
+	schema["title"] = "YANM configuration"
+	return json.MarshalIndent(schema, "", "  ")
+}
+
+func typeSchema(t reflect.Type) map[string]any {
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice:
+		return map[string]any{"type": "array", "items": typeSchema(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": typeSchema(t.Elem())}
+	case reflect.Struct:
+		properties := map[string]any{}
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			key, ok := yamlKey(field)
+			if !ok {
+				continue
+			}
+			properties[key] = typeSchema(field.Type)
+		}
+		// unknown keys are rejected when loading in strict mode.
+		return map[string]any{"type": "object", "properties": properties, "additionalProperties": false}
+	default:
+		return map[string]any{}
+	}
+}
@@ -0,0 +1,39 @@
+package config
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONSchema(t *testing.T) {
+	out, err := JSONSchema()
+	require.NoError(t, err)
+
+	var schema struct {
+		Schema     string `json:"$schema"`
+		Properties map[string]struct {
+			Type       string                     `json:"type"`
+			Properties map[string]json.RawMessage `json:"properties"`
+		} `json:"properties"`
+	}
+	require.NoError(t, json.Unmarshal(out, &schema))
+
+	assert.Equal(t, _schemaDraft, schema.Schema)
+	assert.ElementsMatch(t, []string{"include", "network", "metrics", "logging", "debug_server", "grpc", "central_server", "tracing", "plan", "reports", "stats", "labels", "profiles"}, keys(schema.Properties))
+	assert.Equal(t, "object", schema.Properties["metrics"].Type)
+	assert.JSONEq(t, `{"type": "object", "additionalProperties": {"type": "string"}}`,
+		string(schema.Properties["metrics"].Properties["labels"]))
+	assert.JSONEq(t, `{"type": "string"}`, string(schema.Properties["logging"].Properties["level"]))
+	assert.JSONEq(t, `{"type": "boolean"}`, string(schema.Properties["debug_server"].Properties["disabled"]))
+}
+
+func keys[V any](m map[string]V) []string {
+	out := make([]string, 0, len(m))
+	for k := range m {
+		out = append(out, k)
+	}
+	return out
+}
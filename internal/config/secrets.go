@@ -0,0 +1,77 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"go.uber.org/multierr"
+)
+
+// resolveSecrets replaces secrets given as a file or environment variable reference
+// with their value, so they can stay out of the configuration file.
+func (c *Configuration) resolveSecrets(lookup func(string) (string, bool)) error {
+	influx := &c.Metrics.InfluxDB
+	auth := &c.DebugServer.Auth
+	central := &c.Metrics.Central
+	email := &c.Reports.Email
+	reports := &c.Reports
+	failover := &c.Network.Failover
+	errorReporting := &c.Logging.ErrorReporting
+	secrets := []struct {
+		name             string
+		value            *string
+		file, envVarName string
+	}{
+		{"metrics.influxdb.token", &influx.Token, influx.TokenFile, influx.TokenEnv},
+		{"debug_server.auth.password", &auth.Password, auth.PasswordFile, auth.PasswordEnv},
+		{"debug_server.auth.token", &auth.Token, auth.TokenFile, auth.TokenEnv},
+		{"metrics.central.token", &central.Token, central.TokenFile, central.TokenEnv},
+		{"reports.email.password", &email.Password, email.PasswordFile, email.PasswordEnv},
+		{"reports.webhook_url", &reports.WebhookURL, reports.WebhookURLFile, reports.WebhookURLEnv},
+		{"network.failover.webhook_url", &failover.WebhookURL, failover.WebhookURLFile, failover.WebhookURLEnv},
+		{"logging.error_reporting.sentry_dsn", &errorReporting.SentryDSN, errorReporting.SentryDSNFile, errorReporting.SentryDSNEnv},
+	}
+
+	var errs error
+	for _, secret := range secrets {
+		value, err := resolveSecret(secret.name, *secret.value, secret.file, secret.envVarName, lookup)
+		if err != nil {
+			errs = multierr.Append(errs, err)
+			continue
+		}
+		*secret.value = value
+	}
+	return errs
+}
+
+// resolveSecret returns the secret set by at most one of value, file and env.
+func resolveSecret(name, value, file, env string, lookup func(string) (string, bool)) (string, error) {
+	set := 0
+	for _, v := range []string{value, file, env} {
+		if v != "" {
+			set++
+		}
+	}
+	if set > 1 {
+		return "", fmt.Errorf("%s: only one of %s, %s_file and %s_env may be set", name, name, name, name)
+	}
+
+	switch {
+	case file != "":
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return "", fmt.Errorf("%s_file: %w", name, err)
+		}
+		// secret files usually end with a newline.
+		return strings.TrimSpace(string(data)), nil
+	case env != "":
+		secret, ok := lookup(env)
+		if !ok {
+			return "", fmt.Errorf("%s_env: environment variable %s is not set", name, env)
+		}
+		return secret, nil
+	default:
+		return value, nil
+	}
+}
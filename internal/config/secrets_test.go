@@ -0,0 +1,111 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad_Secrets(t *testing.T) {
+	tokenFile := filepath.Join(t.TempDir(), "influx")
+	require.NoError(t, os.WriteFile(tokenFile, []byte("file-token\n"), 0o600))
+	t.Setenv("TEST_INFLUX_TOKEN", "env-token")
+
+	testCases := []struct {
+		name        string
+		influx      string
+		expectToken string
+		expectError string
+	}{
+		{name: "inline", influx: "token: inline-token", expectToken: "inline-token"},
+		{name: "file", influx: "token_file: " + tokenFile, expectToken: "file-token"},
+		{name: "env", influx: "token_env: TEST_INFLUX_TOKEN", expectToken: "env-token"},
+		{
+			name:        "missing file",
+			influx:      "token_file: " + filepath.Join(t.TempDir(), "missing"),
+			expectError: "metrics.influxdb.token_file: open",
+		},
+		{
+			name:        "unset env",
+			influx:      "token_env: TEST_UNSET_TOKEN",
+			expectError: "metrics.influxdb.token_env: environment variable TEST_UNSET_TOKEN is not set",
+		},
+		{
+			name:        "both",
+			influx:      "token: inline-token\n    token_env: TEST_INFLUX_TOKEN",
+			expectError: "metrics.influxdb.token: only one of",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg, err := Load(strings.NewReader("metrics:\n  influxdb:\n    " + tc.influx + "\n"))
+			if tc.expectError != "" {
+				require.ErrorContains(t, err, tc.expectError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectToken, cfg.Metrics.InfluxDB.Token)
+		})
+	}
+}
+
+func TestLoad_DebugAuthSecrets(t *testing.T) {
+	t.Setenv("TEST_DEBUG_PASSWORD", "hunter2")
+
+	cfg, err := Load(strings.NewReader(`
+debug_server:
+  auth:
+    username: admin
+    password_env: TEST_DEBUG_PASSWORD
+    token: t0ken
+`))
+	require.NoError(t, err)
+	assert.Equal(t, "hunter2", cfg.DebugServer.Auth.Password)
+	assert.Equal(t, "t0ken", cfg.DebugServer.Auth.Token)
+
+	redacted := cfg.Redacted()
+	assert.Equal(t, "***", redacted.DebugServer.Auth.Password)
+	assert.Equal(t, "***", redacted.DebugServer.Auth.Token)
+
+	_, err = Load(strings.NewReader("debug_server:\n  auth:\n    username: admin\n"))
+	require.EqualError(t, err, "debug_server.auth: username and password must be set together")
+}
+
+func TestLoad_WebhookSecrets(t *testing.T) {
+	dsnFile := filepath.Join(t.TempDir(), "sentry")
+	require.NoError(t, os.WriteFile(dsnFile, []byte("https://key@o1.ingest.sentry.io/42\n"), 0o600))
+	t.Setenv("TEST_REPORTS_WEBHOOK", "https://hooks.example.com/reports?token=secret")
+
+	cfg, err := Load(strings.NewReader(`
+network:
+  links:
+    - name: fiber
+      interface: eth0
+    - name: lte
+      interface: wwan0
+  failover:
+    webhook_url: https://hooks.example.com/failover?token=secret
+logging:
+  error_reporting:
+    sentry_dsn_file: ` + dsnFile + `
+reports:
+  webhook_url_env: TEST_REPORTS_WEBHOOK
+`))
+	require.NoError(t, err)
+	assert.Equal(t, "https://hooks.example.com/failover?token=secret", cfg.Network.Failover.WebhookURL)
+	assert.Equal(t, "https://key@o1.ingest.sentry.io/42", cfg.Logging.ErrorReporting.SentryDSN)
+	assert.Equal(t, "https://hooks.example.com/reports?token=secret", cfg.Reports.WebhookURL)
+
+	redacted := cfg.Redacted()
+	assert.Equal(t, "***", redacted.Network.Failover.WebhookURL)
+	assert.Equal(t, "***", redacted.Logging.ErrorReporting.SentryDSN)
+	assert.Equal(t, "***", redacted.Reports.WebhookURL)
+
+	_, err = Load(strings.NewReader("reports:\n  webhook_url: https://hooks.example.com/yanm\n  webhook_url_env: TEST_REPORTS_WEBHOOK\n"))
+	require.ErrorContains(t, err, "reports.webhook_url: only one of")
+}
@@ -0,0 +1,105 @@
+package config
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoad_Targets(t *testing.T) {
+	cfg, err := Load(strings.NewReader(`
+network:
+  ping_test:
+    interval_seconds: 10
+  targets:
+    - name: gateway
+      type: tcp
+      address: 192.168.1.1:80
+      interval_seconds: 5
+      threshold_seconds: 0.1
+    - name: resolver
+      type: dns
+      address: 1.1.1.1:53
+      dnssec: true
+    - name: modem
+      type: exec
+      command: [/usr/local/bin/modem-stats, --json]
+`))
+	require.NoError(t, err)
+
+	assert.Equal(t, []TargetConfig{
+		{
+			Name:             "gateway",
+			Type:             "tcp",
+			Address:          "192.168.1.1:80",
+			IntervalSeconds:  5,
+			ThresholdSeconds: 0.1,
+			TimeoutSeconds:   10,
+		},
+		{
+			Name:             "resolver",
+			Type:             "dns",
+			Address:          "1.1.1.1:53",
+			Query:            "example.com",
+			DNSSEC:           true,
+			IntervalSeconds:  10,
+			ThresholdSeconds: 5,
+			TimeoutSeconds:   10,
+		},
+		{
+			Name:            "modem",
+			Type:            "exec",
+			Command:         []string{"/usr/local/bin/modem-stats", "--json"},
+			IntervalSeconds: 10,
+			TimeoutSeconds:  10,
+		},
+	}, cfg.Network.Targets)
+}
+
+func TestLoad_TargetErrors(t *testing.T) {
+	testCases := []struct {
+		name        string
+		targets     string
+		expectError string
+	}{
+		{
+			name:        "missing name",
+			targets:     "- type: tcp\n      address: a:1",
+			expectError: "network.targets[0].name is required",
+		},
+		{
+			name:        "duplicate name",
+			targets:     "- {name: a, type: tcp, address: a:1}\n    - {name: a, type: tcp, address: b:1}",
+			expectError: `network.targets: duplicate target name "a"`,
+		},
+		{
+			name:        "unknown type",
+			targets:     "- {name: a, type: smtp, address: a:25}",
+			expectError: "network.targets[a].type must be 'ping', 'http', 'dns', 'tcp', 'exec' or 'ntp'",
+		},
+		{
+			name:        "missing address",
+			targets:     "- {name: a, type: http}",
+			expectError: "network.targets[a].address is required",
+		},
+		{
+			name:        "missing command",
+			targets:     "- {name: a, type: exec}",
+			expectError: "network.targets[a].command is required by exec targets",
+		},
+		{
+			name:        "dnssec of another type",
+			targets:     "- {name: a, type: tcp, address: a:53, dnssec: true}",
+			expectError: "network.targets[a].dnssec: only dns targets check DNSSEC",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Load(strings.NewReader("network:\n  targets:\n    " + tc.targets + "\n"))
+			require.EqualError(t, err, tc.expectError)
+		})
+	}
+}
@@ -0,0 +1,26 @@
+// Package dashboard serves a single page overview of the monitor, built on the JSON
+// views of the debug pages.
+package dashboard
+
+import (
+	_ "embed"
+	"net/http"
+)
+
+//go:embed dashboard.html
+var _dashboardHTML []byte
+
+// NewHandler returns the handler serving the dashboard page. The page fetches the
+// speedtest, monitor and storage JSON views and streams /debug/events/, so those routes
+// need to be registered on the same server. The agents of a central server are listed
+// when its /debug/central/ view is registered too.
+func NewHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write(_dashboardHTML)
+	})
+}
@@ -0,0 +1,26 @@
+package dashboard
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewHandler(t *testing.T) {
+	rr := httptest.NewRecorder()
+	NewHandler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/dashboard/", nil))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "text/html; charset=utf-8", rr.Header().Get("Content-Type"))
+	assert.Contains(t, rr.Body.String(), "YANM Dashboard")
+	assert.Contains(t, rr.Body.String(), `new EventSource("/debug/events/")`)
+}
+
+func TestNewHandler_MethodNotAllowed(t *testing.T) {
+	rr := httptest.NewRecorder()
+	NewHandler().ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/dashboard/", nil))
+
+	assert.Equal(t, http.StatusMethodNotAllowed, rr.Code)
+}
@@ -0,0 +1,55 @@
+package debughttp
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// AccessLogConfig logs every request to the debug server, including rejected ones.
+type AccessLogConfig struct {
+	Enabled bool
+	// Level is the level access log entries are written at.
+	Level slog.Level
+}
+
+// logAccess writes an access log entry for every request.
+func logAccess(cfg AccessLogConfig, logger *slog.Logger) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(recorder, r)
+
+			Logger(r.Context(), logger).Log(r.Context(), cfg.Level, "Debug HTTP access",
+				"method", r.Method,
+				"path", r.URL.Path,
+				"status", recorder.status,
+				"duration", time.Since(start),
+				"remoteAddr", r.RemoteAddr)
+		})
+	}
+}
+
+// statusRecorder captures the status code written by a handler.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Flush lets streaming handlers, like the monitor events, flush through the recorder.
+func (r *statusRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap exposes the wrapped writer to http.ResponseController.
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
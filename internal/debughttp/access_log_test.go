@@ -0,0 +1,58 @@
+package debughttp
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewServer_AccessLog(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelWarn}))
+
+	srv, err := NewServer(Config{
+		ListenAddress: ":0",
+		Auth:          AuthConfig{Token: "secret"},
+		AccessLog:     AccessLogConfig{Enabled: true, Level: slog.LevelWarn},
+	}, logger)
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodPost, "/debug/monitor/pause", nil)
+	req.RemoteAddr = "192.0.2.1:1234"
+	srv.httpServer.Handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	line := buf.String()
+	assert.Contains(t, line, "level=WARN")
+	assert.Contains(t, line, `msg="Debug HTTP access"`)
+	assert.Contains(t, line, "method=POST")
+	assert.Contains(t, line, "path=/debug/monitor/pause")
+	assert.Contains(t, line, "status=401")
+	assert.Contains(t, line, "remoteAddr=192.0.2.1:1234")
+	assert.Contains(t, line, "duration=")
+}
+
+func TestNewServer_AccessLogDisabled(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelInfo}))
+
+	srv, err := NewServer(Config{ListenAddress: ":0"}, logger)
+	require.NoError(t, err)
+
+	srv.httpServer.Handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.NotContains(t, buf.String(), "Debug HTTP access")
+}
+
+func TestStatusRecorder_Flush(t *testing.T) {
+	rr := httptest.NewRecorder()
+	var w http.ResponseWriter = &statusRecorder{ResponseWriter: rr, status: http.StatusOK}
+
+	flusher, ok := w.(http.Flusher)
+	require.True(t, ok, "streaming handlers need the recorder to be a Flusher")
+	flusher.Flush()
+	assert.True(t, rr.Flushed)
+}
@@ -0,0 +1,41 @@
+package debughttp
+
+import (
+	"net"
+	"net/http"
+	"net/netip"
+)
+
+// allowCIDRs rejects requests from addresses outside the allowed prefixes with 403, so the
+// debug server can be exposed on a LAN, and requests whose address cannot be parsed too.
+// Requests over a unix socket carry no address and are left to the socket's file
+// permissions.
+func allowCIDRs(allowed []netip.Prefix) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !overUnixSocket(r) {
+				addr, err := netip.ParseAddr(clientIP(r))
+				if err != nil || !containsAddr(allowed, addr.Unmap()) {
+					http.Error(w, "Forbidden", http.StatusForbidden)
+					return
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// overUnixSocket reports whether r was received on a unix socket listener.
+func overUnixSocket(r *http.Request) bool {
+	local, ok := r.Context().Value(http.LocalAddrContextKey).(net.Addr)
+	return ok && local.Network() == "unix"
+}
+
+func containsAddr(prefixes []netip.Prefix, addr netip.Addr) bool {
+	for _, prefix := range prefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
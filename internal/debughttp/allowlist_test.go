@@ -0,0 +1,53 @@
+package debughttp
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_AllowedCIDRs(t *testing.T) {
+	srv, err := NewServer(Config{
+		ListenAddress: ":0",
+		AllowedCIDRs:  []netip.Prefix{netip.MustParsePrefix("192.168.1.0/24"), netip.MustParsePrefix("127.0.0.1/32")},
+	}, slog.New(slog.NewTextHandler(os.Stdout, nil)))
+	require.NoError(t, err)
+
+	socket := &net.UnixAddr{Name: "/run/yanm/debug.sock", Net: "unix"}
+	testCases := []struct {
+		name         string
+		remoteAddr   string
+		localAddr    net.Addr
+		expectStatus int
+	}{
+		{name: "lan", remoteAddr: "192.168.1.20:51234", expectStatus: http.StatusOK},
+		{name: "loopback", remoteAddr: "127.0.0.1:51234", expectStatus: http.StatusOK},
+		{name: "ipv4 mapped ipv6", remoteAddr: "[::ffff:192.168.1.20]:51234", expectStatus: http.StatusOK},
+		{name: "other network", remoteAddr: "10.0.0.5:51234", expectStatus: http.StatusForbidden},
+		{name: "ipv6 loopback", remoteAddr: "[::1]:51234", expectStatus: http.StatusForbidden},
+		{name: "unix socket", remoteAddr: "@", localAddr: socket, expectStatus: http.StatusOK},
+		{name: "unparsable address", remoteAddr: "@", expectStatus: http.StatusForbidden},
+		{name: "no address", remoteAddr: "", expectStatus: http.StatusForbidden},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tc.localAddr != nil {
+				req = req.WithContext(context.WithValue(req.Context(), http.LocalAddrContextKey, tc.localAddr))
+			}
+			req.RemoteAddr = tc.remoteAddr
+			rr := httptest.NewRecorder()
+			srv.ServeHTTP(rr, req)
+			assert.Equal(t, tc.expectStatus, rr.Code)
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package debughttp
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+)
+
+// AuthConfig protects every debug route. A request is allowed when it carries the bearer
+// Token or the basic auth Username and Password. Leaving both unset disables authentication.
+type AuthConfig struct {
+	Username string
+	Password string
+	Token    string
+}
+
+func (c AuthConfig) enabled() bool {
+	return c.Token != "" || c.Username != ""
+}
+
+// requireAuth rejects requests that do not carry valid credentials.
+func requireAuth(cfg AuthConfig) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if cfg.authorized(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if cfg.Username != "" {
+				w.Header().Set("WWW-Authenticate", `Basic realm="yanm debug", charset="UTF-8"`)
+			} else {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="yanm debug"`)
+			}
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		})
+	}
+}
+
+func (c AuthConfig) authorized(r *http.Request) bool {
+	if c.Token != "" {
+		if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok && secureEqual(token, c.Token) {
+			return true
+		}
+	}
+	if c.Username != "" {
+		if user, pass, ok := r.BasicAuth(); ok && secureEqual(user, c.Username) && secureEqual(pass, c.Password) {
+			return true
+		}
+	}
+	return false
+}
+
+// secureEqual compares credentials in constant time.
+func secureEqual(got, want string) bool {
+	return subtle.ConstantTimeCompare([]byte(got), []byte(want)) == 1
+}
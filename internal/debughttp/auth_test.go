@@ -0,0 +1,84 @@
+package debughttp
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewServer_Auth(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+
+	testCases := []struct {
+		name         string
+		auth         AuthConfig
+		setup        func(r *http.Request)
+		expectStatus int
+		expectHeader string
+	}{
+		{
+			name:         "disabled",
+			expectStatus: http.StatusOK,
+		},
+		{
+			name:         "basic auth missing",
+			auth:         AuthConfig{Username: "admin", Password: "secret"},
+			expectStatus: http.StatusUnauthorized,
+			expectHeader: `Basic realm="yanm debug", charset="UTF-8"`,
+		},
+		{
+			name:         "basic auth wrong password",
+			auth:         AuthConfig{Username: "admin", Password: "secret"},
+			setup:        func(r *http.Request) { r.SetBasicAuth("admin", "guess") },
+			expectStatus: http.StatusUnauthorized,
+		},
+		{
+			name:         "basic auth",
+			auth:         AuthConfig{Username: "admin", Password: "secret"},
+			setup:        func(r *http.Request) { r.SetBasicAuth("admin", "secret") },
+			expectStatus: http.StatusOK,
+		},
+		{
+			name:         "bearer missing",
+			auth:         AuthConfig{Token: "t0ken"},
+			expectStatus: http.StatusUnauthorized,
+			expectHeader: `Bearer realm="yanm debug"`,
+		},
+		{
+			name:         "bearer",
+			auth:         AuthConfig{Token: "t0ken"},
+			setup:        func(r *http.Request) { r.Header.Set("Authorization", "Bearer t0ken") },
+			expectStatus: http.StatusOK,
+		},
+		{
+			name:         "basic auth when both are configured",
+			auth:         AuthConfig{Username: "admin", Password: "secret", Token: "t0ken"},
+			setup:        func(r *http.Request) { r.SetBasicAuth("admin", "secret") },
+			expectStatus: http.StatusOK,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			srv, err := NewServer(Config{ListenAddress: ":0", Auth: tc.auth}, logger)
+			require.NoError(t, err)
+
+			req := httptest.NewRequest(http.MethodPost, "/", nil)
+			if tc.setup != nil {
+				tc.setup(req)
+			}
+			rr := httptest.NewRecorder()
+			srv.httpServer.Handler.ServeHTTP(rr, req)
+
+			assert.Equal(t, tc.expectStatus, rr.Code)
+			if tc.expectHeader != "" {
+				assert.Equal(t, tc.expectHeader, rr.Header().Get("WWW-Authenticate"))
+			}
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package debughttp
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// _compressibleTypes are the content types worth compressing. Streams such as the
+// monitor events and binary pprof profiles are left alone.
+var _compressibleTypes = map[string]bool{
+	"text/html":        true,
+	"text/plain":       true,
+	"text/css":         true,
+	"text/javascript":  true,
+	"application/json": true,
+}
+
+// compress gzip or deflate encodes HTML and JSON responses for clients that accept it.
+func compress() Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			encoding := acceptedEncoding(r.Header.Get("Accept-Encoding"))
+			if encoding == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Add("Vary", "Accept-Encoding")
+			cw := &compressWriter{ResponseWriter: w, encoding: encoding}
+			defer cw.Close()
+			next.ServeHTTP(cw, r)
+		})
+	}
+}
+
+// acceptedEncoding returns the preferred supported encoding in an Accept-Encoding header.
+func acceptedEncoding(header string) string {
+	var deflate bool
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if strings.ReplaceAll(params, " ", "") == "q=0" {
+			continue
+		}
+		switch strings.ToLower(name) {
+		case "gzip":
+			return "gzip"
+		case "deflate":
+			deflate = true
+		}
+	}
+	if deflate {
+		return "deflate"
+	}
+	return ""
+}
+
+// compressWriter decides whether to compress on the first write, once the content type is known.
+type compressWriter struct {
+	http.ResponseWriter
+	encoding string
+
+	wroteHeader bool
+	writer      io.WriteCloser // nil when the response is passed through
+}
+
+func (w *compressWriter) WriteHeader(status int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+
+	header := w.Header()
+	if status != http.StatusNoContent && status != http.StatusNotModified &&
+		header.Get("Content-Encoding") == "" && compressible(header.Get("Content-Type")) {
+		header.Set("Content-Encoding", w.encoding)
+		header.Del("Content-Length")
+		if w.encoding == "gzip" {
+			w.writer = gzip.NewWriter(w.ResponseWriter)
+		} else {
+			w.writer, _ = flate.NewWriter(w.ResponseWriter, flate.DefaultCompression) // only errors on an invalid level
+		}
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *compressWriter) Write(p []byte) (int, error) {
+	if !w.wroteHeader {
+		if w.Header().Get("Content-Type") == "" {
+			w.Header().Set("Content-Type", http.DetectContentType(p))
+		}
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.writer == nil {
+		return w.ResponseWriter.Write(p)
+	}
+	return w.writer.Write(p)
+}
+
+// Flush flushes any compressed data so streaming handlers keep working.
+func (w *compressWriter) Flush() {
+	if flusher, ok := w.writer.(interface{ Flush() error }); ok {
+		_ = flusher.Flush()
+	}
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Close writes the end of the compressed stream.
+func (w *compressWriter) Close() error {
+	if w.writer == nil {
+		return nil
+	}
+	return w.writer.Close()
+}
+
+// Unwrap exposes the wrapped writer to http.ResponseController.
+func (w *compressWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func compressible(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && _compressibleTypes[mediaType]
+}
@@ -0,0 +1,91 @@
+package debughttp
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_Compression(t *testing.T) {
+	srv, err := NewServer(Config{ListenAddress: ":0"}, slog.New(slog.NewTextHandler(os.Stdout, nil)))
+	require.NoError(t, err)
+	body := strings.Repeat(`{"latency":"12ms"}`, 100)
+	require.NoError(t, srv.RegisterPage(DebugRoute{
+		Path: "/json",
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = io.WriteString(w, body)
+		}),
+	}))
+	require.NoError(t, srv.RegisterPage(DebugRoute{
+		Path: "/stream",
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = io.WriteString(w, "data: {}\n\n")
+		}),
+	}))
+
+	testCases := []struct {
+		name           string
+		path           string
+		acceptEncoding string
+		expectEncoding string
+	}{
+		{name: "gzip", path: "/json/", acceptEncoding: "gzip, deflate", expectEncoding: "gzip"},
+		{name: "deflate", path: "/json/", acceptEncoding: "deflate", expectEncoding: "deflate"},
+		{name: "gzip refused", path: "/json/", acceptEncoding: "gzip;q=0, deflate", expectEncoding: "deflate"},
+		{name: "not accepted", path: "/json/"},
+		{name: "html", path: "/", acceptEncoding: "gzip", expectEncoding: "gzip"},
+		{name: "event stream", path: "/stream/", acceptEncoding: "gzip"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			if tc.acceptEncoding != "" {
+				req.Header.Set("Accept-Encoding", tc.acceptEncoding)
+			}
+			rr := httptest.NewRecorder()
+			srv.httpServer.Handler.ServeHTTP(rr, req)
+
+			require.Equal(t, http.StatusOK, rr.Code)
+			assert.Equal(t, tc.expectEncoding, rr.Header().Get("Content-Encoding"))
+
+			var reader io.Reader = rr.Body
+			switch tc.expectEncoding {
+			case "gzip":
+				reader, err = gzip.NewReader(rr.Body)
+				require.NoError(t, err)
+			case "deflate":
+				reader = flate.NewReader(rr.Body)
+			}
+			decoded, err := io.ReadAll(reader)
+			require.NoError(t, err)
+			if tc.path == "/json/" {
+				assert.Equal(t, body, string(decoded))
+			}
+			assert.NotEmpty(t, decoded)
+		})
+	}
+}
+
+func TestCompressWriter_Flush(t *testing.T) {
+	rr := httptest.NewRecorder()
+	w := &compressWriter{ResponseWriter: rr, encoding: "gzip"}
+	w.Header().Set("Content-Type", "text/html")
+
+	_, err := io.WriteString(w, "<p>partial</p>")
+	require.NoError(t, err)
+	w.Flush()
+	assert.True(t, rr.Flushed)
+	assert.NotZero(t, rr.Body.Len(), "flush should push the compressed data through")
+}
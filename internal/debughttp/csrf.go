@@ -0,0 +1,47 @@
+package debughttp
+
+import (
+	"net/http"
+	"net/url"
+)
+
+// sameOrigin rejects state-changing requests, like the monitor pause/resume actions,
+// made by a browser on behalf of another site. Browsers send Sec-Fetch-Site or Origin
+// with such requests; requests carrying neither, e.g. from curl, are not cross-site.
+func sameOrigin() Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if isSafeMethod(r.Method) || isSameOrigin(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			http.Error(w, "Forbidden: cross-origin request", http.StatusForbidden)
+		})
+	}
+}
+
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	default:
+		return false
+	}
+}
+
+func isSameOrigin(r *http.Request) bool {
+	switch r.Header.Get("Sec-Fetch-Site") {
+	case "same-origin", "none": // "none" is a user navigation, e.g. a bookmark.
+		return true
+	case "":
+	default:
+		return false
+	}
+
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	return err == nil && u.Host == r.Host
+}
@@ -0,0 +1,49 @@
+package debughttp
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_SameOrigin(t *testing.T) {
+	srv, err := NewServer(Config{ListenAddress: ":0"}, slog.New(slog.NewTextHandler(os.Stdout, nil)))
+	require.NoError(t, err)
+	require.NoError(t, srv.RegisterPage(DebugRoute{
+		Path:    "/action",
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusNoContent) }),
+	}))
+
+	testCases := []struct {
+		name         string
+		method       string
+		headers      map[string]string
+		expectStatus int
+	}{
+		{name: "get from another site", method: http.MethodGet, headers: map[string]string{"Sec-Fetch-Site": "cross-site"}, expectStatus: http.StatusNoContent},
+		{name: "post without browser headers", method: http.MethodPost, expectStatus: http.StatusNoContent},
+		{name: "post same origin", method: http.MethodPost, headers: map[string]string{"Sec-Fetch-Site": "same-origin"}, expectStatus: http.StatusNoContent},
+		{name: "post cross site", method: http.MethodPost, headers: map[string]string{"Sec-Fetch-Site": "cross-site"}, expectStatus: http.StatusForbidden},
+		{name: "post same site", method: http.MethodPost, headers: map[string]string{"Sec-Fetch-Site": "same-site"}, expectStatus: http.StatusForbidden},
+		{name: "post matching origin", method: http.MethodPost, headers: map[string]string{"Origin": "http://yanm.local:8090"}, expectStatus: http.StatusNoContent},
+		{name: "post other origin", method: http.MethodPost, headers: map[string]string{"Origin": "https://evil.example"}, expectStatus: http.StatusForbidden},
+		{name: "delete other origin", method: http.MethodDelete, headers: map[string]string{"Origin": "https://evil.example"}, expectStatus: http.StatusForbidden},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, "http://yanm.local:8090/action/", nil)
+			for k, v := range tc.headers {
+				req.Header.Set(k, v)
+			}
+			rr := httptest.NewRecorder()
+			srv.httpServer.Handler.ServeHTTP(rr, req)
+			assert.Equal(t, tc.expectStatus, rr.Code)
+		})
+	}
+}
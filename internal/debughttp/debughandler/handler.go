@@ -6,14 +6,17 @@ import (
 	_ "embed"
 	"html/template"
 	"io"
+	"mime"
 	"net/http"
 	"net/http/httptest"
+	"sync"
 )
 
 var (
 	//go:embed layout.html
 	_layoutHTMLTemplate string
 
+	_layoutMu   sync.RWMutex
 	_layoutTmpl = template.Must(template.New("layout").Parse(_layoutHTMLTemplate))
 )
 
@@ -25,6 +28,8 @@ type contextKey string
 type PageContextData struct {
 	Title    string
 	NavLinks []NavLink
+	// Refresh reloads the page after this many seconds when positive.
+	Refresh int
 }
 
 // pageContextKey is the context key for PageContextData.
@@ -62,6 +67,28 @@ func NewContextWithPageData(parent context.Context, data PageContextData) contex
 type NavLink struct {
 	Path string
 	Name string
+	// Group is the nav section the link is listed under, empty for the top level.
+	Group string
+}
+
+// NavGroup is a section of consecutive NavLinks sharing a Group.
+type NavGroup struct {
+	Name  string
+	Links []NavLink
+}
+
+// NavGroups groups the page's NavLinks into sections, keeping their order. The links
+// are expected to be sorted so that each group's links are consecutive.
+func (p Page) NavGroups() []NavGroup {
+	var groups []NavGroup
+	for _, link := range p.NavLinks {
+		if len(groups) == 0 || groups[len(groups)-1].Name != link.Group {
+			groups = append(groups, NavGroup{Name: link.Group})
+		}
+		last := &groups[len(groups)-1]
+		last.Links = append(last.Links, link)
+	}
+	return groups
 }
 
 // Page represents the data passed to the layout template.
@@ -70,12 +97,29 @@ type Page struct {
 	Title       string        // Title of the HTML page
 	NavLinks    []NavLink     // Navigation links for the header/sidebar
 	ContentBody template.HTML // The main HTML content for the page
+	Refresh     int           // Seconds after which the browser reloads the page, zero for never
 }
 
 // ExecuteLayout executes the main layout template with the provided Page data,
 // writing the output to w.
 func ExecuteLayout(w io.Writer, data Page) error {
-	return _layoutTmpl.Execute(w, data)
+	_layoutMu.RLock()
+	tmpl := _layoutTmpl
+	_layoutMu.RUnlock()
+	return tmpl.Execute(w, data)
+}
+
+// SetLayout replaces the embedded layout template, e.g. to brand the debug pages. The
+// template is executed with a Page, so it should render .ContentBody and .NavGroups.
+func SetLayout(layout string) error {
+	tmpl, err := template.New("layout").Parse(layout)
+	if err != nil {
+		return err
+	}
+	_layoutMu.Lock()
+	defer _layoutMu.Unlock()
+	_layoutTmpl = tmpl
+	return nil
 }
 
 // --- HTML Producing Handler ---
@@ -83,21 +127,33 @@ func ExecuteLayout(w io.Writer, data Page) error {
 // NewHTMLProducingHandler wraps an existing http.Handler that generates raw HTML.
 // The output of the given handler is captured and used as the content for a debug page,
 // fitting into the standard debug server layout. This is useful for integrating
-// http.Handlers that already output HTML directly.
+// http.Handlers that already output HTML directly. JSON responses, such as a page's
+// application/json view, are passed through untouched.
 func NewHTMLProducingHandler(source http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// httptest does not import testing package, so we use the recorder directly.
 		recorder := httptest.NewRecorder()
 		source.ServeHTTP(recorder, r)
 
+		if isJSON(recorder.Header().Get("Content-Type")) {
+			for key, values := range recorder.Header() {
+				w.Header()[key] = values
+			}
+			w.WriteHeader(recorder.Code)
+			_, _ = recorder.Body.WriteTo(w)
+			return
+		}
+
 		pageTitle := "Debug Page" // Default title
 		var navLinks []NavLink
+		var refresh int
 
 		if pageData, ok := PageDataFromContext(r.Context()); ok {
 			if pageData.Title != "" {
 				pageTitle = pageData.Title
 			}
 			navLinks = pageData.NavLinks
+			refresh = pageData.Refresh
 		}
 
 		buf := bytes.NewBuffer(nil)
@@ -105,6 +161,7 @@ func NewHTMLProducingHandler(source http.Handler) http.Handler {
 			Title:       pageTitle,
 			NavLinks:    navLinks,
 			ContentBody: template.HTML(recorder.Body.String()),
+			Refresh:     refresh,
 		}); err != nil {
 			// should be an invariant violation.
 			w.WriteHeader(http.StatusInternalServerError)
@@ -121,3 +178,8 @@ func NewHTMLProducingHandler(source http.Handler) http.Handler {
 		_, _ = buf.WriteTo(w)
 	})
 }
+
+func isJSON(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && mediaType == "application/json"
+}
@@ -16,6 +16,7 @@ func TestNewHTMLProducingHandler(t *testing.T) {
 		expectedBodySubstr string
 		expectedStatusCode int
 		expectedHeaders    map[string]string
+		passthrough        bool // the source response is served without the layout
 	}{
 		{
 			name:               "Simple HTML content",
@@ -40,6 +41,16 @@ func TestNewHTMLProducingHandler(t *testing.T) {
 			expectedStatusCode: http.StatusOK,
 			expectedHeaders:    map[string]string{"X-Custom-Header": "value1", "Cache-Control": "no-cache"},
 		},
+		{
+			name:               "JSON content is passed through",
+			sourceContent:      `{"status":"ok"}`,
+			sourceStatusCode:   http.StatusOK,
+			sourceHeaders:      map[string]string{"Content-Type": "application/json"},
+			expectedBodySubstr: `{"status":"ok"}`,
+			expectedStatusCode: http.StatusOK,
+			expectedHeaders:    map[string]string{"Content-Type": "application/json"},
+			passthrough:        true,
+		},
 		{
 			name:               "Empty content",
 			sourceContent:      "",
@@ -81,7 +92,11 @@ func TestNewHTMLProducingHandler(t *testing.T) {
 			}
 
 			// Check if the layout was applied (e.g., by looking for a known part of the layout)
-			if !strings.Contains(body, "<title>") || !strings.Contains(body, "YANM Debug") {
+			if tt.passthrough {
+				if body != tt.sourceContent {
+					t.Errorf("handler body = %s; want the source content %s untouched", body, tt.sourceContent)
+				}
+			} else if !strings.Contains(body, "<title>") || !strings.Contains(body, "YANM Debug") {
 				t.Errorf("handler body does not seem to contain the layout: %s", body)
 			}
 
@@ -96,3 +111,25 @@ func TestNewHTMLProducingHandler(t *testing.T) {
 		})
 	}
 }
+
+func TestPage_NavGroups(t *testing.T) {
+	page := Page{NavLinks: []NavLink{
+		{Path: "/", Name: "Home"},
+		{Path: "/a/", Name: "A", Group: "Results"},
+		{Path: "/b/", Name: "B", Group: "Results"},
+		{Path: "/c/", Name: "C", Group: "System"},
+	}}
+
+	groups := page.NavGroups()
+	if len(groups) != 3 {
+		t.Fatalf("NavGroups() returned %d groups, want 3: %+v", len(groups), groups)
+	}
+	for i, want := range []struct {
+		name  string
+		links int
+	}{{"", 1}, {"Results", 2}, {"System", 1}} {
+		if groups[i].Name != want.name || len(groups[i].Links) != want.links {
+			t.Errorf("group %d = %q with %d links, want %q with %d", i, groups[i].Name, len(groups[i].Links), want.name, want.links)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package debughandler
+
+import (
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"strings"
+)
+
+// NewNegotiatingHandler returns a handler serving the value returned by data as JSON when
+// the request accepts application/json, and otherwise rendered with tmpl as HTML content
+// for NewHTMLProducingHandler to wrap in the layout. Both views share the same value, so
+// every page gets a JSON view for free.
+func NewNegotiatingHandler(data func(r *http.Request) (any, error), tmpl *template.Template) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		value, err := data(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		if WantsJSON(r) {
+			w.Header().Set("Content-Type", "application/json")
+			if err := json.NewEncoder(w).Encode(value); err != nil {
+				http.Error(w, "Failed to encode JSON", http.StatusInternalServerError)
+			}
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if err := tmpl.Execute(w, value); err != nil {
+			http.Error(w, "Failed to execute template", http.StatusInternalServerError)
+		}
+	})
+}
+
+// WantsJSON reports whether r asks for the JSON view of a page.
+func WantsJSON(r *http.Request) bool {
+	return strings.Contains(r.Header.Get("Accept"), "application/json")
+}
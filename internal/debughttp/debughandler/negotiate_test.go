@@ -0,0 +1,67 @@
+package debughandler
+
+import (
+	"errors"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNewNegotiatingHandler(t *testing.T) {
+	tmpl := template.Must(template.New("test").Parse(`<p>{{.Name}}</p>`))
+	handler := NewNegotiatingHandler(func(*http.Request) (any, error) {
+		return struct {
+			Name string `json:"name"`
+		}{Name: "<gateway>"}, nil
+	}, tmpl)
+
+	tests := []struct {
+		name        string
+		accept      string
+		contentType string
+		body        string
+	}{
+		{name: "browser", accept: "text/html,application/xhtml+xml,*/*;q=0.8", contentType: "text/html; charset=utf-8", body: "<p>&lt;gateway&gt;</p>"},
+		{name: "no accept header", contentType: "text/html; charset=utf-8", body: "<p>&lt;gateway&gt;</p>"},
+		{name: "json", accept: "application/json", contentType: "application/json", body: `{"name":"\u003cgateway\u003e"}` + "\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/debug/test", nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			if rr.Code != http.StatusOK {
+				t.Errorf("status = %d, want %d", rr.Code, http.StatusOK)
+			}
+			if got := rr.Header().Get("Content-Type"); got != tt.contentType {
+				t.Errorf("Content-Type = %q, want %q", got, tt.contentType)
+			}
+			if got := rr.Body.String(); got != tt.body {
+				t.Errorf("body = %q, want %q", got, tt.body)
+			}
+		})
+	}
+}
+
+func TestNewNegotiatingHandler_DataError(t *testing.T) {
+	handler := NewNegotiatingHandler(func(*http.Request) (any, error) {
+		return nil, errors.New("backend unavailable")
+	}, template.Must(template.New("test").Parse(`unused`)))
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/debug/test", nil))
+
+	if rr.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want %d", rr.Code, http.StatusInternalServerError)
+	}
+	if !strings.Contains(rr.Body.String(), "backend unavailable") {
+		t.Errorf("body = %q, want the data error", rr.Body.String())
+	}
+}
@@ -0,0 +1,31 @@
+package debughttp
+
+import (
+	"expvar"
+	"fmt"
+	"net/http"
+)
+
+// _expvarPath serves the published expvars, e.g. the monitor's check counters.
+const _expvarPath = "/debug/vars/"
+
+// expvarHandler serves the published expvars as a JSON object like expvar.Handler, but
+// leaves out cmdline, as the command line may carry secrets such as -config-header.
+func expvarHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		fmt.Fprint(w, "{\n")
+		first := true
+		expvar.Do(func(kv expvar.KeyValue) {
+			if kv.Key == "cmdline" {
+				return
+			}
+			if !first {
+				fmt.Fprint(w, ",\n")
+			}
+			first = false
+			fmt.Fprintf(w, "%q: %s", kv.Key, kv.Value)
+		})
+		fmt.Fprint(w, "\n}\n")
+	})
+}
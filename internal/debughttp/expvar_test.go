@@ -0,0 +1,32 @@
+package debughttp
+
+import (
+	"encoding/json"
+	"expvar"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var _testVar = expvar.NewInt("debughttp_test_counter")
+
+func TestNewServer_Expvar(t *testing.T) {
+	srv, err := NewServer(Config{ListenAddress: ":0"}, slog.New(slog.NewTextHandler(os.Stdout, nil)))
+	require.NoError(t, err)
+	_testVar.Set(42)
+
+	rr := httptest.NewRecorder()
+	srv.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/debug/vars/", nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var vars map[string]json.RawMessage
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &vars))
+	assert.JSONEq(t, "42", string(vars["debughttp_test_counter"]))
+	assert.Contains(t, vars, "memstats")
+	assert.NotContains(t, vars, "cmdline", "the command line may carry secrets")
+}
@@ -0,0 +1,34 @@
+package debughttp
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"strings"
+)
+
+// _unixScheme prefixes a listen address that is a unix domain socket path.
+const _unixScheme = "unix://"
+
+// listen opens the listener for address, either host:port or unix:///path/to.sock.
+// A stale socket file left behind by an unclean shutdown is replaced.
+func listen(address string) (net.Listener, error) {
+	path, ok := strings.CutPrefix(address, _unixScheme)
+	if !ok {
+		return net.Listen("tcp", address)
+	}
+
+	if info, err := os.Stat(path); err == nil {
+		if info.Mode().Type() != fs.ModeSocket {
+			return nil, fmt.Errorf("debug server socket %s exists and is not a socket", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("failed to remove stale debug server socket: %w", err)
+		}
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+	return net.Listen("unix", path)
+}
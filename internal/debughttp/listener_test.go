@@ -0,0 +1,107 @@
+package debughttp
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// socketDir returns a short temporary directory, t.TempDir can exceed the socket path limit.
+func socketDir(t *testing.T) string {
+	dir, err := os.MkdirTemp("", "yanm")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+	return dir
+}
+
+func TestServer_UnixSocket(t *testing.T) {
+	socket := filepath.Join(socketDir(t), "debug.sock")
+	// the allowlist leaves unix socket clients to the socket's file permissions.
+	srv, err := NewServer(Config{
+		ListenAddress: "unix://" + socket,
+		AllowedCIDRs:  []netip.Prefix{netip.MustParsePrefix("192.168.1.0/24")},
+	}, slog.New(slog.NewTextHandler(os.Stdout, nil)))
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	require.NoError(t, srv.Start(ctx))
+	defer func() { _ = srv.Stop(ctx) }()
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", socket)
+		},
+	}}
+	require.Eventually(t, func() bool {
+		_, err := os.Stat(socket)
+		return err == nil
+	}, time.Second, 10*time.Millisecond)
+
+	resp, err := client.Get("http://yanm/")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, string(body), "Available Debug Endpoints")
+}
+
+func TestServer_StartAddressInUse(t *testing.T) {
+	taken, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer taken.Close()
+
+	srv, err := NewServer(Config{ListenAddress: taken.Addr().String()}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, err)
+	err = srv.Start(context.Background())
+	require.ErrorContains(t, err, "failed to listen on "+taken.Addr().String())
+}
+
+func TestServer_StopReleasesAddress(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	for range 10 {
+		srv, err := NewServer(Config{ListenAddress: "127.0.0.1:0"}, logger)
+		require.NoError(t, err)
+		require.NoError(t, srv.Start(context.Background()))
+		addr := srv.listener.Addr().String()
+		// stopped straight away, possibly before it serves.
+		require.NoError(t, srv.Stop(context.Background()))
+
+		l, err := net.Listen("tcp", addr)
+		require.NoError(t, err, "the address is free once stopped")
+		require.NoError(t, l.Close())
+	}
+}
+
+func TestListen_StaleSocket(t *testing.T) {
+	socket := filepath.Join(socketDir(t), "debug.sock")
+	stale, err := net.Listen("unix", socket)
+	require.NoError(t, err)
+	// leave the socket file behind, like a crashed process would.
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	require.NoError(t, stale.Close())
+
+	listener, err := listen("unix://" + socket)
+	require.NoError(t, err)
+	require.NoError(t, listener.Close())
+}
+
+func TestListen_NotASocket(t *testing.T) {
+	path := filepath.Join(socketDir(t), "config.yml")
+	require.NoError(t, os.WriteFile(path, nil, 0o600))
+
+	_, err := listen("unix://" + path)
+	require.ErrorContains(t, err, "is not a socket")
+	_, err = os.Stat(path)
+	assert.NoError(t, err, "a regular file must not be removed")
+}
@@ -0,0 +1,85 @@
+package debughttp
+
+import (
+	"cmp"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// serverMetrics describe the debug server itself, e.g. scrape and UI latency.
+type serverMetrics struct {
+	requests *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+}
+
+func newServerMetrics(reg prometheus.Registerer, namespace string) (*serverMetrics, error) {
+	namespace = cmp.Or(namespace, "yanm")
+	requests, err := registerOrReuse(reg, prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "debug_http",
+		Name:      "requests_total",
+		Help:      "Number of debug server requests, partitioned by route, method and status code.",
+	}, []string{"route", "method", "code"}))
+	if err != nil {
+		return nil, err
+	}
+	duration, err := registerOrReuse(reg, prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "debug_http",
+		Name:      "request_duration_seconds",
+		Help:      "Time taken to serve a debug server request; event streams last as long as the client stays connected.",
+		Buckets:   []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30},
+	}, []string{"route"}))
+	if err != nil {
+		return nil, err
+	}
+	return &serverMetrics{requests: requests, duration: duration}, nil
+}
+
+// registerOrReuse registers c, or returns the collector already registered in its place
+// by a previous server, e.g. one replaced after a configuration reload, so the counts
+// carry on.
+func registerOrReuse[C prometheus.Collector](reg prometheus.Registerer, c C) (C, error) {
+	err := reg.Register(c)
+	var registered prometheus.AlreadyRegisteredError
+	if errors.As(err, &registered) {
+		if existing, ok := registered.ExistingCollector.(C); ok {
+			return existing, nil
+		}
+	}
+	return c, err
+}
+
+// instrument records every request, including rejected ones, by the route pattern it
+// matches, so paths requested by clients cannot grow the number of series.
+func (s *Server) instrument() Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(recorder, r)
+
+			_, route := s.mux.mux.Handler(r)
+			if route == "" {
+				route = "unmatched"
+			}
+			s.metrics.requests.WithLabelValues(route, methodLabel(r.Method), strconv.Itoa(recorder.status)).Inc()
+			s.metrics.duration.WithLabelValues(route).Observe(time.Since(start).Seconds())
+		})
+	}
+}
+
+// methodLabel bounds the method label to the standard methods.
+func methodLabel(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace:
+		return method
+	default:
+		return "other"
+	}
+}
@@ -0,0 +1,53 @@
+package debughttp
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewServer_Metrics(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	srv, err := NewServer(Config{
+		ListenAddress: ":0",
+		Auth:          AuthConfig{Token: "secret"},
+		Registerer:    reg,
+	}, slog.New(slog.NewTextHandler(os.Stdout, nil)))
+	require.NoError(t, err)
+	require.NoError(t, srv.RegisterPage(DebugRoute{
+		Path:    "/debug/page",
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusNoContent) }),
+	}))
+
+	do := func(method, path string, authorized bool) {
+		req := httptest.NewRequest(method, path, nil)
+		if authorized {
+			req.Header.Set("Authorization", "Bearer secret")
+		}
+		srv.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	do(http.MethodGet, "/debug/page/", true)
+	do(http.MethodGet, "/debug/page/sub/path", true)
+	do(http.MethodGet, "/debug/page/", false)
+	do("BREW", "/debug/page/", true)
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(srv.metrics.requests.WithLabelValues("/debug/page/", http.MethodGet, "204")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(srv.metrics.requests.WithLabelValues("/debug/page/", http.MethodGet, "401")), "rejected requests are counted")
+	assert.Equal(t, 1.0, testutil.ToFloat64(srv.metrics.requests.WithLabelValues("/debug/page/", "other", "204")))
+
+	count, err := testutil.GatherAndCount(reg, "yanm_debug_http_request_duration_seconds")
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	// a server replacing this one on the same registry continues the counts.
+	next, err := NewServer(Config{ListenAddress: ":0", Registerer: reg}, slog.New(slog.NewTextHandler(os.Stdout, nil)))
+	require.NoError(t, err)
+	assert.Equal(t, 2.0, testutil.ToFloat64(next.metrics.requests.WithLabelValues("/debug/page/", http.MethodGet, "204")))
+}
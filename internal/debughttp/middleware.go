@@ -0,0 +1,30 @@
+package debughttp
+
+import "net/http"
+
+// Middleware wraps every request to the debug server, e.g. to authenticate or log it.
+type Middleware func(next http.Handler) http.Handler
+
+// Use appends middleware to the chain wrapping every route. Middleware runs in the order
+// it was added, so the first added sees the request first. Use should be called before Start.
+func (s *Server) Use(middleware ...Middleware) {
+	s.handlerMu.Lock()
+	defer s.handlerMu.Unlock()
+
+	s.middleware = append(s.middleware, middleware...)
+
+	var handler http.Handler = s.mux
+	for i := len(s.middleware) - 1; i >= 0; i-- {
+		handler = s.middleware[i](handler)
+	}
+	s.handler = handler
+}
+
+// ServeHTTP serves a request through the middleware chain.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.handlerMu.RLock()
+	handler := s.handler
+	s.handlerMu.RUnlock()
+
+	handler.ServeHTTP(w, r)
+}
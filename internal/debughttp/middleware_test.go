@@ -0,0 +1,53 @@
+package debughttp
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_Use(t *testing.T) {
+	srv, err := NewServer(Config{ListenAddress: ":0"}, slog.New(slog.NewTextHandler(os.Stdout, nil)))
+	require.NoError(t, err)
+	require.NoError(t, srv.RegisterPage(DebugRoute{
+		Path: "/test",
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.Header().Add("X-Order", "handler")
+		}),
+	}))
+
+	order := func(name string) Middleware {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Add("X-Order", name)
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+	srv.Use(order("first"), order("second"))
+	srv.Use(order("third"))
+
+	rr := httptest.NewRecorder()
+	srv.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/test/", nil))
+	assert.Equal(t, []string{"first", "second", "third", "handler"}, rr.Header().Values("X-Order"))
+}
+
+func TestServer_UseShortCircuit(t *testing.T) {
+	srv, err := NewServer(Config{ListenAddress: ":0"}, slog.New(slog.NewTextHandler(os.Stdout, nil)))
+	require.NoError(t, err)
+
+	srv.Use(func(http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+		})
+	})
+
+	rr := httptest.NewRecorder()
+	srv.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+}
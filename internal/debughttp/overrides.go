@@ -0,0 +1,68 @@
+package debughttp
+
+import (
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"yanm/internal/debughttp/debughandler"
+)
+
+// Files looked up in Config.TemplateDir. Any that are missing fall back to the embedded ones.
+const (
+	_layoutOverride = "layout.html"
+	_rootOverride   = "debug_root.html"
+	_staticOverride = "static"
+)
+
+// applyTemplateOverrides replaces the embedded layout and root page templates with the
+// ones in dir, if present, and returns the static assets to serve with files in dir/static
+// taking precedence over the embedded ones.
+func (s *Server) applyTemplateOverrides(dir string, static fs.FS) (fs.FS, error) {
+	if layout, ok, err := readOverride(dir, _layoutOverride); err != nil {
+		return nil, err
+	} else if ok {
+		if err := debughandler.SetLayout(layout); err != nil {
+			return nil, fmt.Errorf("invalid %s override: %w", _layoutOverride, err)
+		}
+	}
+
+	if root, ok, err := readOverride(dir, _rootOverride); err != nil {
+		return nil, err
+	} else if ok {
+		tmpl, err := htmltemplate.New("root").Parse(root)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s override: %w", _rootOverride, err)
+		}
+		s.rootTemplate = tmpl
+	}
+
+	return overlayFS{upper: os.DirFS(filepath.Join(dir, _staticOverride)), lower: static}, nil
+}
+
+func readOverride(dir, name string) (string, bool, error) {
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("failed to read debug template override: %w", err)
+	}
+	return string(data), true, nil
+}
+
+// overlayFS serves files from upper, falling back to lower for files upper does not have.
+type overlayFS struct {
+	upper, lower fs.FS
+}
+
+func (o overlayFS) Open(name string) (fs.File, error) {
+	f, err := o.upper.Open(name)
+	if err == nil {
+		return f, nil
+	}
+	return o.lower.Open(name)
+}
@@ -0,0 +1,57 @@
+package debughttp
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"yanm/internal/debughttp/debughandler"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewServer_TemplateDir(t *testing.T) {
+	embeddedLayout, err := os.ReadFile(filepath.Join("debughandler", "layout.html"))
+	require.NoError(t, err)
+	t.Cleanup(func() { require.NoError(t, debughandler.SetLayout(string(embeddedLayout))) })
+
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "layout.html"),
+		[]byte(`<html><title>ACME Network</title><body>{{.ContentBody}}</body></html>`), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "debug_root.html"),
+		[]byte(`<h2>ACME pages</h2>{{range .Groups}}{{range .Handlers}}{{.Path}} {{end}}{{end}}`), 0o600))
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "static"), 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "static", "styles.css"), []byte("body { color: red; }"), 0o600))
+
+	srv, err := NewServer(Config{ListenAddress: ":0", TemplateDir: dir}, slog.New(slog.NewTextHandler(os.Stdout, nil)))
+	require.NoError(t, err)
+
+	get := func(path string) string {
+		rr := httptest.NewRecorder()
+		srv.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, path, nil))
+		require.Equal(t, http.StatusOK, rr.Code, path)
+		body, err := io.ReadAll(rr.Body)
+		require.NoError(t, err)
+		return string(body)
+	}
+
+	root := get("/")
+	assert.Contains(t, root, "<title>ACME Network</title>")
+	assert.Contains(t, root, "<h2>ACME pages</h2>")
+	assert.Equal(t, "body { color: red; }", get("/debug/static/styles.css"))
+	// files missing from the override directory fall back to the embedded ones.
+	assert.Contains(t, get("/debug/static/scripts.js"), "timeSeries")
+}
+
+func TestNewServer_TemplateDirInvalid(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "debug_root.html"), []byte(`{{.Unclosed`), 0o600))
+
+	_, err := NewServer(Config{ListenAddress: ":0", TemplateDir: dir}, slog.New(slog.NewTextHandler(os.Stdout, nil)))
+	require.ErrorContains(t, err, "invalid debug_root.html override")
+}
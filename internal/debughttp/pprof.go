@@ -0,0 +1,21 @@
+package debughttp
+
+import (
+	"net/http"
+	"net/http/pprof"
+)
+
+// _pprofPath is where the net/http/pprof handlers are served, matching the path the pprof tool expects.
+const _pprofPath = "/debug/pprof/"
+
+// pprofHandler serves the net/http/pprof endpoints. The index serves every named
+// runtime profile (heap, goroutine, ...), the rest need their own handler.
+func pprofHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(_pprofPath, pprof.Index)
+	mux.HandleFunc(_pprofPath+"cmdline", pprof.Cmdline)
+	mux.HandleFunc(_pprofPath+"profile", pprof.Profile)
+	mux.HandleFunc(_pprofPath+"symbol", pprof.Symbol)
+	mux.HandleFunc(_pprofPath+"trace", pprof.Trace)
+	return mux
+}
@@ -0,0 +1,52 @@
+package debughttp
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewServer_Pprof(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+
+	testCases := []struct {
+		name       string
+		pprof      bool
+		path       string
+		expectBody string
+	}{
+		// falls through to the debug home page.
+		{name: "disabled", path: "/debug/pprof/", expectBody: "Available Debug Endpoints"},
+		{name: "index", pprof: true, path: "/debug/pprof/", expectBody: "Types of profiles available"},
+		{name: "heap", pprof: true, path: "/debug/pprof/heap?debug=1", expectBody: "heap profile"},
+		{name: "cmdline", pprof: true, path: "/debug/pprof/cmdline"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			srv, err := NewServer(Config{ListenAddress: ":0", Pprof: tc.pprof}, logger)
+			require.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			srv.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, tc.path, nil))
+			assert.Equal(t, http.StatusOK, rr.Code)
+			assert.Contains(t, rr.Body.String(), tc.expectBody)
+		})
+	}
+}
+
+func TestNewServer_PprofNotInNav(t *testing.T) {
+	srv, err := NewServer(Config{ListenAddress: ":0", Pprof: true}, slog.New(slog.NewTextHandler(os.Stdout, nil)))
+	require.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	srv.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+	nav, _, _ := strings.Cut(rr.Body.String(), "</nav>")
+	assert.NotContains(t, nav, `href="/debug/pprof/"`)
+}
@@ -0,0 +1,27 @@
+package debughttp
+
+// PageProvider is implemented by subsystems serving debug pages, so they can be registered
+// without the caller assembling their routes.
+type PageProvider interface {
+	DebugRoutes() []DebugRoute
+}
+
+// Routes provides a fixed list of routes, for pages not owned by a PageProvider.
+type Routes []DebugRoute
+
+// DebugRoutes returns the routes.
+func (r Routes) DebugRoutes() []DebugRoute {
+	return r
+}
+
+// RegisterProviders registers the routes of every provider, stopping at the first error.
+func (s *Server) RegisterProviders(providers ...PageProvider) error {
+	for _, provider := range providers {
+		for _, route := range provider.DebugRoutes() {
+			if err := s.RegisterPage(route); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
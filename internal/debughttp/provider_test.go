@@ -0,0 +1,34 @@
+package debughttp
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_RegisterProviders(t *testing.T) {
+	srv, err := NewServer(Config{ListenAddress: ":0"}, slog.New(slog.NewTextHandler(os.Stdout, nil)))
+	require.NoError(t, err)
+
+	page := func(body string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) { _, _ = w.Write([]byte(body)) })
+	}
+	require.NoError(t, srv.RegisterProviders(
+		Routes{{Path: "/debug/one", Handler: page("one")}},
+		Routes{{Path: "/debug/two", Handler: page("two")}, {Path: "/debug/three", Handler: page("three")}},
+	))
+
+	for _, path := range []string{"one", "two", "three"} {
+		rr := httptest.NewRecorder()
+		srv.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/debug/"+path+"/", nil))
+		assert.Equal(t, path, rr.Body.String())
+	}
+
+	err = srv.RegisterProviders(Routes{{Path: "/debug/one", Handler: page("again")}})
+	assert.ErrorIs(t, err, ErrPathAlreadyRegistered)
+}
@@ -0,0 +1,114 @@
+package debughttp
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// _clientIdleTimeout is how long a client's limiter is kept after its last control request.
+const _clientIdleTimeout = 10 * time.Minute
+
+// RateLimitConfig limits how often each client IP may use the control routes, such as
+// triggering a speed test, so a misbehaving script cannot saturate the link.
+type RateLimitConfig struct {
+	// RequestsPerMinute is the sustained rate allowed per client, zero disables the limit.
+	RequestsPerMinute int
+	// Burst is how many requests a client may make at once, at least one.
+	Burst int
+}
+
+func (c RateLimitConfig) enabled() bool {
+	return c.RequestsPerMinute > 0
+}
+
+type clientLimiter struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// clientLimiters holds a token bucket per client IP, forgetting idle clients.
+type clientLimiters struct {
+	limit rate.Limit
+	burst int
+	now   func() time.Time
+
+	mu        sync.Mutex
+	clients   map[string]*clientLimiter
+	lastSweep time.Time
+}
+
+// reserve takes a token for the client, returning how long it must wait when none is left.
+func (c *clientLimiters) reserve(client string) (time.Duration, bool) {
+	now := c.now()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if now.Sub(c.lastSweep) > _clientIdleTimeout {
+		for ip, cl := range c.clients {
+			if now.Sub(cl.lastSeen) > _clientIdleTimeout {
+				delete(c.clients, ip)
+			}
+		}
+		c.lastSweep = now
+	}
+
+	cl, ok := c.clients[client]
+	if !ok {
+		cl = &clientLimiter{limiter: rate.NewLimiter(c.limit, c.burst)}
+		c.clients[client] = cl
+	}
+	cl.lastSeen = now
+
+	reservation := cl.limiter.ReserveN(now, 1)
+	if wait := reservation.DelayFrom(now); wait > 0 {
+		// rejected requests do not use up the client's allowance.
+		reservation.CancelAt(now)
+		return wait, false
+	}
+	return 0, true
+}
+
+// rateLimit rejects state-changing requests from clients over the configured rate with
+// 429 Too Many Requests. Safe methods, i.e. viewing the pages, are never limited.
+func rateLimit(cfg RateLimitConfig) Middleware {
+	limiters := &clientLimiters{
+		limit:   rate.Limit(float64(cfg.RequestsPerMinute) / time.Minute.Seconds()),
+		burst:   max(cfg.Burst, 1),
+		now:     time.Now,
+		clients: make(map[string]*clientLimiter),
+	}
+	return limiters.middleware
+}
+
+func (c *clientLimiters) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if isSafeMethod(r.Method) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if wait, ok := c.reserve(clientIP(r)); !ok {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			http.Error(w, "Too many requests", http.StatusTooManyRequests)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// clientIP returns the IP of the client making r. Forwarding headers are not trusted, as
+// the debug server is not expected to run behind a proxy.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		// e.g. unix socket clients, which all share one limiter.
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,65 @@
+package debughttp
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_RateLimit(t *testing.T) {
+	srv, err := NewServer(Config{
+		ListenAddress: ":0",
+		RateLimit:     RateLimitConfig{RequestsPerMinute: 1, Burst: 2},
+	}, slog.New(slog.NewTextHandler(os.Stdout, nil)))
+	require.NoError(t, err)
+	require.NoError(t, srv.RegisterPage(DebugRoute{
+		Path:    "/action",
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusNoContent) }),
+	}))
+
+	do := func(method, remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/action/", nil)
+		req.RemoteAddr = remoteAddr
+		rr := httptest.NewRecorder()
+		srv.ServeHTTP(rr, req)
+		return rr
+	}
+
+	assert.Equal(t, http.StatusNoContent, do(http.MethodPost, "192.0.2.1:1234").Code)
+	assert.Equal(t, http.StatusNoContent, do(http.MethodPost, "192.0.2.1:1235").Code, "burst allows a second request")
+
+	rr := do(http.MethodPost, "192.0.2.1:1236")
+	assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+	assert.Equal(t, "60", rr.Header().Get("Retry-After"))
+
+	assert.Equal(t, http.StatusNoContent, do(http.MethodGet, "192.0.2.1:1237").Code, "viewing pages is not limited")
+	assert.Equal(t, http.StatusNoContent, do(http.MethodPost, "192.0.2.2:1234").Code, "clients are limited separately")
+}
+
+func TestClientLimiters_ForgetsIdleClients(t *testing.T) {
+	now := time.Now()
+	limiters := &clientLimiters{
+		limit:     1,
+		burst:     1,
+		now:       func() time.Time { return now },
+		clients:   make(map[string]*clientLimiter),
+		lastSweep: now,
+	}
+
+	_, ok := limiters.reserve("192.0.2.1")
+	require.True(t, ok)
+	_, ok = limiters.reserve("192.0.2.1")
+	require.False(t, ok)
+
+	now = now.Add(_clientIdleTimeout + time.Second)
+	_, ok = limiters.reserve("192.0.2.2")
+	require.True(t, ok)
+	assert.NotContains(t, limiters.clients, "192.0.2.1")
+	assert.Contains(t, limiters.clients, "192.0.2.2")
+}
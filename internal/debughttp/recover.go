@@ -0,0 +1,39 @@
+package debughttp
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+)
+
+// PanicLogger logs v, recovered from a panicking handler, see Config.LogPanic.
+type PanicLogger func(ctx context.Context, log *slog.Logger, v any)
+
+// logPanic is the PanicLogger used when Config.LogPanic is not set.
+func logPanic(ctx context.Context, log *slog.Logger, v any) {
+	log.ErrorContext(ctx, "Panic", "panic", v, "stack", string(debug.Stack()))
+}
+
+// recoverPanics turns a panicking handler into a 500, logging the panic instead of leaving
+// net/http to print it unstructured and drop the connection. http.ErrAbortHandler is
+// passed on, it aborts the response on purpose.
+func recoverPanics(log *slog.Logger, logPanic PanicLogger) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer func() {
+				v := recover()
+				if v == nil {
+					return
+				}
+				if err, ok := v.(error); ok && errors.Is(err, http.ErrAbortHandler) {
+					panic(v)
+				}
+				logPanic(r.Context(), Logger(r.Context(), log).With("path", r.URL.Path), v)
+				http.Error(w, "Internal server error", http.StatusInternalServerError)
+			}()
+			next.ServeHTTP(w, r)
+		})
+	}
+}
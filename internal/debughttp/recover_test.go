@@ -0,0 +1,51 @@
+package debughttp
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_RecoverPanics(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+
+	var recovered any
+	srv, err := NewServer(Config{
+		ListenAddress: ":0",
+		AccessLog:     AccessLogConfig{Enabled: true},
+		LogPanic: func(ctx context.Context, log *slog.Logger, v any) {
+			recovered = v
+			log.ErrorContext(ctx, "Panic")
+		},
+	}, logger)
+	require.NoError(t, err)
+	require.NoError(t, srv.RegisterPage(DebugRoute{
+		Path:    "/panic",
+		Handler: http.HandlerFunc(func(http.ResponseWriter, *http.Request) { panic("boom") }),
+	}))
+
+	rr := httptest.NewRecorder()
+	srv.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/panic/", nil))
+
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	assert.Equal(t, "boom", recovered)
+	// logged with the request ID and path, and the access log still sees the 500.
+	assert.Contains(t, buf.String(), "msg=Panic component=debug_server requestID="+rr.Header().Get(RequestIDHeader)+" path=/panic/")
+	assert.Contains(t, buf.String(), "status=500")
+
+	// an aborted response is left to net/http.
+	require.NoError(t, srv.RegisterPage(DebugRoute{
+		Path:    "/abort",
+		Handler: http.HandlerFunc(func(http.ResponseWriter, *http.Request) { panic(http.ErrAbortHandler) }),
+	}))
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		srv.httpServer.Handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/abort/", nil))
+	})
+}
@@ -0,0 +1,59 @@
+package debughttp
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"regexp"
+
+	"yanm/internal/logctx"
+)
+
+// RequestIDHeader carries the request ID in both the request and the response.
+const RequestIDHeader = "X-Request-ID"
+
+// _requestIDRE limits the IDs accepted from clients or proxies to something safe to log.
+var _requestIDRE = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+type requestContextKey struct{}
+
+type requestContext struct {
+	id     string
+	logger *slog.Logger
+}
+
+// assignRequestID gives every request an ID, reusing a valid one sent by the client, returns
+// it in the response header and attaches it to the request-scoped logger and, with logctx,
+// to the lines logged with the request's context elsewhere, e.g. by a triggered speed test.
+func assignRequestID(logger *slog.Logger) Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := r.Header.Get(RequestIDHeader)
+			if !_requestIDRE.MatchString(id) {
+				id = logctx.NewID()
+			}
+			w.Header().Set(RequestIDHeader, id)
+
+			ctx := context.WithValue(logctx.WithRequestID(r.Context(), id), requestContextKey{}, requestContext{
+				id:     id,
+				logger: logger.With(logctx.RequestIDKey, id),
+			})
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// RequestID returns the ID of the debug server request ctx belongs to, empty if none.
+func RequestID(ctx context.Context) string {
+	rc, _ := ctx.Value(requestContextKey{}).(requestContext)
+	return rc.id
+}
+
+// Logger returns the logger for the debug server request ctx belongs to, which includes
+// the request ID. Outside of a request it returns fallback.
+func Logger(ctx context.Context, fallback *slog.Logger) *slog.Logger {
+	if rc, ok := ctx.Value(requestContextKey{}).(requestContext); ok {
+		return rc.logger
+	}
+	return fallback
+}
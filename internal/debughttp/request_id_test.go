@@ -0,0 +1,72 @@
+package debughttp
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"yanm/internal/logctx"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_RequestID(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+	srv, err := NewServer(Config{ListenAddress: ":0", AccessLog: AccessLogConfig{Enabled: true}}, logger)
+	require.NoError(t, err)
+
+	var handlerID string
+	var ctxAttrs []slog.Attr
+	require.NoError(t, srv.RegisterPage(DebugRoute{
+		Path: "/test",
+		Handler: http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+			handlerID = RequestID(r.Context())
+			ctxAttrs = logctx.Attrs(r.Context())
+			Logger(r.Context(), logger).InfoContext(r.Context(), "Handling test page")
+		}),
+	}))
+
+	testCases := []struct {
+		name     string
+		sent     string
+		expectID string // empty when a new ID is generated
+	}{
+		{name: "generated"},
+		{name: "from client", sent: "abc-123", expectID: "abc-123"},
+		{name: "invalid from client", sent: "has spaces\nand newlines"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			buf.Reset()
+			req := httptest.NewRequest(http.MethodGet, "/test/", nil)
+			if tc.sent != "" {
+				req.Header.Set(RequestIDHeader, tc.sent)
+			}
+			rr := httptest.NewRecorder()
+			srv.httpServer.Handler.ServeHTTP(rr, req)
+
+			id := rr.Header().Get(RequestIDHeader)
+			if tc.expectID != "" {
+				assert.Equal(t, tc.expectID, id)
+			} else {
+				assert.Len(t, id, 16)
+			}
+			assert.Equal(t, id, handlerID)
+			assert.Equal(t, []slog.Attr{slog.String("requestID", id)}, ctxAttrs)
+			// both the handler and the access log lines carry the ID.
+			assert.Equal(t, 2, bytes.Count(buf.Bytes(), []byte("requestID="+id)), buf.String())
+		})
+	}
+}
+
+func TestLogger_OutsideRequest(t *testing.T) {
+	fallback := slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil))
+	assert.Same(t, fallback, Logger(context.Background(), fallback))
+	assert.Empty(t, RequestID(context.Background()))
+}
@@ -0,0 +1,117 @@
+package debughttp
+
+import (
+	"fmt"
+	"html/template"
+	"net/http"
+	"os"
+	"runtime"
+	"time"
+
+	"yanm/internal/debughttp/debughandler"
+)
+
+const _runtimePage = `
+<h1>Runtime</h1>
+<table>
+	<tr><th>Goroutines</th><td>{{.Goroutines}}</td></tr>
+	<tr><th>GOMAXPROCS / CPUs</th><td>{{.GOMAXPROCS}} / {{.NumCPU}}</td></tr>
+	<tr><th>Open File Descriptors</th><td>{{with .OpenFDs}}{{.}}{{else}}unavailable{{end}}</td></tr>
+</table>
+
+<h2>Memory</h2>
+<table>
+	<tr><th>Heap In Use</th><td>{{bytes .HeapInuseBytes}}</td></tr>
+	<tr><th>Heap Allocated</th><td>{{bytes .HeapAllocBytes}} in {{.HeapObjects}} objects</td></tr>
+	<tr><th>Heap Reserved</th><td>{{bytes .HeapSysBytes}}</td></tr>
+	<tr><th>Total Reserved</th><td>{{bytes .SysBytes}}</td></tr>
+</table>
+
+<h2>Garbage Collection</h2>
+<table>
+	<tr><th>Collections</th><td>{{.NumGC}}</td></tr>
+	<tr><th>Last Collection</th><td>{{if .LastGC.IsZero}}Never{{else}}{{.LastGC.Format "2006-01-02 15:04:05"}}{{end}}</td></tr>
+	<tr><th>Next Collection At</th><td>{{bytes .NextGCBytes}}</td></tr>
+	<tr><th>Total Pause</th><td>{{.PauseTotal}}</td></tr>
+	<tr><th>Last Pause</th><td>{{.LastPause}}</td></tr>
+</table>
+`
+
+var _runtimeTmpl = template.Must(template.New("runtime").Funcs(template.FuncMap{
+	"bytes": formatBytes,
+}).Parse(_runtimePage))
+
+// runtimeStats is the data behind both views of the runtime page.
+type runtimeStats struct {
+	Goroutines int  `json:"goroutines"`
+	GOMAXPROCS int  `json:"gomaxprocs"`
+	NumCPU     int  `json:"num_cpu"`
+	OpenFDs    *int `json:"open_fds,omitempty"` // nil where /proc/self/fd is unavailable
+
+	HeapAllocBytes uint64 `json:"heap_alloc_bytes"`
+	HeapInuseBytes uint64 `json:"heap_inuse_bytes"`
+	HeapSysBytes   uint64 `json:"heap_sys_bytes"`
+	HeapObjects    uint64 `json:"heap_objects"`
+	SysBytes       uint64 `json:"sys_bytes"`
+
+	NumGC       uint32        `json:"num_gc"`
+	LastGC      time.Time     `json:"last_gc"`
+	NextGCBytes uint64        `json:"next_gc_bytes"`
+	PauseTotal  time.Duration `json:"pause_total_ns"`
+	LastPause   time.Duration `json:"last_pause_ns"`
+}
+
+// NewRuntimeDebugPageProvider creates a debug page reporting the goroutine count, heap
+// usage, garbage collection and open file descriptors of the process, e.g. to diagnose
+// resource issues on small devices.
+// The handler returned is the raw content-producing handler.
+func NewRuntimeDebugPageProvider() http.Handler {
+	return debughandler.NewNegotiatingHandler(func(*http.Request) (any, error) {
+		return readRuntimeStats(), nil
+	}, _runtimeTmpl)
+}
+
+func readRuntimeStats() runtimeStats {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	stats := runtimeStats{
+		Goroutines: runtime.NumGoroutine(),
+		GOMAXPROCS: runtime.GOMAXPROCS(0),
+		NumCPU:     runtime.NumCPU(),
+
+		HeapAllocBytes: mem.HeapAlloc,
+		HeapInuseBytes: mem.HeapInuse,
+		HeapSysBytes:   mem.HeapSys,
+		HeapObjects:    mem.HeapObjects,
+		SysBytes:       mem.Sys,
+
+		NumGC:       mem.NumGC,
+		NextGCBytes: mem.NextGC,
+		PauseTotal:  time.Duration(mem.PauseTotalNs),
+	}
+	if mem.NumGC > 0 {
+		stats.LastGC = time.Unix(0, int64(mem.LastGC))
+		stats.LastPause = time.Duration(mem.PauseNs[(mem.NumGC+255)%256])
+	}
+	if fds, err := os.ReadDir("/proc/self/fd"); err == nil {
+		// the directory listing itself holds one descriptor open.
+		open := len(fds) - 1
+		stats.OpenFDs = &open
+	}
+	return stats
+}
+
+// formatBytes renders a byte count with a binary unit, e.g. 1.5 MiB.
+func formatBytes(n uint64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := uint64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
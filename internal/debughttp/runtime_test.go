@@ -0,0 +1,47 @@
+package debughttp
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRuntimeDebugPage(t *testing.T) {
+	runtime.GC()
+	page := NewRuntimeDebugPageProvider()
+
+	req := httptest.NewRequest(http.MethodGet, "/debug/runtime/", nil)
+	rr := httptest.NewRecorder()
+	page.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), "<th>Goroutines</th>")
+	assert.Contains(t, rr.Body.String(), "iB</td>")
+
+	req.Header.Set("Accept", "application/json")
+	rr = httptest.NewRecorder()
+	page.ServeHTTP(rr, req)
+	require.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+
+	var stats runtimeStats
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &stats))
+	assert.Positive(t, stats.Goroutines)
+	assert.Positive(t, stats.HeapInuseBytes)
+	assert.Positive(t, stats.NumGC)
+	assert.False(t, stats.LastGC.IsZero())
+	if runtime.GOOS == "linux" {
+		require.NotNil(t, stats.OpenFDs)
+		assert.Positive(t, *stats.OpenFDs)
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	assert.Equal(t, "512 B", formatBytes(512))
+	assert.Equal(t, "1.5 KiB", formatBytes(1536))
+	assert.Equal(t, "3.0 MiB", formatBytes(3<<20))
+	assert.Equal(t, "2.0 GiB", formatBytes(2<<30))
+}
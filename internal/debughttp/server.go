@@ -1,18 +1,25 @@
 package debughttp
 
 import (
-	"bytes" 
+	"bytes"
+	"cmp"
 	"context"
 	"embed"
-	"errors" 
+	"errors"
 	"fmt"
-	htmltemplate "html/template" 
+	htmltemplate "html/template"
 	"io/fs"
 	"log/slog"
+	"net"
 	"net/http"
+	"net/netip"
+	"slices"
 	"strings"
-	"sync" 
+	"sync"
+	"time"
 	"yanm/internal/debughttp/debughandler"
+
+	"github.com/prometheus/client_golang/prometheus"
 )
 
 // NavVisibility determines if a debug route should be visible in navigation links.
@@ -32,23 +39,99 @@ type DebugRoute struct {
 	Description string        // optional
 	Handler     http.Handler  // required
 	Visibility  NavVisibility // Controls inclusion in navigation links
+	Group       string        // optional, nav section the route is listed under
+	Order       int           // optional, position within its group, lower first
+	AutoRefresh bool          // optional, reload the page every Config.RefreshInterval
+}
+
+// sortedRoutes returns routes in display order: ungrouped routes first, then each group
+// ordered by the lowest Order among its routes, then routes by Order within their group.
+// Routes that compare equal keep their registration order.
+func sortedRoutes(routes []DebugRoute) []DebugRoute {
+	groupOrder := make(map[string]int)
+	for _, rt := range routes {
+		if order, ok := groupOrder[rt.Group]; !ok || rt.Order < order {
+			groupOrder[rt.Group] = rt.Order
+		}
+	}
+
+	sorted := slices.Clone(routes)
+	slices.SortStableFunc(sorted, func(a, b DebugRoute) int {
+		switch {
+		case a.Group == b.Group:
+		case a.Group == "":
+			return -1
+		case b.Group == "":
+			return 1
+		default:
+			return cmp.Or(cmp.Compare(groupOrder[a.Group], groupOrder[b.Group]), cmp.Compare(a.Group, b.Group))
+		}
+		return cmp.Compare(a.Order, b.Order)
+	})
+	return sorted
 }
 
 // Config holds the configuration for the debug HTTP server.
 type Config struct {
+	// ListenAddress is a host:port, or unix:///path/to.sock to listen on a unix domain socket.
 	ListenAddress string
+	// AllowedCIDRs, when set, rejects requests from other addresses with 403.
+	AllowedCIDRs []netip.Prefix
+	// Auth protects every route, including the control actions, when set.
+	Auth AuthConfig
+	// TLS serves the debug server over HTTPS when set.
+	TLS TLSConfig
+	// AccessLog logs every request when enabled.
+	AccessLog AccessLogConfig
+	// RateLimit limits the control requests, e.g. triggering a speed test, per client IP.
+	RateLimit RateLimitConfig
+	// Pprof registers the net/http/pprof profiling handlers under /debug/pprof/.
+	Pprof bool
+
+	// ReadTimeout, WriteTimeout and IdleTimeout bound a connection like the http.Server
+	// fields of the same name, zero means no timeout. Streaming handlers such as the
+	// monitor events clear the write deadline themselves.
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+	IdleTimeout  time.Duration
+
+	// RefreshInterval is how often the browser reloads pages registered with AutoRefresh,
+	// e.g. for a wall-mounted display. Zero disables reloading.
+	RefreshInterval time.Duration
+
+	// Registerer, when set, receives metrics about the debug server's own requests.
+	Registerer prometheus.Registerer
+	// Namespace names those metrics, yanm when empty.
+	Namespace string
+
+	// LogPanic logs a panic recovered from a handler, which then responds with a 500, e.g.
+	// to write a crash report. A panic is logged with its stack when it is not set.
+	LogPanic PanicLogger
+
+	// TemplateDir overrides the embedded layout.html, debug_root.html and static/ assets
+	// with the files of the same name in the directory, to brand or restyle the pages.
+	TemplateDir string
 }
 
 // Server represents the debug HTTP server.
 type Server struct {
 	httpServer *http.Server
+	listener   net.Listener // nil until started
 	logger     *slog.Logger
 	mux        *mux
+
+	rootTemplate *htmltemplate.Template
+	metrics      *serverMetrics // nil when not instrumented
+
+	handlerMu  sync.RWMutex
+	middleware []Middleware
+	handler    http.Handler // mux wrapped in middleware
 }
 
 type mux struct {
-	mux    *http.ServeMux
-	logger *slog.Logger
+	mux     *http.ServeMux
+	logger  *slog.Logger
+	refresh time.Duration
 
 	mu     sync.RWMutex
 	routes []DebugRoute
@@ -95,7 +178,7 @@ func (m *mux) handleRoute(route DebugRoute) error {
 }
 
 func (m *mux) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	m.logger.DebugContext(r.Context(),
+	Logger(r.Context(), m.logger).DebugContext(r.Context(),
 		"Debug HTTP request",
 		"method", r.Method,
 		"url", r.URL)
@@ -105,12 +188,13 @@ func (m *mux) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	navLinksResult := make([]debughandler.NavLink, 0, len(m.routes))
 	currentTitle := "Debug"
 	foundTitle := false
+	autoRefresh := false
 
-	for _, rt := range m.routes {
+	for _, rt := range sortedRoutes(m.routes) {
 		// Add to NavLinks only if visibility is not Exclude
 		// Default (zero value) or explicit Include will be added.
 		if rt.Visibility != NavExclude {
-			navLinksResult = append(navLinksResult, debughandler.NavLink{Path: rt.Path, Name: rt.Name})
+			navLinksResult = append(navLinksResult, debughandler.NavLink{Path: rt.Path, Name: rt.Name, Group: rt.Group})
 		}
 
 		// Check if this route matches the current request path to set the title
@@ -119,9 +203,11 @@ func (m *mux) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			if rt.Path == "/" {
 				currentTitle = "Debug Home"
 				foundTitle = true
+				autoRefresh = false
 			} else if !foundTitle || len(rt.Path) > len(currentTitle) { // Prefer more specific match for title
 				currentTitle = rt.Name
 				foundTitle = true
+				autoRefresh = rt.AutoRefresh
 			}
 		}
 	}
@@ -135,6 +221,9 @@ func (m *mux) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		Title:    currentTitle,
 		NavLinks: navLinksResult,
 	}
+	if autoRefresh && m.refresh > 0 {
+		pageCtxData.Refresh = int(m.refresh.Round(time.Second).Seconds())
+	}
 	ctxWithData := debughandler.NewContextWithPageData(r.Context(), pageCtxData)
 	rWithData := r.WithContext(ctxWithData)
 
@@ -145,18 +234,70 @@ func (m *mux) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 // It takes the debug server's configuration and a logger.
 func NewServer(cfg Config, logger *slog.Logger) (*Server, error) {
 	mux := &mux{
-		mux:    http.NewServeMux(),
-		logger: logger, // Use the validated logger
+		mux:     http.NewServeMux(),
+		logger:  logger, // Use the validated logger
+		refresh: cfg.RefreshInterval,
 	}
 	serverLogger := logger.With("component", "debug_server")
 
 	server := Server{
-		mux: mux,
-		httpServer: &http.Server{
-			Addr:    cfg.ListenAddress,
-			Handler: mux, // Use the custom mux
-		},
-		logger: serverLogger, // Use the component-specific logger for the server itself
+		mux:          mux,
+		rootTemplate: _rootTemplate,
+		handler:      mux,          // Use the custom mux
+		logger:       serverLogger, // Use the component-specific logger for the server itself
+	}
+	server.httpServer = &http.Server{
+		Addr:              cfg.ListenAddress,
+		Handler:           &server,
+		ReadHeaderTimeout: cfg.ReadTimeout,
+		ReadTimeout:       cfg.ReadTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+		Protocols:         new(http.Protocols),
+	}
+	// HTTP/2 multiplexes the dashboard's event stream and JSON requests over one
+	// connection; plaintext listeners accept it with prior knowledge (h2c).
+	server.httpServer.Protocols.SetHTTP1(true)
+	server.httpServer.Protocols.SetHTTP2(true)
+	server.httpServer.Protocols.SetUnencryptedHTTP2(true)
+
+	server.Use(assignRequestID(serverLogger))
+	if cfg.Registerer != nil {
+		metrics, err := newServerMetrics(cfg.Registerer, cfg.Namespace)
+		if err != nil {
+			return nil, err
+		}
+		server.metrics = metrics
+		server.Use(server.instrument())
+	}
+	if cfg.AccessLog.Enabled {
+		// before auth, so requests rejected by auth are logged too.
+		server.Use(logAccess(cfg.AccessLog, serverLogger))
+	}
+	if len(cfg.AllowedCIDRs) > 0 {
+		server.Use(allowCIDRs(cfg.AllowedCIDRs))
+	}
+	if cfg.Auth.enabled() {
+		server.Use(requireAuth(cfg.Auth))
+	}
+	if cfg.RateLimit.enabled() {
+		server.Use(rateLimit(cfg.RateLimit))
+	}
+	server.Use(sameOrigin())
+	server.Use(compress())
+	// last, so the access log and metrics see the 500 of a panicking page.
+	panicLogger := cfg.LogPanic
+	if panicLogger == nil {
+		panicLogger = logPanic
+	}
+	server.Use(recoverPanics(serverLogger, panicLogger))
+
+	if cfg.TLS.enabled() {
+		tlsConfig, err := newTLSConfig(cfg.TLS, cfg.ListenAddress)
+		if err != nil {
+			return nil, err
+		}
+		server.httpServer.TLSConfig = tlsConfig
 	}
 
 	// Setup default handlers
@@ -165,6 +306,11 @@ func NewServer(cfg Config, logger *slog.Logger) (*Server, error) {
 		logger.Error("Failed to create sub FS for static assets", "error", err)
 		return nil, err
 	}
+	if cfg.TemplateDir != "" {
+		if staticSubFS, err = server.applyTemplateOverrides(cfg.TemplateDir, staticSubFS); err != nil {
+			return nil, err
+		}
+	}
 
 	if err := mux.Handle(DebugRoute{
 		Path:       "/debug/static/",
@@ -174,6 +320,26 @@ func NewServer(cfg Config, logger *slog.Logger) (*Server, error) {
 		return nil, err
 	}
 
+	if err := mux.Handle(DebugRoute{
+		Path:       _expvarPath,
+		Name:       "Variables",
+		Handler:    expvarHandler(),
+		Visibility: NavExclude,
+	}); err != nil {
+		return nil, err
+	}
+
+	if cfg.Pprof {
+		if err := mux.Handle(DebugRoute{
+			Path:       _pprofPath,
+			Name:       "Profiling",
+			Handler:    pprofHandler(),
+			Visibility: NavExclude,
+		}); err != nil {
+			return nil, err
+		}
+	}
+
 	if err := mux.Handle(DebugRoute{
 		Path:    "/",
 		Name:    "Home",
@@ -189,20 +355,41 @@ func (s *Server) RegisterPage(route DebugRoute) error {
 	return s.mux.Handle(route)
 }
 
-// Start runs the debug HTTP server in a new goroutine.
-func (s *Server) Start(_ context.Context) {
-	s.logger.Info("Starting debug HTTP server", "address", s.httpServer.Addr)
+// Start listens on the server's address and serves the debug HTTP server in a new
+// goroutine. It returns the error of listening, e.g. when the address is in use.
+func (s *Server) Start(_ context.Context) error {
+	s.logger.Info("Starting debug HTTP server", "address", s.httpServer.Addr, "tls", s.httpServer.TLSConfig != nil)
+	listener, err := listen(s.httpServer.Addr)
+	if err != nil {
+		return fmt.Errorf("debug HTTP server failed to listen on %s: %w", s.httpServer.Addr, err)
+	}
+	s.listener = listener
+
+	serve := s.httpServer.Serve
+	if s.httpServer.TLSConfig != nil {
+		// the certificate is already loaded into TLSConfig.
+		serve = func(l net.Listener) error { return s.httpServer.ServeTLS(l, "", "") }
+	}
 	go func() {
-		if err := s.httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		if err := serve(listener); err != nil && err != http.ErrServerClosed {
 			s.logger.Error("Debug HTTP server failed or unexpectedly shut down", "error", err)
 		}
 	}()
+	return nil
 }
 
-// Stop gracefully shuts down the debug HTTP server.
+// Stop gracefully shuts down the debug HTTP server, waiting for active requests until ctx is done.
 func (s *Server) Stop(ctx context.Context) error {
 	s.logger.Info("Stopping debug HTTP server...")
-	return s.httpServer.Shutdown(ctx)
+	err := s.httpServer.Shutdown(ctx)
+	// Shutdown only closes the listener once Serve has taken it over, which a server stopped
+	// right after starting may not have yet, keeping the address in use.
+	if s.listener != nil {
+		if closeErr := s.listener.Close(); closeErr != nil && !errors.Is(closeErr, net.ErrClosed) {
+			err = errors.Join(err, closeErr)
+		}
+	}
+	return err
 }
 
 //go:embed debug_root.html
@@ -219,16 +406,30 @@ func (s *Server) handleRoot(w http.ResponseWriter, r *http.Request) {
 	// It needs to use the same layout mechanism as other pages created via NewHTMLProducingHandler.
 
 	// 1. Prepare the data for the _rootTemplate (which is the specific content for this page)
-	pageContentData := struct {
+	type routeGroup struct {
+		Name     string
 		Handlers []DebugRoute
+	}
+	s.mux.mu.RLock()
+	routes := sortedRoutes(s.mux.routes) // Get the list of registered routes
+	s.mux.mu.RUnlock()
+	var groups []routeGroup
+	for _, rt := range routes {
+		if len(groups) == 0 || groups[len(groups)-1].Name != rt.Group {
+			groups = append(groups, routeGroup{Name: rt.Group})
+		}
+		groups[len(groups)-1].Handlers = append(groups[len(groups)-1].Handlers, rt)
+	}
+	pageContentData := struct {
+		Groups []routeGroup
 	}{
-		Handlers: s.mux.routes, // Get the list of registered routes
+		Groups: groups,
 	}
 
 	// 2. Execute the _rootTemplate to get its HTML content
 	var contentBuf bytes.Buffer
-	if err := _rootTemplate.Execute(&contentBuf, pageContentData); err != nil {
-		s.logger.ErrorContext(r.Context(), "Failed to execute root debug template", "error", err)
+	if err := s.rootTemplate.Execute(&contentBuf, pageContentData); err != nil {
+		Logger(r.Context(), s.logger).ErrorContext(r.Context(), "Failed to execute root debug template", "error", err)
 		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
 		return
 	}
@@ -237,7 +438,7 @@ func (s *Server) handleRoot(w http.ResponseWriter, r *http.Request) {
 	//    Retrieve PageContextData which includes Title and NavLinks prepared by mux.ServeHTTP.
 	pageCtxData, ok := debughandler.PageDataFromContext(r.Context())
 	if !ok {
-		s.logger.ErrorContext(r.Context(), "PageContextData not found in context for root handler, this is unexpected.")
+		Logger(r.Context(), s.logger).ErrorContext(r.Context(), "PageContextData not found in context for root handler, this is unexpected.")
 		pageCtxData = debughandler.PageContextData{
 			Title:    "Debug Home",
 			NavLinks: []debughandler.NavLink{{Path: "/", Name: "Home"}},
@@ -252,6 +453,6 @@ func (s *Server) handleRoot(w http.ResponseWriter, r *http.Request) {
 
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
 	if err := debughandler.ExecuteLayout(w, layoutPageData); err != nil {
-		s.logger.ErrorContext(r.Context(), "Failed to execute debug layout template for root", "error", err)
+		Logger(r.Context(), s.logger).ErrorContext(r.Context(), "Failed to execute debug layout template for root", "error", err)
 	}
 }
@@ -4,10 +4,13 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
+	"time"
 
 	"yanm/internal/debughttp/debughandler"
 
@@ -24,6 +27,53 @@ func TestNewServer(t *testing.T) {
 	require.NotNil(t, srv.mux, "Server's mux should not be nil")
 }
 
+func TestNewServer_Timeouts(t *testing.T) {
+	srv, err := NewServer(Config{
+		ListenAddress: ":0",
+		ReadTimeout:   time.Second,
+		WriteTimeout:  2 * time.Second,
+		IdleTimeout:   3 * time.Second,
+	}, slog.New(slog.NewTextHandler(os.Stdout, nil)))
+	require.NoError(t, err)
+
+	assert.Equal(t, time.Second, srv.httpServer.ReadHeaderTimeout)
+	assert.Equal(t, time.Second, srv.httpServer.ReadTimeout)
+	assert.Equal(t, 2*time.Second, srv.httpServer.WriteTimeout)
+	assert.Equal(t, 3*time.Second, srv.httpServer.IdleTimeout)
+}
+
+func TestNewServer_H2C(t *testing.T) {
+	srv, err := NewServer(Config{ListenAddress: "127.0.0.1:0"}, slog.New(slog.NewTextHandler(os.Stdout, nil)))
+	require.NoError(t, err)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() { _ = srv.httpServer.Serve(listener) }()
+	t.Cleanup(func() { _ = srv.httpServer.Close() })
+
+	for _, tc := range []struct {
+		name       string
+		http2      bool
+		protoMajor int
+	}{
+		{name: "http/1.1", protoMajor: 1},
+		{name: "h2c", http2: true, protoMajor: 2},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			transport := &http.Transport{Protocols: new(http.Protocols)}
+			transport.Protocols.SetHTTP1(!tc.http2)
+			transport.Protocols.SetUnencryptedHTTP2(tc.http2)
+			defer transport.CloseIdleConnections()
+
+			resp, err := (&http.Client{Transport: transport}).Get("http://" + listener.Addr().String() + "/")
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+			assert.Equal(t, tc.protoMajor, resp.ProtoMajor)
+		})
+	}
+}
+
 // TestServer_RegisterPage tests various scenarios for page registration,
 // including input validation, path/name normalization, and successful registration.
 func TestServer_RegisterPage(t *testing.T) {
@@ -436,3 +486,58 @@ func (frw *failingResponseWriter) Write(p []byte) (int, error) {
 	}
 	return n, err
 }
+
+func TestSortedRoutes(t *testing.T) {
+	routes := []DebugRoute{
+		{Path: "/storage/", Group: "System", Order: 20},
+		{Path: "/monitor/", Group: "Monitor", Order: 10},
+		{Path: "/config/", Group: "System", Order: 5},
+		{Path: "/", Order: 100},
+		{Path: "/events/", Group: "Monitor", Order: 10},
+		{Path: "/speedtest/", Group: "Results", Order: 1},
+	}
+
+	var paths []string
+	for _, rt := range sortedRoutes(routes) {
+		paths = append(paths, rt.Path)
+	}
+	// ungrouped first, then Results (1), System (5) and Monitor (10), equal orders keep registration order.
+	assert.Equal(t, []string{"/", "/speedtest/", "/config/", "/storage/", "/monitor/", "/events/"}, paths)
+}
+
+func TestMux_ServeHTTP_NavGroups(t *testing.T) {
+	srv, err := NewServer(Config{ListenAddress: ":0"}, slog.New(slog.NewTextHandler(os.Stdout, nil)))
+	require.NoError(t, err)
+	page := debughandler.NewHTMLProducingHandler(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	require.NoError(t, srv.RegisterPage(DebugRoute{Path: "/debug/storage", Name: "Storage", Group: "System", Handler: page}))
+	require.NoError(t, srv.RegisterPage(DebugRoute{Path: "/debug/speedtest", Name: "Speed Test", Group: "Results", Handler: page}))
+
+	rr := httptest.NewRecorder()
+	srv.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/debug/storage/", nil))
+
+	nav, _, _ := strings.Cut(rr.Body.String(), "</nav>")
+	results := strings.Index(nav, "Results:")
+	system := strings.Index(nav, "System:")
+	require.NotEqual(t, -1, results)
+	require.NotEqual(t, -1, system)
+	assert.Less(t, strings.Index(nav, `href="/"`), results, "ungrouped routes come first")
+	assert.Less(t, results, strings.Index(nav, `href="/debug/speedtest/"`))
+	assert.Less(t, strings.Index(nav, `href="/debug/speedtest/"`), system)
+	assert.Less(t, system, strings.Index(nav, `href="/debug/storage/"`))
+}
+
+func TestMux_ServeHTTP_AutoRefresh(t *testing.T) {
+	srv, err := NewServer(Config{ListenAddress: ":0", RefreshInterval: 30 * time.Second}, slog.New(slog.NewTextHandler(os.Stdout, nil)))
+	require.NoError(t, err)
+	page := debughandler.NewHTMLProducingHandler(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	require.NoError(t, srv.RegisterPage(DebugRoute{Path: "/debug/speedtest", Name: "Speed Test", Handler: page, AutoRefresh: true}))
+	require.NoError(t, srv.RegisterPage(DebugRoute{Path: "/debug/storage", Name: "Storage", Handler: page}))
+
+	rr := httptest.NewRecorder()
+	srv.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/debug/speedtest/", nil))
+	assert.Contains(t, rr.Body.String(), `<meta http-equiv="refresh" content="30">`)
+
+	rr = httptest.NewRecorder()
+	srv.httpServer.Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/debug/storage/", nil))
+	assert.NotContains(t, rr.Body.String(), `http-equiv="refresh"`)
+}
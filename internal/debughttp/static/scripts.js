@@ -1 +1,65 @@
-// Future JavaScript code will go here
+// Shared helpers for the debug pages. Page scripts should run on DOMContentLoaded,
+// as this file is loaded after the page content.
+var yanm = (function () {
+    const svgNS = "http://www.w3.org/2000/svg";
+    const width = 600, height = 200, padLeft = 50, padRight = 10, padTop = 10, padBottom = 30;
+
+    function el(name, attrs, text) {
+        const node = document.createElementNS(svgNS, name);
+        for (const key in attrs) {
+            node.setAttribute(key, attrs[key]);
+        }
+        if (text !== undefined) {
+            node.textContent = text;
+        }
+        return node;
+    }
+
+    // timeSeries renders an SVG line chart into container. series is a list of
+    // {name, color, points: [{time: Date, value: number}]} sharing one y axis in unit.
+    function timeSeries(container, series, unit) {
+        container.replaceChildren();
+        const points = series.flatMap(s => s.points);
+        if (points.length === 0) {
+            container.textContent = "No results yet.";
+            return;
+        }
+
+        const times = points.map(p => p.time.getTime());
+        const minTime = Math.min(...times), maxTime = Math.max(...times);
+        const maxValue = Math.max(...points.map(p => p.value)) || 1;
+        const x = t => padLeft + (maxTime === minTime ? 0.5 : (t - minTime) / (maxTime - minTime)) * (width - padLeft - padRight);
+        const y = v => height - padBottom - (v / maxValue) * (height - padTop - padBottom);
+
+        const svg = el("svg", {viewBox: `0 0 ${width} ${height}`, width: "100%", role: "img"});
+        svg.appendChild(el("line", {x1: padLeft, y1: y(0), x2: width - padRight, y2: y(0), stroke: "#999"}));
+        svg.appendChild(el("text", {x: padLeft - 5, y: y(maxValue) + 10, "text-anchor": "end", "font-size": 11}, maxValue.toFixed(1) + " " + unit));
+        svg.appendChild(el("text", {x: padLeft - 5, y: y(0), "text-anchor": "end", "font-size": 11}, "0"));
+        svg.appendChild(el("text", {x: padLeft, y: height - 8, "font-size": 11}, new Date(minTime).toLocaleString()));
+        svg.appendChild(el("text", {x: width - padRight, y: height - 8, "text-anchor": "end", "font-size": 11}, new Date(maxTime).toLocaleString()));
+
+        for (const s of series) {
+            const sorted = s.points.slice().sort((a, b) => a.time - b.time);
+            const path = sorted.map(p => `${x(p.time.getTime()).toFixed(1)},${y(p.value).toFixed(1)}`).join(" ");
+            svg.appendChild(el("polyline", {points: path, fill: "none", stroke: s.color, "stroke-width": 2}));
+            for (const p of sorted) {
+                const dot = el("circle", {cx: x(p.time.getTime()), cy: y(p.value), r: 3, fill: s.color});
+                dot.appendChild(el("title", {}, `${s.name}: ${p.value.toFixed(2)} ${unit} at ${p.time.toLocaleString()}`));
+                svg.appendChild(dot);
+            }
+        }
+        container.appendChild(svg);
+
+        const legend = document.createElement("p");
+        legend.className = "chart-legend";
+        for (const s of series) {
+            const item = document.createElement("span");
+            item.style.color = s.color;
+            item.textContent = "■ " + s.name + " ";
+            legend.appendChild(item);
+        }
+        container.appendChild(legend);
+    }
+
+    return {timeSeries: timeSeries};
+})();
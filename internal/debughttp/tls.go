@@ -0,0 +1,93 @@
+package debughttp
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"time"
+)
+
+const _selfSignedValidity = 365 * 24 * time.Hour
+
+// TLSConfig serves the debug server over HTTPS, from CertFile and KeyFile or,
+// with SelfSigned, from a certificate generated at startup.
+type TLSConfig struct {
+	CertFile   string
+	KeyFile    string
+	SelfSigned bool
+}
+
+func (c TLSConfig) enabled() bool {
+	return c.CertFile != "" || c.SelfSigned
+}
+
+// newTLSConfig loads or generates the server certificate.
+func newTLSConfig(cfg TLSConfig, listenAddress string) (*tls.Config, error) {
+	var (
+		cert tls.Certificate
+		err  error
+	)
+	if cfg.CertFile != "" {
+		cert, err = tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load debug server certificate: %w", err)
+		}
+	} else {
+		cert, err = selfSignedCertificate(listenAddress)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate self-signed debug server certificate: %w", err)
+		}
+	}
+
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}, nil
+}
+
+// selfSignedCertificate returns a certificate valid for localhost, the hostname and the
+// listen address, so it can at least be pinned by clients.
+func selfSignedCertificate(listenAddress string) (tls.Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{Organization: []string{"yanm"}, CommonName: "yanm debug server"},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(_selfSignedValidity),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		DNSNames:              []string{"localhost"},
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback},
+	}
+	if hostname, err := os.Hostname(); err == nil {
+		template.DNSNames = append(template.DNSNames, hostname)
+	}
+	if host, _, err := net.SplitHostPort(listenAddress); err == nil {
+		if ip := net.ParseIP(host); ip != nil && !ip.IsUnspecified() {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}, nil
+}
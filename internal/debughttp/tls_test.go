@@ -0,0 +1,67 @@
+package debughttp
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewServer_TLS(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+
+	generated, err := selfSignedCertificate("127.0.0.1:8090")
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalPKCS8PrivateKey(generated.PrivateKey)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	require.NoError(t, os.WriteFile(certFile,
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: generated.Certificate[0]}), 0o600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}), 0o600))
+
+	testCases := []struct {
+		name string
+		tls  TLSConfig
+	}{
+		{name: "cert files", tls: TLSConfig{CertFile: certFile, KeyFile: keyFile}},
+		{name: "self signed", tls: TLSConfig{SelfSigned: true}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			srv, err := NewServer(Config{ListenAddress: "127.0.0.1:0", TLS: tc.tls}, logger)
+			require.NoError(t, err)
+			require.NotNil(t, srv.httpServer.TLSConfig)
+
+			ts := httptest.NewUnstartedServer(srv.httpServer.Handler)
+			ts.TLS = srv.httpServer.TLSConfig
+			ts.StartTLS()
+			defer ts.Close()
+
+			leaf, err := x509.ParseCertificate(srv.httpServer.TLSConfig.Certificates[0].Certificate[0])
+			require.NoError(t, err)
+			assert.Contains(t, leaf.DNSNames, "localhost")
+			pool := x509.NewCertPool()
+			pool.AddCert(leaf)
+
+			client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}}}
+			resp, err := client.Get(ts.URL)
+			require.NoError(t, err)
+			resp.Body.Close()
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+		})
+	}
+
+	_, err = NewServer(Config{ListenAddress: ":0", TLS: TLSConfig{CertFile: filepath.Join(dir, "missing.pem")}}, logger)
+	require.ErrorContains(t, err, "failed to load debug server certificate")
+}
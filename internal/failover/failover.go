@@ -0,0 +1,426 @@
+// Package failover detects traffic shifting between the uplinks of a multi-WAN host,
+// timing how long it stays off the primary link.
+package failover
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// _maxFailovers bounds the failovers kept for the debug page.
+const _maxFailovers = 100
+
+// _httpTimeout bounds looking up a public IP and posting to the webhook.
+const _httpTimeout = 10 * time.Second
+
+// The targets the default route is looked up for. Looking up a route sends no packets.
+const (
+	_routeTarget4 = "1.1.1.1:53"
+	_routeTarget6 = "[2606:4700:4700::1111]:53"
+)
+
+// Alert events posted to the webhook.
+const (
+	EventFailover = "failover"
+	EventRestored = "restored"
+)
+
+// Link is an uplink the traffic can take.
+type Link struct {
+	Name string
+	// Address returns the link's local address, looked up on every check as the address
+	// of an interface may change when it reconnects.
+	Address func() (string, error)
+}
+
+// Config configures the detection.
+type Config struct {
+	// Interval between checks of the link the traffic takes.
+	Interval time.Duration
+	// PublicIPURL returns the public IP address of the caller as plain text. When set, the
+	// public IP of the default route and of every link is looked up on each check, and
+	// the traffic takes the link sharing the default route's public IP when the route's
+	// local address is none of the links', e.g. behind a failover router.
+	PublicIPURL string
+	// WebhookURL is posted an Alert on every failover and restore.
+	WebhookURL string
+	// Namespace names the metrics, yanm when empty.
+	Namespace string
+}
+
+// Failover is a period the traffic was off the primary link, the first one.
+type Failover struct {
+	From string `json:"from"`
+	// To is the link the traffic took last, empty when it took none of the links.
+	To    string    `json:"to"`
+	Start time.Time `json:"start"`
+	// End is when the traffic returned to the primary link, nil while it is off it.
+	End *time.Time `json:"end,omitempty"`
+	// DurationSeconds is how long the service was degraded, up to now while it still is.
+	DurationSeconds float64 `json:"duration_seconds"`
+}
+
+// LinkStatus is a link as last checked.
+type LinkStatus struct {
+	Name     string `json:"name"`
+	Address  string `json:"address"`
+	PublicIP string `json:"public_ip,omitempty"`
+	Active   bool   `json:"active"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Status is the link the traffic takes, and the failovers since the process started.
+type Status struct {
+	Primary string `json:"primary"`
+	// Active is the link the traffic takes, empty when it takes none of the links.
+	Active    string       `json:"active"`
+	Degraded  bool         `json:"degraded"`
+	LastCheck time.Time    `json:"last_check"`
+	Links     []LinkStatus `json:"links"`
+	// Failovers are newest first.
+	Failovers []Failover `json:"failovers"`
+}
+
+// Alert is the JSON object posted to the webhook.
+type Alert struct {
+	// Event is EventFailover or EventRestored.
+	Event string    `json:"event"`
+	Time  time.Time `json:"time"`
+	From  string    `json:"from"`
+	To    string    `json:"to"`
+	// DurationSeconds is how long the service was degraded, set when restored.
+	DurationSeconds float64 `json:"duration_seconds,omitempty"`
+}
+
+// Detector checks which link the traffic takes on every interval, reporting failovers
+// away from the primary link and the return to it.
+type Detector struct {
+	logger *slog.Logger
+	links  []Link
+	cfg    Config
+	client *http.Client
+
+	mu        sync.Mutex
+	checked   bool
+	active    string
+	lastCheck time.Time
+	statuses  []LinkStatus
+	failovers []Failover // newest first, the first one is open while End is nil
+
+	activeLink    *prometheus.GaugeVec
+	degraded      prometheus.Gauge
+	failoverCount prometheus.Counter
+
+	// route returns the local address of the default route over network, replaced in tests.
+	route func(ctx context.Context, network string) (string, error)
+	// publicIP returns the public IP of traffic sent from source, the default route when
+	// empty, replaced in tests.
+	publicIP func(ctx context.Context, source string) (string, error)
+
+	clock clock.Clock
+}
+
+// NewDetector creates a Detector for links, the first one being the primary link, and
+// exports its metrics to registerer when not nil.
+func NewDetector(logger *slog.Logger, links []Link, cfg Config, registerer prometheus.Registerer) (*Detector, error) {
+	namespace := cmp.Or(cfg.Namespace, "yanm")
+	d := &Detector{
+		logger: logger,
+		links:  links,
+		cfg:    cfg,
+		client: &http.Client{Timeout: _httpTimeout},
+		activeLink: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "failover",
+			Name:      "active_link",
+			Help:      "1 for the link the traffic takes, 0 for the others.",
+		}, []string{"link"}),
+		degraded: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "failover",
+			Name:      "degraded",
+			Help:      "1 while the traffic is off the primary link.",
+		}),
+		failoverCount: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "failover",
+			Name:      "failovers_total",
+			Help:      "Number of times the traffic left the primary link.",
+		}),
+		clock: clock.New(),
+	}
+	d.route = routeSource
+	d.publicIP = d.lookupPublicIP
+	if registerer != nil {
+		for _, c := range []prometheus.Collector{d.activeLink, d.degraded, d.failoverCount} {
+			if err := registerer.Register(c); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return d, nil
+}
+
+// Run checks the link the traffic takes every interval until ctx is done.
+func (d *Detector) Run(ctx context.Context) {
+	ticker := d.clock.Ticker(d.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		d.Check(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Check looks up the link the traffic takes, and reports a failover or restore when it
+// changed.
+func (d *Detector) Check(ctx context.Context) {
+	statuses := make([]LinkStatus, len(d.links))
+	for i, link := range d.links {
+		statuses[i] = LinkStatus{Name: link.Name}
+		addr, err := link.Address()
+		if err != nil {
+			statuses[i].Error = err.Error()
+			continue
+		}
+		statuses[i].Address = addr
+	}
+
+	active := d.activeByRoute(ctx, statuses)
+	if d.cfg.PublicIPURL != "" {
+		byIP := d.activeByPublicIP(ctx, statuses)
+		if active == "" {
+			active = byIP
+		}
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	now := d.clock.Now()
+	for i := range statuses {
+		statuses[i].Active = statuses[i].Name == active
+		d.activeLink.WithLabelValues(statuses[i].Name).Set(boolValue(statuses[i].Active))
+		if d.checked && statuses[i].PublicIP != "" && d.statuses[i].PublicIP != "" && statuses[i].PublicIP != d.statuses[i].PublicIP {
+			d.logger.InfoContext(ctx, "Public IP of link changed", "link", statuses[i].Name,
+				"from", d.statuses[i].PublicIP, "to", statuses[i].PublicIP)
+		}
+	}
+	d.statuses, d.lastCheck = statuses, now
+
+	prev, checked := d.active, d.checked
+	d.active, d.checked = active, true
+	if checked && active == prev {
+		return
+	}
+	primary := d.links[0].Name
+	switch {
+	case active == primary && checked:
+		d.restore(ctx, now)
+	case active == primary:
+	case !checked || prev == primary:
+		d.failover(ctx, now, active)
+	default:
+		// the traffic moved between the backup links, it is still off the primary link.
+		d.failovers[0].To = active
+		d.logger.WarnContext(ctx, "Traffic moved to another backup link", "from", prev, "to", active)
+	}
+}
+
+// failover opens a failover to the link to, d.mu must be held.
+func (d *Detector) failover(ctx context.Context, now time.Time, to string) {
+	f := Failover{From: d.links[0].Name, To: to, Start: now}
+	d.failovers = append([]Failover{f}, d.failovers...)
+	if len(d.failovers) > _maxFailovers {
+		d.failovers = d.failovers[:_maxFailovers]
+	}
+	d.degraded.Set(1)
+	d.failoverCount.Inc()
+	d.logger.WarnContext(ctx, "Traffic failed over from the primary link", "from", f.From, "to", to)
+	d.alert(ctx, Alert{Event: EventFailover, Time: now, From: f.From, To: to})
+}
+
+// restore closes the open failover, d.mu must be held.
+func (d *Detector) restore(ctx context.Context, now time.Time) {
+	if len(d.failovers) == 0 || d.failovers[0].End != nil {
+		return
+	}
+	f := &d.failovers[0]
+	f.End = &now
+	f.DurationSeconds = now.Sub(f.Start).Seconds()
+	d.degraded.Set(0)
+	d.logger.WarnContext(ctx, "Traffic returned to the primary link", "from", f.To, "to", f.From,
+		"degradedFor", now.Sub(f.Start).Round(time.Second).String())
+	d.alert(ctx, Alert{Event: EventRestored, Time: now, From: f.To, To: f.From, DurationSeconds: f.DurationSeconds})
+}
+
+// activeByRoute returns the link whose address the default route is sent from, empty
+// when none of them.
+func (d *Detector) activeByRoute(ctx context.Context, statuses []LinkStatus) string {
+	for _, network := range []string{"udp4", "udp6"} {
+		source, err := d.route(ctx, network)
+		if err != nil {
+			d.logger.DebugContext(ctx, "Failed to look up the default route", "network", network, "error", err)
+			continue
+		}
+		for _, s := range statuses {
+			if s.Address != "" && s.Address == source {
+				return s.Name
+			}
+		}
+	}
+	return ""
+}
+
+// activeByPublicIP sets the public IP of every link, returning the one sharing the
+// default route's public IP, empty when none of them.
+func (d *Detector) activeByPublicIP(ctx context.Context, statuses []LinkStatus) string {
+	for i := range statuses {
+		if statuses[i].Address == "" {
+			continue
+		}
+		ip, err := d.publicIP(ctx, statuses[i].Address)
+		if err != nil {
+			statuses[i].Error = err.Error()
+			continue
+		}
+		statuses[i].PublicIP = ip
+	}
+
+	ip, err := d.publicIP(ctx, "")
+	if err != nil {
+		d.logger.DebugContext(ctx, "Failed to look up the public IP", "error", err)
+		return ""
+	}
+	for _, s := range statuses {
+		if s.PublicIP == ip {
+			return s.Name
+		}
+	}
+	return ""
+}
+
+// alert posts a to the webhook, if any, d.mu must be held.
+func (d *Detector) alert(ctx context.Context, a Alert) {
+	if d.cfg.WebhookURL == "" {
+		return
+	}
+	body, err := json.Marshal(a)
+	if err != nil {
+		d.logger.ErrorContext(ctx, "Failed to encode the failover alert", "error", err)
+		return
+	}
+	// posted in the background, so a slow webhook does not hold up the checks.
+	go func() {
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), _httpTimeout)
+		defer cancel()
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.cfg.WebhookURL, bytes.NewReader(body))
+		if err != nil {
+			d.logger.ErrorContext(ctx, "Failed to post the failover alert", "error", err)
+			return
+		}
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := d.client.Do(req)
+		if err != nil {
+			d.logger.ErrorContext(ctx, "Failed to post the failover alert", "error", err)
+			return
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			d.logger.ErrorContext(ctx, "Failed to post the failover alert", "status", resp.Status)
+		}
+	}()
+}
+
+// Status returns the link the traffic takes, and the failovers since the process started.
+func (d *Detector) Status() Status {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := d.clock.Now()
+	failovers := make([]Failover, len(d.failovers))
+	copy(failovers, d.failovers)
+	degraded := len(failovers) > 0 && failovers[0].End == nil
+	if degraded {
+		failovers[0].DurationSeconds = now.Sub(failovers[0].Start).Seconds()
+	}
+	return Status{
+		Primary:   d.links[0].Name,
+		Active:    d.active,
+		Degraded:  degraded,
+		LastCheck: d.lastCheck,
+		Links:     append([]LinkStatus(nil), d.statuses...),
+		Failovers: failovers,
+	}
+}
+
+// routeSource returns the local address the default route sends from over network, udp4
+// or udp6. Connecting a UDP socket only looks up the route.
+func routeSource(ctx context.Context, network string) (string, error) {
+	target := _routeTarget4
+	if network == "udp6" {
+		target = _routeTarget6
+	}
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, network, target)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+	return conn.LocalAddr().(*net.UDPAddr).IP.String(), nil
+}
+
+// lookupPublicIP fetches PublicIPURL from source, the default route when empty.
+func (d *Detector) lookupPublicIP(ctx context.Context, source string) (string, error) {
+	client := d.client
+	if source != "" {
+		dialer := &net.Dialer{LocalAddr: &net.TCPAddr{IP: net.ParseIP(source)}}
+		client = &http.Client{
+			Timeout:   _httpTimeout,
+			Transport: &http.Transport{DialContext: dialer.DialContext, DisableKeepAlives: true},
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, d.cfg.PublicIPURL, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to look up the public IP: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to look up the public IP: %s", resp.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 256))
+	if err != nil {
+		return "", fmt.Errorf("failed to look up the public IP: %w", err)
+	}
+	ip := strings.TrimSpace(string(body))
+	if net.ParseIP(ip) == nil {
+		return "", fmt.Errorf("public IP lookup returned %q, not an IP address", ip)
+	}
+	return ip, nil
+}
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,82 @@
+package failover
+
+import (
+	"html/template"
+	"net/http"
+
+	"yanm/internal/debughttp"
+	"yanm/internal/debughttp/debughandler"
+)
+
+const _failoverPage = `
+<h1>Failover</h1>
+<p>
+	{{ if .Degraded }}<strong>The traffic is off the primary link {{ .Primary }}{{ with .Active }}, taking {{ . }}{{ else }}, taking none of the links{{ end }}.</strong>
+	{{ else if .Active }}The traffic takes the primary link {{ .Primary }}.
+	{{ else }}Not checked yet.{{ end }}
+	{{ if not .LastCheck.IsZero }}Last checked {{ .LastCheck.Format "2006-01-02 15:04:05 MST" }}.{{ end }}
+</p>
+
+<h2>Links</h2>
+<table>
+	<tr>
+		<th>Link</th>
+		<th>Address</th>
+		<th>Public IP</th>
+		<th>Active</th>
+		<th>Error</th>
+	</tr>
+	{{ range .Links }}
+	<tr>
+		<td>{{ .Name }}</td>
+		<td>{{ .Address }}</td>
+		<td>{{ .PublicIP }}</td>
+		<td>{{ if .Active }}Yes{{ end }}</td>
+		<td>{{ .Error }}</td>
+	</tr>
+	{{ end }}
+</table>
+
+<h2>Failovers</h2>
+<table>
+	<tr>
+		<th>Start</th>
+		<th>End</th>
+		<th>From</th>
+		<th>To</th>
+		<th>Degraded For</th>
+	</tr>
+	{{ range .Failovers }}
+	<tr>
+		<td>{{ .Start.Format "2006-01-02 15:04:05 MST" }}</td>
+		<td>{{ with .End }}{{ .Format "2006-01-02 15:04:05 MST" }}{{ else }}Ongoing{{ end }}</td>
+		<td>{{ .From }}</td>
+		<td>{{ with .To }}{{ . }}{{ else }}none{{ end }}</td>
+		<td>{{ printf "%.0f" .DurationSeconds }} s</td>
+	</tr>
+	{{ else }}
+	<tr><td colspan="5">The traffic has not left the primary link.</td></tr>
+	{{ end }}
+</table>
+`
+
+var _failoverPageTemplate = template.Must(template.New("failover").Parse(_failoverPage))
+
+func (d *Detector) view(*http.Request) (any, error) {
+	return d.Status(), nil
+}
+
+var _ debughttp.PageProvider = (*Detector)(nil)
+
+// DebugRoutes returns the page listing the links and the failovers.
+func (d *Detector) DebugRoutes() []debughttp.DebugRoute {
+	return []debughttp.DebugRoute{{
+		Path:        "/debug/failover",
+		Name:        "Failover",
+		Description: "Shows the link the traffic takes and the failovers away from the primary link.",
+		Handler: debughandler.NewHTMLProducingHandler(
+			debughandler.NewNegotiatingHandler(d.view, _failoverPageTemplate)),
+		Group: "Monitor",
+		Order: 20,
+	}}
+}
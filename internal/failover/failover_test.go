@@ -0,0 +1,186 @@
+package failover
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func address(addr string) func() (string, error) {
+	return func() (string, error) { return addr, nil }
+}
+
+func newTestDetector(t *testing.T, cfg Config) (*Detector, *clock.Mock, *string, *bytes.Buffer) {
+	t.Helper()
+	logs := &bytes.Buffer{}
+	d, err := NewDetector(slog.New(slog.NewTextHandler(logs, nil)), []Link{
+		{Name: "fiber", Address: address("192.168.1.2")},
+		{Name: "lte", Address: address("192.168.8.2")},
+	}, cfg, prometheus.NewRegistry())
+	require.NoError(t, err)
+
+	mockClock := clock.NewMock()
+	mockClock.Set(time.Date(2025, 3, 1, 20, 0, 0, 0, time.UTC))
+	d.clock = mockClock
+	source := "192.168.1.2"
+	d.route = func(_ context.Context, network string) (string, error) {
+		if network == "udp6" {
+			return "", errors.New("network is unreachable")
+		}
+		return source, nil
+	}
+	return d, mockClock, &source, logs
+}
+
+func TestDetector_Failover(t *testing.T) {
+	alerts := make(chan Alert, 2)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var a Alert
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&a))
+		alerts <- a
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	d, mockClock, source, logs := newTestDetector(t, Config{WebhookURL: srv.URL})
+
+	d.Check(ctx)
+	assert.Equal(t, "fiber", d.Status().Active)
+	assert.False(t, d.Status().Degraded)
+	assert.Equal(t, 1.0, testutil.ToFloat64(d.activeLink.WithLabelValues("fiber")))
+
+	// the default route moves to the backup link.
+	mockClock.Add(time.Minute)
+	*source = "192.168.8.2"
+	d.Check(ctx)
+	assert.Equal(t, Alert{Event: EventFailover, Time: mockClock.Now(), From: "fiber", To: "lte"}, <-alerts)
+	assert.Contains(t, logs.String(), `level=WARN msg="Traffic failed over from the primary link" from=fiber to=lte`)
+	assert.Equal(t, 1.0, testutil.ToFloat64(d.degraded))
+	assert.Equal(t, 1.0, testutil.ToFloat64(d.failoverCount))
+	assert.Equal(t, 0.0, testutil.ToFloat64(d.activeLink.WithLabelValues("fiber")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(d.activeLink.WithLabelValues("lte")))
+
+	mockClock.Add(5 * time.Minute)
+	status := d.Status()
+	assert.True(t, status.Degraded)
+	require.Len(t, status.Failovers, 1)
+	assert.Nil(t, status.Failovers[0].End)
+	assert.Equal(t, 300.0, status.Failovers[0].DurationSeconds)
+
+	// and back, closing the failover.
+	mockClock.Add(5 * time.Minute)
+	*source = "192.168.1.2"
+	d.Check(ctx)
+	assert.Equal(t, Alert{Event: EventRestored, Time: mockClock.Now(), From: "lte", To: "fiber", DurationSeconds: 600}, <-alerts)
+	assert.Contains(t, logs.String(), `msg="Traffic returned to the primary link" from=lte to=fiber degradedFor=10m0s`)
+	assert.Equal(t, 0.0, testutil.ToFloat64(d.degraded))
+
+	mockClock.Add(time.Hour)
+	status = d.Status()
+	assert.False(t, status.Degraded)
+	require.Len(t, status.Failovers, 1)
+	require.NotNil(t, status.Failovers[0].End)
+	assert.Equal(t, time.Date(2025, 3, 1, 20, 11, 0, 0, time.UTC), *status.Failovers[0].End)
+	assert.Equal(t, 600.0, status.Failovers[0].DurationSeconds)
+}
+
+func TestDetector_StartsOffPrimary(t *testing.T) {
+	d, _, source, _ := newTestDetector(t, Config{})
+
+	// no link routes the traffic, e.g. every link is down.
+	*source = "10.0.0.2"
+	d.Check(context.Background())
+	status := d.Status()
+	assert.True(t, status.Degraded)
+	assert.Equal(t, "", status.Active)
+	require.Len(t, status.Failovers, 1)
+	assert.Equal(t, "", status.Failovers[0].To)
+
+	*source = "192.168.8.2"
+	d.Check(context.Background())
+	status = d.Status()
+	require.Len(t, status.Failovers, 1, "moving between backup links keeps the failover open")
+	assert.Equal(t, "lte", status.Failovers[0].To)
+}
+
+func TestDetector_PublicIP(t *testing.T) {
+	d, _, source, logs := newTestDetector(t, Config{PublicIPURL: "https://ip.example"})
+	// behind a failover router, the route is the same whichever link the traffic takes.
+	*source = "192.168.0.10"
+	publicIPs := map[string]string{"": "203.0.113.1", "192.168.1.2": "203.0.113.1", "192.168.8.2": "198.51.100.7"}
+	d.publicIP = func(_ context.Context, source string) (string, error) {
+		ip, ok := publicIPs[source]
+		if !ok {
+			return "", fmt.Errorf("no route from %s", source)
+		}
+		return ip, nil
+	}
+
+	d.Check(context.Background())
+	status := d.Status()
+	assert.Equal(t, "fiber", status.Active)
+	assert.Equal(t, []LinkStatus{
+		{Name: "fiber", Address: "192.168.1.2", PublicIP: "203.0.113.1", Active: true},
+		{Name: "lte", Address: "192.168.8.2", PublicIP: "198.51.100.7"},
+	}, status.Links)
+
+	publicIPs[""], publicIPs["192.168.8.2"] = "198.51.100.9", "198.51.100.9"
+	d.Check(context.Background())
+	assert.Equal(t, "lte", d.Status().Active)
+	assert.Contains(t, logs.String(), `msg="Public IP of link changed" link=lte from=198.51.100.7 to=198.51.100.9`)
+}
+
+func TestDetector_LookupPublicIP(t *testing.T) {
+	body := "203.0.113.1\n"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(body))
+	}))
+	defer srv.Close()
+
+	d, _, _, _ := newTestDetector(t, Config{PublicIPURL: srv.URL})
+	ip, err := d.lookupPublicIP(context.Background(), "127.0.0.1")
+	require.NoError(t, err)
+	assert.Equal(t, "203.0.113.1", ip)
+
+	body = "<html>"
+	_, err = d.lookupPublicIP(context.Background(), "")
+	require.EqualError(t, err, `public IP lookup returned "<html>", not an IP address`)
+}
+
+func TestDetector_DebugRoutes(t *testing.T) {
+	d, _, source, _ := newTestDetector(t, Config{})
+	routes := d.DebugRoutes()
+	require.Len(t, routes, 1)
+	assert.Equal(t, "/debug/failover", routes[0].Path)
+
+	d.Check(context.Background())
+	*source = "192.168.8.2"
+	d.Check(context.Background())
+
+	rr := httptest.NewRecorder()
+	routes[0].Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/debug/failover", nil))
+	assert.Contains(t, rr.Body.String(), "The traffic is off the primary link fiber, taking lte.")
+	assert.Contains(t, rr.Body.String(), "Ongoing")
+
+	req := httptest.NewRequest(http.MethodGet, "/debug/failover", nil)
+	req.Header.Set("Accept", "application/json")
+	rr = httptest.NewRecorder()
+	routes[0].Handler.ServeHTTP(rr, req)
+	var status Status
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&status))
+	assert.Equal(t, "lte", status.Active)
+	require.Len(t, status.Failovers, 1)
+}
@@ -0,0 +1,153 @@
+// Package grpcapi serves the monitor's status, results and controls over gRPC.
+package grpcapi
+
+//go:generate buf generate ../../proto --template ../../proto/buf.gen.yaml
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"slices"
+	"time"
+
+	"yanm/internal/grpcapi/yanmv1"
+	"yanm/internal/network"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Monitor is the part of monitor.Network the server controls.
+type Monitor interface {
+	PingStatus() string
+	NetworkStatus() string
+	Started() time.Time
+	PausePing()
+	ResumePing()
+	PauseNetwork()
+	ResumeNetwork()
+	TriggerSpeedTest(ctx context.Context) bool
+}
+
+// Results provides the retained results, newest first, like network.SpeedTestClient.
+type Results interface {
+	History() ([]*network.PingResult, []*network.PerformanceResult)
+}
+
+// Server implements yanmv1.MonitorServiceServer.
+type Server struct {
+	yanmv1.UnimplementedMonitorServiceServer
+
+	logger  *slog.Logger
+	monitor Monitor
+	results Results
+}
+
+var _ yanmv1.MonitorServiceServer = (*Server)(nil)
+
+// NewServer creates a gRPC MonitorService backed by monitor and results.
+func NewServer(logger *slog.Logger, monitor Monitor, results Results) *Server {
+	return &Server{
+		logger:  logger.With("component", "grpc_server"),
+		monitor: monitor,
+		results: results,
+	}
+}
+
+// Serve listens on listenAddress and serves the MonitorService until ctx is done,
+// then stops gracefully.
+func (s *Server) Serve(ctx context.Context, listenAddress string) error {
+	listener, err := net.Listen("tcp", listenAddress)
+	if err != nil {
+		return err
+	}
+
+	grpcServer := grpc.NewServer()
+	yanmv1.RegisterMonitorServiceServer(grpcServer, s)
+
+	go func() {
+		<-ctx.Done()
+		s.logger.Info("Stopping gRPC server...")
+		grpcServer.GracefulStop()
+	}()
+
+	s.logger.Info("Starting gRPC server", "address", listener.Addr().String())
+	return grpcServer.Serve(listener)
+}
+
+// GetStatus returns the state of the ping and speed test schedules.
+func (s *Server) GetStatus(context.Context, *yanmv1.GetStatusRequest) (*yanmv1.GetStatusResponse, error) {
+	resp := &yanmv1.GetStatusResponse{
+		PingLimiter:    s.monitor.PingStatus(),
+		NetworkLimiter: s.monitor.NetworkStatus(),
+	}
+	if started := s.monitor.Started(); !started.IsZero() {
+		resp.Started = timestamppb.New(started)
+	}
+	return resp, nil
+}
+
+// ListResults returns the retained results, oldest first.
+func (s *Server) ListResults(context.Context, *yanmv1.ListResultsRequest) (*yanmv1.ListResultsResponse, error) {
+	pings, speedTests := s.results.History()
+
+	resp := &yanmv1.ListResultsResponse{
+		Pings:      make([]*yanmv1.PingResult, 0, len(pings)),
+		SpeedTests: make([]*yanmv1.SpeedTestResult, 0, len(speedTests)),
+	}
+	for _, ping := range slices.Backward(pings) {
+		resp.Pings = append(resp.Pings, &yanmv1.PingResult{
+			Time:              timestamppb.New(ping.Timestamp),
+			Server:            ping.TargetName,
+			Latency:           durationpb.New(ping.Latency),
+			Jitter:            durationpb.New(ping.Jitter),
+			PacketLossPercent: ping.PacketLossPercent,
+		})
+	}
+	for _, test := range slices.Backward(speedTests) {
+		resp.SpeedTests = append(resp.SpeedTests, &yanmv1.SpeedTestResult{
+			Time:              timestamppb.New(test.Timestamp),
+			Server:            test.TargetName,
+			DownloadMbps:      test.DownloadSpeedMbps,
+			UploadMbps:        test.UploadSpeedMbps,
+			Latency:           durationpb.New(test.PingLatency),
+			Jitter:            durationpb.New(test.Jitter),
+			PacketLossPercent: test.PacketLossPercent,
+		})
+	}
+	return resp, nil
+}
+
+// Pause stops a check from running until it is resumed.
+func (s *Server) Pause(_ context.Context, req *yanmv1.PauseRequest) (*yanmv1.PauseResponse, error) {
+	switch req.GetCheck() {
+	case yanmv1.Check_CHECK_PING:
+		s.monitor.PausePing()
+	case yanmv1.Check_CHECK_SPEED_TEST:
+		s.monitor.PauseNetwork()
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "unknown check %v", req.GetCheck())
+	}
+	return &yanmv1.PauseResponse{}, nil
+}
+
+// Resume restarts a paused check.
+func (s *Server) Resume(_ context.Context, req *yanmv1.ResumeRequest) (*yanmv1.ResumeResponse, error) {
+	switch req.GetCheck() {
+	case yanmv1.Check_CHECK_PING:
+		s.monitor.ResumePing()
+	case yanmv1.Check_CHECK_SPEED_TEST:
+		s.monitor.ResumeNetwork()
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "unknown check %v", req.GetCheck())
+	}
+	return &yanmv1.ResumeResponse{}, nil
+}
+
+// TriggerSpeedTest queues a speed test, subject to the speed test rate limit.
+func (s *Server) TriggerSpeedTest(ctx context.Context, _ *yanmv1.TriggerSpeedTestRequest) (*yanmv1.TriggerSpeedTestResponse, error) {
+	return &yanmv1.TriggerSpeedTestResponse{Queued: s.monitor.TriggerSpeedTest(ctx)}, nil
+}
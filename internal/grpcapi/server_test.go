@@ -0,0 +1,132 @@
+package grpcapi
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"yanm/internal/grpcapi/yanmv1"
+	"yanm/internal/network"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+type fakeMonitor struct {
+	started     time.Time
+	pingPaused  bool
+	speedPaused bool
+	triggered   int
+}
+
+func (m *fakeMonitor) PingStatus() string    { return limiterStatus(m.pingPaused) }
+func (m *fakeMonitor) NetworkStatus() string { return limiterStatus(m.speedPaused) }
+func (m *fakeMonitor) Started() time.Time    { return m.started }
+func (m *fakeMonitor) PausePing()            { m.pingPaused = true }
+func (m *fakeMonitor) ResumePing()           { m.pingPaused = false }
+func (m *fakeMonitor) PauseNetwork()         { m.speedPaused = true }
+func (m *fakeMonitor) ResumeNetwork()        { m.speedPaused = false }
+func (m *fakeMonitor) TriggerSpeedTest(context.Context) bool {
+	m.triggered++
+	return m.triggered == 1
+}
+
+func limiterStatus(paused bool) string {
+	if paused {
+		return "Paused"
+	}
+	return "0.5 / 2"
+}
+
+type fakeResults struct {
+	pings []*network.PingResult
+	tests []*network.PerformanceResult
+}
+
+func (r fakeResults) History() ([]*network.PingResult, []*network.PerformanceResult) {
+	return r.pings, r.tests
+}
+
+func newTestClient(t *testing.T, monitor Monitor, results Results) yanmv1.MonitorServiceClient {
+	listener := bufconn.Listen(1 << 20)
+	grpcServer := grpc.NewServer()
+	yanmv1.RegisterMonitorServiceServer(grpcServer, NewServer(slog.New(slog.NewTextHandler(os.Stdout, nil)), monitor, results))
+	go func() { _ = grpcServer.Serve(listener) }()
+	t.Cleanup(grpcServer.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	return yanmv1.NewMonitorServiceClient(conn)
+}
+
+func TestServer_Status(t *testing.T) {
+	started := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	monitor := &fakeMonitor{started: started}
+	client := newTestClient(t, monitor, fakeResults{})
+	ctx := context.Background()
+
+	resp, err := client.GetStatus(ctx, &yanmv1.GetStatusRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, "0.5 / 2", resp.GetPingLimiter())
+	assert.Equal(t, started, resp.GetStarted().AsTime())
+
+	_, err = client.Pause(ctx, &yanmv1.PauseRequest{Check: yanmv1.Check_CHECK_SPEED_TEST})
+	require.NoError(t, err)
+	resp, err = client.GetStatus(ctx, &yanmv1.GetStatusRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, "Paused", resp.GetNetworkLimiter())
+
+	_, err = client.Resume(ctx, &yanmv1.ResumeRequest{Check: yanmv1.Check_CHECK_SPEED_TEST})
+	require.NoError(t, err)
+	assert.False(t, monitor.speedPaused)
+
+	_, err = client.Pause(ctx, &yanmv1.PauseRequest{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestServer_ListResults(t *testing.T) {
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	client := newTestClient(t, &fakeMonitor{}, fakeResults{
+		// kept newest first.
+		pings: []*network.PingResult{
+			{TargetName: "b", Timestamp: start.Add(time.Minute), Latency: 20 * time.Millisecond},
+			{TargetName: "a", Timestamp: start, Latency: 10 * time.Millisecond},
+		},
+		tests: []*network.PerformanceResult{
+			{TargetName: "a", Timestamp: start, DownloadSpeedMbps: 300, UploadSpeedMbps: 20, PacketLossPercent: -1},
+		},
+	})
+
+	resp, err := client.ListResults(context.Background(), &yanmv1.ListResultsRequest{})
+	require.NoError(t, err)
+	require.Len(t, resp.GetPings(), 2)
+	assert.Equal(t, "a", resp.GetPings()[0].GetServer())
+	assert.Equal(t, 10*time.Millisecond, resp.GetPings()[0].GetLatency().AsDuration())
+	require.Len(t, resp.GetSpeedTests(), 1)
+	assert.InDelta(t, 300, resp.GetSpeedTests()[0].GetDownloadMbps(), 0)
+	assert.InDelta(t, -1, resp.GetSpeedTests()[0].GetPacketLossPercent(), 0)
+}
+
+func TestServer_TriggerSpeedTest(t *testing.T) {
+	client := newTestClient(t, &fakeMonitor{}, fakeResults{})
+
+	resp, err := client.TriggerSpeedTest(context.Background(), &yanmv1.TriggerSpeedTestRequest{})
+	require.NoError(t, err)
+	assert.True(t, resp.GetQueued())
+
+	resp, err = client.TriggerSpeedTest(context.Background(), &yanmv1.TriggerSpeedTestRequest{})
+	require.NoError(t, err)
+	assert.False(t, resp.GetQueued(), "a speed test is already queued")
+}
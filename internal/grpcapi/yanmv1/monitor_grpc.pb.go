@@ -0,0 +1,287 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: yanm/v1/monitor.proto
+
+package yanmv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	MonitorService_GetStatus_FullMethodName        = "/yanm.v1.MonitorService/GetStatus"
+	MonitorService_ListResults_FullMethodName      = "/yanm.v1.MonitorService/ListResults"
+	MonitorService_Pause_FullMethodName            = "/yanm.v1.MonitorService/Pause"
+	MonitorService_Resume_FullMethodName           = "/yanm.v1.MonitorService/Resume"
+	MonitorService_TriggerSpeedTest_FullMethodName = "/yanm.v1.MonitorService/TriggerSpeedTest"
+)
+
+// MonitorServiceClient is the client API for MonitorService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// MonitorService queries and controls a running monitor.
+type MonitorServiceClient interface {
+	// GetStatus returns the state of the ping and speed test schedules.
+	GetStatus(ctx context.Context, in *GetStatusRequest, opts ...grpc.CallOption) (*GetStatusResponse, error)
+	// ListResults returns the retained ping and speed test results, oldest first.
+	ListResults(ctx context.Context, in *ListResultsRequest, opts ...grpc.CallOption) (*ListResultsResponse, error)
+	// Pause stops a check from running until it is resumed.
+	Pause(ctx context.Context, in *PauseRequest, opts ...grpc.CallOption) (*PauseResponse, error)
+	// Resume restarts a paused check.
+	Resume(ctx context.Context, in *ResumeRequest, opts ...grpc.CallOption) (*ResumeResponse, error)
+	// TriggerSpeedTest queues a speed test, subject to the speed test rate limit.
+	TriggerSpeedTest(ctx context.Context, in *TriggerSpeedTestRequest, opts ...grpc.CallOption) (*TriggerSpeedTestResponse, error)
+}
+
+type monitorServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewMonitorServiceClient(cc grpc.ClientConnInterface) MonitorServiceClient {
+	return &monitorServiceClient{cc}
+}
+
+func (c *monitorServiceClient) GetStatus(ctx context.Context, in *GetStatusRequest, opts ...grpc.CallOption) (*GetStatusResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetStatusResponse)
+	err := c.cc.Invoke(ctx, MonitorService_GetStatus_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *monitorServiceClient) ListResults(ctx context.Context, in *ListResultsRequest, opts ...grpc.CallOption) (*ListResultsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListResultsResponse)
+	err := c.cc.Invoke(ctx, MonitorService_ListResults_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *monitorServiceClient) Pause(ctx context.Context, in *PauseRequest, opts ...grpc.CallOption) (*PauseResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PauseResponse)
+	err := c.cc.Invoke(ctx, MonitorService_Pause_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *monitorServiceClient) Resume(ctx context.Context, in *ResumeRequest, opts ...grpc.CallOption) (*ResumeResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ResumeResponse)
+	err := c.cc.Invoke(ctx, MonitorService_Resume_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *monitorServiceClient) TriggerSpeedTest(ctx context.Context, in *TriggerSpeedTestRequest, opts ...grpc.CallOption) (*TriggerSpeedTestResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(TriggerSpeedTestResponse)
+	err := c.cc.Invoke(ctx, MonitorService_TriggerSpeedTest_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// MonitorServiceServer is the server API for MonitorService service.
+// All implementations must embed UnimplementedMonitorServiceServer
+// for forward compatibility.
+//
+// MonitorService queries and controls a running monitor.
+type MonitorServiceServer interface {
+	// GetStatus returns the state of the ping and speed test schedules.
+	GetStatus(context.Context, *GetStatusRequest) (*GetStatusResponse, error)
+	// ListResults returns the retained ping and speed test results, oldest first.
+	ListResults(context.Context, *ListResultsRequest) (*ListResultsResponse, error)
+	// Pause stops a check from running until it is resumed.
+	Pause(context.Context, *PauseRequest) (*PauseResponse, error)
+	// Resume restarts a paused check.
+	Resume(context.Context, *ResumeRequest) (*ResumeResponse, error)
+	// TriggerSpeedTest queues a speed test, subject to the speed test rate limit.
+	TriggerSpeedTest(context.Context, *TriggerSpeedTestRequest) (*TriggerSpeedTestResponse, error)
+	mustEmbedUnimplementedMonitorServiceServer()
+}
+
+// UnimplementedMonitorServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedMonitorServiceServer struct{}
+
+func (UnimplementedMonitorServiceServer) GetStatus(context.Context, *GetStatusRequest) (*GetStatusResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetStatus not implemented")
+}
+func (UnimplementedMonitorServiceServer) ListResults(context.Context, *ListResultsRequest) (*ListResultsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListResults not implemented")
+}
+func (UnimplementedMonitorServiceServer) Pause(context.Context, *PauseRequest) (*PauseResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Pause not implemented")
+}
+func (UnimplementedMonitorServiceServer) Resume(context.Context, *ResumeRequest) (*ResumeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Resume not implemented")
+}
+func (UnimplementedMonitorServiceServer) TriggerSpeedTest(context.Context, *TriggerSpeedTestRequest) (*TriggerSpeedTestResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method TriggerSpeedTest not implemented")
+}
+func (UnimplementedMonitorServiceServer) mustEmbedUnimplementedMonitorServiceServer() {}
+func (UnimplementedMonitorServiceServer) testEmbeddedByValue()                        {}
+
+// UnsafeMonitorServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to MonitorServiceServer will
+// result in compilation errors.
+type UnsafeMonitorServiceServer interface {
+	mustEmbedUnimplementedMonitorServiceServer()
+}
+
+func RegisterMonitorServiceServer(s grpc.ServiceRegistrar, srv MonitorServiceServer) {
+	// If the following call pancis, it indicates UnimplementedMonitorServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&MonitorService_ServiceDesc, srv)
+}
+
+func _MonitorService_GetStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MonitorServiceServer).GetStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MonitorService_GetStatus_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MonitorServiceServer).GetStatus(ctx, req.(*GetStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MonitorService_ListResults_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListResultsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MonitorServiceServer).ListResults(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MonitorService_ListResults_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MonitorServiceServer).ListResults(ctx, req.(*ListResultsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MonitorService_Pause_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PauseRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MonitorServiceServer).Pause(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MonitorService_Pause_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MonitorServiceServer).Pause(ctx, req.(*PauseRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MonitorService_Resume_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ResumeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MonitorServiceServer).Resume(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MonitorService_Resume_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MonitorServiceServer).Resume(ctx, req.(*ResumeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MonitorService_TriggerSpeedTest_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TriggerSpeedTestRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MonitorServiceServer).TriggerSpeedTest(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MonitorService_TriggerSpeedTest_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MonitorServiceServer).TriggerSpeedTest(ctx, req.(*TriggerSpeedTestRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// MonitorService_ServiceDesc is the grpc.ServiceDesc for MonitorService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var MonitorService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "yanm.v1.MonitorService",
+	HandlerType: (*MonitorServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetStatus",
+			Handler:    _MonitorService_GetStatus_Handler,
+		},
+		{
+			MethodName: "ListResults",
+			Handler:    _MonitorService_ListResults_Handler,
+		},
+		{
+			MethodName: "Pause",
+			Handler:    _MonitorService_Pause_Handler,
+		},
+		{
+			MethodName: "Resume",
+			Handler:    _MonitorService_Resume_Handler,
+		},
+		{
+			MethodName: "TriggerSpeedTest",
+			Handler:    _MonitorService_TriggerSpeedTest_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "yanm/v1/monitor.proto",
+}
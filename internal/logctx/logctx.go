@@ -0,0 +1,61 @@
+// Package logctx attaches log attributes to a context, so that every line logged with
+// it, e.g. all the lines of one speed test, can be grouped by an ID.
+package logctx
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+)
+
+// Keys of the attributes set by the helpers below.
+const (
+	CheckIDKey   = "checkID"
+	RunIDKey     = "runID"
+	RequestIDKey = "requestID"
+	// TraceIDKey holds the ID of the OpenTelemetry trace of a speed test run, when tracing.
+	TraceIDKey = "traceID"
+)
+
+type attrsKey struct{}
+
+// With returns a copy of ctx carrying attrs in addition to those ctx already carries.
+// Loggers created by yanm/internal/logger add them to the lines logged with the
+// returned context, e.g. by InfoContext.
+func With(ctx context.Context, attrs ...slog.Attr) context.Context {
+	prev := Attrs(ctx)
+	// the slice is shared with ctx and must not be appended to in place.
+	all := make([]slog.Attr, 0, len(prev)+len(attrs))
+	all = append(append(all, prev...), attrs...)
+	return context.WithValue(ctx, attrsKey{}, all)
+}
+
+// Attrs returns the attributes ctx carries, oldest first. The caller must not modify them.
+func Attrs(ctx context.Context) []slog.Attr {
+	attrs, _ := ctx.Value(attrsKey{}).([]slog.Attr)
+	return attrs
+}
+
+// WithCheckID returns a copy of ctx identifying the lines logged for a single check,
+// e.g. one ping.
+func WithCheckID(ctx context.Context, id string) context.Context {
+	return With(ctx, slog.String(CheckIDKey, id))
+}
+
+// WithRunID returns a copy of ctx identifying the lines logged for a single speed test run.
+func WithRunID(ctx context.Context, id string) context.Context {
+	return With(ctx, slog.String(RunIDKey, id))
+}
+
+// WithRequestID returns a copy of ctx identifying the lines logged for a single request.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return With(ctx, slog.String(RequestIDKey, id))
+}
+
+// NewID returns a random ID, short enough to read in logs.
+func NewID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b) // never returns an error
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,30 @@
+package logctx
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWith(t *testing.T) {
+	ctx := context.Background()
+	assert.Empty(t, Attrs(ctx))
+
+	run := WithRunID(ctx, "run-1")
+	first := WithCheckID(run, "check-1")
+	second := With(run, slog.Int("attempt", 2))
+
+	assert.Equal(t, []slog.Attr{slog.String("runID", "run-1")}, Attrs(run))
+	// deriving contexts from the same parent doesn't change each other's attributes.
+	assert.Equal(t, []slog.Attr{slog.String("runID", "run-1"), slog.String("checkID", "check-1")}, Attrs(first))
+	assert.Equal(t, []slog.Attr{slog.String("runID", "run-1"), slog.Int("attempt", 2)}, Attrs(second))
+	assert.Equal(t, []slog.Attr{slog.String("requestID", "abc")}, Attrs(WithRequestID(ctx, "abc")))
+}
+
+func TestNewID(t *testing.T) {
+	id := NewID()
+	assert.Regexp(t, `^[0-9a-f]{16}$`, id)
+	assert.NotEqual(t, id, NewID())
+}
@@ -4,4 +4,101 @@ package logger
 type Config struct {
 	Level  string `yaml:"level"`
 	Format string `yaml:"format"`
+	// AddSource adds the file and line that logged each entry.
+	AddSource bool `yaml:"add_source"`
+	// TimeFormat formats entry times as rfc3339, rfc3339nano, datetime or a Go layout
+	// such as "Jan _2 15:04:05", instead of RFC 3339 with milliseconds.
+	TimeFormat string `yaml:"time_format"`
+	// TimeZone writes entry times in an IANA time zone such as Europe/Berlin, UTC or
+	// Local, the default.
+	TimeZone string `yaml:"time_zone"`
+	// Output selects where logs go: file (the default), writing to OutputFile, syslog,
+	// journald, sending structured entries to the systemd journal, or eventlog, writing
+	// to the Windows event log.
+	Output string `yaml:"output"`
+	// Outputs, when set, replaces Output to write every log to several outputs at once,
+	// each of stdout, stderr, file, syslog, journald or eventlog.
+	Outputs []string `yaml:"outputs"`
+	// OutputFile is where logs are written: stdout (the default), stderr, or a file path,
+	// which is created if needed and appended to.
+	OutputFile string `yaml:"output_file"`
+	// Rotation rotates OutputFile when it is a file, so long-running installs don't fill disks.
+	Rotation RotationConfig `yaml:"rotation"`
+	// Syslog configures the syslog output.
+	Syslog SyslogConfig `yaml:"syslog"`
+	// OTLP additionally ships logs to an OpenTelemetry collector when its endpoint is set.
+	OTLP OTLPConfig `yaml:"otlp"`
+	// ErrorReporting sends errors logged and panics to Sentry or a webhook.
+	ErrorReporting ErrorReportingConfig `yaml:"error_reporting"`
+	// BufferSize is how many recent entries are kept in memory for the debug server's
+	// logs page.
+	BufferSize int `yaml:"buffer_size"`
+	// Dedup collapses repeated warnings and errors, e.g. a failing check during an outage.
+	Dedup DedupConfig `yaml:"dedup"`
+	// CrashDir is where a crash report file is written for every panic logged with
+	// LogPanic, empty to not write them.
+	CrashDir string `yaml:"crash_dir"`
+}
+
+// ErrorReportingConfig reports every error logged, and panics, so failures on remote
+// probes are seen centrally. Both services are used when both are set.
+type ErrorReportingConfig struct {
+	// SentryDSN is the DSN of a Sentry project, e.g. https://key@o1.ingest.sentry.io/42.
+	SentryDSN     string `yaml:"sentry_dsn" yanm:"secret"`
+	SentryDSNFile string `yaml:"sentry_dsn_file"`
+	SentryDSNEnv  string `yaml:"sentry_dsn_env"`
+	// WebhookURL receives every report as a JSON POST.
+	WebhookURL string `yaml:"webhook_url" yanm:"secret"`
+	// Environment tells probes apart in reports, e.g. production or cabin.
+	Environment string `yaml:"environment"`
+}
+
+// Enabled reports whether errors are reported anywhere.
+func (c ErrorReportingConfig) Enabled() bool {
+	return c.SentryDSN != "" || c.WebhookURL != ""
+}
+
+// DedupConfig logs the first of identical warnings and errors, and then how often it
+// repeated once every WindowSeconds, instead of every repeat. Zero disables it.
+type DedupConfig struct {
+	WindowSeconds int `yaml:"window_seconds"`
+}
+
+// SyslogConfig sends logs to the local syslog daemon, or a remote one when Address is set.
+type SyslogConfig struct {
+	// Network is udp or tcp for a remote Address, empty for the local daemon.
+	Network string `yaml:"network"`
+	Address string `yaml:"address"`
+	// Facility is a syslog facility name, e.g. daemon (the default), user or local0.
+	Facility string `yaml:"facility"`
+	// Tag names the program in each message, yanm by default.
+	Tag string `yaml:"tag"`
+}
+
+// OTLPConfig ships logs to an OpenTelemetry collector over OTLP/HTTP, in addition to
+// the configured output.
+type OTLPConfig struct {
+	// Endpoint is the collector's OTLP/HTTP base URL, e.g. http://collector:4318.
+	Endpoint string `yaml:"endpoint"`
+	// Headers are sent with every export, e.g. for authentication.
+	Headers map[string]string `yaml:"headers" yanm:"secret"`
+	// ServiceName is the service.name resource attribute, yanm by default.
+	ServiceName string `yaml:"service_name"`
+}
+
+// RotationConfig rotates the log file once it reaches MaxSizeMB, keeping the previous
+// files next to it with a timestamp in their name. Zero values disable each limit.
+type RotationConfig struct {
+	MaxSizeMB int `yaml:"max_size_mb"`
+	// MaxBackups is how many rotated files are kept.
+	MaxBackups int `yaml:"max_backups"`
+	// MaxAgeDays removes rotated files older than this many days.
+	MaxAgeDays int `yaml:"max_age_days"`
+	// Compress gzips rotated files.
+	Compress bool `yaml:"compress"`
+}
+
+// Enabled reports whether the log file is rotated.
+func (c RotationConfig) Enabled() bool {
+	return c.MaxSizeMB > 0
 }
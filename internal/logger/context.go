@@ -0,0 +1,57 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+
+	"yanm/internal/logctx"
+)
+
+// contextHandler adds the attributes attached to the context with logctx to every
+// record, except those already added with WithAttrs, e.g. the debug server's request
+// logger carrying the request ID.
+type contextHandler struct {
+	next slog.Handler
+	// keys are those of the top level attributes added with WithAttrs.
+	keys map[string]bool
+	// grouped is set once WithGroup was called, after which attributes can't clash.
+	grouped bool
+}
+
+func (h *contextHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	for _, a := range logctx.Attrs(ctx) {
+		if !h.keys[a.Key] {
+			r.AddAttrs(a)
+		}
+	}
+	return h.next.Handle(ctx, r)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *h
+	clone.next = h.next.WithAttrs(attrs)
+	if !h.grouped {
+		clone.keys = make(map[string]bool, len(h.keys)+len(attrs))
+		for k := range h.keys {
+			clone.keys[k] = true
+		}
+		for _, a := range attrs {
+			clone.keys[a.Key] = true
+		}
+	}
+	return &clone
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	clone := *h
+	clone.next = h.next.WithGroup(name)
+	clone.grouped = true
+	return &clone
+}
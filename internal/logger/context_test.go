@@ -0,0 +1,55 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"yanm/internal/logctx"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContextHandler(t *testing.T) {
+	var buf bytes.Buffer
+	text := slog.NewTextHandler(&buf, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey && len(groups) == 0 {
+				return slog.Attr{}
+			}
+			return a
+		},
+	})
+	logger := slog.New(&contextHandler{next: text})
+
+	ctx := logctx.WithRunID(context.Background(), "run-1")
+	logger.InfoContext(ctx, "Testing download speed", "serverName", "Cabin ISP")
+	logger.Info("No context")
+	// an attribute added with With isn't repeated from the context.
+	logger.With("runID", "run-1").InfoContext(ctx, "Request logger")
+	logger.WithGroup("result").InfoContext(ctx, "Grouped", "download_mbps", 100)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 4)
+	assert.Equal(t, `level=INFO msg="Testing download speed" serverName="Cabin ISP" runID=run-1`, lines[0])
+	assert.Equal(t, `level=INFO msg="No context"`, lines[1])
+	assert.Equal(t, `level=INFO msg="Request logger" runID=run-1`, lines[2])
+	assert.Equal(t, `level=INFO msg=Grouped result.download_mbps=100 result.runID=run-1`, lines[3])
+}
+
+func TestNew_ContextAttrs(t *testing.T) {
+	logger, levels, err := New(Config{Level: "info", OutputFile: "stderr", BufferSize: 10, Dedup: DedupConfig{WindowSeconds: 60}})
+	require.NoError(t, err)
+
+	for _, id := range []string{"check-1", "check-2"} {
+		logger.ErrorContext(logctx.WithCheckID(context.Background(), id), "Ping failed", "error", "timeout")
+	}
+
+	// repeats are collapsed although their check IDs differ.
+	entries := levels.recent.recent(slog.LevelDebug)
+	require.Len(t, entries, 1)
+	assert.Equal(t, map[string]string{"checkID": "check-1", "error": "timeout"}, entries[0].Attrs)
+}
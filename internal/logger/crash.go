@@ -0,0 +1,79 @@
+package logger
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"yanm/internal/version"
+)
+
+// CrashReportKey holds the path of the crash report written by LogPanic.
+const CrashReportKey = "crashReport"
+
+// _crashDir is where LogPanic writes crash reports, set by the most recently built
+// logger, empty when they are not written.
+var (
+	_crashDirMu sync.Mutex
+	_crashDir   string
+)
+
+func setCrashDir(dir string) {
+	_crashDirMu.Lock()
+	defer _crashDirMu.Unlock()
+	_crashDir = dir
+}
+
+func crashDir() string {
+	_crashDirMu.Lock()
+	defer _crashDirMu.Unlock()
+	return _crashDir
+}
+
+// panicFrames returns the frames of the goroutine that panicked, from the function that
+// panicked outwards, formatted as "function file:line". Called from the deferred function
+// that recovered, it skips the frames of the recovery itself.
+func panicFrames() []string {
+	pcs := make([]uintptr, 64)
+	n := runtime.Callers(1, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+
+	var (
+		formatted []string
+		panicked  bool
+	)
+	for {
+		frame, more := frames.Next()
+		if panicked {
+			formatted = append(formatted, fmt.Sprintf("%s %s:%d", frame.Function, frame.File, frame.Line))
+		}
+		// everything above runtime.gopanic is the recovery.
+		panicked = panicked || frame.Function == "runtime.gopanic"
+		if !more {
+			break
+		}
+	}
+	return formatted
+}
+
+// writeCrashReport writes v and stack to a new file in dir, returning its path.
+func writeCrashReport(dir string, now time.Time, v any, stack []byte) (string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, fmt.Sprintf("crash-%s-%d.txt", now.UTC().Format("20060102T150405.000000000Z"), os.Getpid()))
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "time: %s\n", now.Format(time.RFC3339Nano))
+	fmt.Fprintf(&b, "version: %s\n", version.Get())
+	fmt.Fprintf(&b, "panic: %v\n\n", v)
+	b.Write(stack)
+	if err := os.WriteFile(path, []byte(b.String()), 0o644); err != nil {
+		return "", err
+	}
+	return path, nil
+}
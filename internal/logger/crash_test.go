@@ -0,0 +1,53 @@
+package logger
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogPanic_CrashReport(t *testing.T) {
+	dir := t.TempDir()
+	logFile := filepath.Join(dir, "yanm.log")
+	log, _, err := New(Config{Level: "info", Format: "json", OutputFile: logFile, CrashDir: filepath.Join(dir, "crashes")})
+	require.NoError(t, err)
+	t.Cleanup(func() { setCrashDir("") })
+
+	func() {
+		defer func() {
+			LogPanic(context.Background(), log, recover())
+		}()
+		panicHere()
+	}()
+
+	data, err := os.ReadFile(logFile)
+	require.NoError(t, err)
+	var entry struct {
+		Msg         string   `json:"msg"`
+		Panic       string   `json:"panic"`
+		Frames      []string `json:"frames"`
+		CrashReport string   `json:"crashReport"`
+	}
+	require.NoError(t, json.Unmarshal(data, &entry))
+	assert.Equal(t, "Panic", entry.Msg)
+	assert.Equal(t, "boom", entry.Panic)
+	// the frames start where the panic happened, not in the recovery.
+	require.NotEmpty(t, entry.Frames)
+	assert.True(t, strings.HasPrefix(entry.Frames[0], "yanm/internal/logger.panicHere "), entry.Frames[0])
+
+	report, err := os.ReadFile(entry.CrashReport)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "crashes"), filepath.Dir(entry.CrashReport))
+	assert.Contains(t, string(report), "panic: boom\n")
+	assert.Contains(t, string(report), "logger.panicHere")
+}
+
+func panicHere() {
+	panic("boom")
+}
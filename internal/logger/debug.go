@@ -0,0 +1,175 @@
+package logger
+
+import (
+	"fmt"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"yanm/internal/debughttp"
+	"yanm/internal/debughttp/debughandler"
+)
+
+const _levelPage = `
+<h1>Logging</h1>
+<p>Level: {{ .Level }}</p>
+<form action="/debug/logging/" method="post">
+	{{ range .Levels }}<button name="level" value="{{ . }}">{{ . }}</button>
+	{{ end }}
+</form>
+`
+
+var _levelPageTemplate = template.Must(template.New("logging").Parse(_levelPage))
+
+const _logsPage = `
+<h1>Recent Logs</h1>
+<form action="/debug/logs/" method="get">
+	<label>Level
+		<select name="level" onchange="this.form.submit()">
+			{{ range .Levels }}<option value="{{ . }}"{{ if eq . $.Level }} selected{{ end }}>{{ . }}</option>
+			{{ end }}
+		</select>
+	</label>
+</form>
+<table>
+	<tr><th>Time</th><th>Level</th><th>Message</th><th>Attributes</th></tr>
+	{{ range .Entries }}
+	<tr>
+		<td>{{ .Time.Format "2006-01-02 15:04:05.000" }}</td>
+		<td>{{ .Level }}</td>
+		<td>{{ .Message }}</td>
+		<td>{{ range $k, $v := .Attrs }}{{ $k }}={{ $v }} {{ end }}</td>
+	</tr>
+	{{ else }}
+	<tr><td colspan="4">No entries at this level.</td></tr>
+	{{ end }}
+</table>
+`
+
+var _logsPageTemplate = template.Must(template.New("logs").Parse(_logsPage))
+
+// levelState is the data behind both views of the logging page.
+type levelState struct {
+	Level  string   `json:"level"`
+	Levels []string `json:"levels"`
+}
+
+// logsState is the data behind both views of the logs page.
+type logsState struct {
+	Level   string     `json:"level"`
+	Levels  []string   `json:"-"`
+	Entries []logEntry `json:"entries"`
+}
+
+type levelPage struct {
+	levels *Controller
+	view   http.Handler
+}
+
+var _ debughttp.PageProvider = (*Controller)(nil)
+
+// DebugRoutes returns the page showing and changing the log level, and the page
+// showing the recent logs when they are kept.
+func (c *Controller) DebugRoutes() []debughttp.DebugRoute {
+	p := &levelPage{levels: c}
+	p.view = debughandler.NewNegotiatingHandler(p.state, _levelPageTemplate)
+	routes := []debughttp.DebugRoute{{
+		Path:        "/debug/logging",
+		Name:        "Logging",
+		Description: "Shows and changes the log level at runtime.",
+		Handler:     debughandler.NewHTMLProducingHandler(p),
+		Group:       "System",
+		Order:       55,
+	}}
+	if c.recent != nil {
+		routes = append(routes, debughttp.DebugRoute{
+			Path:        "/debug/logs",
+			Name:        "Logs",
+			Description: "Shows the most recent log entries, filtered by level.",
+			Handler:     debughandler.NewHTMLProducingHandler(newLogsPage(c.recent)),
+			Group:       "System",
+			Order:       56,
+			AutoRefresh: true,
+		})
+	}
+	return routes
+}
+
+// levelNames returns the names of the levels a Controller steps through.
+func levelNames() []string {
+	names := make([]string, 0, len(_levels))
+	for _, level := range _levels {
+		names = append(names, strings.ToLower(level.String()))
+	}
+	return names
+}
+
+func (p *levelPage) state(*http.Request) (any, error) {
+	return levelState{Level: p.levels.String(), Levels: levelNames()}, nil
+}
+
+func (p *levelPage) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		p.view.ServeHTTP(w, r)
+
+	case http.MethodPost:
+		if err := p.levels.Set(r.FormValue("level")); err != nil {
+			http.Error(w, "Invalid level", http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		_, _ = fmt.Fprintf(w, "Log level set to %s", p.levels)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+type logsPage struct {
+	recent *ringBuffer
+	view   http.Handler
+}
+
+func newLogsPage(recent *ringBuffer) *logsPage {
+	p := &logsPage{recent: recent}
+	p.view = debughandler.NewNegotiatingHandler(p.state, _logsPageTemplate)
+	return p
+}
+
+// state returns the entries at the level in the level query parameter or above.
+func (p *logsPage) state(r *http.Request) (any, error) {
+	minLevel, err := queryLevel(r)
+	if err != nil {
+		return nil, err
+	}
+	return logsState{
+		Level:   strings.ToLower(minLevel.String()),
+		Levels:  levelNames(),
+		Entries: p.recent.recent(minLevel),
+	}, nil
+}
+
+func (p *logsPage) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if _, err := queryLevel(r); err != nil {
+		http.Error(w, "Invalid level", http.StatusBadRequest)
+		return
+	}
+	p.view.ServeHTTP(w, r)
+}
+
+// queryLevel returns the level in r's level query parameter, debug if there is none.
+func queryLevel(r *http.Request) (slog.Level, error) {
+	level := slog.LevelDebug
+	if name := r.URL.Query().Get("level"); name != "" {
+		if err := level.UnmarshalText([]byte(name)); err != nil {
+			return level, err
+		}
+	}
+	return level, nil
+}
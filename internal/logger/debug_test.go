@@ -0,0 +1,104 @@
+package logger
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"yanm/internal/debughttp/debughandler"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLevelPage_ServeHTTP(t *testing.T) {
+	levels := &Controller{level: new(slog.LevelVar)}
+	p := &levelPage{levels: levels}
+	p.view = debughandler.NewNegotiatingHandler(p.state, _levelPageTemplate)
+
+	req := httptest.NewRequest(http.MethodGet, "/debug/logging/", nil)
+	req.Header.Set("Accept", "application/json")
+	rr := httptest.NewRecorder()
+	p.ServeHTTP(rr, req)
+	require.Equal(t, http.StatusOK, rr.Code)
+	var state levelState
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &state))
+	require.Equal(t, levelState{Level: "info", Levels: []string{"debug", "info", "warn", "error"}}, state)
+
+	post := func(level string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/debug/logging/",
+			strings.NewReader(url.Values{"level": {level}}.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rr := httptest.NewRecorder()
+		p.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr = post("debug")
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.Equal(t, "Log level set to debug", rr.Body.String())
+	require.Equal(t, slog.LevelDebug, levels.Level())
+
+	rr = post("chatty")
+	require.Equal(t, http.StatusBadRequest, rr.Code)
+	require.Equal(t, slog.LevelDebug, levels.Level())
+
+	rr = httptest.NewRecorder()
+	p.ServeHTTP(rr, httptest.NewRequest(http.MethodDelete, "/debug/logging/", nil))
+	require.Equal(t, http.StatusMethodNotAllowed, rr.Code)
+}
+
+func TestLogsPage_ServeHTTP(t *testing.T) {
+	logger, levels, err := New(Config{Level: "info", OutputFile: "stderr", BufferSize: 10})
+	require.NoError(t, err)
+	logger.Info("Ping succeeded")
+	logger.Error("Ping failed", "error", "timeout")
+
+	routes := levels.DebugRoutes()
+	require.Len(t, routes, 2)
+	require.Equal(t, "/debug/logs", routes[1].Path)
+	p := newLogsPage(levels.recent)
+
+	get := func(target string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req.Header.Set("Accept", "application/json")
+		rr := httptest.NewRecorder()
+		p.ServeHTTP(rr, req)
+		return rr
+	}
+
+	var state struct {
+		Level   string     `json:"level"`
+		Entries []logEntry `json:"entries"`
+	}
+	rr := get("/debug/logs/")
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &state))
+	require.Equal(t, "debug", state.Level)
+	require.Len(t, state.Entries, 2)
+
+	rr = get("/debug/logs/?level=error")
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &state))
+	require.Equal(t, "error", state.Level)
+	require.Len(t, state.Entries, 1)
+	require.Equal(t, "Ping failed", state.Entries[0].Message)
+	require.Equal(t, slog.LevelError, state.Entries[0].Level)
+	require.Equal(t, map[string]string{"error": "timeout"}, state.Entries[0].Attrs)
+
+	rr = httptest.NewRecorder()
+	p.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/debug/logs/?level=error", nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.Contains(t, rr.Body.String(), "<td>Ping failed</td>")
+	require.NotContains(t, rr.Body.String(), "Ping succeeded")
+
+	rr = get("/debug/logs/?level=chatty")
+	require.Equal(t, http.StatusBadRequest, rr.Code)
+
+	_, levels, err = New(Config{Level: "info", OutputFile: "stderr"})
+	require.NoError(t, err)
+	require.Len(t, levels.DebugRoutes(), 1, "no logs page without a buffer")
+}
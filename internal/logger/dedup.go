@@ -0,0 +1,130 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/benbjohnson/clock"
+)
+
+// _dedupMinLevel is the lowest level collapsed by the dedup handler, as repeated
+// debug and info lines are usually wanted, e.g. one per check.
+const _dedupMinLevel = slog.LevelWarn
+
+// dedupHandler collapses identical warnings and errors: the first is logged, and
+// repeats within the window are counted and logged once at its end as a summary
+// carrying a repeated attribute. Records are identical if they have the same level,
+// message and attributes, including those added with WithAttrs and WithGroup.
+type dedupHandler struct {
+	next  slog.Handler
+	state *dedupState
+	// scope identifies the attributes and groups added to next.
+	scope string
+}
+
+// dedupState is shared by a dedupHandler and the handlers derived from it.
+type dedupState struct {
+	clock  clock.Clock
+	window time.Duration
+
+	mu      sync.Mutex
+	entries map[string]*dedupEntry
+}
+
+type dedupEntry struct {
+	handler slog.Handler
+	record  slog.Record
+	// repeats counts the records suppressed in the current window.
+	repeats int
+}
+
+func newDedupHandler(next slog.Handler, window time.Duration, clk clock.Clock) *dedupHandler {
+	return &dedupHandler{
+		next: next,
+		state: &dedupState{
+			clock:   clk,
+			window:  window,
+			entries: make(map[string]*dedupEntry),
+		},
+	}
+}
+
+func (h *dedupHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *dedupHandler) Handle(ctx context.Context, r slog.Record) error {
+	if r.Level < _dedupMinLevel {
+		return h.next.Handle(ctx, r)
+	}
+
+	key := h.key(r)
+	s := h.state
+	s.mu.Lock()
+	if e, ok := s.entries[key]; ok {
+		e.repeats++
+		s.mu.Unlock()
+		return nil
+	}
+	s.entries[key] = &dedupEntry{handler: h.next, record: r.Clone()}
+	s.clock.AfterFunc(s.window, func() { s.summarize(key) })
+	s.mu.Unlock()
+
+	return h.next.Handle(ctx, r)
+}
+
+func (h *dedupHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	var scope strings.Builder
+	scope.WriteString(h.scope)
+	for _, a := range attrs {
+		writeDedupAttr(&scope, a)
+	}
+	return &dedupHandler{next: h.next.WithAttrs(attrs), state: h.state, scope: scope.String()}
+}
+
+func (h *dedupHandler) WithGroup(name string) slog.Handler {
+	return &dedupHandler{next: h.next.WithGroup(name), state: h.state, scope: h.scope + name + "{"}
+}
+
+func (h *dedupHandler) key(r slog.Record) string {
+	var key strings.Builder
+	fmt.Fprintf(&key, "%s\x00%d\x00%s\x00", h.scope, r.Level, r.Message)
+	r.Attrs(func(a slog.Attr) bool {
+		writeDedupAttr(&key, a)
+		return true
+	})
+	return key.String()
+}
+
+func writeDedupAttr(b *strings.Builder, a slog.Attr) {
+	fmt.Fprintf(b, "%s=%v\x00", a.Key, a.Value.Resolve())
+}
+
+// summarize logs how often key repeated during the window that just ended, starting
+// another window, or forgets key if it didn't repeat.
+func (s *dedupState) summarize(key string) {
+	s.mu.Lock()
+	e := s.entries[key]
+	if e.repeats == 0 {
+		delete(s.entries, key)
+		s.mu.Unlock()
+		return
+	}
+	repeats := e.repeats
+	e.repeats = 0
+	s.clock.AfterFunc(s.window, func() { s.summarize(key) })
+	s.mu.Unlock()
+
+	summary := slog.NewRecord(s.clock.Now(), e.record.Level, e.record.Message, e.record.PC)
+	e.record.Attrs(func(a slog.Attr) bool {
+		summary.AddAttrs(a)
+		return true
+	})
+	summary.AddAttrs(slog.Int("repeated", repeats), slog.Duration("window", s.window))
+	// there is nobody to report a failure to, the handler's output is failing.
+	_ = e.handler.Handle(context.Background(), summary)
+}
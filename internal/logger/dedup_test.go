@@ -0,0 +1,71 @@
+package logger
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// syncBuffer is written to from the dedup handler's timers.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) lines() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return strings.Split(strings.TrimSpace(b.buf.String()), "\n")
+}
+
+func TestDedupHandler(t *testing.T) {
+	var out syncBuffer
+	text := slog.NewTextHandler(&out, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey && len(groups) == 0 {
+				return slog.Attr{}
+			}
+			return a
+		},
+	})
+	mock := clock.NewMock()
+	logger := slog.New(newDedupHandler(text, time.Minute, mock)).With("check", "ping")
+
+	for range 30 {
+		logger.Error("Ping failed", "error", "timeout")
+		logger.Info("Ping scheduled")
+		mock.Add(2 * time.Second)
+	}
+	logger.Error("Ping failed", "error", "no route to host")
+	logger.With("check", "dns").Error("Ping failed", "error", "timeout")
+
+	require.Eventually(t, func() bool { return len(out.lines()) == 34 }, time.Second, time.Millisecond)
+	lines := out.lines()
+	assert.Equal(t, `level=ERROR msg="Ping failed" check=ping error=timeout`, lines[0])
+	assert.Equal(t, `level=INFO msg="Ping scheduled" check=ping`, lines[1])
+	// the summary is logged when the window ends.
+	assert.Equal(t, `level=ERROR msg="Ping failed" check=ping error=timeout repeated=29 window=1m0s`, lines[31])
+	// different attributes aren't collapsed.
+	assert.Equal(t, `level=ERROR msg="Ping failed" check=ping error="no route to host"`, lines[32])
+	assert.Equal(t, `level=ERROR msg="Ping failed" check=ping check=dns error=timeout`, lines[33])
+
+	// once the errors stop, the window ends without a summary and the next one is logged.
+	mock.Add(2 * time.Minute)
+	logger.Error("Ping failed", "error", "timeout")
+	require.Eventually(t, func() bool { return len(out.lines()) == 35 }, time.Second, time.Millisecond)
+	assert.Equal(t, `level=ERROR msg="Ping failed" check=ping error=timeout`, out.lines()[34])
+}
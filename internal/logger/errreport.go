@@ -0,0 +1,367 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"yanm/internal/version"
+)
+
+const (
+	// PanicKey holds the value of a recovered panic, logged by LogPanic.
+	PanicKey = "panic"
+
+	_reportQueueSize = 64
+	_reportTimeout   = 10 * time.Second
+)
+
+// LogPanic logs v, recovered from a panic, with the stack that panicked at error level,
+// so error reporting sends it as a crash. The stack is logged as a whole and as the list
+// of frames from the panic outwards, and written to a crash report file when the logger
+// was configured with a CrashDir. Call it from the deferred function that recovered v.
+func LogPanic(ctx context.Context, log *slog.Logger, v any) {
+	stack := debug.Stack()
+	attrs := []slog.Attr{
+		slog.Any(PanicKey, v),
+		slog.String("stack", string(stack)),
+		slog.Any("frames", panicFrames()),
+	}
+	if dir := crashDir(); dir != "" {
+		path, err := writeCrashReport(dir, time.Now(), v, stack)
+		if err != nil {
+			attrs = append(attrs, slog.String(CrashReportKey+"Error", err.Error()))
+		} else {
+			attrs = append(attrs, slog.String(CrashReportKey, path))
+		}
+	}
+	log.LogAttrs(ctx, slog.LevelError, "Panic", attrs...)
+}
+
+// ValidSentryDSN reports whether dsn is a Sentry DSN, e.g. https://key@o1.ingest.sentry.io/42.
+func ValidSentryDSN(dsn string) bool {
+	_, _, err := parseSentryDSN(dsn)
+	return err == nil
+}
+
+// parseSentryDSN returns the envelope endpoint and public key of a Sentry DSN.
+func parseSentryDSN(dsn string) (endpoint, key string, err error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return "", "", err
+	}
+	dir, projectID := path.Split(u.Path)
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.User.Username() == "" || projectID == "" {
+		return "", "", fmt.Errorf("invalid Sentry DSN")
+	}
+	return fmt.Sprintf("%s://%s%sapi/%s/envelope/", u.Scheme, u.Host, dir, projectID), u.User.Username(), nil
+}
+
+// errorReport is an error logged or a panic recovered, to be reported.
+type errorReport struct {
+	Time    time.Time
+	Message string
+	Attrs   map[string]string
+	Panic   bool
+}
+
+// reportSender delivers a report to an error reporting service.
+type reportSender interface {
+	send(r errorReport) error
+}
+
+// errorReporter sends reports in the background, so logging an error doesn't wait on
+// the network.
+type errorReporter struct {
+	senders []reportSender
+	reports chan errorReport
+	flushes chan chan struct{}
+	stop    chan struct{}
+}
+
+func newErrorReporter(cfg ErrorReportingConfig) (*errorReporter, error) {
+	client := &http.Client{Timeout: _reportTimeout}
+	host, _ := os.Hostname()
+	release := version.Get().Version
+
+	var senders []reportSender
+	if cfg.SentryDSN != "" {
+		endpoint, key, err := parseSentryDSN(cfg.SentryDSN)
+		if err != nil {
+			return nil, err
+		}
+		senders = append(senders, &sentrySender{
+			client:      client,
+			endpoint:    endpoint,
+			key:         key,
+			dsn:         cfg.SentryDSN,
+			host:        host,
+			release:     release,
+			environment: cfg.Environment,
+		})
+	}
+	if cfg.WebhookURL != "" {
+		senders = append(senders, &webhookSender{
+			client:      client,
+			url:         cfg.WebhookURL,
+			host:        host,
+			release:     release,
+			environment: cfg.Environment,
+		})
+	}
+
+	r := &errorReporter{
+		senders: senders,
+		reports: make(chan errorReport, _reportQueueSize),
+		flushes: make(chan chan struct{}),
+		stop:    make(chan struct{}),
+	}
+	go r.run()
+	registerFlusher(r)
+	return r, nil
+}
+
+func (r *errorReporter) run() {
+	for {
+		select {
+		case <-r.stop:
+			return
+		case report := <-r.reports:
+			r.send(report)
+		case done := <-r.flushes:
+			for drained := false; !drained; {
+				select {
+				case report := <-r.reports:
+					r.send(report)
+				default:
+					drained = true
+				}
+			}
+			close(done)
+		}
+	}
+}
+
+func (r *errorReporter) send(report errorReport) {
+	for _, s := range r.senders {
+		if err := s.send(report); err != nil {
+			// logging the failure would be reported again.
+			fmt.Fprintf(os.Stderr, "failed to report error %q: %v\n", report.Message, err)
+		}
+	}
+}
+
+// enqueue queues report, dropping it when the services fall behind, e.g. during an
+// outage that also cuts them off.
+func (r *errorReporter) enqueue(report errorReport) {
+	select {
+	case r.reports <- report:
+	default:
+	}
+}
+
+func (r *errorReporter) flush(ctx context.Context) error {
+	done := make(chan struct{})
+	select {
+	case r.flushes <- done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// close sends the queued reports and stops the reporter.
+func (r *errorReporter) close(ctx context.Context) error {
+	unregisterFlusher(r)
+	err := r.flush(ctx)
+	close(r.stop)
+	return err
+}
+
+// reportHandler reports every error logged. Attributes in groups are named with the
+// group as a prefix, e.g. result.download_mbps.
+type reportHandler struct {
+	reporter *errorReporter
+	prefix   string
+	attrs    map[string]string
+}
+
+func (h *reportHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= slog.LevelError
+}
+
+func (h *reportHandler) Handle(_ context.Context, r slog.Record) error {
+	report := errorReport{Time: r.Time, Message: r.Message, Attrs: make(map[string]string, len(h.attrs)+r.NumAttrs())}
+	for k, v := range h.attrs {
+		report.Attrs[k] = v
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		report.Panic = report.Panic || (h.prefix == "" && a.Key == PanicKey)
+		addRingAttr(report.Attrs, h.prefix, a)
+		return true
+	})
+	h.reporter.enqueue(report)
+	return nil
+}
+
+func (h *reportHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *h
+	clone.attrs = make(map[string]string, len(h.attrs)+len(attrs))
+	for k, v := range h.attrs {
+		clone.attrs[k] = v
+	}
+	for _, a := range attrs {
+		addRingAttr(clone.attrs, h.prefix, a)
+	}
+	return &clone
+}
+
+func (h *reportHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	clone := *h
+	clone.prefix = h.prefix + name + "."
+	return &clone
+}
+
+// sentrySender sends reports as events to Sentry's envelope endpoint.
+type sentrySender struct {
+	client      *http.Client
+	endpoint    string
+	key         string
+	dsn         string
+	host        string
+	release     string
+	environment string
+}
+
+type sentryEvent struct {
+	EventID     string            `json:"event_id"`
+	Timestamp   time.Time         `json:"timestamp"`
+	Platform    string            `json:"platform"`
+	Level       string            `json:"level"`
+	Logger      string            `json:"logger"`
+	Message     sentryMessage     `json:"message"`
+	Release     string            `json:"release"`
+	Environment string            `json:"environment,omitempty"`
+	ServerName  string            `json:"server_name,omitempty"`
+	Extra       map[string]string `json:"extra,omitempty"`
+}
+
+type sentryMessage struct {
+	Formatted string `json:"formatted"`
+}
+
+func (s *sentrySender) send(r errorReport) error {
+	id := make([]byte, 16)
+	_, _ = rand.Read(id) // never returns an error
+	event := sentryEvent{
+		EventID:     hex.EncodeToString(id),
+		Timestamp:   r.Time.UTC(),
+		Platform:    "go",
+		Level:       "error",
+		Logger:      "yanm",
+		Message:     sentryMessage{Formatted: r.Message},
+		Release:     "yanm@" + s.release,
+		Environment: s.environment,
+		ServerName:  s.host,
+		Extra:       r.Attrs,
+	}
+	if r.Panic {
+		event.Level = "fatal"
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	// an envelope is a header line followed by items, each a header line and a payload.
+	var body bytes.Buffer
+	_ = json.NewEncoder(&body).Encode(map[string]string{"event_id": event.EventID, "dsn": s.dsn})
+	_ = json.NewEncoder(&body).Encode(map[string]any{"type": "event", "length": len(payload)})
+	body.Write(payload)
+	body.WriteByte('\n')
+
+	req, err := http.NewRequest(http.MethodPost, s.endpoint, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-sentry-envelope")
+	req.Header.Set("X-Sentry-Auth", fmt.Sprintf("Sentry sentry_version=7, sentry_key=%s, sentry_client=yanm/%s", s.key, s.release))
+	return doReport(s.client, req)
+}
+
+// webhookSender posts reports as JSON to a URL, e.g. a chat or incident tool's webhook.
+type webhookSender struct {
+	client      *http.Client
+	url         string
+	host        string
+	release     string
+	environment string
+}
+
+type webhookReport struct {
+	Time        time.Time         `json:"time"`
+	Level       string            `json:"level"`
+	Message     string            `json:"message"`
+	Attrs       map[string]string `json:"attrs,omitempty"`
+	Host        string            `json:"host,omitempty"`
+	Version     string            `json:"version"`
+	Environment string            `json:"environment,omitempty"`
+}
+
+func (s *webhookSender) send(r errorReport) error {
+	level := "error"
+	if r.Panic {
+		level = "panic"
+	}
+	body, err := json.Marshal(webhookReport{
+		Time:        r.Time,
+		Level:       level,
+		Message:     r.Message,
+		Attrs:       r.Attrs,
+		Host:        s.host,
+		Version:     s.release,
+		Environment: s.environment,
+	})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return doReport(s.client, req)
+}
+
+func doReport(client *http.Client, req *http.Request) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("%s responded %s", strings.SplitN(req.URL.Host, ":", 2)[0], resp.Status)
+	}
+	return nil
+}
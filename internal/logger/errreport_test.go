@@ -0,0 +1,106 @@
+package logger
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSentryDSN(t *testing.T) {
+	endpoint, key, err := parseSentryDSN("https://abc123@o1.ingest.sentry.io/42")
+	require.NoError(t, err)
+	assert.Equal(t, "https://o1.ingest.sentry.io/api/42/envelope/", endpoint)
+	assert.Equal(t, "abc123", key)
+
+	endpoint, _, err = parseSentryDSN("http://abc123@sentry.lan:9000/sentry/7")
+	require.NoError(t, err)
+	assert.Equal(t, "http://sentry.lan:9000/sentry/api/7/envelope/", endpoint)
+
+	for _, dsn := range []string{"https://o1.ingest.sentry.io/42", "https://abc123@o1.ingest.sentry.io/", "abc123@o1/42"} {
+		assert.False(t, ValidSentryDSN(dsn), dsn)
+	}
+}
+
+func TestNew_ErrorReporting(t *testing.T) {
+	type request struct {
+		path, auth string
+		body       []byte
+	}
+	requests := make(chan request, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests <- request{path: r.URL.Path, auth: r.Header.Get("X-Sentry-Auth"), body: body}
+	}))
+	defer srv.Close()
+
+	dsn := strings.Replace(srv.URL, "://", "://abc123@", 1) + "/42"
+	log, _, err := New(Config{
+		Level:      "info",
+		OutputFile: "stderr",
+		ErrorReporting: ErrorReportingConfig{
+			SentryDSN:   dsn,
+			WebhookURL:  srv.URL + "/hook",
+			Environment: "cabin",
+		},
+	})
+	require.NoError(t, err)
+
+	log.Warn("not reported")
+	log.With("check", "ping").Error("Ping failed", "error", "timeout")
+	LogPanic(context.Background(), log, "boom")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, Shutdown(ctx))
+	close(requests)
+
+	var events []sentryEvent
+	var hooks []webhookReport
+	for r := range requests {
+		switch r.path {
+		case "/api/42/envelope/":
+			assert.Contains(t, r.auth, "sentry_key=abc123")
+			// the envelope header, the item header and the event.
+			scanner := bufio.NewScanner(strings.NewReader(string(r.body)))
+			var lines []string
+			for scanner.Scan() {
+				lines = append(lines, scanner.Text())
+			}
+			require.Len(t, lines, 3)
+			assert.Contains(t, lines[1], `"type":"event"`)
+			var event sentryEvent
+			require.NoError(t, json.Unmarshal([]byte(lines[2]), &event))
+			events = append(events, event)
+		case "/hook":
+			var hook webhookReport
+			require.NoError(t, json.Unmarshal(r.body, &hook))
+			hooks = append(hooks, hook)
+		default:
+			t.Errorf("unexpected request to %s", r.path)
+		}
+	}
+
+	require.Len(t, events, 2)
+	assert.Equal(t, "Ping failed", events[0].Message.Formatted)
+	assert.Equal(t, "error", events[0].Level)
+	assert.Equal(t, "cabin", events[0].Environment)
+	assert.Equal(t, map[string]string{"check": "ping", "error": "timeout"}, events[0].Extra)
+	assert.Len(t, events[0].EventID, 32)
+	assert.Equal(t, "fatal", events[1].Level)
+	assert.Equal(t, "boom", events[1].Extra[PanicKey])
+	assert.Contains(t, events[1].Extra["stack"], "TestNew_ErrorReporting")
+
+	require.Len(t, hooks, 2)
+	assert.Equal(t, "error", hooks[0].Level)
+	assert.Equal(t, "Ping failed", hooks[0].Message)
+	assert.Equal(t, "panic", hooks[1].Level)
+}
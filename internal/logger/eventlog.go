@@ -0,0 +1,9 @@
+package logger
+
+const (
+	_outputEventLog = "eventlog"
+
+	// EventLogSource is the Windows event log source the eventlog output writes as,
+	// registered when the service is installed.
+	EventLogSource = "yanm"
+)
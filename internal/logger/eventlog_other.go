@@ -0,0 +1,12 @@
+//go:build !windows
+
+package logger
+
+import (
+	"errors"
+)
+
+// openEventLog fails, as the event log only exists on Windows.
+func openEventLog() (syslogSender, error) {
+	return nil, errors.New("eventlog output is only supported on Windows")
+}
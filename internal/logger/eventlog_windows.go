@@ -0,0 +1,43 @@
+//go:build windows
+
+package logger
+
+import (
+	"fmt"
+	"log/slog"
+
+	"golang.org/x/sys/windows/svc/eventlog"
+)
+
+// _eventID is the event ID of every entry, the message carries the record.
+const _eventID = 1
+
+type eventLogWriter struct {
+	log *eventlog.Log
+}
+
+// openEventLog opens the Windows event log as EventLogSource.
+func openEventLog() (syslogSender, error) {
+	log, err := eventlog.Open(EventLogSource)
+	if err != nil {
+		return nil, fmt.Errorf("open the event log: %w", err)
+	}
+	return &eventLogWriter{log: log}, nil
+}
+
+// send writes msg as an error, warning or information event, the event log has no
+// debug level.
+func (e *eventLogWriter) send(level slog.Level, msg string) error {
+	switch {
+	case level >= slog.LevelError:
+		return e.log.Error(_eventID, msg)
+	case level >= slog.LevelWarn:
+		return e.log.Warning(_eventID, msg)
+	default:
+		return e.log.Info(_eventID, msg)
+	}
+}
+
+func (e *eventLogWriter) Close() error {
+	return e.log.Close()
+}
@@ -0,0 +1,156 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"log/slog"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	_outputJournald = "journald"
+
+	// _journalSocket is where systemd-journald receives native protocol messages.
+	_journalSocket = "/run/systemd/journal/socket"
+)
+
+// journaldHandler sends records to systemd-journald using its native protocol, so the
+// level becomes the PRIORITY field and each attribute a field of its own, e.g.
+// "error" becomes ERROR and "result.download_mbps" RESULT_DOWNLOAD_MBPS.
+type journaldHandler struct {
+	opts   slog.HandlerOptions
+	conn   net.Conn
+	mu     *sync.Mutex
+	prefix string // field name prefix of the open groups, e.g. "RESULT_"
+	fields []byte // fields of the attributes added with WithAttrs
+}
+
+func dialJournald(socket string, opts slog.HandlerOptions) (*journaldHandler, error) {
+	conn, err := net.Dial("unixgram", socket)
+	if err != nil {
+		return nil, fmt.Errorf("connect to journald: %w", err)
+	}
+	return &journaldHandler{opts: opts, conn: conn, mu: new(sync.Mutex)}, nil
+}
+
+func (h *journaldHandler) Enabled(_ context.Context, level slog.Level) bool {
+	minLevel := slog.LevelInfo
+	if h.opts.Level != nil {
+		minLevel = h.opts.Level.Level()
+	}
+	return level >= minLevel
+}
+
+func (h *journaldHandler) Handle(_ context.Context, r slog.Record) error {
+	msg := bytes.NewBuffer(nil)
+	appendJournalField(msg, "MESSAGE", r.Message)
+	appendJournalField(msg, "PRIORITY", strconv.Itoa(journalPriority(r.Level)))
+	appendJournalField(msg, "SYSLOG_IDENTIFIER", _defaultSyslogTag)
+	if src, ok := recordSource(r); h.opts.AddSource && ok {
+		appendJournalField(msg, "CODE_FILE", src.File)
+		appendJournalField(msg, "CODE_LINE", strconv.Itoa(src.Line))
+		appendJournalField(msg, "CODE_FUNC", src.Function)
+	}
+	msg.Write(h.fields)
+	r.Attrs(func(a slog.Attr) bool {
+		appendJournalAttr(msg, h.prefix, a)
+		return true
+	})
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := h.conn.Write(msg.Bytes())
+	return err
+}
+
+func (h *journaldHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	fields := bytes.NewBuffer(bytes.Clone(h.fields))
+	for _, a := range attrs {
+		appendJournalAttr(fields, h.prefix, a)
+	}
+	clone := *h
+	clone.fields = fields.Bytes()
+	return &clone
+}
+
+func (h *journaldHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	clone := *h
+	clone.prefix = h.prefix + journalFieldName(name) + "_"
+	return &clone
+}
+
+// journalPriority maps a level to its syslog severity.
+func journalPriority(level slog.Level) int {
+	switch {
+	case level >= slog.LevelError:
+		return 3
+	case level >= slog.LevelWarn:
+		return 4
+	case level >= slog.LevelInfo:
+		return 6
+	default:
+		return 7
+	}
+}
+
+func appendJournalAttr(buf *bytes.Buffer, prefix string, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			prefix += journalFieldName(a.Key) + "_"
+		}
+		for _, ga := range a.Value.Group() {
+			appendJournalAttr(buf, prefix, ga)
+		}
+		return
+	}
+	appendJournalField(buf, prefix+journalFieldName(a.Key), a.Value.String())
+}
+
+// appendJournalField writes a field in the native protocol: KEY=value lines, or a
+// length prefixed value for values spanning lines.
+func appendJournalField(buf *bytes.Buffer, name, value string) {
+	buf.WriteString(name)
+	if !strings.Contains(value, "\n") {
+		buf.WriteByte('=')
+		buf.WriteString(value)
+		buf.WriteByte('\n')
+		return
+	}
+	buf.WriteByte('\n')
+	_ = binary.Write(buf, binary.LittleEndian, uint64(len(value)))
+	buf.WriteString(value)
+	buf.WriteByte('\n')
+}
+
+// journalFieldName turns an attribute key into a journal field name, which may only
+// hold upper case letters, digits and underscores and not start with an underscore,
+// as those fields are reserved for journald.
+func journalFieldName(key string) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_':
+			return r
+		default:
+			return '_'
+		}
+	}, key)
+	name = strings.TrimLeft(name, "_")
+	if name == "" || (name[0] >= '0' && name[0] <= '9') {
+		name = "X" + name
+	}
+	return name
+}
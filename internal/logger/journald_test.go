@@ -0,0 +1,100 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/binary"
+	"log/slog"
+	"net"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// parseJournalFields decodes a native protocol message into its fields.
+func parseJournalFields(t *testing.T, msg []byte) map[string]string {
+	t.Helper()
+	fields := make(map[string]string)
+	for len(msg) > 0 {
+		line, rest, _ := bytes.Cut(msg, []byte("\n"))
+		if name, value, ok := bytes.Cut(line, []byte("=")); ok {
+			fields[string(name)] = string(value)
+			msg = rest
+			continue
+		}
+		size := binary.LittleEndian.Uint64(rest[:8])
+		fields[string(line)] = string(rest[8 : 8+size])
+		msg = rest[8+size+1:]
+	}
+	return fields
+}
+
+func TestJournaldHandler(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("unix datagram sockets are not available")
+	}
+
+	socket := filepath.Join(t.TempDir(), "journal.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socket, Net: "unixgram"})
+	require.NoError(t, err)
+	defer conn.Close()
+
+	level := new(slog.LevelVar)
+	handler, err := dialJournald(socket, slog.HandlerOptions{Level: level})
+	require.NoError(t, err)
+	logger := slog.New(handler)
+
+	receive := func() map[string]string {
+		require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+		buf := make([]byte, 4096)
+		n, err := conn.Read(buf)
+		require.NoError(t, err)
+		return parseJournalFields(t, buf[:n])
+	}
+
+	logger.Debug("not sent")
+	logger.With("component", "monitor").Error("Ping failed", "error", "line one\nline two", "_private", 1)
+	assert.Equal(t, map[string]string{
+		"MESSAGE":           "Ping failed",
+		"PRIORITY":          "3",
+		"SYSLOG_IDENTIFIER": "yanm",
+		"COMPONENT":         "monitor",
+		"ERROR":             "line one\nline two",
+		"PRIVATE":           "1",
+	}, receive())
+
+	logger.WithGroup("result").Info("Speed test", "download-mbps", 100, slog.Group("server", "name", "Cabin ISP"))
+	assert.Equal(t, map[string]string{
+		"MESSAGE":              "Speed test",
+		"PRIORITY":             "6",
+		"SYSLOG_IDENTIFIER":    "yanm",
+		"RESULT_DOWNLOAD_MBPS": "100",
+		"RESULT_SERVER_NAME":   "Cabin ISP",
+	}, receive())
+
+	handler.opts.AddSource = true
+	logger.Info("located")
+	fields := receive()
+	assert.Contains(t, fields["CODE_FILE"], "journald_test.go")
+	assert.NotEmpty(t, fields["CODE_LINE"])
+	assert.Equal(t, "yanm/internal/logger.TestJournaldHandler", fields["CODE_FUNC"])
+}
+
+func TestJournalFieldName(t *testing.T) {
+	assert.Equal(t, "DOWNLOAD_MBPS", journalFieldName("download_mbps"))
+	assert.Equal(t, "REQUESTID", journalFieldName("requestID"))
+	assert.Equal(t, "REMOTE_ADDR", journalFieldName("remote.addr"))
+	assert.Equal(t, "SYSTEMD", journalFieldName("_systemd"))
+	assert.Equal(t, "X1ST", journalFieldName("1st"))
+	assert.Equal(t, "X", journalFieldName("__"))
+}
+
+func TestJournalPriority(t *testing.T) {
+	assert.Equal(t, 7, journalPriority(slog.LevelDebug))
+	assert.Equal(t, 6, journalPriority(slog.LevelInfo))
+	assert.Equal(t, 4, journalPriority(slog.LevelWarn))
+	assert.Equal(t, 3, journalPriority(slog.LevelError))
+}
@@ -0,0 +1,89 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"sync"
+)
+
+// _levels are the levels a Controller steps through, from most to least verbose.
+var _levels = []slog.Level{slog.LevelDebug, slog.LevelInfo, slog.LevelWarn, slog.LevelError}
+
+// Controller changes the level of a logger created by New while it runs, e.g. from
+// the debug server, a signal or a configuration reload.
+type Controller struct {
+	level *slog.LevelVar
+	// recent keeps the last BufferSize entries, nil if disabled.
+	recent *ringBuffer
+
+	root   *swapHandler
+	mu     sync.Mutex
+	opened closers // by the current handler chain
+}
+
+// Level returns the current level.
+func (c *Controller) Level() slog.Level {
+	return c.level.Level()
+}
+
+// SetLevel sets the level, taking effect for the next record logged.
+func (c *Controller) SetLevel(level slog.Level) {
+	c.level.Set(level)
+}
+
+// Set parses a level name such as debug or warn and sets it.
+func (c *Controller) Set(name string) error {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(name)); err != nil {
+		return err
+	}
+	c.SetLevel(level)
+	return nil
+}
+
+// String returns the current level name in lower case, as it is configured.
+func (c *Controller) String() string {
+	return strings.ToLower(c.Level().String())
+}
+
+// MoreVerbose lowers the level by one step, down to debug, and returns the new level.
+func (c *Controller) MoreVerbose() slog.Level {
+	for i := len(_levels) - 1; i >= 0; i-- {
+		if _levels[i] < c.Level() {
+			c.SetLevel(_levels[i])
+			break
+		}
+	}
+	return c.Level()
+}
+
+// LessVerbose raises the level by one step, up to error, and returns the new level.
+func (c *Controller) LessVerbose() slog.Level {
+	for _, level := range _levels {
+		if level > c.Level() {
+			c.SetLevel(level)
+			break
+		}
+	}
+	return c.Level()
+}
+
+// Reconfigure replaces the outputs, format and other settings of the logger with those
+// of config, then closes the files and connections of the previous outputs, waiting
+// until ctx is done for the logs they buffered to be sent. The level is left to Set,
+// and the number of recent entries kept is fixed by New.
+func (c *Controller) Reconfigure(ctx context.Context, config Config) error {
+	handler, opened, err := c.build(config)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	prev := c.opened
+	c.opened = opened
+	c.root.swap(handler)
+	c.mu.Unlock()
+	setCrashDir(config.CrashDir)
+	return prev.close(ctx)
+}
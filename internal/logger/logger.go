@@ -1,29 +1,155 @@
 package logger
 
 import (
+	"context"
+	"fmt"
 	"log/slog"
 	"os"
+	"runtime"
+	"time"
+
+	"github.com/benbjohnson/clock"
 )
 
-// New creates a new Zap logger with the specified log level and output file.
+// New creates a new logger with the specified log level and output file.
 // If outputFile is empty or "stdout", logs will be written to standard output.
 // If outputFile is "stderr", logs will be written to standard error.
-// Otherwise, logs will be written to the specified file.
-func New(config Config) (*slog.Logger, error) {
-	var programLevel = new(slog.LevelVar) // Info by default
-	if err := programLevel.UnmarshalText([]byte(config.Level)); err != nil {
+// Otherwise, logs will be appended to the specified file, which is created if missing.
+// The returned Controller changes the logger's level at runtime, serves the recent
+// logs kept in memory and applies a reloaded configuration.
+func New(config Config) (*slog.Logger, *Controller, error) {
+	level := new(slog.LevelVar)
+	if err := level.UnmarshalText([]byte(config.Level)); err != nil {
+		return nil, nil, err
+	}
+
+	c := &Controller{level: level}
+	if config.BufferSize > 0 {
+		c.recent = newRingBuffer(config.BufferSize)
+	}
+	handler, opened, err := c.build(config)
+	if err != nil {
+		return nil, nil, err
+	}
+	c.root, c.opened = newSwapHandler(handler), opened
+	setCrashDir(config.CrashDir)
+	return slog.New(c.root), c, nil
+}
+
+// build returns the handler chain for config, at the Controller's level and keeping
+// recent entries in its buffer, along with what the chain opened.
+func (c *Controller) build(config Config) (slog.Handler, closers, error) {
+	var opened closers
+	handler, err := newHandler(config, c.level, &opened)
+	if err != nil {
+		_ = opened.close(context.Background())
+		return nil, nil, err
+	}
+	if config.OTLP.Endpoint != "" {
+		exporter := newOTLPExporter(config.OTLP)
+		opened = append(opened, exporter.close)
+		handler = multiHandler{handler, &otlpHandler{exporter: exporter, level: c.level, addSource: config.AddSource}}
+	}
+	if config.ErrorReporting.Enabled() {
+		reporter, err := newErrorReporter(config.ErrorReporting)
+		if err != nil {
+			_ = opened.close(context.Background())
+			return nil, nil, err
+		}
+		opened = append(opened, reporter.close)
+		handler = multiHandler{handler, &reportHandler{reporter: reporter}}
+	}
+	if c.recent != nil {
+		handler = multiHandler{handler, &ringHandler{buf: c.recent, level: c.level}}
+	}
+	handler = &contextHandler{next: handler}
+	// repeats are identified without the context's attributes, which usually differ.
+	if config.Dedup.WindowSeconds > 0 {
+		handler = newDedupHandler(handler, time.Duration(config.Dedup.WindowSeconds)*time.Second, clock.New())
+	}
+	return handler, opened, nil
+}
+
+// newHandler returns the handler writing to the configured outputs, sending every
+// record to each of them when there are several. The files and connections it opens
+// are added to opened.
+func newHandler(config Config, level *slog.LevelVar, opened *closers) (slog.Handler, error) {
+	replace, err := replaceTime(config.TimeFormat, config.TimeZone)
+	if err != nil {
 		return nil, err
 	}
+	opts := &slog.HandlerOptions{
+		Level:       level,
+		AddSource:   config.AddSource,
+		ReplaceAttr: replace,
+	}
 
-	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
-		Level: programLevel,
-	}))
+	if len(config.Outputs) == 0 {
+		return newOutputHandler(config, config.Output, opts, opened)
+	}
+	handlers := make(multiHandler, 0, len(config.Outputs))
+	for _, output := range config.Outputs {
+		handler, err := newOutputHandler(config, output, opts, opened)
+		if err != nil {
+			return nil, fmt.Errorf("logging output %s: %w", output, err)
+		}
+		handlers = append(handlers, handler)
+	}
+	if len(handlers) == 1 {
+		return handlers[0], nil
+	}
+	return handlers, nil
+}
 
+// newOutputHandler returns the handler writing to output: file writes to OutputFile,
+// and stdout and stderr to the standard streams regardless of OutputFile.
+func newOutputHandler(config Config, output string, opts *slog.HandlerOptions, opened *closers) (slog.Handler, error) {
+	outputFile := config.OutputFile
+	switch output {
+	case _outputJournald:
+		handler, err := dialJournald(_journalSocket, *opts)
+		if err != nil {
+			return nil, err
+		}
+		opened.add(handler.conn)
+		return handler, nil
+	case _outputSyslog:
+		sender, err := dialSyslog(config.Syslog)
+		if err != nil {
+			return nil, err
+		}
+		opened.add(sender)
+		return newSyslogHandler(sender, config.Format, opts), nil
+	case _outputEventLog:
+		sender, err := openEventLog()
+		if err != nil {
+			return nil, err
+		}
+		opened.add(sender)
+		return newSyslogHandler(sender, config.Format, opts), nil
+	case _stdout, _stderr:
+		outputFile = output
+	}
+
+	out, err := openOutput(outputFile, config.Rotation)
+	if err != nil {
+		return nil, err
+	}
+	if out != os.Stdout && out != os.Stderr {
+		opened.add(out)
+	}
 	if config.Format == "text" {
-		logger = slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
-			Level: programLevel,
-		}))
+		return slog.NewTextHandler(out, opts), nil
 	}
+	return slog.NewJSONHandler(out, opts), nil
+}
 
-	return logger, nil
+// recordSource returns where r was logged, for the handlers that don't use the standard
+// handlers' AddSource.
+func recordSource(r slog.Record) (slog.Source, bool) {
+	if r.PC == 0 {
+		return slog.Source{}, false
+	}
+	frame, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+	return slog.Source{Function: frame.Function, File: frame.File, Line: frame.Line}, true
 }
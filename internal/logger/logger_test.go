@@ -1,6 +1,11 @@
 package logger
 
 import (
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -64,24 +69,34 @@ func TestNew(t *testing.T) {
 			},
 		},
 		{
-			name: "output file stderr (current code uses stdout)",
+			name: "output file stdout",
 			cfg: Config{
-				Level:  "info",
-				Format: "json",
+				Level:      "info",
+				Format:     "json",
+				OutputFile: "stdout",
 			},
 		},
 		{
-			name: "output file to a specific file (current code uses stdout)",
+			name: "output file stderr",
 			cfg: Config{
-				Level:  "info",
-				Format: "text",
+				Level:      "info",
+				Format:     "json",
+				OutputFile: "stderr",
+			},
+		},
+		{
+			name: "output file in a missing directory",
+			cfg: Config{
+				Level:      "info",
+				Format:     "text",
+				OutputFile: filepath.Join(t.TempDir(), "logs", "yanm.log"),
 			},
 		},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			logger, err := New(tc.cfg)
+			logger, _, err := New(tc.cfg)
 
 			if tc.expectError != "" {
 				require.EqualError(t, err, tc.expectError)
@@ -97,3 +112,104 @@ func TestNew(t *testing.T) {
 		// regarding error returns and successful logger instantiation.
 	}
 }
+
+func TestNew_Controller(t *testing.T) {
+	logger, levels, err := New(Config{Level: "warn", Format: "json"})
+	require.NoError(t, err)
+	require.Equal(t, slog.LevelWarn, levels.Level())
+	require.False(t, logger.Enabled(context.Background(), slog.LevelInfo))
+
+	// changing the level changes the verbosity of the existing logger.
+	require.NoError(t, levels.Set("debug"))
+	require.True(t, logger.Enabled(context.Background(), slog.LevelDebug))
+	require.Equal(t, "debug", levels.String())
+
+	require.Error(t, levels.Set("chatty"))
+	require.Equal(t, slog.LevelDebug, levels.Level())
+}
+
+func TestController_Verbosity(t *testing.T) {
+	levels := &Controller{level: new(slog.LevelVar)}
+
+	require.Equal(t, slog.LevelDebug, levels.MoreVerbose())
+	require.Equal(t, slog.LevelDebug, levels.MoreVerbose())
+	require.Equal(t, slog.LevelInfo, levels.LessVerbose())
+	require.Equal(t, slog.LevelWarn, levels.LessVerbose())
+	require.Equal(t, slog.LevelError, levels.LessVerbose())
+	require.Equal(t, slog.LevelError, levels.LessVerbose())
+
+	// levels in between the named ones step to the next named level.
+	levels.SetLevel(slog.LevelInfo + 2)
+	require.Equal(t, slog.LevelInfo, levels.MoreVerbose())
+	levels.SetLevel(slog.LevelInfo + 2)
+	require.Equal(t, slog.LevelWarn, levels.LessVerbose())
+}
+
+func TestController_Reconfigure(t *testing.T) {
+	dir := t.TempDir()
+	before, after := filepath.Join(dir, "before.log"), filepath.Join(dir, "after.log")
+
+	log, levels, err := New(Config{Level: "info", Format: "text", OutputFile: before, BufferSize: 10})
+	require.NoError(t, err)
+	component := log.With("component", "monitor").WithGroup("result")
+	component.Info("before", "ok", true)
+
+	require.NoError(t, levels.Reconfigure(context.Background(), Config{Level: "info", Format: "json", OutputFile: after}))
+	component.Info("after", "ok", true)
+
+	data, err := os.ReadFile(before)
+	require.NoError(t, err)
+	require.Contains(t, string(data), `msg=before component=monitor result.ok=true`)
+	require.NotContains(t, string(data), "after")
+
+	data, err = os.ReadFile(after)
+	require.NoError(t, err)
+	require.Contains(t, string(data), `"msg":"after","component":"monitor","result":{"ok":true}`)
+	require.Len(t, levels.recent.recent(slog.LevelDebug), 2, "the recent entries are kept")
+
+	err = levels.Reconfigure(context.Background(), Config{Level: "info", OutputFile: dir})
+	require.ErrorContains(t, err, "open log file")
+	component.Info("still after")
+	data, err = os.ReadFile(after)
+	require.NoError(t, err)
+	require.Contains(t, string(data), "still after", "a failed reconfiguration keeps the outputs")
+}
+
+func TestNew_OutputFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "yanm.log")
+	require.NoError(t, os.WriteFile(path, []byte("previous run\n"), 0o644))
+
+	logger, _, err := New(Config{Level: "info", Format: "text", OutputFile: path})
+	require.NoError(t, err)
+	logger.Info("written to file")
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 2)
+	require.Equal(t, "previous run", lines[0], "existing logs are appended to")
+	require.Contains(t, lines[1], "msg=\"written to file\"")
+}
+
+func TestNew_OutputFileError(t *testing.T) {
+	dir := t.TempDir()
+	_, _, err := New(Config{Level: "info", OutputFile: dir})
+	require.ErrorContains(t, err, "open log file")
+}
+
+func TestNew_Outputs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "yanm.log")
+
+	handler, err := newHandler(Config{Format: "text", Outputs: []string{"stderr", "file"}, OutputFile: path}, new(slog.LevelVar), new(closers))
+	require.NoError(t, err)
+	require.IsType(t, multiHandler{}, handler)
+	require.Len(t, handler, 2)
+
+	slog.New(handler).Info("written to both")
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Contains(t, string(data), "msg=\"written to both\"")
+
+	_, _, err = New(Config{Level: "info", Outputs: []string{"stdout", "file"}, OutputFile: t.TempDir()})
+	require.ErrorContains(t, err, "logging output file: open log file")
+}
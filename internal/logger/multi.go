@@ -0,0 +1,45 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+)
+
+// multiHandler sends every record to each of its handlers.
+type multiHandler []slog.Handler
+
+func (m multiHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, h := range m {
+		if h.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m multiHandler) Handle(ctx context.Context, r slog.Record) error {
+	var errs []error
+	for _, h := range m {
+		if h.Enabled(ctx, r.Level) {
+			errs = append(errs, h.Handle(ctx, r.Clone()))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (m multiHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	handlers := make(multiHandler, 0, len(m))
+	for _, h := range m {
+		handlers = append(handlers, h.WithAttrs(attrs))
+	}
+	return handlers
+}
+
+func (m multiHandler) WithGroup(name string) slog.Handler {
+	handlers := make(multiHandler, 0, len(m))
+	for _, h := range m {
+		handlers = append(handlers, h.WithGroup(name))
+	}
+	return handlers
+}
@@ -0,0 +1,354 @@
+package logger
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	_otlpLogsPath      = "/v1/logs"
+	_otlpBatchSize     = 512
+	_otlpQueueSize     = 2048
+	_otlpFlushInterval = 2 * time.Second
+	_otlpTimeout       = 10 * time.Second
+)
+
+// flusher sends the logs it buffered for delivery elsewhere.
+type flusher interface {
+	flush(ctx context.Context) error
+}
+
+// _flushers are flushed by Shutdown.
+var (
+	_flushersMu sync.Mutex
+	_flushers   []flusher
+)
+
+func registerFlusher(f flusher) {
+	_flushersMu.Lock()
+	defer _flushersMu.Unlock()
+	_flushers = append(_flushers, f)
+}
+
+func unregisterFlusher(f flusher) {
+	_flushersMu.Lock()
+	defer _flushersMu.Unlock()
+	_flushers = slices.DeleteFunc(_flushers, func(g flusher) bool { return g == f })
+}
+
+// Shutdown sends the logs still buffered for OTLP export and error reporting, waiting
+// until ctx is done.
+func Shutdown(ctx context.Context) error {
+	_flushersMu.Lock()
+	flushers := _flushers
+	_flushersMu.Unlock()
+
+	for _, f := range flushers {
+		if err := f.flush(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// The types below follow the OTLP JSON encoding of ExportLogsServiceRequest.
+
+type otlpValue struct {
+	StringValue *string      `json:"stringValue,omitempty"`
+	BoolValue   *bool        `json:"boolValue,omitempty"`
+	IntValue    *string      `json:"intValue,omitempty"` // int64 values are strings in OTLP JSON.
+	DoubleValue *float64     `json:"doubleValue,omitempty"`
+	KvlistValue *otlpKeyList `json:"kvlistValue,omitempty"`
+}
+
+type otlpKeyList struct {
+	Values []otlpKeyValue `json:"values"`
+}
+
+type otlpKeyValue struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+type otlpLogRecord struct {
+	TimeUnixNano         string         `json:"timeUnixNano"`
+	ObservedTimeUnixNano string         `json:"observedTimeUnixNano"`
+	SeverityNumber       int            `json:"severityNumber"`
+	SeverityText         string         `json:"severityText"`
+	Body                 otlpValue      `json:"body"`
+	Attributes           []otlpKeyValue `json:"attributes,omitempty"`
+}
+
+type otlpRequest struct {
+	ResourceLogs []otlpResourceLogs `json:"resourceLogs"`
+}
+
+type otlpResourceLogs struct {
+	Resource  otlpResource    `json:"resource"`
+	ScopeLogs []otlpScopeLogs `json:"scopeLogs"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScopeLogs struct {
+	Scope      otlpScope       `json:"scope"`
+	LogRecords []otlpLogRecord `json:"logRecords"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+// otlpExporter batches log records and posts them to the collector in the background.
+type otlpExporter struct {
+	client      *http.Client
+	url         string
+	headers     map[string]string
+	serviceName string
+
+	records chan otlpLogRecord
+	flushes chan chan struct{}
+	stop    chan struct{}
+}
+
+func newOTLPExporter(cfg OTLPConfig) *otlpExporter {
+	url := strings.TrimSuffix(cfg.Endpoint, "/")
+	if !strings.HasSuffix(url, _otlpLogsPath) {
+		url += _otlpLogsPath
+	}
+	e := &otlpExporter{
+		client:      &http.Client{Timeout: _otlpTimeout},
+		url:         url,
+		headers:     cfg.Headers,
+		serviceName: cmp.Or(cfg.ServiceName, _defaultSyslogTag),
+		records:     make(chan otlpLogRecord, _otlpQueueSize),
+		flushes:     make(chan chan struct{}),
+		stop:        make(chan struct{}),
+	}
+	go e.run()
+	registerFlusher(e)
+	return e
+}
+
+func (e *otlpExporter) run() {
+	ticker := time.NewTicker(_otlpFlushInterval)
+	defer ticker.Stop()
+
+	var batch []otlpLogRecord
+	for {
+		select {
+		case <-e.stop:
+			return
+		case r := <-e.records:
+			batch = append(batch, r)
+			if len(batch) < _otlpBatchSize {
+				continue
+			}
+		case <-ticker.C:
+		case done := <-e.flushes:
+			// take what was queued before the flush.
+			for drained := false; !drained; {
+				select {
+				case r := <-e.records:
+					batch = append(batch, r)
+				default:
+					drained = true
+				}
+			}
+			e.export(batch)
+			batch = nil
+			close(done)
+			continue
+		}
+		e.export(batch)
+		batch = nil
+	}
+}
+
+// enqueue queues r for export, dropping it rather than blocking logging when the
+// collector falls behind.
+func (e *otlpExporter) enqueue(r otlpLogRecord) {
+	select {
+	case e.records <- r:
+	default:
+	}
+}
+
+func (e *otlpExporter) flush(ctx context.Context) error {
+	done := make(chan struct{})
+	select {
+	case e.flushes <- done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// close sends the queued records and stops the exporter.
+func (e *otlpExporter) close(ctx context.Context) error {
+	unregisterFlusher(e)
+	err := e.flush(ctx)
+	close(e.stop)
+	return err
+}
+
+func (e *otlpExporter) export(records []otlpLogRecord) {
+	if len(records) == 0 {
+		return
+	}
+	body, err := json.Marshal(otlpRequest{ResourceLogs: []otlpResourceLogs{{
+		Resource: otlpResource{Attributes: []otlpKeyValue{
+			{Key: "service.name", Value: stringValue(e.serviceName)},
+		}},
+		ScopeLogs: []otlpScopeLogs{{Scope: otlpScope{Name: "yanm"}, LogRecords: records}},
+	}}})
+	if err == nil {
+		err = e.post(body)
+	}
+	if err != nil {
+		// logging the failure would feed back into the exporter.
+		fmt.Fprintf(os.Stderr, "failed to export %d log records over OTLP: %v\n", len(records), err)
+	}
+}
+
+func (e *otlpExporter) post(body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, e.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range e.headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("collector responded %s", resp.Status)
+	}
+	return nil
+}
+
+// otlpHandler converts records to OTLP log records for the exporter. Attributes in
+// groups are named with the group as a prefix, e.g. result.download_mbps.
+type otlpHandler struct {
+	exporter  *otlpExporter
+	level     slog.Leveler
+	addSource bool
+	prefix    string
+	attrs     []otlpKeyValue
+}
+
+func (h *otlpHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+func (h *otlpHandler) Handle(_ context.Context, r slog.Record) error {
+	attrs := append([]otlpKeyValue(nil), h.attrs...)
+	r.Attrs(func(a slog.Attr) bool {
+		attrs = appendOTLPAttr(attrs, h.prefix, a)
+		return true
+	})
+	if src, ok := recordSource(r); h.addSource && ok {
+		attrs = append(attrs,
+			otlpKeyValue{Key: "code.file.path", Value: stringValue(src.File)},
+			otlpKeyValue{Key: "code.line.number", Value: otlpValueOf(slog.IntValue(src.Line))},
+			otlpKeyValue{Key: "code.function.name", Value: stringValue(src.Function)},
+		)
+	}
+	h.exporter.enqueue(otlpLogRecord{
+		TimeUnixNano:         strconv.FormatInt(r.Time.UnixNano(), 10),
+		ObservedTimeUnixNano: strconv.FormatInt(time.Now().UnixNano(), 10),
+		SeverityNumber:       otlpSeverity(r.Level),
+		SeverityText:         r.Level.String(),
+		Body:                 stringValue(r.Message),
+		Attributes:           attrs,
+	})
+	return nil
+}
+
+func (h *otlpHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *h
+	clone.attrs = append([]otlpKeyValue(nil), h.attrs...)
+	for _, a := range attrs {
+		clone.attrs = appendOTLPAttr(clone.attrs, h.prefix, a)
+	}
+	return &clone
+}
+
+func (h *otlpHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	clone := *h
+	clone.prefix = h.prefix + name + "."
+	return &clone
+}
+
+// otlpSeverity maps a level to its OpenTelemetry severity number, which places DEBUG,
+// INFO, WARN and ERROR at 5, 9, 13 and 17 like slog's levels at -4, 0, 4 and 8.
+func otlpSeverity(level slog.Level) int {
+	return min(max(int(level)+9, 1), 24)
+}
+
+func appendOTLPAttr(attrs []otlpKeyValue, prefix string, a slog.Attr) []otlpKeyValue {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return attrs
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			prefix += a.Key + "."
+		}
+		for _, ga := range a.Value.Group() {
+			attrs = appendOTLPAttr(attrs, prefix, ga)
+		}
+		return attrs
+	}
+	return append(attrs, otlpKeyValue{Key: prefix + a.Key, Value: otlpValueOf(a.Value)})
+}
+
+func otlpValueOf(v slog.Value) otlpValue {
+	switch v.Kind() {
+	case slog.KindBool:
+		b := v.Bool()
+		return otlpValue{BoolValue: &b}
+	case slog.KindInt64:
+		i := strconv.FormatInt(v.Int64(), 10)
+		return otlpValue{IntValue: &i}
+	case slog.KindUint64:
+		i := strconv.FormatUint(v.Uint64(), 10)
+		return otlpValue{IntValue: &i}
+	case slog.KindFloat64:
+		f := v.Float64()
+		return otlpValue{DoubleValue: &f}
+	default:
+		return stringValue(v.String())
+	}
+}
+
+func stringValue(s string) otlpValue {
+	return otlpValue{StringValue: &s}
+}
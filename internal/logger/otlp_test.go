@@ -0,0 +1,118 @@
+package logger
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew_OTLP(t *testing.T) {
+	requests := make(chan *http.Request, 1)
+	bodies := make(chan otlpRequest, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body otlpRequest
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		requests <- r
+		bodies <- body
+	}))
+	defer srv.Close()
+
+	log, _, err := New(Config{
+		Level:      "info",
+		OutputFile: "stderr",
+		OTLP: OTLPConfig{
+			Endpoint: srv.URL,
+			Headers:  map[string]string{"Authorization": "Bearer secret"},
+		},
+	})
+	require.NoError(t, err)
+
+	log.Debug("dropped")
+	log.With("check", "ping").WithGroup("result").Warn("Check failed", "latency_ms", 42, "ok", false)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, Shutdown(ctx))
+
+	r := <-requests
+	assert.Equal(t, "/v1/logs", r.URL.Path)
+	assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+	assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+
+	body := <-bodies
+	require.Len(t, body.ResourceLogs, 1)
+	resource := body.ResourceLogs[0]
+	assert.Equal(t, []otlpKeyValue{{Key: "service.name", Value: stringValue("yanm")}}, resource.Resource.Attributes)
+	require.Len(t, resource.ScopeLogs, 1)
+	require.Len(t, resource.ScopeLogs[0].LogRecords, 1)
+
+	record := resource.ScopeLogs[0].LogRecords[0]
+	assert.Equal(t, 13, record.SeverityNumber)
+	assert.Equal(t, "WARN", record.SeverityText)
+	assert.Equal(t, stringValue("Check failed"), record.Body)
+	assert.NotEmpty(t, record.TimeUnixNano)
+	latency, ok := "42", false
+	assert.Equal(t, []otlpKeyValue{
+		{Key: "check", Value: stringValue("ping")},
+		{Key: "result.latency_ms", Value: otlpValue{IntValue: &latency}},
+		{Key: "result.ok", Value: otlpValue{BoolValue: &ok}},
+	}, record.Attributes)
+}
+
+func TestOTLPSeverity(t *testing.T) {
+	tests := []struct {
+		level slog.Level
+		want  int
+	}{
+		{slog.LevelDebug, 5},
+		{slog.LevelInfo, 9},
+		{slog.LevelWarn, 13},
+		{slog.LevelError, 17},
+		{slog.LevelDebug - 10, 1},
+		{slog.LevelError + 20, 24},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, otlpSeverity(tt.level), tt.level.String())
+	}
+}
+
+func TestNewOTLPExporter_URL(t *testing.T) {
+	tests := []struct {
+		endpoint string
+		want     string
+	}{
+		{"http://collector:4318", "http://collector:4318/v1/logs"},
+		{"http://collector:4318/", "http://collector:4318/v1/logs"},
+		{"https://otlp.example.com/v1/logs", "https://otlp.example.com/v1/logs"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, newOTLPExporter(OTLPConfig{Endpoint: tt.endpoint}).url, tt.endpoint)
+	}
+}
+
+func TestOTLPExporter_Close(t *testing.T) {
+	exported := make(chan struct{}, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		exported <- struct{}{}
+	}))
+	defer srv.Close()
+
+	e := newOTLPExporter(OTLPConfig{Endpoint: srv.URL})
+	e.enqueue(otlpLogRecord{Body: stringValue("queued")})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, e.close(ctx))
+	<-exported
+
+	_flushersMu.Lock()
+	defer _flushersMu.Unlock()
+	assert.NotContains(t, _flushers, flusher(e), "a closed exporter is no longer flushed by Shutdown")
+}
@@ -0,0 +1,38 @@
+package logger
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+const (
+	_stdout = "stdout"
+	_stderr = "stderr"
+)
+
+// openOutput returns the writer for outputFile: standard output for "" or "stdout",
+// standard error for "stderr", and otherwise the file, appended to and created along
+// with its directory if missing, and rotated when enabled.
+func openOutput(outputFile string, rotation RotationConfig) (io.Writer, error) {
+	switch outputFile {
+	case "", _stdout:
+		return os.Stdout, nil
+	case _stderr:
+		return os.Stderr, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(outputFile), 0o755); err != nil {
+		return nil, fmt.Errorf("create log directory: %w", err)
+	}
+	// the file stays open for the life of the process, like stdout.
+	if rotation.Enabled() {
+		return newRotatingFile(outputFile, rotation)
+	}
+	f, err := os.OpenFile(outputFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open log file: %w", err)
+	}
+	return f, nil
+}
@@ -0,0 +1,119 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// logEntry is a record kept by the ring buffer, with its attributes formatted.
+type logEntry struct {
+	Time    time.Time         `json:"time"`
+	Level   slog.Level        `json:"level"`
+	Message string            `json:"message"`
+	Attrs   map[string]string `json:"attrs,omitempty"`
+}
+
+// ringBuffer keeps the most recent log entries in memory.
+type ringBuffer struct {
+	mu      sync.Mutex
+	entries []logEntry
+	next    int
+	full    bool
+}
+
+func newRingBuffer(size int) *ringBuffer {
+	return &ringBuffer{entries: make([]logEntry, size)}
+}
+
+func (b *ringBuffer) add(e logEntry) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.entries[b.next] = e
+	b.next = (b.next + 1) % len(b.entries)
+	b.full = b.full || b.next == 0
+}
+
+// recent returns the entries at minLevel or above, newest first.
+func (b *ringBuffer) recent(minLevel slog.Level) []logEntry {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	n := b.next
+	if b.full {
+		n = len(b.entries)
+	}
+	entries := make([]logEntry, 0, n)
+	for i := range n {
+		e := b.entries[(b.next-1-i+len(b.entries))%len(b.entries)]
+		if e.Level >= minLevel {
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
+
+// ringHandler adds every record to a ringBuffer. Attributes in groups are named
+// with the group as a prefix, e.g. result.download_mbps.
+type ringHandler struct {
+	buf    *ringBuffer
+	level  slog.Leveler
+	prefix string
+	attrs  map[string]string
+}
+
+func (h *ringHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+func (h *ringHandler) Handle(_ context.Context, r slog.Record) error {
+	attrs := make(map[string]string, len(h.attrs)+r.NumAttrs())
+	for k, v := range h.attrs {
+		attrs[k] = v
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		addRingAttr(attrs, h.prefix, a)
+		return true
+	})
+	h.buf.add(logEntry{Time: r.Time, Level: r.Level, Message: r.Message, Attrs: attrs})
+	return nil
+}
+
+func (h *ringHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *h
+	clone.attrs = make(map[string]string, len(h.attrs)+len(attrs))
+	for k, v := range h.attrs {
+		clone.attrs[k] = v
+	}
+	for _, a := range attrs {
+		addRingAttr(clone.attrs, h.prefix, a)
+	}
+	return &clone
+}
+
+func (h *ringHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	clone := *h
+	clone.prefix = h.prefix + name + "."
+	return &clone
+}
+
+func addRingAttr(attrs map[string]string, prefix string, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			prefix += a.Key + "."
+		}
+		for _, ga := range a.Value.Group() {
+			addRingAttr(attrs, prefix, ga)
+		}
+		return
+	}
+	attrs[prefix+a.Key] = a.Value.String()
+}
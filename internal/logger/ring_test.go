@@ -0,0 +1,43 @@
+package logger
+
+import (
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRingHandler(t *testing.T) {
+	buf := newRingBuffer(3)
+	level := new(slog.LevelVar)
+	logger := slog.New(&ringHandler{buf: buf, level: level})
+
+	assert.Empty(t, buf.recent(slog.LevelDebug))
+
+	logger.Debug("not kept")
+	logger.With("check", "ping").Warn("Ping failed", "error", "timeout")
+	logger.WithGroup("result").Info("Speed test", "download_mbps", 100, slog.Group("server", "name", "Cabin ISP"))
+
+	entries := buf.recent(slog.LevelDebug)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "Speed test", entries[0].Message, "newest first")
+	assert.Equal(t, map[string]string{"result.download_mbps": "100", "result.server.name": "Cabin ISP"}, entries[0].Attrs)
+	assert.Equal(t, slog.LevelWarn, entries[1].Level)
+	assert.Equal(t, map[string]string{"check": "ping", "error": "timeout"}, entries[1].Attrs)
+
+	// the oldest entries are dropped once full.
+	for _, msg := range []string{"one", "two", "three"} {
+		logger.Info(msg)
+	}
+	var messages []string
+	for _, e := range buf.recent(slog.LevelDebug) {
+		messages = append(messages, e.Message)
+	}
+	assert.Equal(t, []string{"three", "two", "one"}, messages)
+
+	logger.Error("four")
+	entries = buf.recent(slog.LevelWarn)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "four", entries[0].Message)
+}
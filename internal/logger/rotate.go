@@ -0,0 +1,223 @@
+package logger
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	_backupTimeFormat = "2006-01-02T15-04-05.000"
+	_compressedSuffix = ".gz"
+)
+
+// rotatingFile is a log file that is renamed aside once it reaches maxSize, e.g.
+// yanm.log becomes yanm-2025-01-02T03-04-05.000.log, and a new file is started.
+type rotatingFile struct {
+	path       string
+	maxSize    int64
+	maxBackups int
+	maxAge     time.Duration
+	compress   bool
+	now        func() time.Time
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+
+	// cleanupMu serializes the cleanups running in the background after each rotation,
+	// which cleanups tracks so Close can wait for them.
+	cleanupMu sync.Mutex
+	cleanups  sync.WaitGroup
+}
+
+var _ io.WriteCloser = (*rotatingFile)(nil)
+
+func newRotatingFile(path string, cfg RotationConfig) (*rotatingFile, error) {
+	r := &rotatingFile{
+		path:       path,
+		maxSize:    int64(cfg.MaxSizeMB) * 1024 * 1024,
+		maxBackups: cfg.MaxBackups,
+		maxAge:     time.Duration(cfg.MaxAgeDays) * 24 * time.Hour,
+		compress:   cfg.Compress,
+		now:        time.Now,
+	}
+	f, size, err := openFile(path)
+	if err != nil {
+		return nil, err
+	}
+	r.file, r.size = f, size
+	return r, nil
+}
+
+// Write writes p to the current file, rotating it first if p would take it past maxSize.
+func (r *rotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.size > 0 && r.size+int64(len(p)) > r.maxSize {
+		if err := r.rotate(); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to rotate log file: %v\n", err)
+		}
+	}
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+// Close closes the current file, once the background cleanups are done.
+func (r *rotatingFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cleanups.Wait()
+	return r.file.Close()
+}
+
+func openFile(path string) (*os.File, int64, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, 0, fmt.Errorf("open log file: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return nil, 0, fmt.Errorf("open log file: %w", err)
+	}
+	return f, info.Size(), nil
+}
+
+// rotate moves the current file aside and starts a new one. When that fails, it keeps
+// appending to the file it has rather than losing logs.
+func (r *rotatingFile) rotate() error {
+	now := r.now()
+	ext := filepath.Ext(r.path)
+	backup := strings.TrimSuffix(r.path, ext) + "-" + now.UTC().Format(_backupTimeFormat) + ext
+
+	closeErr := r.file.Close()
+	if err := os.Rename(r.path, backup); err != nil {
+		return errors.Join(closeErr, fmt.Errorf("rotate log file: %w", err), r.reopen(r.path))
+	}
+	f, size, err := openFile(r.path)
+	if err != nil {
+		return errors.Join(closeErr, err, r.reopen(backup))
+	}
+	r.file, r.size = f, size
+
+	// the new file is in place, so old backups failing to be cleaned up doesn't lose logs.
+	r.cleanups.Add(1)
+	go func() {
+		defer r.cleanups.Done()
+		if err := r.cleanup(now); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to clean up rotated log files: %v\n", err)
+		}
+	}()
+	return closeErr
+}
+
+// reopen goes back to appending to path after a failed rotation. The size starts over, so
+// the next attempt waits for another maxSize to be written instead of running on every write.
+func (r *rotatingFile) reopen(path string) error {
+	f, _, err := openFile(path)
+	if err != nil {
+		return err
+	}
+	r.file, r.size = f, 0
+	return nil
+}
+
+type backupFile struct {
+	path      string
+	timestamp time.Time
+}
+
+// cleanup removes the backups beyond maxBackups or older than maxAge, and compresses
+// the rest when enabled.
+func (r *rotatingFile) cleanup(now time.Time) error {
+	r.cleanupMu.Lock()
+	defer r.cleanupMu.Unlock()
+
+	backups, err := r.backups()
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for i, b := range backups {
+		expired := r.maxAge > 0 && now.Sub(b.timestamp) > r.maxAge
+		if (r.maxBackups > 0 && i >= r.maxBackups) || expired {
+			errs = append(errs, os.Remove(b.path))
+			continue
+		}
+		if r.compress && !strings.HasSuffix(b.path, _compressedSuffix) {
+			errs = append(errs, compressFile(b.path))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// backups lists the rotated files of path, newest first.
+func (r *rotatingFile) backups() ([]backupFile, error) {
+	dir := filepath.Dir(r.path)
+	ext := filepath.Ext(r.path)
+	prefix := strings.TrimSuffix(filepath.Base(r.path), ext) + "-"
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var backups []backupFile
+	for _, e := range entries {
+		name := e.Name()
+		if !e.Type().IsRegular() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		stamp := strings.TrimSuffix(strings.TrimSuffix(name, _compressedSuffix), ext)
+		timestamp, err := time.Parse(_backupTimeFormat, strings.TrimPrefix(stamp, prefix))
+		if err != nil {
+			continue // not one of ours.
+		}
+		backups = append(backups, backupFile{path: filepath.Join(dir, name), timestamp: timestamp})
+	}
+	slices.SortFunc(backups, func(a, b backupFile) int { return b.timestamp.Compare(a.timestamp) })
+	return backups, nil
+}
+
+// compressFile gzips path into path.gz and removes path.
+func compressFile(path string) (err error) {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(path+_compressedSuffix, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = os.Remove(dst.Name())
+		}
+	}()
+
+	gz := gzip.NewWriter(dst)
+	if _, err := io.Copy(gz, src); err != nil {
+		_ = dst.Close()
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		_ = dst.Close()
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
@@ -0,0 +1,167 @@
+package logger
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRotatingFile(t *testing.T, cfg RotationConfig, now *time.Time) (*rotatingFile, string) {
+	t.Helper()
+	dir := t.TempDir()
+	r, err := newRotatingFile(filepath.Join(dir, "yanm.log"), cfg)
+	require.NoError(t, err)
+	r.maxSize = 10 // bytes, to rotate quickly.
+	r.now = func() time.Time { return *now }
+	t.Cleanup(func() { _ = r.Close() })
+	return r, dir
+}
+
+func dirNames(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	slices.Sort(names)
+	return names
+}
+
+func TestRotatingFile_RotatesBySize(t *testing.T) {
+	now := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	r, dir := newTestRotatingFile(t, RotationConfig{MaxSizeMB: 1}, &now)
+
+	_, err := r.Write([]byte("first-1\n"))
+	require.NoError(t, err)
+	_, err = r.Write([]byte("second\n"))
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"yanm-2025-01-02T03-04-05.000.log", "yanm.log"}, dirNames(t, dir))
+	data, err := os.ReadFile(filepath.Join(dir, "yanm-2025-01-02T03-04-05.000.log"))
+	require.NoError(t, err)
+	assert.Equal(t, "first-1\n", string(data))
+	data, err = os.ReadFile(filepath.Join(dir, "yanm.log"))
+	require.NoError(t, err)
+	assert.Equal(t, "second\n", string(data))
+}
+
+func TestRotatingFile_RenameFails(t *testing.T) {
+	now := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	r, dir := newTestRotatingFile(t, RotationConfig{MaxSizeMB: 1}, &now)
+	// a directory in the way of the backup fails the rename, even when running as root.
+	backup := filepath.Join(dir, "yanm-2025-01-02T03-04-05.000.log")
+	require.NoError(t, os.MkdirAll(filepath.Join(backup, "blocked"), 0o755))
+
+	_, err := r.Write([]byte("first-1\n"))
+	require.NoError(t, err)
+	_, err = r.Write([]byte("second\n"))
+	require.NoError(t, err, "writes carry on when the rotation fails")
+
+	data, err := os.ReadFile(filepath.Join(dir, "yanm.log"))
+	require.NoError(t, err)
+	assert.Equal(t, "first-1\nsecond\n", string(data))
+
+	require.NoError(t, os.RemoveAll(backup))
+	_, err = r.Write([]byte("third-3\n"))
+	require.NoError(t, err)
+	r.cleanups.Wait()
+
+	data, err = os.ReadFile(backup)
+	require.NoError(t, err)
+	assert.Equal(t, "first-1\nsecond\n", string(data), "rotates once the rename succeeds")
+	data, err = os.ReadFile(filepath.Join(dir, "yanm.log"))
+	require.NoError(t, err)
+	assert.Equal(t, "third-3\n", string(data))
+}
+
+func TestRotatingFile_AppendsToExistingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "yanm.log")
+	require.NoError(t, os.WriteFile(path, []byte("0123456789"), 0o644))
+
+	r, err := newRotatingFile(path, RotationConfig{MaxSizeMB: 1})
+	require.NoError(t, err)
+	defer r.Close()
+	assert.Equal(t, int64(10), r.size, "the existing size counts towards the limit")
+}
+
+func TestRotatingFile_MaxBackups(t *testing.T) {
+	now := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	r, dir := newTestRotatingFile(t, RotationConfig{MaxSizeMB: 1, MaxBackups: 2}, &now)
+
+	for i := range 4 {
+		now = now.Add(time.Second)
+		_, err := r.Write([]byte(strings.Repeat("x", 8) + "\n"))
+		require.NoError(t, err, "write %d", i)
+	}
+
+	r.cleanups.Wait()
+	assert.Equal(t, []string{
+		"yanm-2025-01-02T03-04-08.000.log",
+		"yanm-2025-01-02T03-04-09.000.log",
+		"yanm.log",
+	}, dirNames(t, dir))
+}
+
+func TestRotatingFile_MaxAge(t *testing.T) {
+	now := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	r, dir := newTestRotatingFile(t, RotationConfig{MaxSizeMB: 1, MaxAgeDays: 1}, &now)
+
+	_, err := r.Write([]byte("old-log\n"))
+	require.NoError(t, err)
+	_, err = r.Write([]byte("new-log\n")) // rotates out old-log.
+	require.NoError(t, err)
+
+	now = now.Add(36 * time.Hour)
+	_, err = r.Write([]byte("latest!\n")) // rotates out new-log, old-log has expired.
+	require.NoError(t, err)
+
+	r.cleanups.Wait()
+	assert.Equal(t, []string{"yanm-2025-01-03T15-04-05.000.log", "yanm.log"}, dirNames(t, dir))
+}
+
+func TestRotatingFile_Compress(t *testing.T) {
+	now := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	r, dir := newTestRotatingFile(t, RotationConfig{MaxSizeMB: 1, Compress: true}, &now)
+
+	_, err := r.Write([]byte("first-1\n"))
+	require.NoError(t, err)
+	_, err = r.Write([]byte("second\n"))
+	require.NoError(t, err)
+
+	r.cleanups.Wait()
+	assert.Equal(t, []string{"yanm-2025-01-02T03-04-05.000.log.gz", "yanm.log"}, dirNames(t, dir))
+
+	f, err := os.Open(filepath.Join(dir, "yanm-2025-01-02T03-04-05.000.log.gz"))
+	require.NoError(t, err)
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	require.NoError(t, err)
+	data, err := io.ReadAll(gz)
+	require.NoError(t, err)
+	assert.Equal(t, "first-1\n", string(data))
+}
+
+func TestRotatingFile_IgnoresOtherFiles(t *testing.T) {
+	now := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	r, dir := newTestRotatingFile(t, RotationConfig{MaxSizeMB: 1, MaxBackups: 1}, &now)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "yanm-notes.log"), nil, 0o644))
+
+	for range 3 {
+		now = now.Add(time.Second)
+		_, err := r.Write([]byte("12345678\n"))
+		require.NoError(t, err)
+	}
+
+	r.cleanups.Wait()
+	assert.Contains(t, dirNames(t, dir), "yanm-notes.log")
+}
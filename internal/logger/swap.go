@@ -0,0 +1,98 @@
+package logger
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"slices"
+	"sync/atomic"
+
+	"go.uber.org/multierr"
+)
+
+// swapHandler sends records to a handler chain that Controller.Reconfigure replaces,
+// so the loggers already handed out write to the new outputs. The attributes and groups
+// added with WithAttrs and WithGroup are applied to whichever chain is current.
+type swapHandler struct {
+	root *atomic.Pointer[slog.Handler]
+	// with re-applies the WithAttrs and WithGroup calls to a chain, in order.
+	with []func(slog.Handler) slog.Handler
+	// derived caches the result of with for the current chain.
+	derived *atomic.Pointer[derivedHandler]
+}
+
+type derivedHandler struct {
+	root    *slog.Handler
+	handler slog.Handler
+}
+
+func newSwapHandler(handler slog.Handler) *swapHandler {
+	h := &swapHandler{root: new(atomic.Pointer[slog.Handler]), derived: new(atomic.Pointer[derivedHandler])}
+	h.root.Store(&handler)
+	return h
+}
+
+// swap makes handler the chain of h and of every handler derived from it.
+func (h *swapHandler) swap(handler slog.Handler) {
+	h.root.Store(&handler)
+}
+
+func (h *swapHandler) current() slog.Handler {
+	root := h.root.Load()
+	if len(h.with) == 0 {
+		return *root
+	}
+	if d := h.derived.Load(); d != nil && d.root == root {
+		return d.handler
+	}
+	handler := *root
+	for _, with := range h.with {
+		handler = with(handler)
+	}
+	h.derived.Store(&derivedHandler{root: root, handler: handler})
+	return handler
+}
+
+func (h *swapHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.current().Enabled(ctx, level)
+}
+
+func (h *swapHandler) Handle(ctx context.Context, r slog.Record) error {
+	return h.current().Handle(ctx, r)
+}
+
+func (h *swapHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return h.derive(func(next slog.Handler) slog.Handler { return next.WithAttrs(attrs) })
+}
+
+func (h *swapHandler) WithGroup(name string) slog.Handler {
+	return h.derive(func(next slog.Handler) slog.Handler { return next.WithGroup(name) })
+}
+
+func (h *swapHandler) derive(with func(slog.Handler) slog.Handler) *swapHandler {
+	return &swapHandler{
+		root:    h.root,
+		with:    append(slices.Clip(h.with), with),
+		derived: new(atomic.Pointer[derivedHandler]),
+	}
+}
+
+// closers release what a handler chain opened, such as files, connections and
+// exporters, once it is replaced.
+type closers []func(ctx context.Context) error
+
+// add closes c with the others, if it is an io.Closer.
+func (c *closers) add(v any) {
+	if closer, ok := v.(io.Closer); ok {
+		*c = append(*c, func(context.Context) error { return closer.Close() })
+	}
+}
+
+// close closes everything, in the reverse order it was opened.
+func (c closers) close(ctx context.Context) error {
+	var errs error
+	for i := len(c) - 1; i >= 0; i-- {
+		errs = multierr.Append(errs, c[i](ctx))
+	}
+	return errs
+}
@@ -0,0 +1,84 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"sync"
+)
+
+const (
+	_outputSyslog = "syslog"
+
+	_defaultSyslogFacility = "daemon"
+	_defaultSyslogTag      = "yanm"
+)
+
+// _syslogFacilities maps facility names to their RFC 5424 codes.
+var _syslogFacilities = map[string]int{
+	"kern": 0, "user": 1, "mail": 2, "daemon": 3, "auth": 4, "syslog": 5, "lpr": 6, "news": 7,
+	"uucp": 8, "cron": 9, "authpriv": 10, "ftp": 11,
+	"local0": 16, "local1": 17, "local2": 18, "local3": 19,
+	"local4": 20, "local5": 21, "local6": 22, "local7": 23,
+}
+
+// ValidSyslogFacility reports whether name is a syslog facility, e.g. daemon or local0.
+func ValidSyslogFacility(name string) bool {
+	_, ok := _syslogFacilities[name]
+	return ok
+}
+
+// syslogSender sends a formatted record to syslog at the severity matching level.
+type syslogSender interface {
+	send(level slog.Level, msg string) error
+}
+
+// syslogHandler formats records with the configured json or text handler, leaving the
+// time to syslog, and sends each one as a message at the matching severity.
+type syslogHandler struct {
+	inner  slog.Handler
+	sender syslogSender
+
+	mu  *sync.Mutex
+	buf *bytes.Buffer // written by inner, shared by the handlers derived from it.
+}
+
+func newSyslogHandler(sender syslogSender, format string, opts *slog.HandlerOptions) *syslogHandler {
+	buf := new(bytes.Buffer)
+	withoutTime := *opts
+	withoutTime.ReplaceAttr = func(groups []string, a slog.Attr) slog.Attr {
+		if len(groups) == 0 && a.Key == slog.TimeKey {
+			return slog.Attr{}
+		}
+		return a
+	}
+
+	var inner slog.Handler = slog.NewJSONHandler(buf, &withoutTime)
+	if format == "text" {
+		inner = slog.NewTextHandler(buf, &withoutTime)
+	}
+	return &syslogHandler{inner: inner, sender: sender, mu: new(sync.Mutex), buf: buf}
+}
+
+func (h *syslogHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.inner.Enabled(ctx, level)
+}
+
+func (h *syslogHandler) Handle(ctx context.Context, r slog.Record) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.buf.Reset()
+	if err := h.inner.Handle(ctx, r); err != nil {
+		return err
+	}
+	return h.sender.send(r.Level, string(bytes.TrimSuffix(h.buf.Bytes(), []byte("\n"))))
+}
+
+func (h *syslogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &syslogHandler{inner: h.inner.WithAttrs(attrs), sender: h.sender, mu: h.mu, buf: h.buf}
+}
+
+func (h *syslogHandler) WithGroup(name string) slog.Handler {
+	return &syslogHandler{inner: h.inner.WithGroup(name), sender: h.sender, mu: h.mu, buf: h.buf}
+}
@@ -0,0 +1,12 @@
+//go:build windows || plan9
+
+package logger
+
+import (
+	"errors"
+)
+
+// dialSyslog fails, as syslog is not available on this platform.
+func dialSyslog(SyslogConfig) (syslogSender, error) {
+	return nil, errors.New("syslog output is not supported on this platform")
+}
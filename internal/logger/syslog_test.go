@@ -0,0 +1,54 @@
+package logger
+
+import (
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type sentMessage struct {
+	level slog.Level
+	msg   string
+}
+
+type fakeSyslog struct {
+	sent []sentMessage
+}
+
+func (f *fakeSyslog) send(level slog.Level, msg string) error {
+	f.sent = append(f.sent, sentMessage{level: level, msg: msg})
+	return nil
+}
+
+func TestSyslogHandler(t *testing.T) {
+	sender := &fakeSyslog{}
+	logger := slog.New(newSyslogHandler(sender, "text", &slog.HandlerOptions{Level: slog.LevelInfo}))
+
+	logger.Debug("not sent")
+	logger.With("component", "monitor").Warn("Ping failed", "error", "timeout")
+	logger.WithGroup("result").Info("Speed test", "download_mbps", 100)
+
+	require.Len(t, sender.sent, 2)
+	assert.Equal(t, sentMessage{level: slog.LevelWarn, msg: `level=WARN msg="Ping failed" component=monitor error=timeout`}, sender.sent[0])
+	assert.Equal(t, sentMessage{level: slog.LevelInfo, msg: `level=INFO msg="Speed test" result.download_mbps=100`}, sender.sent[1])
+}
+
+func TestSyslogHandler_JSON(t *testing.T) {
+	sender := &fakeSyslog{}
+	logger := slog.New(newSyslogHandler(sender, "json", &slog.HandlerOptions{}))
+
+	logger.Error("Storage write failed", "backend", "influxdb")
+
+	require.Len(t, sender.sent, 1)
+	assert.Equal(t, slog.LevelError, sender.sent[0].level)
+	assert.JSONEq(t, `{"level":"ERROR","msg":"Storage write failed","backend":"influxdb"}`, sender.sent[0].msg)
+}
+
+func TestValidSyslogFacility(t *testing.T) {
+	assert.True(t, ValidSyslogFacility("daemon"))
+	assert.True(t, ValidSyslogFacility("local7"))
+	assert.False(t, ValidSyslogFacility("local8"))
+	assert.False(t, ValidSyslogFacility(""))
+}
@@ -0,0 +1,42 @@
+//go:build !windows && !plan9
+
+package logger
+
+import (
+	"cmp"
+	"fmt"
+	"log/slog"
+	"log/syslog"
+)
+
+type syslogWriter struct {
+	w *syslog.Writer
+}
+
+// dialSyslog connects to the syslog daemon described by cfg.
+func dialSyslog(cfg SyslogConfig) (syslogSender, error) {
+	facility := cmp.Or(cfg.Facility, _defaultSyslogFacility)
+	priority := syslog.Priority(_syslogFacilities[facility]<<3) | syslog.LOG_INFO
+	w, err := syslog.Dial(cfg.Network, cfg.Address, priority, cmp.Or(cfg.Tag, _defaultSyslogTag))
+	if err != nil {
+		return nil, fmt.Errorf("connect to syslog: %w", err)
+	}
+	return &syslogWriter{w: w}, nil
+}
+
+func (s *syslogWriter) send(level slog.Level, msg string) error {
+	switch {
+	case level >= slog.LevelError:
+		return s.w.Err(msg)
+	case level >= slog.LevelWarn:
+		return s.w.Warning(msg)
+	case level >= slog.LevelInfo:
+		return s.w.Info(msg)
+	default:
+		return s.w.Debug(msg)
+	}
+}
+
+func (s *syslogWriter) Close() error {
+	return s.w.Close()
+}
@@ -0,0 +1,39 @@
+//go:build !windows && !plan9
+
+package logger
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew_Syslog(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	logger, _, err := New(Config{
+		Level:  "info",
+		Format: "text",
+		Output: "syslog",
+		Syslog: SyslogConfig{Network: "udp", Address: conn.LocalAddr().String(), Facility: "local3"},
+	})
+	require.NoError(t, err)
+	logger.Error("Ping failed", "error", "timeout")
+
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	buf := make([]byte, 1024)
+	n, _, err := conn.ReadFrom(buf)
+	require.NoError(t, err)
+
+	msg := string(buf[:n])
+	// local3 (19) * 8 + err (3)
+	assert.True(t, strings.HasPrefix(msg, "<155>"), msg)
+	assert.Contains(t, msg, " yanm[")
+	assert.Contains(t, msg, `level=ERROR msg="Ping failed" error=timeout`)
+}
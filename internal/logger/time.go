@@ -0,0 +1,59 @@
+package logger
+
+import (
+	"log/slog"
+	"strings"
+	"time"
+	// time zones work on images without zoneinfo, such as alpine.
+	_ "time/tzdata"
+)
+
+// _timeFormats are the names accepted for a time format besides Go layouts.
+var _timeFormats = map[string]string{
+	"rfc3339":     time.RFC3339,
+	"rfc3339nano": time.RFC3339Nano,
+	"datetime":    time.DateTime,
+}
+
+// timeLayout returns the Go layout for a time format name or layout.
+func timeLayout(format string) string {
+	if layout, ok := _timeFormats[strings.ToLower(format)]; ok {
+		return layout
+	}
+	return format
+}
+
+// ValidTimeFormat reports whether format is a known time format name, such as rfc3339,
+// or a Go layout such as "2006-01-02 15:04:05". A layout without any of the reference
+// time's elements, e.g. "YYYY-MM-DD", is not valid as it would print itself.
+func ValidTimeFormat(format string) bool {
+	layout := timeLayout(format)
+	return time.Unix(0, 0).UTC().Format(layout) != layout
+}
+
+// replaceTime returns a ReplaceAttr function writing record times in zone, as a string
+// in format if set, or nil if neither is set.
+func replaceTime(format, zone string) (func([]string, slog.Attr) slog.Attr, error) {
+	if format == "" && zone == "" {
+		return nil, nil
+	}
+	loc := time.Local
+	if zone != "" {
+		var err error
+		if loc, err = time.LoadLocation(zone); err != nil {
+			return nil, err
+		}
+	}
+	layout := timeLayout(format)
+
+	return func(groups []string, a slog.Attr) slog.Attr {
+		if len(groups) > 0 || a.Key != slog.TimeKey || a.Value.Kind() != slog.KindTime {
+			return a
+		}
+		t := a.Value.Time().In(loc)
+		if layout == "" {
+			return slog.Time(a.Key, t)
+		}
+		return slog.String(a.Key, t.Format(layout))
+	}, nil
+}
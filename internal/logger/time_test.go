@@ -0,0 +1,79 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidTimeFormat(t *testing.T) {
+	for _, format := range []string{"rfc3339", "RFC3339Nano", "datetime", "Jan _2 15:04:05", time.Kitchen} {
+		assert.True(t, ValidTimeFormat(format), format)
+	}
+	for _, format := range []string{"YYYY-MM-DD", "iso"} {
+		assert.False(t, ValidTimeFormat(format), format)
+	}
+}
+
+func TestReplaceTime(t *testing.T) {
+	when := time.Date(2025, 1, 2, 10, 4, 5, 0, time.UTC)
+	tests := []struct {
+		format, zone string
+		want         string
+	}{
+		{"", "America/Denver", "2025-01-02T03:04:05-07:00"},
+		{"datetime", "America/Denver", "2025-01-02 03:04:05"},
+		{"Jan _2 15:04:05", "UTC", "Jan  2 10:04:05"},
+		{"rfc3339", "Asia/Kolkata", "2025-01-02T15:34:05+05:30"},
+	}
+	for _, tt := range tests {
+		replace, err := replaceTime(tt.format, tt.zone)
+		require.NoError(t, err)
+
+		var buf bytes.Buffer
+		handler := slog.NewJSONHandler(&buf, &slog.HandlerOptions{ReplaceAttr: replace})
+		r := slog.NewRecord(when, slog.LevelInfo, "message", 0)
+		r.AddAttrs(slog.Group("result", slog.Time(slog.TimeKey, when)))
+		require.NoError(t, handler.Handle(t.Context(), r))
+
+		var entry struct {
+			Time   string `json:"time"`
+			Result struct {
+				Time time.Time `json:"time"`
+			} `json:"result"`
+		}
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+		assert.Equal(t, tt.want, entry.Time, tt.format)
+		assert.True(t, when.Equal(entry.Result.Time), "only the entry time is replaced")
+	}
+
+	replace, err := replaceTime("", "")
+	require.NoError(t, err)
+	assert.Nil(t, replace)
+
+	_, err = replaceTime("", "Mars/Olympus_Mons")
+	require.Error(t, err)
+}
+
+func TestNew_AddSource(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "yanm.log")
+	logger, _, err := New(Config{Level: "info", Format: "text", OutputFile: path, AddSource: true, TimeFormat: "datetime", TimeZone: "UTC"})
+	require.NoError(t, err)
+	logger.Info("located")
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "source=")
+	assert.Contains(t, string(data), "time_test.go")
+	assert.Regexp(t, `^time="\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2}"`, string(data))
+
+	_, ok := recordSource(slog.NewRecord(time.Now(), slog.LevelInfo, "no caller", 0))
+	assert.False(t, ok)
+}
@@ -0,0 +1,104 @@
+package monitor
+
+import (
+	"context"
+	"time"
+
+	"yanm/internal/network"
+)
+
+// DataBudget caps the data the speed tests transfer in a month, for metered links.
+type DataBudget struct {
+	// MonthlyBytes is the data the speed tests may transfer each month, zero for no cap.
+	MonthlyBytes int64 `json:"monthly_bytes"`
+	// ResetDay is the day of the month the usage starts over on, 1 to 28.
+	ResetDay int `json:"reset_day"`
+	// ReducePercent of the budget, once used, reduces the speed tests when the speed tester
+	// supports it. Zero never reduces them.
+	ReducePercent float64 `json:"reduce_percent"`
+}
+
+// Enabled reports whether the budget caps the speed tests.
+func (b DataBudget) Enabled() bool {
+	return b.MonthlyBytes > 0
+}
+
+// periodStart returns the start of the budget month t is in.
+func (b DataBudget) periodStart(t time.Time) time.Time {
+	start := time.Date(t.Year(), t.Month(), max(b.ResetDay, 1), 0, 0, 0, 0, t.Location())
+	if start.After(t) {
+		start = start.AddDate(0, -1, 0)
+	}
+	return start
+}
+
+// DataUsage is the data transferred by the speed tests in the current budget month.
+type DataUsage struct {
+	Budget DataBudget `json:"budget"`
+	// Since is the start of the budget month.
+	Since     time.Time `json:"since"`
+	UsedBytes int64     `json:"used_bytes"`
+}
+
+// UsedPercent returns the percentage of the budget used, zero without a budget.
+func (u DataUsage) UsedPercent() float64 {
+	if !u.Budget.Enabled() {
+		return 0
+	}
+	return 100 * float64(u.UsedBytes) / float64(u.Budget.MonthlyBytes)
+}
+
+// SetDataBudget changes the monthly data budget of the speed tests, keeping the usage of
+// the current budget month unless the reset day moved.
+func (m *Network) SetDataBudget(budget DataBudget) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.usage.Budget = budget
+	m.metrics.dataBudget.Set(float64(budget.MonthlyBytes))
+	m.rollUsage()
+}
+
+// DataUsage returns the data transferred by the speed tests in the current budget month.
+func (m *Network) DataUsage() DataUsage {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.rollUsage()
+	return m.usage
+}
+
+// speedTest runs a speed test of client within the data budget. It returns false without
+// running one when the budget is used up, and runs a reduced one once the reduce
+// percentage of the budget is used.
+func (m *Network) speedTest(ctx context.Context, client network.SpeedTester) (*network.PerformanceResult, bool, error) {
+	usage := m.DataUsage()
+	budget := usage.Budget
+	if budget.Enabled() && usage.UsedBytes >= budget.MonthlyBytes {
+		return nil, false, nil
+	}
+	if reduced, ok := client.(network.ReducedSpeedTester); ok &&
+		budget.Enabled() && budget.ReducePercent > 0 && usage.UsedPercent() >= budget.ReducePercent {
+		m.logger.InfoContext(ctx, "Running a reduced speed test to save data", "usedPercent", usage.UsedPercent())
+		result, err := reduced.PerformReducedSpeedTest(ctx)
+		return result, true, err
+	}
+	result, err := client.PerformSpeedTest(ctx)
+	return result, true, err
+}
+
+// addUsage counts the data transferred by a speed test against the budget.
+func (m *Network) addUsage(result *network.PerformanceResult) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.rollUsage()
+	m.usage.UsedBytes += result.DownloadBytes + result.UploadBytes
+	m.metrics.dataUsed.Set(float64(m.usage.UsedBytes))
+}
+
+// rollUsage starts the usage over when a new budget month started, m.mu must be held.
+func (m *Network) rollUsage() {
+	if since := m.usage.Budget.periodStart(m.clock.Now()); !since.Equal(m.usage.Since) {
+		m.usage.Since = since
+		m.usage.UsedBytes = 0
+		m.metrics.dataUsed.Set(0)
+	}
+}
@@ -0,0 +1,80 @@
+package monitor
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"yanm/internal/network"
+	"yanm/internal/network/networkmock"
+	"yanm/internal/storage/storagemock"
+
+	"github.com/benbjohnson/clock"
+	"github.com/golang/mock/gomock"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+// reducedSpeedTester is a speed tester able to run reduced speed tests.
+type reducedSpeedTester struct {
+	*networkmock.MockSpeedTester
+	*networkmock.MockReducedSpeedTester
+}
+
+func TestDataBudget_PeriodStart(t *testing.T) {
+	budget := DataBudget{ResetDay: 15}
+	assert.Equal(t, time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC),
+		budget.periodStart(time.Date(2024, 3, 20, 12, 0, 0, 0, time.UTC)))
+	assert.Equal(t, time.Date(2024, 2, 15, 0, 0, 0, 0, time.UTC),
+		budget.periodStart(time.Date(2024, 3, 14, 12, 0, 0, 0, time.UTC)))
+	assert.Equal(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		DataBudget{}.periodStart(time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)))
+}
+
+func TestNetwork_DataBudget(t *testing.T) {
+	logs := bytes.Buffer{}
+	logger := slog.New(slog.NewTextHandler(&logs, nil))
+
+	mockCtrl := gomock.NewController(t)
+	storageMock := storagemock.NewMockMetricsStorage(mockCtrl)
+	client := reducedSpeedTester{networkmock.NewMockSpeedTester(mockCtrl), networkmock.NewMockReducedSpeedTester(mockCtrl)}
+	m := NewNetwork(logger, storageMock, client)
+	mockClock := clock.NewMock()
+	mockClock.Set(time.Date(2024, 3, 20, 12, 0, 0, 0, time.UTC))
+	m.clock = mockClock
+	m.SetDataBudget(DataBudget{MonthlyBytes: 1000, ResetDay: 1, ReducePercent: 50})
+
+	storageMock.EXPECT().StoreNetworkPerformance(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(),
+		gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(3)
+
+	// a full speed test until half the budget is used, then reduced ones until it is used up.
+	client.MockSpeedTester.EXPECT().PerformSpeedTest(gomock.Any()).
+		Return(&network.PerformanceResult{DownloadBytes: 400, UploadBytes: 200}, nil)
+	m.performNetworkCheck(context.Background())
+	assert.Equal(t, int64(600), m.DataUsage().UsedBytes)
+	assert.Equal(t, 600.0, testutil.ToFloat64(m.metrics.dataUsed))
+	assert.Equal(t, 1000.0, testutil.ToFloat64(m.metrics.dataBudget))
+
+	client.MockReducedSpeedTester.EXPECT().PerformReducedSpeedTest(gomock.Any()).
+		Return(&network.PerformanceResult{DownloadBytes: 300, UploadBytes: 100}, nil)
+	m.performNetworkCheck(context.Background())
+	assert.Equal(t, 100.0, m.DataUsage().UsedPercent())
+	assert.Contains(t, logs.String(), `msg="Running a reduced speed test to save data" usedPercent=60`)
+
+	m.performNetworkCheck(context.Background())
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.metrics.budgetSkips))
+
+	// the usage starts over on the reset day.
+	mockClock.Set(time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC))
+	assert.Equal(t, DataUsage{
+		Budget:    DataBudget{MonthlyBytes: 1000, ResetDay: 1, ReducePercent: 50},
+		Since:     time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC),
+		UsedBytes: 0,
+	}, m.DataUsage())
+	client.MockSpeedTester.EXPECT().PerformSpeedTest(gomock.Any()).
+		Return(&network.PerformanceResult{DownloadBytes: 400, UploadBytes: 200}, nil)
+	m.performNetworkCheck(context.Background())
+	assert.Equal(t, int64(600), m.DataUsage().UsedBytes)
+}
@@ -1,75 +1,105 @@
 package monitor
 
 import (
-	"encoding/json"
 	"html/template"
 	"net/http"
-	"strings"
+	"time"
+
+	"yanm/internal/debughttp"
+	"yanm/internal/debughttp/debughandler"
 )
 
 type monitorPage struct {
 	monitor *Network
+	view    http.Handler
 }
 
 const _monitorPage = `
 <h1>Monitor Debug</h1>
+{{ with .Labels }}
+<p>Result labels: {{ range $name, $value := . }}<code>{{ $name }}="{{ $value }}"</code> {{ end }}</p>
+{{ end }}
 <div>
 	<h2>Current Limiter States</h2>
 	<p>Ping Limiter: {{ .PingLimiter }}</p>
 	<p>Network Limiter: {{ .NetworkLimiter }}</p>
 </div>
+<div>
+	<h2>Data Usage</h2>
+	{{ with .DataUsage }}
+	<p>
+		Speed tests transferred {{ printf "%.1f" (megabytes .UsedBytes) }} MB since {{ .Since.Format "Jan 2" }}
+		{{- if .Budget.Enabled }} of the {{ printf "%.0f" (megabytes .Budget.MonthlyBytes) }} MB monthly budget ({{ printf "%.1f" .UsedPercent }}%){{ end }}.
+	</p>
+	{{ end }}
+</div>
 <form action="/debug/monitor/" method="post">
 	<button name="action" value="pause-ping">Pause Ping</button>
 	<button name="action" value="resume-ping">Resume Ping</button>
 	<button name="action" value="pause-network">Pause Network</button>
 	<button name="action" value="resume-network">Resume Network</button>
 </form>
+<div>
+	<h2>Live Events</h2>
+	<ul id="monitor-events"></ul>
+</div>
+<script>
+	(function () {
+		const list = document.getElementById("monitor-events");
+		const source = new EventSource("/debug/events/");
+		for (const type of ["ping", "speedtest", "target", "check_failed", "triggered", "paused", "resumed"]) {
+			source.addEventListener(type, function (msg) {
+				const e = JSON.parse(msg.data);
+				const item = document.createElement("li");
+				item.textContent = e.time + " " + e.type + (e.check ? " " + e.check : "") + (e.error ? ": " + e.error : "");
+				list.prepend(item);
+				while (list.children.length > 20) {
+					list.lastChild.remove();
+				}
+			});
+		}
+	})();
+</script>
 `
 
-var _monitorPageTemplate = template.Must(template.New("monitor").Parse(_monitorPage))
+var _monitorPageTemplate = template.Must(template.New("monitor").Funcs(template.FuncMap{
+	"megabytes": func(bytes int64) float64 { return float64(bytes) / 1e6 },
+}).Parse(_monitorPage))
+
+// monitorState is the data behind both views of the monitor page.
+type monitorState struct {
+	PingLimiter    string    `json:"ping_limiter"`
+	NetworkLimiter string    `json:"network_limiter"`
+	Started        time.Time `json:"started"`
+	DataUsage      DataUsage `json:"data_usage"`
+	// Labels are the labels attached to every result.
+	Labels map[string]string `json:"labels,omitempty"`
+}
 
 // NewMonitorDebugPageProvider creates a new debug page provider for the application configuration.
 // The handler returned is the raw content-producing handler.
 func NewMonitorDebugPageProvider(monitor *Network) http.Handler {
-	return &monitorPage{
+	p := &monitorPage{
 		monitor: monitor,
 	}
+	p.view = debughandler.NewNegotiatingHandler(p.state, _monitorPageTemplate)
+	return p
+}
+
+func (p *monitorPage) state(*http.Request) (any, error) {
+	return monitorState{
+		PingLimiter:    p.monitor.pingLimiter.Status(),
+		NetworkLimiter: p.monitor.networkLimiter.Status(),
+		Started:        p.monitor.Started(),
+		DataUsage:      p.monitor.DataUsage(),
+		Labels:         p.monitor.labels,
+	}, nil
 }
 
 func (p *monitorPage) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	switch r.Method {
 	case http.MethodGet:
-		accept := r.Header.Get("Accept")
-		if strings.Contains(accept, "text/html") {
-			// Serve HTML
-			w.Header().Set("Content-Type", "text/html")
-			page := struct {
-				PingLimiter    string
-				NetworkLimiter string
-			}{
-				PingLimiter:    p.monitor.pingLimiter.Status(),
-				NetworkLimiter: p.monitor.networkLimiter.Status(),
-			}
-			if err := _monitorPageTemplate.Execute(w, page); err != nil {
-				http.Error(w, "Failed to execute template", http.StatusInternalServerError)
-				return
-			}
-		} else {
-			// Serve JSON
-			w.Header().Set("Content-Type", "application/json")
-			// Calculate limiter states first
-			state := struct {
-				PingLimiter    string `json:"ping_limiter"`
-				NetworkLimiter string `json:"network_limiter"`
-			}{
-				PingLimiter:    p.monitor.pingLimiter.Status(),
-				NetworkLimiter: p.monitor.networkLimiter.Status(),
-			}
-			if err := json.NewEncoder(w).Encode(state); err != nil {
-				http.Error(w, "Failed to encode JSON", http.StatusInternalServerError)
-				return
-			}
-		}
+		p.view.ServeHTTP(w, r)
 
 	case http.MethodPost:
 		action := r.FormValue("action")
@@ -98,3 +128,29 @@ func (p *monitorPage) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 	}
 }
+
+var _ debughttp.PageProvider = (*Network)(nil)
+
+// DebugRoutes returns the monitor control page and the events stream routes.
+func (m *Network) DebugRoutes() []debughttp.DebugRoute {
+	return []debughttp.DebugRoute{
+		{
+			Path:        "/debug/monitor",
+			Name:        "Monitor",
+			Description: "Controls the monitor service.",
+			Handler:     debughandler.NewHTMLProducingHandler(NewMonitorDebugPageProvider(m)),
+			Group:       "Monitor",
+			Order:       20,
+			AutoRefresh: true,
+		},
+		{
+			Path:        "/debug/events",
+			Name:        "Events",
+			Description: "Streams monitor events and results as Server-Sent Events.",
+			Handler:     NewEventsHandler(m),
+			Visibility:  debughttp.NavExclude,
+			Group:       "Monitor",
+			Order:       30,
+		},
+	}
+}
@@ -0,0 +1,90 @@
+package monitor
+
+import (
+	"sync"
+	"time"
+
+	"yanm/internal/network"
+)
+
+// Event types published by the monitor.
+const (
+	EventPing        = "ping"
+	EventSpeedTest   = "speedtest"
+	EventTarget      = "target"
+	EventCheckFailed = "check_failed"
+	EventTriggered   = "triggered"
+	EventPaused      = "paused"
+	EventResumed     = "resumed"
+)
+
+// _eventBuffer is how many events a subscriber may fall behind before events are dropped for it.
+const _eventBuffer = 16
+
+// Event describes a check result or a change in the monitor's state.
+type Event struct {
+	Type string    `json:"type"`
+	Time time.Time `json:"time"`
+	// Check is the check the event relates to: ping, speedtest or a target name.
+	Check     string                     `json:"check,omitempty"`
+	Ping      *network.PingResult        `json:"ping,omitempty"`
+	SpeedTest *network.PerformanceResult `json:"speedtest,omitempty"`
+	Error     string                     `json:"error,omitempty"`
+	// Link is the uplink the monitor measures, empty for the default route.
+	Link string `json:"link,omitempty"`
+	// Backend is the speed test backend of a speed test event, when comparing backends.
+	Backend string `json:"backend,omitempty"`
+	// Labels are the labels configured for every result.
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// eventHub fans events out to every subscriber. Publishing never blocks the checks,
+// subscribers that fall behind miss events instead.
+type eventHub struct {
+	mu   sync.Mutex
+	subs map[chan Event]struct{}
+}
+
+func (h *eventHub) subscribe() (<-chan Event, func()) {
+	ch := make(chan Event, _eventBuffer)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.subs == nil {
+		h.subs = make(map[chan Event]struct{})
+	}
+	h.subs[ch] = struct{}{}
+
+	return ch, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		if _, ok := h.subs[ch]; ok {
+			delete(h.subs, ch)
+			close(ch)
+		}
+	}
+}
+
+func (h *eventHub) publish(e Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.subs {
+		select {
+		case ch <- e:
+		default:
+		}
+	}
+}
+
+// Subscribe returns a channel receiving the monitor's events, and a function to stop
+// receiving them which closes the channel.
+func (m *Network) Subscribe() (<-chan Event, func()) {
+	return m.events.subscribe()
+}
+
+func (m *Network) publish(e Event) {
+	e.Time = m.clock.Now()
+	e.Link = m.link
+	e.Labels = m.labels
+	m.events.publish(e)
+}
@@ -0,0 +1,66 @@
+package monitor
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// _sseKeepAlive is how often a comment is sent on idle streams, so proxies keep them open.
+const _sseKeepAlive = 15 * time.Second
+
+type eventsHandler struct {
+	monitor *Network
+}
+
+// NewEventsHandler returns a handler streaming the monitor's events as Server-Sent Events,
+// for clients that cannot use WebSockets. Each event is named after its type and its data
+// is the JSON encoded Event.
+func NewEventsHandler(monitor *Network) http.Handler {
+	return &eventsHandler{monitor: monitor}
+}
+
+func (h *eventsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	// the stream is expected to outlive the server's write timeout.
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Time{})
+
+	events, unsubscribe := h.monitor.Subscribe()
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(_sseKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+		case e := <-events:
+			data, err := json.Marshal(e)
+			if err != nil {
+				h.monitor.logger.ErrorContext(r.Context(), "Failed to encode event", "error", err)
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", e.Type, data); err != nil {
+				return
+			}
+		}
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,73 @@
+package monitor
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"yanm/internal/network"
+	"yanm/internal/network/networkmock"
+	"yanm/internal/storage/storagemock"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNetwork_Subscribe(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil))
+
+	mockCtrl := gomock.NewController(t)
+	m := NewNetwork(logger, storagemock.NewMockMetricsStorage(mockCtrl), networkmock.NewMockSpeedTester(mockCtrl))
+
+	events, unsubscribe := m.Subscribe()
+	m.PausePing()
+	m.triggerNetwork(context.Background(), "")
+
+	assert.Equal(t, EventPaused, (<-events).Type)
+	e := <-events
+	assert.Equal(t, EventTriggered, e.Type)
+	assert.Equal(t, _checkSpeedTest, e.Check)
+
+	unsubscribe()
+	_, ok := <-events
+	assert.False(t, ok, "channel is closed once unsubscribed")
+	m.ResumePing() // publishing without subscribers does not block.
+}
+
+func TestEventsHandler(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil))
+
+	mockCtrl := gomock.NewController(t)
+	storageMock := storagemock.NewMockMetricsStorage(mockCtrl)
+	networkMock := networkmock.NewMockSpeedTester(mockCtrl)
+	m := NewNetwork(logger, storageMock, networkMock)
+
+	srv := httptest.NewServer(NewEventsHandler(m))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	// the subscription exists once the headers are flushed.
+	networkMock.EXPECT().PerformPingTest(gomock.Any()).Return(&network.PingResult{TargetName: "server", Latency: time.Millisecond}, nil)
+	storageMock.EXPECT().StorePingResult(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+	_, err = m.performPingCheck(context.Background())
+	require.NoError(t, err)
+
+	reader := bufio.NewReader(resp.Body)
+	line, err := reader.ReadString('\n')
+	require.NoError(t, err)
+	assert.Equal(t, "event: ping\n", line)
+	line, err = reader.ReadString('\n')
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(line, `data: {"type":"ping"`), line)
+	assert.Contains(t, line, `"TargetName":"server"`)
+}
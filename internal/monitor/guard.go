@@ -0,0 +1,52 @@
+package monitor
+
+import (
+	"sync"
+
+	"yanm/internal/network"
+)
+
+// speedTestFlight is a speed test in flight, whose outcome is set before done is closed.
+type speedTestFlight struct {
+	done   chan struct{}
+	result *network.PerformanceResult
+	err    error
+}
+
+// wait returns the outcome of the speed test once it is done.
+func (f *speedTestFlight) wait() (*network.PerformanceResult, error) {
+	<-f.done
+	return f.result, f.err
+}
+
+// speedTestGuard tracks the speed test in flight, run one at a time by runNetwork, so the
+// triggers made meanwhile coalesce onto it, sharing its outcome, rather than queuing
+// another: two speed tests in a row would measure the same link state twice.
+type speedTestGuard struct {
+	mu     sync.Mutex
+	flight *speedTestFlight
+}
+
+// do runs test as the speed test in flight, returning its outcome.
+func (g *speedTestGuard) do(test func() (*network.PerformanceResult, error)) (*network.PerformanceResult, error) {
+	f := &speedTestFlight{done: make(chan struct{})}
+	g.mu.Lock()
+	g.flight = f
+	g.mu.Unlock()
+
+	defer func() {
+		g.mu.Lock()
+		g.flight = nil
+		g.mu.Unlock()
+		close(f.done)
+	}()
+	f.result, f.err = test()
+	return f.result, f.err
+}
+
+// inFlight returns the speed test in flight, nil when none is.
+func (g *speedTestGuard) inFlight() *speedTestFlight {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.flight
+}
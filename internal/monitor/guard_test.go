@@ -0,0 +1,88 @@
+package monitor
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+	"yanm/internal/network"
+	"yanm/internal/network/networkmock"
+	"yanm/internal/storage/storagemock"
+
+	"github.com/golang/mock/gomock"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSpeedTestGuard(t *testing.T) {
+	var g speedTestGuard
+	assert.Nil(t, g.inFlight())
+
+	started, release := make(chan struct{}), make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		result, err := g.do(func() (*network.PerformanceResult, error) {
+			close(started)
+			<-release
+			return &network.PerformanceResult{DownloadSpeedMbps: 500}, nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 500.0, result.DownloadSpeedMbps)
+	}()
+	<-started
+
+	// a trigger made meanwhile waits for the speed test in flight and shares its result.
+	flight := g.inFlight()
+	require.NotNil(t, flight)
+	close(release)
+	result, err := flight.wait()
+	require.NoError(t, err)
+	assert.Equal(t, 500.0, result.DownloadSpeedMbps)
+	<-done
+	assert.Nil(t, g.inFlight())
+}
+
+func TestNetwork_TriggerCoalesced(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil))
+
+	mockCtrl := gomock.NewController(t)
+	storageMock := storagemock.NewMockMetricsStorage(mockCtrl)
+	networkMock := networkmock.NewMockSpeedTester(mockCtrl)
+	m := NewNetwork(logger, storageMock, networkMock)
+
+	started, release := make(chan struct{}), make(chan struct{})
+	networkMock.EXPECT().PerformSpeedTest(gomock.Any()).DoAndReturn(func(context.Context) (*network.PerformanceResult, error) {
+		close(started)
+		<-release
+		return &network.PerformanceResult{DownloadSpeedMbps: 500}, nil
+	})
+	storageMock.EXPECT().StoreNetworkPerformance(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(),
+		gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, _ = m.performNetworkCheck(context.Background())
+	}()
+	<-started
+
+	// triggers made meanwhile join the speed test in flight rather than queuing another.
+	assert.True(t, m.triggerNetwork(context.Background(), ""))
+	job, ok := m.QueueSpeedTest(context.Background())
+	require.True(t, ok)
+	assert.Equal(t, JobRunning, job.Status)
+	assert.Empty(t, m.triggerNetworkCheck)
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.metrics.coalescedRuns.WithLabelValues(_triggerLatency)))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.metrics.coalescedRuns.WithLabelValues(_triggerManual)))
+
+	close(release)
+	<-done
+	require.Eventually(t, func() bool {
+		job, _ := m.Job(job.ID)
+		return job.Status == JobDone
+	}, time.Second, time.Millisecond)
+	job, _ = m.Job(job.ID)
+	assert.Equal(t, 500.0, job.SpeedTest.DownloadSpeedMbps)
+}
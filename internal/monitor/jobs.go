@@ -0,0 +1,131 @@
+package monitor
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"yanm/internal/logctx"
+	"yanm/internal/network"
+)
+
+// Job statuses of a speed test triggered on request.
+const (
+	JobQueued  = "queued"
+	JobRunning = "running"
+	JobDone    = "done"
+	JobFailed  = "failed"
+)
+
+// _maxJobs is how many jobs are remembered, the oldest are forgotten first.
+const _maxJobs = 32
+
+var (
+	errRateLimited  = errors.New("skipped by the speed test rate limit")
+	errBudgetUsedUp = errors.New("skipped, the monthly data budget is used up")
+)
+
+// Job is a speed test triggered on request, which runs once the speed test ahead of it is
+// done. Its result can be looked up by ID afterwards.
+type Job struct {
+	ID     string    `json:"id"`
+	Status string    `json:"status"`
+	Queued time.Time `json:"queued"`
+	// Finished is when the job was done or failed, zero until then.
+	Finished  time.Time                  `json:"finished,omitzero"`
+	SpeedTest *network.PerformanceResult `json:"speedtest,omitempty"`
+	Error     string                     `json:"error,omitempty"`
+}
+
+// jobs remembers the most recent jobs.
+type jobs struct {
+	mu   sync.Mutex
+	jobs []Job
+}
+
+func (j *jobs) add(job Job) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if len(j.jobs) == _maxJobs {
+		j.jobs = j.jobs[1:]
+	}
+	j.jobs = append(j.jobs, job)
+}
+
+func (j *jobs) remove(id string) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	for i := range j.jobs {
+		if j.jobs[i].ID == id {
+			j.jobs = append(j.jobs[:i], j.jobs[i+1:]...)
+			return
+		}
+	}
+}
+
+func (j *jobs) get(id string) (Job, bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	for _, job := range j.jobs {
+		if job.ID == id {
+			return job, true
+		}
+	}
+	return Job{}, false
+}
+
+// update applies f to the job with id, ignoring jobs already forgotten and an empty id,
+// that of a speed test triggered by high latency.
+func (j *jobs) update(id string, f func(*Job)) {
+	if id == "" {
+		return
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	for i := range j.jobs {
+		if j.jobs[i].ID == id {
+			f(&j.jobs[i])
+			return
+		}
+	}
+}
+
+func (j *jobs) start(id string) {
+	j.update(id, func(job *Job) { job.Status = JobRunning })
+}
+
+func (j *jobs) finish(id string, ts time.Time, result *network.PerformanceResult, err error) {
+	j.update(id, func(job *Job) {
+		job.Finished = ts
+		if err != nil {
+			job.Status = JobFailed
+			job.Error = err.Error()
+			return
+		}
+		job.Status = JobDone
+		job.SpeedTest = result
+	})
+}
+
+// QueueSpeedTest queues a speed test like TriggerSpeedTest, returning its job. It returns
+// false when a speed test is already queued.
+func (m *Network) QueueSpeedTest(ctx context.Context) (Job, bool) {
+	job := Job{ID: logctx.NewID(), Status: JobQueued, Queued: m.clock.Now()}
+	// added first, the speed test may start before triggerNetwork returns.
+	m.jobs.add(job)
+	if !m.triggerNetwork(ctx, job.ID) {
+		m.jobs.remove(job.ID)
+		return Job{}, false
+	}
+	// running already when coalesced onto the speed test in flight.
+	if current, ok := m.jobs.get(job.ID); ok {
+		job = current
+	}
+	return job, true
+}
+
+// Job returns the speed test job with id, false once it is forgotten or if it never existed.
+func (m *Network) Job(id string) (Job, bool) {
+	return m.jobs.get(id)
+}
@@ -0,0 +1,113 @@
+package monitor
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+	"yanm/internal/network"
+	"yanm/internal/network/networkmock"
+	"yanm/internal/storage/storagemock"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNetwork_QueueSpeedTest(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil))
+
+	mockCtrl := gomock.NewController(t)
+	storageMock := storagemock.NewMockMetricsStorage(mockCtrl)
+	networkMock := networkmock.NewMockSpeedTester(mockCtrl)
+	m := NewNetwork(logger, storageMock, networkMock, WithNetworkInterval(time.Hour))
+
+	job, ok := m.QueueSpeedTest(context.Background())
+	require.True(t, ok)
+	assert.Equal(t, JobQueued, job.Status)
+	// only one speed test is queued at a time.
+	_, ok = m.QueueSpeedTest(context.Background())
+	assert.False(t, ok)
+	assert.False(t, m.TriggerSpeedTest(context.Background()))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	networkMock.EXPECT().PerformSpeedTest(gomock.Any()).Return(&network.PerformanceResult{DownloadSpeedMbps: 500}, nil)
+	storageMock.EXPECT().StoreNetworkPerformance(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(),
+		gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(context.Context, time.Time, float64, float64, int64, float64, float64, int64, int64, string, string, string) error {
+			running, _ := m.Job(job.ID)
+			assert.Equal(t, JobRunning, running.Status)
+			return nil
+		})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		m.runNetwork(ctx)
+	}()
+	require.Eventually(t, func() bool {
+		job, _ := m.Job(job.ID)
+		return job.Status == JobDone
+	}, time.Second, time.Millisecond)
+	cancel()
+	<-done
+
+	job, ok = m.Job(job.ID)
+	require.True(t, ok)
+	assert.False(t, job.Finished.IsZero())
+	assert.Equal(t, 500.0, job.SpeedTest.DownloadSpeedMbps)
+
+	// a job the rate limiter skips fails.
+	job, ok = m.QueueSpeedTest(context.Background())
+	require.True(t, ok)
+	ctx, cancel = context.WithCancel(context.Background())
+	done = make(chan struct{})
+	go func() {
+		defer close(done)
+		m.runNetwork(ctx)
+	}()
+	require.Eventually(t, func() bool {
+		job, _ := m.Job(job.ID)
+		return job.Status == JobFailed
+	}, time.Second, time.Millisecond)
+	cancel()
+	<-done
+	job, _ = m.Job(job.ID)
+	assert.Equal(t, errRateLimited.Error(), job.Error)
+
+	_, ok = m.Job("unknown")
+	assert.False(t, ok)
+}
+
+func TestNetwork_RunPing(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil))
+
+	mockCtrl := gomock.NewController(t)
+	storageMock := storagemock.NewMockMetricsStorage(mockCtrl)
+	networkMock := networkmock.NewMockSpeedTester(mockCtrl)
+	m := NewNetwork(logger, storageMock, networkMock, WithLink("lte"))
+	m.PausePing()
+
+	// the ping runs even when the pings are paused, stored as measured over the link.
+	networkMock.EXPECT().PerformPingTest(gomock.Any()).Return(&network.PingResult{TargetName: "server", Latency: time.Millisecond}, nil)
+	storageMock.EXPECT().StorePingResult(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), "server", gomock.Any(), gomock.Any()).Return(nil)
+	result, err := m.RunPing(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, time.Millisecond, result.Latency)
+
+	networkMock.EXPECT().PerformPingTest(gomock.Any()).Return(nil, errors.New("timeout"))
+	_, err = m.RunPing(context.Background())
+	assert.EqualError(t, err, "timeout")
+}
+
+func TestJobs_Forget(t *testing.T) {
+	var j jobs
+	for i := range _maxJobs + 1 {
+		j.add(Job{ID: string(rune('a' + i))})
+	}
+	_, ok := j.get("a")
+	assert.False(t, ok)
+	_, ok = j.get(string(rune('a' + _maxJobs)))
+	assert.True(t, ok)
+}
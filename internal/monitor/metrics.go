@@ -0,0 +1,228 @@
+package monitor
+
+import (
+	"cmp"
+	"expvar"
+	"slices"
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	_checkPing      = "ping"
+	_checkSpeedTest = "speedtest"
+	_checkTarget    = "target"
+
+	_resultSuccess = "success"
+	_resultFailure = "failure"
+
+	_triggerScheduled = "scheduled"
+	_triggerLatency   = "latency"
+	// _triggerManual is a speed test triggered on request, over gRPC or the API.
+	_triggerManual = "manual"
+
+	_triggerQueued  = "queued"
+	_triggerDropped = "dropped"
+)
+
+// _vars publishes the counters below as the "monitor" expvar, served on /debug/vars for
+// scripting without Prometheus. expvars are process wide, so every monitor adds to them.
+var _vars = struct {
+	checks         *expvar.Map // keyed by check then result, e.g. "ping_failure"
+	limiterAllowed *expvar.Map // keyed by trigger
+	limiterSkips   *expvar.Map // keyed by trigger
+	triggers       *expvar.Map // keyed by trigger then result, e.g. "latency_dropped"
+	coalesced      *expvar.Map // keyed by trigger
+	storageErrors  *expvar.Map // keyed by check
+	panics         *expvar.Map // keyed by check loop
+}{
+	checks:         new(expvar.Map).Init(),
+	limiterAllowed: new(expvar.Map).Init(),
+	limiterSkips:   new(expvar.Map).Init(),
+	triggers:       new(expvar.Map).Init(),
+	coalesced:      new(expvar.Map).Init(),
+	storageErrors:  new(expvar.Map).Init(),
+	panics:         new(expvar.Map).Init(),
+}
+
+func init() {
+	monitorVars := expvar.NewMap("monitor")
+	monitorVars.Set("checks", _vars.checks)
+	monitorVars.Set("limiter_allowed", _vars.limiterAllowed)
+	monitorVars.Set("limiter_skips", _vars.limiterSkips)
+	monitorVars.Set("speedtest_triggers", _vars.triggers)
+	monitorVars.Set("speedtest_coalesced", _vars.coalesced)
+	monitorVars.Set("storage_write_errors", _vars.storageErrors)
+	monitorVars.Set("panics", _vars.panics)
+}
+
+// metrics are the monitor's self-metrics, describing how YANM itself is behaving
+// rather than the network it measures. Go runtime stats (goroutines, heap, GC)
+// come from the collectors already present on the default registry.
+type metrics struct {
+	checks         *prometheus.CounterVec
+	checkDuration  *prometheus.HistogramVec
+	limiterAllowed *prometheus.CounterVec
+	limiterSkips   *prometheus.CounterVec
+	limiterTokens  []prometheus.GaugeFunc
+	percentiles    []prometheus.GaugeFunc
+	triggers       *prometheus.CounterVec
+	coalescedRuns  *prometheus.CounterVec
+	storageErrors  *prometheus.CounterVec
+	panics         *prometheus.CounterVec
+	dataUsed       prometheus.Gauge
+	dataBudget     prometheus.Gauge
+	budgetSkips    prometheus.Counter
+}
+
+// newMetrics creates the metrics named under namespace, yanm when empty, reading the
+// tokens available to the ping and speed test limiters, by check, from tokens and the ping
+// latency percentiles from percentile when collected.
+func newMetrics(namespace string, tokens func(check string) float64, percentile func(q float64) float64) *metrics {
+	namespace = cmp.Or(namespace, "yanm")
+	limiterTokens := func(check string) prometheus.GaugeFunc {
+		return prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace:   namespace,
+			Subsystem:   "limiter",
+			Name:        "tokens",
+			Help:        "Checks the rate limiter would let run right away, partitioned by limiter: ping or speedtest.",
+			ConstLabels: prometheus.Labels{"limiter": check},
+		}, func() float64 { return tokens(check) })
+	}
+	percentiles := make([]prometheus.GaugeFunc, 0, len(_percentiles))
+	for _, q := range _percentiles {
+		percentiles = append(percentiles, prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace:   namespace,
+			Subsystem:   "ping",
+			Name:        "latency_percentile_ms",
+			Help:        "Ping latency percentile over the sliding window of network.ping_test.percentile_window_minutes, NaN without pings in the window.",
+			ConstLabels: prometheus.Labels{"quantile": strconv.FormatFloat(q, 'f', -1, 64)},
+		}, func() float64 { return percentile(q) }))
+	}
+	return &metrics{
+		checks: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "checks_total",
+			Help:      "Number of checks attempted, partitioned by check and result.",
+		}, []string{"check", "result"}),
+		checkDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "check_duration_seconds",
+			Help:      "Time taken to run a check, including failed attempts.",
+			Buckets:   []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 20, 30, 60, 120},
+		}, []string{"check"}),
+		limiterAllowed: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "limiter_allowed_total",
+			Help:      "Number of speed tests the rate limiter let run, partitioned by trigger: scheduled, latency or manual.",
+		}, []string{"trigger"}),
+		limiterSkips: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "limiter_skips_total",
+			Help:      "Number of speed tests skipped because the rate limiter was active.",
+		}, []string{"trigger"}),
+		limiterTokens: []prometheus.GaugeFunc{limiterTokens(_checkPing), limiterTokens(_checkSpeedTest)},
+		percentiles:   percentiles,
+		triggers: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "speedtest_triggers_total",
+			Help:      "Number of speed tests triggered outside the schedule, partitioned by trigger and result: queued, or dropped as one was already queued.",
+		}, []string{"trigger", "result"}),
+		coalescedRuns: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "speedtest_coalesced_total",
+			Help:      "Number of speed tests requested while one was running, which shared its result rather than running, partitioned by trigger.",
+		}, []string{"trigger"}),
+		storageErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "storage_write_errors_total",
+			Help:      "Number of results that failed to be written to storage.",
+		}, []string{"check"}),
+		panics: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "check_loop_panics_total",
+			Help:      "Number of check loops restarted after a panic, partitioned by loop: ping, speedtest or a target name.",
+		}, []string{"loop"}),
+		dataUsed: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "data_budget",
+			Name:      "used_bytes",
+			Help:      "Data transferred by the speed tests since the start of the budget month.",
+		}),
+		dataBudget: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "data_budget",
+			Name:      "bytes",
+			Help:      "Data the speed tests may transfer each month, zero without a budget.",
+		}),
+		budgetSkips: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "data_budget",
+			Name:      "skips_total",
+			Help:      "Number of speed tests skipped because the monthly data budget was used up.",
+		}),
+	}
+}
+
+// register adds the metrics to reg, a nil registerer leaves them unexported.
+func (m *metrics) register(reg prometheus.Registerer) error {
+	if reg == nil {
+		return nil
+	}
+
+	collectors := []prometheus.Collector{
+		m.checks, m.checkDuration, m.limiterAllowed, m.limiterSkips, m.triggers, m.coalescedRuns, m.storageErrors, m.panics, m.dataUsed, m.dataBudget, m.budgetSkips,
+	}
+	for _, c := range slices.Concat(m.limiterTokens, m.percentiles) {
+		collectors = append(collectors, c)
+	}
+	for _, c := range collectors {
+		if err := reg.Register(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checked counts a check attempt with its result.
+func (m *metrics) checked(check, result string) {
+	m.checks.WithLabelValues(check, result).Inc()
+	_vars.checks.Add(check+"_"+result, 1)
+}
+
+// allowed counts a speed test the rate limiter let run.
+func (m *metrics) allowed(trigger string) {
+	m.limiterAllowed.WithLabelValues(trigger).Inc()
+	_vars.limiterAllowed.Add(trigger, 1)
+}
+
+// triggered counts a speed test triggered outside the schedule, queued or dropped.
+func (m *metrics) triggered(trigger, result string) {
+	m.triggers.WithLabelValues(trigger, result).Inc()
+	_vars.triggers.Add(trigger+"_"+result, 1)
+}
+
+// coalesced counts a speed test requested while one was running, which shared its result.
+func (m *metrics) coalesced(trigger string) {
+	m.coalescedRuns.WithLabelValues(trigger).Inc()
+	_vars.coalesced.Add(trigger, 1)
+}
+
+// skipped counts a speed test skipped by the rate limiter.
+func (m *metrics) skipped(trigger string) {
+	m.limiterSkips.WithLabelValues(trigger).Inc()
+	_vars.limiterSkips.Add(trigger, 1)
+}
+
+// storageFailed counts a result of check that failed to be written to storage.
+func (m *metrics) storageFailed(check string) {
+	m.storageErrors.WithLabelValues(check).Inc()
+	_vars.storageErrors.Add(check, 1)
+}
+
+// panicked counts a check loop restarted after a panic.
+func (m *metrics) panicked(loop string) {
+	m.panics.WithLabelValues(loop).Inc()
+	_vars.panics.Add(loop, 1)
+}
@@ -2,12 +2,16 @@ package monitor
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"sync"
+	"sync/atomic"
 	"time"
+	"yanm/internal/logctx"
 	"yanm/internal/network"
 	"yanm/internal/storage"
+	"yanm/internal/tracing"
 
 	"github.com/benbjohnson/clock"
 	"golang.org/x/time/rate"
@@ -42,12 +46,49 @@ type Network struct {
 	client  network.SpeedTester
 	logger  *slog.Logger
 
+	// mu guards the limiter settings and threshold, which can change on config reload.
+	mu                   sync.RWMutex
 	pingLimiter          trackingLimiter
 	networkLimiter       trackingLimiter
 	networkTicker        *time.Ticker
+	networkInterval      time.Duration
 	pingTriggerThreshold time.Duration
-
-	triggerNetworkCheck chan struct{}
+	storageWriteTimeout  time.Duration
+	speedTestTimeout     time.Duration
+	// cancelSpeedTest cancels the speed test in flight, nil when none is.
+	cancelSpeedTest context.CancelCauseFunc
+	started         time.Time
+	restartOnPanic  atomic.Bool
+	// usage is the data used by the speed tests in the budget month, guarded by mu.
+	usage DataUsage
+	// lastSpeedTest is when the last speed test started, as saved to stateFile, guarded by mu.
+	lastSpeedTest time.Time
+
+	// triggerNetworkCheck queues a speed test, with the ID of its job when triggered on
+	// request rather than by high latency.
+	triggerNetworkCheck chan string
+	jobs                jobs
+	speedTests          speedTestGuard
+	// latencies are the ping latencies of the percentile window.
+	latencies latencyWindow
+
+	targets []TargetCheck
+
+	// link is the uplink measured, empty for the default route.
+	link string
+	// labels are attached to every result and event, nil when none are configured.
+	labels map[string]string
+	// stateFile keeps when the last speed test ran and the data usage across restarts,
+	// unused when empty.
+	stateFile string
+	// backend names the client's backend when its speed tests are compared to those of
+	// comparison, nil otherwise.
+	backend    string
+	comparison *Backend
+
+	events eventHub
+
+	metrics *metrics
 
 	clock clock.Clock
 }
@@ -62,6 +103,9 @@ func NewNetwork(
 		pingInterval:         time.Second * 15,
 		networkInterval:      time.Minute,
 		pingTriggerThreshold: time.Second * 10,
+		storageWriteTimeout:  time.Second * 10,
+		speedTestTimeout:     time.Minute * 5,
+		percentileWindow:     time.Minute * 15,
 	}
 
 	for _, o := range opts {
@@ -84,12 +128,33 @@ func NewNetwork(
 			originalLimit: networkLimit,
 		},
 		networkTicker:        time.NewTicker(opt.networkInterval),
+		networkInterval:      opt.networkInterval,
 		pingTriggerThreshold: opt.pingTriggerThreshold,
+		storageWriteTimeout:  opt.storageWriteTimeout,
+		speedTestTimeout:     opt.speedTestTimeout,
+
+		triggerNetworkCheck: make(chan string, 1),
+
+		targets: opt.targets,
+		link:    opt.link,
+		labels:  opt.labels,
+
+		stateFile: opt.stateFile,
 
-		triggerNetworkCheck: make(chan struct{}, 1),
+		backend:    opt.backend,
+		comparison: opt.comparison,
 
 		clock: clock.New(),
 	}
+	m.metrics = newMetrics(opt.namespace, m.limiterTokens, m.latencyPercentile)
+	m.latencies.setWindow(opt.percentileWindow)
+
+	m.restartOnPanic.Store(opt.restartOnPanic)
+	m.SetDataBudget(opt.dataBudget)
+
+	if err := m.metrics.register(opt.registerer); err != nil {
+		logger.Error("Failed to register monitor metrics", "error", err)
+	}
 
 	return m
 }
@@ -98,31 +163,149 @@ func NewNetwork(
 //
 // monitoring will stop when the parentContext is done.
 func (m *Network) Monitor(ctx context.Context) {
+	ctx = m.resultContext(ctx)
+	m.mu.Lock()
+	m.started = m.clock.Now()
+	m.mu.Unlock()
 	m.run(ctx)
 }
 
+// resultContext returns ctx carrying the link, labels and backend the results are stored with.
+func (m *Network) resultContext(ctx context.Context) context.Context {
+	if m.link != "" {
+		ctx = storage.WithLink(ctx, m.link)
+	}
+	if len(m.labels) > 0 {
+		ctx = storage.WithResultLabels(ctx, m.labels)
+	}
+	// the client pings, its results are those of its backend.
+	if m.comparison != nil {
+		ctx = storage.WithBackend(ctx, m.backend)
+	}
+	return ctx
+}
+
+// PingStatus describes the ping check schedule: Paused, Unlimited or its rate and burst.
+func (m *Network) PingStatus() string {
+	return m.pingLimiter.Status()
+}
+
+// NetworkStatus describes the speed test schedule: Paused, Unlimited or its rate and burst.
+func (m *Network) NetworkStatus() string {
+	return m.networkLimiter.Status()
+}
+
+// Started returns when monitoring started, zero if it has not.
+func (m *Network) Started() time.Time {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.started
+}
+
 // PausePing pauses the ping checks.
 func (m *Network) PausePing() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	m.pingLimiter.SetLimit(rate.Limit(0))
 	m.pingLimiter.SetBurst(0)
+	m.publish(Event{Type: EventPaused, Check: _checkPing})
 }
 
 // ResumePing resumes the ping checks.
 func (m *Network) ResumePing() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	m.pingLimiter.SetLimit(m.pingLimiter.originalLimit)
 	m.pingLimiter.SetBurst(_burstPing)
+	m.publish(Event{Type: EventResumed, Check: _checkPing})
 }
 
 // PauseNetwork pauses the network checks.
 func (m *Network) PauseNetwork() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	m.networkLimiter.SetLimit(rate.Limit(0))
 	m.networkLimiter.SetBurst(0)
+	m.publish(Event{Type: EventPaused, Check: _checkSpeedTest})
 }
 
 // ResumeNetwork resumes the network checks.
 func (m *Network) ResumeNetwork() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	m.networkLimiter.SetLimit(m.networkLimiter.originalLimit)
 	m.networkLimiter.SetBurst(_burstNetwork)
+	m.publish(Event{Type: EventResumed, Check: _checkSpeedTest})
+}
+
+// SetPingInterval changes how often ping checks run. Paused checks stay paused
+// and pick up the new interval once resumed.
+func (m *Network) SetPingInterval(interval time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.pingLimiter.originalLimit = rate.Every(interval)
+	if m.pingLimiter.Limit() != 0 {
+		m.pingLimiter.SetLimit(m.pingLimiter.originalLimit)
+	}
+}
+
+// SetNetworkInterval changes how often scheduled network checks run. Paused checks
+// stay paused and pick up the new interval once resumed.
+func (m *Network) SetNetworkInterval(interval time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.networkLimiter.originalLimit = rate.Every(interval)
+	if m.networkLimiter.Limit() != 0 {
+		m.networkLimiter.SetLimit(m.networkLimiter.originalLimit)
+	}
+	m.networkInterval = interval
+	m.networkTicker.Reset(interval)
+}
+
+func (m *Network) networkIntervalValue() time.Duration {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.networkInterval
+}
+
+// SetPingTriggerThreshold changes the ping latency above which a network check is triggered.
+func (m *Network) SetPingTriggerThreshold(threshold time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.pingTriggerThreshold = threshold
+}
+
+// SetStorageWriteTimeout changes how long a single storage write may take.
+func (m *Network) SetStorageWriteTimeout(timeout time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.storageWriteTimeout = timeout
+}
+
+// SetSpeedTestTimeout changes how long a speed test may take before it is abandoned,
+// from the next speed test on.
+func (m *Network) SetSpeedTestTimeout(timeout time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.speedTestTimeout = timeout
+}
+
+// SetRestartOnPanic changes whether a check loop that panicked is restarted, rather than
+// crashing the process.
+func (m *Network) SetRestartOnPanic(restart bool) {
+	m.restartOnPanic.Store(restart)
+}
+
+func (m *Network) triggerThreshold() time.Duration {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.pingTriggerThreshold
+}
+
+func (m *Network) writeTimeout() time.Duration {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.storageWriteTimeout
 }
 
 func (m *Network) run(ctx context.Context) {
@@ -131,63 +314,26 @@ func (m *Network) run(ctx context.Context) {
 	var wg sync.WaitGroup
 
 	wg.Add(2)
-
-	// Goroutine for Ping Checks
 	go func() {
 		defer wg.Done()
-
-		for {
-			select {
-			case <-ctx.Done():
-				m.logger.InfoContext(ctx, "Ping check goroutine stopping...")
-				return
-			case <-time.Tick(time.Millisecond * 500):
-				if !m.pingLimiter.Allow() {
-					continue
-				}
-
-				m.logger.DebugContext(ctx, "Performing ping check...")
-				pingResult, err := m.performPingCheck(ctx)
-				if err != nil {
-					// TODO: trigger network check for some ping error conditions.
-					m.logger.ErrorContext(ctx, "Ping failed", "error", err)
-					continue
-				}
-
-				if pingResult != nil && pingResult.Latency > m.pingTriggerThreshold {
-					m.logger.InfoContext(ctx, "Ping latency is high", "latency", pingResult.Latency)
-					m.triggerNetwork(ctx)
-				}
-			}
-		}
+		m.supervise(ctx, _checkPing, m.runPing)
 	}()
 
-	// Goroutine for Network Checks
 	go func() {
 		defer wg.Done()
-		for {
-			select {
-			case <-ctx.Done():
-				m.logger.InfoContext(ctx, "Network check goroutine stopping...")
-				return
-			case <-m.triggerNetworkCheck:
-				m.logger.DebugContext(ctx, "TRIGGER: Performing network check due to high ping latency...")
-				if !m.networkLimiter.Allow() { // Respect the limiter even for triggered checks
-					m.logger.InfoContext(ctx, "Network check rate limit active, triggered check skipped.", "tokens", m.networkLimiter.Tokens())
-					continue
-				}
-				m.performNetworkCheck(ctx)
-			case <-m.networkTicker.C:
-				m.logger.DebugContext(ctx, "SCHEDULED: Performing network check...")
-				if !m.networkLimiter.Allow() {
-					m.logger.InfoContext(ctx, "Network check rate limit active, scheduled check skipped.", "tokens", m.networkLimiter.Tokens())
-					continue
-				}
-				m.performNetworkCheck(ctx)
-			}
-		}
+		m.supervise(ctx, _checkSpeedTest, m.runNetwork)
 	}()
 
+	for _, target := range m.targets {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			m.supervise(ctx, target.Checker.Target().Name, func(ctx context.Context) {
+				m.runTarget(ctx, target)
+			})
+		}()
+	}
+
 	m.logger.InfoContext(ctx, "Monitoring goroutines started.")
 	<-ctx.Done()
 	m.logger.InfoContext(ctx, "Shutting down monitor...")
@@ -195,57 +341,305 @@ func (m *Network) run(ctx context.Context) {
 	m.logger.InfoContext(ctx, "Monitor shut down gracefully.")
 }
 
+// runPing runs the ping checks until ctx is done.
+func (m *Network) runPing(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			m.logger.InfoContext(ctx, "Ping check goroutine stopping...")
+			return
+		case <-time.Tick(time.Millisecond * 500):
+			if !m.pingLimiter.Allow() {
+				continue
+			}
+
+			checkCtx := logctx.WithCheckID(ctx, logctx.NewID())
+			m.logger.DebugContext(checkCtx, "Performing ping check...")
+			pingResult, err := m.performPingCheck(checkCtx)
+			if err != nil {
+				// TODO: trigger network check for some ping error conditions.
+				m.logger.ErrorContext(checkCtx, "Ping failed", "error", err)
+				continue
+			}
+
+			if pingResult != nil && pingResult.Latency > m.triggerThreshold() {
+				m.logger.InfoContext(checkCtx, "Ping latency is high", "latency", pingResult.Latency)
+				m.triggerNetwork(checkCtx, "")
+			}
+		}
+	}
+}
+
+// runNetwork runs the scheduled and triggered network checks until ctx is done.
+func (m *Network) runNetwork(ctx context.Context) {
+	due := m.restoreSchedule(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			m.logger.InfoContext(ctx, "Network check goroutine stopping...")
+			return
+		case jobID := <-m.triggerNetworkCheck:
+			trigger := triggerOf(jobID)
+			m.logger.DebugContext(ctx, "TRIGGER: Performing triggered network check...", "trigger", trigger)
+			if !m.allowNetwork(ctx, trigger) { // Respect the limiter even for triggered checks
+				m.jobs.finish(jobID, m.clock.Now(), nil, errRateLimited)
+				continue
+			}
+			m.jobs.start(jobID)
+			result, err := m.performNetworkCheck(ctx)
+			m.jobs.finish(jobID, m.clock.Now(), result, err)
+		case <-due:
+			// the schedule carries on from the restored speed test.
+			due = nil
+			m.networkTicker.Reset(m.networkIntervalValue())
+			m.scheduledNetworkCheck(ctx)
+		case <-m.networkTicker.C:
+			m.scheduledNetworkCheck(ctx)
+		}
+	}
+}
+
+func (m *Network) scheduledNetworkCheck(ctx context.Context) {
+	m.logger.DebugContext(ctx, "SCHEDULED: Performing network check...")
+	if !m.allowNetwork(ctx, _triggerScheduled) {
+		return
+	}
+	m.performNetworkCheck(ctx)
+}
+
+// allowNetwork reports whether the rate limiter lets a network check run, counting the
+// decision by trigger.
+func (m *Network) allowNetwork(ctx context.Context, trigger string) bool {
+	if !m.networkLimiter.Allow() {
+		m.logger.InfoContext(ctx, "Network check rate limit active, check skipped.", "trigger", trigger, "tokens", m.networkLimiter.Tokens())
+		m.metrics.skipped(trigger)
+		return false
+	}
+	m.metrics.allowed(trigger)
+	return true
+}
+
+// limiterTokens returns the tokens available to the limiter of check, ping or speedtest.
+func (m *Network) limiterTokens(check string) float64 {
+	if check == _checkPing {
+		return m.pingLimiter.Tokens()
+	}
+	return m.networkLimiter.Tokens()
+}
+
 func (m *Network) performPingCheck(ctx context.Context) (*network.PingResult, error) {
+	start := m.clock.Now()
 	pingResult, err := m.client.PerformPingTest(ctx)
+	m.metrics.checkDuration.WithLabelValues(_checkPing).Observe(m.clock.Since(start).Seconds())
 
 	if err != nil {
+		m.metrics.checked(_checkPing, _resultFailure)
+		m.publish(Event{Type: EventCheckFailed, Check: _checkPing, Error: err.Error()})
 		m.logger.ErrorContext(ctx, "Ping failed", "error", err)
 		return nil, err
 	}
+	m.metrics.checked(_checkPing, _resultSuccess)
+	m.latencies.add(m.clock.Now(), pingResult.Latency)
+	pingResult.Labels = m.labels
+	m.publish(Event{Type: EventPing, Check: _checkPing, Ping: pingResult})
 
 	// Store ping result
+	writeCtx, cancel := context.WithTimeout(ctx, m.writeTimeout())
+	defer cancel()
 	err = m.storage.StorePingResult(
-		ctx,
+		writeCtx,
 		m.clock.Now(),
 		pingResult.Latency.Milliseconds(),
+		durationMs(pingResult.Jitter),
+		pingResult.PacketLossPercent,
 		pingResult.TargetName,
 		pingResult.Geo.Lat,
 		pingResult.Geo.Lon,
 	)
 	if err != nil {
+		m.metrics.storageFailed(_checkPing)
 		m.logger.ErrorContext(ctx, "Failed to store ping result", "error", err)
 	}
 
 	return pingResult, nil
 }
 
-func (m *Network) performNetworkCheck(ctx context.Context) {
-	speedResult, err := m.client.PerformSpeedTest(ctx)
+// performNetworkCheck runs a speed test, followed by one of the backend compared to when
+// comparing backends, so both measure the link in the same state. It returns the result of
+// the first. Triggers made while it runs coalesce onto it, sharing its result.
+func (m *Network) performNetworkCheck(ctx context.Context) (*network.PerformanceResult, error) {
+	return m.speedTests.do(func() (*network.PerformanceResult, error) {
+		m.saveSchedule(ctx)
+		result, err := m.performSpeedTest(ctx, m.client)
+		if m.comparison != nil {
+			_, _ = m.performSpeedTest(storage.WithBackend(ctx, m.comparison.Name), m.comparison.Client)
+		}
+		return result, err
+	})
+}
+
+// performSpeedTest runs a speed test of client, whose lines are logged with a run ID. When
+// tracing, the run is a trace whose ID is logged too.
+func (m *Network) performSpeedTest(ctx context.Context, client network.SpeedTester) (*network.PerformanceResult, error) {
+	runID := logctx.NewID()
+	ctx = logctx.WithRunID(ctx, runID)
+	ctx, span := tracing.Start(ctx, "speedtest", slog.String(logctx.RunIDKey, runID))
+	defer span.End()
+	if traceID := span.TraceID(); traceID != "" {
+		ctx = logctx.With(ctx, slog.String(logctx.TraceIDKey, traceID))
+	}
+	backend := storage.Backend(ctx)
+	if backend != "" {
+		ctx = logctx.With(ctx, slog.String("backend", backend))
+		span.SetAttributes(slog.String("backend", backend))
+	}
+
+	m.logger.InfoContext(ctx, "Starting speed test")
+	start := m.clock.Now()
+	testCtx, stop := m.speedTestContext(ctx)
+	speedResult, ran, err := m.speedTest(testCtx, client)
+	// a result returned past the deadline or once canceled is not trusted.
+	if cause := context.Cause(testCtx); ran && cause != nil {
+		speedResult, err = nil, cause
+	}
+	stop()
+	if !ran {
+		m.metrics.budgetSkips.Inc()
+		m.logger.InfoContext(ctx, "Monthly data budget used up, speed test skipped.")
+		return nil, errBudgetUsedUp
+	}
+	m.metrics.checkDuration.WithLabelValues(_checkSpeedTest).Observe(m.clock.Since(start).Seconds())
 	if err != nil {
+		span.SetError(err)
+		m.metrics.checked(_checkSpeedTest, _resultFailure)
+		m.publish(Event{Type: EventCheckFailed, Check: _checkSpeedTest, Error: err.Error(), Backend: backend})
 		m.logger.ErrorContext(ctx, "Speed test failed", "error", err)
-		return
+		return nil, err
 	}
+	m.addUsage(speedResult)
+	m.writeState(ctx)
+	speedResult.Labels = m.labels
+	m.metrics.checked(_checkSpeedTest, _resultSuccess)
+	m.publish(Event{Type: EventSpeedTest, Check: _checkSpeedTest, SpeedTest: speedResult, Backend: backend})
+	span.SetAttributes(
+		slog.String("server", speedResult.TargetName),
+		slog.Float64("download_mbps", speedResult.DownloadSpeedMbps),
+		slog.Float64("upload_mbps", speedResult.UploadSpeedMbps),
+		slog.Int64("ping_ms", speedResult.PingLatency.Milliseconds()),
+	)
 
 	// Store speed result
+	writeCtx, cancel := context.WithTimeout(ctx, m.writeTimeout())
+	defer cancel()
+	writeCtx, writeSpan := tracing.Start(writeCtx, "storage_write")
 	err = m.storage.StoreNetworkPerformance(
-		ctx,
+		writeCtx,
 		m.clock.Now(),
 		speedResult.DownloadSpeedMbps,
 		speedResult.UploadSpeedMbps,
 		speedResult.PingLatency.Milliseconds(),
+		durationMs(speedResult.Jitter),
+		speedResult.PacketLossPercent,
+		speedResult.DownloadBytes,
+		speedResult.UploadBytes,
 		speedResult.TargetName,
 		speedResult.Geo.Lat,
 		speedResult.Geo.Lon,
 	)
+	writeSpan.SetError(err)
+	writeSpan.End()
 	if err != nil {
+		m.metrics.storageFailed(_checkSpeedTest)
 		m.logger.ErrorContext(ctx, "Failed to store speed result", "error", err)
 	}
+	return speedResult, nil
+}
+
+var (
+	errSpeedTestDeadline = errors.New("speed test deadline exceeded")
+	errSpeedTestCanceled = errors.New("speed test canceled on request")
+)
+
+// speedTestContext returns ctx bounded by the speed test timeout, which CancelSpeedTest
+// cancels until stop is called.
+func (m *Network) speedTestContext(ctx context.Context) (_ context.Context, stop func()) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	ctx, cancelTimeout := context.WithTimeoutCause(ctx, m.speedTestTimeout,
+		fmt.Errorf("%w after %v", errSpeedTestDeadline, m.speedTestTimeout))
+	ctx, cancel := context.WithCancelCause(ctx)
+	m.cancelSpeedTest = cancel
+	return ctx, func() {
+		m.mu.Lock()
+		m.cancelSpeedTest = nil
+		m.mu.Unlock()
+		cancel(nil)
+		cancelTimeout()
+	}
+}
+
+// CancelSpeedTest cancels the speed test in flight, which fails. It returns false when no
+// speed test is running.
+func (m *Network) CancelSpeedTest(ctx context.Context) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.cancelSpeedTest == nil {
+		return false
+	}
+	m.logger.InfoContext(ctx, "Canceling the speed test in flight")
+	m.cancelSpeedTest(errSpeedTestCanceled)
+	return true
 }
 
-func (m *Network) triggerNetwork(ctx context.Context) {
+// TriggerSpeedTest queues a speed test. Like one triggered by high latency it is subject to
+// the speed test rate limit. It returns false when a speed test is already queued.
+func (m *Network) TriggerSpeedTest(ctx context.Context) bool {
+	_, ok := m.QueueSpeedTest(ctx)
+	return ok
+}
+
+// RunPing runs a ping check straight away, regardless of the ping schedule, storing and
+// publishing its result like a scheduled one.
+func (m *Network) RunPing(ctx context.Context) (*network.PingResult, error) {
+	return m.performPingCheck(logctx.WithCheckID(m.resultContext(ctx), logctx.NewID()))
+}
+
+func (m *Network) triggerNetwork(ctx context.Context, jobID string) bool {
+	// a speed test in flight answers the trigger, rather than another one right after it.
+	if flight := m.speedTests.inFlight(); flight != nil {
+		m.metrics.coalesced(triggerOf(jobID))
+		m.logger.InfoContext(ctx, "Network check trigger coalesced onto the one in flight")
+		if jobID != "" {
+			m.jobs.start(jobID)
+			go func() {
+				result, err := flight.wait()
+				m.jobs.finish(jobID, m.clock.Now(), result, err)
+			}()
+		}
+		return true
+	}
+
 	select {
-	case m.triggerNetworkCheck <- struct{}{}:
+	case m.triggerNetworkCheck <- jobID:
+		m.metrics.triggered(triggerOf(jobID), _triggerQueued)
+		m.publish(Event{Type: EventTriggered, Check: _checkSpeedTest})
+		return true
 	default:
+		m.metrics.triggered(triggerOf(jobID), _triggerDropped)
 		m.logger.InfoContext(ctx, "Network check trigger channel is full. Skipping immediate check.")
+		return false
+	}
+}
+
+// triggerOf returns what triggered the speed test of jobID: high latency has no job.
+func triggerOf(jobID string) string {
+	if jobID == "" {
+		return _triggerLatency
 	}
+	return _triggerManual
+}
+
+// durationMs converts d to fractional milliseconds.
+func durationMs(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
 }
@@ -2,13 +2,24 @@ package monitor
 
 import (
 	"bytes"
+	"context"
+	"errors"
+	"expvar"
 	"log/slog"
+	"strings"
 	"testing"
+	"time"
+	"yanm/internal/network"
 	"yanm/internal/network/networkmock"
+	"yanm/internal/storage"
 	"yanm/internal/storage/storagemock"
 
 	"github.com/golang/mock/gomock"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/time/rate"
 )
 
 // TestNewNetwork tests the creation of a Network monitor
@@ -23,3 +34,268 @@ func TestNewNetwork(t *testing.T) {
 	network := NewNetwork(logger, storageMock, networkMock)
 	require.NotNil(t, network)
 }
+
+func TestNetwork_Link(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil))
+
+	mockCtrl := gomock.NewController(t)
+	storageMock := storagemock.NewMockMetricsStorage(mockCtrl)
+	networkMock := networkmock.NewMockSpeedTester(mockCtrl)
+	m := NewNetwork(logger, storageMock, networkMock, WithLink("lte"))
+	events, unsubscribe := m.Subscribe()
+	defer unsubscribe()
+
+	// the results are stored, and published, as measured over the link.
+	ctx, cancel := context.WithCancel(context.Background())
+	networkMock.EXPECT().PerformPingTest(gomock.Any()).Return(&network.PingResult{TargetName: "server", Latency: time.Millisecond}, nil)
+	storageMock.EXPECT().StorePingResult(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, _ time.Time, _ int64, _, _ float64, _, _, _ string) error {
+			assert.Equal(t, "lte", storage.Link(ctx))
+			cancel()
+			return nil
+		})
+	m.Monitor(ctx)
+
+	e := <-events
+	assert.Equal(t, EventPing, e.Type)
+	assert.Equal(t, "lte", e.Link)
+}
+
+func TestNetwork_Labels(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil))
+
+	mockCtrl := gomock.NewController(t)
+	storageMock := storagemock.NewMockMetricsStorage(mockCtrl)
+	networkMock := networkmock.NewMockSpeedTester(mockCtrl)
+	labels := map[string]string{"host": "mybox", "site": "cabin"}
+	m := NewNetwork(logger, storageMock, networkMock, WithLabels(labels))
+	events, unsubscribe := m.Subscribe()
+	defer unsubscribe()
+
+	// the results carry the labels, to the storage and in the events.
+	ctx, cancel := context.WithCancel(context.Background())
+	networkMock.EXPECT().PerformPingTest(gomock.Any()).Return(&network.PingResult{TargetName: "server", Latency: time.Millisecond}, nil)
+	storageMock.EXPECT().StorePingResult(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, _ time.Time, _ int64, _, _ float64, _, _, _ string) error {
+			assert.Equal(t, labels, storage.ResultLabels(ctx))
+			cancel()
+			return nil
+		})
+	m.Monitor(ctx)
+
+	e := <-events
+	assert.Equal(t, EventPing, e.Type)
+	assert.Equal(t, labels, e.Labels)
+	assert.Equal(t, labels, e.Ping.Labels)
+}
+
+func TestNetwork_Comparison(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil))
+
+	mockCtrl := gomock.NewController(t)
+	storageMock := storagemock.NewMockMetricsStorage(mockCtrl)
+	networkMock := networkmock.NewMockSpeedTester(mockCtrl)
+	compareMock := networkmock.NewMockSpeedTester(mockCtrl)
+	m := NewNetwork(logger, storageMock, networkMock,
+		WithComparison(network.BackendOokla, Backend{Name: network.BackendLibreSpeed, Client: compareMock}))
+	events, unsubscribe := m.Subscribe()
+	defer unsubscribe()
+
+	// the backend compared to runs right after, each result stored with its backend.
+	var backends []string
+	gomock.InOrder(
+		networkMock.EXPECT().PerformSpeedTest(gomock.Any()).Return(&network.PerformanceResult{DownloadSpeedMbps: 500}, nil),
+		compareMock.EXPECT().PerformSpeedTest(gomock.Any()).Return(&network.PerformanceResult{DownloadSpeedMbps: 450}, nil),
+	)
+	storageMock.EXPECT().StoreNetworkPerformance(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(),
+		gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, _ time.Time, _, _ float64, _ int64, _, _ float64, _, _ int64, _, _, _ string) error {
+			backends = append(backends, storage.Backend(ctx))
+			return nil
+		}).Times(2)
+	m.performNetworkCheck(storage.WithBackend(context.Background(), network.BackendOokla))
+	assert.Equal(t, []string{network.BackendOokla, network.BackendLibreSpeed}, backends)
+
+	e := <-events
+	assert.Equal(t, network.BackendOokla, e.Backend)
+	assert.Equal(t, 500.0, e.SpeedTest.DownloadSpeedMbps)
+	e = <-events
+	assert.Equal(t, network.BackendLibreSpeed, e.Backend)
+	assert.Equal(t, 450.0, e.SpeedTest.DownloadSpeedMbps)
+
+	// and the pings of the client are those of its backend.
+	ctx, cancel := context.WithCancel(context.Background())
+	networkMock.EXPECT().PerformPingTest(gomock.Any()).Return(&network.PingResult{TargetName: "server", Latency: time.Millisecond}, nil)
+	storageMock.EXPECT().StorePingResult(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, _ time.Time, _ int64, _, _ float64, _, _, _ string) error {
+			assert.Equal(t, network.BackendOokla, storage.Backend(ctx))
+			cancel()
+			return nil
+		})
+	m.Monitor(ctx)
+}
+
+func TestNetwork_SelfMetrics(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil))
+
+	mockCtrl := gomock.NewController(t)
+	storageMock := storagemock.NewMockMetricsStorage(mockCtrl)
+	networkMock := networkmock.NewMockSpeedTester(mockCtrl)
+
+	reg := prometheus.NewRegistry()
+	m := NewNetwork(logger, storageMock, networkMock, WithRegisterer(reg))
+
+	// expvars are process wide, so compare against the values before this test.
+	varValue := func(vars *expvar.Map, key string) int64 {
+		if v, ok := vars.Get(key).(*expvar.Int); ok {
+			return v.Value()
+		}
+		return 0
+	}
+	successes := varValue(_vars.checks, "ping_success")
+	failures := varValue(_vars.checks, "ping_failure")
+	storageErrors := varValue(_vars.storageErrors, _checkPing)
+
+	networkMock.EXPECT().PerformPingTest(gomock.Any()).Return(&network.PingResult{TargetName: "server"}, nil)
+	storageMock.EXPECT().StorePingResult(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), "server", gomock.Any(), gomock.Any()).
+		Return(errors.New("write failed"))
+	_, err := m.performPingCheck(context.Background())
+	require.NoError(t, err)
+
+	networkMock.EXPECT().PerformPingTest(gomock.Any()).Return(nil, errors.New("timeout"))
+	_, err = m.performPingCheck(context.Background())
+	require.Error(t, err)
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.metrics.checks.WithLabelValues(_checkPing, _resultSuccess)))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.metrics.checks.WithLabelValues(_checkPing, _resultFailure)))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.metrics.storageErrors.WithLabelValues(_checkPing)))
+
+	count, err := testutil.GatherAndCount(reg, "yanm_checks_total")
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	assert.Equal(t, successes+1, varValue(_vars.checks, "ping_success"))
+	assert.Equal(t, failures+1, varValue(_vars.checks, "ping_failure"))
+	assert.Equal(t, storageErrors+1, varValue(_vars.storageErrors, _checkPing))
+}
+
+func TestNetwork_StorageWriteTimeout(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil))
+
+	mockCtrl := gomock.NewController(t)
+	storageMock := storagemock.NewMockMetricsStorage(mockCtrl)
+	networkMock := networkmock.NewMockSpeedTester(mockCtrl)
+
+	m := NewNetwork(logger, storageMock, networkMock, WithStorageWriteTimeout(time.Millisecond))
+
+	networkMock.EXPECT().PerformPingTest(gomock.Any()).Return(&network.PingResult{TargetName: "server"}, nil)
+	storageMock.EXPECT().StorePingResult(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, _ time.Time, _ int64, _, _ float64, _, _, _ string) error {
+			// a hung backend is released once the write deadline passes.
+			<-ctx.Done()
+			return ctx.Err()
+		})
+
+	_, err := m.performPingCheck(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.metrics.storageErrors.WithLabelValues(_checkPing)))
+}
+
+func TestNetwork_SetIntervals(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil))
+
+	mockCtrl := gomock.NewController(t)
+	m := NewNetwork(logger, storagemock.NewMockMetricsStorage(mockCtrl), networkmock.NewMockSpeedTester(mockCtrl),
+		WithPingInterval(time.Second))
+
+	m.SetPingInterval(2 * time.Second)
+	assert.Equal(t, rate.Every(2*time.Second), m.pingLimiter.Limit())
+
+	// a paused check stays paused, and resumes at the new interval.
+	m.PausePing()
+	m.SetPingInterval(4 * time.Second)
+	assert.Equal(t, "Paused", m.pingLimiter.Status())
+	m.ResumePing()
+	assert.Equal(t, rate.Every(4*time.Second), m.pingLimiter.Limit())
+
+	m.SetPingTriggerThreshold(time.Minute)
+	assert.Equal(t, time.Minute, m.triggerThreshold())
+}
+
+func TestNetwork_SchedulingMetrics(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil))
+
+	mockCtrl := gomock.NewController(t)
+	reg := prometheus.NewRegistry()
+	m := NewNetwork(logger, storagemock.NewMockMetricsStorage(mockCtrl), networkmock.NewMockSpeedTester(mockCtrl),
+		WithRegisterer(reg), WithNetworkInterval(time.Hour))
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.metrics.limiterTokens[1]))
+	assert.True(t, m.allowNetwork(context.Background(), _triggerScheduled))
+	assert.False(t, m.allowNetwork(context.Background(), _triggerLatency))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.metrics.limiterAllowed.WithLabelValues(_triggerScheduled)))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.metrics.limiterSkips.WithLabelValues(_triggerLatency)))
+	assert.InDelta(t, 0.0, testutil.ToFloat64(m.metrics.limiterTokens[1]), 0.01)
+	assert.Equal(t, float64(_burstPing), testutil.ToFloat64(m.metrics.limiterTokens[0]))
+
+	// a second trigger is dropped while the first is queued.
+	assert.True(t, m.triggerNetwork(context.Background(), ""))
+	assert.False(t, m.TriggerSpeedTest(context.Background()))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.metrics.triggers.WithLabelValues(_triggerLatency, _triggerQueued)))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.metrics.triggers.WithLabelValues(_triggerManual, _triggerDropped)))
+
+	count, err := testutil.GatherAndCount(reg, "yanm_limiter_tokens")
+	require.NoError(t, err)
+	assert.Equal(t, 2, count)
+}
+
+func TestNetwork_Namespace(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil))
+
+	mockCtrl := gomock.NewController(t)
+	reg := prometheus.NewRegistry()
+	NewNetwork(logger, storagemock.NewMockMetricsStorage(mockCtrl), networkmock.NewMockSpeedTester(mockCtrl),
+		WithRegisterer(reg), WithNamespace("home"))
+
+	families, err := reg.Gather()
+	require.NoError(t, err)
+	require.NotEmpty(t, families)
+	for _, family := range families {
+		assert.True(t, strings.HasPrefix(family.GetName(), "home_"), family.GetName())
+	}
+}
+
+func TestNetwork_SpeedTestDeadline(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil))
+
+	mockCtrl := gomock.NewController(t)
+	networkMock := networkmock.NewMockSpeedTester(mockCtrl)
+	m := NewNetwork(logger, storagemock.NewMockMetricsStorage(mockCtrl), networkMock,
+		WithSpeedTestTimeout(10*time.Millisecond))
+	assert.False(t, m.CancelSpeedTest(context.Background()))
+
+	// a result returned past the deadline is discarded, not stored.
+	networkMock.EXPECT().PerformSpeedTest(gomock.Any()).DoAndReturn(func(ctx context.Context) (*network.PerformanceResult, error) {
+		<-ctx.Done()
+		return &network.PerformanceResult{DownloadSpeedMbps: 1}, nil
+	})
+	_, err := m.performNetworkCheck(context.Background())
+	require.ErrorIs(t, err, errSpeedTestDeadline)
+	assert.EqualError(t, err, "speed test deadline exceeded after 10ms")
+
+	m.SetSpeedTestTimeout(time.Minute)
+	started := make(chan struct{})
+	networkMock.EXPECT().PerformSpeedTest(gomock.Any()).DoAndReturn(func(ctx context.Context) (*network.PerformanceResult, error) {
+		close(started)
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	go func() {
+		<-started
+		assert.True(t, m.CancelSpeedTest(context.Background()))
+	}()
+	_, err = m.performNetworkCheck(context.Background())
+	require.ErrorIs(t, err, errSpeedTestCanceled)
+	assert.False(t, m.CancelSpeedTest(context.Background()))
+	assert.Equal(t, 2.0, testutil.ToFloat64(m.metrics.checks.WithLabelValues(_checkSpeedTest, _resultFailure)))
+}
@@ -1,11 +1,30 @@
 package monitor
 
-import "time"
+import (
+	"time"
+
+	"yanm/internal/network"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
 
 type options struct {
 	pingInterval         time.Duration
 	networkInterval      time.Duration
 	pingTriggerThreshold time.Duration
+	storageWriteTimeout  time.Duration
+	speedTestTimeout     time.Duration
+	percentileWindow     time.Duration
+	registerer           prometheus.Registerer
+	namespace            string
+	targets              []TargetCheck
+	restartOnPanic       bool
+	dataBudget           DataBudget
+	link                 string
+	labels               map[string]string
+	backend              string
+	comparison           *Backend
+	stateFile            string
 }
 
 type Option interface {
@@ -47,3 +66,174 @@ func (o *pingTriggerThresholdOption) apply(opts *options) {
 func WithPingTriggerThreshold(threshold time.Duration) Option {
 	return &pingTriggerThresholdOption{threshold}
 }
+
+type registererOption struct {
+	registerer prometheus.Registerer
+}
+
+func (o *registererOption) apply(opts *options) {
+	opts.registerer = o.registerer
+}
+
+// WithRegisterer exports the monitor's self-metrics to the given registerer.
+func WithRegisterer(registerer prometheus.Registerer) Option {
+	return &registererOption{registerer}
+}
+
+type namespaceOption struct {
+	namespace string
+}
+
+func (o *namespaceOption) apply(opts *options) {
+	opts.namespace = o.namespace
+}
+
+// WithNamespace names the monitor's self-metrics under namespace rather than yanm, like
+// the metrics of the storage.
+func WithNamespace(namespace string) Option {
+	return &namespaceOption{namespace}
+}
+
+type storageWriteTimeoutOption struct {
+	timeout time.Duration
+}
+
+func (o *storageWriteTimeoutOption) apply(opts *options) {
+	opts.storageWriteTimeout = o.timeout
+}
+
+// WithStorageWriteTimeout bounds how long a single storage write may take.
+func WithStorageWriteTimeout(timeout time.Duration) Option {
+	return &storageWriteTimeoutOption{timeout}
+}
+
+type targetsOption struct {
+	targets []TargetCheck
+}
+
+func (o *targetsOption) apply(opts *options) {
+	opts.targets = append(opts.targets, o.targets...)
+}
+
+// WithTargets adds latency checks against the given targets, each run on its own interval.
+func WithTargets(targets ...TargetCheck) Option {
+	return &targetsOption{targets}
+}
+
+type restartOnPanicOption struct {
+	restart bool
+}
+
+func (o *restartOnPanicOption) apply(opts *options) {
+	opts.restartOnPanic = o.restart
+}
+
+// WithRestartOnPanic restarts a check loop that panicked instead of crashing the process,
+// the panic is logged either way.
+func WithRestartOnPanic(restart bool) Option {
+	return &restartOnPanicOption{restart}
+}
+
+type dataBudgetOption struct {
+	budget DataBudget
+}
+
+func (o *dataBudgetOption) apply(opts *options) {
+	opts.dataBudget = o.budget
+}
+
+// WithDataBudget caps the data the speed tests transfer each month.
+func WithDataBudget(budget DataBudget) Option {
+	return &dataBudgetOption{budget}
+}
+
+type linkOption struct {
+	link string
+}
+
+func (o *linkOption) apply(opts *options) {
+	opts.link = o.link
+}
+
+// WithLink labels the results and events with the uplink the client measures, for
+// multi-WAN hosts running a monitor per link.
+func WithLink(link string) Option {
+	return &linkOption{link}
+}
+
+type labelsOption struct {
+	labels map[string]string
+}
+
+func (o *labelsOption) apply(opts *options) {
+	opts.labels = o.labels
+}
+
+// WithLabels attaches labels, such as the host or site, to every result and event, so they
+// reach every storage backend.
+func WithLabels(labels map[string]string) Option {
+	return &labelsOption{labels}
+}
+
+// Backend is a speed test backend the speed tests of the monitor's client are compared to.
+type Backend struct {
+	Name   string
+	Client network.SpeedTester
+}
+
+type comparisonOption struct {
+	backend    string
+	comparison Backend
+}
+
+func (o *comparisonOption) apply(opts *options) {
+	opts.backend = o.backend
+	opts.comparison = &o.comparison
+}
+
+// WithComparison runs a speed test of comparison right after each one of the client, whose
+// backend is named backend, labelling the results of both with the name of their backend.
+func WithComparison(backend string, comparison Backend) Option {
+	return &comparisonOption{backend, comparison}
+}
+
+type stateFileOption struct {
+	path string
+}
+
+func (o *stateFileOption) apply(opts *options) {
+	opts.stateFile = o.path
+}
+
+// WithStateFile saves when the last speed test ran to path, and restores the speed test
+// schedule from it on start, so a restart neither runs one early nor skips an overdue one.
+func WithStateFile(path string) Option {
+	return &stateFileOption{path}
+}
+
+type speedTestTimeoutOption struct {
+	timeout time.Duration
+}
+
+func (o *speedTestTimeoutOption) apply(opts *options) {
+	opts.speedTestTimeout = o.timeout
+}
+
+// WithSpeedTestTimeout abandons a speed test that did not complete within timeout.
+func WithSpeedTestTimeout(timeout time.Duration) Option {
+	return &speedTestTimeoutOption{timeout}
+}
+
+type percentileWindowOption struct {
+	window time.Duration
+}
+
+func (o *percentileWindowOption) apply(opts *options) {
+	opts.percentileWindow = o.window
+}
+
+// WithPercentileWindow computes the ping latency percentile gauges over the pings of the
+// last window, 15 minutes by default.
+func WithPercentileWindow(window time.Duration) Option {
+	return &percentileWindowOption{window}
+}
@@ -0,0 +1,76 @@
+package monitor
+
+import (
+	"math"
+	"slices"
+	"sync"
+	"time"
+)
+
+// _percentiles are the ping latency percentiles exported as gauges.
+var _percentiles = []float64{0.5, 0.95, 0.99}
+
+type latencySample struct {
+	time      time.Time
+	latencyMs float64
+}
+
+// latencyWindow keeps the ping latencies of a sliding window, oldest first, for dashboards
+// that cannot compute percentiles from the histograms.
+type latencyWindow struct {
+	mu      sync.Mutex
+	window  time.Duration
+	samples []latencySample
+}
+
+func (w *latencyWindow) setWindow(window time.Duration) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.window = window
+}
+
+func (w *latencyWindow) add(ts time.Time, latency time.Duration) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.samples = append(w.samples, latencySample{time: ts, latencyMs: durationMs(latency)})
+	w.prune(ts)
+}
+
+// percentile returns the nearest-rank q percentile of the latencies in the window ending
+// at now, NaN without any.
+func (w *latencyWindow) percentile(now time.Time, q float64) float64 {
+	w.mu.Lock()
+	w.prune(now)
+	latencies := make([]float64, len(w.samples))
+	for i, sample := range w.samples {
+		latencies[i] = sample.latencyMs
+	}
+	w.mu.Unlock()
+
+	if len(latencies) == 0 {
+		return math.NaN()
+	}
+	slices.Sort(latencies)
+	return latencies[max(int(math.Ceil(q*float64(len(latencies))))-1, 0)]
+}
+
+// prune forgets the samples older than the window, w.mu must be held.
+func (w *latencyWindow) prune(now time.Time) {
+	oldest := now.Add(-w.window)
+	i := 0
+	for i < len(w.samples) && w.samples[i].time.Before(oldest) {
+		i++
+	}
+	w.samples = w.samples[i:]
+}
+
+// SetPercentileWindow changes the sliding window the ping latency percentiles are
+// computed over.
+func (m *Network) SetPercentileWindow(window time.Duration) {
+	m.latencies.setWindow(window)
+}
+
+// latencyPercentile returns the q percentile of the ping latencies in the window.
+func (m *Network) latencyPercentile(q float64) float64 {
+	return m.latencies.percentile(m.clock.Now(), q)
+}
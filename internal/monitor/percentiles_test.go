@@ -0,0 +1,76 @@
+package monitor
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"math"
+	"strings"
+	"testing"
+	"time"
+	"yanm/internal/network"
+	"yanm/internal/network/networkmock"
+	"yanm/internal/storage/storagemock"
+
+	"github.com/benbjohnson/clock"
+	"github.com/golang/mock/gomock"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLatencyWindow(t *testing.T) {
+	now := time.Date(2025, 3, 14, 21, 30, 0, 0, time.UTC)
+	w := latencyWindow{window: 10 * time.Minute}
+	assert.True(t, math.IsNaN(w.percentile(now, 0.5)))
+
+	// older than the window.
+	w.add(now.Add(-11*time.Minute), time.Second)
+	for i := 100; i >= 1; i-- {
+		w.add(now.Add(-time.Duration(i)*time.Second), time.Duration(i)*time.Millisecond)
+	}
+	assert.Equal(t, 50.0, w.percentile(now, 0.5))
+	assert.Equal(t, 95.0, w.percentile(now, 0.95))
+	assert.Equal(t, 99.0, w.percentile(now, 0.99))
+	assert.Equal(t, 1.0, w.percentile(now, 0))
+
+	// a shorter window drops the older pings.
+	w.setWindow(50 * time.Second)
+	assert.Equal(t, 50.0, w.percentile(now, 0.99))
+	assert.True(t, math.IsNaN(w.percentile(now.Add(time.Hour), 0.5)))
+}
+
+func TestNetwork_LatencyPercentiles(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil))
+
+	mockCtrl := gomock.NewController(t)
+	storageMock := storagemock.NewMockMetricsStorage(mockCtrl)
+	networkMock := networkmock.NewMockSpeedTester(mockCtrl)
+	reg := prometheus.NewRegistry()
+	m := NewNetwork(logger, storageMock, networkMock, WithRegisterer(reg), WithPercentileWindow(time.Minute))
+	mockClock := clock.NewMock()
+	m.clock = mockClock
+
+	storageMock.EXPECT().StorePingResult(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(nil).AnyTimes()
+	for _, latency := range []time.Duration{10, 20, 30, 40} {
+		networkMock.EXPECT().PerformPingTest(gomock.Any()).Return(&network.PingResult{TargetName: "server", Latency: latency * time.Millisecond}, nil)
+		_, err := m.performPingCheck(context.Background())
+		require.NoError(t, err)
+	}
+	// failed pings have no latency.
+	networkMock.EXPECT().PerformPingTest(gomock.Any()).Return(nil, assert.AnError)
+	_, _ = m.performPingCheck(context.Background())
+
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+# HELP yanm_ping_latency_percentile_ms Ping latency percentile over the sliding window of network.ping_test.percentile_window_minutes, NaN without pings in the window.
+# TYPE yanm_ping_latency_percentile_ms gauge
+yanm_ping_latency_percentile_ms{quantile="0.5"} 20
+yanm_ping_latency_percentile_ms{quantile="0.95"} 40
+yanm_ping_latency_percentile_ms{quantile="0.99"} 40
+`), "yanm_ping_latency_percentile_ms"))
+
+	mockClock.Add(2 * time.Minute)
+	assert.True(t, math.IsNaN(testutil.ToFloat64(m.metrics.percentiles[0])))
+}
@@ -0,0 +1,128 @@
+package monitor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// schedulingState is the part of the speed test schedule and data usage kept across restarts.
+type schedulingState struct {
+	// LastSpeedTest is when the last speed test started.
+	LastSpeedTest time.Time `json:"last_speed_test"`
+	// UsageSince is the start of the budget month UsedBytes was counted in.
+	UsageSince time.Time `json:"usage_since"`
+	// UsedBytes is the data the speed tests transferred in that month.
+	UsedBytes int64 `json:"used_bytes"`
+}
+
+// loadState reads the state saved to path, the zero state when there is none yet.
+func loadState(path string) (schedulingState, error) {
+	var state schedulingState
+	b, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return state, err
+	}
+	if err := json.Unmarshal(b, &state); err != nil {
+		return state, fmt.Errorf("invalid state file %s: %w", path, err)
+	}
+	return state, nil
+}
+
+// saveState writes state to path, through a temporary file renamed over it so a crash
+// never leaves it half written.
+func saveState(path string, state schedulingState) error {
+	b, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// restoreSchedule restores the speed test schedule and the data usage from the state file.
+// It returns a channel receiving when the first scheduled speed test is due, an interval
+// after the last one, right away when it is overdue. It returns nil without a saved state,
+// leaving the first one an interval after the start.
+func (m *Network) restoreSchedule(ctx context.Context) <-chan time.Time {
+	if m.stateFile == "" {
+		return nil
+	}
+	state, err := loadState(m.stateFile)
+	if err != nil {
+		m.logger.WarnContext(ctx, "Failed to restore the speed test schedule", "stateFile", m.stateFile, "error", err)
+		return nil
+	}
+	m.restoreUsage(ctx, state)
+	if state.LastSpeedTest.IsZero() {
+		return nil
+	}
+	m.mu.Lock()
+	m.lastSpeedTest = state.LastSpeedTest
+	m.mu.Unlock()
+
+	// the limiter is as it was after the last speed test, so triggers are limited as before.
+	m.networkLimiter.AllowN(state.LastSpeedTest, 1)
+	interval := m.networkIntervalValue()
+	// a last speed test in the future, e.g. after the clock was set back, waits an interval.
+	wait := min(max(state.LastSpeedTest.Add(interval).Sub(m.clock.Now()), 0), interval)
+	m.logger.InfoContext(ctx, "Restored the speed test schedule", "lastSpeedTest", state.LastSpeedTest, "nextIn", wait)
+	return m.clock.After(wait)
+}
+
+// restoreUsage restores the data usage saved in state, unless a new budget month has
+// started since.
+func (m *Network) restoreUsage(ctx context.Context, state schedulingState) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.rollUsage()
+	if state.UsedBytes == 0 || !state.UsageSince.Equal(m.usage.Since) {
+		return
+	}
+	m.usage.UsedBytes = state.UsedBytes
+	m.metrics.dataUsed.Set(float64(state.UsedBytes))
+	m.logger.InfoContext(ctx, "Restored the data usage", "since", state.UsageSince, "usedBytes", state.UsedBytes)
+}
+
+// saveSchedule saves that a speed test starts now to the state file.
+func (m *Network) saveSchedule(ctx context.Context) {
+	if m.stateFile == "" {
+		return
+	}
+	m.mu.Lock()
+	m.lastSpeedTest = m.clock.Now()
+	m.mu.Unlock()
+	m.writeState(ctx)
+}
+
+// writeState saves when the last speed test started and the data usage to the state file.
+func (m *Network) writeState(ctx context.Context) {
+	if m.stateFile == "" {
+		return
+	}
+	m.mu.RLock()
+	state := schedulingState{LastSpeedTest: m.lastSpeedTest, UsageSince: m.usage.Since, UsedBytes: m.usage.UsedBytes}
+	m.mu.RUnlock()
+	if err := saveState(m.stateFile, state); err != nil {
+		m.logger.WarnContext(ctx, "Failed to save the monitor state", "stateFile", m.stateFile, "error", err)
+	}
+}
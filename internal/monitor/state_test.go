@@ -0,0 +1,156 @@
+package monitor
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+	"yanm/internal/network"
+	"yanm/internal/network/networkmock"
+	"yanm/internal/storage/storagemock"
+
+	"github.com/benbjohnson/clock"
+	"github.com/golang/mock/gomock"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestState_SaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+
+	state, err := loadState(path)
+	require.NoError(t, err)
+	assert.Zero(t, state)
+
+	last := time.Date(2025, 3, 14, 21, 30, 0, 0, time.UTC)
+	require.NoError(t, saveState(path, schedulingState{LastSpeedTest: last}))
+	state, err = loadState(path)
+	require.NoError(t, err)
+	assert.True(t, last.Equal(state.LastSpeedTest))
+	// the temporary file is renamed over the state file.
+	entries, err := os.ReadDir(filepath.Dir(path))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+
+	require.NoError(t, os.WriteFile(path, []byte("{"), 0o644))
+	_, err = loadState(path)
+	assert.ErrorContains(t, err, "invalid state file")
+}
+
+func TestNetwork_RestoreSchedule(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil))
+	now := time.Date(2025, 3, 14, 21, 30, 0, 0, time.UTC)
+	newMonitor := func(t *testing.T, last time.Time) (*Network, *clock.Mock, string) {
+		path := filepath.Join(t.TempDir(), "state.json")
+		if !last.IsZero() {
+			require.NoError(t, saveState(path, schedulingState{LastSpeedTest: last}))
+		}
+		mockCtrl := gomock.NewController(t)
+		m := NewNetwork(logger, storagemock.NewMockMetricsStorage(mockCtrl), networkmock.NewMockSpeedTester(mockCtrl),
+			WithNetworkInterval(time.Hour), WithStateFile(path))
+		mockClock := clock.NewMock()
+		mockClock.Set(now)
+		m.clock = mockClock
+		return m, mockClock, path
+	}
+
+	t.Run("without state", func(t *testing.T) {
+		m, mockClock, _ := newMonitor(t, time.Time{})
+		assert.Nil(t, m.restoreSchedule(context.Background()))
+		assert.True(t, m.networkLimiter.AllowN(mockClock.Now(), 1))
+	})
+
+	t.Run("recent", func(t *testing.T) {
+		m, mockClock, _ := newMonitor(t, now.Add(-45*time.Minute))
+		due := m.restoreSchedule(context.Background())
+		require.NotNil(t, due)
+		// the speed test before the restart still counts against the limiter.
+		assert.False(t, m.networkLimiter.AllowN(mockClock.Now(), 1))
+
+		mockClock.Add(15*time.Minute - time.Second)
+		select {
+		case <-due:
+			t.Fatal("due before an interval since the last speed test")
+		default:
+		}
+		mockClock.Add(time.Second)
+		select {
+		case <-due:
+		default:
+			t.Fatal("not due an interval after the last speed test")
+		}
+	})
+
+	t.Run("overdue", func(t *testing.T) {
+		m, mockClock, _ := newMonitor(t, now.Add(-3*time.Hour))
+		due := m.restoreSchedule(context.Background())
+		mockClock.Add(0)
+		select {
+		case <-due:
+		default:
+			t.Fatal("overdue speed test not due right away")
+		}
+		assert.True(t, m.networkLimiter.AllowN(mockClock.Now(), 1))
+	})
+
+	t.Run("saved", func(t *testing.T) {
+		m, mockClock, path := newMonitor(t, time.Time{})
+		networkMock := networkmock.NewMockSpeedTester(gomock.NewController(t))
+		m.client = networkMock
+		networkMock.EXPECT().PerformSpeedTest(gomock.Any()).Return(nil, assert.AnError)
+		_, err := m.performNetworkCheck(context.Background())
+		require.ErrorIs(t, err, assert.AnError)
+
+		state, err := loadState(path)
+		require.NoError(t, err)
+		assert.True(t, mockClock.Now().Equal(state.LastSpeedTest))
+	})
+}
+
+func TestNetwork_DataUsageState(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil))
+	path := filepath.Join(t.TempDir(), "state.json")
+	mockClock := clock.NewMock()
+	mockClock.Set(time.Date(2024, 3, 20, 12, 0, 0, 0, time.UTC))
+	budget := DataBudget{MonthlyBytes: 1000, ResetDay: 1}
+	newMonitor := func(t *testing.T, client *networkmock.MockSpeedTester) *Network {
+		mockCtrl := gomock.NewController(t)
+		storageMock := storagemock.NewMockMetricsStorage(mockCtrl)
+		storageMock.EXPECT().StoreNetworkPerformance(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(),
+			gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+		m := NewNetwork(logger, storageMock, client, WithDataBudget(budget), WithStateFile(path))
+		m.clock = mockClock
+		return m
+	}
+
+	client := networkmock.NewMockSpeedTester(gomock.NewController(t))
+	client.EXPECT().PerformSpeedTest(gomock.Any()).
+		Return(&network.PerformanceResult{DownloadBytes: 400, UploadBytes: 200}, nil)
+	m := newMonitor(t, client)
+	_, err := m.performNetworkCheck(context.Background())
+	require.NoError(t, err)
+
+	state, err := loadState(path)
+	require.NoError(t, err)
+	assert.True(t, mockClock.Now().Equal(state.LastSpeedTest))
+	assert.True(t, time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC).Equal(state.UsageSince))
+	assert.Equal(t, int64(600), state.UsedBytes)
+
+	t.Run("restored", func(t *testing.T) {
+		m := newMonitor(t, networkmock.NewMockSpeedTester(gomock.NewController(t)))
+		m.restoreSchedule(context.Background())
+		assert.Equal(t, int64(600), m.DataUsage().UsedBytes)
+		assert.Equal(t, 600.0, testutil.ToFloat64(m.metrics.dataUsed))
+	})
+
+	t.Run("new budget month", func(t *testing.T) {
+		mockClock.Set(time.Date(2024, 4, 2, 0, 0, 0, 0, time.UTC))
+		m := newMonitor(t, networkmock.NewMockSpeedTester(gomock.NewController(t)))
+		m.restoreSchedule(context.Background())
+		assert.Zero(t, m.DataUsage().UsedBytes)
+	})
+}
@@ -0,0 +1,51 @@
+package monitor
+
+import (
+	"context"
+	"time"
+
+	"yanm/internal/logger"
+)
+
+// _panicRestartDelay is how long a check loop that panicked waits before restarting, so a
+// loop that panics straight away doesn't spin.
+const _panicRestartDelay = 5 * time.Second
+
+// supervise runs the check loop fn until ctx is done. A panic in fn is logged, with its
+// stack and crash report, and then either fn is restarted when restarting on panic is
+// enabled, or the panic goes on to crash the process.
+func (m *Network) supervise(ctx context.Context, name string, fn func(ctx context.Context)) {
+	for {
+		if !m.runRecovering(ctx, name, fn) || ctx.Err() != nil {
+			return
+		}
+		m.metrics.panicked(name)
+		m.logger.WarnContext(ctx, "Restarting check loop after a panic", "loop", name, "delay", _panicRestartDelay)
+		select {
+		case <-ctx.Done():
+			return
+		case <-m.clock.After(_panicRestartDelay):
+		}
+	}
+}
+
+// runRecovering runs fn, reporting whether it panicked and was recovered.
+func (m *Network) runRecovering(ctx context.Context, name string, fn func(ctx context.Context)) (panicked bool) {
+	defer func() {
+		v := recover()
+		if v == nil {
+			return
+		}
+		logger.LogPanic(ctx, m.logger.With("loop", name), v)
+		if !m.restartOnPanic.Load() {
+			// the process is about to crash, send the report while we can.
+			flushCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+			defer cancel()
+			_ = logger.Shutdown(flushCtx)
+			panic(v)
+		}
+		panicked = true
+	}()
+	fn(ctx)
+	return false
+}
@@ -0,0 +1,74 @@
+package monitor
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"yanm/internal/network/networkmock"
+	"yanm/internal/storage/storagemock"
+
+	"github.com/benbjohnson/clock"
+	"github.com/golang/mock/gomock"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNetwork_Supervise(t *testing.T) {
+	logs := bytes.Buffer{}
+	logger := slog.New(slog.NewTextHandler(&logs, nil))
+
+	mockCtrl := gomock.NewController(t)
+	m := NewNetwork(logger, storagemock.NewMockMetricsStorage(mockCtrl), networkmock.NewMockSpeedTester(mockCtrl),
+		WithRestartOnPanic(true))
+	mockClock := clock.NewMock()
+	m.clock = mockClock
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	runs := make(chan int)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		run := 0
+		m.supervise(ctx, "flaky", func(context.Context) {
+			run++
+			runs <- run
+			if run == 1 {
+				panic("boom")
+			}
+		})
+	}()
+
+	// the loop is restarted once the delay has passed, and then returns normally.
+	assert.Equal(t, 1, <-runs)
+	assert.Eventually(t, func() bool {
+		mockClock.Add(_panicRestartDelay)
+		select {
+		case run := <-runs:
+			return run == 2
+		default:
+			return false
+		}
+	}, time.Second, 10*time.Millisecond)
+	<-done
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.metrics.panics.WithLabelValues("flaky")))
+	assert.Contains(t, logs.String(), `msg=Panic loop=flaky panic=boom`)
+	assert.Contains(t, logs.String(), `msg="Restarting check loop after a panic" loop=flaky`)
+}
+
+func TestNetwork_SuperviseCrashes(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil))
+
+	mockCtrl := gomock.NewController(t)
+	m := NewNetwork(logger, storagemock.NewMockMetricsStorage(mockCtrl), networkmock.NewMockSpeedTester(mockCtrl))
+
+	// without restarting, the panic goes on after it was logged.
+	assert.PanicsWithValue(t, "boom", func() {
+		m.supervise(context.Background(), "ping", func(context.Context) { panic("boom") })
+	})
+}
@@ -0,0 +1,84 @@
+package monitor
+
+import (
+	"context"
+	"time"
+
+	"yanm/internal/logctx"
+	"yanm/internal/network"
+	"yanm/internal/storage"
+)
+
+// TargetCheck is a latency check against a single configured target, run on its own interval.
+type TargetCheck struct {
+	Checker  network.Checker
+	Interval time.Duration
+	// Threshold triggers a network check when the latency exceeds it, 0 never triggers one.
+	Threshold time.Duration
+}
+
+// runTarget checks target every interval until ctx is done.
+func (m *Network) runTarget(ctx context.Context, target TargetCheck) {
+	ticker := m.clock.Ticker(target.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			m.logger.InfoContext(ctx, "Target check goroutine stopping...", "target", target.Checker.Target().Name)
+			return
+		case <-ticker.C:
+			checkCtx := logctx.WithCheckID(ctx, logctx.NewID())
+			result, err := m.performTargetCheck(checkCtx, target)
+			if err != nil {
+				continue
+			}
+			if target.Threshold > 0 && result.Latency > target.Threshold {
+				m.logger.InfoContext(checkCtx, "Target latency is high", "target", result.TargetName, "latency", result.Latency)
+				m.triggerNetwork(checkCtx, "")
+			}
+		}
+	}
+}
+
+func (m *Network) performTargetCheck(ctx context.Context, target TargetCheck) (*network.PingResult, error) {
+	name := target.Checker.Target().Name
+
+	start := m.clock.Now()
+	result, err := target.Checker.Check(ctx)
+	m.metrics.checkDuration.WithLabelValues(_checkTarget).Observe(m.clock.Since(start).Seconds())
+	if err != nil {
+		m.metrics.checked(_checkTarget, _resultFailure)
+		m.publish(Event{Type: EventCheckFailed, Check: name, Error: err.Error()})
+		m.logger.ErrorContext(ctx, "Target check failed", "target", name, "error", err)
+		return nil, err
+	}
+	m.metrics.checked(_checkTarget, _resultSuccess)
+	result.Labels = m.labels
+	m.publish(Event{Type: EventTarget, Check: name, Ping: result})
+
+	writeCtx, cancel := context.WithTimeout(ctx, m.writeTimeout())
+	defer cancel()
+	err = m.storage.StorePingResult(
+		writeCtx,
+		m.clock.Now(),
+		result.Latency.Milliseconds(),
+		durationMs(result.Jitter),
+		result.PacketLossPercent,
+		result.TargetName,
+		result.Geo.Lat,
+		result.Geo.Lon,
+	)
+	if err != nil {
+		m.metrics.storageFailed(_checkTarget)
+		m.logger.ErrorContext(ctx, "Failed to store target result", "target", name, "error", err)
+	}
+	if len(result.Values) > 0 {
+		if err := storage.StoreProbeValues(writeCtx, m.storage, result.Timestamp, result.TargetName, result.Values); err != nil {
+			m.metrics.storageFailed(_checkTarget)
+			m.logger.ErrorContext(ctx, "Failed to store target values", "target", name, "error", err)
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,72 @@
+package monitor
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+	"yanm/internal/network"
+	"yanm/internal/network/networkmock"
+	"yanm/internal/storage"
+	"yanm/internal/storage/storagemock"
+
+	"github.com/golang/mock/gomock"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNetwork_PerformTargetCheck(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil))
+
+	mockCtrl := gomock.NewController(t)
+	storageMock := storagemock.NewMockMetricsStorage(mockCtrl)
+	checker := networkmock.NewMockChecker(mockCtrl)
+	checker.EXPECT().Target().Return(network.Target{Name: "gateway", Type: network.TargetTCP}).AnyTimes()
+
+	target := TargetCheck{Checker: checker, Interval: time.Second, Threshold: 50 * time.Millisecond}
+	m := NewNetwork(logger, storageMock, networkmock.NewMockSpeedTester(mockCtrl), WithTargets(target))
+
+	checker.EXPECT().Check(gomock.Any()).Return(&network.PingResult{
+		TargetName:        "gateway",
+		Latency:           20 * time.Millisecond,
+		PacketLossPercent: -1,
+	}, nil)
+	storageMock.EXPECT().StorePingResult(gomock.Any(), gomock.Any(), int64(20), 0.0, -1.0, "gateway", "", "").Return(nil)
+	result, err := m.performTargetCheck(context.Background(), target)
+	require.NoError(t, err)
+	assert.Equal(t, 20*time.Millisecond, result.Latency)
+
+	checker.EXPECT().Check(gomock.Any()).Return(nil, errors.New("connection refused"))
+	_, err = m.performTargetCheck(context.Background(), target)
+	require.Error(t, err)
+
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.metrics.checks.WithLabelValues(_checkTarget, _resultSuccess)))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.metrics.checks.WithLabelValues(_checkTarget, _resultFailure)))
+}
+
+func TestNetwork_PerformTargetCheckValues(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil))
+
+	mockCtrl := gomock.NewController(t)
+	checker := networkmock.NewMockChecker(mockCtrl)
+	checker.EXPECT().Target().Return(network.Target{Name: "modem", Type: network.TargetExec}).AnyTimes()
+
+	var out bytes.Buffer
+	target := TargetCheck{Checker: checker, Interval: time.Second}
+	m := NewNetwork(logger, storage.NewConsoleStorage(&out), networkmock.NewMockSpeedTester(mockCtrl), WithTargets(target))
+
+	ts := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	checker.EXPECT().Check(gomock.Any()).Return(&network.PingResult{
+		TargetName:        "modem",
+		Timestamp:         ts,
+		Latency:           20 * time.Millisecond,
+		PacketLossPercent: -1,
+		Values:            map[string]float64{"snr_db": 38.5},
+	}, nil)
+	_, err := m.performTargetCheck(context.Background(), target)
+	require.NoError(t, err)
+	assert.Contains(t, out.String(), "would store 2025-01-02T03:04:05Z probe server=\"modem\" snr_db=38.5\n")
+}
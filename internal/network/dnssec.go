@@ -0,0 +1,105 @@
+package network
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// _dnssecBogusQuery is a name whose DNSSEC signatures are deliberately broken, which a
+// validating resolver refuses to resolve.
+const _dnssecBogusQuery = "dnssec-failed.org"
+
+// _dnsUDPSize is the largest response advertised over EDNS0, large enough for signatures.
+const _dnsUDPSize = 1232
+
+// checkDNSSEC verifies the target resolver validates DNSSEC: it has to vouch for the
+// signed answer of the query with the AD flag, and refuse to answer the bogus query. It
+// returns the round trip of the query.
+func (c *dnsChecker) checkDNSSEC(ctx context.Context) (time.Duration, error) {
+	start := c.clock.Now()
+	h, err := c.exchange(ctx, c.target.Query)
+	if err != nil {
+		return 0, err
+	}
+	latency := c.clock.Since(start)
+	if h.RCode != dnsmessage.RCodeSuccess {
+		return 0, fmt.Errorf("lookup of %s failed: %s", c.target.Query, h.RCode)
+	}
+	if !h.AuthenticData {
+		return 0, fmt.Errorf("resolver %s did not validate the DNSSEC signatures of %s", c.target.Address, c.target.Query)
+	}
+
+	h, err = c.exchange(ctx, c.bogusQuery)
+	if err != nil {
+		return 0, err
+	}
+	if h.RCode != dnsmessage.RCodeServerFailure {
+		return 0, fmt.Errorf("resolver %s answered %s despite its invalid DNSSEC signatures", c.target.Address, c.bogusQuery)
+	}
+	return latency, nil
+}
+
+// exchange sends an A query for name with the DNSSEC OK bit to the target resolver over
+// UDP, returning the header of the response.
+func (c *dnsChecker) exchange(ctx context.Context, name string) (dnsmessage.Header, error) {
+	qname, err := dnsmessage.NewName(name + ".")
+	if err != nil {
+		return dnsmessage.Header{}, fmt.Errorf("invalid query %q: %w", name, err)
+	}
+	id := uint16(rand.Uint32())
+	b := dnsmessage.NewBuilder(nil, dnsmessage.Header{ID: id, RecursionDesired: true, AuthenticData: true})
+	b.EnableCompression()
+	var opt dnsmessage.ResourceHeader
+	if err := opt.SetEDNS0(_dnsUDPSize, dnsmessage.RCodeSuccess, true); err != nil {
+		return dnsmessage.Header{}, err
+	}
+	if err := b.StartQuestions(); err != nil {
+		return dnsmessage.Header{}, err
+	}
+	if err := b.Question(dnsmessage.Question{Name: qname, Type: dnsmessage.TypeA, Class: dnsmessage.ClassINET}); err != nil {
+		return dnsmessage.Header{}, err
+	}
+	if err := b.StartAdditionals(); err != nil {
+		return dnsmessage.Header{}, err
+	}
+	if err := b.OPTResource(opt, dnsmessage.OPTResource{}); err != nil {
+		return dnsmessage.Header{}, err
+	}
+	req, err := b.Finish()
+	if err != nil {
+		return dnsmessage.Header{}, err
+	}
+
+	conn, err := c.dialer.DialContext(ctx, "udp", c.target.Address)
+	if err != nil {
+		return dnsmessage.Header{}, err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			return dnsmessage.Header{}, err
+		}
+	}
+	if _, err := conn.Write(req); err != nil {
+		return dnsmessage.Header{}, fmt.Errorf("lookup of %s failed: %w", name, err)
+	}
+
+	resp := make([]byte, _dnsUDPSize)
+	for {
+		n, err := conn.Read(resp)
+		if err != nil {
+			return dnsmessage.Header{}, fmt.Errorf("lookup of %s failed: %w", name, err)
+		}
+		var p dnsmessage.Parser
+		h, err := p.Start(resp[:n])
+		// ignore packets that are not the answer, e.g. spoofed ones.
+		if err != nil || !h.Response || h.ID != id {
+			continue
+		}
+		return h, nil
+	}
+}
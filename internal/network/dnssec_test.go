@@ -0,0 +1,108 @@
+package network
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// newResolver answers the A queries it receives with the rcode and AD flag answer
+// returns for the queried name, without the final dot.
+func newResolver(t *testing.T, answer func(name string) (dnsmessage.RCode, bool)) string {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			var p dnsmessage.Parser
+			h, err := p.Start(buf[:n])
+			if err != nil {
+				continue
+			}
+			q, err := p.Question()
+			if err != nil {
+				continue
+			}
+			rcode, ad := answer(strings.TrimSuffix(q.Name.String(), "."))
+			resp := dnsmessage.Message{
+				Header:    dnsmessage.Header{ID: h.ID, Response: true, RCode: rcode, AuthenticData: ad},
+				Questions: []dnsmessage.Question{q},
+			}
+			out, err := resp.Pack()
+			if err != nil {
+				continue
+			}
+			_, _ = conn.WriteTo(out, addr)
+		}
+	}()
+	return conn.LocalAddr().String()
+}
+
+func TestDNSChecker_DNSSEC(t *testing.T) {
+	validating := func(name string) (dnsmessage.RCode, bool) {
+		if name == _dnssecBogusQuery {
+			return dnsmessage.RCodeServerFailure, false
+		}
+		return dnsmessage.RCodeSuccess, true
+	}
+
+	tests := []struct {
+		name        string
+		answer      func(name string) (dnsmessage.RCode, bool)
+		expectError string
+	}{
+		{
+			name:   "validating",
+			answer: validating,
+		},
+		{
+			name:        "not validating",
+			answer:      func(string) (dnsmessage.RCode, bool) { return dnsmessage.RCodeSuccess, false },
+			expectError: "did not validate the DNSSEC signatures of example.com",
+		},
+		{
+			name: "answering bogus names",
+			answer: func(string) (dnsmessage.RCode, bool) {
+				// the AD flag alone proves nothing, it may be copied from the query.
+				return dnsmessage.RCodeSuccess, true
+			},
+			expectError: "answered dnssec-failed.org despite its invalid DNSSEC signatures",
+		},
+		{
+			name:        "failing",
+			answer:      func(string) (dnsmessage.RCode, bool) { return dnsmessage.RCodeNameError, false },
+			expectError: "lookup of example.com failed: RCodeNameError",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			addr := newResolver(t, tt.answer)
+			checker, err := NewChecker(Target{Name: "resolver", Type: TargetDNS, Address: addr, Query: "example.com", DNSSEC: true, Timeout: time.Second})
+			require.NoError(t, err)
+
+			result, err := checker.Check(context.Background())
+			if tt.expectError != "" {
+				require.ErrorContains(t, err, tt.expectError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "resolver", result.TargetName)
+			assert.Positive(t, result.Latency)
+			assert.Negative(t, result.PacketLossPercent)
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package network
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/benbjohnson/clock"
+)
+
+// _execMaxOutput bounds what is read from an exec probe's stdout and stderr.
+const _execMaxOutput = 64 << 10
+
+// ExecOutput is the JSON object an exec probe's command writes to stdout. Every field
+// is optional, e.g. a modem script may only report values.
+type ExecOutput struct {
+	// LatencyMs is the latency the command measured, how long it ran if not set.
+	LatencyMs *float64 `json:"latency_ms"`
+	JitterMs  float64  `json:"jitter_ms"`
+	// PacketLossPercent is in the range 0-100, not measured if not set.
+	PacketLossPercent *float64 `json:"packet_loss_percent"`
+	// Values are stored by name alongside the latency, e.g. {"snr_db": 38.5}.
+	Values map[string]float64 `json:"values"`
+}
+
+// execChecker runs a command and reads its result from the JSON it writes to stdout.
+// The check fails when the command exits with an error or its output isn't valid.
+type execChecker struct {
+	target Target
+	clock  clock.Clock
+}
+
+func (c *execChecker) Target() Target { return c.target }
+
+func (c *execChecker) Check(ctx context.Context) (*PingResult, error) {
+	if len(c.target.Command) == 0 {
+		return nil, fmt.Errorf("exec target %q has no command", c.target.Name)
+	}
+	ctx, cancel := context.WithTimeout(ctx, c.target.Timeout)
+	defer cancel()
+
+	var stdout, stderr limitedBuffer
+	cmd := exec.CommandContext(ctx, c.target.Command[0], c.target.Command[1:]...)
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+
+	start := c.clock.Now()
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%s failed: %w: %s", c.target.Command[0], err, msg)
+		}
+		return nil, fmt.Errorf("%s failed: %w", c.target.Command[0], err)
+	}
+	elapsed := c.clock.Since(start)
+
+	var out ExecOutput
+	if err := json.Unmarshal(stdout.Bytes(), &out); err != nil {
+		return nil, fmt.Errorf("%s wrote invalid output: %v", c.target.Command[0], err)
+	}
+
+	result := &PingResult{
+		TargetName:        c.target.Name,
+		Timestamp:         c.clock.Now(),
+		Latency:           elapsed,
+		Jitter:            msDuration(out.JitterMs),
+		PacketLossPercent: -1,
+		Values:            out.Values,
+	}
+	if out.LatencyMs != nil {
+		result.Latency = msDuration(*out.LatencyMs)
+	}
+	if out.PacketLossPercent != nil {
+		result.PacketLossPercent = *out.PacketLossPercent
+	}
+	return result, nil
+}
+
+// msDuration converts fractional milliseconds to a duration.
+func msDuration(ms float64) time.Duration {
+	return time.Duration(ms * float64(time.Millisecond))
+}
+
+// limitedBuffer keeps the first _execMaxOutput bytes written to it, so a runaway command
+// cannot exhaust memory.
+type limitedBuffer struct {
+	bytes.Buffer
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := _execMaxOutput - b.Len(); room > 0 {
+		b.Buffer.Write(p[:min(len(p), room)])
+	}
+	// pretend everything was written, the command would fail on a short write.
+	return len(p), nil
+}
@@ -0,0 +1,79 @@
+//go:build !windows
+
+package network
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewChecker_Exec(t *testing.T) {
+	tests := []struct {
+		name    string
+		script  string
+		want    *PingResult
+		wantErr string
+	}{
+		{
+			name:   "latency and values",
+			script: `echo '{"latency_ms": 12.5, "jitter_ms": 1, "packet_loss_percent": 0, "values": {"snr_db": 38.5}}'`,
+			want: &PingResult{
+				TargetName: "modem",
+				Latency:    12500 * time.Microsecond,
+				Jitter:     time.Millisecond,
+				Values:     map[string]float64{"snr_db": 38.5},
+			},
+		},
+		{
+			name:   "values only",
+			script: `echo '{"values": {"power_dbmv": -2}}'`,
+			want: &PingResult{
+				TargetName:        "modem",
+				PacketLossPercent: -1,
+				Values:            map[string]float64{"power_dbmv": -2},
+			},
+		},
+		{
+			name:    "failed",
+			script:  `echo "modem unreachable" >&2; exit 2`,
+			wantErr: "sh failed: exit status 2: modem unreachable",
+		},
+		{
+			name:    "invalid output",
+			script:  `echo snr=38.5`,
+			wantErr: "sh wrote invalid output: invalid character 's' looking for beginning of value",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checker, err := NewChecker(Target{Name: "modem", Type: TargetExec, Command: []string{"sh", "-c", tt.script}, Timeout: 5 * time.Second})
+			require.NoError(t, err)
+
+			result, err := checker.Check(context.Background())
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			if tt.want.Latency == 0 {
+				// the time the command took.
+				assert.Positive(t, result.Latency)
+				result.Latency = 0
+			}
+			result.Timestamp = time.Time{}
+			assert.Equal(t, tt.want, result)
+		})
+	}
+}
+
+func TestNewChecker_ExecTimeout(t *testing.T) {
+	checker, err := NewChecker(Target{Name: "slow", Type: TargetExec, Command: []string{"sleep", "5"}, Timeout: 50 * time.Millisecond})
+	require.NoError(t, err)
+
+	_, err = checker.Check(context.Background())
+	require.EqualError(t, err, "sleep failed: signal: killed")
+}
@@ -20,7 +20,15 @@ type PerformanceResult struct {
 	DownloadSpeedMbps float64
 	UploadSpeedMbps   float64
 	PingLatency       time.Duration
+	Jitter            time.Duration
+	// PacketLossPercent is in the range 0-100, or negative when not measured.
+	PacketLossPercent float64
 	Geo               Geo
+	// DownloadBytes and UploadBytes are the data the speed test transferred.
+	DownloadBytes int64
+	UploadBytes   int64
+	// Labels are the labels configured for every result, such as the host or site.
+	Labels map[string]string
 }
 
 // PingResult represents the result of a network ping
@@ -28,7 +36,14 @@ type PingResult struct {
 	TargetName string
 	Timestamp  time.Time
 	Latency    time.Duration
-	Geo        Geo
+	Jitter     time.Duration
+	// PacketLossPercent is in the range 0-100, or negative when not measured.
+	PacketLossPercent float64
+	Geo               Geo
+	// Values are the named values reported by an exec target, in addition to the latency.
+	Values map[string]float64
+	// Labels are the labels configured for every result, such as the host or site.
+	Labels map[string]string
 }
 
 // SpeedTester defines the interface for performing network speed tests
@@ -42,3 +57,19 @@ type SpeedTester interface {
 	// Debug returns a DebugRoute to optioanlly expose functions.
 	Debug() http.Handler
 }
+
+// ReducedSpeedTester is implemented by speed testers able to run a shorter speed test,
+// transferring less data at the cost of precision.
+type ReducedSpeedTester interface {
+	// PerformReducedSpeedTest runs a shorter network speed test.
+	PerformReducedSpeedTest(ctx context.Context) (*PerformanceResult, error)
+}
+
+// Checker measures the latency to a single configured target.
+type Checker interface {
+	// Target returns the target being checked.
+	Target() Target
+
+	// Check measures the latency to the target once.
+	Check(ctx context.Context) (*PingResult, error)
+}
@@ -0,0 +1,358 @@
+package network
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"yanm/internal/tracing"
+
+	"github.com/benbjohnson/clock"
+	"go.uber.org/multierr"
+)
+
+// Speed test backends, the backend label of compared results.
+const (
+	BackendOokla      = "ookla"
+	BackendLibreSpeed = "librespeed"
+)
+
+// Files served by the backend of a LibreSpeed server, relative to its URL.
+const (
+	_libreSpeedGarbage = "garbage.php"
+	_libreSpeedEmpty   = "empty.php"
+)
+
+const (
+	// _libreSpeedStreams is the number of connections each direction transfers over, like
+	// the LibreSpeed web client.
+	_libreSpeedStreams = 6
+	// _libreSpeedChunks is the number of 1 MiB chunks a download request asks for, more
+	// than a stream transfers in the capture time on most links.
+	_libreSpeedChunks = 100
+	// _libreSpeedUploadSize is the body of an upload request.
+	_libreSpeedUploadSize = 1 << 20
+	// _libreSpeedPings is the number of round trips measuring the latency, after the one
+	// opening the connection.
+	_libreSpeedPings = 10
+)
+
+// LibreSpeedClient runs speed tests against a LibreSpeed server, implementing the
+// SpeedTester interface like SpeedTestClient does for speedtest.net.
+type LibreSpeedClient struct {
+	server *url.URL
+	client *http.Client
+	logger *slog.Logger
+	// upload is the body of the upload requests, incompressible so proxies can't shrink it.
+	upload []byte
+
+	mu                 sync.RWMutex
+	lastNetworkResults []*PerformanceResult
+	lastPingResults    []*PingResult
+
+	// testing fields
+	clock clock.Clock
+}
+
+// Verify LibreSpeedClient implements SpeedTester interface
+var (
+	_ SpeedTester        = (*LibreSpeedClient)(nil)
+	_ ReducedSpeedTester = (*LibreSpeedClient)(nil)
+)
+
+// NewLibreSpeedClient creates a speed test client of the LibreSpeed server whose backend
+// is at server, e.g. https://librespeed.example/backend/. When source is set, the tests
+// are sent from that local address.
+func NewLibreSpeedClient(logger *slog.Logger, server, source string) (*LibreSpeedClient, error) {
+	u, err := url.Parse(server)
+	if err != nil {
+		return nil, fmt.Errorf("librespeed server: %w", err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("librespeed server %q is not an http(s) URL", server)
+	}
+	// the backend's files are resolved relative to the server's directory.
+	if u.Path == "" || u.Path[len(u.Path)-1] != '/' {
+		u.Path += "/"
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// the data transferred is counted as sent, and garbage doesn't compress anyway.
+	transport.DisableCompression = true
+	transport.MaxIdleConnsPerHost = _libreSpeedStreams
+	if source != "" {
+		ip := net.ParseIP(source)
+		if ip == nil {
+			return nil, fmt.Errorf("librespeed source address %q is not an IP address", source)
+		}
+		transport.DialContext = (&net.Dialer{LocalAddr: &net.TCPAddr{IP: ip}, Timeout: 30 * time.Second}).DialContext
+		logger = logger.With("source", source)
+	}
+
+	upload := make([]byte, _libreSpeedUploadSize)
+	var state uint32 = 2463534242
+	for i := range upload {
+		// xorshift, random enough to defeat compression.
+		state ^= state << 13
+		state ^= state >> 17
+		state ^= state << 5
+		upload[i] = byte(state)
+	}
+
+	return &LibreSpeedClient{
+		server: u,
+		client: &http.Client{Transport: transport},
+		logger: logger,
+		upload: upload,
+		clock:  clock.New(),
+	}, nil
+}
+
+// History returns copies of the retained ping and speed test results, newest first.
+func (c *LibreSpeedClient) History() ([]*PingResult, []*PerformanceResult) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return append([]*PingResult(nil), c.lastPingResults...), append([]*PerformanceResult(nil), c.lastNetworkResults...)
+}
+
+// PerformSpeedTest measures the latency, then the download and the upload speed, each
+// direction over several connections for the capture time.
+func (c *LibreSpeedClient) PerformSpeedTest(ctx context.Context) (*PerformanceResult, error) {
+	return c.performSpeedTest(ctx, _captureTime)
+}
+
+// PerformReducedSpeedTest conducts a speed test transferring data for a third of the time,
+// for metered links running out of data.
+func (c *LibreSpeedClient) PerformReducedSpeedTest(ctx context.Context) (*PerformanceResult, error) {
+	return c.performSpeedTest(ctx, _reducedCaptureTime)
+}
+
+func (c *LibreSpeedClient) performSpeedTest(ctx context.Context, captureTime time.Duration) (*PerformanceResult, error) {
+	latency, jitter, err := c.ping(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("ping test failed: %w", err)
+	}
+
+	var errs error
+	downloadBytes, downloadMbps, err := c.transfer(ctx, "download", captureTime, c.download)
+	errs = multierr.Append(errs, err)
+	uploadBytes, uploadMbps, err := c.transfer(ctx, "upload", captureTime, c.uploadChunk)
+	errs = multierr.Append(errs, err)
+	if errs != nil {
+		return nil, fmt.Errorf("failed to perform tests: %v", errs)
+	}
+
+	performance := &PerformanceResult{
+		TargetName:        c.server.Host,
+		Timestamp:         c.clock.Now(),
+		DownloadSpeedMbps: downloadMbps,
+		UploadSpeedMbps:   uploadMbps,
+		PingLatency:       latency,
+		Jitter:            jitter,
+		PacketLossPercent: -1,
+		DownloadBytes:     downloadBytes,
+		UploadBytes:       uploadBytes,
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lastNetworkResults = append([]*PerformanceResult{performance}, c.lastNetworkResults...)
+	if len(c.lastNetworkResults) > maxHistory {
+		c.lastNetworkResults = c.lastNetworkResults[:maxHistory]
+	}
+	return performance, nil
+}
+
+// PerformPingTest measures the round trip time of requests to the server.
+func (c *LibreSpeedClient) PerformPingTest(ctx context.Context) (*PingResult, error) {
+	pingCtx, cancel := context.WithTimeout(ctx, PingTimeout)
+	defer cancel()
+	latency, jitter, err := c.ping(pingCtx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &PingResult{
+		TargetName:        c.server.Host,
+		Timestamp:         c.clock.Now(),
+		Latency:           latency,
+		Jitter:            jitter,
+		PacketLossPercent: -1,
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lastPingResults = append([]*PingResult{result}, c.lastPingResults...)
+	if len(c.lastPingResults) > maxHistory {
+		c.lastPingResults = c.lastPingResults[:maxHistory]
+	}
+	return result, nil
+}
+
+// ping returns the lowest round trip time of empty requests, and their jitter: the mean
+// difference between consecutive round trips. The first request, opening the connection,
+// is not counted.
+func (c *LibreSpeedClient) ping(ctx context.Context) (latency, jitter time.Duration, err error) {
+	ctx, span := tracing.Start(ctx, "ping", slog.String("server", c.server.Host))
+	defer func() {
+		span.SetError(err)
+		span.End()
+	}()
+
+	var previous, jitterSum time.Duration
+	for i := range _libreSpeedPings + 1 {
+		start := c.clock.Now()
+		if err := c.get(ctx, _libreSpeedEmpty, nil, io.Discard); err != nil {
+			return 0, 0, err
+		}
+		rtt := c.clock.Since(start)
+		switch {
+		case i == 0:
+			continue
+		case i == 1:
+			latency = rtt
+		default:
+			latency = min(latency, rtt)
+			jitterSum += (rtt - previous).Abs()
+		}
+		previous = rtt
+	}
+	return latency, jitterSum / (_libreSpeedPings - 1), nil
+}
+
+// transfer runs request over several connections until captureTime is over, returning the
+// data transferred and the speed.
+func (c *LibreSpeedClient) transfer(
+	ctx context.Context,
+	direction string,
+	captureTime time.Duration,
+	request func(ctx context.Context, counter *atomic.Int64) error,
+) (int64, float64, error) {
+	ctx, span := tracing.Start(ctx, direction, slog.String("server", c.server.Host))
+	defer span.End()
+	c.logger.InfoContext(ctx, "Testing "+direction+" speed on server", "serverName", c.server.Host)
+
+	captureCtx, cancel := context.WithTimeout(ctx, captureTime)
+	defer cancel()
+
+	var (
+		wg      sync.WaitGroup
+		counter atomic.Int64
+		mu      sync.Mutex
+		errs    error // protected with mu
+	)
+	start := c.clock.Now()
+	for range _libreSpeedStreams {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for captureCtx.Err() == nil {
+				// requests cut short by the end of the capture time still count.
+				if err := request(captureCtx, &counter); err != nil && captureCtx.Err() == nil {
+					mu.Lock()
+					errs = multierr.Append(errs, err)
+					mu.Unlock()
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	elapsed := c.clock.Since(start)
+
+	transferred := counter.Load()
+	if err := ctx.Err(); err != nil {
+		span.SetError(err)
+		return transferred, 0, fmt.Errorf("%s test failed: %w", direction, err)
+	}
+	if errs != nil {
+		span.SetError(errs)
+		return transferred, 0, fmt.Errorf("%s test failed: %w", direction, errs)
+	}
+	mbps := float64(transferred) * 8 / 1e6 / math.Max(elapsed.Seconds(), time.Millisecond.Seconds())
+	span.SetAttributes(slog.Float64(direction+"_mbps", mbps))
+	return transferred, mbps, nil
+}
+
+// download downloads a request's worth of garbage, counting the bytes as they arrive.
+func (c *LibreSpeedClient) download(ctx context.Context, counter *atomic.Int64) error {
+	return c.get(ctx, _libreSpeedGarbage, url.Values{"ckSize": {strconv.Itoa(_libreSpeedChunks)}}, counterWriter{counter})
+}
+
+// uploadChunk uploads a chunk of the upload data, counting the bytes as they are sent.
+func (c *LibreSpeedClient) uploadChunk(ctx context.Context, counter *atomic.Int64) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url(_libreSpeedEmpty, nil),
+		&counterReader{Reader: bytes.NewReader(c.upload), counter: counter})
+	if err != nil {
+		return err
+	}
+	req.ContentLength = int64(len(c.upload))
+	req.Header.Set("Content-Type", "application/octet-stream")
+	return c.do(req, io.Discard)
+}
+
+// get requests the named file of the server, copying the response body to w.
+func (c *LibreSpeedClient) get(ctx context.Context, name string, query url.Values, w io.Writer) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url(name, query), nil)
+	if err != nil {
+		return err
+	}
+	return c.do(req, w)
+}
+
+func (c *LibreSpeedClient) do(req *http.Request, w io.Writer) error {
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("librespeed server returned %s for %s", resp.Status, req.URL.Path)
+	}
+	_, err = io.Copy(w, resp.Body)
+	return err
+}
+
+// url returns the URL of the named file of the server, with a random parameter defeating
+// caches like the LibreSpeed web client.
+func (c *LibreSpeedClient) url(name string, query url.Values) string {
+	u := c.server.ResolveReference(&url.URL{Path: name})
+	if query == nil {
+		query = url.Values{}
+	}
+	query.Set("r", strconv.FormatInt(c.clock.Now().UnixNano(), 36))
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+// counterWriter counts the bytes written to it.
+type counterWriter struct {
+	counter *atomic.Int64
+}
+
+func (w counterWriter) Write(p []byte) (int, error) {
+	w.counter.Add(int64(len(p)))
+	return len(p), nil
+}
+
+// counterReader counts the bytes read from Reader.
+type counterReader struct {
+	io.Reader
+	counter *atomic.Int64
+}
+
+func (r *counterReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	r.counter.Add(int64(n))
+	return n, err
+}
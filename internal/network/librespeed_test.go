@@ -0,0 +1,97 @@
+package network
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newLibreSpeedServer serves the backend of a LibreSpeed server under /backend/,
+// counting the bytes it received.
+func newLibreSpeedServer(t *testing.T) (*httptest.Server, *atomic.Int64) {
+	t.Helper()
+	var received atomic.Int64
+	chunk := make([]byte, 1<<20)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/backend/garbage.php", func(w http.ResponseWriter, r *http.Request) {
+		chunks, err := strconv.Atoi(r.URL.Query().Get("ckSize"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		for range chunks {
+			if _, err := w.Write(chunk); err != nil {
+				return
+			}
+		}
+	})
+	mux.HandleFunc("/backend/empty.php", func(w http.ResponseWriter, r *http.Request) {
+		n, _ := io.Copy(io.Discard, r.Body)
+		received.Add(n)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv, &received
+}
+
+func TestLibreSpeedClient_SpeedTest(t *testing.T) {
+	srv, received := newLibreSpeedServer(t)
+	c, err := NewLibreSpeedClient(slog.New(slog.NewTextHandler(os.Stdout, nil)), srv.URL+"/backend", "127.0.0.1")
+	require.NoError(t, err)
+
+	result, err := c.performSpeedTest(context.Background(), 200*time.Millisecond)
+	require.NoError(t, err)
+	assert.Equal(t, srv.Listener.Addr().String(), result.TargetName)
+	assert.Positive(t, result.DownloadSpeedMbps)
+	assert.Positive(t, result.UploadSpeedMbps)
+	assert.Positive(t, result.PingLatency)
+	assert.Equal(t, -1.0, result.PacketLossPercent)
+	assert.Positive(t, result.DownloadBytes)
+	// the upload is counted as it is sent, so the server may miss the last bytes.
+	assert.GreaterOrEqual(t, result.UploadBytes, received.Load())
+	assert.Positive(t, received.Load())
+
+	_, tests := c.History()
+	assert.Equal(t, []*PerformanceResult{result}, tests)
+}
+
+func TestLibreSpeedClient_PingTest(t *testing.T) {
+	srv, _ := newLibreSpeedServer(t)
+	c, err := NewLibreSpeedClient(slog.New(slog.NewTextHandler(os.Stdout, nil)), srv.URL+"/backend/", "")
+	require.NoError(t, err)
+
+	result, err := c.PerformPingTest(context.Background())
+	require.NoError(t, err)
+	assert.Positive(t, result.Latency)
+	assert.Equal(t, -1.0, result.PacketLossPercent)
+
+	pings, _ := c.History()
+	assert.Equal(t, []*PingResult{result}, pings)
+}
+
+func TestLibreSpeedClient_Errors(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
+
+	_, err := NewLibreSpeedClient(logger, "librespeed.example", "")
+	require.EqualError(t, err, `librespeed server "librespeed.example" is not an http(s) URL`)
+	_, err = NewLibreSpeedClient(logger, "https://librespeed.example/backend/", "eth0")
+	require.EqualError(t, err, `librespeed source address "eth0" is not an IP address`)
+
+	// a server without the LibreSpeed backend.
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+	c, err := NewLibreSpeedClient(logger, srv.URL, "")
+	require.NoError(t, err)
+	_, err = c.PerformSpeedTest(context.Background())
+	require.EqualError(t, err, "ping test failed: librespeed server returned 404 Not Found for /empty.php")
+}
@@ -0,0 +1,40 @@
+package network
+
+import (
+	"fmt"
+	"net"
+)
+
+// InterfaceAddress returns the address tests sent over the named network interface
+// originate from: its first global unicast IPv4 address, or else its first IPv6 one.
+func InterfaceAddress(name string) (string, error) {
+	iface, err := net.InterfaceByName(name)
+	if err != nil {
+		return "", fmt.Errorf("interface %s: %w", name, err)
+	}
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return "", fmt.Errorf("interface %s addresses: %w", name, err)
+	}
+	return firstAddress(name, addrs)
+}
+
+func firstAddress(name string, addrs []net.Addr) (string, error) {
+	var v6 net.IP
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if !ok || !ipNet.IP.IsGlobalUnicast() {
+			continue
+		}
+		if ip4 := ipNet.IP.To4(); ip4 != nil {
+			return ip4.String(), nil
+		}
+		if v6 == nil {
+			v6 = ipNet.IP
+		}
+	}
+	if v6 == nil {
+		return "", fmt.Errorf("interface %s has no global unicast address", name)
+	}
+	return v6.String(), nil
+}
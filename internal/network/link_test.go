@@ -0,0 +1,33 @@
+package network
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFirstAddress(t *testing.T) {
+	ipNet := func(cidr string) net.Addr {
+		ip, n, err := net.ParseCIDR(cidr)
+		require.NoError(t, err)
+		n.IP = ip
+		return n
+	}
+
+	// IPv4 is preferred over IPv6, link-local addresses are skipped.
+	addr, err := firstAddress("eth0", []net.Addr{ipNet("fe80::1/64"), ipNet("2001:db8::2/64"), ipNet("192.168.1.2/24")})
+	require.NoError(t, err)
+	assert.Equal(t, "192.168.1.2", addr)
+
+	addr, err = firstAddress("eth0", []net.Addr{ipNet("fe80::1/64"), ipNet("2001:db8::2/64")})
+	require.NoError(t, err)
+	assert.Equal(t, "2001:db8::2", addr)
+
+	_, err = firstAddress("wwan0", []net.Addr{ipNet("169.254.1.1/16")})
+	require.EqualError(t, err, "interface wwan0 has no global unicast address")
+
+	_, err = InterfaceAddress("yanm-missing0")
+	require.ErrorContains(t, err, "interface yanm-missing0")
+}
@@ -79,3 +79,93 @@ func (mr *MockSpeedTesterMockRecorder) PerformSpeedTest(ctx interface{}) *gomock
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PerformSpeedTest", reflect.TypeOf((*MockSpeedTester)(nil).PerformSpeedTest), ctx)
 }
+
+// MockReducedSpeedTester is a mock of ReducedSpeedTester interface.
+type MockReducedSpeedTester struct {
+	ctrl     *gomock.Controller
+	recorder *MockReducedSpeedTesterMockRecorder
+}
+
+// MockReducedSpeedTesterMockRecorder is the mock recorder for MockReducedSpeedTester.
+type MockReducedSpeedTesterMockRecorder struct {
+	mock *MockReducedSpeedTester
+}
+
+// NewMockReducedSpeedTester creates a new mock instance.
+func NewMockReducedSpeedTester(ctrl *gomock.Controller) *MockReducedSpeedTester {
+	mock := &MockReducedSpeedTester{ctrl: ctrl}
+	mock.recorder = &MockReducedSpeedTesterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockReducedSpeedTester) EXPECT() *MockReducedSpeedTesterMockRecorder {
+	return m.recorder
+}
+
+// PerformReducedSpeedTest mocks base method.
+func (m *MockReducedSpeedTester) PerformReducedSpeedTest(ctx context.Context) (*network.PerformanceResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PerformReducedSpeedTest", ctx)
+	ret0, _ := ret[0].(*network.PerformanceResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PerformReducedSpeedTest indicates an expected call of PerformReducedSpeedTest.
+func (mr *MockReducedSpeedTesterMockRecorder) PerformReducedSpeedTest(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PerformReducedSpeedTest", reflect.TypeOf((*MockReducedSpeedTester)(nil).PerformReducedSpeedTest), ctx)
+}
+
+// MockChecker is a mock of Checker interface.
+type MockChecker struct {
+	ctrl     *gomock.Controller
+	recorder *MockCheckerMockRecorder
+}
+
+// MockCheckerMockRecorder is the mock recorder for MockChecker.
+type MockCheckerMockRecorder struct {
+	mock *MockChecker
+}
+
+// NewMockChecker creates a new mock instance.
+func NewMockChecker(ctrl *gomock.Controller) *MockChecker {
+	mock := &MockChecker{ctrl: ctrl}
+	mock.recorder = &MockCheckerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockChecker) EXPECT() *MockCheckerMockRecorder {
+	return m.recorder
+}
+
+// Check mocks base method.
+func (m *MockChecker) Check(ctx context.Context) (*network.PingResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Check", ctx)
+	ret0, _ := ret[0].(*network.PingResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Check indicates an expected call of Check.
+func (mr *MockCheckerMockRecorder) Check(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Check", reflect.TypeOf((*MockChecker)(nil).Check), ctx)
+}
+
+// Target mocks base method.
+func (m *MockChecker) Target() network.Target {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Target")
+	ret0, _ := ret[0].(network.Target)
+	return ret0
+}
+
+// Target indicates an expected call of Target.
+func (mr *MockCheckerMockRecorder) Target() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Target", reflect.TypeOf((*MockChecker)(nil).Target))
+}
@@ -0,0 +1,120 @@
+package network
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/benbjohnson/clock"
+)
+
+const (
+	// NTPOffsetValue is the value of ntp targets holding the offset of the local clock
+	// from the server's in milliseconds, positive when the local clock is behind.
+	NTPOffsetValue = "offset_ms"
+
+	_ntpPort       = "123"
+	_ntpPacketSize = 48
+	// _ntpEpochOffset is the number of seconds from the NTP epoch, 1900, to the Unix epoch.
+	_ntpEpochOffset = 2208988800
+)
+
+// ntpChecker asks an NTP server for its time with a single SNTP request, measuring the
+// round trip delay and the offset of the local clock. A skewed clock silently corrupts
+// the timestamps of every result stored.
+type ntpChecker struct {
+	target Target
+	dialer *net.Dialer
+	clock  clock.Clock
+}
+
+func (c *ntpChecker) Target() Target { return c.target }
+
+func (c *ntpChecker) Check(ctx context.Context) (*PingResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.target.Timeout)
+	defer cancel()
+
+	address := c.target.Address
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		address = net.JoinHostPort(address, _ntpPort)
+	}
+	conn, err := c.dialer.DialContext(ctx, "udp", address)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			return nil, err
+		}
+	}
+
+	req := make([]byte, _ntpPacketSize)
+	req[0] = 0x23 // no leap warning, version 4, client mode.
+	sent := c.clock.Now()
+	// the server echoes the transmit timestamp as the origin of its reply.
+	binary.BigEndian.PutUint64(req[40:], toNTPTime(sent))
+	if _, err := conn.Write(req); err != nil {
+		return nil, fmt.Errorf("ntp request to %s failed: %w", address, err)
+	}
+
+	resp := make([]byte, _ntpPacketSize)
+	n, err := conn.Read(resp)
+	if err != nil {
+		return nil, fmt.Errorf("ntp request to %s failed: %w", address, err)
+	}
+	received := c.clock.Now()
+	if err := checkNTPReply(resp[:n], req); err != nil {
+		return nil, fmt.Errorf("ntp server %s: %w", address, err)
+	}
+
+	serverReceived := fromNTPTime(binary.BigEndian.Uint64(resp[32:]))
+	serverSent := fromNTPTime(binary.BigEndian.Uint64(resp[40:]))
+	offset := (serverReceived.Sub(sent) + serverSent.Sub(received)) / 2
+	// the time the server held the request is not part of the round trip.
+	delay := max(received.Sub(sent)-serverSent.Sub(serverReceived), 0)
+
+	return &PingResult{
+		TargetName:        c.target.Name,
+		Timestamp:         received,
+		Latency:           delay,
+		PacketLossPercent: -1,
+		Values:            map[string]float64{NTPOffsetValue: float64(offset) / float64(time.Millisecond)},
+	}, nil
+}
+
+// checkNTPReply returns an error when resp is not a usable answer to req.
+func checkNTPReply(resp, req []byte) error {
+	if len(resp) < _ntpPacketSize {
+		return fmt.Errorf("short reply of %d bytes", len(resp))
+	}
+	if mode := resp[0] & 0x7; mode != 4 {
+		return fmt.Errorf("reply is not in server mode, got mode %d", mode)
+	}
+	if stratum := resp[1]; stratum == 0 {
+		return fmt.Errorf("kiss-o'-death %q", resp[12:16])
+	}
+	if leap := resp[0] >> 6; leap == 3 {
+		return fmt.Errorf("server clock is not synchronized")
+	}
+	if binary.BigEndian.Uint64(resp[24:]) != binary.BigEndian.Uint64(req[40:]) {
+		return fmt.Errorf("reply does not match the request")
+	}
+	return nil
+}
+
+// toNTPTime returns t as an NTP timestamp, seconds since 1900 in 32.32 fixed point.
+func toNTPTime(t time.Time) uint64 {
+	secs := uint64(t.Unix() + _ntpEpochOffset)
+	frac := uint64(t.Nanosecond()) << 32 / uint64(time.Second)
+	return secs<<32 | frac
+}
+
+// fromNTPTime returns the time of an NTP timestamp.
+func fromNTPTime(ts uint64) time.Time {
+	secs := int64(ts>>32) - _ntpEpochOffset
+	nanos := (ts & 0xffffffff) * uint64(time.Second) >> 32
+	return time.Unix(secs, int64(nanos))
+}
@@ -0,0 +1,107 @@
+package network
+
+import (
+	"context"
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newNTPServer answers NTP requests with a clock ahead of the local one by offset,
+// passing each reply through edit first.
+func newNTPServer(t *testing.T, offset time.Duration, edit func(resp []byte)) string {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	go func() {
+		req := make([]byte, _ntpPacketSize)
+		for {
+			n, addr, err := conn.ReadFrom(req)
+			if err != nil {
+				return
+			}
+			received := time.Now().Add(offset)
+			resp := make([]byte, _ntpPacketSize)
+			resp[0] = 0x24 // no leap warning, version 4, server mode.
+			resp[1] = 2
+			copy(resp[24:32], req[40:n])
+			binary.BigEndian.PutUint64(resp[32:], toNTPTime(received))
+			binary.BigEndian.PutUint64(resp[40:], toNTPTime(time.Now().Add(offset)))
+			if edit != nil {
+				edit(resp)
+			}
+			_, _ = conn.WriteTo(resp, addr)
+		}
+	}()
+	return conn.LocalAddr().String()
+}
+
+func TestNewChecker_NTP(t *testing.T) {
+	addr := newNTPServer(t, 2*time.Second, nil)
+
+	checker, err := NewChecker(Target{Name: "pool", Type: TargetNTP, Address: addr, Timeout: time.Second})
+	require.NoError(t, err)
+
+	result, err := checker.Check(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "pool", result.TargetName)
+	assert.GreaterOrEqual(t, result.Latency, time.Duration(0))
+	assert.Less(t, result.Latency, time.Second)
+	assert.Negative(t, result.PacketLossPercent)
+	assert.InDelta(t, 2000, result.Values[NTPOffsetValue], 50)
+}
+
+func TestNewChecker_NTPErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		edit        func(resp []byte)
+		expectError string
+	}{
+		{
+			name: "kiss-o'-death",
+			edit: func(resp []byte) {
+				resp[1] = 0
+				copy(resp[12:16], "RATE")
+			},
+			expectError: `kiss-o'-death "RATE"`,
+		},
+		{
+			name:        "unsynchronized",
+			edit:        func(resp []byte) { resp[0] |= 3 << 6 },
+			expectError: "server clock is not synchronized",
+		},
+		{
+			name:        "client mode",
+			edit:        func(resp []byte) { resp[0] = 0x23 },
+			expectError: "reply is not in server mode, got mode 3",
+		},
+		{
+			name:        "other request",
+			edit:        func(resp []byte) { resp[24]++ },
+			expectError: "reply does not match the request",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			addr := newNTPServer(t, 0, tt.edit)
+			checker, err := NewChecker(Target{Name: "pool", Type: TargetNTP, Address: addr, Timeout: time.Second})
+			require.NoError(t, err)
+
+			_, err = checker.Check(context.Background())
+			require.EqualError(t, err, "ntp server "+addr+": "+tt.expectError)
+		})
+	}
+}
+
+func TestNTPTime(t *testing.T) {
+	ts := time.Date(2025, 3, 14, 21, 30, 0, 250_000_000, time.UTC)
+	assert.WithinDuration(t, ts, fromNTPTime(toNTPTime(ts)), time.Microsecond)
+	assert.Equal(t, uint64(_ntpEpochOffset)<<32, toNTPTime(time.Unix(0, 0)))
+}
@@ -0,0 +1,182 @@
+package network
+
+import (
+	"context"
+	"html/template"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"yanm/internal/debughttp"
+	"yanm/internal/debughttp/debughandler"
+	"yanm/probe"
+
+	"github.com/benbjohnson/clock"
+)
+
+// TargetProbe is the type of the targets of registered Go probes.
+const TargetProbe = "probe"
+
+// _probesPath lists the probes, each probe's own page is under it.
+const _probesPath = "/debug/probes/"
+
+// ProbeChecker checks a registered probe.Probe like a configured target, keeping its
+// latest outcome for the probes page.
+type ProbeChecker struct {
+	probe probe.Probe
+
+	mu      sync.Mutex
+	lastRun time.Time
+	last    *PingResult
+	lastErr string
+
+	// testing fields
+	clock clock.Clock
+}
+
+var _ Checker = (*ProbeChecker)(nil)
+
+// NewProbeChecker creates a ProbeChecker running p.
+func NewProbeChecker(p probe.Probe) *ProbeChecker {
+	return &ProbeChecker{probe: p, clock: clock.New()}
+}
+
+// Probe returns the probe being checked.
+func (c *ProbeChecker) Probe() probe.Probe { return c.probe }
+
+// Target returns the probe as a target of type TargetProbe.
+func (c *ProbeChecker) Target() Target {
+	return Target{Name: c.probe.Name(), Type: TargetProbe}
+}
+
+// Check runs the probe once.
+func (c *ProbeChecker) Check(ctx context.Context) (*PingResult, error) {
+	res, err := c.probe.Run(ctx)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lastRun = c.clock.Now()
+	if err != nil {
+		c.lastErr = err.Error()
+		return nil, err
+	}
+	c.lastErr = ""
+	c.last = &PingResult{
+		TargetName:        c.probe.Name(),
+		Timestamp:         c.lastRun,
+		Latency:           res.Latency,
+		Jitter:            res.Jitter,
+		PacketLossPercent: res.PacketLossPercent,
+		Values:            res.Values,
+	}
+	result := *c.last
+	return &result, nil
+}
+
+const _probesPage = `
+<h1>Probes</h1>
+<table>
+	<tr>
+		<th>Probe</th>
+		<th>Last Run</th>
+		<th>Latency</th>
+		<th>Values</th>
+		<th>Last Error</th>
+	</tr>
+	{{ range . }}
+	<tr>
+		<td>{{ if .HasDebug }}<a href="/debug/probes/{{ .Name }}/">{{ .Name }}</a>{{ else }}{{ .Name }}{{ end }}</td>
+		<td>{{ if .LastRun.IsZero }}-{{ else }}{{ .LastRun.Format "2006-01-02 15:04:05" }}{{ end }}</td>
+		<td>{{ with .LatencyMs }}{{ printf "%.1f" . }} ms{{ else }}-{{ end }}</td>
+		<td>{{ range $name, $value := .Values }}{{ $name }}={{ $value }} {{ else }}-{{ end }}</td>
+		<td>{{ .LastError }}</td>
+	</tr>
+	{{ else }}
+	<tr><td colspan="5">No probe is registered.</td></tr>
+	{{ end }}
+</table>
+`
+
+var _probesPageTemplate = template.Must(template.New("probes").Parse(_probesPage))
+
+// ProbeStatus is the latest outcome of a probe, on the probes page.
+type ProbeStatus struct {
+	Name string `json:"name"`
+	// HasDebug is set when the probe serves a page of its own under /debug/probes/<name>/.
+	HasDebug  bool               `json:"has_debug"`
+	LastRun   time.Time          `json:"last_run"`
+	LatencyMs *float64           `json:"latency_ms,omitempty"`
+	Values    map[string]float64 `json:"values,omitempty"`
+	LastError string             `json:"last_error,omitempty"`
+}
+
+func (c *ProbeChecker) status() ProbeStatus {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	status := ProbeStatus{
+		Name:      c.probe.Name(),
+		HasDebug:  c.probe.Debug() != nil,
+		LastRun:   c.lastRun,
+		LastError: c.lastErr,
+	}
+	if c.last != nil {
+		latency := milliseconds(c.last.Latency)
+		status.LatencyMs = &latency
+		status.Values = c.last.Values
+	}
+	return status
+}
+
+// ProbePages lists the probes and serves the debug page of each.
+type ProbePages []*ProbeChecker
+
+var _ debughttp.PageProvider = ProbePages(nil)
+
+// DebugRoutes returns the probes page, listed when there are probes.
+func (p ProbePages) DebugRoutes() []debughttp.DebugRoute {
+	visibility := debughttp.NavDefault
+	if len(p) == 0 {
+		visibility = debughttp.NavExclude
+	}
+	return []debughttp.DebugRoute{{
+		Path:        strings.TrimSuffix(_probesPath, "/"),
+		Name:        "Probes",
+		Description: "Lists the probes built into this binary, with their latest results and pages.",
+		Handler:     p.handler(),
+		Visibility:  visibility,
+		Group:       "Results",
+		Order:       30,
+		AutoRefresh: true,
+	}}
+}
+
+func (p ProbePages) handler() http.Handler {
+	list := debughandler.NewHTMLProducingHandler(debughandler.NewNegotiatingHandler(p.state, _probesPageTemplate))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name, _, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, _probesPath), "/")
+		if name == "" {
+			list.ServeHTTP(w, r)
+			return
+		}
+		for _, c := range p {
+			if c.probe.Name() != name {
+				continue
+			}
+			if debug := c.probe.Debug(); debug != nil {
+				http.StripPrefix(_probesPath+name, debug).ServeHTTP(w, r)
+				return
+			}
+		}
+		http.NotFound(w, r)
+	})
+}
+
+func (p ProbePages) state(*http.Request) (any, error) {
+	statuses := make([]ProbeStatus, 0, len(p))
+	for _, c := range p {
+		statuses = append(statuses, c.status())
+	}
+	return statuses, nil
+}
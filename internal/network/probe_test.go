@@ -0,0 +1,86 @@
+package network
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"yanm/probe"
+
+	"github.com/benbjohnson/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeProbe struct {
+	result probe.Result
+	err    error
+	debug  http.Handler
+}
+
+func (p *fakeProbe) Name() string                              { return "modem" }
+func (p *fakeProbe) Run(context.Context) (probe.Result, error) { return p.result, p.err }
+func (p *fakeProbe) Debug() http.Handler                       { return p.debug }
+
+func TestProbeChecker(t *testing.T) {
+	p := &fakeProbe{
+		result: probe.Result{Latency: 12 * time.Millisecond, PacketLossPercent: -1, Values: map[string]float64{"snr_db": 38.5}},
+		debug: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = io.WriteString(w, "modem page at "+r.URL.Path)
+		}),
+	}
+	mockClock := clock.NewMock()
+	checker := NewProbeChecker(p)
+	checker.clock = mockClock
+	assert.Equal(t, Target{Name: "modem", Type: TargetProbe}, checker.Target())
+
+	result, err := checker.Check(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, &PingResult{
+		TargetName:        "modem",
+		Timestamp:         mockClock.Now(),
+		Latency:           12 * time.Millisecond,
+		PacketLossPercent: -1,
+		Values:            map[string]float64{"snr_db": 38.5},
+	}, result)
+
+	p.err = errors.New("modem unreachable")
+	_, err = checker.Check(context.Background())
+	require.EqualError(t, err, "modem unreachable")
+
+	routes := ProbePages{checker}.DebugRoutes()
+	require.Len(t, routes, 1)
+	handler := routes[0].Handler
+
+	req := httptest.NewRequest(http.MethodGet, "/debug/probes/", nil)
+	req.Header.Set("Accept", "application/json")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var statuses []ProbeStatus
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&statuses))
+	latency := 12.0
+	assert.Equal(t, []ProbeStatus{{
+		Name:      "modem",
+		HasDebug:  true,
+		LastRun:   mockClock.Now().UTC(),
+		LatencyMs: &latency,
+		Values:    map[string]float64{"snr_db": 38.5},
+		LastError: "modem unreachable",
+	}}, statuses)
+
+	// each probe serves its own page below the list.
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/debug/probes/modem/signal", nil))
+	assert.Equal(t, "modem page at /signal", rr.Body.String())
+
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/debug/probes/battery/", nil))
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
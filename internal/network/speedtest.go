@@ -7,12 +7,22 @@ import (
 	"sync"
 	"time"
 
+	"yanm/internal/tracing"
+
 	"github.com/benbjohnson/clock"
 	"github.com/showwin/speedtest-go/speedtest"
 	"go.uber.org/multierr"
 )
 
-const _pingTimeout = time.Second * 10
+// PingTimeout bounds a single ping test.
+const PingTimeout = time.Second * 10
+
+// Time each direction of a speed test transfers data for, a reduced speed test transfers
+// about a third of the data.
+const (
+	_captureTime        = time.Second * 15
+	_reducedCaptureTime = time.Second * 5
+)
 
 // SpeedTestClient implements the SpeedTester interface
 type SpeedTestClient struct {
@@ -29,7 +39,10 @@ type SpeedTestClient struct {
 }
 
 // Verify SpeedTestClient implements SpeedTester interface
-var _ SpeedTester = (*SpeedTestClient)(nil)
+var (
+	_ SpeedTester        = (*SpeedTestClient)(nil)
+	_ ReducedSpeedTester = (*SpeedTestClient)(nil)
+)
 
 const maxHistory = 10
 
@@ -42,28 +55,59 @@ func NewSpeedTestClient(logger *slog.Logger) *SpeedTestClient {
 	}
 }
 
-// PerformSpeedTest conducts a network speed test
-func (s *SpeedTestClient) PerformSpeedTest(ctx context.Context) (*PerformanceResult, error) {
-	serverList, err := s.st.FetchServerListContext(ctx)
-	if err != nil {
-		return nil, fmt.Errorf("failed to fetch server list: %v", err)
+// NewSourceSpeedTestClient creates a speed test client sending its tests from the local
+// address source, to measure a single uplink of a multi-WAN host.
+func NewSourceSpeedTestClient(logger *slog.Logger, source string) *SpeedTestClient {
+	return &SpeedTestClient{
+		st:     speedtest.New(speedtest.WithUserConfig(&speedtest.UserConfig{Source: source})),
+		clock:  clock.New(),
+		logger: logger.With("source", source),
 	}
+}
 
-	targets, err := serverList.Available().FindServer([]int{})
-	if err != nil {
-		return nil, fmt.Errorf("no suitable speedtest servers found: %v", err)
+// History returns copies of the retained ping and speed test results, newest first.
+func (s *SpeedTestClient) History() ([]*PingResult, []*PerformanceResult) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var pings []*PingResult
+	if len(s.lastPingResults) > 0 {
+		pings = make([]*PingResult, len(s.lastPingResults))
+		copy(pings, s.lastPingResults)
 	}
 
-	if len(targets) < 1 {
-		return nil, fmt.Errorf("no target ")
+	var networkTests []*PerformanceResult
+	if len(s.lastNetworkResults) > 0 {
+		networkTests = make([]*PerformanceResult, len(s.lastNetworkResults))
+		copy(networkTests, s.lastNetworkResults)
 	}
 
-	target := targets[0]
-	s.logger.DebugContext(ctx, "Selected server", "serverName", target.Name)
+	return pings, networkTests
+}
+
+// PerformSpeedTest conducts a network speed test, traced with a span for each phase.
+func (s *SpeedTestClient) PerformSpeedTest(ctx context.Context) (*PerformanceResult, error) {
+	return s.performSpeedTest(ctx, _captureTime)
+}
+
+// PerformReducedSpeedTest conducts a network speed test transferring data for a third of
+// the time, for metered links running out of data.
+func (s *SpeedTestClient) PerformReducedSpeedTest(ctx context.Context) (*PerformanceResult, error) {
+	return s.performSpeedTest(ctx, _reducedCaptureTime)
+}
+
+func (s *SpeedTestClient) performSpeedTest(ctx context.Context, captureTime time.Duration) (*PerformanceResult, error) {
+	target, err := s.selectServer(ctx)
+	if err != nil {
+		return nil, err
+	}
 
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	// the client keeps counting the data transferred across tests.
+	s.st.SetCaptureTime(captureTime)
+	downloaded, uploaded := s.st.GetTotalDownload(), s.st.GetTotalUpload()
 	if err := s.performTests(ctx, target); err != nil {
 		return nil, fmt.Errorf("failed to perform tests: %v", err)
 	}
@@ -74,7 +118,11 @@ func (s *SpeedTestClient) PerformSpeedTest(ctx context.Context) (*PerformanceRes
 		DownloadSpeedMbps: float64(target.DLSpeed.Mbps()),
 		UploadSpeedMbps:   float64(target.ULSpeed.Mbps()),
 		PingLatency:       target.Latency,
+		Jitter:            target.Jitter,
+		PacketLossPercent: target.PacketLoss.LossPercent(),
 		Geo:               Geo{Lat: target.Lat, Lon: target.Lon},
+		DownloadBytes:     s.st.GetTotalDownload() - downloaded,
+		UploadBytes:       s.st.GetTotalUpload() - uploaded,
 	}
 
 	s.lastNetworkResults = append([]*PerformanceResult{performance}, s.lastNetworkResults...)
@@ -120,15 +168,47 @@ func (s *SpeedTestClient) PerformPingTest(ctx context.Context) (*PingResult, err
 		s.lastPingResults = s.lastPingResults[:maxHistory]
 	}
 
-	pingCtx, cancel := context.WithTimeout(ctx, _pingTimeout)
+	pingCtx, cancel := context.WithTimeout(ctx, PingTimeout)
 	defer cancel()
 	if err := target.PingTestContext(pingCtx, _callback); err != nil {
 		return nil, err
 	}
+	// jitter and loss are only known once the whole ping test has completed.
+	result.Jitter = target.Jitter
+	result.PacketLossPercent = target.PacketLoss.LossPercent()
 
 	return result, nil
 }
 
+// selectServer picks the closest server to run a speed test against.
+func (s *SpeedTestClient) selectServer(ctx context.Context) (_ *speedtest.Server, err error) {
+	ctx, span := tracing.Start(ctx, "server_selection")
+	defer func() {
+		span.SetError(err)
+		span.End()
+	}()
+
+	serverList, err := s.st.FetchServerListContext(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch server list: %v", err)
+	}
+
+	targets, err := serverList.Available().FindServer([]int{})
+	if err != nil {
+		return nil, fmt.Errorf("no suitable speedtest servers found: %v", err)
+	}
+
+	if len(targets) < 1 {
+		return nil, fmt.Errorf("no target ")
+	}
+
+	target := targets[0]
+	s.logger.DebugContext(ctx, "Selected server", "serverName", target.Name)
+	span.SetAttributes(slog.String("server", target.Name), slog.Int("servers", len(serverList)),
+		slog.Int64("latency_ms", target.Latency.Milliseconds()))
+	return target, nil
+}
+
 func (s *SpeedTestClient) performTests(ctx context.Context, target *speedtest.Server) error {
 	var (
 		wg   sync.WaitGroup
@@ -140,24 +220,36 @@ func (s *SpeedTestClient) performTests(ctx context.Context, target *speedtest.Se
 	go func() {
 		defer wg.Done()
 
+		ctx, span := tracing.Start(ctx, "download", slog.String("server", target.Name))
+		defer span.End()
+
 		s.logger.InfoContext(ctx, "Testing download speed on server", "serverName", target.Name)
 		if err := target.DownloadTestContext(ctx); err != nil {
+			span.SetError(err)
 			mu.Lock()
 			defer mu.Unlock()
 			errs = multierr.Append(errs, fmt.Errorf("download test failed: %v", err))
+			return
 		}
+		span.SetAttributes(slog.Float64("download_mbps", target.DLSpeed.Mbps()))
 	}()
 
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
 
+		ctx, span := tracing.Start(ctx, "upload", slog.String("server", target.Name))
+		defer span.End()
+
 		s.logger.InfoContext(ctx, "Testing upload speed on server", "serverName", target.Name)
 		if err := target.UploadTestContext(ctx); err != nil {
+			span.SetError(err)
 			mu.Lock()
 			defer mu.Unlock()
 			errs = multierr.Append(errs, fmt.Errorf("upload test failed: %v", err))
+			return
 		}
+		span.SetAttributes(slog.Float64("upload_mbps", target.ULSpeed.Mbps()))
 	}()
 
 	wg.Wait()
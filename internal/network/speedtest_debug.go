@@ -1,105 +1,167 @@
 package network
 
 import (
+	"html/template"
 	"net/http"
-	"text/template"
+	"slices"
+	"time"
+
+	"yanm/internal/debughttp"
+	"yanm/internal/debughttp/debughandler"
 )
 
+// The charts are drawn client-side from the JSON view of this page.
 const speedTestDebugHTMLTemplate = `
 <h1>Speed Test Results</h1>
 
-<h2>Last {{.PingCount}} Ping Tests (Max {{.MaxHistory}})</h2>
-{{if .Pings}}
-<table>
-    <tr>
-        <th>Timestamp</th>
-        <th>Target Server</th>
-        <th>Latency</th>
-    </tr>
-    {{range .Pings}}
-    <tr>
-        <td>{{.Timestamp.Format "2006-01-02 15:04:05"}}</td>
-        <td>{{.TargetName}}</td>
-        <td>{{.Latency}}</td>
-    </tr>
-    {{end}}
-</table>
-{{else}}
-<p>No ping test results yet.</p>
-{{end}}
-
-<h2>Last {{.NetworkCount}} Network Speed Tests (Max {{.MaxHistory}})</h2>
-{{if .NetworkTests}}
-<table>
-    <tr>
-        <th>Timestamp</th>
-        <th>Target Server</th>
-        <th>Download (Mbps)</th>
-        <th>Upload (Mbps)</th>
-        <th>Ping Latency</th>
-    </tr>
-    {{range .NetworkTests}}
-    <tr>
-        <td>{{.Timestamp.Format "2006-01-02 15:04:05"}}</td>
-        <td>{{.TargetName}}</td>
-        <td>{{printf "%.2f" .DownloadSpeedMbps}}</td>
-        <td>{{printf "%.2f" .UploadSpeedMbps}}</td>
-        <td>{{.PingLatency}}</td>
-    </tr>
-    {{end}}
-</table>
-{{else}}
-<p>No network speed test results yet.</p>
-{{end}}
+<h2>Ping Latency (Last {{.PingCount}}, Max {{.MaxHistory}})</h2>
+<div class="chart" id="ping-chart"></div>
+
+<h2>Network Speed (Last {{.NetworkCount}}, Max {{.MaxHistory}})</h2>
+<div class="chart" id="speed-chart"></div>
+
+<h2>Network Speed Test Latency</h2>
+<div class="chart" id="speed-latency-chart"></div>
+
+<script>
+	document.addEventListener("DOMContentLoaded", function () {
+		fetch(window.location.pathname, {headers: {Accept: "application/json"}})
+			.then(resp => resp.json())
+			.then(function (history) {
+				const points = (results, field) => results.map(r => ({time: new Date(r.time), value: r[field]}));
+				yanm.timeSeries(document.getElementById("ping-chart"), [
+					{name: "Latency", color: "#1f77b4", points: points(history.pings, "latency_ms")},
+					{name: "Jitter", color: "#ff7f0e", points: points(history.pings, "jitter_ms")},
+				], "ms");
+				yanm.timeSeries(document.getElementById("speed-chart"), [
+					{name: "Download", color: "#2ca02c", points: points(history.speed_tests, "download_mbps")},
+					{name: "Upload", color: "#9467bd", points: points(history.speed_tests, "upload_mbps")},
+				], "Mbps");
+				yanm.timeSeries(document.getElementById("speed-latency-chart"), [
+					{name: "Latency", color: "#1f77b4", points: points(history.speed_tests, "latency_ms")},
+					{name: "Jitter", color: "#ff7f0e", points: points(history.speed_tests, "jitter_ms")},
+				], "ms");
+			});
+	});
+</script>
 `
 
 var _tempTmpl = template.Must(template.New("speedtest_debug").Parse(speedTestDebugHTMLTemplate))
 
-type page struct {
-	s *SpeedTestClient
+// pingHistory is a ping result in the JSON view.
+type pingHistory struct {
+	Time      time.Time `json:"time"`
+	Server    string    `json:"server"`
+	LatencyMs float64   `json:"latency_ms"`
+	JitterMs  float64   `json:"jitter_ms"`
 }
 
-func (p *page) getPageData() ([]*PingResult, []*PerformanceResult) {
-	p.s.mu.RLock()
-	defer p.s.mu.RUnlock()
+// speedTestHistory is a network speed test result in the JSON view.
+type speedTestHistory struct {
+	Time         time.Time `json:"time"`
+	Server       string    `json:"server"`
+	DownloadMbps float64   `json:"download_mbps"`
+	UploadMbps   float64   `json:"upload_mbps"`
+	LatencyMs    float64   `json:"latency_ms"`
+	JitterMs     float64   `json:"jitter_ms"`
+	// DownloadBytes and UploadBytes are the data the speed test transferred.
+	DownloadBytes int64 `json:"download_bytes"`
+	UploadBytes   int64 `json:"upload_bytes"`
+}
 
-	var pings []*PingResult
-	if len(p.s.lastPingResults) > 0 {
-		pings = make([]*PingResult, len(p.s.lastPingResults))
-		copy(pings, p.s.lastPingResults)
-	}
+// speedTestView is the retained history behind both views of the page, oldest first.
+type speedTestView struct {
+	MaxHistory int                `json:"max_history"`
+	Pings      []pingHistory      `json:"pings"`
+	SpeedTests []speedTestHistory `json:"speed_tests"`
+}
 
-	var networkTests []*PerformanceResult
-	if len(p.s.lastNetworkResults) > 0 {
-		networkTests = make([]*PerformanceResult, len(p.s.lastNetworkResults))
-		copy(networkTests, p.s.lastNetworkResults)
-	}
+// PingCount is the number of retained ping results.
+func (v speedTestView) PingCount() int { return len(v.Pings) }
+
+// NetworkCount is the number of retained network speed test results.
+func (v speedTestView) NetworkCount() int { return len(v.SpeedTests) }
 
-	return pings, networkTests
+func (s *SpeedTestClient) view(*http.Request) (any, error) {
+	return newSpeedTestView(s.History()), nil
 }
 
-func (p *page) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	pings, networkTests := p.getPageData()
-	if err := _tempTmpl.Execute(w, struct {
-		Pings        []*PingResult
-		NetworkTests []*PerformanceResult
-		PingCount    int
-		NetworkCount int
-		MaxHistory   int
-	}{
-		Pings:        pings,
-		NetworkTests: networkTests,
-		PingCount:    len(pings),
-		NetworkCount: len(networkTests),
-		MaxHistory:   maxHistory, // This is the const from speedtest.go
-	}); err != nil {
-		p.s.logger.ErrorContext(r.Context(), "Failed to execute template", "error", err)
-		http.Error(w, "Failed to execute template", http.StatusInternalServerError)
+// newSpeedTestView returns the view of the retained results, kept newest first.
+func newSpeedTestView(pings []*PingResult, networkTests []*PerformanceResult) speedTestView {
+	history := speedTestView{
+		MaxHistory: maxHistory, // This is the const from speedtest.go
+		Pings:      make([]pingHistory, 0, len(pings)),
+		SpeedTests: make([]speedTestHistory, 0, len(networkTests)),
 	}
+	// results are kept newest first.
+	for _, ping := range slices.Backward(pings) {
+		history.Pings = append(history.Pings, pingHistory{
+			Time:      ping.Timestamp,
+			Server:    ping.TargetName,
+			LatencyMs: milliseconds(ping.Latency),
+			JitterMs:  milliseconds(ping.Jitter),
+		})
+	}
+	for _, test := range slices.Backward(networkTests) {
+		history.SpeedTests = append(history.SpeedTests, speedTestHistory{
+			Time:         test.Timestamp,
+			Server:       test.TargetName,
+			DownloadMbps: test.DownloadSpeedMbps,
+			UploadMbps:   test.UploadSpeedMbps,
+			LatencyMs:    milliseconds(test.PingLatency),
+			JitterMs:     milliseconds(test.Jitter),
+
+			DownloadBytes: test.DownloadBytes,
+			UploadBytes:   test.UploadBytes,
+		})
+	}
+	return history
 }
 
-// Debug returns a DebugRoute for the speedtest debug page.
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
 
+// Debug returns the speedtest debug page, rendering the result charts, or the retained
+// history as JSON when requested with an application/json Accept header.
 func (s *SpeedTestClient) Debug() http.Handler {
-	return &page{s: s}
+	return debughandler.NewNegotiatingHandler(s.view, _tempTmpl)
+}
+
+// Debug returns the speedtest debug page of the LibreSpeed results.
+func (c *LibreSpeedClient) Debug() http.Handler {
+	return debughandler.NewNegotiatingHandler(func(*http.Request) (any, error) {
+		return newSpeedTestView(c.History()), nil
+	}, _tempTmpl)
+}
+
+var (
+	_ debughttp.PageProvider = (*SpeedTestClient)(nil)
+	_ debughttp.PageProvider = (*LibreSpeedClient)(nil)
+)
+
+// DebugRoutes returns the speedtest debug page route.
+func (s *SpeedTestClient) DebugRoutes() []debughttp.DebugRoute {
+	return []debughttp.DebugRoute{{
+		Path:        "/debug/speedtest",
+		Name:        "Speed Test Results",
+		Description: "Displays recent speed test and ping results.",
+		Handler:     debughandler.NewHTMLProducingHandler(s.Debug()),
+		Group:       "Results",
+		Order:       10,
+		AutoRefresh: true,
+	}}
+}
+
+// DebugRoutes returns the speedtest debug page route.
+func (c *LibreSpeedClient) DebugRoutes() []debughttp.DebugRoute {
+	return []debughttp.DebugRoute{{
+		Path:        "/debug/speedtest",
+		Name:        "Speed Test Results",
+		Description: "Displays recent speed test and ping results.",
+		Handler:     debughandler.NewHTMLProducingHandler(c.Debug()),
+		Group:       "Results",
+		Order:       10,
+		AutoRefresh: true,
+	}}
 }
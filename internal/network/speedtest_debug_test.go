@@ -0,0 +1,57 @@
+package network
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSpeedTestDebug_JSON(t *testing.T) {
+	s := NewSpeedTestClient(slog.New(slog.NewTextHandler(os.Stdout, nil)))
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	// kept newest first.
+	s.lastPingResults = []*PingResult{
+		{TargetName: "b", Timestamp: start.Add(time.Minute), Latency: 20 * time.Millisecond, Jitter: time.Millisecond},
+		{TargetName: "a", Timestamp: start, Latency: 12500 * time.Microsecond},
+	}
+	s.lastNetworkResults = []*PerformanceResult{
+		{TargetName: "a", Timestamp: start, DownloadSpeedMbps: 300, UploadSpeedMbps: 20, PingLatency: 15 * time.Millisecond,
+			DownloadBytes: 560_000_000, UploadBytes: 37_000_000},
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/debug/speedtest/", nil)
+	req.Header.Set("Accept", "application/json")
+	rr := httptest.NewRecorder()
+	s.Debug().ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{
+		"max_history": 10,
+		"pings": [
+			{"time": "2025-01-01T12:00:00Z", "server": "a", "latency_ms": 12.5, "jitter_ms": 0},
+			{"time": "2025-01-01T12:01:00Z", "server": "b", "latency_ms": 20, "jitter_ms": 1}
+		],
+		"speed_tests": [
+			{"time": "2025-01-01T12:00:00Z", "server": "a", "download_mbps": 300, "upload_mbps": 20, "latency_ms": 15, "jitter_ms": 0,
+			 "download_bytes": 560000000, "upload_bytes": 37000000}
+		]
+	}`, rr.Body.String())
+}
+
+func TestSpeedTestDebug_HTML(t *testing.T) {
+	s := NewSpeedTestClient(slog.New(slog.NewTextHandler(os.Stdout, nil)))
+
+	rr := httptest.NewRecorder()
+	s.Debug().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/debug/speedtest/", nil))
+
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `id="ping-chart"`)
+	assert.Contains(t, rr.Body.String(), `id="speed-chart"`)
+}
@@ -0,0 +1,210 @@
+package network
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"github.com/showwin/speedtest-go/speedtest"
+)
+
+// Target types supported by NewChecker.
+const (
+	TargetPing = "ping"
+	TargetHTTP = "http"
+	TargetDNS  = "dns"
+	TargetTCP  = "tcp"
+	TargetExec = "exec"
+	TargetNTP  = "ntp"
+)
+
+const (
+	_icmpEchoes   = 3
+	_icmpEchoFreq = 100 * time.Millisecond
+)
+
+// Target is a single host checked on its own, in addition to the speedtest servers.
+type Target struct {
+	Name string
+	// Type is one of TargetPing, TargetHTTP, TargetDNS, TargetTCP, TargetExec or TargetNTP.
+	Type string
+	// Address is a host for ping, a URL for http, a resolver host:port for dns,
+	// a host:port for tcp and a server host, with an optional port, for ntp targets.
+	Address string
+	// Query is the name resolved by dns targets.
+	Query string
+	// DNSSEC has dns targets verify the resolver validates DNSSEC, see checkDNSSEC.
+	DNSSEC bool
+	// Command is the program and arguments run by exec targets, see ExecOutput.
+	Command []string
+	Timeout time.Duration
+}
+
+// NewChecker returns the Checker for the target's type.
+func NewChecker(target Target) (Checker, error) {
+	switch target.Type {
+	case TargetPing:
+		return &icmpChecker{target: target, clock: clock.New()}, nil
+	case TargetHTTP:
+		return &httpChecker{target: target, client: &http.Client{Timeout: target.Timeout}, clock: clock.New()}, nil
+	case TargetDNS:
+		return newDNSChecker(target), nil
+	case TargetTCP:
+		return &tcpChecker{target: target, dialer: &net.Dialer{Timeout: target.Timeout}, clock: clock.New()}, nil
+	case TargetExec:
+		return &execChecker{target: target, clock: clock.New()}, nil
+	case TargetNTP:
+		return &ntpChecker{target: target, dialer: &net.Dialer{}, clock: clock.New()}, nil
+	default:
+		return nil, fmt.Errorf("unknown target type %q", target.Type)
+	}
+}
+
+// icmpChecker sends ICMP echo requests, which needs CAP_NET_RAW or root.
+type icmpChecker struct {
+	target Target
+	clock  clock.Clock
+}
+
+func (c *icmpChecker) Target() Target { return c.target }
+
+func (c *icmpChecker) Check(ctx context.Context) (*PingResult, error) {
+	server, err := speedtest.New().CustomServer("http://" + c.target.Address)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ping target %q: %v", c.target.Address, err)
+	}
+
+	latencies, err := server.ICMPPing(ctx, c.target.Timeout, _icmpEchoes, _icmpEchoFreq, nil)
+	if err != nil {
+		return nil, fmt.Errorf("ping %s failed: %v", c.target.Address, err)
+	}
+
+	var sum, jitterSum time.Duration
+	for i, l := range latencies {
+		sum += time.Duration(l)
+		if i > 0 {
+			jitterSum += (time.Duration(l) - time.Duration(latencies[i-1])).Abs()
+		}
+	}
+	result := &PingResult{
+		TargetName:        c.target.Name,
+		Timestamp:         c.clock.Now(),
+		Latency:           sum / time.Duration(len(latencies)),
+		PacketLossPercent: float64(_icmpEchoes-len(latencies)) / _icmpEchoes * 100,
+	}
+	if len(latencies) > 1 {
+		result.Jitter = jitterSum / time.Duration(len(latencies)-1)
+	}
+	return result, nil
+}
+
+// httpChecker measures the time until the response headers of a GET request are received.
+type httpChecker struct {
+	target Target
+	client *http.Client
+	clock  clock.Clock
+}
+
+func (c *httpChecker) Target() Target { return c.target }
+
+func (c *httpChecker) Check(ctx context.Context) (*PingResult, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.target.Address, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid http target %q: %v", c.target.Address, err)
+	}
+
+	start := c.clock.Now()
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	latency := c.clock.Since(start)
+	resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return nil, fmt.Errorf("http %s returned %s", c.target.Address, resp.Status)
+	}
+	return newLatencyResult(c.target, c.clock, latency), nil
+}
+
+// dnsChecker measures how long the target resolver takes to answer a lookup of the query.
+type dnsChecker struct {
+	target   Target
+	resolver *net.Resolver
+	dialer   *net.Dialer
+	// bogusQuery is resolved when checking DNSSEC, and must fail.
+	bogusQuery string
+	clock      clock.Clock
+}
+
+func newDNSChecker(target Target) *dnsChecker {
+	dialer := &net.Dialer{Timeout: target.Timeout}
+	return &dnsChecker{
+		target:     target,
+		dialer:     dialer,
+		bogusQuery: _dnssecBogusQuery,
+		resolver: &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+				// always ask the target resolver rather than the system one.
+				return dialer.DialContext(ctx, network, target.Address)
+			},
+		},
+		clock: clock.New(),
+	}
+}
+
+func (c *dnsChecker) Target() Target { return c.target }
+
+func (c *dnsChecker) Check(ctx context.Context) (*PingResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, c.target.Timeout)
+	defer cancel()
+
+	if c.target.DNSSEC {
+		latency, err := c.checkDNSSEC(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return newLatencyResult(c.target, c.clock, latency), nil
+	}
+
+	start := c.clock.Now()
+	if _, err := c.resolver.LookupHost(ctx, c.target.Query); err != nil {
+		return nil, err
+	}
+	return newLatencyResult(c.target, c.clock, c.clock.Since(start)), nil
+}
+
+// tcpChecker measures how long a TCP connection takes to be established.
+type tcpChecker struct {
+	target Target
+	dialer *net.Dialer
+	clock  clock.Clock
+}
+
+func (c *tcpChecker) Target() Target { return c.target }
+
+func (c *tcpChecker) Check(ctx context.Context) (*PingResult, error) {
+	start := c.clock.Now()
+	conn, err := c.dialer.DialContext(ctx, "tcp", c.target.Address)
+	if err != nil {
+		return nil, err
+	}
+	latency := c.clock.Since(start)
+	conn.Close()
+
+	return newLatencyResult(c.target, c.clock, latency), nil
+}
+
+// newLatencyResult returns the result of a single round trip, for which jitter and loss are not measured.
+func newLatencyResult(target Target, clk clock.Clock, latency time.Duration) *PingResult {
+	return &PingResult{
+		TargetName:        target.Name,
+		Timestamp:         clk.Now(),
+		Latency:           latency,
+		PacketLossPercent: -1,
+	}
+}
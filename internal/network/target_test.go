@@ -0,0 +1,57 @@
+package network
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewChecker_HTTP(t *testing.T) {
+	status := http.StatusOK
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	checker, err := NewChecker(Target{Name: "web", Type: TargetHTTP, Address: srv.URL, Timeout: time.Second})
+	require.NoError(t, err)
+	assert.Equal(t, "web", checker.Target().Name)
+
+	result, err := checker.Check(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "web", result.TargetName)
+	assert.Positive(t, result.Latency)
+	assert.Negative(t, result.PacketLossPercent)
+
+	status = http.StatusBadGateway
+	_, err = checker.Check(context.Background())
+	require.ErrorContains(t, err, "502 Bad Gateway")
+}
+
+func TestNewChecker_TCP(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := l.Addr().String()
+
+	checker, err := NewChecker(Target{Name: "gateway", Type: TargetTCP, Address: addr, Timeout: time.Second})
+	require.NoError(t, err)
+
+	result, err := checker.Check(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "gateway", result.TargetName)
+
+	require.NoError(t, l.Close())
+	_, err = checker.Check(context.Background())
+	require.Error(t, err)
+}
+
+func TestNewChecker_UnknownType(t *testing.T) {
+	_, err := NewChecker(Target{Name: "x", Type: "smtp"})
+	require.EqualError(t, err, `unknown target type "smtp"`)
+}
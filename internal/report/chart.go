@@ -0,0 +1,131 @@
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math"
+	"time"
+)
+
+// Size of the charts in pixels. They have no text, the report describes their scale.
+const (
+	_chartWidth  = 640
+	_chartHeight = 200
+)
+
+var (
+	_chartBackground = color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}
+	_chartGrid       = color.RGBA{R: 0xe0, G: 0xe0, B: 0xe0, A: 0xff}
+)
+
+// point is a value of a series at a time.
+type point struct {
+	Time  time.Time
+	Value float64
+}
+
+// series is a line of a chart.
+type series struct {
+	Name   string
+	Color  color.RGBA
+	Points []point
+}
+
+// Hex returns the color of the series in CSS notation, for the chart's legend.
+func (s series) Hex() template.CSS {
+	return template.CSS(fmt.Sprintf("#%02x%02x%02x", s.Color.R, s.Color.G, s.Color.B))
+}
+
+// chart is a line chart of the report, drawn as a PNG.
+type chart struct {
+	// Name identifies the chart in the email, e.g. speed for speed.png.
+	Name   string
+	Unit   string
+	Series []series
+	// Max is the value at the top of the chart, the bottom is zero.
+	Max float64
+	PNG []byte
+	// Src is where the report's img tag loads the chart from, set when rendering.
+	Src template.URL
+}
+
+// drawChart draws the series from from to to, with a horizontal grid line at every
+// quarter of the scale.
+func drawChart(name, unit string, from, to time.Time, lines ...series) (*chart, error) {
+	c := &chart{Name: name, Unit: unit, Series: lines}
+	for _, s := range lines {
+		for _, p := range s.Points {
+			c.Max = max(c.Max, p.Value)
+		}
+	}
+	c.Max = niceCeil(c.Max)
+
+	img := image.NewRGBA(image.Rect(0, 0, _chartWidth, _chartHeight))
+	draw.Draw(img, img.Bounds(), image.NewUniform(_chartBackground), image.Point{}, draw.Src)
+	for i := 0; i <= 4; i++ {
+		y := (_chartHeight - 1) * i / 4
+		for x := range _chartWidth {
+			img.SetRGBA(x, y, _chartGrid)
+		}
+	}
+
+	span := to.Sub(from).Seconds()
+	toPixel := func(p point) (float64, float64) {
+		x := float64(_chartWidth-1) * p.Time.Sub(from).Seconds() / span
+		y := float64(_chartHeight-1) * (1 - p.Value/c.Max)
+		return x, y
+	}
+	for _, s := range lines {
+		for i, p := range s.Points {
+			x, y := toPixel(p)
+			if i == 0 {
+				// a lone point is still visible.
+				drawLine(img, x, y, x, y, s.Color)
+				continue
+			}
+			prevX, prevY := toPixel(s.Points[i-1])
+			drawLine(img, prevX, prevY, x, y, s.Color)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("failed to encode the %s chart: %w", name, err)
+	}
+	c.PNG = buf.Bytes()
+	return c, nil
+}
+
+// drawLine draws a line two pixels wide from (x0, y0) to (x1, y1).
+func drawLine(img *image.RGBA, x0, y0, x1, y1 float64, c color.RGBA) {
+	steps := int(math.Max(math.Abs(x1-x0), math.Abs(y1-y0))) + 1
+	for i := 0; i <= steps; i++ {
+		t := float64(i) / float64(steps)
+		x := int(math.Round(x0 + t*(x1-x0)))
+		y := int(math.Round(y0 + t*(y1-y0)))
+		for _, d := range [][2]int{{0, 0}, {1, 0}, {0, 1}, {1, 1}} {
+			if (image.Point{X: x + d[0], Y: y + d[1]}).In(img.Rect) {
+				img.SetRGBA(x+d[0], y+d[1], c)
+			}
+		}
+	}
+}
+
+// niceCeil rounds v up to 1, 2 or 5 times a power of ten, so the scale reads well.
+func niceCeil(v float64) float64 {
+	if v <= 0 {
+		return 1
+	}
+	magnitude := math.Pow(10, math.Floor(math.Log10(v)))
+	for _, step := range []float64{1, 2, 5, 10} {
+		if v <= step*magnitude {
+			return step * magnitude
+		}
+	}
+	return 10 * magnitude
+}
@@ -0,0 +1,173 @@
+package report
+
+import (
+	"context"
+	"fmt"
+	"html/template"
+	"slices"
+	"sync"
+	"time"
+
+	"yanm/internal/monitor"
+
+	"github.com/benbjohnson/clock"
+)
+
+// _heatmapDays is how many days the heatmap shows, two weeks show weekday patterns.
+const _heatmapDays = 14
+
+// HeatmapCell is the median latency of an hour of a day.
+type HeatmapCell struct {
+	Hour     int     `json:"hour"`
+	Pings    int     `json:"pings"`
+	MedianMs float64 `json:"median_ms"`
+	// Color shades the cell from green, the lowest median of the heatmap, to red, the highest.
+	Color template.CSS `json:"-"`
+}
+
+// HeatmapDay is a row of the heatmap.
+type HeatmapDay struct {
+	Date string `json:"date"`
+	// Hours has a cell for every hour of the day, those without a ping have none.
+	Hours []HeatmapCell `json:"hours"`
+}
+
+// HeatmapView is the day-by-hour median latency of the ping checks kept.
+type HeatmapView struct {
+	MinMs float64 `json:"min_ms"`
+	MaxMs float64 `json:"max_ms"`
+	// Days are newest first.
+	Days []HeatmapDay `json:"days"`
+	// Typical is the median latency of each hour over every day.
+	Typical []HeatmapCell `json:"typical"`
+}
+
+// Heatmap keeps the latency of the ping checks of the last days by hour, to show when in
+// the day the latency gets worse.
+type Heatmap struct {
+	mu sync.Mutex
+	// hours holds the latencies of each hour, keyed by its start.
+	hours map[time.Time][]float64
+
+	location *time.Location
+	clock    clock.Clock
+}
+
+// NewHeatmap creates an empty Heatmap, grouping the pings by the hours of the local time.
+func NewHeatmap() *Heatmap {
+	return &Heatmap{
+		hours:    make(map[time.Time][]float64),
+		location: time.Local,
+		clock:    clock.New(),
+	}
+}
+
+// Run records the ping checks of events until ctx is done or events is closed.
+func (h *Heatmap) Run(ctx context.Context, events <-chan monitor.Event) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case e, ok := <-events:
+			if !ok {
+				return
+			}
+			if e.Type == monitor.EventPing && e.Ping != nil {
+				h.Record(e.Time, e.Ping.Latency)
+			}
+		}
+	}
+}
+
+// Record adds the latency of a ping check run at ts.
+func (h *Heatmap) Record(ts time.Time, latency time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	start := h.hourStart(ts)
+	h.hours[start] = append(h.hours[start], float64(latency)/float64(time.Millisecond))
+	h.prune()
+}
+
+// View returns the heatmap of the days ending today.
+func (h *Heatmap) View() HeatmapView {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.prune()
+
+	view := HeatmapView{Typical: make([]HeatmapCell, 0, 24)}
+	byHour := make([][]float64, 24)
+	today := h.dayStart(h.clock.Now())
+	for i := range _heatmapDays {
+		day := today.AddDate(0, 0, -i)
+		row := HeatmapDay{Date: day.Format("Mon 2006-01-02"), Hours: make([]HeatmapCell, 24)}
+		for hour := range 24 {
+			latencies := h.hours[time.Date(day.Year(), day.Month(), day.Day(), hour, 0, 0, 0, h.location)]
+			row.Hours[hour] = h.cell(hour, latencies, &view)
+			byHour[hour] = append(byHour[hour], latencies...)
+		}
+		view.Days = append(view.Days, row)
+	}
+	for hour, latencies := range byHour {
+		view.Typical = append(view.Typical, h.cell(hour, latencies, &view))
+	}
+
+	// the colors are relative to the range of the medians, known once they all are.
+	for i := range view.Days {
+		for j := range view.Days[i].Hours {
+			view.Days[i].Hours[j].Color = view.color(view.Days[i].Hours[j])
+		}
+	}
+	for i := range view.Typical {
+		view.Typical[i].Color = view.color(view.Typical[i])
+	}
+	return view
+}
+
+// cell returns the cell of hour with the median of latencies, widening the range of view.
+func (h *Heatmap) cell(hour int, latencies []float64, view *HeatmapView) HeatmapCell {
+	c := HeatmapCell{Hour: hour, Pings: len(latencies)}
+	if len(latencies) == 0 {
+		return c
+	}
+	sorted := slices.Clone(latencies)
+	slices.Sort(sorted)
+	c.MedianMs = median(sorted)
+	if view.MaxMs == 0 || c.MedianMs < view.MinMs {
+		view.MinMs = c.MedianMs
+	}
+	view.MaxMs = max(view.MaxMs, c.MedianMs)
+	return c
+}
+
+// color shades c by where its median is in the range of the view, grey without pings.
+func (v HeatmapView) color(c HeatmapCell) template.CSS {
+	if c.Pings == 0 {
+		return "#eeeeee"
+	}
+	ratio := 0.0
+	if v.MaxMs > v.MinMs {
+		ratio = (c.MedianMs - v.MinMs) / (v.MaxMs - v.MinMs)
+	}
+	return template.CSS(fmt.Sprintf("hsl(%.0f, 70%%, 60%%)", 120*(1-ratio)))
+}
+
+func (h *Heatmap) hourStart(t time.Time) time.Time {
+	t = t.In(h.location)
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, h.location)
+}
+
+func (h *Heatmap) dayStart(t time.Time) time.Time {
+	t = t.In(h.location)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, h.location)
+}
+
+// prune forgets the hours of the days before those shown.
+func (h *Heatmap) prune() {
+	oldest := h.dayStart(h.clock.Now()).AddDate(0, 0, 1-_heatmapDays)
+	for start := range h.hours {
+		if start.Before(oldest) {
+			delete(h.hours, start)
+		}
+	}
+}
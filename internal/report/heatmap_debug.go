@@ -0,0 +1,61 @@
+package report
+
+import (
+	"html/template"
+	"net/http"
+
+	"yanm/internal/debughttp"
+	"yanm/internal/debughttp/debughandler"
+)
+
+const _heatmapPage = `
+<h1>Latency Heatmap</h1>
+{{ if .MaxMs }}
+<p>
+	The median ping latency of each hour of the last {{ len .Days }} days, from
+	<span style="background: hsl(120, 70%, 60%)">&nbsp;{{ printf "%.1f" .MinMs }} ms&nbsp;</span> to
+	<span style="background: hsl(0, 70%, 60%)">&nbsp;{{ printf "%.1f" .MaxMs }} ms&nbsp;</span>.
+	Hover over an hour for the number of pings behind it.
+</p>
+<table style="border-collapse: collapse; text-align: center; font-size: small">
+	<tr>
+		<th></th>
+		{{ range .Typical }}<th style="padding: 2px 4px">{{ printf "%02d" .Hour }}</th>{{ end }}
+	</tr>
+	{{ range .Days }}
+	<tr>
+		<th style="text-align: left; padding-right: 8px">{{ .Date }}</th>
+		{{ range .Hours }}{{ template "cell" . }}{{ end }}
+	</tr>
+	{{ end }}
+	<tr>
+		<th style="text-align: left; padding-right: 8px">Every day</th>
+		{{ range .Typical }}{{ template "cell" . }}{{ end }}
+	</tr>
+</table>
+{{ else }}
+<p>No ping check succeeded yet.</p>
+{{ end }}
+
+{{ define "cell" }}<td style="background: {{ .Color }}; padding: 2px 4px; border: 1px solid #fff" title="Pings: {{ .Pings }}">{{ if .Pings }}{{ printf "%.0f" .MedianMs }}{{ end }}</td>{{ end }}
+`
+
+var _heatmapPageTemplate = template.Must(template.New("heatmap").Parse(_heatmapPage))
+
+func (h *Heatmap) view(*http.Request) (any, error) {
+	return h.View(), nil
+}
+
+var _ debughttp.PageProvider = (*Heatmap)(nil)
+
+// DebugRoutes returns the latency heatmap page.
+func (h *Heatmap) DebugRoutes() []debughttp.DebugRoute {
+	return []debughttp.DebugRoute{{
+		Path:        "/debug/heatmap",
+		Name:        "Latency Heatmap",
+		Description: "Shows the median ping latency by day and hour, revealing the times of day the latency gets worse.",
+		Handler:     debughandler.NewHTMLProducingHandler(debughandler.NewNegotiatingHandler(h.view, _heatmapPageTemplate)),
+		Group:       "Results",
+		Order:       45,
+	}}
+}
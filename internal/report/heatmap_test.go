@@ -0,0 +1,101 @@
+package report
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"yanm/internal/monitor"
+	"yanm/internal/network"
+
+	"github.com/benbjohnson/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestHeatmap() (*Heatmap, *clock.Mock) {
+	h := NewHeatmap()
+	h.location = time.UTC
+	mockClock := clock.NewMock()
+	mockClock.Set(time.Date(2025, 3, 14, 21, 30, 0, 0, time.UTC))
+	h.clock = mockClock
+	return h, mockClock
+}
+
+func TestHeatmap(t *testing.T) {
+	h, mockClock := newTestHeatmap()
+
+	// every evening at 8pm the latency gets bad.
+	for day := range 3 {
+		date := time.Date(2025, 3, 14-day, 0, 0, 0, 0, time.UTC)
+		h.Record(date.Add(9*time.Hour), 10*time.Millisecond)
+		h.Record(date.Add(9*time.Hour+time.Minute), 14*time.Millisecond)
+		h.Record(date.Add(20*time.Hour), 80*time.Millisecond)
+		h.Record(date.Add(20*time.Hour+time.Minute), 90*time.Millisecond)
+		h.Record(date.Add(20*time.Hour+2*time.Minute), 500*time.Millisecond)
+	}
+	// older than the days shown.
+	h.Record(mockClock.Now().AddDate(0, 0, -20), time.Second)
+
+	view := h.View()
+	require.Len(t, view.Days, _heatmapDays)
+	assert.Equal(t, "Fri 2025-03-14", view.Days[0].Date)
+	assert.Equal(t, HeatmapCell{Hour: 9, Pings: 2, MedianMs: 12, Color: "hsl(120, 70%, 60%)"}, view.Days[0].Hours[9])
+	assert.Equal(t, HeatmapCell{Hour: 20, Pings: 3, MedianMs: 90, Color: "hsl(0, 70%, 60%)"}, view.Days[2].Hours[20])
+	assert.Equal(t, HeatmapCell{Hour: 21, Color: "#eeeeee"}, view.Days[0].Hours[21])
+	assert.Equal(t, 0, view.Days[3].Hours[20].Pings)
+	assert.Equal(t, 12.0, view.MinMs)
+	assert.Equal(t, 90.0, view.MaxMs)
+
+	require.Len(t, view.Typical, 24)
+	assert.Equal(t, 9, view.Typical[20].Pings)
+	assert.Equal(t, 90.0, view.Typical[20].MedianMs)
+
+	// the days move on.
+	mockClock.Add(3 * 24 * time.Hour)
+	view = h.View()
+	assert.Equal(t, "Mon 2025-03-17", view.Days[0].Date)
+	assert.Equal(t, 3, view.Days[3].Hours[20].Pings)
+	assert.Len(t, h.hours, 6)
+}
+
+func TestHeatmap_Run(t *testing.T) {
+	h, mockClock := newTestHeatmap()
+
+	events := make(chan monitor.Event, 2)
+	events <- monitor.Event{Type: monitor.EventPing, Time: mockClock.Now(), Ping: &network.PingResult{Latency: 20 * time.Millisecond}}
+	events <- monitor.Event{Type: monitor.EventSpeedTest, Time: mockClock.Now(), SpeedTest: speedTest(90, 10)}
+	close(events)
+	h.Run(context.Background(), events)
+
+	assert.Equal(t, HeatmapCell{Hour: 21, Pings: 1, MedianMs: 20, Color: "hsl(120, 70%, 60%)"}, h.View().Days[0].Hours[21])
+}
+
+func TestHeatmap_DebugRoutes(t *testing.T) {
+	h, mockClock := newTestHeatmap()
+	routes := h.DebugRoutes()
+	require.Len(t, routes, 1)
+	assert.Equal(t, "/debug/heatmap", routes[0].Path)
+
+	rr := httptest.NewRecorder()
+	routes[0].Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/debug/heatmap", nil))
+	assert.Contains(t, rr.Body.String(), "No ping check succeeded yet.")
+
+	h.Record(mockClock.Now(), 25*time.Millisecond)
+	rr = httptest.NewRecorder()
+	routes[0].Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/debug/heatmap", nil))
+	assert.Contains(t, rr.Body.String(), "Fri 2025-03-14")
+	assert.Contains(t, rr.Body.String(), `title="Pings: 1">25</td>`)
+
+	req := httptest.NewRequest(http.MethodGet, "/debug/heatmap", nil)
+	req.Header.Set("Accept", "application/json")
+	rr = httptest.NewRecorder()
+	routes[0].Handler.ServeHTTP(rr, req)
+	var view HeatmapView
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&view))
+	require.Len(t, view.Days, _heatmapDays)
+	assert.Equal(t, 25.0, view.Days[0].Hours[21].MedianMs)
+}
@@ -0,0 +1,308 @@
+// Package report summarizes the results, against the internet plan subscribed to, by the
+// hour of the day in a latency heatmap and in the reports sent by email or to a webhook
+// every period.
+package report
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"yanm/internal/monitor"
+	"yanm/internal/network"
+
+	"github.com/benbjohnson/clock"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// _maxTests bounds the speed tests kept for the window, a month of tests at the shortest
+// speed test interval fits.
+const _maxTests = 1 << 15
+
+// Directions of a speed test, the direction label of the plan metrics.
+const (
+	Download = "download"
+	Upload   = "upload"
+)
+
+// Plan is the internet plan subscribed to. A speed test complies with it when it reaches
+// MinimumPercent of every speed the plan sets.
+type Plan struct {
+	// Provider names the ISP on the report.
+	Provider     string
+	DownloadMbps float64
+	UploadMbps   float64
+	// MinimumPercent of the plan speeds a speed test has to reach.
+	MinimumPercent float64
+	// Window is the period the compliance is computed over.
+	Window time.Duration
+}
+
+// Enabled reports whether the plan sets a speed to compare the speed tests to.
+func (p Plan) Enabled() bool {
+	return p.DownloadMbps > 0 || p.UploadMbps > 0
+}
+
+// Test is a speed test compared to the plan. The percentages of a speed the plan does not
+// set are zero.
+type Test struct {
+	Time            time.Time `json:"time"`
+	Server          string    `json:"server"`
+	DownloadMbps    float64   `json:"download_mbps"`
+	UploadMbps      float64   `json:"upload_mbps"`
+	DownloadPercent float64   `json:"download_percent"`
+	UploadPercent   float64   `json:"upload_percent"`
+	Compliant       bool      `json:"compliant"`
+}
+
+// SpeedStats summarizes a direction of the speed tests in the window.
+type SpeedStats struct {
+	PlanMbps   float64 `json:"plan_mbps"`
+	MinMbps    float64 `json:"min_mbps"`
+	AvgMbps    float64 `json:"avg_mbps"`
+	MedianMbps float64 `json:"median_mbps"`
+	// AvgPercent and MedianPercent are of the plan speed.
+	AvgPercent    float64 `json:"avg_percent"`
+	MedianPercent float64 `json:"median_percent"`
+	// CompliantPercent is the percentage of the tests reaching the minimum in this direction.
+	CompliantPercent float64 `json:"compliant_percent"`
+}
+
+// Compliance summarizes the speed tests of the window against the plan.
+type Compliance struct {
+	Provider       string    `json:"provider,omitempty"`
+	MinimumPercent float64   `json:"minimum_percent"`
+	From           time.Time `json:"from"`
+	To             time.Time `json:"to"`
+	Tests          int       `json:"tests"`
+	CompliantTests int       `json:"compliant_tests"`
+	// CompliantPercent is the percentage of the tests complying with the plan.
+	CompliantPercent float64     `json:"compliant_percent"`
+	Download         *SpeedStats `json:"download,omitempty"`
+	Upload           *SpeedStats `json:"upload,omitempty"`
+	// Failed lists the tests that did not comply, newest first.
+	Failed []Test `json:"failed"`
+}
+
+// Tracker compares every speed test to the plan, keeping those of the window.
+type Tracker struct {
+	backend string
+
+	mu    sync.Mutex
+	plan  Plan
+	tests []Test // oldest first
+
+	latestPercent    *prometheus.GaugeVec
+	compliantPercent *prometheus.GaugeVec
+
+	clock clock.Clock
+}
+
+// NewTracker creates a Tracker for plan, exporting its metrics named under namespace, yanm
+// when empty, to registerer when not nil. When backends are compared, only the speed tests
+// of backend are compared to the plan.
+func NewTracker(plan Plan, backend, namespace string, registerer prometheus.Registerer) (*Tracker, error) {
+	namespace = cmp.Or(namespace, "yanm")
+	t := &Tracker{
+		backend: backend,
+		plan:    plan,
+		latestPercent: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "plan",
+			Name:      "speed_percent",
+			Help:      "Speed measured by the most recent speed test as a percentage of the plan speed, partitioned by direction.",
+		}, []string{"direction"}),
+		compliantPercent: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "plan",
+			Name:      "compliant_percent",
+			Help:      "Percentage of the speed tests of the plan window reaching the plan's minimum speed, partitioned by direction.",
+		}, []string{"direction"}),
+		clock: clock.New(),
+	}
+	if registerer != nil {
+		for _, c := range []prometheus.Collector{t.latestPercent, t.compliantPercent} {
+			if err := registerer.Register(c); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return t, nil
+}
+
+// Plan returns the plan the speed tests are compared to.
+func (t *Tracker) Plan() Plan {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.plan
+}
+
+// SetPlan compares the speed tests to plan from now on, including those already kept.
+func (t *Tracker) SetPlan(plan Plan) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.plan = plan
+	for i := range t.tests {
+		t.tests[i] = t.plan.compare(t.tests[i].Time, t.tests[i].Server, t.tests[i].DownloadMbps, t.tests[i].UploadMbps)
+	}
+	t.latestPercent.Reset()
+	if len(t.tests) > 0 {
+		t.setLatest(t.tests[len(t.tests)-1])
+	}
+	t.updateCompliance()
+}
+
+// Run records the speed tests of events until ctx is done or events is closed.
+func (t *Tracker) Run(ctx context.Context, events <-chan monitor.Event) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case e, ok := <-events:
+			if !ok {
+				return
+			}
+			if e.Type == monitor.EventSpeedTest && e.SpeedTest != nil && ofBackend(e, t.backend) {
+				t.Record(e.Time, e.SpeedTest)
+			}
+		}
+	}
+}
+
+// ofBackend reports whether the speed test of e was run by backend. The backend is only set
+// on events when comparing backends, whose other speed tests would skew the statistics.
+func ofBackend(e monitor.Event, backend string) bool {
+	return e.Backend == "" || e.Backend == backend
+}
+
+// Record compares the speed test result received at ts to the plan.
+func (t *Tracker) Record(ts time.Time, result *network.PerformanceResult) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	test := t.plan.compare(ts, result.TargetName, result.DownloadSpeedMbps, result.UploadSpeedMbps)
+	t.tests = append(t.tests, test)
+	if len(t.tests) > _maxTests {
+		t.tests = slices.Delete(t.tests, 0, len(t.tests)-_maxTests)
+	}
+	t.setLatest(test)
+	t.updateCompliance()
+}
+
+// Compliance summarizes the speed tests of the window ending now.
+func (t *Tracker) Compliance() Compliance {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.compliance()
+}
+
+func (t *Tracker) setLatest(test Test) {
+	if t.plan.DownloadMbps > 0 {
+		t.latestPercent.WithLabelValues(Download).Set(test.DownloadPercent)
+	}
+	if t.plan.UploadMbps > 0 {
+		t.latestPercent.WithLabelValues(Upload).Set(test.UploadPercent)
+	}
+}
+
+func (t *Tracker) updateCompliance() {
+	t.compliantPercent.Reset()
+	c := t.compliance()
+	if c.Download != nil {
+		t.compliantPercent.WithLabelValues(Download).Set(c.Download.CompliantPercent)
+	}
+	if c.Upload != nil {
+		t.compliantPercent.WithLabelValues(Upload).Set(c.Upload.CompliantPercent)
+	}
+}
+
+// compliance summarizes the tests of the window, dropping the older ones.
+func (t *Tracker) compliance() Compliance {
+	now := t.clock.Now()
+	from := now.Add(-t.plan.Window)
+	first, _ := slices.BinarySearchFunc(t.tests, from, func(test Test, from time.Time) int {
+		return test.Time.Compare(from)
+	})
+	t.tests = slices.Delete(t.tests, 0, first)
+	return t.plan.summarize(t.tests, from, now)
+}
+
+// compare compares a speed test to the plan.
+func (p Plan) compare(ts time.Time, server string, downloadMbps, uploadMbps float64) Test {
+	test := Test{Time: ts, Server: server, DownloadMbps: downloadMbps, UploadMbps: uploadMbps, Compliant: true}
+	if p.DownloadMbps > 0 {
+		test.DownloadPercent = 100 * downloadMbps / p.DownloadMbps
+		test.Compliant = test.DownloadPercent >= p.MinimumPercent
+	}
+	if p.UploadMbps > 0 {
+		test.UploadPercent = 100 * uploadMbps / p.UploadMbps
+		test.Compliant = test.Compliant && test.UploadPercent >= p.MinimumPercent
+	}
+	return test
+}
+
+// summarize summarizes the tests run from from to to, oldest first, against the plan.
+func (p Plan) summarize(tests []Test, from, to time.Time) Compliance {
+	c := Compliance{
+		Provider:       p.Provider,
+		MinimumPercent: p.MinimumPercent,
+		From:           from,
+		To:             to,
+		Tests:          len(tests),
+		Failed:         []Test{},
+	}
+	if !p.Enabled() || len(tests) == 0 {
+		return c
+	}
+	for _, test := range slices.Backward(tests) {
+		if test.Compliant {
+			c.CompliantTests++
+		} else {
+			c.Failed = append(c.Failed, test)
+		}
+	}
+	c.CompliantPercent = 100 * float64(c.CompliantTests) / float64(c.Tests)
+	if p.DownloadMbps > 0 {
+		c.Download = p.speedStats(tests, p.DownloadMbps, func(test Test) float64 { return test.DownloadMbps })
+	}
+	if p.UploadMbps > 0 {
+		c.Upload = p.speedStats(tests, p.UploadMbps, func(test Test) float64 { return test.UploadMbps })
+	}
+	return c
+}
+
+func (p Plan) speedStats(tests []Test, planMbps float64, speed func(Test) float64) *SpeedStats {
+	speeds := make([]float64, len(tests))
+	var sum float64
+	compliant := 0
+	for i, test := range tests {
+		speeds[i] = speed(test)
+		sum += speeds[i]
+		if 100*speeds[i]/planMbps >= p.MinimumPercent {
+			compliant++
+		}
+	}
+	slices.Sort(speeds)
+
+	stats := &SpeedStats{
+		PlanMbps:         planMbps,
+		MinMbps:          speeds[0],
+		AvgMbps:          sum / float64(len(speeds)),
+		MedianMbps:       median(speeds),
+		CompliantPercent: 100 * float64(compliant) / float64(len(speeds)),
+	}
+	stats.AvgPercent = 100 * stats.AvgMbps / planMbps
+	stats.MedianPercent = 100 * stats.MedianMbps / planMbps
+	return stats
+}
+
+// median returns the median of the sorted, non-empty values.
+func median(sorted []float64) float64 {
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 1 {
+		return sorted[mid]
+	}
+	return (sorted[mid-1] + sorted[mid]) / 2
+}
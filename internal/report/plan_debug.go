@@ -0,0 +1,106 @@
+package report
+
+import (
+	"html/template"
+	"net/http"
+
+	"yanm/internal/debughttp"
+	"yanm/internal/debughttp/debughandler"
+)
+
+// The page is meant to be printed or saved and sent to the ISP, so it describes the
+// measurements rather than linking to other pages.
+const _planPage = `
+<h1>Internet Plan Compliance Report</h1>
+{{ if not .Enabled }}
+<p>No plan is configured, set <code>plan.download_mbps</code> or <code>plan.upload_mbps</code> to compare the speed tests to it.</p>
+{{ else }}{{ with .Compliance }}
+<p>
+	{{ with .Provider }}Provider: {{ . }}<br>{{ end }}
+	Period: {{ .From.Format "2006-01-02 15:04 MST" }} to {{ .To.Format "2006-01-02 15:04 MST" }}<br>
+	A speed test complies with the plan when it reaches {{ printf "%.0f" .MinimumPercent }}% of the subscribed speeds.
+</p>
+<p><strong>{{ .CompliantTests }} of {{ .Tests }} speed tests ({{ printf "%.1f" .CompliantPercent }}%) complied with the plan.</strong></p>
+<table>
+	<tr>
+		<th></th>
+		<th>Plan</th>
+		<th>Minimum</th>
+		<th>Average</th>
+		<th>Median</th>
+		<th>Tests Reaching the Minimum</th>
+	</tr>
+	{{ with .Download }}
+	<tr>
+		<td>Download</td>
+		<td>{{ printf "%.0f" .PlanMbps }} Mbps</td>
+		<td>{{ printf "%.1f" .MinMbps }} Mbps</td>
+		<td>{{ printf "%.1f" .AvgMbps }} Mbps ({{ printf "%.1f" .AvgPercent }}%)</td>
+		<td>{{ printf "%.1f" .MedianMbps }} Mbps ({{ printf "%.1f" .MedianPercent }}%)</td>
+		<td>{{ printf "%.1f" .CompliantPercent }}%</td>
+	</tr>
+	{{ end }}
+	{{ with .Upload }}
+	<tr>
+		<td>Upload</td>
+		<td>{{ printf "%.0f" .PlanMbps }} Mbps</td>
+		<td>{{ printf "%.1f" .MinMbps }} Mbps</td>
+		<td>{{ printf "%.1f" .AvgMbps }} Mbps ({{ printf "%.1f" .AvgPercent }}%)</td>
+		<td>{{ printf "%.1f" .MedianMbps }} Mbps ({{ printf "%.1f" .MedianPercent }}%)</td>
+		<td>{{ printf "%.1f" .CompliantPercent }}%</td>
+	</tr>
+	{{ end }}
+</table>
+
+<h2>Speed Tests Below the Plan</h2>
+<table>
+	<tr>
+		<th>Time</th>
+		<th>Server</th>
+		<th>Download</th>
+		<th>Upload</th>
+	</tr>
+	{{ range .Failed }}
+	<tr>
+		<td>{{ .Time.Format "2006-01-02 15:04:05 MST" }}</td>
+		<td>{{ .Server }}</td>
+		<td>{{ printf "%.1f" .DownloadMbps }} Mbps{{ if .DownloadPercent }} ({{ printf "%.1f" .DownloadPercent }}%){{ end }}</td>
+		<td>{{ printf "%.1f" .UploadMbps }} Mbps{{ if .UploadPercent }} ({{ printf "%.1f" .UploadPercent }}%){{ end }}</td>
+	</tr>
+	{{ else }}
+	<tr><td colspan="4">Every speed test complied with the plan.</td></tr>
+	{{ end }}
+</table>
+{{ end }}{{ end }}
+`
+
+var _planPageTemplate = template.Must(template.New("plan").Parse(_planPage))
+
+// planView is the data behind both views of the plan page.
+type planView struct {
+	Enabled    bool       `json:"enabled"`
+	Compliance Compliance `json:"compliance"`
+}
+
+func (t *Tracker) view(*http.Request) (any, error) {
+	return planView{Enabled: t.Plan().Enabled(), Compliance: t.Compliance()}, nil
+}
+
+var _ debughttp.PageProvider = (*Tracker)(nil)
+
+// DebugRoutes returns the compliance report page, listed when a plan is configured.
+func (t *Tracker) DebugRoutes() []debughttp.DebugRoute {
+	visibility := debughttp.NavDefault
+	if !t.Plan().Enabled() {
+		visibility = debughttp.NavExclude
+	}
+	return []debughttp.DebugRoute{{
+		Path:        "/debug/plan",
+		Name:        "Plan Compliance",
+		Description: "Reports how the speed tests compare to the internet plan subscribed to.",
+		Handler:     debughandler.NewHTMLProducingHandler(debughandler.NewNegotiatingHandler(t.view, _planPageTemplate)),
+		Visibility:  visibility,
+		Group:       "Results",
+		Order:       40,
+	}}
+}
@@ -0,0 +1,137 @@
+package report
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"yanm/internal/monitor"
+	"yanm/internal/network"
+
+	"github.com/benbjohnson/clock"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestTracker(t *testing.T, plan Plan) (*Tracker, *clock.Mock, *prometheus.Registry) {
+	t.Helper()
+	reg := prometheus.NewRegistry()
+	tracker, err := NewTracker(plan, network.BackendOokla, "", reg)
+	require.NoError(t, err)
+	mockClock := clock.NewMock()
+	mockClock.Set(time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC))
+	tracker.clock = mockClock
+	return tracker, mockClock, reg
+}
+
+func speedTest(download, upload float64) *network.PerformanceResult {
+	return &network.PerformanceResult{TargetName: "Example ISP", DownloadSpeedMbps: download, UploadSpeedMbps: upload}
+}
+
+func TestTracker(t *testing.T) {
+	tracker, mockClock, reg := newTestTracker(t, Plan{
+		Provider: "Example ISP", DownloadMbps: 500, UploadMbps: 50, MinimumPercent: 80, Window: 24 * time.Hour,
+	})
+
+	tracker.Record(mockClock.Now(), speedTest(450, 48))
+	mockClock.Add(time.Hour)
+	tracker.Record(mockClock.Now(), speedTest(300, 45)) // download below 80%
+	mockClock.Add(time.Hour)
+	tracker.Record(mockClock.Now(), speedTest(480, 30)) // upload below 80%
+	mockClock.Add(time.Hour)
+	tracker.Record(mockClock.Now(), speedTest(500, 50))
+
+	c := tracker.Compliance()
+	assert.Equal(t, 4, c.Tests)
+	assert.Equal(t, 2, c.CompliantTests)
+	assert.Equal(t, 50.0, c.CompliantPercent)
+	assert.Equal(t, &SpeedStats{
+		PlanMbps: 500, MinMbps: 300, AvgMbps: 432.5, MedianMbps: 465,
+		AvgPercent: 86.5, MedianPercent: 93, CompliantPercent: 75,
+	}, c.Download)
+	assert.Equal(t, 75.0, c.Upload.CompliantPercent)
+	require.Len(t, c.Failed, 2)
+	assert.Equal(t, 30.0, c.Failed[0].UploadMbps, "newest first")
+	assert.Equal(t, 60.0, c.Failed[1].DownloadPercent)
+
+	expected := `
+# HELP yanm_plan_compliant_percent Percentage of the speed tests of the plan window reaching the plan's minimum speed, partitioned by direction.
+# TYPE yanm_plan_compliant_percent gauge
+yanm_plan_compliant_percent{direction="download"} 75
+yanm_plan_compliant_percent{direction="upload"} 75
+# HELP yanm_plan_speed_percent Speed measured by the most recent speed test as a percentage of the plan speed, partitioned by direction.
+# TYPE yanm_plan_speed_percent gauge
+yanm_plan_speed_percent{direction="download"} 100
+yanm_plan_speed_percent{direction="upload"} 100
+`
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(expected)))
+
+	// the tests leave the window a day after they ran.
+	mockClock.Add(23 * time.Hour)
+	c = tracker.Compliance()
+	assert.Equal(t, 2, c.Tests)
+	assert.Len(t, c.Failed, 1)
+
+	// a new plan applies to the tests already kept, and only exports the speeds it sets.
+	tracker.SetPlan(Plan{DownloadMbps: 1000, MinimumPercent: 80, Window: 24 * time.Hour})
+	c = tracker.Compliance()
+	assert.Equal(t, 0, c.CompliantTests)
+	assert.Nil(t, c.Upload)
+	expected = `
+# HELP yanm_plan_speed_percent Speed measured by the most recent speed test as a percentage of the plan speed, partitioned by direction.
+# TYPE yanm_plan_speed_percent gauge
+yanm_plan_speed_percent{direction="download"} 50
+`
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(expected), "yanm_plan_speed_percent"))
+}
+
+func TestTracker_Run(t *testing.T) {
+	tracker, mockClock, _ := newTestTracker(t, Plan{DownloadMbps: 100, MinimumPercent: 80, Window: time.Hour})
+
+	events := make(chan monitor.Event, 3)
+	events <- monitor.Event{Type: monitor.EventPing, Time: mockClock.Now(), Ping: &network.PingResult{}}
+	events <- monitor.Event{Type: monitor.EventSpeedTest, Time: mockClock.Now(), Backend: network.BackendOokla, SpeedTest: speedTest(90, 10)}
+	// the speed test of the compared backend is not held against the plan.
+	events <- monitor.Event{Type: monitor.EventSpeedTest, Time: mockClock.Now(), Backend: network.BackendLibreSpeed, SpeedTest: speedTest(20, 10)}
+	close(events)
+	tracker.Run(context.Background(), events)
+
+	c := tracker.Compliance()
+	assert.Equal(t, 1, c.Tests)
+	assert.Equal(t, 1, c.CompliantTests)
+}
+
+func TestTracker_DebugRoutes(t *testing.T) {
+	tracker, mockClock, _ := newTestTracker(t, Plan{})
+	routes := tracker.DebugRoutes()
+	require.Len(t, routes, 1)
+	assert.Equal(t, "/debug/plan", routes[0].Path)
+
+	rr := httptest.NewRecorder()
+	routes[0].Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/debug/plan", nil))
+	assert.Contains(t, rr.Body.String(), "No plan is configured")
+
+	tracker.SetPlan(Plan{Provider: "Example ISP", DownloadMbps: 100, MinimumPercent: 80, Window: time.Hour})
+	tracker.Record(mockClock.Now(), speedTest(50, 10))
+
+	rr = httptest.NewRecorder()
+	routes[0].Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/debug/plan", nil))
+	assert.Contains(t, rr.Body.String(), "Provider: Example ISP")
+	assert.Contains(t, rr.Body.String(), "0 of 1 speed tests (0.0%) complied with the plan.")
+
+	req := httptest.NewRequest(http.MethodGet, "/debug/plan", nil)
+	req.Header.Set("Accept", "application/json")
+	rr = httptest.NewRecorder()
+	routes[0].Handler.ServeHTTP(rr, req)
+	var view planView
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&view))
+	assert.True(t, view.Enabled)
+	require.Len(t, view.Compliance.Failed, 1)
+	assert.Equal(t, 50.0, view.Compliance.Failed[0].DownloadPercent)
+}
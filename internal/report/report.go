@@ -0,0 +1,510 @@
+package report
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"image/color"
+	"log/slog"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/http"
+	"net/smtp"
+	"net/textproto"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"yanm/internal/monitor"
+
+	"github.com/benbjohnson/clock"
+)
+
+// Periods a report can cover, it is sent every period.
+const (
+	Daily  = 24 * time.Hour
+	Weekly = 7 * Daily
+)
+
+// _contentIDDomain ends the Content-ID of the charts attached to the email.
+const _contentIDDomain = "yanm"
+
+// _webhookTimeout bounds posting a report to the webhook.
+const _webhookTimeout = 30 * time.Second
+
+var (
+	_colorDownload = color.RGBA{R: 0x2c, G: 0xa0, B: 0x2c, A: 0xff}
+	_colorUpload   = color.RGBA{R: 0x94, G: 0x67, B: 0xbd, A: 0xff}
+	_colorAverage  = color.RGBA{R: 0x1f, G: 0x77, B: 0xb4, A: 0xff}
+	_colorMax      = color.RGBA{R: 0xff, G: 0x7f, B: 0x0e, A: 0xff}
+)
+
+// Schedule picks when the reports are sent.
+type Schedule struct {
+	// Period is how often a report is sent and how long it covers, Daily or Weekly.
+	Period time.Duration
+	// Weekday is the day weekly reports are sent on.
+	Weekday time.Weekday
+	// At is the time of day reports are sent at, from midnight in Location.
+	At       time.Duration
+	Location *time.Location
+}
+
+// Next returns when the first report after t is due.
+func (s Schedule) Next(t time.Time) time.Time {
+	loc := s.Location
+	if loc == nil {
+		loc = time.Local
+	}
+	t = t.In(loc)
+	hour, minute := int(s.At/time.Hour), int(s.At%time.Hour/time.Minute)
+	// days are added to the date rather than the time, so DST changes keep the time of day.
+	for day := 0; ; day++ {
+		next := time.Date(t.Year(), t.Month(), t.Day()+day, hour, minute, 0, 0, loc)
+		if next.After(t) && (s.Period != Weekly || next.Weekday() == s.Weekday) {
+			return next
+		}
+	}
+}
+
+// EmailConfig sends the reports through an SMTP server, using STARTTLS when it offers it.
+type EmailConfig struct {
+	// SMTPAddress is the server's host:port, no email is sent when empty.
+	SMTPAddress string
+	// Username and Password authenticate with PLAIN auth when set.
+	Username string
+	Password string
+	From     string
+	To       []string
+}
+
+// Config configures the scheduled reports, sent by email, to a webhook or both.
+type Config struct {
+	Schedule   Schedule
+	Email      EmailConfig
+	WebhookURL string
+}
+
+// Stats summarizes a measurement over the period.
+type Stats struct {
+	Min float64 `json:"min"`
+	Avg float64 `json:"avg"`
+	Max float64 `json:"max"`
+}
+
+// Summary is a report of the results of a period.
+type Summary struct {
+	Title      string    `json:"title"`
+	From       time.Time `json:"from"`
+	To         time.Time `json:"to"`
+	SpeedTests int       `json:"speed_tests"`
+	// Download and Upload are in Mbps, nil when no speed test ran.
+	Download *Stats `json:"download_mbps,omitempty"`
+	Upload   *Stats `json:"upload_mbps,omitempty"`
+	Pings    int    `json:"pings"`
+	// Latency of the latency checks in milliseconds, nil when none succeeded.
+	Latency      *Stats `json:"latency_ms,omitempty"`
+	FailedChecks int    `json:"failed_checks"`
+	// Plan is the compliance with the plan over the period, nil when no plan is configured.
+	Plan *Compliance `json:"plan,omitempty"`
+
+	SpeedChart   *chart `json:"-"`
+	LatencyChart *chart `json:"-"`
+}
+
+// charts returns the charts of the report.
+func (s *Summary) charts() []*chart {
+	return slices.DeleteFunc([]*chart{s.SpeedChart, s.LatencyChart}, func(c *chart) bool { return c == nil })
+}
+
+// speedSample is a speed test of the period.
+type speedSample struct {
+	Time         time.Time
+	Server       string
+	DownloadMbps float64
+	UploadMbps   float64
+}
+
+// hourSample aggregates the latency checks of an hour, a week of them would not fit.
+type hourSample struct {
+	Start    time.Time
+	Pings    int
+	SumMs    float64
+	MinMs    float64
+	MaxMs    float64
+	Failures int
+}
+
+// Reporter collects the results of the monitor and sends a report of them every period.
+type Reporter struct {
+	logger  *slog.Logger
+	cfg     Config
+	backend string
+	// plan is the plan whose compliance is reported, may be nil.
+	plan *Tracker
+
+	mu    sync.Mutex
+	tests []speedSample // oldest first
+	hours []hourSample  // oldest first
+
+	client   *http.Client
+	sendMail func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+	clock    clock.Clock
+}
+
+// NewReporter creates a Reporter sending the reports configured by cfg, reporting the
+// compliance with the plan of plan when it is not nil. When backends are compared, only
+// the speed tests of backend are reported.
+func NewReporter(logger *slog.Logger, cfg Config, backend string, plan *Tracker) *Reporter {
+	return &Reporter{
+		logger:   logger.With("component", "reporter"),
+		cfg:      cfg,
+		backend:  backend,
+		plan:     plan,
+		client:   &http.Client{Timeout: _webhookTimeout},
+		sendMail: smtp.SendMail,
+		clock:    clock.New(),
+	}
+}
+
+// Run collects the results of events and sends the reports when they are due, until ctx
+// is done.
+func (r *Reporter) Run(ctx context.Context, events <-chan monitor.Event) {
+	next := r.cfg.Schedule.Next(r.clock.Now())
+	timer := r.clock.Timer(next.Sub(r.clock.Now()))
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case e, ok := <-events:
+			if !ok {
+				// the reports due still cover what was collected.
+				events = nil
+				continue
+			}
+			r.record(e)
+		case <-timer.C:
+			if err := r.Send(ctx, next); err != nil {
+				r.logger.ErrorContext(ctx, "Failed to send report", "error", err)
+			}
+			next = r.cfg.Schedule.Next(next)
+			timer.Reset(next.Sub(r.clock.Now()))
+		}
+	}
+}
+
+func (r *Reporter) record(e monitor.Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	switch e.Type {
+	case monitor.EventSpeedTest:
+		if e.SpeedTest == nil || !ofBackend(e, r.backend) {
+			return
+		}
+		r.tests = append(r.tests, speedSample{
+			Time:         e.Time,
+			Server:       e.SpeedTest.TargetName,
+			DownloadMbps: e.SpeedTest.DownloadSpeedMbps,
+			UploadMbps:   e.SpeedTest.UploadSpeedMbps,
+		})
+	case monitor.EventPing:
+		if e.Ping == nil {
+			return
+		}
+		ms := float64(e.Ping.Latency) / float64(time.Millisecond)
+		h := r.hour(e.Time)
+		if h.Pings == 0 {
+			h.MinMs, h.MaxMs = ms, ms
+		}
+		h.Pings++
+		h.SumMs += ms
+		h.MinMs, h.MaxMs = min(h.MinMs, ms), max(h.MaxMs, ms)
+	case monitor.EventCheckFailed:
+		r.hour(e.Time).Failures++
+	default:
+		return
+	}
+	r.prune(e.Time)
+}
+
+// hour returns the sample of the hour of t, adding it if needed.
+func (r *Reporter) hour(t time.Time) *hourSample {
+	start := t.Truncate(time.Hour)
+	if n := len(r.hours); n > 0 && r.hours[n-1].Start.Equal(start) {
+		return &r.hours[n-1]
+	}
+	r.hours = append(r.hours, hourSample{Start: start})
+	return &r.hours[len(r.hours)-1]
+}
+
+// prune forgets what is older than the report due next needs.
+func (r *Reporter) prune(now time.Time) {
+	oldest := now.Add(-r.cfg.Schedule.Period - time.Hour)
+	r.tests = slices.DeleteFunc(r.tests, func(s speedSample) bool { return s.Time.Before(oldest) })
+	r.hours = slices.DeleteFunc(r.hours, func(s hourSample) bool { return s.Start.Before(oldest) })
+}
+
+// Summary reports the results of the period ending at to.
+func (r *Reporter) Summary(to time.Time) (*Summary, error) {
+	from := to.Add(-r.cfg.Schedule.Period)
+	s := &Summary{Title: "Network Report", From: from, To: to}
+	switch r.cfg.Schedule.Period {
+	case Daily:
+		s.Title = "Daily Network Report"
+	case Weekly:
+		s.Title = "Weekly Network Report"
+	}
+
+	r.mu.Lock()
+	var (
+		tests            []speedSample
+		hours            []hourSample
+		download, upload []point
+		avg, peak        []point
+	)
+	for _, t := range r.tests {
+		if !t.Time.Before(from) && !t.Time.After(to) {
+			tests = append(tests, t)
+		}
+	}
+	for _, h := range r.hours {
+		if !h.Start.Before(from.Truncate(time.Hour)) && h.Start.Before(to) {
+			hours = append(hours, h)
+		}
+	}
+	r.mu.Unlock()
+
+	if len(tests) > 0 {
+		s.SpeedTests = len(tests)
+		s.Download, s.Upload = &Stats{Min: tests[0].DownloadMbps}, &Stats{Min: tests[0].UploadMbps}
+		for _, t := range tests {
+			s.Download.add(t.DownloadMbps)
+			s.Upload.add(t.UploadMbps)
+			download = append(download, point{Time: t.Time, Value: t.DownloadMbps})
+			upload = append(upload, point{Time: t.Time, Value: t.UploadMbps})
+		}
+		s.Download.Avg /= float64(len(tests))
+		s.Upload.Avg /= float64(len(tests))
+	}
+
+	var sumMs float64
+	for _, h := range hours {
+		s.FailedChecks += h.Failures
+		if h.Pings == 0 {
+			continue
+		}
+		if s.Latency == nil {
+			s.Latency = &Stats{Min: h.MinMs}
+		}
+		s.Pings += h.Pings
+		sumMs += h.SumMs
+		s.Latency.Min, s.Latency.Max = min(s.Latency.Min, h.MinMs), max(s.Latency.Max, h.MaxMs)
+		// charted at the middle of the hour.
+		mid := h.Start.Add(30 * time.Minute)
+		avg = append(avg, point{Time: mid, Value: h.SumMs / float64(h.Pings)})
+		peak = append(peak, point{Time: mid, Value: h.MaxMs})
+	}
+	if s.Latency != nil {
+		s.Latency.Avg = sumMs / float64(s.Pings)
+	}
+
+	if r.plan != nil {
+		if plan := r.plan.Plan(); plan.Enabled() {
+			compared := make([]Test, len(tests))
+			for i, t := range tests {
+				compared[i] = plan.compare(t.Time, t.Server, t.DownloadMbps, t.UploadMbps)
+			}
+			compliance := plan.summarize(compared, from, to)
+			s.Plan = &compliance
+		}
+	}
+
+	var err error
+	if len(tests) > 0 {
+		s.SpeedChart, err = drawChart("speed", "Mbps", from, to,
+			series{Name: "Download", Color: _colorDownload, Points: download},
+			series{Name: "Upload", Color: _colorUpload, Points: upload})
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(avg) > 0 {
+		s.LatencyChart, err = drawChart("latency", "ms", from, to,
+			series{Name: "Hourly average", Color: _colorAverage, Points: avg},
+			series{Name: "Hourly maximum", Color: _colorMax, Points: peak})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// add adds v to the minimum, maximum and the sum held in Avg.
+func (s *Stats) add(v float64) {
+	s.Min, s.Max = min(s.Min, v), max(s.Max, v)
+	s.Avg += v
+}
+
+// Send sends the report of the period ending at to, by email and to the webhook.
+func (r *Reporter) Send(ctx context.Context, to time.Time) error {
+	s, err := r.Summary(to)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	if r.cfg.Email.SMTPAddress != "" {
+		if err := r.sendEmail(s); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if r.cfg.WebhookURL != "" {
+		if err := r.postWebhook(ctx, s); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+	r.logger.InfoContext(ctx, "Sent report", "from", s.From, "to", s.To, "speedTests", s.SpeedTests, "pings", s.Pings)
+	return nil
+}
+
+// subject returns the subject of the email and webhook post.
+func (s *Summary) subject() string {
+	return fmt.Sprintf("YANM %s, %s to %s", strings.ToLower(s.Title),
+		s.From.Format("Jan 2 15:04"), s.To.Format("Jan 2 15:04"))
+}
+
+// render executes the named report template with the charts loaded from src.
+func (s *Summary) render(name string, src func(*chart) template.URL) ([]byte, error) {
+	for _, c := range s.charts() {
+		c.Src = src(c)
+	}
+	var buf bytes.Buffer
+	if err := _reportTemplate.ExecuteTemplate(&buf, name, s); err != nil {
+		return nil, fmt.Errorf("failed to render the report: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// dataURI embeds the chart in the document, for the webhook and the debug page.
+func dataURI(c *chart) template.URL {
+	return template.URL("data:image/png;base64," + base64.StdEncoding.EncodeToString(c.PNG))
+}
+
+// contentID refers to the chart attached to the email.
+func contentID(c *chart) string {
+	return c.Name + "@" + _contentIDDomain
+}
+
+// sendEmail sends the report as an HTML email, with the charts attached inline.
+func (r *Reporter) sendEmail(s *Summary) error {
+	cfg := r.cfg.Email
+	html, err := s.render("email", func(c *chart) template.URL { return template.URL("cid:" + contentID(c)) })
+	if err != nil {
+		return err
+	}
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	part, err := mw.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {"text/html; charset=utf-8"},
+		"Content-Transfer-Encoding": {"quoted-printable"},
+	})
+	if err != nil {
+		return err
+	}
+	qp := quotedprintable.NewWriter(part)
+	if _, err := qp.Write(html); err != nil {
+		return err
+	}
+	if err := qp.Close(); err != nil {
+		return err
+	}
+	for _, c := range s.charts() {
+		part, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {"image/png"},
+			"Content-Transfer-Encoding": {"base64"},
+			"Content-ID":                {"<" + contentID(c) + ">"},
+			"Content-Disposition":       {`inline; filename="` + c.Name + `.png"`},
+		})
+		if err != nil {
+			return err
+		}
+		encoded := base64.StdEncoding.EncodeToString(c.PNG)
+		// lines of encoded data are limited to 76 characters.
+		for len(encoded) > 76 {
+			fmt.Fprintf(part, "%s\r\n", encoded[:76])
+			encoded = encoded[76:]
+		}
+		fmt.Fprintf(part, "%s\r\n", encoded)
+	}
+	if err := mw.Close(); err != nil {
+		return err
+	}
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", cfg.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(cfg.To, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", s.subject()))
+	fmt.Fprintf(&msg, "Date: %s\r\n", r.clock.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&msg, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&msg, "Content-Type: multipart/related; boundary=%s\r\n\r\n", mw.Boundary())
+	msg.Write(body.Bytes())
+
+	var auth smtp.Auth
+	if cfg.Username != "" {
+		host, _, _ := net.SplitHostPort(cfg.SMTPAddress)
+		auth = smtp.PlainAuth("", cfg.Username, cfg.Password, host)
+	}
+	if err := r.sendMail(cfg.SMTPAddress, auth, cfg.From, cfg.To, msg.Bytes()); err != nil {
+		return fmt.Errorf("failed to email the report: %w", err)
+	}
+	return nil
+}
+
+// WebhookPayload is the JSON object posted to the webhook.
+type WebhookPayload struct {
+	Subject string   `json:"subject"`
+	Summary *Summary `json:"summary"`
+	// HTML is the report as emailed, with the charts embedded as data URIs.
+	HTML string `json:"html"`
+}
+
+// postWebhook posts the report to the webhook.
+func (r *Reporter) postWebhook(ctx context.Context, s *Summary) error {
+	html, err := s.render("email", dataURI)
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(WebhookPayload{Subject: s.subject(), Summary: s, HTML: string(html)})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.cfg.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to post the report: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post the report: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("failed to post the report: webhook returned %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package report
+
+import (
+	"html/template"
+	"net/http"
+
+	"yanm/internal/debughttp"
+	"yanm/internal/debughttp/debughandler"
+)
+
+// _reportBody is the report, as emailed and previewed on the debug page. Email clients
+// ignore stylesheets, so it is styled inline.
+const _reportBody = `
+<h1>{{ .Title }}</h1>
+<p>{{ .From.Format "Mon Jan 2 15:04" }} to {{ .To.Format "Mon Jan 2 15:04 MST" }}</p>
+
+<h2>Speed</h2>
+{{ if .SpeedTests }}
+<p>{{ .SpeedTests }} speed tests ran.</p>
+<table style="border-collapse: collapse; text-align: right">
+	<tr><th></th><th style="padding: 2px 12px">Min</th><th style="padding: 2px 12px">Average</th><th style="padding: 2px 12px">Max</th></tr>
+	{{ with .Download }}<tr><th style="text-align: left">Download</th><td style="padding: 2px 12px">{{ printf "%.1f" .Min }} Mbps</td><td style="padding: 2px 12px">{{ printf "%.1f" .Avg }} Mbps</td><td style="padding: 2px 12px">{{ printf "%.1f" .Max }} Mbps</td></tr>{{ end }}
+	{{ with .Upload }}<tr><th style="text-align: left">Upload</th><td style="padding: 2px 12px">{{ printf "%.1f" .Min }} Mbps</td><td style="padding: 2px 12px">{{ printf "%.1f" .Avg }} Mbps</td><td style="padding: 2px 12px">{{ printf "%.1f" .Max }} Mbps</td></tr>{{ end }}
+</table>
+{{ template "chart" .SpeedChart }}
+{{ else }}
+<p>No speed test ran.</p>
+{{ end }}
+
+<h2>Latency</h2>
+{{ with .Latency }}
+<p>{{ $.Pings }} latency checks took {{ printf "%.1f" .Min }} ms at best, {{ printf "%.1f" .Avg }} ms on average and {{ printf "%.1f" .Max }} ms at worst.</p>
+{{ template "chart" $.LatencyChart }}
+{{ else }}
+<p>No latency check succeeded.</p>
+{{ end }}
+<p>{{ if .FailedChecks }}{{ .FailedChecks }} checks failed.{{ else }}No check failed.{{ end }}</p>
+
+{{ with .Plan }}
+<h2>Plan</h2>
+<p>
+	{{ .CompliantTests }} of {{ .Tests }} speed tests{{ if .Tests }} ({{ printf "%.1f" .CompliantPercent }}%){{ end }} reached {{ printf "%.0f" .MinimumPercent }}% of the
+	{{ with .Provider }}{{ . }} {{ end }}plan{{ with .Download }}, {{ printf "%.0f" .PlanMbps }} Mbps down{{ end }}{{ with .Upload }}, {{ printf "%.0f" .PlanMbps }} Mbps up{{ end }}.
+</p>
+{{ end }}
+
+{{ define "chart" }}{{ with . }}
+<p>
+	<img src="{{ .Src }}" width="640" height="200" alt="{{ .Name }} chart" style="border: 1px solid #e0e0e0"><br>
+	{{ range .Series }}<span style="color: {{ .Hex }}">&#9632;</span> {{ .Name }} &nbsp; {{ end }}
+	(0 to {{ .Max }} {{ .Unit }}, lines every quarter)
+</p>
+{{ end }}{{ end }}
+
+{{ define "email" }}<!DOCTYPE html>
+<html>
+<body style="font-family: Arial, Helvetica, sans-serif; color: #222">
+{{ template "report" . }}
+</body>
+</html>
+{{ end }}
+`
+
+var _reportTemplate = template.Must(template.New("report").Parse(_reportBody))
+
+// _reportPageTemplate previews the report on the debug page.
+var _reportPageTemplate = template.Must(template.Must(_reportTemplate.Clone()).New("page").Parse(`
+<form action="/debug/report/" method="post"><button>Send Now</button></form>
+{{ template "report" . }}
+`))
+
+func (r *Reporter) view(*http.Request) (any, error) {
+	s, err := r.Summary(r.clock.Now())
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range s.charts() {
+		c.Src = dataURI(c)
+	}
+	return s, nil
+}
+
+type reportPage struct {
+	reporter *Reporter
+	view     http.Handler
+}
+
+func (p *reportPage) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		p.view.ServeHTTP(w, r)
+
+	case http.MethodPost:
+		if err := p.reporter.Send(r.Context(), p.reporter.clock.Now()); err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		_, _ = w.Write([]byte("Report sent"))
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+var _ debughttp.PageProvider = (*Reporter)(nil)
+
+// DebugRoutes returns the page previewing the report of the period ending now, which sends
+// it when posted to.
+func (r *Reporter) DebugRoutes() []debughttp.DebugRoute {
+	p := &reportPage{reporter: r}
+	p.view = debughandler.NewNegotiatingHandler(r.view, _reportPageTemplate)
+	return []debughttp.DebugRoute{{
+		Path:        "/debug/report",
+		Name:        "Report",
+		Description: "Previews the scheduled report of the period ending now, and sends it on request.",
+		Handler:     debughandler.NewHTMLProducingHandler(p),
+		Group:       "Results",
+		Order:       50,
+	}}
+}
@@ -0,0 +1,228 @@
+package report
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"image/png"
+	"io"
+	"log/slog"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/mail"
+	"net/smtp"
+	"testing"
+	"time"
+
+	"yanm/internal/monitor"
+	"yanm/internal/network"
+
+	"github.com/benbjohnson/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchedule_Next(t *testing.T) {
+	at := func(s string) time.Time {
+		ts, err := time.ParseInLocation("2006-01-02 15:04", s, time.UTC)
+		require.NoError(t, err)
+		return ts
+	}
+	daily := Schedule{Period: Daily, At: 8 * time.Hour, Location: time.UTC}
+	weekly := Schedule{Period: Weekly, Weekday: time.Monday, At: 8*time.Hour + 30*time.Minute, Location: time.UTC}
+
+	testCases := []struct {
+		schedule Schedule
+		after    string
+		want     string
+	}{
+		{daily, "2024-03-06 07:00", "2024-03-06 08:00"},
+		{daily, "2024-03-06 08:00", "2024-03-07 08:00"},
+		{weekly, "2024-03-06 07:00", "2024-03-11 08:30"}, // a Wednesday
+		{weekly, "2024-03-11 08:00", "2024-03-11 08:30"},
+		{weekly, "2024-03-11 08:30", "2024-03-18 08:30"},
+	}
+	for _, tc := range testCases {
+		assert.Equal(t, at(tc.want), tc.schedule.Next(at(tc.after)), "after %s", tc.after)
+	}
+}
+
+// newTestReporter returns a reporter of the day up to 2024-03-06 08:00 UTC, which has run
+// two speed tests and latency checks in two hours.
+func newTestReporter(t *testing.T, cfg Config, plan *Tracker) (*Reporter, *clock.Mock) {
+	t.Helper()
+	cfg.Schedule = Schedule{Period: Daily, At: 8 * time.Hour, Location: time.UTC}
+	r := NewReporter(slog.New(slog.NewTextHandler(io.Discard, nil)), cfg, network.BackendOokla, plan)
+	mockClock := clock.NewMock()
+	mockClock.Set(time.Date(2024, 3, 6, 8, 0, 0, 0, time.UTC))
+	r.clock = mockClock
+
+	start := mockClock.Now().Add(-3 * time.Hour)
+	ping := func(offset time.Duration, latency time.Duration) monitor.Event {
+		return monitor.Event{Type: monitor.EventPing, Time: start.Add(offset), Ping: &network.PingResult{Latency: latency}}
+	}
+	for _, e := range []monitor.Event{
+		// a day before the period, forgotten.
+		{Type: monitor.EventSpeedTest, Time: start.Add(-48 * time.Hour), SpeedTest: speedTest(1, 1)},
+		{Type: monitor.EventSpeedTest, Time: start, SpeedTest: speedTest(450, 45)},
+		ping(time.Minute, 10*time.Millisecond),
+		ping(2*time.Minute, 30*time.Millisecond),
+		{Type: monitor.EventCheckFailed, Time: start.Add(3 * time.Minute), Check: "ping"},
+		ping(time.Hour+time.Minute, 20*time.Millisecond),
+		{Type: monitor.EventSpeedTest, Time: start.Add(2 * time.Hour), SpeedTest: speedTest(300, 50)},
+		// that of the compared backend, left out of the report.
+		{Type: monitor.EventSpeedTest, Time: start.Add(2 * time.Hour), Backend: network.BackendLibreSpeed, SpeedTest: speedTest(100, 5)},
+	} {
+		r.record(e)
+	}
+	return r, mockClock
+}
+
+func TestReporter_Summary(t *testing.T) {
+	plan, err := NewTracker(Plan{Provider: "Example ISP", DownloadMbps: 500, UploadMbps: 50, MinimumPercent: 80, Window: Weekly}, network.BackendOokla, "", nil)
+	require.NoError(t, err)
+	r, mockClock := newTestReporter(t, Config{}, plan)
+
+	s, err := r.Summary(mockClock.Now())
+	require.NoError(t, err)
+	assert.Equal(t, "Daily Network Report", s.Title)
+	assert.Equal(t, mockClock.Now().Add(-Daily), s.From)
+	assert.Equal(t, 2, s.SpeedTests)
+	assert.Equal(t, &Stats{Min: 300, Avg: 375, Max: 450}, s.Download)
+	assert.Equal(t, &Stats{Min: 45, Avg: 47.5, Max: 50}, s.Upload)
+	assert.Equal(t, 3, s.Pings)
+	assert.Equal(t, &Stats{Min: 10, Avg: 20, Max: 30}, s.Latency)
+	assert.Equal(t, 1, s.FailedChecks)
+	require.NotNil(t, s.Plan)
+	assert.Equal(t, 1, s.Plan.CompliantTests)
+	assert.Equal(t, "YANM daily network report, Mar 5 08:00 to Mar 6 08:00", s.subject())
+
+	require.NotNil(t, s.SpeedChart)
+	img, err := png.Decode(bytes.NewReader(s.SpeedChart.PNG))
+	require.NoError(t, err)
+	assert.Equal(t, _chartWidth, img.Bounds().Dx())
+	assert.Equal(t, 500.0, s.SpeedChart.Max)
+	require.NotNil(t, s.LatencyChart)
+	assert.Equal(t, 50.0, s.LatencyChart.Max)
+
+	// an empty period still renders.
+	s, err = r.Summary(mockClock.Now().Add(-Weekly))
+	require.NoError(t, err)
+	assert.Zero(t, s.SpeedTests)
+	assert.Nil(t, s.SpeedChart)
+	html, err := s.render("email", dataURI)
+	require.NoError(t, err)
+	assert.Contains(t, string(html), "No speed test ran.")
+}
+
+func TestReporter_SendEmail(t *testing.T) {
+	var sent struct {
+		addr string
+		from string
+		to   []string
+		msg  []byte
+	}
+	r, mockClock := newTestReporter(t, Config{Email: EmailConfig{
+		SMTPAddress: "smtp.example.com:587",
+		Username:    "yanm",
+		Password:    "secret",
+		From:        "yanm@example.com",
+		To:          []string{"me@example.com", "isp@example.com"},
+	}}, nil)
+	r.sendMail = func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+		require.NotNil(t, a)
+		sent.addr, sent.from, sent.to, sent.msg = addr, from, to, msg
+		return nil
+	}
+
+	require.NoError(t, r.Send(context.Background(), mockClock.Now()))
+	assert.Equal(t, "smtp.example.com:587", sent.addr)
+	assert.Equal(t, []string{"me@example.com", "isp@example.com"}, sent.to)
+
+	msg, err := mail.ReadMessage(bytes.NewReader(sent.msg))
+	require.NoError(t, err)
+	assert.Equal(t, "me@example.com, isp@example.com", msg.Header.Get("To"))
+	assert.Equal(t, "YANM daily network report, Mar 5 08:00 to Mar 6 08:00", msg.Header.Get("Subject"))
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	require.NoError(t, err)
+	assert.Equal(t, "multipart/related", mediaType)
+
+	mr := multipart.NewReader(msg.Body, params["boundary"])
+	part, err := mr.NextPart()
+	require.NoError(t, err)
+	html, err := io.ReadAll(part) // NextPart decodes quoted-printable
+	require.NoError(t, err)
+	assert.Contains(t, string(html), `<img src="cid:speed@yanm"`)
+	assert.Contains(t, string(html), `<img src="cid:latency@yanm"`)
+
+	var contentIDs []string
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		assert.Equal(t, "image/png", part.Header.Get("Content-Type"))
+		contentIDs = append(contentIDs, part.Header.Get("Content-ID"))
+	}
+	assert.Equal(t, []string{"<speed@yanm>", "<latency@yanm>"}, contentIDs)
+}
+
+func TestReporter_Webhook(t *testing.T) {
+	posted := make(chan WebhookPayload, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload WebhookPayload
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		posted <- payload
+	}))
+	defer srv.Close()
+
+	r, mockClock := newTestReporter(t, Config{WebhookURL: srv.URL}, nil)
+	mockClock.Set(time.Date(2024, 3, 6, 7, 30, 0, 0, time.UTC))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		r.Run(ctx, nil)
+	}()
+
+	// the report of the day is posted at 08:00.
+	assert.Eventually(t, func() bool {
+		mockClock.Add(time.Hour)
+		return len(posted) > 0
+	}, time.Second, 10*time.Millisecond)
+	payload := <-posted
+	assert.Equal(t, "YANM daily network report, Mar 5 08:00 to Mar 6 08:00", payload.Subject)
+	assert.Equal(t, 2, payload.Summary.SpeedTests)
+	assert.Contains(t, payload.HTML, `<img src="data:image/png;base64,`)
+	cancel()
+	<-done
+
+	srv.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	})
+	err := r.Send(context.Background(), mockClock.Now())
+	require.ErrorContains(t, err, "failed to post the report: webhook returned 403 Forbidden")
+}
+
+func TestReporter_DebugRoutes(t *testing.T) {
+	r, _ := newTestReporter(t, Config{}, nil)
+	routes := r.DebugRoutes()
+	require.Len(t, routes, 1)
+
+	rr := httptest.NewRecorder()
+	routes[0].Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/debug/report/", nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+	body := rr.Body.String()
+	assert.Contains(t, body, "<h1>Daily Network Report</h1>")
+	assert.Contains(t, body, "2 speed tests ran.")
+	assert.Contains(t, body, `<img src="data:image/png;base64,`)
+	assert.Contains(t, body, "1 checks failed.")
+
+	rr = httptest.NewRecorder()
+	routes[0].Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/debug/report/", nil))
+	assert.Contains(t, rr.Body.String(), "Report sent")
+}
@@ -0,0 +1,173 @@
+package report
+
+import (
+	"cmp"
+	"context"
+	"math"
+	"slices"
+	"sync"
+	"time"
+
+	"yanm/internal/monitor"
+
+	"github.com/benbjohnson/clock"
+)
+
+// _resolverWindow is how long the checks of the resolvers are compared over, a day covers
+// the busy and quiet hours of the network.
+const _resolverWindow = 24 * time.Hour
+
+// Resolver is a DNS resolver checked by a dns target.
+type Resolver struct {
+	// Name is the name of the target checking the resolver.
+	Name    string `json:"name"`
+	Address string `json:"address"`
+}
+
+// ResolverStats summarizes the checks of a resolver within the window.
+type ResolverStats struct {
+	Resolver
+	Checks         int     `json:"checks"`
+	Failures       int     `json:"failures"`
+	FailurePercent float64 `json:"failure_percent"`
+	// P50Ms and P95Ms are the percentiles of the successful lookups, zero without any.
+	P50Ms float64 `json:"p50_ms"`
+	P95Ms float64 `json:"p95_ms"`
+	// Best is set on the resolver with the lowest median among those failing least.
+	Best bool `json:"best"`
+}
+
+// ResolverComparisonView compares the resolvers, best first.
+type ResolverComparisonView struct {
+	WindowHours int             `json:"window_hours"`
+	Resolvers   []ResolverStats `json:"resolvers"`
+}
+
+// resolverCheck is the outcome of a single check of a resolver.
+type resolverCheck struct {
+	time      time.Time
+	latencyMs float64
+	failed    bool
+}
+
+// ResolverComparison keeps the recent checks of the dns targets, comparing the lookup
+// latency and failure rate of the resolvers they check, so the best one for the network
+// can be picked on data.
+type ResolverComparison struct {
+	resolvers []Resolver
+
+	mu     sync.Mutex
+	checks map[string][]resolverCheck
+
+	clock clock.Clock
+}
+
+// NewResolverComparison creates a ResolverComparison of resolvers.
+func NewResolverComparison(resolvers []Resolver) *ResolverComparison {
+	return &ResolverComparison{
+		resolvers: resolvers,
+		checks:    make(map[string][]resolverCheck, len(resolvers)),
+		clock:     clock.New(),
+	}
+}
+
+// Run records the checks of the resolvers from events until ctx is done or events is closed.
+func (c *ResolverComparison) Run(ctx context.Context, events <-chan monitor.Event) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case e, ok := <-events:
+			if !ok {
+				return
+			}
+			switch {
+			case e.Type == monitor.EventTarget && e.Ping != nil:
+				c.Record(e.Check, e.Time, e.Ping.Latency, false)
+			case e.Type == monitor.EventCheckFailed:
+				c.Record(e.Check, e.Time, 0, true)
+			}
+		}
+	}
+}
+
+// Record adds a check of the named resolver run at ts, ignoring other targets.
+func (c *ResolverComparison) Record(name string, ts time.Time, latency time.Duration, failed bool) {
+	if !slices.ContainsFunc(c.resolvers, func(r Resolver) bool { return r.Name == name }) {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checks[name] = append(c.checks[name], resolverCheck{
+		time:      ts,
+		latencyMs: float64(latency) / float64(time.Millisecond),
+		failed:    failed,
+	})
+	c.prune()
+}
+
+// View returns the comparison of the resolvers over the window ending now.
+func (c *ResolverComparison) View() ResolverComparisonView {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.prune()
+
+	view := ResolverComparisonView{
+		WindowHours: int(_resolverWindow / time.Hour),
+		Resolvers:   make([]ResolverStats, 0, len(c.resolvers)),
+	}
+	for _, r := range c.resolvers {
+		view.Resolvers = append(view.Resolvers, summarizeResolver(r, c.checks[r.Name]))
+	}
+
+	// resolvers that never answered sort last, then the least failing and quickest first.
+	slices.SortStableFunc(view.Resolvers, func(a, b ResolverStats) int {
+		answered := func(s ResolverStats) bool { return s.Checks > s.Failures }
+		if answered(a) != answered(b) {
+			if answered(a) {
+				return -1
+			}
+			return 1
+		}
+		return cmp.Or(cmp.Compare(a.FailurePercent, b.FailurePercent), cmp.Compare(a.P50Ms, b.P50Ms))
+	})
+	if len(view.Resolvers) > 0 && view.Resolvers[0].Checks > view.Resolvers[0].Failures {
+		view.Resolvers[0].Best = true
+	}
+	return view
+}
+
+func summarizeResolver(r Resolver, checks []resolverCheck) ResolverStats {
+	stats := ResolverStats{Resolver: r, Checks: len(checks)}
+	var latencies []float64
+	for _, check := range checks {
+		if check.failed {
+			stats.Failures++
+			continue
+		}
+		latencies = append(latencies, check.latencyMs)
+	}
+	if stats.Checks > 0 {
+		stats.FailurePercent = 100 * float64(stats.Failures) / float64(stats.Checks)
+	}
+	if len(latencies) > 0 {
+		slices.Sort(latencies)
+		stats.P50Ms = median(latencies)
+		// nearest-rank percentile.
+		stats.P95Ms = latencies[int(math.Ceil(0.95*float64(len(latencies))))-1]
+	}
+	return stats
+}
+
+// prune forgets the checks older than the window.
+func (c *ResolverComparison) prune() {
+	oldest := c.clock.Now().Add(-_resolverWindow)
+	for name, checks := range c.checks {
+		i := 0
+		for i < len(checks) && checks[i].time.Before(oldest) {
+			i++
+		}
+		c.checks[name] = checks[i:]
+	}
+}
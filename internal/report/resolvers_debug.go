@@ -0,0 +1,58 @@
+package report
+
+import (
+	"html/template"
+	"net/http"
+
+	"yanm/internal/debughttp"
+	"yanm/internal/debughttp/debughandler"
+)
+
+const _resolversPage = `
+<h1>Resolver Comparison</h1>
+{{ if .Resolvers }}
+<p>The lookups of the dns targets over the last {{ .WindowHours }} hours, best first.</p>
+<table>
+	<tr>
+		<th>Target</th>
+		<th>Resolver</th>
+		<th>Checks</th>
+		<th>Failures</th>
+		<th>p50</th>
+		<th>p95</th>
+	</tr>
+	{{ range .Resolvers }}
+	<tr>
+		<td>{{ .Name }}{{ if .Best }} (best){{ end }}</td>
+		<td>{{ .Address }}</td>
+		<td>{{ .Checks }}</td>
+		<td>{{ .Failures }}{{ if .Checks }} ({{ printf "%.1f" .FailurePercent }}%){{ end }}</td>
+		<td>{{ if lt .Failures .Checks }}{{ printf "%.1f" .P50Ms }} ms{{ else }}-{{ end }}</td>
+		<td>{{ if lt .Failures .Checks }}{{ printf "%.1f" .P95Ms }} ms{{ else }}-{{ end }}</td>
+	</tr>
+	{{ end }}
+</table>
+{{ else }}
+<p>No dns target is configured, add one per resolver to compare under <code>network.targets</code>.</p>
+{{ end }}
+`
+
+var _resolversPageTemplate = template.Must(template.New("resolvers").Parse(_resolversPage))
+
+func (c *ResolverComparison) view(*http.Request) (any, error) {
+	return c.View(), nil
+}
+
+var _ debughttp.PageProvider = (*ResolverComparison)(nil)
+
+// DebugRoutes returns the resolver comparison page.
+func (c *ResolverComparison) DebugRoutes() []debughttp.DebugRoute {
+	return []debughttp.DebugRoute{{
+		Path:        "/debug/resolvers",
+		Name:        "Resolvers",
+		Description: "Compares the lookup latency percentiles and failure rate of the resolvers checked by dns targets.",
+		Handler:     debughandler.NewHTMLProducingHandler(debughandler.NewNegotiatingHandler(c.view, _resolversPageTemplate)),
+		Group:       "Results",
+		Order:       35,
+	}}
+}
@@ -0,0 +1,104 @@
+package report
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"yanm/internal/monitor"
+	"yanm/internal/network"
+
+	"github.com/benbjohnson/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestResolverComparison() (*ResolverComparison, *clock.Mock) {
+	c := NewResolverComparison([]Resolver{
+		{Name: "isp", Address: "192.168.1.1:53"},
+		{Name: "cloudflare", Address: "1.1.1.1:53"},
+		{Name: "quad9", Address: "9.9.9.9:53"},
+	})
+	mockClock := clock.NewMock()
+	mockClock.Set(time.Date(2025, 3, 14, 21, 30, 0, 0, time.UTC))
+	c.clock = mockClock
+	return c, mockClock
+}
+
+func TestResolverComparison(t *testing.T) {
+	c, mockClock := newTestResolverComparison()
+	now := mockClock.Now()
+
+	for i := range 20 {
+		ts := now.Add(-time.Duration(i) * time.Minute)
+		c.Record("cloudflare", ts, time.Duration(10+i)*time.Millisecond, false)
+		c.Record("isp", ts, 5*time.Millisecond, i%4 == 0)
+	}
+	// not a resolver.
+	c.Record("gateway", now, time.Millisecond, false)
+	// older than the window.
+	c.Record("quad9", now.Add(-25*time.Hour), time.Millisecond, false)
+
+	view := c.View()
+	assert.Equal(t, 24, view.WindowHours)
+	require.Len(t, view.Resolvers, 3)
+	assert.Equal(t, ResolverStats{
+		Resolver: Resolver{Name: "cloudflare", Address: "1.1.1.1:53"},
+		Checks:   20, P50Ms: 19.5, P95Ms: 28, Best: true,
+	}, view.Resolvers[0])
+	// the quickest resolver fails too often to be the best.
+	assert.Equal(t, ResolverStats{
+		Resolver: Resolver{Name: "isp", Address: "192.168.1.1:53"},
+		Checks:   20, Failures: 5, FailurePercent: 25, P50Ms: 5, P95Ms: 5,
+	}, view.Resolvers[1])
+	assert.Equal(t, ResolverStats{Resolver: Resolver{Name: "quad9", Address: "9.9.9.9:53"}}, view.Resolvers[2])
+	assert.NotContains(t, c.checks, "gateway")
+}
+
+func TestResolverComparison_Run(t *testing.T) {
+	c, mockClock := newTestResolverComparison()
+
+	events := make(chan monitor.Event, 3)
+	events <- monitor.Event{Type: monitor.EventTarget, Check: "quad9", Time: mockClock.Now(), Ping: &network.PingResult{Latency: 20 * time.Millisecond}}
+	events <- monitor.Event{Type: monitor.EventCheckFailed, Check: "quad9", Time: mockClock.Now(), Error: "i/o timeout"}
+	events <- monitor.Event{Type: monitor.EventPing, Check: "ping", Time: mockClock.Now(), Ping: &network.PingResult{Latency: time.Millisecond}}
+	close(events)
+	c.Run(context.Background(), events)
+
+	view := c.View()
+	assert.Equal(t, ResolverStats{
+		Resolver: Resolver{Name: "quad9", Address: "9.9.9.9:53"},
+		Checks:   2, Failures: 1, FailurePercent: 50, P50Ms: 20, P95Ms: 20, Best: true,
+	}, view.Resolvers[0])
+}
+
+func TestResolverComparison_DebugRoutes(t *testing.T) {
+	routes := NewResolverComparison(nil).DebugRoutes()
+	require.Len(t, routes, 1)
+	assert.Equal(t, "/debug/resolvers", routes[0].Path)
+
+	rr := httptest.NewRecorder()
+	routes[0].Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/debug/resolvers", nil))
+	assert.Contains(t, rr.Body.String(), "No dns target is configured")
+
+	c, mockClock := newTestResolverComparison()
+	c.Record("cloudflare", mockClock.Now(), 12*time.Millisecond, false)
+	routes = c.DebugRoutes()
+	rr = httptest.NewRecorder()
+	routes[0].Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/debug/resolvers", nil))
+	assert.Contains(t, rr.Body.String(), "cloudflare (best)")
+	assert.Contains(t, rr.Body.String(), "12.0 ms")
+
+	req := httptest.NewRequest(http.MethodGet, "/debug/resolvers", nil)
+	req.Header.Set("Accept", "application/json")
+	rr = httptest.NewRecorder()
+	routes[0].Handler.ServeHTTP(rr, req)
+	var view ResolverComparisonView
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&view))
+	require.Len(t, view.Resolvers, 3)
+	assert.Equal(t, "cloudflare", view.Resolvers[0].Name)
+	assert.Equal(t, 12.0, view.Resolvers[0].P95Ms)
+}
@@ -0,0 +1,202 @@
+// Package stats summarizes the recent results of the monitor over sliding windows, such as
+// the last hour, day and week.
+package stats
+
+import (
+	"context"
+	"math"
+	"slices"
+	"sync"
+	"time"
+
+	"yanm/internal/monitor"
+
+	"github.com/benbjohnson/clock"
+)
+
+// DefaultWindows are the windows summarized when none are configured.
+var DefaultWindows = []time.Duration{time.Hour, 24 * time.Hour, 7 * 24 * time.Hour}
+
+// Stats describes a measurement over a window.
+type Stats struct {
+	Count int     `json:"count"`
+	Min   float64 `json:"min"`
+	Avg   float64 `json:"avg"`
+	Max   float64 `json:"max"`
+	// StdDev is the population standard deviation.
+	StdDev float64 `json:"stddev"`
+}
+
+// Window summarizes the results of a window ending now. A measurement without any result
+// in the window is nil.
+type Window struct {
+	Minutes      int       `json:"window_minutes"`
+	From         time.Time `json:"from"`
+	LatencyMs    *Stats    `json:"latency_ms,omitempty"`
+	DownloadMbps *Stats    `json:"download_mbps,omitempty"`
+	UploadMbps   *Stats    `json:"upload_mbps,omitempty"`
+}
+
+// Summary summarizes the results over each window, shortest first.
+type Summary struct {
+	Windows []Window `json:"windows"`
+}
+
+// accumulator sums the values of a measurement, from which the stats are computed.
+type accumulator struct {
+	count      int
+	sum, sumSq float64
+	min, max   float64
+}
+
+func (a *accumulator) add(v float64) {
+	a.merge(accumulator{count: 1, sum: v, sumSq: v * v, min: v, max: v})
+}
+
+func (a *accumulator) merge(b accumulator) {
+	if b.count == 0 {
+		return
+	}
+	if a.count == 0 {
+		a.min, a.max = b.min, b.max
+	}
+	a.count += b.count
+	a.sum += b.sum
+	a.sumSq += b.sumSq
+	a.min, a.max = min(a.min, b.min), max(a.max, b.max)
+}
+
+func (a accumulator) stats() *Stats {
+	if a.count == 0 {
+		return nil
+	}
+	n := float64(a.count)
+	avg := a.sum / n
+	return &Stats{
+		Count: a.count,
+		Min:   a.min,
+		Avg:   avg,
+		Max:   a.max,
+		// rounding may take the variance of equal values just below zero.
+		StdDev: math.Sqrt(max(a.sumSq/n-avg*avg, 0)),
+	}
+}
+
+// minute holds the pings of a minute, so a week of pings every second stays small.
+type minute struct {
+	start   time.Time
+	latency accumulator
+}
+
+type speedTest struct {
+	time                     time.Time
+	downloadMbps, uploadMbps float64
+}
+
+// Collector keeps the results of the monitor for the longest window, and summarizes them
+// over every window.
+type Collector struct {
+	windows []time.Duration
+	backend string
+
+	mu      sync.Mutex
+	minutes []minute    // oldest first
+	tests   []speedTest // oldest first
+
+	clock clock.Clock
+}
+
+// NewCollector creates a Collector summarizing the results over windows, DefaultWindows
+// when empty. When backends are compared, only the speed tests of backend are summarized,
+// as mixing in those of the other would skew the statistics.
+func NewCollector(windows []time.Duration, backend string) *Collector {
+	if len(windows) == 0 {
+		windows = DefaultWindows
+	}
+	return &Collector{
+		windows: slices.Sorted(slices.Values(windows)),
+		backend: backend,
+		clock:   clock.New(),
+	}
+}
+
+// Run records the results of events until ctx is done or events is closed.
+func (c *Collector) Run(ctx context.Context, events <-chan monitor.Event) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case e, ok := <-events:
+			if !ok {
+				return
+			}
+			c.record(e)
+		}
+	}
+}
+
+func (c *Collector) record(e monitor.Event) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch {
+	case e.Type == monitor.EventPing && e.Ping != nil:
+		start := e.Time.Truncate(time.Minute)
+		if n := len(c.minutes); n == 0 || !c.minutes[n-1].start.Equal(start) {
+			c.minutes = append(c.minutes, minute{start: start})
+		}
+		c.minutes[len(c.minutes)-1].latency.add(float64(e.Ping.Latency) / float64(time.Millisecond))
+	// the backend is only set when comparing backends.
+	case e.Type == monitor.EventSpeedTest && e.SpeedTest != nil && (e.Backend == "" || e.Backend == c.backend):
+		c.tests = append(c.tests, speedTest{
+			time:         e.Time,
+			downloadMbps: e.SpeedTest.DownloadSpeedMbps,
+			uploadMbps:   e.SpeedTest.UploadSpeedMbps,
+		})
+	default:
+		return
+	}
+	c.prune(e.Time)
+}
+
+// prune forgets the results older than the longest window, c.mu must be held.
+func (c *Collector) prune(now time.Time) {
+	oldest := now.Add(-c.windows[len(c.windows)-1])
+	c.minutes = slices.DeleteFunc(c.minutes, func(m minute) bool { return m.start.Before(oldest.Truncate(time.Minute)) })
+	c.tests = slices.DeleteFunc(c.tests, func(t speedTest) bool { return t.time.Before(oldest) })
+}
+
+// Summary summarizes the results over each window ending now. The pings are kept by the
+// minute, so a window covers the pings from the start of its first minute.
+func (c *Collector) Summary() Summary {
+	now := c.clock.Now()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.prune(now)
+
+	summary := Summary{Windows: make([]Window, 0, len(c.windows))}
+	for _, window := range c.windows {
+		from := now.Add(-window)
+		var latency, download, upload accumulator
+		for _, m := range c.minutes {
+			if !m.start.Before(from.Truncate(time.Minute)) {
+				latency.merge(m.latency)
+			}
+		}
+		for _, t := range c.tests {
+			if !t.time.Before(from) {
+				download.add(t.downloadMbps)
+				upload.add(t.uploadMbps)
+			}
+		}
+		summary.Windows = append(summary.Windows, Window{
+			Minutes:      int(window / time.Minute),
+			From:         from,
+			LatencyMs:    latency.stats(),
+			DownloadMbps: download.stats(),
+			UploadMbps:   upload.stats(),
+		})
+	}
+	return summary
+}
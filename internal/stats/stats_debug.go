@@ -0,0 +1,76 @@
+package stats
+
+import (
+	"html/template"
+	"net/http"
+
+	"yanm/internal/debughttp"
+	"yanm/internal/debughttp/debughandler"
+)
+
+const _statsPage = `
+<h1>Statistics</h1>
+<p>The min, average, max and standard deviation of the results over each window ending now.</p>
+<table>
+	<tr>
+		<th>Window</th>
+		<th>Measurement</th>
+		<th>Count</th>
+		<th>Min</th>
+		<th>Avg</th>
+		<th>Max</th>
+		<th>Std dev</th>
+	</tr>
+	{{ range .Windows }}
+	{{ $minutes := .Minutes }}
+	{{ template "row" (row $minutes "Latency" "ms" .LatencyMs) }}
+	{{ template "row" (row $minutes "Download" "Mbps" .DownloadMbps) }}
+	{{ template "row" (row $minutes "Upload" "Mbps" .UploadMbps) }}
+	{{ end }}
+</table>
+{{ define "row" }}
+	<tr>
+		<td>{{ .Minutes }} min</td>
+		<td>{{ .Name }}</td>
+		{{ with .Stats }}
+		<td>{{ .Count }}</td>
+		<td>{{ printf "%.1f" .Min }} {{ $.Unit }}</td>
+		<td>{{ printf "%.1f" .Avg }} {{ $.Unit }}</td>
+		<td>{{ printf "%.1f" .Max }} {{ $.Unit }}</td>
+		<td>{{ printf "%.1f" .StdDev }} {{ $.Unit }}</td>
+		{{ else }}
+		<td>0</td><td>-</td><td>-</td><td>-</td><td>-</td>
+		{{ end }}
+	</tr>
+{{ end }}
+`
+
+type statsRow struct {
+	Minutes    int
+	Name, Unit string
+	Stats      *Stats
+}
+
+var _statsPageTemplate = template.Must(template.New("stats").Funcs(template.FuncMap{
+	"row": func(minutes int, name, unit string, stats *Stats) statsRow {
+		return statsRow{Minutes: minutes, Name: name, Unit: unit, Stats: stats}
+	},
+}).Parse(_statsPage))
+
+func (c *Collector) view(*http.Request) (any, error) {
+	return c.Summary(), nil
+}
+
+var _ debughttp.PageProvider = (*Collector)(nil)
+
+// DebugRoutes returns the statistics page.
+func (c *Collector) DebugRoutes() []debughttp.DebugRoute {
+	return []debughttp.DebugRoute{{
+		Path:        "/debug/stats",
+		Name:        "Statistics",
+		Description: "Summarizes the ping latency and speed test results over the configured windows.",
+		Handler:     debughandler.NewHTMLProducingHandler(debughandler.NewNegotiatingHandler(c.view, _statsPageTemplate)),
+		Group:       "Results",
+		Order:       25,
+	}}
+}
@@ -0,0 +1,161 @@
+package stats
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"yanm/internal/monitor"
+	"yanm/internal/network"
+
+	"github.com/benbjohnson/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func pingEvent(ts time.Time, latency time.Duration) monitor.Event {
+	return monitor.Event{Type: monitor.EventPing, Check: "ping", Time: ts, Ping: &network.PingResult{Latency: latency}}
+}
+
+func speedTestEvent(ts time.Time, backend string, download, upload float64) monitor.Event {
+	return monitor.Event{Type: monitor.EventSpeedTest, Check: "speedtest", Time: ts, Backend: backend,
+		SpeedTest: &network.PerformanceResult{DownloadSpeedMbps: download, UploadSpeedMbps: upload}}
+}
+
+func TestCollector_Summary(t *testing.T) {
+	start := time.Date(2025, 6, 2, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		windows []time.Duration
+		backend string
+		events  []monitor.Event
+		// advance moves the clock on after the events are recorded.
+		advance time.Duration
+		expect  []Window
+	}{
+		{
+			name:    "windows",
+			windows: []time.Duration{24 * time.Hour, time.Hour},
+			events: []monitor.Event{
+				// older than the longest window.
+				pingEvent(start.Add(-25*time.Hour), time.Second),
+				pingEvent(start.Add(-2*time.Hour), 10*time.Millisecond),
+				pingEvent(start.Add(-2*time.Hour+time.Second), 30*time.Millisecond),
+				speedTestEvent(start.Add(-2*time.Hour), "", 50, 5),
+				pingEvent(start.Add(-10*time.Minute), 10*time.Millisecond),
+				pingEvent(start.Add(-time.Minute), 30*time.Millisecond),
+				speedTestEvent(start.Add(-time.Minute), "", 100, 10),
+				{Type: monitor.EventCheckFailed, Check: "ping", Time: start, Error: "i/o timeout"},
+			},
+			expect: []Window{
+				{
+					Minutes:      60,
+					From:         start.Add(-time.Hour),
+					LatencyMs:    &Stats{Count: 2, Min: 10, Avg: 20, Max: 30, StdDev: 10},
+					DownloadMbps: &Stats{Count: 1, Min: 100, Avg: 100, Max: 100},
+					UploadMbps:   &Stats{Count: 1, Min: 10, Avg: 10, Max: 10},
+				},
+				{
+					Minutes:      1440,
+					From:         start.Add(-24 * time.Hour),
+					LatencyMs:    &Stats{Count: 4, Min: 10, Avg: 20, Max: 30, StdDev: 10},
+					DownloadMbps: &Stats{Count: 2, Min: 50, Avg: 75, Max: 100, StdDev: 25},
+					UploadMbps:   &Stats{Count: 2, Min: 5, Avg: 7.5, Max: 10, StdDev: 2.5},
+				},
+			},
+		},
+		{
+			name:    "results aging out",
+			windows: []time.Duration{time.Hour, 24 * time.Hour},
+			events: []monitor.Event{
+				pingEvent(start.Add(-10*time.Minute), 10*time.Millisecond),
+				pingEvent(start.Add(-time.Minute), 30*time.Millisecond),
+				speedTestEvent(start.Add(-time.Minute), "", 100, 10),
+			},
+			advance: 23 * time.Hour,
+			expect: []Window{
+				{Minutes: 60, From: start.Add(22 * time.Hour)},
+				{
+					Minutes:      1440,
+					From:         start.Add(-time.Hour),
+					LatencyMs:    &Stats{Count: 2, Min: 10, Avg: 20, Max: 30, StdDev: 10},
+					DownloadMbps: &Stats{Count: 1, Min: 100, Avg: 100, Max: 100},
+					UploadMbps:   &Stats{Count: 1, Min: 10, Avg: 10, Max: 10},
+				},
+			},
+		},
+		{
+			name:    "compared backends",
+			windows: []time.Duration{time.Hour},
+			backend: network.BackendOokla,
+			events: []monitor.Event{
+				speedTestEvent(start.Add(-time.Minute), network.BackendOokla, 100, 10),
+				speedTestEvent(start.Add(-time.Minute), network.BackendLibreSpeed, 50, 5),
+			},
+			expect: []Window{{
+				Minutes:      60,
+				From:         start.Add(-time.Hour),
+				DownloadMbps: &Stats{Count: 1, Min: 100, Avg: 100, Max: 100},
+				UploadMbps:   &Stats{Count: 1, Min: 10, Avg: 10, Max: 10},
+			}},
+		},
+		{
+			name: "default windows",
+			expect: []Window{
+				{Minutes: 60, From: start.Add(-time.Hour)},
+				{Minutes: 1440, From: start.Add(-24 * time.Hour)},
+				{Minutes: 10080, From: start.Add(-7 * 24 * time.Hour)},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClock := clock.NewMock()
+			mockClock.Set(start)
+			c := NewCollector(tt.windows, tt.backend)
+			c.clock = mockClock
+
+			events := make(chan monitor.Event, len(tt.events))
+			for _, e := range tt.events {
+				events <- e
+			}
+			close(events)
+			c.Run(context.Background(), events)
+			mockClock.Add(tt.advance)
+
+			assert.Equal(t, tt.expect, c.Summary().Windows)
+		})
+	}
+}
+
+func TestCollector_DebugRoutes(t *testing.T) {
+	mockClock := clock.NewMock()
+	mockClock.Set(time.Date(2025, 6, 2, 9, 0, 0, 0, time.UTC))
+	c := NewCollector([]time.Duration{time.Hour, 24 * time.Hour}, "")
+	c.clock = mockClock
+	c.record(pingEvent(mockClock.Now(), 12*time.Millisecond))
+
+	routes := c.DebugRoutes()
+	require.Len(t, routes, 1)
+	assert.Equal(t, "/debug/stats", routes[0].Path)
+
+	rr := httptest.NewRecorder()
+	routes[0].Handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/debug/stats", nil))
+	assert.Contains(t, rr.Body.String(), "12.0 ms")
+	assert.Contains(t, rr.Body.String(), "1440 min")
+
+	req := httptest.NewRequest(http.MethodGet, "/debug/stats", nil)
+	req.Header.Set("Accept", "application/json")
+	rr = httptest.NewRecorder()
+	routes[0].Handler.ServeHTTP(rr, req)
+	var summary Summary
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&summary))
+	require.Len(t, summary.Windows, 2)
+	assert.Equal(t, 12.0, summary.Windows[0].LatencyMs.Max)
+	assert.Nil(t, summary.Windows[0].DownloadMbps)
+}
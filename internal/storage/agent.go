@@ -0,0 +1,20 @@
+package storage
+
+import "context"
+
+// LabelAgent names the agent a result was received from on a central server.
+const LabelAgent = "agent"
+
+type agentKey struct{}
+
+// WithAgent returns a context storing results on behalf of the named agent,
+// so backends can tell the results of several agents apart.
+func WithAgent(ctx context.Context, agent string) context.Context {
+	return context.WithValue(ctx, agentKey{}, agent)
+}
+
+// Agent returns the agent set by WithAgent, empty for results measured locally.
+func Agent(ctx context.Context) string {
+	agent, _ := ctx.Value(agentKey{}).(string)
+	return agent
+}
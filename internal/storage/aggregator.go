@@ -0,0 +1,225 @@
+package storage
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/benbjohnson/clock"
+	"go.uber.org/multierr"
+)
+
+// PingSummary summarizes the ping results received from a single server over one window.
+type PingSummary struct {
+	Start      time.Time
+	End        time.Time
+	ServerName string
+	Latitude   string
+	Longitude  string
+
+	Count int
+	MinMs float64
+	AvgMs float64
+	MaxMs float64
+	P95Ms float64
+
+	JitterMs float64
+	// PacketLossPercent is the average over the samples that measured loss, or negative if none did.
+	PacketLossPercent float64
+}
+
+// PingSummaryStorer is implemented by backends that can persist a PingSummary natively.
+// Backends that do not implement it receive the average as a regular ping result.
+type PingSummaryStorer interface {
+	StorePingSummary(ctx context.Context, summary PingSummary) error
+}
+
+// storePingSummary writes summary to backend, natively if supported.
+func storePingSummary(ctx context.Context, backend MetricsStorage, summary PingSummary) error {
+	if s, ok := backend.(PingSummaryStorer); ok {
+		return s.StorePingSummary(ctx, summary)
+	}
+
+	return backend.StorePingResult(ctx,
+		summary.End,
+		int64(math.Round(summary.AvgMs)),
+		summary.JitterMs,
+		summary.PacketLossPercent,
+		summary.ServerName,
+		summary.Latitude,
+		summary.Longitude,
+	)
+}
+
+type aggregationKey struct {
+	agent, link, backend, serverName, latitude, longitude string
+	// labels are the formatted result labels, the map itself is kept by the bucket.
+	labels string
+}
+
+type pingBucket struct {
+	start     time.Time
+	labels    map[string]string
+	latencies []float64
+	jitterSum float64
+	lossSum   float64
+	lossCount int
+}
+
+// AggregatingStorage buffers ping results and writes a PingSummary per server for every window,
+// reducing write volume for push-based backends. Speed test results are written through.
+type AggregatingStorage struct {
+	MetricsStorage
+
+	window time.Duration
+	logger *slog.Logger
+
+	mu      sync.Mutex
+	buckets map[aggregationKey]*pingBucket
+
+	// testing fields
+	clock clock.Clock
+}
+
+// Verify AggregatingStorage implements MetricsStorage interface
+var (
+	_ MetricsStorage   = (*AggregatingStorage)(nil)
+	_ ProbeValueStorer = (*AggregatingStorage)(nil)
+)
+
+// NewAggregatingStorage wraps backend, summarizing ping results every window once Run is called.
+func NewAggregatingStorage(logger *slog.Logger, backend MetricsStorage, window time.Duration) *AggregatingStorage {
+	return &AggregatingStorage{
+		MetricsStorage: backend,
+		window:         window,
+		logger:         logger.With("component", "storage_aggregator"),
+		buckets:        make(map[aggregationKey]*pingBucket),
+		clock:          clock.New(),
+	}
+}
+
+// Run flushes the buffered ping results every window until ctx is done.
+func (a *AggregatingStorage) Run(ctx context.Context) {
+	ticker := a.clock.Ticker(a.window)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := a.Flush(ctx); err != nil {
+				a.logger.ErrorContext(ctx, "Failed to store ping summaries", "error", err)
+			}
+		}
+	}
+}
+
+// StorePingResult buffers the result until the next flush.
+func (a *AggregatingStorage) StorePingResult(
+	ctx context.Context,
+	_ time.Time,
+	pingMs int64,
+	jitterMs, packetLossPercent float64,
+	serverName string,
+	lat, lon string,
+) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	labels := ResultLabels(ctx)
+	key := aggregationKey{agent: Agent(ctx), link: Link(ctx), backend: Backend(ctx), serverName: serverName, latitude: lat, longitude: lon, labels: formatLabels(labels)}
+	bucket, ok := a.buckets[key]
+	if !ok {
+		bucket = &pingBucket{start: a.clock.Now(), labels: labels}
+		a.buckets[key] = bucket
+	}
+
+	bucket.latencies = append(bucket.latencies, float64(pingMs))
+	bucket.jitterSum += jitterMs
+	if packetLossPercent >= 0 {
+		bucket.lossSum += packetLossPercent
+		bucket.lossCount++
+	}
+	return nil
+}
+
+// StoreProbeValues writes the values through to the wrapped backend, they are not aggregated.
+func (a *AggregatingStorage) StoreProbeValues(ctx context.Context, timestamp time.Time, probe string, values map[string]float64) error {
+	return StoreProbeValues(ctx, a.MetricsStorage, timestamp, probe, values)
+}
+
+// Flush writes a summary of every buffered server, agent, link, speed test backend and set
+// of result labels to the wrapped backend.
+func (a *AggregatingStorage) Flush(ctx context.Context) error {
+	a.mu.Lock()
+	buckets := a.buckets
+	a.buckets = make(map[aggregationKey]*pingBucket)
+	a.mu.Unlock()
+
+	end := a.clock.Now()
+	var errs error
+	for key, bucket := range buckets {
+		storeCtx := ctx
+		if key.agent != "" {
+			storeCtx = WithAgent(storeCtx, key.agent)
+		}
+		if key.link != "" {
+			storeCtx = WithLink(storeCtx, key.link)
+		}
+		if key.backend != "" {
+			storeCtx = WithBackend(storeCtx, key.backend)
+		}
+		if bucket.labels != nil {
+			storeCtx = WithResultLabels(storeCtx, bucket.labels)
+		}
+		errs = multierr.Append(errs, storePingSummary(storeCtx, a.MetricsStorage, summarize(key, bucket, end)))
+	}
+	return errs
+}
+
+// Close flushes any buffered results before closing the wrapped backend.
+func (a *AggregatingStorage) Close(ctx context.Context) {
+	// the context is usually cancelled by the time we shut down, still try to write the last window.
+	if err := a.Flush(context.WithoutCancel(ctx)); err != nil {
+		a.logger.ErrorContext(ctx, "Failed to store final ping summaries", "error", err)
+	}
+	a.MetricsStorage.Close(ctx)
+}
+
+func summarize(key aggregationKey, bucket *pingBucket, end time.Time) PingSummary {
+	latencies := bucket.latencies
+	slices.Sort(latencies)
+
+	var sum float64
+	for _, l := range latencies {
+		sum += l
+	}
+	count := len(latencies)
+
+	// nearest-rank percentile.
+	p95 := latencies[int(math.Ceil(0.95*float64(count)))-1]
+
+	lossPercent := -1.0
+	if bucket.lossCount > 0 {
+		lossPercent = bucket.lossSum / float64(bucket.lossCount)
+	}
+
+	return PingSummary{
+		Start:             bucket.start,
+		End:               end,
+		ServerName:        key.serverName,
+		Latitude:          key.latitude,
+		Longitude:         key.longitude,
+		Count:             count,
+		MinMs:             latencies[0],
+		AvgMs:             sum / float64(count),
+		MaxMs:             latencies[count-1],
+		P95Ms:             p95,
+		JitterMs:          bucket.jitterSum / float64(count),
+		PacketLossPercent: lossPercent,
+	}
+}
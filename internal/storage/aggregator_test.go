@@ -0,0 +1,130 @@
+package storage
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"yanm/internal/storage/storagemock"
+
+	"github.com/benbjohnson/clock"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// summaryRecorder is a MetricsStorage that records ping summaries natively.
+type summaryRecorder struct {
+	*NoOpStorage
+
+	summaries []PingSummary
+}
+
+func (r *summaryRecorder) StorePingSummary(_ context.Context, summary PingSummary) error {
+	r.summaries = append(r.summaries, summary)
+	return nil
+}
+
+func TestAggregatingStorage_Flush(t *testing.T) {
+	ctx := context.Background()
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	mockClock := clock.NewMock()
+
+	backend := &summaryRecorder{NoOpStorage: NewNoOpStorage(logger)}
+	a := NewAggregatingStorage(logger, backend, time.Minute)
+	a.clock = mockClock
+
+	start := mockClock.Now()
+	for i := int64(1); i <= 20; i++ {
+		loss := -1.0
+		if i <= 2 {
+			loss = float64(i)
+		}
+		require.NoError(t, a.StorePingResult(ctx, mockClock.Now(), i, 1, loss, "server", "1", "2"))
+	}
+	mockClock.Add(time.Minute)
+
+	require.NoError(t, a.Flush(ctx))
+	require.Len(t, backend.summaries, 1)
+	assert.Equal(t, PingSummary{
+		Start:             start,
+		End:               start.Add(time.Minute),
+		ServerName:        "server",
+		Latitude:          "1",
+		Longitude:         "2",
+		Count:             20,
+		MinMs:             1,
+		AvgMs:             10.5,
+		MaxMs:             20,
+		P95Ms:             19,
+		JitterMs:          1,
+		PacketLossPercent: 1.5,
+	}, backend.summaries[0])
+
+	// buffers are reset after a flush.
+	require.NoError(t, a.Flush(ctx))
+	assert.Len(t, backend.summaries, 1)
+}
+
+func TestAggregatingStorage_FallbackToPingResult(t *testing.T) {
+	ctx := context.Background()
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+
+	mockCtrl := gomock.NewController(t)
+	backend := storagemock.NewMockMetricsStorage(mockCtrl)
+	a := NewAggregatingStorage(logger, backend, time.Minute)
+
+	require.NoError(t, a.StorePingResult(ctx, time.Now(), 10, 0, -1, "server", "1", "2"))
+	require.NoError(t, a.StorePingResult(ctx, time.Now(), 21, 0, -1, "server", "1", "2"))
+
+	backend.EXPECT().StorePingResult(gomock.Any(), gomock.Any(), int64(16), 0.0, -1.0, "server", "1", "2").Return(nil)
+	backend.EXPECT().Close(gomock.Any())
+	a.Close(ctx)
+}
+
+func TestAggregatingStorage_PerAgent(t *testing.T) {
+	ctx := context.Background()
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+
+	mockCtrl := gomock.NewController(t)
+	backend := storagemock.NewMockMetricsStorage(mockCtrl)
+	a := NewAggregatingStorage(logger, backend, time.Minute)
+
+	require.NoError(t, a.StorePingResult(WithAgent(ctx, "office"), time.Now(), 10, 0, -1, "server", "1", "2"))
+	require.NoError(t, a.StorePingResult(WithAgent(ctx, "cabin"), time.Now(), 30, 0, -1, "server", "1", "2"))
+
+	// each agent is summarized on its own, with the agent passed on to the backend.
+	agents := map[string]int64{}
+	backend.EXPECT().StorePingResult(gomock.Any(), gomock.Any(), gomock.Any(), 0.0, -1.0, "server", "1", "2").
+		DoAndReturn(func(ctx context.Context, _ time.Time, pingMs int64, _, _ float64, _, _, _ string) error {
+			agents[Agent(ctx)] = pingMs
+			return nil
+		}).Times(2)
+	require.NoError(t, a.Flush(ctx))
+	assert.Equal(t, map[string]int64{"office": 10, "cabin": 30}, agents)
+}
+
+func TestAggregatingStorage_PerResultLabels(t *testing.T) {
+	ctx := context.Background()
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+
+	mockCtrl := gomock.NewController(t)
+	backend := storagemock.NewMockMetricsStorage(mockCtrl)
+	a := NewAggregatingStorage(logger, backend, time.Minute)
+
+	require.NoError(t, a.StorePingResult(WithResultLabels(ctx, map[string]string{"site": "office"}), time.Now(), 10, 0, -1, "server", "1", "2"))
+	require.NoError(t, a.StorePingResult(WithResultLabels(ctx, map[string]string{"site": "office"}), time.Now(), 20, 0, -1, "server", "1", "2"))
+	require.NoError(t, a.StorePingResult(WithResultLabels(ctx, map[string]string{"site": "cabin"}), time.Now(), 30, 0, -1, "server", "1", "2"))
+
+	// results with different labels are summarized apart, with their labels passed on.
+	sites := map[string]int64{}
+	backend.EXPECT().StorePingResult(gomock.Any(), gomock.Any(), gomock.Any(), 0.0, -1.0, "server", "1", "2").
+		DoAndReturn(func(ctx context.Context, _ time.Time, pingMs int64, _, _ float64, _, _, _ string) error {
+			sites[ResultLabels(ctx)["site"]] = pingMs
+			return nil
+		}).Times(2)
+	require.NoError(t, a.Flush(ctx))
+	assert.Equal(t, map[string]int64{"office": 15, "cabin": 30}, sites)
+}
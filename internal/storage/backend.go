@@ -0,0 +1,21 @@
+package storage
+
+import "context"
+
+// LabelBackend names the speed test backend a result was measured with, when comparing
+// backends.
+const LabelBackend = "backend"
+
+type backendKey struct{}
+
+// WithBackend returns a context storing results measured with the named speed test
+// backend, so backends comparing them can tell their results apart.
+func WithBackend(ctx context.Context, backend string) context.Context {
+	return context.WithValue(ctx, backendKey{}, backend)
+}
+
+// Backend returns the backend set by WithBackend, empty when backends are not compared.
+func Backend(ctx context.Context) string {
+	backend, _ := ctx.Value(backendKey{}).(string)
+	return backend
+}
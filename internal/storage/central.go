@@ -0,0 +1,344 @@
+package storage
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const (
+	// CentralPath is where the central server receives batches, on its debug server.
+	CentralPath = "/debug/central/"
+
+	_centralPushInterval = 10 * time.Second
+	_centralTimeout      = 10 * time.Second
+	// _centralQueueSize bounds the results kept while the central server is unreachable,
+	// about a day of pings at the default interval.
+	_centralQueueSize = 10000
+)
+
+// Result types in a CentralBatch.
+const (
+	CentralResultPing      = "ping"
+	CentralResultSpeedTest = "speedtest"
+)
+
+// CentralBatch is the body an agent posts to the central server.
+type CentralBatch struct {
+	Agent   string          `json:"agent"`
+	Results []CentralResult `json:"results"`
+}
+
+// CentralResult is a single ping or speed test result measured by an agent.
+type CentralResult struct {
+	Type      string    `json:"type"`
+	Time      time.Time `json:"time"`
+	Server    string    `json:"server"`
+	Latitude  string    `json:"latitude"`
+	Longitude string    `json:"longitude"`
+
+	DownloadMbps float64 `json:"download_mbps,omitempty"`
+	UploadMbps   float64 `json:"upload_mbps,omitempty"`
+	PingMs       int64   `json:"ping_ms"`
+	JitterMs     float64 `json:"jitter_ms"`
+	// PacketLossPercent is negative when loss was not measured.
+	PacketLossPercent float64 `json:"packet_loss_percent"`
+	// DownloadBytes and UploadBytes are the data a speed test transferred.
+	DownloadBytes int64 `json:"download_bytes,omitempty"`
+	UploadBytes   int64 `json:"upload_bytes,omitempty"`
+	// Link is the uplink the result was measured over, empty for the default route.
+	Link string `json:"link,omitempty"`
+	// Backend is the speed test backend the result was measured with, when comparing them.
+	Backend string `json:"backend,omitempty"`
+	// Labels are the result labels configured on the agent.
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// Store writes r to backend.
+func (r CentralResult) Store(ctx context.Context, backend MetricsStorage) error {
+	if r.Link != "" {
+		ctx = WithLink(ctx, r.Link)
+	}
+	if r.Backend != "" {
+		ctx = WithBackend(ctx, r.Backend)
+	}
+	if len(r.Labels) > 0 {
+		ctx = WithResultLabels(ctx, r.Labels)
+	}
+	switch r.Type {
+	case CentralResultPing:
+		return backend.StorePingResult(ctx, r.Time, r.PingMs, r.JitterMs, r.PacketLossPercent,
+			r.Server, r.Latitude, r.Longitude)
+	case CentralResultSpeedTest:
+		return backend.StoreNetworkPerformance(ctx, r.Time, r.DownloadMbps, r.UploadMbps, r.PingMs,
+			r.JitterMs, r.PacketLossPercent, r.DownloadBytes, r.UploadBytes, r.Server, r.Latitude, r.Longitude)
+	default:
+		return fmt.Errorf("unknown result type %q", r.Type)
+	}
+}
+
+// CentralConfig holds the settings for pushing results to a central server.
+type CentralConfig struct {
+	// URL is the central server's debug server.
+	URL string
+	// Token authenticates with the central server's debug server, if it requires it.
+	Token string
+	// Agent names this instance on the central server, the host name if empty.
+	Agent string
+	// Interval between pushes, 10 seconds if zero.
+	Interval time.Duration
+}
+
+// CentralStorage queues results and pushes them to a central server in the background,
+// keeping them queued while the central server is unreachable.
+type CentralStorage struct {
+	client *http.Client
+	url    string
+	token  string
+	agent  string
+
+	mu    sync.Mutex
+	queue []CentralResult
+	// pushMu serializes pushes, so results are sent in order.
+	pushMu sync.Mutex
+
+	stop chan struct{}
+	done chan struct{}
+
+	logger *slog.Logger
+}
+
+// Verify CentralStorage implements MetricsStorage interface
+var (
+	_ MetricsStorage = (*CentralStorage)(nil)
+	_ QueueDepther   = (*CentralStorage)(nil)
+)
+
+// NewCentralStorage creates a CentralStorage, pushing every interval until Close.
+func NewCentralStorage(logger *slog.Logger, cfg CentralConfig) (*CentralStorage, error) {
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("central url is required")
+	}
+	agent := cfg.Agent
+	if agent == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return nil, fmt.Errorf("name the agent: %w", err)
+		}
+		agent = hostname
+	}
+
+	c := &CentralStorage{
+		client: &http.Client{Timeout: _centralTimeout},
+		url:    strings.TrimSuffix(cfg.URL, "/") + CentralPath,
+		token:  cfg.Token,
+		agent:  agent,
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+		logger: logger.With("component", "central_storage", "agent", agent),
+	}
+	go c.run(cmp.Or(cfg.Interval, _centralPushInterval))
+	return c, nil
+}
+
+func (c *CentralStorage) run(interval time.Duration) {
+	defer close(c.done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.stop:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), _centralTimeout)
+			if err := c.Flush(ctx); err != nil {
+				c.logger.Warn("Failed to push results to the central server, will retry",
+					"error", err, "queued", c.QueueDepth())
+			}
+			cancel()
+		}
+	}
+}
+
+// StoreNetworkPerformance queues the speed test result.
+func (c *CentralStorage) StoreNetworkPerformance(
+	ctx context.Context,
+	timestamp time.Time,
+	downloadSpeedMbps, uploadSpeedMbps float64,
+	pingMs int64,
+	jitterMs, packetLossPercent float64,
+	downloadBytes, uploadBytes int64,
+	serverName string,
+	lat, lon string,
+) error {
+	c.enqueue(CentralResult{
+		Type:              CentralResultSpeedTest,
+		Time:              timestamp,
+		Server:            serverName,
+		Latitude:          lat,
+		Longitude:         lon,
+		DownloadMbps:      downloadSpeedMbps,
+		UploadMbps:        uploadSpeedMbps,
+		PingMs:            pingMs,
+		JitterMs:          jitterMs,
+		PacketLossPercent: packetLossPercent,
+		DownloadBytes:     downloadBytes,
+		UploadBytes:       uploadBytes,
+		Link:              Link(ctx),
+		Backend:           Backend(ctx),
+		Labels:            ResultLabels(ctx),
+	})
+	return nil
+}
+
+// StorePingResult queues the ping result.
+func (c *CentralStorage) StorePingResult(
+	ctx context.Context,
+	timestamp time.Time,
+	pingMs int64,
+	jitterMs, packetLossPercent float64,
+	serverName string,
+	lat, lon string,
+) error {
+	c.enqueue(CentralResult{
+		Type:              CentralResultPing,
+		Time:              timestamp,
+		Server:            serverName,
+		Latitude:          lat,
+		Longitude:         lon,
+		PingMs:            pingMs,
+		JitterMs:          jitterMs,
+		PacketLossPercent: packetLossPercent,
+		Link:              Link(ctx),
+		Backend:           Backend(ctx),
+		Labels:            ResultLabels(ctx),
+	})
+	return nil
+}
+
+// enqueue queues results after any already queued, dropping the oldest beyond the queue size.
+func (c *CentralStorage) enqueue(results ...CentralResult) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.queue = append(c.queue, results...)
+	if dropped := len(c.queue) - _centralQueueSize; dropped > 0 {
+		c.queue = c.queue[dropped:]
+		c.logger.Warn("Central server queue is full, dropped the oldest results", "dropped", dropped)
+	}
+}
+
+// Flush pushes the queued results, queueing them again if the push fails.
+func (c *CentralStorage) Flush(ctx context.Context) error {
+	c.pushMu.Lock()
+	defer c.pushMu.Unlock()
+
+	c.mu.Lock()
+	results := c.queue
+	c.queue = nil
+	c.mu.Unlock()
+
+	if len(results) == 0 {
+		return nil
+	}
+	if err := c.push(ctx, results); err != nil {
+		c.mu.Lock()
+		queued := c.queue
+		c.queue = results
+		c.mu.Unlock()
+		c.enqueue(queued...)
+		return err
+	}
+	return nil
+}
+
+func (c *CentralStorage) push(ctx context.Context, results []CentralResult) error {
+	body, err := json.Marshal(CentralBatch{Agent: c.agent, Results: results})
+	if err != nil {
+		return err
+	}
+	req, err := c.newRequest(ctx, http.MethodPost, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("central server returned %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+func (c *CentralStorage) newRequest(ctx context.Context, method string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.url, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	return req, nil
+}
+
+// QueueDepth returns the number of results waiting to be pushed.
+func (c *CentralStorage) QueueDepth() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.queue)
+}
+
+// Ping checks that the central server is reachable and accepts this agent's token.
+func (c *CentralStorage) Ping(ctx context.Context) error {
+	req, err := c.newRequest(ctx, http.MethodGet, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("central server %s returned %s", c.url, resp.Status)
+	}
+	return nil
+}
+
+// MetricsHTTPHandler serves the process metrics, results themselves live on the central server.
+func (c *CentralStorage) MetricsHTTPHandler() http.Handler {
+	return promhttp.Handler()
+}
+
+// Close stops the background pushes and pushes the results still queued.
+func (c *CentralStorage) Close(ctx context.Context) {
+	close(c.stop)
+	<-c.done
+
+	// the context is usually cancelled by the time we shut down, still try to push the rest.
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), _centralTimeout)
+	defer cancel()
+	if err := c.Flush(ctx); err != nil {
+		c.logger.ErrorContext(ctx, "Failed to push the final results to the central server",
+			"error", err, "dropped", c.QueueDepth())
+	}
+}
@@ -0,0 +1,87 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCentralStorage_Flush(t *testing.T) {
+	var (
+		batches []CentralBatch
+		fail    atomic.Bool
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, CentralPath, r.URL.Path)
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		if r.Method == http.MethodGet {
+			return
+		}
+		if fail.Load() {
+			http.Error(w, "storage is down", http.StatusInternalServerError)
+			return
+		}
+		var batch CentralBatch
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&batch))
+		batches = append(batches, batch)
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	c, err := NewCentralStorage(logger, CentralConfig{URL: srv.URL + "/", Token: "secret", Agent: "office", Interval: time.Hour})
+	require.NoError(t, err)
+	require.NoError(t, c.Ping(ctx))
+
+	ts := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	require.NoError(t, c.StorePingResult(ctx, ts, 12, 0.5, -1, "Example", "1.0", "2.0"))
+
+	// the result stays queued until the central server accepts it.
+	fail.Store(true)
+	require.ErrorContains(t, c.Flush(ctx), "central server returned 500 Internal Server Error: storage is down")
+	assert.Equal(t, 1, c.QueueDepth())
+
+	fail.Store(false)
+	require.NoError(t, c.StoreNetworkPerformance(WithResultLabels(WithBackend(WithLink(ctx, "fiber"), "ookla"), map[string]string{"site": "cabin"}), ts, 100, 20, 15, 1.5, 0, 150_000_000, 30_000_000, "Example", "1.0", "2.0"))
+	c.Close(ctx)
+	assert.Equal(t, 0, c.QueueDepth())
+
+	require.Len(t, batches, 1)
+	assert.Equal(t, CentralBatch{Agent: "office", Results: []CentralResult{
+		{Type: CentralResultPing, Time: ts, Server: "Example", Latitude: "1.0", Longitude: "2.0",
+			PingMs: 12, JitterMs: 0.5, PacketLossPercent: -1},
+		{Type: CentralResultSpeedTest, Time: ts, Server: "Example", Latitude: "1.0", Longitude: "2.0",
+			DownloadMbps: 100, UploadMbps: 20, PingMs: 15, JitterMs: 1.5, DownloadBytes: 150_000_000, UploadBytes: 30_000_000, Link: "fiber", Backend: "ookla",
+			Labels: map[string]string{"site": "cabin"}},
+	}}, batches[0])
+}
+
+func TestCentralResult_Store(t *testing.T) {
+	var out strings.Builder
+	backend := NewConsoleStorage(&out)
+
+	ts := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	require.NoError(t, CentralResult{Type: CentralResultPing, Time: ts, Server: "Example", PingMs: 12, PacketLossPercent: -1}.
+		Store(context.Background(), backend))
+	require.NoError(t, CentralResult{Type: CentralResultPing, Time: ts, Server: "Example", PingMs: 40, PacketLossPercent: -1, Link: "lte"}.
+		Store(context.Background(), backend))
+	require.NoError(t, CentralResult{Type: CentralResultPing, Time: ts, Server: "Example", PingMs: 14, PacketLossPercent: -1, Backend: "librespeed"}.
+		Store(context.Background(), backend))
+	require.NoError(t, CentralResult{Type: CentralResultPing, Time: ts, Server: "Example", PingMs: 15, PacketLossPercent: -1, Labels: map[string]string{"site": "cabin"}}.
+		Store(context.Background(), backend))
+	require.EqualError(t, CentralResult{Type: "trace"}.Store(context.Background(), backend), `unknown result type "trace"`)
+	assert.Equal(t, "would store 2025-01-02T03:04:05Z ping server=\"Example\" ping_ms=12 jitter_ms=0.00 location=,\n"+
+		"would store 2025-01-02T03:04:05Z ping server=\"Example\" ping_ms=40 jitter_ms=0.00 location=, link=\"lte\"\n"+
+		"would store 2025-01-02T03:04:05Z ping server=\"Example\" ping_ms=14 jitter_ms=0.00 location=, backend=\"librespeed\"\n"+
+		"would store 2025-01-02T03:04:05Z ping server=\"Example\" ping_ms=15 jitter_ms=0.00 location=, site=\"cabin\"\n", out.String())
+}
@@ -0,0 +1,131 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ConsoleStorage prints every result instead of storing it, one line each, so a
+// configuration can be tried out without writing to the real backend.
+type ConsoleStorage struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// Verify that ConsoleStorage implements MetricsStorage interface
+var (
+	_ MetricsStorage    = (*ConsoleStorage)(nil)
+	_ PingSummaryStorer = (*ConsoleStorage)(nil)
+	_ ProbeValueStorer  = (*ConsoleStorage)(nil)
+)
+
+// NewConsoleStorage creates a ConsoleStorage printing to w.
+func NewConsoleStorage(w io.Writer) *ConsoleStorage {
+	return &ConsoleStorage{w: w}
+}
+
+// StoreNetworkPerformance prints the speed test result.
+func (c *ConsoleStorage) StoreNetworkPerformance(
+	ctx context.Context,
+	timestamp time.Time,
+	downloadSpeedMbps, uploadSpeedMbps float64,
+	pingMs int64,
+	jitterMs, packetLossPercent float64,
+	downloadBytes, uploadBytes int64,
+	serverName string,
+	lat, lon string,
+) error {
+	return c.printf(timestamp, "speedtest", "server=%s download_mbps=%.2f upload_mbps=%.2f ping_ms=%d jitter_ms=%.2f%s download_bytes=%d upload_bytes=%d location=%s,%s%s",
+		strconv.Quote(serverName), downloadSpeedMbps, uploadSpeedMbps, pingMs, jitterMs, packetLoss(packetLossPercent), downloadBytes, uploadBytes, lat, lon, link(ctx)+backend(ctx)+resultLabels(ctx))
+}
+
+// StorePingResult prints the ping result.
+func (c *ConsoleStorage) StorePingResult(
+	ctx context.Context,
+	timestamp time.Time,
+	pingMs int64,
+	jitterMs, packetLossPercent float64,
+	serverName string,
+	lat, lon string,
+) error {
+	return c.printf(timestamp, "ping", "server=%s ping_ms=%d jitter_ms=%.2f%s location=%s,%s%s",
+		strconv.Quote(serverName), pingMs, jitterMs, packetLoss(packetLossPercent), lat, lon, link(ctx)+backend(ctx)+resultLabels(ctx))
+}
+
+// StorePingSummary prints the ping summary.
+func (c *ConsoleStorage) StorePingSummary(ctx context.Context, s PingSummary) error {
+	return c.printf(s.End, "ping_summary", "server=%s start=%s count=%d min_ms=%.2f avg_ms=%.2f max_ms=%.2f p95_ms=%.2f jitter_ms=%.2f%s%s",
+		strconv.Quote(s.ServerName), s.Start.Format(time.RFC3339), s.Count, s.MinMs, s.AvgMs, s.MaxMs, s.P95Ms, s.JitterMs, packetLoss(s.PacketLossPercent), link(ctx)+backend(ctx)+resultLabels(ctx))
+}
+
+// StoreProbeValues prints the probe's values, by name.
+func (c *ConsoleStorage) StoreProbeValues(_ context.Context, timestamp time.Time, probe string, values map[string]float64) error {
+	var fields strings.Builder
+	for _, name := range slices.Sorted(maps.Keys(values)) {
+		fmt.Fprintf(&fields, " %s=%g", name, values[name])
+	}
+	return c.printf(timestamp, "probe", "server=%s%s", strconv.Quote(probe), fields.String())
+}
+
+func (c *ConsoleStorage) printf(timestamp time.Time, measurement, format string, args ...any) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	_, err := fmt.Fprintf(c.w, "would store %s %s "+format+"\n",
+		append([]any{timestamp.Format(time.RFC3339), measurement}, args...)...)
+	return err
+}
+
+// packetLoss formats the packet loss field, omitted when loss was not measured.
+func packetLoss(percent float64) string {
+	if percent < 0 {
+		return ""
+	}
+	return fmt.Sprintf(" packet_loss_percent=%.2f", percent)
+}
+
+// link formats the link field, omitted for results measured over the default route.
+func link(ctx context.Context) string {
+	if link := Link(ctx); link != "" {
+		return " link=" + strconv.Quote(link)
+	}
+	return ""
+}
+
+// backend formats the backend field, omitted when backends are not compared.
+func backend(ctx context.Context) string {
+	if backend := Backend(ctx); backend != "" {
+		return " backend=" + strconv.Quote(backend)
+	}
+	return ""
+}
+
+// resultLabels formats the result labels as fields, omitted when none are configured.
+func resultLabels(ctx context.Context) string {
+	if labels := ResultLabels(ctx); len(labels) > 0 {
+		return " " + formatLabels(labels)
+	}
+	return ""
+}
+
+// Ping always succeeds
+func (c *ConsoleStorage) Ping(_ context.Context) error {
+	return nil
+}
+
+// Close does nothing
+func (c *ConsoleStorage) Close(_ context.Context) {}
+
+func (c *ConsoleStorage) MetricsHTTPHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNotImplemented)
+	})
+}
@@ -0,0 +1,32 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConsoleStorage(t *testing.T) {
+	var buf bytes.Buffer
+	s := NewConsoleStorage(&buf)
+	ctx := context.Background()
+	ts := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	require.NoError(t, s.StoreNetworkPerformance(ctx, ts, 94.5, 11.25, 12, 1.5, 0, 170_000_000, 21_000_000, "Example ISP", "1.5", "-2.5"))
+	require.NoError(t, s.StorePingResult(ctx, ts, 12, 1.5, -1, "Example ISP", "1.5", "-2.5"))
+	require.NoError(t, s.StorePingSummary(ctx, PingSummary{
+		Start: ts.Add(-time.Minute), End: ts, ServerName: "Example ISP",
+		Count: 6, MinMs: 10, AvgMs: 12, MaxMs: 15, P95Ms: 15, JitterMs: 1, PacketLossPercent: 0.5,
+	}))
+	require.NoError(t, s.StoreProbeValues(ctx, ts, "modem", map[string]float64{"snr_db": 38.5, "power_dbmv": -2}))
+
+	assert.Equal(t, `would store 2025-01-02T03:04:05Z speedtest server="Example ISP" download_mbps=94.50 upload_mbps=11.25 ping_ms=12 jitter_ms=1.50 packet_loss_percent=0.00 download_bytes=170000000 upload_bytes=21000000 location=1.5,-2.5
+would store 2025-01-02T03:04:05Z ping server="Example ISP" ping_ms=12 jitter_ms=1.50 location=1.5,-2.5
+would store 2025-01-02T03:04:05Z ping_summary server="Example ISP" start=2025-01-02T03:03:05Z count=6 min_ms=10.00 avg_ms=12.00 max_ms=15.00 p95_ms=15.00 jitter_ms=1.00 packet_loss_percent=0.50
+would store 2025-01-02T03:04:05Z probe server="modem" power_dbmv=-2 snr_db=38.5
+`, buf.String())
+}
@@ -0,0 +1,326 @@
+package storage
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Datasources a Grafana dashboard can be generated for.
+const (
+	DashboardPrometheus = "prometheus"
+	DashboardInfluxDB   = "influxdb"
+)
+
+// _dashboardInput is the datasource chosen when the dashboard is imported into Grafana.
+const _dashboardInput = "DS_YANM"
+
+// DashboardOptions describes the metrics a Grafana dashboard charts, which have to match
+// the configuration of the storage writing them.
+type DashboardOptions struct {
+	// Datasource is DashboardPrometheus or DashboardInfluxDB.
+	Datasource string
+	Title      string
+
+	// Namespace and Labels are those given to NewPrometheusStorage.
+	Namespace string
+	Labels    []string
+
+	// Bucket is the InfluxDB bucket the points are written to.
+	Bucket string
+}
+
+// GrafanaDashboard is a dashboard in the JSON model Grafana imports, with the datasource
+// asked for on import.
+type GrafanaDashboard struct {
+	Inputs        []grafanaInput     `json:"__inputs"`
+	Title         string             `json:"title"`
+	UID           string             `json:"uid"`
+	Tags          []string           `json:"tags"`
+	Editable      bool               `json:"editable"`
+	Refresh       string             `json:"refresh"`
+	SchemaVersion int                `json:"schemaVersion"`
+	Time          grafanaTimeRange   `json:"time"`
+	Templating    grafanaTemplating  `json:"templating"`
+	Panels        []grafanaPanel     `json:"panels"`
+	Annotations   grafanaAnnotations `json:"annotations"`
+}
+
+type grafanaInput struct {
+	Name     string `json:"name"`
+	Label    string `json:"label"`
+	Type     string `json:"type"`
+	PluginID string `json:"pluginId"`
+}
+
+type grafanaDatasource struct {
+	Type string `json:"type"`
+	UID  string `json:"uid"`
+}
+
+type grafanaTimeRange struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+type grafanaTemplating struct {
+	List []grafanaVariable `json:"list"`
+}
+
+type grafanaVariable struct {
+	Name       string            `json:"name"`
+	Label      string            `json:"label"`
+	Type       string            `json:"type"`
+	Datasource grafanaDatasource `json:"datasource"`
+	Query      string            `json:"query"`
+	Refresh    int               `json:"refresh"`
+	Multi      bool              `json:"multi"`
+	IncludeAll bool              `json:"includeAll"`
+	Sort       int               `json:"sort"`
+}
+
+type grafanaAnnotations struct {
+	List []any `json:"list"`
+}
+
+type grafanaPanel struct {
+	ID          int                `json:"id"`
+	Type        string             `json:"type"`
+	Title       string             `json:"title"`
+	Description string             `json:"description,omitempty"`
+	Datasource  grafanaDatasource  `json:"datasource"`
+	GridPos     grafanaGridPos     `json:"gridPos"`
+	FieldConfig grafanaFieldConfig `json:"fieldConfig"`
+	Targets     []grafanaTarget    `json:"targets"`
+}
+
+type grafanaGridPos struct {
+	H int `json:"h"`
+	W int `json:"w"`
+	X int `json:"x"`
+	Y int `json:"y"`
+}
+
+type grafanaFieldConfig struct {
+	Defaults  grafanaFieldDefaults `json:"defaults"`
+	Overrides []any                `json:"overrides"`
+}
+
+type grafanaFieldDefaults struct {
+	Unit string `json:"unit,omitempty"`
+	Min  *int   `json:"min,omitempty"`
+}
+
+type grafanaTarget struct {
+	RefID      string            `json:"refId"`
+	Datasource grafanaDatasource `json:"datasource"`
+	// Expr and LegendFormat query Prometheus, Query is a Flux query.
+	Expr         string `json:"expr,omitempty"`
+	LegendFormat string `json:"legendFormat,omitempty"`
+	Query        string `json:"query,omitempty"`
+}
+
+// Grafana units of the panels.
+const (
+	_unitMbps    = "Mbits"
+	_unitMs      = "ms"
+	_unitPercent = "percent"
+	_unitFromNow = "dateTimeFromNow"
+	_unitBytes   = "decbytes"
+)
+
+// NewGrafanaDashboard returns a dashboard charting the speed tests, latency checks and
+// probe values stored by the Prometheus or InfluxDB storage configured like opts.
+func NewGrafanaDashboard(opts DashboardOptions) (*GrafanaDashboard, error) {
+	ds := grafanaDatasource{Type: opts.Datasource, UID: "${" + _dashboardInput + "}"}
+	d := &GrafanaDashboard{
+		Title:         opts.Title,
+		UID:           "yanm-" + opts.Datasource,
+		Tags:          []string{"yanm", "network"},
+		Editable:      true,
+		Refresh:       "1m",
+		SchemaVersion: 39,
+		Time:          grafanaTimeRange{From: "now-24h", To: "now"},
+		Templating:    grafanaTemplating{List: []grafanaVariable{}},
+		Annotations:   grafanaAnnotations{List: []any{}},
+	}
+	if d.Title == "" {
+		d.Title = "YANM Network Monitor"
+	}
+
+	var (
+		panels   []grafanaPanel
+		variable *grafanaVariable
+	)
+	switch opts.Datasource {
+	case DashboardPrometheus:
+		d.Inputs = []grafanaInput{{Name: _dashboardInput, Label: "Prometheus", Type: "datasource", PluginID: "prometheus"}}
+		panels, variable = prometheusPanels(opts)
+	case DashboardInfluxDB:
+		if opts.Bucket == "" {
+			return nil, fmt.Errorf("an influxdb bucket is required")
+		}
+		d.Inputs = []grafanaInput{{Name: _dashboardInput, Label: "InfluxDB", Type: "datasource", PluginID: "influxdb"}}
+		panels, variable = influxPanels(opts)
+	default:
+		return nil, fmt.Errorf("unknown dashboard datasource %q, must be %s or %s", opts.Datasource, DashboardPrometheus, DashboardInfluxDB)
+	}
+
+	if variable != nil {
+		variable.Datasource = ds
+		d.Templating.List = append(d.Templating.List, *variable)
+	}
+	// two panels a row, the stat panels are half as tall.
+	x, y := 0, 0
+	for i, p := range panels {
+		p.ID = i + 1
+		p.Datasource = ds
+		for j := range p.Targets {
+			p.Targets[j].RefID = string(rune('A' + j))
+			p.Targets[j].Datasource = ds
+		}
+		p.GridPos = grafanaGridPos{H: 8, W: 12, X: x, Y: y}
+		if p.Type == "stat" {
+			p.GridPos.H = 4
+		}
+		if x += 12; x == 24 {
+			x, y = 0, y+p.GridPos.H
+		}
+		d.Panels = append(d.Panels, p)
+	}
+	return d, nil
+}
+
+func newPanel(kind, title, unit, description string, targets ...grafanaTarget) grafanaPanel {
+	defaults := grafanaFieldDefaults{Unit: unit}
+	// speeds, latencies, losses and data are never negative, probe values may be.
+	if unit == _unitMbps || unit == _unitMs || unit == _unitPercent || unit == _unitBytes {
+		zero := 0
+		defaults.Min = &zero
+	}
+	return grafanaPanel{
+		Type:        kind,
+		Title:       title,
+		Description: description,
+		FieldConfig: grafanaFieldConfig{Defaults: defaults, Overrides: []any{}},
+		Targets:     targets,
+	}
+}
+
+// prometheusPanels queries the metrics registered by NewPrometheusStorage, grouping the
+// histograms by the server, agent, link and backend labels when they are attached, and the
+// most recent value gauges by the link and backend.
+func prometheusPanels(opts DashboardOptions) ([]grafanaPanel, *grafanaVariable) {
+	name := func(subsystem, name string) string {
+		return prometheus.BuildFQName(opts.Namespace, subsystem, name)
+	}
+
+	var by, legend []string
+	for _, label := range []string{LabelAgent, LabelLink, LabelBackend, LabelServer} {
+		if slices.Contains(opts.Labels, label) {
+			by = append(by, label)
+			legend = append(legend, "{{"+label+"}}")
+		}
+	}
+	selector := ""
+	var variable *grafanaVariable
+	if slices.Contains(opts.Labels, LabelServer) {
+		selector = `{server=~"$server"}`
+		variable = &grafanaVariable{
+			Name: LabelServer, Label: "Server", Type: "query",
+			Query:   fmt.Sprintf("label_values(%s_bucket, %s)", name("ping", "network_latency_ms"), LabelServer),
+			Refresh: 2, Multi: true, IncludeAll: true, Sort: 1,
+		}
+	}
+	quantile := func(q float64, metric, window, suffix string) grafanaTarget {
+		return grafanaTarget{
+			Expr: fmt.Sprintf("histogram_quantile(%g, sum by (%s) (rate(%s_bucket%s[%s])))",
+				q, strings.Join(append([]string{"le"}, by...), ", "), metric, selector, window),
+			LegendFormat: strings.TrimSpace(strings.Join(legend, " ") + " " + suffix),
+		}
+	}
+	dataBy, gaugeLegend := []string{LabelDirection}, ""
+	for _, label := range []string{LabelLink, LabelBackend} {
+		if slices.Contains(opts.Labels, label) {
+			dataBy, gaugeLegend = append(dataBy, label), gaugeLegend+"{{"+label+"}} "
+		}
+	}
+	dataLegend := gaugeLegend + "{{" + LabelDirection + "}}"
+	gauge := func(metric, legend string) grafanaTarget {
+		return grafanaTarget{Expr: metric, LegendFormat: strings.TrimSpace(gaugeLegend + legend)}
+	}
+
+	return []grafanaPanel{
+		newPanel("timeseries", "Speed", _unitMbps, "Download and upload speed measured by each speed test.",
+			gauge(name("speedtest", "last_download_mbps"), "download"),
+			gauge(name("speedtest", "last_upload_mbps"), "upload")),
+		newPanel("timeseries", "Median Speed by Server", _unitMbps, "Median speed over the speed tests of the last 6 hours.",
+			quantile(0.5, name("speedtest", "network_download_speed_mbps"), "6h", "download"),
+			quantile(0.5, name("speedtest", "network_upload_speed_mbps"), "6h", "upload")),
+		newPanel("timeseries", "Latency", _unitMs, "Most recent latency, and the 95th percentile of the latency checks.",
+			gauge(name("ping", "last_ping_ms"), "last"),
+			quantile(0.95, name("ping", "network_latency_ms"), "$__rate_interval", "p95")),
+		newPanel("timeseries", "Jitter", _unitMs, "Most recent jitter, and the 95th percentile of the latency checks.",
+			gauge(name("ping", "last_jitter_ms"), "last"),
+			quantile(0.95, name("ping", "network_jitter_ms"), "$__rate_interval", "p95")),
+		newPanel("timeseries", "Packet Loss", _unitPercent, "",
+			gauge(name("ping", "last_packet_loss_percent"), "packet loss")),
+		newPanel("timeseries", "Speed Test Data", _unitBytes, "Data transferred by the speed tests over the last day.",
+			grafanaTarget{
+				Expr:         fmt.Sprintf("sum by (%s) (increase(%s[1d]))", strings.Join(dataBy, ", "), name("speedtest", "data_bytes_total")),
+				LegendFormat: dataLegend,
+			}),
+		newPanel("timeseries", "Probe Values", "", "Values reported by exec and Go probes.",
+			grafanaTarget{Expr: name("probe", "value") + selector, LegendFormat: "{{server}} {{name}}"}),
+		newPanel("stat", "Last Speed Test", _unitFromNow, "",
+			gauge(name("speedtest", "last_test_timestamp_seconds")+" * 1000", "")),
+	}, variable
+}
+
+// influxPanels queries the measurements written by InfluxDBStorage, filtered by the
+// server tag.
+func influxPanels(opts DashboardOptions) ([]grafanaPanel, *grafanaVariable) {
+	variable := &grafanaVariable{
+		Name: LabelServer, Label: "Server", Type: "query",
+		Query:   fmt.Sprintf("import \"influxdata/influxdb/schema\"\nschema.tagValues(bucket: %q, tag: %q)", opts.Bucket, LabelServer),
+		Refresh: 2, Multi: true, IncludeAll: true, Sort: 1,
+	}
+	query := func(measurement string, fields ...string) grafanaTarget {
+		filter := fmt.Sprintf("r._measurement == %q", measurement)
+		if len(fields) > 0 {
+			quoted := make([]string, len(fields))
+			for i, field := range fields {
+				quoted[i] = fmt.Sprintf("r._field == %q", field)
+			}
+			filter += " and (" + strings.Join(quoted, " or ") + ")"
+		}
+		return grafanaTarget{Query: fmt.Sprintf(`from(bucket: %q)
+  |> range(start: v.timeRangeStart, stop: v.timeRangeStop)
+  |> filter(fn: (r) => %s)
+  |> filter(fn: (r) => r.server =~ /^${server:regex}$/)
+  |> aggregateWindow(every: v.windowPeriod, fn: mean, createEmpty: false)`, opts.Bucket, filter)}
+	}
+
+	return []grafanaPanel{
+		newPanel("timeseries", "Speed", _unitMbps, "Download and upload speed measured by each speed test.",
+			query(_measurementSpeedTest, "download_mbps", "upload_mbps")),
+		newPanel("timeseries", "Latency", _unitMs, "Latency of the latency checks and speed tests.",
+			query(_measurementPing, "latency_ms"),
+			query(_measurementSpeedTest, "ping_ms")),
+		newPanel("timeseries", "Jitter", _unitMs, "",
+			query(_measurementPing, "jitter_ms"),
+			query(_measurementSpeedTest, "jitter_ms")),
+		newPanel("timeseries", "Packet Loss", _unitPercent, "",
+			query(_measurementPing, "packet_loss_percent"),
+			query(_measurementSpeedTest, "packet_loss_percent"),
+			query(_measurementPingSum, "packet_loss_percent")),
+		newPanel("timeseries", "Latency Summary", _unitMs, "Windows of latency checks, stored when aggregation is enabled.",
+			query(_measurementPingSum, "min_ms", "avg_ms", "max_ms", "p95_ms", "jitter_ms")),
+		newPanel("timeseries", "Speed Test Data", _unitBytes, "Data transferred by each speed test.",
+			query(_measurementSpeedTest, "download_bytes", "upload_bytes")),
+		newPanel("timeseries", "Probe Values", "", "Values reported by exec and Go probes.",
+			query(_measurementProbe)),
+	}, variable
+}
@@ -0,0 +1,149 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// The dashboards must chart every metric the storage writes, so renaming one fails here.
+
+func TestNewGrafanaDashboard_Prometheus(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	ctx := context.Background()
+	ts := time.Unix(1700000000, 0)
+
+	testCases := []struct {
+		name         string
+		labels       []string
+		wantVariable bool
+		wantQuantile string
+	}{
+		{name: "all labels", labels: AllLabels, wantVariable: true, wantQuantile: "sum by (le, server)"},
+		{name: "agent", labels: []string{LabelServer, LabelAgent}, wantVariable: true, wantQuantile: "sum by (le, agent, server)"},
+		{name: "link", labels: []string{LabelServer, LabelLink}, wantVariable: true, wantQuantile: "sum by (le, link, server)"},
+		{name: "backend", labels: []string{LabelServer, LabelLink, LabelBackend}, wantVariable: true, wantQuantile: "sum by (le, link, backend, server)"},
+		{name: "no labels", labels: []string{}, wantQuantile: "sum by (le)"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			reg := prometheus.NewRegistry()
+			p, err := NewPrometheusStorage(logger, WithLabels(tc.labels...), WithNamespace("home"), WithRegisterer(reg))
+			require.NoError(t, err)
+			require.NoError(t, p.StoreNetworkPerformance(ctx, ts, 100, 20, 15, 1.5, 0, 150_000_000, 30_000_000, "Example", "1.0", "2.0"))
+			require.NoError(t, p.StorePingResult(ctx, ts, 12, 0.5, 0, "Example", "1.0", "2.0"))
+			require.NoError(t, p.StoreProbeValues(ctx, ts, "modem", map[string]float64{"snr_db": 38.5}))
+
+			d, err := NewGrafanaDashboard(DashboardOptions{Datasource: DashboardPrometheus, Namespace: "home", Labels: tc.labels})
+			require.NoError(t, err)
+			exprs := dashboardQueries(d)
+
+			families, err := reg.Gather()
+			require.NoError(t, err)
+			require.NotEmpty(t, families)
+			for _, family := range families {
+				assert.True(t, containsAny(exprs, family.GetName()), "metric %s is not on the dashboard", family.GetName())
+			}
+			assert.True(t, containsAny(exprs, tc.wantQuantile), "no quantile %s in %v", tc.wantQuantile, exprs)
+			assert.Equal(t, tc.wantVariable, len(d.Templating.List) == 1)
+		})
+	}
+}
+
+func TestNewGrafanaDashboard_InfluxDB(t *testing.T) {
+	var lines []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		lines = append(lines, strings.Split(strings.TrimSpace(string(body)), "\n")...)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	s, err := NewInfluxDBStorage(logger, InfluxDBConfig{URL: srv.URL, Org: "home", Bucket: "yanm"})
+	require.NoError(t, err)
+	defer s.Close(context.Background())
+
+	ctx := context.Background()
+	ts := time.Unix(1700000000, 0)
+	require.NoError(t, s.StoreNetworkPerformance(ctx, ts, 100, 20, 15, 1.5, 0, 150_000_000, 30_000_000, "Example", "1.0", "2.0"))
+	require.NoError(t, s.StorePingResult(ctx, ts, 12, 0.5, 0, "Example", "1.0", "2.0"))
+	require.NoError(t, s.StorePingSummary(ctx, PingSummary{Start: ts, End: ts.Add(time.Minute), ServerName: "Example", Count: 6}))
+	require.NoError(t, s.StoreProbeValues(ctx, ts, "modem", map[string]float64{"snr_db": 38.5}))
+
+	d, err := NewGrafanaDashboard(DashboardOptions{Datasource: DashboardInfluxDB, Bucket: "yanm"})
+	require.NoError(t, err)
+	queries := dashboardQueries(d)
+	require.Len(t, d.Templating.List, 1)
+	assert.Contains(t, d.Templating.List[0].Query, `schema.tagValues(bucket: "yanm", tag: "server")`)
+
+	// neither the location nor the size of a summary's window is charted.
+	notCharted := map[string]bool{LabelLatitude: true, LabelLongitude: true, "count": true, "window_seconds": true}
+	require.Len(t, lines, 4)
+	for _, line := range lines {
+		series, fields, _ := strings.Cut(line, " ")
+		measurement, _, _ := strings.Cut(series, ",")
+		filter := `r._measurement == "` + measurement + `"`
+		if measurement == _measurementProbe {
+			// every value a probe reports is charted.
+			assert.True(t, containsAny(queries, filter+")"), "probe values are not on the dashboard")
+			continue
+		}
+		fields, _, _ = strings.Cut(fields, " ")
+		for _, field := range strings.Split(fields, ",") {
+			key, _, _ := strings.Cut(field, "=")
+			if notCharted[key] {
+				continue
+			}
+			assert.True(t, containsAll(queries, filter, `r._field == "`+key+`"`), "%s %s is not on the dashboard", measurement, key)
+		}
+	}
+}
+
+func TestNewGrafanaDashboard_Errors(t *testing.T) {
+	_, err := NewGrafanaDashboard(DashboardOptions{Datasource: "graphite"})
+	require.EqualError(t, err, `unknown dashboard datasource "graphite", must be prometheus or influxdb`)
+
+	_, err = NewGrafanaDashboard(DashboardOptions{Datasource: DashboardInfluxDB})
+	require.EqualError(t, err, "an influxdb bucket is required")
+}
+
+// dashboardQueries returns the query of every panel target of d.
+func dashboardQueries(d *GrafanaDashboard) []string {
+	var queries []string
+	for _, p := range d.Panels {
+		for _, target := range p.Targets {
+			queries = append(queries, target.Expr+target.Query)
+		}
+	}
+	return queries
+}
+
+func containsAny(queries []string, substr string) bool {
+	return containsAll(queries, substr)
+}
+
+// containsAll reports whether one of queries contains every one of substrs.
+func containsAll(queries []string, substrs ...string) bool {
+	for _, q := range queries {
+		found := true
+		for _, substr := range substrs {
+			found = found && strings.Contains(q, substr)
+		}
+		if found {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,201 @@
+package storage
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/benbjohnson/clock"
+)
+
+// QueueDepther is implemented by backends that buffer writes before flushing
+// them, allowing the number of pending writes to be reported.
+type QueueDepther interface {
+	QueueDepth() int
+}
+
+// HealthStatus is a point-in-time snapshot of a storage backend's health.
+type HealthStatus struct {
+	Name           string    `json:"name"`
+	Healthy        bool      `json:"healthy"`
+	LastPing       time.Time `json:"last_ping"`
+	LastPingError  string    `json:"last_ping_error,omitempty"`
+	LastWrite      time.Time `json:"last_write"`
+	Writes         int64     `json:"writes"`
+	WriteErrors    int64     `json:"write_errors"`
+	LastWriteError string    `json:"last_write_error,omitempty"`
+	QueueDepth     int       `json:"queue_depth"`
+}
+
+// HealthTrackingStorage wraps a MetricsStorage, recording the outcome of every
+// write and periodically probing the backend with Ping.
+type HealthTrackingStorage struct {
+	MetricsStorage
+
+	interval   time.Duration
+	baseLogger *slog.Logger
+
+	mu     sync.RWMutex
+	name   string
+	logger *slog.Logger
+	status HealthStatus
+
+	// testing fields
+	clock clock.Clock
+}
+
+// Verify HealthTrackingStorage implements MetricsStorage interface
+var (
+	_ MetricsStorage   = (*HealthTrackingStorage)(nil)
+	_ ProbeValueStorer = (*HealthTrackingStorage)(nil)
+)
+
+// NewHealthTrackingStorage wraps backend so its health can be reported under name.
+// The backend is probed every interval once Run is called.
+func NewHealthTrackingStorage(
+	logger *slog.Logger,
+	name string,
+	backend MetricsStorage,
+	interval time.Duration,
+) *HealthTrackingStorage {
+	baseLogger := logger.With("component", "storage_health")
+	return &HealthTrackingStorage{
+		MetricsStorage: backend,
+		name:           name,
+		interval:       interval,
+		baseLogger:     baseLogger,
+		logger:         baseLogger.With("backend", name),
+		status:         HealthStatus{Name: name, Healthy: true},
+		clock:          clock.New(),
+	}
+}
+
+// Rename reports the health under name from now on, starting over as the backend it
+// names was replaced, e.g. after switching the metrics engine.
+func (h *HealthTrackingStorage) Rename(name string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.name = name
+	h.logger = h.baseLogger.With("backend", name)
+	h.status = HealthStatus{Name: name, Healthy: true}
+}
+
+// Run probes the backend until ctx is done.
+func (h *HealthTrackingStorage) Run(ctx context.Context) {
+	ticker := h.clock.Ticker(h.interval)
+	defer ticker.Stop()
+
+	_ = h.probe(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			_ = h.probe(ctx)
+		}
+	}
+}
+
+func (h *HealthTrackingStorage) probe(ctx context.Context) error {
+	err := h.MetricsStorage.Ping(ctx)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.status.LastPing = h.clock.Now()
+	h.status.LastPingError = ""
+	h.status.Healthy = err == nil
+	if err != nil {
+		h.status.LastPingError = err.Error()
+		h.logger.WarnContext(ctx, "Storage backend health check failed", "error", err)
+	}
+	return err
+}
+
+// Ping probes the backend and records the result.
+func (h *HealthTrackingStorage) Ping(ctx context.Context) error {
+	return h.probe(ctx)
+}
+
+// Healthy reports whether the most recent health check succeeded.
+func (h *HealthTrackingStorage) Healthy() bool {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.status.Healthy
+}
+
+// Status returns a snapshot of the backend's health.
+func (h *HealthTrackingStorage) Status() HealthStatus {
+	h.mu.RLock()
+	status := h.status
+	h.mu.RUnlock()
+
+	if q, ok := h.MetricsStorage.(QueueDepther); ok {
+		status.QueueDepth = q.QueueDepth()
+	}
+	return status
+}
+
+// StoreNetworkPerformance stores the result in the wrapped backend and records the outcome.
+func (h *HealthTrackingStorage) StoreNetworkPerformance(
+	ctx context.Context,
+	timestamp time.Time,
+	downloadSpeedMbps, uploadSpeedMbps float64,
+	pingMs int64,
+	jitterMs, packetLossPercent float64,
+	downloadBytes, uploadBytes int64,
+	serverName string,
+	lat, lon string,
+) error {
+	err := h.MetricsStorage.StoreNetworkPerformance(
+		ctx, timestamp, downloadSpeedMbps, uploadSpeedMbps, pingMs, jitterMs, packetLossPercent, downloadBytes, uploadBytes, serverName, lat, lon)
+	h.recordWrite(err)
+	return err
+}
+
+// StorePingResult stores the result in the wrapped backend and records the outcome.
+func (h *HealthTrackingStorage) StorePingResult(
+	ctx context.Context,
+	timestamp time.Time,
+	pingMs int64,
+	jitterMs, packetLossPercent float64,
+	serverName string,
+	lat, lon string,
+) error {
+	err := h.MetricsStorage.StorePingResult(ctx, timestamp, pingMs, jitterMs, packetLossPercent, serverName, lat, lon)
+	h.recordWrite(err)
+	return err
+}
+
+// StorePingSummary stores the summary in the wrapped backend and records the outcome.
+func (h *HealthTrackingStorage) StorePingSummary(ctx context.Context, summary PingSummary) error {
+	err := storePingSummary(ctx, h.MetricsStorage, summary)
+	h.recordWrite(err)
+	return err
+}
+
+// StoreProbeValues stores the values in the wrapped backend, if it supports them, and
+// records the outcome.
+func (h *HealthTrackingStorage) StoreProbeValues(ctx context.Context, timestamp time.Time, probe string, values map[string]float64) error {
+	if _, ok := h.MetricsStorage.(ProbeValueStorer); !ok {
+		return nil
+	}
+	err := StoreProbeValues(ctx, h.MetricsStorage, timestamp, probe, values)
+	h.recordWrite(err)
+	return err
+}
+
+func (h *HealthTrackingStorage) recordWrite(err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.status.Writes++
+	if err != nil {
+		h.status.WriteErrors++
+		h.status.LastWriteError = err.Error()
+		return
+	}
+	h.status.LastWrite = h.clock.Now()
+}
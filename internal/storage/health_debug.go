@@ -0,0 +1,78 @@
+package storage
+
+import (
+	"html/template"
+	"net/http"
+
+	"yanm/internal/debughttp"
+	"yanm/internal/debughttp/debughandler"
+)
+
+const storageDebugHTMLTemplate = `
+<h1>Storage Backends</h1>
+{{if .}}
+<table>
+    <tr>
+        <th>Backend</th>
+        <th>Healthy</th>
+        <th>Last Health Check</th>
+        <th>Last Successful Write</th>
+        <th>Writes</th>
+        <th>Write Errors</th>
+        <th>Queue Depth</th>
+        <th>Last Error</th>
+    </tr>
+    {{range .}}
+    <tr>
+        <td>{{.Name}}</td>
+        <td>{{if .Healthy}}Yes{{else}}No{{end}}</td>
+        <td>{{if .LastPing.IsZero}}Never{{else}}{{.LastPing.Format "2006-01-02 15:04:05"}}{{end}}</td>
+        <td>{{if .LastWrite.IsZero}}Never{{else}}{{.LastWrite.Format "2006-01-02 15:04:05"}}{{end}}</td>
+        <td>{{.Writes}}</td>
+        <td>{{.WriteErrors}}</td>
+        <td>{{.QueueDepth}}</td>
+        <td>{{if .LastPingError}}{{.LastPingError}}{{else}}{{.LastWriteError}}{{end}}</td>
+    </tr>
+    {{end}}
+</table>
+{{else}}
+<p>No storage backends configured.</p>
+{{end}}
+`
+
+var _storageTmpl = template.Must(template.New("storage_debug").Parse(storageDebugHTMLTemplate))
+
+type storagePage struct {
+	backends []*HealthTrackingStorage
+}
+
+// NewStorageDebugPageProvider creates a new debug page provider reporting the health of the given backends.
+// The handler returned is the raw content-producing handler.
+func NewStorageDebugPageProvider(backends ...*HealthTrackingStorage) http.Handler {
+	p := &storagePage{
+		backends: backends,
+	}
+	return debughandler.NewNegotiatingHandler(p.statuses, _storageTmpl)
+}
+
+func (p *storagePage) statuses(*http.Request) (any, error) {
+	statuses := make([]HealthStatus, 0, len(p.backends))
+	for _, b := range p.backends {
+		statuses = append(statuses, b.Status())
+	}
+	return statuses, nil
+}
+
+var _ debughttp.PageProvider = (*HealthTrackingStorage)(nil)
+
+// DebugRoutes returns the storage debug page route, reporting the health of h.
+func (h *HealthTrackingStorage) DebugRoutes() []debughttp.DebugRoute {
+	return []debughttp.DebugRoute{{
+		Path:        "/debug/storage",
+		Name:        "Storage",
+		Description: "Displays the health of the metrics storage backends.",
+		Handler:     debughandler.NewHTMLProducingHandler(NewStorageDebugPageProvider(h)),
+		Group:       "System",
+		Order:       50,
+	}}
+}
@@ -0,0 +1,78 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"yanm/internal/storage/storagemock"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHealthTrackingStorage(t *testing.T) {
+	ctx := context.Background()
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+
+	mockCtrl := gomock.NewController(t)
+	backend := storagemock.NewMockMetricsStorage(mockCtrl)
+
+	h := NewHealthTrackingStorage(logger, "mock", backend, time.Minute)
+	require.True(t, h.Healthy(), "backends start healthy until proven otherwise")
+
+	backend.EXPECT().Ping(gomock.Any()).Return(errors.New("connection refused"))
+	require.Error(t, h.Ping(ctx))
+	assert.False(t, h.Healthy())
+	assert.Equal(t, "connection refused", h.Status().LastPingError)
+
+	backend.EXPECT().Ping(gomock.Any()).Return(nil)
+	require.NoError(t, h.Ping(ctx))
+	assert.True(t, h.Healthy())
+	assert.Empty(t, h.Status().LastPingError)
+
+	backend.EXPECT().StorePingResult(gomock.Any(), gomock.Any(), int64(12), 0.5, -1.0, "server", "1", "2").Return(nil)
+	require.NoError(t, h.StorePingResult(ctx, time.Now(), 12, 0.5, -1, "server", "1", "2"))
+
+	backend.EXPECT().StorePingResult(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(errors.New("write failed"))
+	require.Error(t, h.StorePingResult(ctx, time.Now(), 12, 0.5, -1, "server", "1", "2"))
+
+	status := h.Status()
+	assert.Equal(t, "mock", status.Name)
+	assert.Equal(t, int64(2), status.Writes)
+	assert.Equal(t, int64(1), status.WriteErrors)
+	assert.Equal(t, "write failed", status.LastWriteError)
+	assert.False(t, status.LastWrite.IsZero())
+
+	h.Rename("other")
+	assert.Equal(t, HealthStatus{Name: "other", Healthy: true}, h.Status())
+}
+
+func TestStorageDebugPage(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	h := NewHealthTrackingStorage(logger, "no-op", NewNoOpStorage(logger), time.Minute)
+
+	page := NewStorageDebugPageProvider(h)
+
+	req := httptest.NewRequest(http.MethodGet, "/debug/storage/", nil)
+	req.Header.Set("Accept", "text/html")
+	rr := httptest.NewRecorder()
+	page.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), "<td>no-op</td>")
+
+	req = httptest.NewRequest(http.MethodGet, "/debug/storage/", nil)
+	req.Header.Set("Accept", "application/json")
+	rr = httptest.NewRecorder()
+	page.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+	assert.Contains(t, rr.Body.String(), `"name":"no-op"`)
+}
@@ -0,0 +1,256 @@
+package storage
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log/slog"
+	"maps"
+	"net/http"
+	"os"
+	"time"
+
+	influxdb2 "github.com/influxdata/influxdb-client-go/v2"
+	"github.com/influxdata/influxdb-client-go/v2/api"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const (
+	_measurementSpeedTest = "speedtest"
+	_measurementPing      = "ping"
+	_measurementPingSum   = "ping_summary"
+	_measurementProbe     = "probe"
+)
+
+// InfluxDBConfig holds the connection settings for an InfluxDB v2 server.
+type InfluxDBConfig struct {
+	URL    string
+	Token  string
+	Org    string
+	Bucket string
+
+	// Tags are applied to every point written, in addition to the server tag.
+	Tags map[string]string
+
+	// CAFile is a PEM encoded CA bundle used to verify the server, in addition to the system roots.
+	CAFile string
+	// InsecureSkipVerify disables verification of the server certificate.
+	InsecureSkipVerify bool
+	// Timeout bounds each HTTP request, zero uses the client default.
+	Timeout time.Duration
+}
+
+// InfluxDBStorage writes network performance metrics to InfluxDB
+type InfluxDBStorage struct {
+	client influxdb2.Client
+	writer api.WriteAPIBlocking
+	tags   map[string]string
+
+	logger *slog.Logger
+}
+
+// Verify InfluxDBStorage implements MetricsStorage interface
+var (
+	_ MetricsStorage    = (*InfluxDBStorage)(nil)
+	_ PingSummaryStorer = (*InfluxDBStorage)(nil)
+	_ ProbeValueStorer  = (*InfluxDBStorage)(nil)
+)
+
+// NewInfluxDBStorage creates a new InfluxDB storage client
+func NewInfluxDBStorage(logger *slog.Logger, cfg InfluxDBConfig) (*InfluxDBStorage, error) {
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("influxdb url is required")
+	}
+	if _, ok := cfg.Tags[LabelServer]; ok {
+		return nil, fmt.Errorf("influxdb tag %q is reserved", LabelServer)
+	}
+
+	options := influxdb2.DefaultOptions()
+	if cfg.Timeout > 0 {
+		options.SetHTTPRequestTimeout(uint(cfg.Timeout.Seconds()))
+	}
+	if cfg.CAFile != "" || cfg.InsecureSkipVerify {
+		tlsConfig, err := newTLSConfig(cfg.CAFile, cfg.InsecureSkipVerify)
+		if err != nil {
+			return nil, err
+		}
+		options.SetTLSConfig(tlsConfig)
+	}
+
+	client := influxdb2.NewClientWithOptions(cfg.URL, cfg.Token, options)
+	return &InfluxDBStorage{
+		client: client,
+		writer: client.WriteAPIBlocking(cfg.Org, cfg.Bucket),
+		tags:   maps.Clone(cfg.Tags),
+		logger: logger,
+	}, nil
+}
+
+// newTLSConfig builds a client TLS configuration trusting the CA bundle in caFile.
+func newTLSConfig(caFile string, insecureSkipVerify bool) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: insecureSkipVerify, //nolint:gosec // explicitly requested by configuration
+	}
+	if caFile == "" {
+		return tlsConfig, nil
+	}
+
+	pem, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read influxdb ca file: %w", err)
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates found in influxdb ca file %s", caFile)
+	}
+	tlsConfig.RootCAs = pool
+	return tlsConfig, nil
+}
+
+// pointTags returns the configured tags and result labels plus the server, agent, link and
+// backend tags for a single point.
+func (i *InfluxDBStorage) pointTags(ctx context.Context, serverName string) map[string]string {
+	labels := ResultLabels(ctx)
+	tags := make(map[string]string, len(i.tags)+len(labels)+4)
+	maps.Copy(tags, i.tags)
+	maps.Copy(tags, labels)
+	tags[LabelServer] = serverName
+	if agent := Agent(ctx); agent != "" {
+		tags[LabelAgent] = agent
+	}
+	if link := Link(ctx); link != "" {
+		tags[LabelLink] = link
+	}
+	if backend := Backend(ctx); backend != "" {
+		tags[LabelBackend] = backend
+	}
+	return tags
+}
+
+// StoreNetworkPerformance writes a speedtest point to InfluxDB
+func (i *InfluxDBStorage) StoreNetworkPerformance(
+	ctx context.Context,
+	timestamp time.Time,
+	downloadSpeedMbps, uploadSpeedMbps float64,
+	pingMs int64,
+	jitterMs, packetLossPercent float64,
+	downloadBytes, uploadBytes int64,
+	serverName string,
+	latitude, longitude string,
+) error {
+	fields := map[string]any{
+		"download_mbps":  downloadSpeedMbps,
+		"upload_mbps":    uploadSpeedMbps,
+		"ping_ms":        pingMs,
+		"jitter_ms":      jitterMs,
+		"download_bytes": downloadBytes,
+		"upload_bytes":   uploadBytes,
+		LabelLatitude:    latitude,
+		LabelLongitude:   longitude,
+	}
+	if packetLossPercent >= 0 {
+		fields["packet_loss_percent"] = packetLossPercent
+	}
+
+	point := influxdb2.NewPoint(_measurementSpeedTest, i.pointTags(ctx, serverName), fields, timestamp)
+
+	if err := i.writer.WritePoint(ctx, point); err != nil {
+		return fmt.Errorf("failed to write speedtest point: %w", err)
+	}
+	return nil
+}
+
+// StorePingResult writes a ping point to InfluxDB
+func (i *InfluxDBStorage) StorePingResult(
+	ctx context.Context,
+	timestamp time.Time,
+	pingMs int64,
+	jitterMs, packetLossPercent float64,
+	serverName string,
+	latitude, longitude string,
+) error {
+	fields := map[string]any{
+		"latency_ms":   pingMs,
+		"jitter_ms":    jitterMs,
+		LabelLatitude:  latitude,
+		LabelLongitude: longitude,
+	}
+	if packetLossPercent >= 0 {
+		fields["packet_loss_percent"] = packetLossPercent
+	}
+
+	point := influxdb2.NewPoint(_measurementPing, i.pointTags(ctx, serverName), fields, timestamp)
+
+	if err := i.writer.WritePoint(ctx, point); err != nil {
+		return fmt.Errorf("failed to write ping point: %w", err)
+	}
+	return nil
+}
+
+// StoreProbeValues writes a probe point to InfluxDB, with a field for each value and the
+// probe as the server tag.
+func (i *InfluxDBStorage) StoreProbeValues(ctx context.Context, timestamp time.Time, probe string, values map[string]float64) error {
+	fields := make(map[string]any, len(values))
+	for name, value := range values {
+		fields[name] = value
+	}
+
+	point := influxdb2.NewPoint(_measurementProbe, i.pointTags(ctx, probe), fields, timestamp)
+
+	if err := i.writer.WritePoint(ctx, point); err != nil {
+		return fmt.Errorf("failed to write probe point: %w", err)
+	}
+	return nil
+}
+
+// StorePingSummary writes a ping summary point to InfluxDB, timestamped at the end of the window.
+func (i *InfluxDBStorage) StorePingSummary(ctx context.Context, summary PingSummary) error {
+	fields := map[string]any{
+		"count":          summary.Count,
+		"min_ms":         summary.MinMs,
+		"avg_ms":         summary.AvgMs,
+		"max_ms":         summary.MaxMs,
+		"p95_ms":         summary.P95Ms,
+		"jitter_ms":      summary.JitterMs,
+		"window_seconds": summary.End.Sub(summary.Start).Seconds(),
+		LabelLatitude:    summary.Latitude,
+		LabelLongitude:   summary.Longitude,
+	}
+	if summary.PacketLossPercent >= 0 {
+		fields["packet_loss_percent"] = summary.PacketLossPercent
+	}
+
+	point := influxdb2.NewPoint(_measurementPingSum, i.pointTags(ctx, summary.ServerName), fields, summary.End)
+
+	if err := i.writer.WritePoint(ctx, point); err != nil {
+		return fmt.Errorf("failed to write ping summary point: %w", err)
+	}
+	return nil
+}
+
+// Ping checks that the InfluxDB server is reachable.
+func (i *InfluxDBStorage) Ping(ctx context.Context) error {
+	ok, err := i.client.Ping(ctx)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("influxdb server %s is not ready", i.client.ServerURL())
+	}
+	return nil
+}
+
+// MetricsHTTPHandler serves the process metrics, results themselves live in InfluxDB.
+func (i *InfluxDBStorage) MetricsHTTPHandler() http.Handler {
+	return promhttp.Handler()
+}
+
+// Close terminates the InfluxDB client
+func (i *InfluxDBStorage) Close(_ context.Context) {
+	i.client.Close()
+}
@@ -0,0 +1,131 @@
+package storage
+
+import (
+	"context"
+	"encoding/pem"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInfluxDBStorage_Tags(t *testing.T) {
+	// sent by the handler before it answers, so a line is ready once a write returns.
+	lines := make(chan string, 5)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+		lines <- string(body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	s, err := NewInfluxDBStorage(logger, InfluxDBConfig{
+		URL:    srv.URL,
+		Token:  "token",
+		Org:    "home",
+		Bucket: "yanm",
+		Tags:   map[string]string{"host": "mybox", "site": "cabin"},
+	})
+	require.NoError(t, err)
+	defer s.Close(context.Background())
+
+	require.NoError(t, s.StorePingResult(context.Background(), time.Unix(1700000000, 0), 12, 0.5, 2, "Example", "1.0", "2.0"))
+	line := <-lines
+	assert.Contains(t, line, "ping,host=mybox,server=Example,site=cabin ")
+	assert.Contains(t, line, "latency_ms=12i")
+	assert.Contains(t, line, "jitter_ms=0.5")
+	assert.Contains(t, line, "packet_loss_percent=2")
+
+	// results received by a central server are tagged with their agent.
+	require.NoError(t, s.StorePingResult(WithAgent(context.Background(), "office"), time.Unix(1700000000, 0), 12, 0.5, 2, "Example", "1.0", "2.0"))
+	line = <-lines
+	assert.Contains(t, line, "ping,agent=office,host=mybox,server=Example,site=cabin ")
+
+	// and results measured over a configured link with the link.
+	require.NoError(t, s.StorePingResult(WithLink(context.Background(), "lte"), time.Unix(1700000000, 0), 40, 3, 0, "Example", "1.0", "2.0"))
+	line = <-lines
+	assert.Contains(t, line, "ping,host=mybox,link=lte,server=Example,site=cabin ")
+
+	// and speed tests of compared backends with the backend.
+	require.NoError(t, s.StorePingResult(WithBackend(context.Background(), "librespeed"), time.Unix(1700000000, 0), 14, 1, 0, "Example", "1.0", "2.0"))
+	line = <-lines
+	assert.Contains(t, line, "ping,backend=librespeed,host=mybox,server=Example,site=cabin ")
+
+	// the result labels are added to, and win over, the configured tags.
+	require.NoError(t, s.StorePingResult(WithResultLabels(context.Background(), map[string]string{"site": "office", "rack": "2"}),
+		time.Unix(1700000000, 0), 14, 1, 0, "Example", "1.0", "2.0"))
+	line = <-lines
+	assert.Contains(t, line, "ping,host=mybox,rack=2,server=Example,site=office ")
+	assert.Empty(t, lines)
+}
+
+func TestNewInfluxDBStorage_Errors(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+
+	_, err := NewInfluxDBStorage(logger, InfluxDBConfig{})
+	require.EqualError(t, err, "influxdb url is required")
+
+	_, err = NewInfluxDBStorage(logger, InfluxDBConfig{URL: "http://localhost:8086", Tags: map[string]string{"server": "x"}})
+	require.EqualError(t, err, `influxdb tag "server" is reserved`)
+}
+
+func TestInfluxDBStorage_TLS(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, os.WriteFile(caFile,
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw}), 0o600))
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	ctx := context.Background()
+
+	testCases := []struct {
+		name        string
+		cfg         InfluxDBConfig
+		expectError string
+	}{
+		{
+			name:        "untrusted certificate",
+			cfg:         InfluxDBConfig{URL: srv.URL},
+			expectError: "certificate",
+		},
+		{
+			name: "custom ca",
+			cfg:  InfluxDBConfig{URL: srv.URL, CAFile: caFile, Timeout: time.Second},
+		},
+		{
+			name: "insecure skip verify",
+			cfg:  InfluxDBConfig{URL: srv.URL, InsecureSkipVerify: true},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s, err := NewInfluxDBStorage(logger, tc.cfg)
+			require.NoError(t, err)
+			defer s.Close(ctx)
+
+			err = s.StorePingResult(ctx, time.Now(), 12, 0, -1, "Example", "1.0", "2.0")
+			if tc.expectError != "" {
+				require.ErrorContains(t, err, tc.expectError)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+
+	_, err := NewInfluxDBStorage(logger, InfluxDBConfig{URL: srv.URL, CAFile: filepath.Join(t.TempDir(), "missing.pem")})
+	require.ErrorContains(t, err, "failed to read influxdb ca file")
+}
@@ -8,6 +8,10 @@ import (
 
 // MetricsStorage defines the interface for storing network performance metrics
 //
+// Jitter is reported in milliseconds alongside the latency it was measured with.
+// packetLossPercent is in the range 0-100, or negative when loss was not measured
+// and should not be persisted.
+//
 //go:generate mockgen -source interface.go -destination storagemock/storage_mock.go -package storagemock
 type MetricsStorage interface {
 	// StoreNetworkPerformance stores the network performance metrics
@@ -16,6 +20,8 @@ type MetricsStorage interface {
 		timestamp time.Time,
 		downloadSpeedMbps, uploadSpeedMbps float64,
 		pingMs int64,
+		jitterMs, packetLossPercent float64,
+		downloadBytes, uploadBytes int64,
 		serverName string,
 		lat, lon string,
 	) error
@@ -25,10 +31,14 @@ type MetricsStorage interface {
 		ctx context.Context,
 		timestamp time.Time,
 		pingMs int64,
+		jitterMs, packetLossPercent float64,
 		serverName string,
 		lat, lon string,
 	) error
 
+	// Ping checks that the backend is reachable and able to accept writes.
+	Ping(ctx context.Context) error
+
 	// Close terminates the storage connection and performs any final operations
 	Close(ctx context.Context)
 
@@ -0,0 +1,32 @@
+package storage
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+type resultLabelsKey struct{}
+
+// WithResultLabels returns a context storing results carrying the configured labels, such
+// as the host or site that measured them. The labels must not be modified afterwards.
+func WithResultLabels(ctx context.Context, labels map[string]string) context.Context {
+	return context.WithValue(ctx, resultLabelsKey{}, labels)
+}
+
+// ResultLabels returns the labels set by WithResultLabels, nil when none are configured.
+func ResultLabels(ctx context.Context) map[string]string {
+	labels, _ := ctx.Value(resultLabelsKey{}).(map[string]string)
+	return labels
+}
+
+// formatLabels formats labels as space separated name="value" pairs sorted by name.
+func formatLabels(labels map[string]string) string {
+	pairs := make([]string, 0, len(labels))
+	for _, name := range slices.Sorted(maps.Keys(labels)) {
+		pairs = append(pairs, name+"="+strconv.Quote(labels[name]))
+	}
+	return strings.Join(pairs, " ")
+}
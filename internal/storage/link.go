@@ -0,0 +1,20 @@
+package storage
+
+import "context"
+
+// LabelLink names the uplink a result was measured over, on multi-WAN hosts.
+const LabelLink = "link"
+
+type linkKey struct{}
+
+// WithLink returns a context storing results measured over the named uplink,
+// so backends can tell the results of several links apart.
+func WithLink(ctx context.Context, link string) context.Context {
+	return context.WithValue(ctx, linkKey{}, link)
+}
+
+// Link returns the link set by WithLink, empty for results measured over the default route.
+func Link(ctx context.Context) string {
+	link, _ := ctx.Value(linkKey{}).(string)
+	return link
+}
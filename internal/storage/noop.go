@@ -14,7 +14,11 @@ type NoOpStorage struct {
 }
 
 // Verify that NoOpStorage implements MetricsStorage interface
-var _ MetricsStorage = (*NoOpStorage)(nil)
+var (
+	_ MetricsStorage    = (*NoOpStorage)(nil)
+	_ PingSummaryStorer = (*NoOpStorage)(nil)
+	_ ProbeValueStorer  = (*NoOpStorage)(nil)
+)
 
 // NewNoOpStorage creates a new NoOpStorage instance
 func NewNoOpStorage(logger *slog.Logger) *NoOpStorage {
@@ -29,6 +33,8 @@ func (n *NoOpStorage) StoreNetworkPerformance(
 	_ time.Time,
 	downloadSpeedMbps, uploadSpeedMbps float64,
 	ping int64,
+	jitterMs, packetLossPercent float64,
+	downloadBytes, uploadBytes int64,
 	serverName string,
 	lat, lon string,
 ) error {
@@ -36,6 +42,10 @@ func (n *NoOpStorage) StoreNetworkPerformance(
 		"downloadSpeedMbps", downloadSpeedMbps,
 		"uploadSpeedMbps", uploadSpeedMbps,
 		"ping", ping,
+		"jitterMs", jitterMs,
+		"packetLossPercent", packetLossPercent,
+		"downloadBytes", downloadBytes,
+		"uploadBytes", uploadBytes,
 		"serverName", serverName,
 		"lat", lat,
 		"lon", lon)
@@ -47,17 +57,45 @@ func (n *NoOpStorage) StorePingResult(
 	ctx context.Context,
 	_ time.Time,
 	pingMs int64,
+	jitterMs, packetLossPercent float64,
 	serverName string,
 	lat, lon string,
 ) error {
 	n.logger.InfoContext(ctx, "NoOpStorage: logging ping result",
 		"pingMs", pingMs,
+		"jitterMs", jitterMs,
+		"packetLossPercent", packetLossPercent,
 		"serverName", serverName,
 		"lat", lat,
 		"lon", lon)
 	return nil
 }
 
+// StoreProbeValues does nothing and always returns nil
+func (n *NoOpStorage) StoreProbeValues(ctx context.Context, _ time.Time, probe string, values map[string]float64) error {
+	n.logger.InfoContext(ctx, "NoOpStorage: logging probe values",
+		"probe", probe,
+		"values", values)
+	return nil
+}
+
+// StorePingSummary does nothing and always returns nil
+func (n *NoOpStorage) StorePingSummary(ctx context.Context, summary PingSummary) error {
+	n.logger.InfoContext(ctx, "NoOpStorage: logging ping summary",
+		"count", summary.Count,
+		"minMs", summary.MinMs,
+		"avgMs", summary.AvgMs,
+		"maxMs", summary.MaxMs,
+		"p95Ms", summary.P95Ms,
+		"serverName", summary.ServerName)
+	return nil
+}
+
+// Ping always succeeds
+func (n *NoOpStorage) Ping(_ context.Context) error {
+	return nil
+}
+
 // Close does nothing
 func (n *NoOpStorage) Close(_ context.Context) {
 	// No-op
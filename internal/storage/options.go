@@ -0,0 +1,73 @@
+package storage
+
+import "github.com/prometheus/client_golang/prometheus"
+
+type prometheusOptions struct {
+	labels       []string
+	resultLabels []string
+	namespace    string
+	registerer   prometheus.Registerer
+}
+
+// PrometheusOption configures a PrometheusStorage.
+type PrometheusOption interface {
+	apply(*prometheusOptions)
+}
+
+type labelsOption struct {
+	labels []string
+}
+
+func (o *labelsOption) apply(opts *prometheusOptions) {
+	opts.labels = o.labels
+}
+
+// WithLabels restricts the labels attached to the speed and latency histograms.
+// Valid labels are LabelServer, LabelLatitude, LabelLongitude, LabelAgent, LabelLink and
+// LabelBackend; passing none drops them all.
+func WithLabels(labels ...string) PrometheusOption {
+	return &labelsOption{labels}
+}
+
+type resultLabelsOption struct {
+	names []string
+}
+
+func (o *resultLabelsOption) apply(opts *prometheusOptions) {
+	opts.resultLabels = o.names
+}
+
+// WithResultLabelNames attaches the named result labels, set by WithResultLabels on the
+// context, to the histograms, the most recent value gauges and the data counter. Results
+// without one of them get an empty value.
+func WithResultLabelNames(names ...string) PrometheusOption {
+	return &resultLabelsOption{names}
+}
+
+type namespaceOption struct {
+	namespace string
+}
+
+func (o *namespaceOption) apply(opts *prometheusOptions) {
+	opts.namespace = o.namespace
+}
+
+// WithNamespace prefixes the name of every metric with namespace.
+func WithNamespace(namespace string) PrometheusOption {
+	return &namespaceOption{namespace}
+}
+
+type registererOption struct {
+	registerer prometheus.Registerer
+}
+
+func (o *registererOption) apply(opts *prometheusOptions) {
+	opts.registerer = o.registerer
+}
+
+// WithRegisterer registers the metrics with registerer instead of the default registry.
+// The metrics are still served from the default gatherer, so registerer is expected
+// to wrap prometheus.DefaultRegisterer, e.g. to attach constant labels.
+func WithRegisterer(registerer prometheus.Registerer) PrometheusOption {
+	return &registererOption{registerer}
+}
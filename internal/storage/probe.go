@@ -0,0 +1,21 @@
+package storage
+
+import (
+	"context"
+	"time"
+)
+
+// ProbeValueStorer is implemented by backends that can store the named values reported
+// by an exec probe, e.g. a modem's signal to noise ratio. Backends that do not implement
+// it only store the probe's latency, as a ping result.
+type ProbeValueStorer interface {
+	StoreProbeValues(ctx context.Context, timestamp time.Time, probe string, values map[string]float64) error
+}
+
+// StoreProbeValues writes the values reported by probe to backend, if it supports them.
+func StoreProbeValues(ctx context.Context, backend MetricsStorage, timestamp time.Time, probe string, values map[string]float64) error {
+	if s, ok := backend.(ProbeValueStorer); ok {
+		return s.StoreProbeValues(ctx, timestamp, probe, values)
+	}
+	return nil
+}
@@ -2,10 +2,14 @@ package storage
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
 	"net/http"
+	"slices"
 	"time"
 
+	"yanm/internal/tracing"
+
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
@@ -17,12 +21,61 @@ type PrometheusStorage struct {
 	downloadSpeed *prometheus.HistogramVec
 	uploadSpeed   *prometheus.HistogramVec
 	pingLatency   *prometheus.HistogramVec
+	pingJitter    *prometheus.HistogramVec
+
+	// gauges holding the most recent values, histograms make "current speed" awkward to graph.
+	// They are labelled by link and backend only, when LabelLink and LabelBackend are attached,
+	// and by the result labels.
+	lastDownloadSpeed *prometheus.GaugeVec
+	lastUploadSpeed   *prometheus.GaugeVec
+	lastPingLatency   *prometheus.GaugeVec
+	lastTestTimestamp *prometheus.GaugeVec
+	lastJitter        *prometheus.GaugeVec
+	lastPacketLoss    *prometheus.GaugeVec
+
+	// dataBytes counts the data transferred by the speed tests, partitioned by direction,
+	// by link and backend when LabelLink and LabelBackend are attached, and by the result labels.
+	dataBytes *prometheus.CounterVec
+
+	// probeValues holds the latest value of every name reported by each exec probe.
+	probeValues *prometheus.GaugeVec
+
+	labels []string
+	// resultLabels are the names of the result labels attached after labels.
+	resultLabels []string
+
+	// registerer holds the collectors until Close.
+	registerer prometheus.Registerer
+	collectors []prometheus.Collector
 
 	logger *slog.Logger
 }
 
 // Verify PrometheusStorage implements MetricsStorage interface
-var _ MetricsStorage = (*PrometheusStorage)(nil)
+var (
+	_ MetricsStorage   = (*PrometheusStorage)(nil)
+	_ ProbeValueStorer = (*PrometheusStorage)(nil)
+)
+
+// Label names available on the speed and latency histograms.
+const (
+	LabelServer    = "server"
+	LabelLatitude  = "latitude"
+	LabelLongitude = "longitude"
+)
+
+// LabelDirection partitions the speed test data counter, by DirectionDownload or
+// DirectionUpload.
+const (
+	LabelDirection    = "direction"
+	DirectionDownload = "download"
+	DirectionUpload   = "upload"
+)
+
+// AllLabels lists the histogram labels attached by default, in order. LabelAgent is only
+// useful on a central server, LabelLink on multi-WAN hosts and LabelBackend when comparing
+// speed test backends, they have to be asked for.
+var AllLabels = []string{LabelServer, LabelLatitude, LabelLongitude}
 
 // want whole numbers, but not linerar.
 var _pingBuckets = []float64{
@@ -34,68 +87,327 @@ var _pingBuckets = []float64{
 }
 
 // NewPrometheusStorage creates a new Prometheus storage client
-func NewPrometheusStorage(logger *slog.Logger) (*PrometheusStorage, error) {
-	// Create metrics using promauto
-	downloadSpeed := promauto.NewHistogramVec(prometheus.HistogramOpts{
+func NewPrometheusStorage(logger *slog.Logger, opts ...PrometheusOption) (*PrometheusStorage, error) {
+	opt := &prometheusOptions{
+		labels:     AllLabels,
+		registerer: prometheus.DefaultRegisterer,
+	}
+
+	for _, o := range opts {
+		o.apply(opt)
+	}
+
+	for _, label := range opt.labels {
+		switch label {
+		case LabelServer, LabelLatitude, LabelLongitude, LabelAgent, LabelLink, LabelBackend:
+		default:
+			return nil, fmt.Errorf("unknown prometheus label %q", label)
+		}
+	}
+	var gaugeLabels []string
+	for _, label := range []string{LabelLink, LabelBackend} {
+		if slices.Contains(opt.labels, label) {
+			gaugeLabels = append(gaugeLabels, label)
+		}
+	}
+	gaugeLabels = append(gaugeLabels, opt.resultLabels...)
+	histogramLabels := append(slices.Clip(opt.labels), opt.resultLabels...)
+
+	// Create metrics using promauto, registered below so a failure is returned rather than a panic.
+	factory := promauto.With(nil)
+
+	downloadSpeed := factory.NewHistogramVec(prometheus.HistogramOpts{
 		Name:      "network_download_speed_mbps",
 		Help:      "Network download speed in Mbps",
+		Namespace: opt.namespace,
 		Subsystem: "speedtest",
 		Buckets:   prometheus.LinearBuckets(0, 25, 20), // up to 500mbps
-	}, []string{"server", "latitude", "longitude"})
+	}, histogramLabels)
 
-	uploadSpeed := promauto.NewHistogramVec(prometheus.HistogramOpts{
+	uploadSpeed := factory.NewHistogramVec(prometheus.HistogramOpts{
 		Name:      "network_upload_speed_mbps",
 		Help:      "Network upload speed in Mbps",
+		Namespace: opt.namespace,
 		Subsystem: "speedtest",
 		Buckets:   prometheus.LinearBuckets(0, 25, 20), // up to 500mbps
-	}, []string{"server", "latitude", "longitude"})
+	}, histogramLabels)
 
-	pingLatency := promauto.NewHistogramVec(prometheus.HistogramOpts{
+	pingLatency := factory.NewHistogramVec(prometheus.HistogramOpts{
 		Name:      "network_latency_ms",
 		Help:      "Network ping latency in milliseconds",
+		Namespace: opt.namespace,
+		Subsystem: "ping",
+		Buckets:   _pingBuckets,
+	}, histogramLabels)
+
+	pingJitter := factory.NewHistogramVec(prometheus.HistogramOpts{
+		Name:      "network_jitter_ms",
+		Help:      "Network latency jitter (standard deviation) in milliseconds",
+		Namespace: opt.namespace,
 		Subsystem: "ping",
 		Buckets:   _pingBuckets,
-	}, []string{"server", "latitude", "longitude"})
+	}, histogramLabels)
+
+	lastDownloadSpeed := factory.NewGaugeVec(prometheus.GaugeOpts{
+		Name:      "last_download_mbps",
+		Help:      "Download speed in Mbps measured by the most recent speed test",
+		Namespace: opt.namespace,
+		Subsystem: "speedtest",
+	}, gaugeLabels)
+
+	lastUploadSpeed := factory.NewGaugeVec(prometheus.GaugeOpts{
+		Name:      "last_upload_mbps",
+		Help:      "Upload speed in Mbps measured by the most recent speed test",
+		Namespace: opt.namespace,
+		Subsystem: "speedtest",
+	}, gaugeLabels)
+
+	lastPingLatency := factory.NewGaugeVec(prometheus.GaugeOpts{
+		Name:      "last_ping_ms",
+		Help:      "Most recently measured ping latency in milliseconds",
+		Namespace: opt.namespace,
+		Subsystem: "ping",
+	}, gaugeLabels)
+
+	lastTestTimestamp := factory.NewGaugeVec(prometheus.GaugeOpts{
+		Name:      "last_test_timestamp_seconds",
+		Help:      "Unix timestamp of the most recent speed test",
+		Namespace: opt.namespace,
+		Subsystem: "speedtest",
+	}, gaugeLabels)
+
+	lastJitter := factory.NewGaugeVec(prometheus.GaugeOpts{
+		Name:      "last_jitter_ms",
+		Help:      "Most recently measured latency jitter in milliseconds",
+		Namespace: opt.namespace,
+		Subsystem: "ping",
+	}, gaugeLabels)
+
+	lastPacketLoss := factory.NewGaugeVec(prometheus.GaugeOpts{
+		Name:      "last_packet_loss_percent",
+		Help:      "Most recently measured packet loss as a percentage of packets sent",
+		Namespace: opt.namespace,
+		Subsystem: "ping",
+	}, gaugeLabels)
+
+	dataBytes := factory.NewCounterVec(prometheus.CounterOpts{
+		Name:      "data_bytes_total",
+		Help:      "Data transferred by the speed tests in bytes, partitioned by direction: download or upload",
+		Namespace: opt.namespace,
+		Subsystem: "speedtest",
+	}, append([]string{LabelDirection}, gaugeLabels...))
+
+	probeValues := factory.NewGaugeVec(prometheus.GaugeOpts{
+		Name:      "value",
+		Help:      "Most recent value of each name reported by an exec probe",
+		Namespace: opt.namespace,
+		Subsystem: "probe",
+	}, []string{LabelServer, "name"})
+
+	// without link, backend or result labels the gauges are exported from the start, as
+	// before any test ran.
+	if gaugeLabels == nil {
+		for _, g := range []*prometheus.GaugeVec{
+			lastDownloadSpeed, lastUploadSpeed, lastPingLatency, lastTestTimestamp, lastJitter, lastPacketLoss,
+		} {
+			g.WithLabelValues()
+		}
+	}
+
+	collectors := []prometheus.Collector{
+		downloadSpeed, uploadSpeed, pingLatency, pingJitter,
+		lastDownloadSpeed, lastUploadSpeed, lastPingLatency, lastTestTimestamp, lastJitter, lastPacketLoss,
+		dataBytes, probeValues,
+	}
+	for i, c := range collectors {
+		if err := opt.registerer.Register(c); err != nil {
+			for _, registered := range collectors[:i] {
+				opt.registerer.Unregister(registered)
+			}
+			return nil, fmt.Errorf("register prometheus metrics: %w", err)
+		}
+	}
+
+	// exemplars are only exposed in the OpenMetrics format.
+	handler := promhttp.InstrumentMetricHandler(prometheus.DefaultRegisterer,
+		promhttp.HandlerFor(prometheus.DefaultGatherer, promhttp.HandlerOpts{EnableOpenMetrics: true}))
 
 	return &PrometheusStorage{
-		handler:       promhttp.Handler(),
-		downloadSpeed: downloadSpeed,
-		uploadSpeed:   uploadSpeed,
-		pingLatency:   pingLatency,
-		logger:        logger,
+		handler:           handler,
+		downloadSpeed:     downloadSpeed,
+		uploadSpeed:       uploadSpeed,
+		pingLatency:       pingLatency,
+		pingJitter:        pingJitter,
+		lastDownloadSpeed: lastDownloadSpeed,
+		lastUploadSpeed:   lastUploadSpeed,
+		lastPingLatency:   lastPingLatency,
+		lastTestTimestamp: lastTestTimestamp,
+		lastJitter:        lastJitter,
+		lastPacketLoss:    lastPacketLoss,
+		dataBytes:         dataBytes,
+		probeValues:       probeValues,
+		labels:            opt.labels,
+		resultLabels:      opt.resultLabels,
+		registerer:        opt.registerer,
+		collectors:        collectors,
+		logger:            logger,
 	}, nil
 }
 
 // StoreNetworkPerformance sends network performance metrics to Prometheus
 func (p *PrometheusStorage) StoreNetworkPerformance(
-	_ context.Context,
-	_ time.Time,
+	ctx context.Context,
+	timestamp time.Time,
 	downloadSpeedMbps, uploadSpeedMbps float64,
 	pingMs int64,
+	jitterMs, packetLossPercent float64,
+	downloadBytes, uploadBytes int64,
 	serverName string,
 	latitude, longitude string,
 ) error {
 	// Set metric values
-	p.downloadSpeed.WithLabelValues(serverName, latitude, longitude).Observe(downloadSpeedMbps)
-	p.uploadSpeed.WithLabelValues(serverName, latitude, longitude).Observe(uploadSpeedMbps)
-	p.pingLatency.WithLabelValues(serverName, latitude, longitude).Observe(float64(pingMs))
+	labels := p.labelValues(ctx, serverName, latitude, longitude)
+	exemplar := exemplarLabels(serverName, timestamp, tracing.TraceID(ctx))
+	observeWithExemplar(p.downloadSpeed.With(labels), downloadSpeedMbps, exemplar)
+	observeWithExemplar(p.uploadSpeed.With(labels), uploadSpeedMbps, exemplar)
+	observeWithExemplar(p.pingLatency.With(labels), float64(pingMs), exemplar)
+
+	gauge := p.gaugeValues(ctx)
+	p.lastDownloadSpeed.WithLabelValues(gauge...).Set(downloadSpeedMbps)
+	p.lastUploadSpeed.WithLabelValues(gauge...).Set(uploadSpeedMbps)
+	p.lastTestTimestamp.WithLabelValues(gauge...).Set(float64(timestamp.Unix()))
+	p.dataBytes.WithLabelValues(append([]string{DirectionDownload}, gauge...)...).Add(float64(downloadBytes))
+	p.dataBytes.WithLabelValues(append([]string{DirectionUpload}, gauge...)...).Add(float64(uploadBytes))
+	p.storeLinkQuality(labels, gauge, pingMs, jitterMs, packetLossPercent)
 	return nil
 }
 
 func (p *PrometheusStorage) StorePingResult(
-	_ context.Context,
-	_ time.Time,
+	ctx context.Context,
+	timestamp time.Time,
 	pingMs int64,
+	jitterMs, packetLossPercent float64,
 	serverName string,
 	latitude, longitude string,
 ) error {
 	// Set metric values with server label
-	p.pingLatency.WithLabelValues(serverName, latitude, longitude).Observe(float64(pingMs))
+	labels := p.labelValues(ctx, serverName, latitude, longitude)
+	observeWithExemplar(p.pingLatency.With(labels), float64(pingMs), exemplarLabels(serverName, timestamp, tracing.TraceID(ctx)))
+	p.storeLinkQuality(labels, p.gaugeValues(ctx), pingMs, jitterMs, packetLossPercent)
 	return nil
 }
+
+// StoreProbeValues sets the probe's gauge of each value, labelled with the probe as the
+// server and the value's name.
+func (p *PrometheusStorage) StoreProbeValues(_ context.Context, _ time.Time, probe string, values map[string]float64) error {
+	for name, value := range values {
+		p.probeValues.WithLabelValues(probe, name).Set(value)
+	}
+	return nil
+}
+
+// storeLinkQuality records the latency, jitter and packet loss shared by ping and speed test results.
+func (p *PrometheusStorage) storeLinkQuality(labels prometheus.Labels, gauge []string, pingMs int64, jitterMs, packetLossPercent float64) {
+	p.pingJitter.With(labels).Observe(jitterMs)
+	p.lastPingLatency.WithLabelValues(gauge...).Set(float64(pingMs))
+	p.lastJitter.WithLabelValues(gauge...).Set(jitterMs)
+	if packetLossPercent >= 0 {
+		p.lastPacketLoss.WithLabelValues(gauge...).Set(packetLossPercent)
+	}
+}
+
+// _maxExemplarRunes is the OpenMetrics limit on the combined length of exemplar label names and values.
+const _maxExemplarRunes = 128
+
+// _exemplarTraceID is the exemplar label Grafana links to a trace by default.
+const _exemplarTraceID = "trace_id"
+
+// exemplarLabels identifies the test run behind an observation, so a dashboard can jump
+// from a spike in a histogram bucket to the exact server and time of the run, and to its
+// trace when traceID is set. The server name is always kept as an exemplar label even
+// when dropped from the histogram labels.
+func exemplarLabels(serverName string, timestamp time.Time, traceID string) prometheus.Labels {
+	ts := timestamp.UTC().Format(time.RFC3339)
+	labels := prometheus.Labels{"timestamp": ts}
+
+	budget := _maxExemplarRunes - len(LabelServer) - len("timestamp") - len(ts)
+	if traceID != "" {
+		labels[_exemplarTraceID] = traceID
+		budget -= len(_exemplarTraceID) + len(traceID)
+	}
+	if server := []rune(serverName); len(server) > budget {
+		serverName = string(server[:budget])
+	}
+	labels[LabelServer] = serverName
+	return labels
+}
+
+// observeWithExemplar records value with exemplar when the observer supports it.
+func observeWithExemplar(o prometheus.Observer, value float64, exemplar prometheus.Labels) {
+	if eo, ok := o.(prometheus.ExemplarObserver); ok {
+		eo.ObserveWithExemplar(value, exemplar)
+		return
+	}
+	o.Observe(value)
+}
+
+// labelValues returns the values for the configured subset of histogram labels and the
+// result labels.
+func (p *PrometheusStorage) labelValues(ctx context.Context, serverName, latitude, longitude string) prometheus.Labels {
+	labels := make(prometheus.Labels, len(p.labels)+len(p.resultLabels))
+	for _, label := range p.labels {
+		switch label {
+		case LabelServer:
+			labels[label] = serverName
+		case LabelLatitude:
+			labels[label] = latitude
+		case LabelLongitude:
+			labels[label] = longitude
+		case LabelAgent:
+			labels[label] = Agent(ctx)
+		case LabelLink:
+			labels[label] = Link(ctx)
+		case LabelBackend:
+			labels[label] = Backend(ctx)
+		}
+	}
+	resultLabels := ResultLabels(ctx)
+	for _, name := range p.resultLabels {
+		labels[name] = resultLabels[name]
+	}
+	return labels
+}
+
+// gaugeValues returns the label values of the most recent value gauges, the link and
+// backend when LabelLink and LabelBackend are attached followed by the result labels.
+func (p *PrometheusStorage) gaugeValues(ctx context.Context) []string {
+	var values []string
+	if slices.Contains(p.labels, LabelLink) {
+		values = append(values, Link(ctx))
+	}
+	if slices.Contains(p.labels, LabelBackend) {
+		values = append(values, Backend(ctx))
+	}
+	resultLabels := ResultLabels(ctx)
+	for _, name := range p.resultLabels {
+		values = append(values, resultLabels[name])
+	}
+	return values
+}
+
+// Ping always succeeds, metrics are held in-process until scraped.
+func (p *PrometheusStorage) Ping(_ context.Context) error {
+	return nil
+}
+
 func (p *PrometheusStorage) MetricsHTTPHandler() http.Handler {
 	return p.handler
 }
 
 // Close terminates the Prometheus storage connection
-func (p *PrometheusStorage) Close(_ context.Context) {}
+// Close unregisters the metrics, so another PrometheusStorage can take their place.
+func (p *PrometheusStorage) Close(_ context.Context) {
+	for _, c := range p.collectors {
+		p.registerer.Unregister(c)
+	}
+}
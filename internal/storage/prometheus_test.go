@@ -0,0 +1,263 @@
+package storage
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"yanm/internal/tracing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrometheusStorage_LabelsAndNamespace(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	reg := prometheus.NewRegistry()
+
+	p, err := NewPrometheusStorage(logger,
+		WithLabels(LabelServer),
+		WithNamespace("home"),
+		WithRegisterer(prometheus.WrapRegistererWith(prometheus.Labels{"site": "cabin"}, reg)),
+	)
+	require.NoError(t, err)
+
+	require.NoError(t, p.StoreNetworkPerformance(context.Background(), time.Unix(1700000000, 0),
+		100, 20, 15, 1.5, -1, 150_000_000, 30_000_000, "Example ISP", "1.0", "2.0"))
+	require.NoError(t, p.StoreNetworkPerformance(context.Background(), time.Unix(1700003600, 0),
+		80, 20, 15, 1.5, -1, 120_000_000, 30_000_000, "Example ISP", "1.0", "2.0"))
+
+	expected := `
+# HELP home_speedtest_last_download_mbps Download speed in Mbps measured by the most recent speed test
+# TYPE home_speedtest_last_download_mbps gauge
+home_speedtest_last_download_mbps{site="cabin"} 80
+# HELP home_speedtest_last_test_timestamp_seconds Unix timestamp of the most recent speed test
+# TYPE home_speedtest_last_test_timestamp_seconds gauge
+home_speedtest_last_test_timestamp_seconds{site="cabin"} 1.7000036e+09
+# HELP home_speedtest_data_bytes_total Data transferred by the speed tests in bytes, partitioned by direction: download or upload
+# TYPE home_speedtest_data_bytes_total counter
+home_speedtest_data_bytes_total{direction="download",site="cabin"} 2.7e+08
+home_speedtest_data_bytes_total{direction="upload",site="cabin"} 6e+07
+`
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(expected),
+		"home_speedtest_last_download_mbps", "home_speedtest_last_test_timestamp_seconds", "home_speedtest_data_bytes_total"))
+
+	// only the server label should be attached to the histograms.
+	count, err := testutil.GatherAndCount(reg, "home_speedtest_network_download_speed_mbps")
+	require.NoError(t, err)
+	require.Equal(t, 1, count)
+}
+
+func TestPrometheusStorage_ProbeValues(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	reg := prometheus.NewRegistry()
+
+	p, err := NewPrometheusStorage(logger, WithRegisterer(reg))
+	require.NoError(t, err)
+
+	// a backend wrapped by the monitor stores them too.
+	var backend MetricsStorage = NewSwitchableStorage(p)
+	require.NoError(t, StoreProbeValues(context.Background(), backend, time.Unix(1700000000, 0), "modem",
+		map[string]float64{"snr_db": 38.5, "power_dbmv": -2}))
+
+	expected := `
+# HELP probe_value Most recent value of each name reported by an exec probe
+# TYPE probe_value gauge
+probe_value{name="power_dbmv",server="modem"} -2
+probe_value{name="snr_db",server="modem"} 38.5
+`
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(expected), "probe_value"))
+}
+
+func TestPrometheusStorage_UnknownLabel(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+
+	_, err := NewPrometheusStorage(logger, WithLabels("city"), WithRegisterer(prometheus.NewRegistry()))
+	require.EqualError(t, err, `unknown prometheus label "city"`)
+}
+
+func TestPrometheusStorage_AgentLabel(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	reg := prometheus.NewRegistry()
+
+	p, err := NewPrometheusStorage(logger, WithLabels(LabelAgent), WithRegisterer(reg))
+	require.NoError(t, err)
+
+	require.NoError(t, p.StorePingResult(WithAgent(context.Background(), "office"), time.Now(), 12, 0, -1, "Example ISP", "1.0", "2.0"))
+	require.NoError(t, p.StorePingResult(WithAgent(context.Background(), "cabin"), time.Now(), 12, 0, -1, "Example ISP", "1.0", "2.0"))
+
+	count, err := testutil.GatherAndCount(reg, "ping_network_latency_ms")
+	require.NoError(t, err)
+	require.Equal(t, 2, count)
+}
+
+func TestPrometheusStorage_LinkLabel(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	reg := prometheus.NewRegistry()
+
+	p, err := NewPrometheusStorage(logger, WithLabels(LabelServer, LabelLink), WithRegisterer(reg))
+	require.NoError(t, err)
+
+	require.NoError(t, p.StoreNetworkPerformance(WithLink(context.Background(), "fiber"), time.Unix(1700000000, 0),
+		500, 100, 8, 1, -1, 700_000_000, 150_000_000, "Example ISP", "1.0", "2.0"))
+	require.NoError(t, p.StoreNetworkPerformance(WithLink(context.Background(), "lte"), time.Unix(1700000000, 0),
+		50, 10, 40, 6, -1, 70_000_000, 15_000_000, "Example ISP", "1.0", "2.0"))
+
+	// the most recent values are kept for each link.
+	expected := `
+# HELP speedtest_last_download_mbps Download speed in Mbps measured by the most recent speed test
+# TYPE speedtest_last_download_mbps gauge
+speedtest_last_download_mbps{link="fiber"} 500
+speedtest_last_download_mbps{link="lte"} 50
+# HELP speedtest_data_bytes_total Data transferred by the speed tests in bytes, partitioned by direction: download or upload
+# TYPE speedtest_data_bytes_total counter
+speedtest_data_bytes_total{direction="download",link="fiber"} 7e+08
+speedtest_data_bytes_total{direction="download",link="lte"} 7e+07
+speedtest_data_bytes_total{direction="upload",link="fiber"} 1.5e+08
+speedtest_data_bytes_total{direction="upload",link="lte"} 1.5e+07
+`
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(expected),
+		"speedtest_last_download_mbps", "speedtest_data_bytes_total"))
+
+	count, err := testutil.GatherAndCount(reg, "speedtest_network_download_speed_mbps")
+	require.NoError(t, err)
+	require.Equal(t, 2, count)
+}
+
+func TestPrometheusStorage_BackendLabel(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	reg := prometheus.NewRegistry()
+
+	p, err := NewPrometheusStorage(logger, WithLabels(LabelServer, LabelLink, LabelBackend), WithRegisterer(reg))
+	require.NoError(t, err)
+
+	ctx := WithLink(context.Background(), "fiber")
+	require.NoError(t, p.StoreNetworkPerformance(WithBackend(ctx, "ookla"), time.Unix(1700000000, 0),
+		500, 100, 8, 1, -1, 700_000_000, 150_000_000, "Example ISP", "1.0", "2.0"))
+	require.NoError(t, p.StoreNetworkPerformance(WithBackend(ctx, "librespeed"), time.Unix(1700000060, 0),
+		450, 90, 9, 1, -1, 600_000_000, 120_000_000, "librespeed.example", "", ""))
+
+	// the results of the backends compared are kept apart.
+	expected := `
+# HELP speedtest_last_download_mbps Download speed in Mbps measured by the most recent speed test
+# TYPE speedtest_last_download_mbps gauge
+speedtest_last_download_mbps{backend="librespeed",link="fiber"} 450
+speedtest_last_download_mbps{backend="ookla",link="fiber"} 500
+`
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(expected), "speedtest_last_download_mbps"))
+
+	count, err := testutil.GatherAndCount(reg, "speedtest_data_bytes_total")
+	require.NoError(t, err)
+	require.Equal(t, 4, count)
+}
+
+func TestPrometheusStorage_ResultLabels(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	reg := prometheus.NewRegistry()
+
+	p, err := NewPrometheusStorage(logger, WithLabels(LabelServer), WithResultLabelNames("host", "site"), WithRegisterer(reg))
+	require.NoError(t, err)
+
+	ctx := WithResultLabels(context.Background(), map[string]string{"host": "mybox", "site": "cabin"})
+	require.NoError(t, p.StoreNetworkPerformance(ctx, time.Unix(1700000000, 0),
+		500, 100, 8, 1, -1, 700_000_000, 150_000_000, "Example ISP", "1.0", "2.0"))
+	// a result without some of the labels, e.g. from an agent, gets empty values.
+	ctx = WithResultLabels(context.Background(), map[string]string{"site": "office", "rack": "2"})
+	require.NoError(t, p.StoreNetworkPerformance(ctx, time.Unix(1700000000, 0),
+		50, 10, 40, 6, -1, 70_000_000, 15_000_000, "Example ISP", "1.0", "2.0"))
+
+	expected := `
+# HELP speedtest_last_download_mbps Download speed in Mbps measured by the most recent speed test
+# TYPE speedtest_last_download_mbps gauge
+speedtest_last_download_mbps{host="",site="office"} 50
+speedtest_last_download_mbps{host="mybox",site="cabin"} 500
+`
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(expected), "speedtest_last_download_mbps"))
+
+	count, err := testutil.GatherAndCount(reg, "speedtest_network_download_speed_mbps")
+	require.NoError(t, err)
+	require.Equal(t, 2, count)
+	count, err = testutil.GatherAndCount(reg, "speedtest_data_bytes_total")
+	require.NoError(t, err)
+	require.Equal(t, 4, count)
+}
+
+func TestPrometheusStorage_Close(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	reg := prometheus.NewRegistry()
+
+	p, err := NewPrometheusStorage(logger, WithRegisterer(reg))
+	require.NoError(t, err)
+
+	// the metrics are registered until the storage is closed.
+	_, err = NewPrometheusStorage(logger, WithRegisterer(reg))
+	require.ErrorContains(t, err, "register prometheus metrics")
+
+	p.Close(context.Background())
+	_, err = NewPrometheusStorage(logger, WithRegisterer(reg))
+	require.NoError(t, err)
+}
+
+func TestPrometheusStorage_Exemplars(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	reg := prometheus.NewRegistry()
+
+	p, err := NewPrometheusStorage(logger, WithLabels(), WithRegisterer(reg))
+	require.NoError(t, err)
+
+	require.NoError(t, p.StorePingResult(context.Background(), time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+		12, 0, -1, "Example ISP", "1.0", "2.0"))
+	require.Equal(t, map[string]string{"server": "Example ISP", "timestamp": "2025-01-02T03:04:05Z"},
+		latencyExemplar(t, reg))
+
+	// a traced run links to its trace.
+	tracer := tracing.NewTracer(tracing.Config{Endpoint: "http://localhost:4318"})
+	tracing.SetTracer(tracer)
+	defer func() {
+		tracing.SetTracer(nil)
+		_ = tracer.Shutdown(context.Background())
+	}()
+	ctx, _ := tracing.Start(context.Background(), "speedtest")
+	require.NoError(t, p.StorePingResult(ctx, time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+		12, 0, -1, "Example ISP", "1.0", "2.0"))
+	require.Equal(t, map[string]string{"server": "Example ISP", "timestamp": "2025-01-02T03:04:05Z", "trace_id": tracing.TraceID(ctx)},
+		latencyExemplar(t, reg))
+}
+
+// latencyExemplar returns the labels of the latest exemplar of the latency histogram.
+func latencyExemplar(t *testing.T, reg *prometheus.Registry) map[string]string {
+	families, err := reg.Gather()
+	require.NoError(t, err)
+
+	var exemplarLabels map[string]string
+	for _, family := range families {
+		if family.GetName() != "ping_network_latency_ms" {
+			continue
+		}
+		for _, bucket := range family.GetMetric()[0].GetHistogram().GetBucket() {
+			if e := bucket.GetExemplar(); e != nil {
+				exemplarLabels = map[string]string{}
+				for _, l := range e.GetLabel() {
+					exemplarLabels[l.GetName()] = l.GetValue()
+				}
+			}
+		}
+	}
+	return exemplarLabels
+}
+
+func TestExemplarLabels_Truncation(t *testing.T) {
+	for _, traceID := range []string{"", strings.Repeat("a", 32)} {
+		labels := exemplarLabels(strings.Repeat("x", 200), time.Unix(0, 0), traceID)
+
+		var runes int
+		for name, value := range labels {
+			runes += len(name) + len(value)
+		}
+		require.Equal(t, _maxExemplarRunes, runes)
+	}
+}
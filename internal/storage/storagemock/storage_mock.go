@@ -62,30 +62,44 @@ func (mr *MockMetricsStorageMockRecorder) MetricsHTTPHandler() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MetricsHTTPHandler", reflect.TypeOf((*MockMetricsStorage)(nil).MetricsHTTPHandler))
 }
 
+// Ping mocks base method.
+func (m *MockMetricsStorage) Ping(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Ping", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Ping indicates an expected call of Ping.
+func (mr *MockMetricsStorageMockRecorder) Ping(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Ping", reflect.TypeOf((*MockMetricsStorage)(nil).Ping), ctx)
+}
+
 // StoreNetworkPerformance mocks base method.
-func (m *MockMetricsStorage) StoreNetworkPerformance(ctx context.Context, timestamp time.Time, downloadSpeedMbps, uploadSpeedMbps float64, pingMs int64, serverName, lat, lon string) error {
+func (m *MockMetricsStorage) StoreNetworkPerformance(ctx context.Context, timestamp time.Time, downloadSpeedMbps, uploadSpeedMbps float64, pingMs int64, jitterMs, packetLossPercent float64, downloadBytes, uploadBytes int64, serverName, lat, lon string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "StoreNetworkPerformance", ctx, timestamp, downloadSpeedMbps, uploadSpeedMbps, pingMs, serverName, lat, lon)
+	ret := m.ctrl.Call(m, "StoreNetworkPerformance", ctx, timestamp, downloadSpeedMbps, uploadSpeedMbps, pingMs, jitterMs, packetLossPercent, downloadBytes, uploadBytes, serverName, lat, lon)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // StoreNetworkPerformance indicates an expected call of StoreNetworkPerformance.
-func (mr *MockMetricsStorageMockRecorder) StoreNetworkPerformance(ctx, timestamp, downloadSpeedMbps, uploadSpeedMbps, pingMs, serverName, lat, lon interface{}) *gomock.Call {
+func (mr *MockMetricsStorageMockRecorder) StoreNetworkPerformance(ctx, timestamp, downloadSpeedMbps, uploadSpeedMbps, pingMs, jitterMs, packetLossPercent, downloadBytes, uploadBytes, serverName, lat, lon interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StoreNetworkPerformance", reflect.TypeOf((*MockMetricsStorage)(nil).StoreNetworkPerformance), ctx, timestamp, downloadSpeedMbps, uploadSpeedMbps, pingMs, serverName, lat, lon)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StoreNetworkPerformance", reflect.TypeOf((*MockMetricsStorage)(nil).StoreNetworkPerformance), ctx, timestamp, downloadSpeedMbps, uploadSpeedMbps, pingMs, jitterMs, packetLossPercent, downloadBytes, uploadBytes, serverName, lat, lon)
 }
 
 // StorePingResult mocks base method.
-func (m *MockMetricsStorage) StorePingResult(ctx context.Context, timestamp time.Time, pingMs int64, serverName, lat, lon string) error {
+func (m *MockMetricsStorage) StorePingResult(ctx context.Context, timestamp time.Time, pingMs int64, jitterMs, packetLossPercent float64, serverName, lat, lon string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "StorePingResult", ctx, timestamp, pingMs, serverName, lat, lon)
+	ret := m.ctrl.Call(m, "StorePingResult", ctx, timestamp, pingMs, jitterMs, packetLossPercent, serverName, lat, lon)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // StorePingResult indicates an expected call of StorePingResult.
-func (mr *MockMetricsStorageMockRecorder) StorePingResult(ctx, timestamp, pingMs, serverName, lat, lon interface{}) *gomock.Call {
+func (mr *MockMetricsStorageMockRecorder) StorePingResult(ctx, timestamp, pingMs, jitterMs, packetLossPercent, serverName, lat, lon interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StorePingResult", reflect.TypeOf((*MockMetricsStorage)(nil).StorePingResult), ctx, timestamp, pingMs, serverName, lat, lon)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StorePingResult", reflect.TypeOf((*MockMetricsStorage)(nil).StorePingResult), ctx, timestamp, pingMs, jitterMs, packetLossPercent, serverName, lat, lon)
 }
@@ -0,0 +1,107 @@
+package storage
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// SwitchableStorage passes every call to a backend that can be replaced while results are
+// being stored, e.g. when the metrics engine is changed by a configuration reload.
+type SwitchableStorage struct {
+	mu      sync.RWMutex
+	backend MetricsStorage
+}
+
+// Verify SwitchableStorage implements MetricsStorage interface
+var (
+	_ MetricsStorage    = (*SwitchableStorage)(nil)
+	_ PingSummaryStorer = (*SwitchableStorage)(nil)
+	_ QueueDepther      = (*SwitchableStorage)(nil)
+	_ ProbeValueStorer  = (*SwitchableStorage)(nil)
+)
+
+// NewSwitchableStorage creates a SwitchableStorage storing to backend until Switch is called.
+func NewSwitchableStorage(backend MetricsStorage) *SwitchableStorage {
+	return &SwitchableStorage{backend: backend}
+}
+
+// Backend returns the backend results are stored to.
+func (s *SwitchableStorage) Backend() MetricsStorage {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.backend
+}
+
+// Switch stores the next results to backend and returns the previous one, which the
+// caller closes once it is no longer needed.
+func (s *SwitchableStorage) Switch(backend MetricsStorage) MetricsStorage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	prev := s.backend
+	s.backend = backend
+	return prev
+}
+
+// StoreNetworkPerformance stores the result in the current backend.
+func (s *SwitchableStorage) StoreNetworkPerformance(
+	ctx context.Context,
+	timestamp time.Time,
+	downloadSpeedMbps, uploadSpeedMbps float64,
+	pingMs int64,
+	jitterMs, packetLossPercent float64,
+	downloadBytes, uploadBytes int64,
+	serverName string,
+	lat, lon string,
+) error {
+	return s.Backend().StoreNetworkPerformance(
+		ctx, timestamp, downloadSpeedMbps, uploadSpeedMbps, pingMs, jitterMs, packetLossPercent, downloadBytes, uploadBytes, serverName, lat, lon)
+}
+
+// StorePingResult stores the result in the current backend.
+func (s *SwitchableStorage) StorePingResult(
+	ctx context.Context,
+	timestamp time.Time,
+	pingMs int64,
+	jitterMs, packetLossPercent float64,
+	serverName string,
+	lat, lon string,
+) error {
+	return s.Backend().StorePingResult(ctx, timestamp, pingMs, jitterMs, packetLossPercent, serverName, lat, lon)
+}
+
+// StorePingSummary stores the summary in the current backend, natively if supported.
+func (s *SwitchableStorage) StorePingSummary(ctx context.Context, summary PingSummary) error {
+	return storePingSummary(ctx, s.Backend(), summary)
+}
+
+// StoreProbeValues stores the values in the current backend, if it supports them.
+func (s *SwitchableStorage) StoreProbeValues(ctx context.Context, timestamp time.Time, probe string, values map[string]float64) error {
+	return StoreProbeValues(ctx, s.Backend(), timestamp, probe, values)
+}
+
+// QueueDepth returns the number of writes the current backend has pending, if it buffers them.
+func (s *SwitchableStorage) QueueDepth() int {
+	if q, ok := s.Backend().(QueueDepther); ok {
+		return q.QueueDepth()
+	}
+	return 0
+}
+
+// Ping checks the current backend.
+func (s *SwitchableStorage) Ping(ctx context.Context) error {
+	return s.Backend().Ping(ctx)
+}
+
+// Close closes the current backend.
+func (s *SwitchableStorage) Close(ctx context.Context) {
+	s.Backend().Close(ctx)
+}
+
+// MetricsHTTPHandler serves the metrics of the backend current at the time of the request.
+func (s *SwitchableStorage) MetricsHTTPHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.Backend().MetricsHTTPHandler().ServeHTTP(w, r)
+	})
+}
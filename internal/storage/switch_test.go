@@ -0,0 +1,42 @@
+package storage
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"yanm/internal/storage/storagemock"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSwitchableStorage(t *testing.T) {
+	ctx := context.Background()
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelError}))
+	mockCtrl := gomock.NewController(t)
+	first := storagemock.NewMockMetricsStorage(mockCtrl)
+	second := &summaryRecorder{NoOpStorage: NewNoOpStorage(logger)}
+
+	s := NewSwitchableStorage(first)
+	first.EXPECT().StorePingResult(gomock.Any(), gomock.Any(), int64(12), 0.5, -1.0, "server", "1", "2").Return(nil)
+	require.NoError(t, s.StorePingResult(ctx, time.Now(), 12, 0.5, -1, "server", "1", "2"))
+	// backends that don't store summaries natively receive the average.
+	first.EXPECT().StorePingResult(gomock.Any(), gomock.Any(), int64(15), 1.0, -1.0, "server", "", "").Return(nil)
+	require.NoError(t, s.StorePingSummary(ctx, PingSummary{ServerName: "server", AvgMs: 15, JitterMs: 1, PacketLossPercent: -1}))
+
+	assert.Same(t, first, s.Switch(second))
+	assert.Same(t, second, s.Backend())
+	require.NoError(t, s.StorePingSummary(ctx, PingSummary{ServerName: "server", AvgMs: 15}))
+	assert.Len(t, second.summaries, 1)
+
+	// the metrics handler follows the switch.
+	rr := httptest.NewRecorder()
+	s.MetricsHTTPHandler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, http.StatusNotImplemented, rr.Code)
+}
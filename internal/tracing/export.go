@@ -0,0 +1,265 @@
+package tracing
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	_otlpTracesPath    = "/v1/traces"
+	_otlpQueueSize     = 2048
+	_otlpFlushInterval = 5 * time.Second
+	_otlpTimeout       = 10 * time.Second
+
+	_defaultServiceName = "yanm"
+)
+
+// OTLP JSON span status codes.
+const (
+	_statusOK    = 1
+	_statusError = 2
+)
+
+// Config exports traces to an OpenTelemetry collector over OTLP/HTTP.
+type Config struct {
+	// Endpoint is the collector's OTLP/HTTP base URL, e.g. http://collector:4318.
+	Endpoint string
+	// Headers are sent with every export, e.g. for authentication.
+	Headers map[string]string
+	// ServiceName is the service.name resource attribute, yanm by default.
+	ServiceName string
+}
+
+// The types below follow the OTLP JSON encoding of ExportTraceServiceRequest.
+
+type otlpValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	BoolValue   *bool    `json:"boolValue,omitempty"`
+	IntValue    *string  `json:"intValue,omitempty"` // int64 values are strings in OTLP JSON.
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+}
+
+type otlpKeyValue struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+type otlpSpan struct {
+	TraceID           string         `json:"traceId"`
+	SpanID            string         `json:"spanId"`
+	ParentSpanID      string         `json:"parentSpanId,omitempty"`
+	Name              string         `json:"name"`
+	Kind              int            `json:"kind"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	Status            otlpStatus     `json:"status"`
+}
+
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+// Tracer batches ended spans and posts them to the collector in the background.
+type Tracer struct {
+	client      *http.Client
+	url         string
+	headers     map[string]string
+	serviceName string
+
+	mu    sync.Mutex
+	queue []otlpSpan
+	// exportMu serializes exports, so a flush waits for one in progress.
+	exportMu sync.Mutex
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewTracer creates a Tracer exporting every 5 seconds until Shutdown.
+func NewTracer(cfg Config) *Tracer {
+	url := strings.TrimSuffix(cfg.Endpoint, "/")
+	if !strings.HasSuffix(url, _otlpTracesPath) {
+		url += _otlpTracesPath
+	}
+	t := &Tracer{
+		client:      &http.Client{Timeout: _otlpTimeout},
+		url:         url,
+		headers:     cfg.Headers,
+		serviceName: cmp.Or(cfg.ServiceName, _defaultServiceName),
+		stop:        make(chan struct{}),
+		done:        make(chan struct{}),
+	}
+	go t.run()
+	return t
+}
+
+func (t *Tracer) run() {
+	defer close(t.done)
+
+	ticker := time.NewTicker(_otlpFlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-t.stop:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), _otlpTimeout)
+			t.report(t.Flush(ctx))
+			cancel()
+		}
+	}
+}
+
+// enqueue queues span for export, dropping it rather than growing without bound when
+// the collector is unreachable.
+func (t *Tracer) enqueue(span otlpSpan) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.queue) < _otlpQueueSize {
+		t.queue = append(t.queue, span)
+	}
+}
+
+// Flush exports the spans ended so far.
+func (t *Tracer) Flush(ctx context.Context) error {
+	t.exportMu.Lock()
+	defer t.exportMu.Unlock()
+
+	t.mu.Lock()
+	spans := t.queue
+	t.queue = nil
+	t.mu.Unlock()
+
+	if len(spans) == 0 {
+		return nil
+	}
+	if err := t.export(ctx, spans); err != nil {
+		return fmt.Errorf("export %d spans: %w", len(spans), err)
+	}
+	return nil
+}
+
+// Shutdown stops the background exports and exports the spans still queued.
+func (t *Tracer) Shutdown(ctx context.Context) error {
+	close(t.stop)
+	<-t.done
+	return t.Flush(ctx)
+}
+
+// report writes a failed export to stderr, logging it would include the trace ID of
+// the failure being exported.
+func (t *Tracer) report(err error) {
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to export traces over OTLP: %v\n", err)
+	}
+}
+
+func (t *Tracer) export(ctx context.Context, spans []otlpSpan) error {
+	body, err := json.Marshal(otlpRequest{ResourceSpans: []otlpResourceSpans{{
+		Resource: otlpResource{Attributes: []otlpKeyValue{
+			{Key: "service.name", Value: stringValue(t.serviceName)},
+		}},
+		ScopeSpans: []otlpScopeSpans{{Scope: otlpScope{Name: "yanm"}, Spans: spans}},
+	}}})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range t.headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("collector responded %s", resp.Status)
+	}
+	return nil
+}
+
+// record converts the span, ended at end, to its OTLP form. s.mu must be held.
+func (s *Span) record(end time.Time) otlpSpan {
+	span := otlpSpan{
+		TraceID:           s.traceID,
+		SpanID:            s.spanID,
+		ParentSpanID:      s.parentID,
+		Name:              s.name,
+		Kind:              1, // SPAN_KIND_INTERNAL
+		StartTimeUnixNano: strconv.FormatInt(s.start.UnixNano(), 10),
+		EndTimeUnixNano:   strconv.FormatInt(end.UnixNano(), 10),
+		Status:            otlpStatus{Code: _statusOK},
+	}
+	for _, a := range s.attrs {
+		span.Attributes = append(span.Attributes, otlpKeyValue{Key: a.Key, Value: otlpValueOf(a.Value)})
+	}
+	if s.err != nil {
+		span.Status = otlpStatus{Code: _statusError, Message: s.err.Error()}
+	}
+	return span
+}
+
+func otlpValueOf(v slog.Value) otlpValue {
+	switch v = v.Resolve(); v.Kind() {
+	case slog.KindBool:
+		b := v.Bool()
+		return otlpValue{BoolValue: &b}
+	case slog.KindInt64:
+		i := strconv.FormatInt(v.Int64(), 10)
+		return otlpValue{IntValue: &i}
+	case slog.KindUint64:
+		i := strconv.FormatUint(v.Uint64(), 10)
+		return otlpValue{IntValue: &i}
+	case slog.KindFloat64:
+		f := v.Float64()
+		return otlpValue{DoubleValue: &f}
+	default:
+		return stringValue(v.String())
+	}
+}
+
+func stringValue(s string) otlpValue {
+	return otlpValue{StringValue: &s}
+}
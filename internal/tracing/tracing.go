@@ -0,0 +1,125 @@
+// Package tracing records speed test runs as OpenTelemetry traces, one span per phase,
+// and exports them to a collector over OTLP/HTTP so slow phases can be found.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// _tracer records the spans started by Start, nil when tracing is off.
+var _tracer atomic.Pointer[Tracer]
+
+// SetTracer makes t record the spans started from now on, nil turns tracing off.
+// It returns the tracer it replaced.
+func SetTracer(t *Tracer) *Tracer {
+	return _tracer.Swap(t)
+}
+
+type spanKey struct{}
+
+// Span is a single timed operation of a trace. The methods of a nil Span do nothing,
+// which is what Start returns when tracing is off.
+type Span struct {
+	tracer   *Tracer
+	traceID  string
+	spanID   string
+	parentID string
+	name     string
+	start    time.Time
+
+	mu    sync.Mutex
+	attrs []slog.Attr
+	err   error
+	ended bool
+}
+
+// Start starts a span named name, a child of the span ctx carries if any, and returns a
+// copy of ctx carrying it. The span must be ended with End.
+func Start(ctx context.Context, name string, attrs ...slog.Attr) (context.Context, *Span) {
+	t := _tracer.Load()
+	if t == nil {
+		return ctx, nil
+	}
+
+	span := &Span{
+		tracer: t,
+		spanID: newID(8),
+		name:   name,
+		start:  time.Now(),
+		attrs:  attrs,
+	}
+	if parent := FromContext(ctx); parent != nil {
+		span.traceID, span.parentID = parent.traceID, parent.spanID
+	} else {
+		span.traceID = newID(16)
+	}
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+// FromContext returns the span ctx carries, nil if none.
+func FromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanKey{}).(*Span)
+	return span
+}
+
+// TraceID returns the ID of the trace of the span ctx carries, empty if none.
+func TraceID(ctx context.Context) string {
+	return FromContext(ctx).TraceID()
+}
+
+// TraceID returns the hex ID of the span's trace.
+func (s *Span) TraceID() string {
+	if s == nil {
+		return ""
+	}
+	return s.traceID
+}
+
+// SetAttributes adds attrs to the span, e.g. the result of the operation.
+func (s *Span) SetAttributes(attrs ...slog.Attr) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.attrs = append(s.attrs, attrs...)
+}
+
+// SetError marks the operation as failed with err.
+func (s *Span) SetError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.err = err
+}
+
+// End records the span's end and queues it for export. Only the first call counts.
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	end := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ended {
+		return
+	}
+	s.ended = true
+	s.tracer.enqueue(s.record(end))
+}
+
+// newID returns n random bytes as hex, the encoding of trace and span IDs in OTLP JSON.
+func newID(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b) // never returns an error
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,71 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStart(t *testing.T) {
+	requests := make(chan otlpRequest, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/traces", r.URL.Path)
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		var req otlpRequest
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		requests <- req
+	}))
+	defer srv.Close()
+
+	tracer := NewTracer(Config{Endpoint: srv.URL, Headers: map[string]string{"Authorization": "Bearer token"}})
+	SetTracer(tracer)
+	defer SetTracer(nil)
+
+	ctx, run := Start(context.Background(), "speedtest", slog.String("runID", "abc"))
+	require.NotNil(t, run)
+	assert.Regexp(t, `^[0-9a-f]{32}$`, TraceID(ctx))
+
+	_, download := Start(ctx, "download")
+	download.SetAttributes(slog.Float64("download_mbps", 95.5))
+	download.SetError(errors.New("connection reset"))
+	download.End()
+	run.End()
+	run.End()
+
+	require.NoError(t, tracer.Shutdown(context.Background()))
+	req := <-requests
+	require.Len(t, req.ResourceSpans, 1)
+	assert.Equal(t, []otlpKeyValue{{Key: "service.name", Value: stringValue("yanm")}}, req.ResourceSpans[0].Resource.Attributes)
+
+	spans := req.ResourceSpans[0].ScopeSpans[0].Spans
+	require.Len(t, spans, 2)
+	assert.Equal(t, "download", spans[0].Name)
+	assert.Equal(t, run.traceID, spans[0].TraceID)
+	assert.Equal(t, run.spanID, spans[0].ParentSpanID)
+	assert.Equal(t, "download_mbps", spans[0].Attributes[0].Key)
+	assert.Equal(t, 95.5, *spans[0].Attributes[0].Value.DoubleValue)
+	assert.Equal(t, otlpStatus{Code: _statusError, Message: "connection reset"}, spans[0].Status)
+
+	assert.Equal(t, "speedtest", spans[1].Name)
+	assert.Empty(t, spans[1].ParentSpanID)
+	assert.Equal(t, []otlpKeyValue{{Key: "runID", Value: stringValue("abc")}}, spans[1].Attributes)
+	assert.Equal(t, otlpStatus{Code: _statusOK}, spans[1].Status)
+}
+
+func TestStart_Disabled(t *testing.T) {
+	ctx, span := Start(context.Background(), "speedtest")
+	assert.Nil(t, span)
+	assert.Empty(t, TraceID(ctx))
+
+	// a nil span can be used as any other.
+	span.SetAttributes(slog.Int("ping_ms", 12))
+	span.SetError(errors.New("failed"))
+	span.End()
+}
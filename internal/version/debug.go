@@ -0,0 +1,48 @@
+package version
+
+import (
+	"html/template"
+	"net/http"
+	"time"
+
+	"yanm/internal/debughttp/debughandler"
+)
+
+const _versionPage = `
+<h1>Version</h1>
+<table>
+	<tr><th>Version</th><td>{{.Version}}</td></tr>
+	<tr><th>Commit</th><td>{{or .Commit "unknown"}}</td></tr>
+	<tr><th>Build Date</th><td>{{or .Date "unknown"}}</td></tr>
+	<tr><th>Go Version</th><td>{{.GoVersion}}</td></tr>
+	<tr><th>Uptime</th><td>{{.Uptime}}</td></tr>
+	<tr><th>Config File</th><td>{{.ConfigFile}}</td></tr>
+</table>
+`
+
+var _versionTmpl = template.Must(template.New("version").Parse(_versionPage))
+
+// status is the data behind both views of the version page.
+type status struct {
+	Info
+	Started       time.Time     `json:"started"`
+	Uptime        time.Duration `json:"-"`
+	UptimeSeconds float64       `json:"uptime_seconds"`
+	ConfigFile    string        `json:"config_file"`
+}
+
+// NewDebugPageProvider creates a debug page reporting the build information, the uptime
+// since started and the configuration file in use.
+// The handler returned is the raw content-producing handler.
+func NewDebugPageProvider(started time.Time, configFile string) http.Handler {
+	return debughandler.NewNegotiatingHandler(func(*http.Request) (any, error) {
+		uptime := time.Since(started)
+		return status{
+			Info:          Get(),
+			Started:       started,
+			Uptime:        uptime.Round(time.Second),
+			UptimeSeconds: uptime.Seconds(),
+			ConfigFile:    configFile,
+		}, nil
+	}, _versionTmpl)
+}
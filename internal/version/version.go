@@ -0,0 +1,65 @@
+// Package version reports the build information of the running binary.
+package version
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+)
+
+// Set at build time, e.g.
+//
+//	go build -ldflags "-X yanm/internal/version.Version=v1.2.3 -X yanm/internal/version.Commit=$(git rev-parse HEAD) -X yanm/internal/version.Date=$(date -u +%Y-%m-%dT%H:%M:%SZ)" ./cmd
+//
+// Commit and Date fall back to the VCS information Go embeds in the binary.
+var (
+	Version = "dev"
+	Commit  = ""
+	Date    = ""
+)
+
+// Info describes the build of the running binary.
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit,omitempty"`
+	Date      string `json:"date,omitempty"`
+	GoVersion string `json:"go_version"`
+}
+
+// Get returns the build information of the running binary.
+func Get() Info {
+	info := Info{
+		Version:   Version,
+		Commit:    Commit,
+		Date:      Date,
+		GoVersion: runtime.Version(),
+	}
+
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range bi.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				if info.Commit == "" {
+					info.Commit = setting.Value
+				}
+			case "vcs.time":
+				if info.Date == "" {
+					info.Date = setting.Value
+				}
+			}
+		}
+	}
+	return info
+}
+
+// String describes the build on one line, e.g. "v1.2.3 (commit abc123, built 2025-01-02T03:04:05Z, go1.24.1)".
+func (i Info) String() string {
+	return fmt.Sprintf("%s (commit %s, built %s, %s)", i.Version, or(i.Commit, "unknown"), or(i.Date, "unknown"), i.GoVersion)
+}
+
+func or(s, fallback string) string {
+	if s == "" {
+		return fallback
+	}
+	return s
+}
@@ -0,0 +1,54 @@
+package version
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGet(t *testing.T) {
+	defer func(version, commit, date string) { Version, Commit, Date = version, commit, date }(Version, Commit, Date)
+	Version, Commit, Date = "v1.2.3", "abc123", "2025-01-02T03:04:05Z"
+
+	assert.Equal(t, Info{
+		Version:   "v1.2.3",
+		Commit:    "abc123",
+		Date:      "2025-01-02T03:04:05Z",
+		GoVersion: runtime.Version(),
+	}, Get())
+}
+
+func TestInfo_String(t *testing.T) {
+	assert.Equal(t, "v1.2.3 (commit abc123, built 2025-01-02T03:04:05Z, go1.24.1)",
+		Info{Version: "v1.2.3", Commit: "abc123", Date: "2025-01-02T03:04:05Z", GoVersion: "go1.24.1"}.String())
+	assert.Equal(t, "dev (commit unknown, built unknown, go1.24.1)", Info{Version: "dev", GoVersion: "go1.24.1"}.String())
+}
+
+func TestDebugPage(t *testing.T) {
+	page := NewDebugPageProvider(time.Now().Add(-time.Hour), "/etc/yanm/config.yml")
+
+	req := httptest.NewRequest(http.MethodGet, "/debug/version/", nil)
+	rr := httptest.NewRecorder()
+	page.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), "<td>/etc/yanm/config.yml</td>")
+	assert.Contains(t, rr.Body.String(), "<td>1h0m0s</td>")
+
+	req.Header.Set("Accept", "application/json")
+	rr = httptest.NewRecorder()
+	page.ServeHTTP(rr, req)
+	assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+
+	var got map[string]any
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &got))
+	assert.Equal(t, Version, got["version"])
+	assert.Equal(t, runtime.Version(), got["go_version"])
+	assert.Equal(t, "/etc/yanm/config.yml", got["config_file"])
+	assert.InDelta(t, time.Hour.Seconds(), got["uptime_seconds"], 5)
+}
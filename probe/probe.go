@@ -0,0 +1,96 @@
+// Package probe lets Go packages add their own checks to YANM. A probe registered with
+// Register, usually from the init function of a package blank imported into the yanm
+// binary (see cmd/probes.go), is run by the monitor like a configured target, its
+// results stored under its name, and listed on the debug server's /debug/probes page.
+package probe
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Result is what a single run of a probe measured.
+type Result struct {
+	// Latency is stored like the latency of a target, and compared to its threshold.
+	Latency time.Duration
+	Jitter  time.Duration
+	// PacketLossPercent is in the range 0-100, or negative when not measured.
+	PacketLossPercent float64
+	// Values are stored by name alongside the latency, e.g. {"snr_db": 38.5}.
+	Values map[string]float64
+}
+
+// Probe is a check run by the monitor on its own interval.
+type Probe interface {
+	// Name identifies the probe in stored results, logs and debug pages. It must be
+	// unique among the probes and targets.
+	Name() string
+
+	// Run checks once. A returned error fails the check, which is logged and counted.
+	Run(ctx context.Context) (Result, error)
+
+	// Debug returns the handler serving the probe's own debug page under
+	// /debug/probes/<name>/, or nil for none.
+	Debug() http.Handler
+}
+
+// Scheduler is implemented by probes choosing how often they run, instead of the
+// ping_test interval.
+type Scheduler interface {
+	Interval() time.Duration
+}
+
+// Thresholder is implemented by probes whose latency triggers a speed test above a
+// threshold. Probes that do not implement it never trigger one.
+type Thresholder interface {
+	Threshold() time.Duration
+}
+
+var (
+	_mu     sync.RWMutex
+	_probes = make(map[string]Probe)
+)
+
+// Register makes p run by the monitor. Like database/sql.Register, it panics if p is
+// nil, has no name or a probe of the same name was registered.
+func Register(p Probe) {
+	if p == nil {
+		panic("probe: Register probe is nil")
+	}
+	name := p.Name()
+	if name == "" {
+		panic("probe: Register probe has no name")
+	}
+
+	_mu.Lock()
+	defer _mu.Unlock()
+	if _, dup := _probes[name]; dup {
+		panic(fmt.Sprintf("probe: Register called twice for probe %q", name))
+	}
+	_probes[name] = p
+}
+
+// Probes returns the registered probes, by name.
+func Probes() []Probe {
+	_mu.RLock()
+	defer _mu.RUnlock()
+
+	probes := make([]Probe, 0, len(_probes))
+	for _, p := range _probes {
+		probes = append(probes, p)
+	}
+	slices.SortFunc(probes, func(a, b Probe) int { return cmp.Compare(a.Name(), b.Name()) })
+	return probes
+}
+
+// unregisterAll forgets every probe, for tests.
+func unregisterAll() {
+	_mu.Lock()
+	defer _mu.Unlock()
+	clear(_probes)
+}
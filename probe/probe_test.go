@@ -0,0 +1,27 @@
+package probe
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type namedProbe string
+
+func (p namedProbe) Name() string                        { return string(p) }
+func (p namedProbe) Run(context.Context) (Result, error) { return Result{}, nil }
+func (p namedProbe) Debug() http.Handler                 { return nil }
+
+func TestRegister(t *testing.T) {
+	defer unregisterAll()
+
+	Register(namedProbe("modem"))
+	Register(namedProbe("battery"))
+	assert.Equal(t, []Probe{namedProbe("battery"), namedProbe("modem")}, Probes())
+
+	assert.PanicsWithValue(t, `probe: Register called twice for probe "modem"`, func() { Register(namedProbe("modem")) })
+	assert.PanicsWithValue(t, "probe: Register probe has no name", func() { Register(namedProbe("")) })
+	assert.PanicsWithValue(t, "probe: Register probe is nil", func() { Register(nil) })
+}